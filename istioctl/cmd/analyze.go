@@ -29,10 +29,12 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"istio.io/istio/istioctl/pkg/util/configdump"
 	"istio.io/istio/istioctl/pkg/util/formatting"
 	"istio.io/istio/istioctl/pkg/util/handlers"
 	"istio.io/istio/pkg/config/analysis"
 	"istio.io/istio/pkg/config/analysis/analyzers"
+	"istio.io/istio/pkg/config/analysis/analyzers/virtualservice"
 	"istio.io/istio/pkg/config/analysis/diag"
 	"istio.io/istio/pkg/config/analysis/local"
 	"istio.io/istio/pkg/config/analysis/msg"
@@ -62,6 +64,7 @@ func (f FileParseError) Error() string {
 
 var (
 	listAnalyzers     bool
+	explain           string
 	useKube           bool
 	failureThreshold  = formatting.MessageThreshold{Level: diag.Error} // messages at least this level will generate an error exit code
 	outputThreshold   = formatting.MessageThreshold{Level: diag.Info}  // messages at least this level will be included in the output
@@ -71,9 +74,12 @@ var (
 	selectedNamespace string
 	allNamespaces     bool
 	suppress          []string
+	suppressionPath   string
 	analysisTimeout   time.Duration
 	recursive         bool
 	ignoreUnknown     bool
+	remoteContexts    []string
+	configDumpFiles   []string
 
 	fileExtensions = []string{".json", ".yaml", ".yml"}
 )
@@ -102,9 +108,33 @@ func Analyze() *cobra.Command {
   # and suppress MisplacedAnnotation on deployment foobar in namespace default.
   istioctl analyze -S "IST0103=Pod *.testing" -S "IST0107=Deployment foobar.default"
 
+  # Analyze the current live cluster, looking up the subsets referenced by the VirtualServices in the
+  # DestinationRules of the other clusters of the mesh too
+  istioctl analyze --remote-contexts cluster2,cluster3
+
+  # Analyze the current live cluster, checking the routes of the VirtualServices are in the config dump of a proxy
+  istioctl proxy-config all productpage-v1-7f44c4d57c-ncdrs -o json > productpage-config-dump.json
+  istioctl analyze --config-dump productpage-config-dump.json
+
+  # Analyze the current live cluster, applying the suppressions and severity overrides of a file
+  istioctl analyze --suppression-file analysis-suppressions.yaml
+
   # List available analyzers
-  istioctl analyze -L`,
+  istioctl analyze -L
+
+  # Explain the causes and remediation of a message code
+  istioctl analyze --explain IST0101
+
+  # Dump the catalog of all message codes, to map analysis findings to runbooks
+  istioctl analyze --explain all -o json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// The global --output flag overrides -o. Unlike -o json, which prints the bare array of messages, it
+			// prints json as a versioned document, as for the other commands.
+			jsonDocument := false
+			if cmd.Flags().Changed(FlagOutput) {
+				msgOutputFormat = globalOutputFormat
+				jsonDocument = strings.ToLower(msgOutputFormat) == formatting.JSONFormat
+			}
 			msgOutputFormat = strings.ToLower(msgOutputFormat)
 			_, ok := formatting.MsgOutputFormats[msgOutputFormat]
 			if !ok {
@@ -118,6 +148,15 @@ func Analyze() *cobra.Command {
 				return nil
 			}
 
+			if explain != "" {
+				out, err := explainMessages(explain, msgOutputFormat)
+				if err != nil {
+					return err
+				}
+				fmt.Fprint(cmd.OutOrStdout(), out)
+				return nil
+			}
+
 			readers, err := gatherFiles(cmd, args)
 			if err != nil {
 				return err
@@ -146,7 +185,27 @@ func Analyze() *cobra.Command {
 				selectedNamespace = ""
 			}
 
-			sa := local.NewIstiodAnalyzer(analyzers.AllCombined(),
+			combined := analyzers.AllCombined()
+			if len(remoteContexts) > 0 {
+				if !useKube {
+					return fmt.Errorf("--remote-contexts requires a live cluster, it cannot be used with --use-kube=false")
+				}
+				var remoteDRs []*resource.Instance
+				for _, remoteContext := range remoteContexts {
+					client, err := kube.NewExtendedClient(kube.BuildClientCmd(kubeconfig, remoteContext), "")
+					if err != nil {
+						return fmt.Errorf("failed to create a client for context %s: %v", remoteContext, err)
+					}
+					drs, err := remoteDestinationRules(client)
+					if err != nil {
+						return fmt.Errorf("failed to list the DestinationRules of context %s: %v", remoteContext, err)
+					}
+					remoteDRs = append(remoteDRs, drs...)
+				}
+				combined = analysis.Combine("all", withRemoteDestinationRules(analyzers.All(), remoteDRs)...)
+			}
+
+			sa := local.NewIstiodAnalyzer(combined,
 				resource.Namespace(selectedNamespace),
 				resource.Namespace(istioNamespace), nil, true)
 
@@ -175,6 +234,18 @@ func Analyze() *cobra.Command {
 					ResourceName: parts[1],
 				})
 			}
+			if suppressionPath != "" {
+				f, err := local.LoadSuppressionFile(suppressionPath)
+				if err != nil {
+					return err
+				}
+				fileSuppressions, overrides, warnings := f.Active(time.Now())
+				for _, w := range warnings {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s, it no longer has any effect.\n", w)
+				}
+				suppressions = append(suppressions, fileSuppressions...)
+				sa.SetSeverityOverrides(overrides)
+			}
 			sa.SetSuppressions(suppressions)
 
 			// If we're using kube, use that as a base source.
@@ -214,6 +285,17 @@ func Analyze() *cobra.Command {
 				}
 			}
 
+			// If config dumps of proxies are provided, check the configuration they got from istiod too.
+			if len(configDumpFiles) > 0 {
+				dumps, err := readConfigDumps(configDumpFiles)
+				if err != nil {
+					return err
+				}
+				if err = sa.AddConfigDumpSource(dumps); err != nil {
+					return err
+				}
+			}
+
 			// Do the analysis
 			result, err := sa.Analyze(cancel)
 			if err != nil {
@@ -243,11 +325,20 @@ func Analyze() *cobra.Command {
 			outputMessages := result.Messages.SetDocRef("istioctl-analyze").FilterOutLowerThan(outputThreshold.Level)
 
 			// Print all the messages to stdout in the specified format
-			output, err := formatting.Print(outputMessages, msgOutputFormat, colorize)
-			if err != nil {
-				return err
+			if jsonDocument {
+				if outputMessages == nil {
+					outputMessages = diag.Messages{}
+				}
+				if err := formatting.PrintJSONDocument(cmd.OutOrStdout(), "AnalysisMessageList", outputMessages); err != nil {
+					return err
+				}
+			} else {
+				output, err := formatting.Print(outputMessages, msgOutputFormat, colorize)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), output)
 			}
-			fmt.Fprintln(cmd.OutOrStdout(), output)
 
 			// An extra message on success
 			if len(outputMessages) == 0 {
@@ -290,6 +381,9 @@ func Analyze() *cobra.Command {
 
 	analysisCmd.PersistentFlags().BoolVarP(&listAnalyzers, "list-analyzers", "L", false,
 		"List the analyzers available to run. Suppresses normal execution.")
+	analysisCmd.PersistentFlags().StringVar(&explain, "explain", "",
+		"Explain a message code, such as IST0101: its description, causes and remediation. "+
+			"Use 'all' to dump the catalog of all message codes in the --output format. Suppresses normal execution.")
 	analysisCmd.PersistentFlags().BoolVarP(&useKube, "use-kube", "k", true,
 		"Use live Kubernetes cluster for analysis. Set --use-kube=false to analyze files only.")
 	analysisCmd.PersistentFlags().BoolVar(&colorize, "color", formatting.IstioctlColorDefault(analysisCmd.OutOrStdout()),
@@ -300,8 +394,9 @@ func Analyze() *cobra.Command {
 		fmt.Sprintf("The severity level of analysis at which to set a non-zero exit code. Valid values: %v", diag.GetAllLevelStrings()))
 	analysisCmd.PersistentFlags().Var(&outputThreshold, "output-threshold",
 		fmt.Sprintf("The severity level of analysis at which to display messages. Valid values: %v", diag.GetAllLevelStrings()))
-	analysisCmd.PersistentFlags().StringVarP(&msgOutputFormat, "output", "o", formatting.LogFormat,
-		fmt.Sprintf("Output format: one of %v", formatting.MsgOutputFormatKeys))
+	analysisCmd.PersistentFlags().StringVarP(&msgOutputFormat, "output-format", "o", formatting.LogFormat,
+		fmt.Sprintf("Output format: one of %v. Use the global --%s flag instead for a versioned JSON document, "+
+			"with the messages in its items field", formatting.MsgOutputFormatKeys, FlagOutput))
 	analysisCmd.PersistentFlags().StringVar(&meshCfgFile, "meshConfigFile", "",
 		"Overrides the mesh config values to use for analysis.")
 	analysisCmd.PersistentFlags().BoolVarP(&allNamespaces, "all-namespaces", "A", false,
@@ -310,15 +405,85 @@ func Analyze() *cobra.Command {
 		"Suppress reporting a message code on a specific resource. Values are supplied in the form "+
 			`<code>=<resource> (e.g. '--suppress "IST0102=DestinationRule primary-dr.default"'). Can be repeated. `+
 			`You can include the wildcard character '*' to support a partial match (e.g. '--suppress "IST0102=DestinationRule *.default" ).`)
+	analysisCmd.PersistentFlags().StringVar(&suppressionPath, "suppression-file", "",
+		"A YAML file of suppressions and severity overrides of message codes on resources, which can expire. "+
+			"Suppressions have the same form as --suppress and are applied in addition to them.")
 	analysisCmd.PersistentFlags().DurationVar(&analysisTimeout, "timeout", 30*time.Second,
 		"The duration to wait before failing")
 	analysisCmd.PersistentFlags().BoolVarP(&recursive, "recursive", "R", false,
 		"Process directory arguments recursively. Useful when you want to analyze related manifests organized within the same directory.")
+	analysisCmd.PersistentFlags().StringSliceVar(&remoteContexts, "remote-contexts", nil,
+		"The kubeconfig contexts of the other clusters of the mesh. The subsets referenced by the VirtualServices are "+
+			"looked up in the DestinationRules of all the clusters, and only reported if no cluster defines them.")
+	analysisCmd.PersistentFlags().StringSliceVar(&configDumpFiles, "config-dump", nil,
+		"Envoy config dump files of sidecars, as printed by istioctl proxy-config all -o json. The routes of the "+
+			"VirtualServices are checked to be in the config dumps of the sidecars routing to their hosts.")
 	analysisCmd.PersistentFlags().BoolVar(&ignoreUnknown, "ignore-unknown", false,
 		"Don't complain about un-parseable input documents, for cases where analyze should run only on k8s compliant inputs.")
+	supportGlobalOutput(analysisCmd)
 	return analysisCmd
 }
 
+// remoteDestinationRules returns the DestinationRules of all the namespaces of a remote cluster.
+func remoteDestinationRules(client kube.ExtendedClient) ([]*resource.Instance, error) {
+	list, err := client.Istio().NetworkingV1alpha3().DestinationRules(v1.NamespaceAll).List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*resource.Instance, 0, len(list.Items))
+	for _, dr := range list.Items {
+		out = append(out, &resource.Instance{
+			Metadata: resource.Metadata{
+				FullName:    resource.NewFullName(resource.Namespace(dr.Namespace), resource.LocalName(dr.Name)),
+				CreateTime:  dr.CreationTimestamp.Time,
+				Version:     resource.Version(dr.ResourceVersion),
+				Labels:      dr.Labels,
+				Annotations: dr.Annotations,
+			},
+			Message: &dr.Spec,
+		})
+	}
+	return out, nil
+}
+
+// withRemoteDestinationRules sets the DestinationRules of the remote clusters in the analyzers checking the subsets
+// referenced by the VirtualServices.
+func withRemoteDestinationRules(all []analysis.Analyzer, drs []*resource.Instance) []analysis.Analyzer {
+	for i, a := range all {
+		if _, ok := a.(*virtualservice.DestinationRuleAnalyzer); ok {
+			all[i] = &virtualservice.DestinationRuleAnalyzer{RemoteDestinationRules: drs}
+		}
+	}
+	return all
+}
+
+// readConfigDumps reads Envoy config dump files, naming each after the <pod name>.<namespace> of the proxy in its
+// bootstrap node ID.
+func readConfigDumps(files []string) ([]local.ProxyConfigDump, error) {
+	out := make([]local.ProxyConfigDump, 0, len(files))
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		w := &configdump.Wrapper{}
+		if err := w.UnmarshalJSON(b); err != nil {
+			return nil, fmt.Errorf("failed to parse the config dump %s: %v", f, err)
+		}
+		bootstrap, err := w.GetBootstrapConfigDump()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the bootstrap of the config dump %s: %v", f, err)
+		}
+		// The node ID is <type>~<ip>~<pod name>.<namespace>~<domain>.
+		parts := strings.Split(bootstrap.GetBootstrap().GetNode().GetId(), "~")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("the config dump %s has an unexpected node ID %q", f, bootstrap.GetBootstrap().GetNode().GetId())
+		}
+		out = append(out, local.ProxyConfigDump{Proxy: parts[2], ConfigDump: w.ConfigDump})
+	}
+	return out, nil
+}
+
 func gatherFiles(cmd *cobra.Command, args []string) ([]local.ReaderSource, error) {
 	var readers []local.ReaderSource
 	for _, f := range args {
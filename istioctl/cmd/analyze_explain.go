@@ -0,0 +1,77 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/istioctl/pkg/util/formatting"
+	"istio.io/istio/pkg/config/analysis/msg"
+)
+
+// explainAll is the value of --explain which dumps the catalog of all message codes.
+const explainAll = "all"
+
+// explainMessages renders the explanation of a message code given to --explain, or of all of them, in the output
+// format.
+func explainMessages(code, format string) (string, error) {
+	var explanations []*msg.Explanation
+	if strings.EqualFold(code, explainAll) {
+		explanations = msg.Catalog()
+	} else {
+		e, ok := msg.Explain(code)
+		if !ok {
+			return "", CommandParseError{fmt.Errorf("unknown message code %q, use --explain %s to list them", code, explainAll)}
+		}
+		explanations = []*msg.Explanation{e}
+	}
+
+	switch format {
+	case formatting.JSONFormat:
+		out, err := json.MarshalIndent(explanations, "", "\t")
+		return string(out) + "\n", err
+	case formatting.YAMLFormat:
+		out, err := yaml.Marshal(explanations)
+		return string(out), err
+	default:
+		rendered := make([]string, 0, len(explanations))
+		for _, e := range explanations {
+			rendered = append(rendered, explanationAsString(e))
+		}
+		return strings.Join(rendered, "\n"), nil
+	}
+}
+
+func explanationAsString(e *msg.Explanation) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s [%s]\n", e.Level, e.Name, e.Code)
+	fmt.Fprintf(&b, "Description:\n    %s\n", e.Description)
+	fmt.Fprintf(&b, "Message:\n    %s\n", e.Template)
+	if len(e.Causes) > 0 {
+		b.WriteString("Causes:\n")
+		for _, c := range e.Causes {
+			fmt.Fprintf(&b, "  * %s\n", c)
+		}
+	}
+	if e.Remediation != "" {
+		fmt.Fprintf(&b, "Remediation:\n    %s\n", e.Remediation)
+	}
+	fmt.Fprintf(&b, "Documentation:\n    %s\n", e.DocumentationURL)
+	return b.String()
+}
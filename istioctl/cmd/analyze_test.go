@@ -15,11 +15,24 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 
 	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	networking "istio.io/api/networking/v1alpha3"
+	clientnetworking "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"istio.io/istio/istioctl/pkg/util/formatting"
+	"istio.io/istio/pkg/config/analysis/analyzers"
+	"istio.io/istio/pkg/config/analysis/analyzers/virtualservice"
 	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	kubelib "istio.io/istio/pkg/kube"
 )
 
 func TestErrorOnIssuesFound(t *testing.T) {
@@ -63,3 +76,72 @@ func TestNoErrorIfMessageLevelsBelowThreshold(t *testing.T) {
 
 	g.Expect(err).To(BeNil())
 }
+
+func TestExplainMessages(t *testing.T) {
+	g := NewWithT(t)
+
+	out, err := explainMessages("ist0101", formatting.LogFormat)
+	g.Expect(err).To(BeNil())
+	g.Expect(out).To(ContainSubstring("Error ReferencedResourceNotFound [IST0101]\n"))
+	g.Expect(out).To(ContainSubstring("Remediation:\n"))
+	g.Expect(out).To(ContainSubstring("/docs/reference/config/analysis/ist0101/\n"))
+
+	_, err = explainMessages("IST9999", formatting.LogFormat)
+	g.Expect(err).NotTo(BeNil())
+
+	out, err = explainMessages("all", formatting.JSONFormat)
+	g.Expect(err).To(BeNil())
+	var catalog []msg.Explanation
+	g.Expect(json.Unmarshal([]byte(out), &catalog)).To(Succeed())
+	g.Expect(catalog).To(HaveLen(len(msg.All())))
+	for i, mt := range msg.All() {
+		g.Expect(catalog[i].Code).To(Equal(mt.Code()))
+		g.Expect(catalog[i].Level).To(Equal(mt.Level().String()))
+		g.Expect(catalog[i].Template).To(Equal(mt.Template()))
+	}
+}
+
+func TestRemoteDestinationRules(t *testing.T) {
+	g := NewWithT(t)
+
+	client := kubelib.NewFakeClient()
+	_, err := client.Istio().NetworkingV1alpha3().DestinationRules("default").Create(context.TODO(), &clientnetworking.DestinationRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "reviews", Namespace: "default"},
+		Spec: networking.DestinationRule{
+			Host:    "reviews",
+			Subsets: []*networking.Subset{{Name: "v2"}},
+		},
+	}, metav1.CreateOptions{})
+	g.Expect(err).To(BeNil())
+
+	drs, err := remoteDestinationRules(client)
+	g.Expect(err).To(BeNil())
+	g.Expect(drs).To(HaveLen(1))
+	g.Expect(drs[0].Metadata.FullName).To(Equal(resource.NewFullName("default", "reviews")))
+	g.Expect(drs[0].Message.(*networking.DestinationRule).GetSubsets()[0].GetName()).To(Equal("v2"))
+
+	all := withRemoteDestinationRules(analyzers.All(), drs)
+	found := false
+	for _, a := range all {
+		if dra, ok := a.(*virtualservice.DestinationRuleAnalyzer); ok {
+			g.Expect(dra.RemoteDestinationRules).To(Equal(drs))
+			found = true
+		}
+	}
+	g.Expect(found).To(BeTrue())
+}
+
+func TestReadConfigDumps(t *testing.T) {
+	g := NewWithT(t)
+
+	dumps, err := readConfigDumps([]string{"../../pkg/config/analysis/analyzers/testdata/virtualservice_proxyroutes_configdump.json"})
+	g.Expect(err).To(BeNil())
+	g.Expect(dumps).To(HaveLen(1))
+	g.Expect(dumps[0].Proxy).To(Equal("productpage-v1-6b746f74dc-9stvs.default"))
+	g.Expect(dumps[0].ConfigDump.GetConfigs()).NotTo(BeEmpty())
+
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	g.Expect(os.WriteFile(invalid, []byte(`{"configs": [}`), 0o644)).To(Succeed())
+	_, err = readConfigDumps([]string{invalid})
+	g.Expect(err).NotTo(BeNil())
+}
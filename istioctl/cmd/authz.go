@@ -19,12 +19,17 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	security "istio.io/api/security/v1beta1"
 	"istio.io/istio/istioctl/pkg/authz"
 	"istio.io/istio/istioctl/pkg/util/configdump"
 	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/kube"
 	"istio.io/pkg/log"
 )
@@ -140,6 +145,141 @@ func getConfigDumpFromPod(podName, podNamespace string) (*configdump.Wrapper, er
 	return envoyConfig, nil
 }
 
+func authzTestCmd() *cobra.Command {
+	var testFile string
+	var policyFiles []string
+	cmd := &cobra.Command{
+		Use:   "test -f <test-file>",
+		Short: "Test AuthorizationPolicies against declared requests.",
+		Long: `Test evaluates the requests declared in a test file against AuthorizationPolicies and compares the
+decisions with the expected ones. The policies are read from the cluster, or from local files with --policies,
+which allows gating AuthorizationPolicy changes in CI. Policies in the Istio namespace apply to all workloads.
+
+Each test declares the workload receiving the request, the request attributes and the expected decision:
+
+  tests:
+  - name: frontend can read
+    workload: {namespace: foo, labels: {app: httpbin}}
+    request:
+      principal: cluster.local/ns/foo/sa/frontend
+      namespace: foo
+      method: GET
+      path: /status/200
+      headers: {x-token: admin}
+    expect: allow
+
+Requests matching a CUSTOM policy are reported as errors, as their decision is made by the external authorizer.
+The command exits with an error if any test fails.`,
+		Example: `  # Test the AuthorizationPolicies in the cluster
+  istioctl x authz test -f tests.yaml
+
+  # Test local AuthorizationPolicies, for example in CI
+  istioctl x authz test -f tests.yaml --policies policies/`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if testFile == "" {
+				return fmt.Errorf("a test file must be specified with -f")
+			}
+			b, err := os.ReadFile(testFile)
+			if err != nil {
+				return err
+			}
+			tf, err := authz.ParseTestFile(b)
+			if err != nil {
+				return fmt.Errorf("failed to parse test file %s: %v", testFile, err)
+			}
+			var policies []authz.Policy
+			if len(policyFiles) > 0 {
+				policies, err = readAuthorizationPolicies(policyFiles)
+			} else {
+				policies, err = listAuthorizationPolicies()
+			}
+			if err != nil {
+				return err
+			}
+			if failed := authz.RunTests(cmd.OutOrStdout(), tf.Tests, policies, istioNamespace); failed > 0 {
+				return fmt.Errorf("%d of %d tests failed", failed, len(tf.Tests))
+			}
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringVarP(&testFile, "file", "f", "", "The YAML file with the test cases")
+	cmd.PersistentFlags().StringSliceVar(&policyFiles, "policies", nil,
+		"Files or directories with the AuthorizationPolicies to test, instead of the ones in the cluster")
+	return cmd
+}
+
+func readAuthorizationPolicies(paths []string) ([]authz.Policy, error) {
+	files, err := expandFilenames(paths)
+	if err != nil {
+		return nil, err
+	}
+	var policies []authz.Policy
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		configs, _, err := crd.ParseInputs(string(b))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", f, err)
+		}
+		for _, c := range configs {
+			if c.GroupVersionKind != gvk.AuthorizationPolicy {
+				continue
+			}
+			ns := c.Namespace
+			if ns == "" {
+				ns = handlers.HandleNamespace(namespace, defaultNamespace)
+			}
+			policies = append(policies, authz.Policy{Name: c.Name, Namespace: ns, Spec: c.Spec.(*security.AuthorizationPolicy)})
+		}
+	}
+	return policies, nil
+}
+
+// expandFilenames replaces directories with the YAML files they contain.
+func expandFilenames(paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if ext := filepath.Ext(e.Name()); !e.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, filepath.Join(p, e.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+func listAuthorizationPolicies() ([]authz.Policy, error) {
+	client, err := kubeClient(kubeconfig, configContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create k8s client: %w", err)
+	}
+	list, err := client.Istio().SecurityV1beta1().AuthorizationPolicies(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list AuthorizationPolicies: %v", err)
+	}
+	policies := make([]authz.Policy, 0, len(list.Items))
+	for i := range list.Items {
+		p := list.Items[i]
+		policies = append(policies, authz.Policy{Name: p.Name, Namespace: p.Namespace, Spec: &p.Spec})
+	}
+	return policies, nil
+}
+
 // AuthZ groups commands used for inspecting and interacting the authorization policy.
 // Note: this is still under active development and is not ready for real use.
 func AuthZ() *cobra.Command {
@@ -149,6 +289,7 @@ func AuthZ() *cobra.Command {
 	}
 
 	cmd.AddCommand(checkCmd)
+	cmd.AddCommand(authzTestCmd())
 	cmd.Long += "\n\n" + ExperimentalMsg
 	return cmd
 }
@@ -0,0 +1,114 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/clioptions"
+	caserver "istio.io/istio/security/pkg/server/ca"
+)
+
+const csvOutput = "csv"
+
+func certificatesCommand() *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var output string
+	cmd := &cobra.Command{
+		Use:   "certificates",
+		Short: "Lists the workload certificates issued by the istiod CA.",
+		Long: `Lists the workload certificates issued by each istiod instance to the proxies in the mesh, with their
+SANs, issuer, serial number and validity. Only the latest certificate issued to each proxy and identity is listed,
+until it expires.`,
+		Example: `  # List the certificates ordered by expiry
+  istioctl x certificates
+
+  # Export the certificates as CSV
+  istioctl x certificates -o csv > certificates.csv`,
+		Aliases: []string{"certs"},
+		Args: func(cmd *cobra.Command, args []string) error {
+			if output != summaryOutput && output != jsonOutput && output != csvOutput {
+				return fmt.Errorf("unknown output format %q, must be one of short|json|csv", output)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeClient, err := kubeClientWithRevision(kubeconfig, configContext, opts.Revision)
+			if err != nil {
+				return err
+			}
+			res, err := kubeClient.AllDiscoveryDo(context.Background(), istioNamespace, "/debug/certz")
+			if err != nil {
+				return err
+			}
+			certs, err := parseIssuedCertificates(res)
+			if err != nil {
+				return err
+			}
+			return writeIssuedCertificates(cmd.OutOrStdout(), certs, output)
+		},
+	}
+	opts.AttachControlPlaneFlags(cmd)
+	cmd.PersistentFlags().StringVarP(&output, "output", "o", summaryOutput, "Output format: one of short|json|csv")
+	return cmd
+}
+
+// parseIssuedCertificates merges the certificates reported by each istiod, ordered by expiry.
+func parseIssuedCertificates(input map[string][]byte) ([]caserver.IssuedCertificate, error) {
+	var certs []caserver.IssuedCertificate
+	for istiod, bytes := range input {
+		var parsed []caserver.IssuedCertificate
+		if err := json.Unmarshal(bytes, &parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse certificates from %s: %v: %s", istiod, err, string(bytes))
+		}
+		certs = append(certs, parsed...)
+	}
+	sort.SliceStable(certs, func(i, j int) bool {
+		if !certs[i].NotAfter.Equal(certs[j].NotAfter) {
+			return certs[i].NotAfter.Before(certs[j].NotAfter)
+		}
+		return certs[i].Requester < certs[j].Requester
+	})
+	return certs, nil
+}
+
+func writeIssuedCertificates(out io.Writer, certs []caserver.IssuedCertificate, format string) error {
+	switch format {
+	case jsonOutput:
+		b, err := json.MarshalIndent(certs, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(b))
+		return err
+	case csvOutput:
+		return caserver.WriteCSV(out, certs)
+	}
+	w := new(tabwriter.Writer).Init(out, 0, 8, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "REQUESTER\tSANS\tSERIAL\tNOT AFTER")
+	for _, c := range certs {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Requester, strings.Join(c.SANs, ","), c.Serial, c.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,66 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteIssuedCertificates(t *testing.T) {
+	certs, err := parseIssuedCertificates(map[string][]byte{
+		"istiod-1.istio-system": []byte(`[{"requester": "10.0.0.2", "sans": ["spiffe://cluster.local/ns/default/sa/b"],
+"issuer": "O=cluster.local", "serial": "2", "notBefore": "2022-01-01T00:00:00Z", "notAfter": "2022-01-03T00:00:00Z"}]`),
+		"istiod-2.istio-system": []byte(`[{"requester": "10.0.0.1", "sans": ["spiffe://cluster.local/ns/default/sa/a"],
+"issuer": "O=cluster.local", "serial": "1", "notBefore": "2022-01-01T00:00:00Z", "notAfter": "2022-01-02T00:00:00Z"}]`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: summaryOutput,
+			want: `REQUESTER   SANS                                     SERIAL   NOT AFTER
+10.0.0.1    spiffe://cluster.local/ns/default/sa/a   1        2022-01-02T00:00:00Z
+10.0.0.2    spiffe://cluster.local/ns/default/sa/b   2        2022-01-03T00:00:00Z
+`,
+		},
+		{
+			format: csvOutput,
+			want: `requester,sans,issuer,serial,not_before,not_after
+10.0.0.1,spiffe://cluster.local/ns/default/sa/a,O=cluster.local,1,2022-01-01T00:00:00Z,2022-01-02T00:00:00Z
+10.0.0.2,spiffe://cluster.local/ns/default/sa/b,O=cluster.local,2,2022-01-01T00:00:00Z,2022-01-03T00:00:00Z
+`,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.format, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			if err := writeIssuedCertificates(out, certs, tt.format); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", out.String(), tt.want)
+			}
+		})
+	}
+
+	if _, err := parseIssuedCertificates(map[string][]byte{"istiod": []byte("istiod CA is not enabled")}); err == nil {
+		t.Error("expected error for invalid response")
+	}
+}
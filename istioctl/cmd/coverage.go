@@ -0,0 +1,258 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	admit_v1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/kube"
+)
+
+const (
+	// coverageSidecar is the mode of workloads whose pods all run a sidecar.
+	coverageSidecar = "sidecar"
+	// coveragePartial is the mode of workloads with pods both with and without a sidecar.
+	coveragePartial = "partial"
+	// coverageUnmeshed is the mode of workloads whose pods run no sidecar.
+	coverageUnmeshed = "unmeshed"
+)
+
+type workloadCoverage struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Pods        int    `json:"pods"`
+	SidecarPods int    `json:"sidecarPods"`
+	Mode        string `json:"mode"`
+	// Gap explains why the workload is not fully in the mesh.
+	Gap string `json:"gap,omitempty"`
+}
+
+type namespaceCoverage struct {
+	Name string `json:"name"`
+	// Injection is the revision injecting sidecars in the namespace, empty if injection is not enabled.
+	Injection string             `json:"injection,omitempty"`
+	Workloads []workloadCoverage `json:"workloads"`
+}
+
+type coverageTotals struct {
+	Namespaces int `json:"namespaces"`
+	Workloads  int `json:"workloads"`
+	Sidecar    int `json:"sidecar"`
+	Partial    int `json:"partial"`
+	Unmeshed   int `json:"unmeshed"`
+}
+
+type coverageReport struct {
+	Namespaces []namespaceCoverage `json:"namespaces"`
+	Totals     coverageTotals      `json:"totals"`
+}
+
+func coverageCommand() *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	output := summaryOutput
+	cmd := &cobra.Command{
+		Use:   "coverage",
+		Short: "Report which workloads are in the mesh",
+		Long: `Lists the workloads of every namespace and whether their pods run a sidecar, with totals, to track mesh
+coverage. Workloads not fully in the mesh are reported with the reason, such as a namespace without sidecar injection
+or pods started before injection was enabled. System namespaces and the Istio namespace are skipped.
+
+This version of Istio supports the sidecar data plane only, so there are no ambient or waypoint modes.`,
+		Example: `  # Show the mesh coverage of the cluster
+  istioctl x coverage
+
+  # Export the mesh coverage for compliance tracking
+  istioctl x coverage -o json > coverage.json`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("coverage takes no arguments")
+			}
+			if output != summaryOutput && output != jsonOutput && output != yamlOutput {
+				return fmt.Errorf("unknown output format %q, must be one of short|json|yaml", output)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := kubeClientWithRevision(kubeconfig, configContext, opts.Revision)
+			if err != nil {
+				return fmt.Errorf("failed to create k8s client: %v", err)
+			}
+			ctx := context.Background()
+			nslist, err := getNamespaces(ctx, client)
+			if err != nil {
+				return err
+			}
+			hooks, err := getWebhooks(ctx, client)
+			if err != nil {
+				return err
+			}
+			pods, err := getPods(ctx, client)
+			if err != nil {
+				return err
+			}
+			return writeCoverage(cmd.OutOrStdout(), buildCoverageReport(nslist, hooks, pods), output)
+		},
+	}
+	opts.AttachControlPlaneFlags(cmd)
+	cmd.PersistentFlags().StringVarP(&output, "output", "o", summaryOutput, "Output format: one of short|json|yaml")
+	return cmd
+}
+
+// buildCoverageReport groups the pods of each namespace by workload, and classifies the workloads by how many of their
+// pods run a sidecar.
+func buildCoverageReport(namespaces []v1.Namespace, hooks []admit_v1.MutatingWebhookConfiguration,
+	allPods map[resource.Namespace][]v1.Pod,
+) coverageReport {
+	report := coverageReport{Namespaces: []namespaceCoverage{}}
+	for i := range namespaces {
+		namespace := &namespaces[i]
+		if hideFromOutput(resource.Namespace(namespace.Name)) {
+			continue
+		}
+		injection := getInjectedRevision(namespace, hooks)
+		if strings.HasPrefix(injection, "MISSING/") {
+			// The namespace asks for injection, but no injector matches it.
+			injection = ""
+		}
+		nsCoverage := namespaceCoverage{Name: namespace.Name, Injection: injection, Workloads: []workloadCoverage{}}
+		workloads := map[string]*workloadCoverage{}
+		disabled := map[string]int{}
+		for j := range allPods[resource.Namespace(namespace.Name)] {
+			pod := &allPods[resource.Namespace(namespace.Name)][j]
+			if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+				continue
+			}
+			deployMeta, typeMeta := kube.GetDeployMetaFromPod(pod)
+			key := typeMeta.Kind + "/" + deployMeta.Name
+			wl, ok := workloads[key]
+			if !ok {
+				wl = &workloadCoverage{Name: deployMeta.Name, Kind: typeMeta.Kind}
+				workloads[key] = wl
+			}
+			wl.Pods++
+			if hasSidecar(pod) {
+				wl.SidecarPods++
+			} else if injectionDisabled(pod) {
+				disabled[key]++
+			}
+		}
+		for key, wl := range workloads {
+			switch {
+			case wl.SidecarPods == wl.Pods:
+				wl.Mode = coverageSidecar
+			case wl.SidecarPods > 0:
+				wl.Mode = coveragePartial
+			default:
+				wl.Mode = coverageUnmeshed
+			}
+			switch {
+			case wl.Mode == coverageSidecar:
+			case disabled[key] > 0:
+				wl.Gap = "sidecar injection disabled for the pods"
+			case injection == "":
+				wl.Gap = "sidecar injection not enabled for the namespace"
+			default:
+				wl.Gap = "pods need a restart to inject the sidecar"
+			}
+			nsCoverage.Workloads = append(nsCoverage.Workloads, *wl)
+			report.Totals.Workloads++
+			switch wl.Mode {
+			case coverageSidecar:
+				report.Totals.Sidecar++
+			case coveragePartial:
+				report.Totals.Partial++
+			default:
+				report.Totals.Unmeshed++
+			}
+		}
+		sort.Slice(nsCoverage.Workloads, func(i, j int) bool {
+			if nsCoverage.Workloads[i].Name != nsCoverage.Workloads[j].Name {
+				return nsCoverage.Workloads[i].Name < nsCoverage.Workloads[j].Name
+			}
+			return nsCoverage.Workloads[i].Kind < nsCoverage.Workloads[j].Kind
+		})
+		report.Namespaces = append(report.Namespaces, nsCoverage)
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool {
+		return report.Namespaces[i].Name < report.Namespaces[j].Name
+	})
+	report.Totals.Namespaces = len(report.Namespaces)
+	return report
+}
+
+// hasSidecar returns true if the pod runs the istio-proxy sidecar.
+func hasSidecar(pod *v1.Pod) bool {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == "istio-proxy" {
+			return true
+		}
+	}
+	return false
+}
+
+func writeCoverage(out io.Writer, report coverageReport, format string) error {
+	switch format {
+	case jsonOutput:
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(b))
+		return err
+	case yamlOutput:
+		b, err := yaml.Marshal(report)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(b)
+		return err
+	}
+	w := new(tabwriter.Writer).Init(out, 0, 8, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAMESPACE\tINJECTION\tWORKLOAD\tPODS\tMODE\tGAP")
+	for _, ns := range report.Namespaces {
+		injection := ns.Injection
+		if injection == "" {
+			injection = "-"
+		}
+		if len(ns.Workloads) == 0 {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t<no workloads>\t\t\t\n", ns.Name, injection)
+			continue
+		}
+		for _, wl := range ns.Workloads {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s/%s\t%d/%d\t%s\t%s\n", ns.Name, injection, strings.ToLower(wl.Kind), wl.Name,
+				wl.SidecarPods, wl.Pods, wl.Mode, wl.Gap)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	t := report.Totals
+	_, err := fmt.Fprintf(out, "\n%d namespaces, %d workloads: %d sidecar, %d partial, %d unmeshed\n",
+		t.Namespaces, t.Workloads, t.Sidecar, t.Partial, t.Unmeshed)
+	return err
+}
@@ -0,0 +1,111 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	admit_v1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/api/annotation"
+	"istio.io/api/label"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func coveragePod(namespace, name, replicaSet string, sidecar bool, annotations map[string]string) v1.Pod {
+	controller := true
+	pod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       namespace,
+			GenerateName:    replicaSet + "-",
+			Labels:          map[string]string{"pod-template-hash": "abc"},
+			Annotations:     annotations,
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: replicaSet, Controller: &controller}},
+		},
+		Spec: v1.PodSpec{Containers: []v1.Container{{Name: "app"}}},
+	}
+	if sidecar {
+		pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: "istio-proxy"})
+	}
+	return pod
+}
+
+func TestBuildCoverageReport(t *testing.T) {
+	namespaces := []v1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "meshed", Labels: map[string]string{"istio-injection": "enabled"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "plain"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+	}
+	hooks := []admit_v1.MutatingWebhookConfiguration{{
+		ObjectMeta: metav1.ObjectMeta{Name: "istio-sidecar-injector", Labels: map[string]string{label.IoIstioRev.Name: "default"}},
+		Webhooks: []admit_v1.MutatingWebhook{{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"istio-injection": "enabled"}},
+		}},
+	}}
+	pods := map[resource.Namespace][]v1.Pod{
+		"meshed": {
+			coveragePod("meshed", "a-abc-1", "a-abc", true, nil),
+			coveragePod("meshed", "a-abc-2", "a-abc", true, nil),
+			coveragePod("meshed", "b-abc-1", "b-abc", true, nil),
+			coveragePod("meshed", "b-abc-2", "b-abc", false, nil),
+			coveragePod("meshed", "c-abc-1", "c-abc", false, map[string]string{annotation.SidecarInject.Name: "false"}),
+		},
+		"plain":       {coveragePod("plain", "d-abc-1", "d-abc", false, nil)},
+		"kube-system": {coveragePod("kube-system", "e-abc-1", "e-abc", false, nil)},
+	}
+
+	report := buildCoverageReport(namespaces, hooks, pods)
+	assert.Equal(t, report, coverageReport{
+		Namespaces: []namespaceCoverage{
+			{
+				Name:      "meshed",
+				Injection: "default",
+				Workloads: []workloadCoverage{
+					{Name: "a", Kind: "Deployment", Pods: 2, SidecarPods: 2, Mode: coverageSidecar},
+					{
+						Name: "b", Kind: "Deployment", Pods: 2, SidecarPods: 1, Mode: coveragePartial,
+						Gap: "pods need a restart to inject the sidecar",
+					},
+					{
+						Name: "c", Kind: "Deployment", Pods: 1, Mode: coverageUnmeshed,
+						Gap: "sidecar injection disabled for the pods",
+					},
+				},
+			},
+			{
+				Name: "plain",
+				Workloads: []workloadCoverage{{
+					Name: "d", Kind: "Deployment", Pods: 1, Mode: coverageUnmeshed,
+					Gap: "sidecar injection not enabled for the namespace",
+				}},
+			},
+		},
+		Totals: coverageTotals{Namespaces: 2, Workloads: 4, Sidecar: 1, Partial: 1, Unmeshed: 2},
+	})
+
+	var out bytes.Buffer
+	if err := writeCoverage(&out, report, summaryOutput); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "2 namespaces, 4 workloads: 1 sidecar, 1 partial, 2 unmeshed") {
+		t.Errorf("missing totals in output:\n%s", out.String())
+	}
+}
@@ -48,6 +48,7 @@ import (
 	"istio.io/istio/istioctl/pkg/clioptions"
 	"istio.io/istio/istioctl/pkg/tag"
 	"istio.io/istio/istioctl/pkg/util/configdump"
+	"istio.io/istio/istioctl/pkg/util/formatting"
 	"istio.io/istio/istioctl/pkg/util/handlers"
 	istio_envoy_configdump "istio.io/istio/istioctl/pkg/writer/envoy/configdump"
 	"istio.io/istio/pilot/pkg/config/kube/crdclient"
@@ -90,11 +91,18 @@ func podDescribeCmd() *cobra.Command {
 		Short:   "Describe pods and their Istio configuration [kube-only]",
 		Long: `Analyzes pod, its Services, DestinationRules, and VirtualServices and reports
 the configuration objects that affect that pod.`,
-		Example: `  istioctl experimental describe pod productpage-v1-c7765c886-7zzd4`,
+		Example: `  istioctl experimental describe pod productpage-v1-c7765c886-7zzd4
+
+  # Print the pod and the services selecting it as a JSON document
+  istioctl experimental describe pod productpage-v1-c7765c886-7zzd4 --output json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 1 {
 				return fmt.Errorf("expecting pod name")
 			}
+			jsonDocument, err := jsonDocumentOutput()
+			if err != nil {
+				return err
+			}
 
 			podName, ns := handlers.InferPodInfo(args[0], handlers.HandleNamespace(namespace, defaultNamespace))
 
@@ -113,22 +121,25 @@ the configuration objects that affect that pod.`,
 			annotations := k8s_labels.Set(pod.ObjectMeta.Annotations)
 			opts.Revision = getRevisionFromPodAnnotation(annotations)
 
-			printPod(writer, pod, opts.Revision)
-
 			svcs, err := client.CoreV1().Services(ns).List(context.TODO(), metav1.ListOptions{})
 			if err != nil {
 				return err
 			}
+			matchingServices := servicesSelectingPod(svcs.Items, podLabels)
+			if jsonDocument {
+				return formatting.PrintJSONDocument(writer, "PodDescription", describePodJSON(pod, opts.Revision, matchingServices))
+			}
 
-			matchingServices := make([]v1.Service, 0, len(svcs.Items))
-			for _, svc := range svcs.Items {
-				if len(svc.Spec.Selector) > 0 {
-					svcSelector := k8s_labels.SelectorFromSet(svc.Spec.Selector)
-					if svcSelector.Matches(podLabels) {
-						matchingServices = append(matchingServices, svc)
-					}
+			printPod(writer, pod, opts.Revision)
+			if isMeshed(pod) && !ignoreUnmeshed {
+				// The namespace may not be readable, its annotations are then ignored.
+				var namespaceAnnotations map[string]string
+				if podNamespace, err := client.CoreV1().Namespaces().Get(context.TODO(), ns, metav1.GetOptions{}); err == nil {
+					namespaceAnnotations = podNamespace.Annotations
 				}
+				printHoldApplication(writer, pod, namespaceAnnotations)
 			}
+
 			// Validate Istio's "Service association" requirement
 			if len(matchingServices) == 0 && !ignoreUnmeshed {
 				fmt.Fprintf(cmd.OutOrStdout(),
@@ -172,9 +183,24 @@ the configuration objects that affect that pod.`,
 	cmd.PersistentFlags().BoolVar(&ignoreUnmeshed, "ignoreUnmeshed", false,
 		"Suppress warnings for unmeshed pods")
 	cmd.Long += "\n\n" + ExperimentalMsg
+	supportGlobalOutput(cmd)
 	return cmd
 }
 
+// servicesSelectingPod returns the services whose selector matches the labels of a pod.
+func servicesSelectingPod(svcs []v1.Service, podLabels k8s_labels.Set) []v1.Service {
+	matchingServices := make([]v1.Service, 0, len(svcs))
+	for _, svc := range svcs {
+		if len(svc.Spec.Selector) > 0 {
+			svcSelector := k8s_labels.SelectorFromSet(svc.Spec.Selector)
+			if svcSelector.Matches(podLabels) {
+				matchingServices = append(matchingServices, svc)
+			}
+		}
+	}
+	return matchingServices
+}
+
 func getRevisionFromPodAnnotation(anno k8s_labels.Set) string {
 	statusString := anno.Get(apiannotation.SidecarStatus.Name)
 	var injectionStatus inject.SidecarInjectionStatus
@@ -488,6 +514,22 @@ func printPod(writer io.Writer, pod *v1.Pod, revision string) {
 	}
 }
 
+// printHoldApplication prints whether the application containers of the pod wait for its sidecar to start, and
+// which setting decides it.
+func printHoldApplication(writer io.Writer, pod *v1.Pod, namespaceAnnotations map[string]string) {
+	hold, source, err := inject.HoldApplicationUntilProxyStarts(pod.ObjectMeta.Annotations, namespaceAnnotations)
+	if err != nil {
+		fmt.Fprintf(writer, "   WARNING: %v\n", err)
+		return
+	}
+	// The injector moves the proxy before the application containers when they wait for it.
+	held := len(pod.Spec.Containers) > 0 && pod.Spec.Containers[0].Name == inject.ProxyContainerName
+	fmt.Fprintf(writer, "   Hold Application Until Proxy Starts: %v (%s)\n", held, source)
+	if (source == inject.HoldApplicationSourcePod || source == inject.HoldApplicationSourceNamespace) && hold != held {
+		fmt.Fprintf(writer, "   WARNING: the %s now sets it to %v, the pod must be recreated to apply it\n", source, hold)
+	}
+}
+
 func kname(meta metav1.ObjectMeta) string {
 	ns := handlers.HandleNamespace(namespace, defaultNamespace)
 	if meta.Namespace == ns {
@@ -1019,7 +1061,10 @@ func svcDescribeCmd() *cobra.Command {
 		Short:   "Describe services and their Istio configuration [kube-only]",
 		Long: `Analyzes service, pods, DestinationRules, and VirtualServices and reports
 the configuration objects that affect that service.`,
-		Example: `  istioctl experimental describe service productpage`,
+		Example: `  istioctl experimental describe service productpage
+
+  # Print the service and the pods it selects as a JSON document
+  istioctl experimental describe service productpage --output json`,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 1 {
 				cmd.Println(cmd.UsageString())
@@ -1028,6 +1073,10 @@ the configuration objects that affect that service.`,
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			jsonDocument, err := jsonDocumentOutput()
+			if err != nil {
+				return err
+			}
 			svcName, ns := handlers.InferPodInfo(args[0], handlers.HandleNamespace(namespace, defaultNamespace))
 
 			client, err := interfaceFactory(kubeconfig)
@@ -1045,6 +1094,9 @@ the configuration objects that affect that service.`,
 			if err != nil {
 				return err
 			}
+			if jsonDocument {
+				return formatting.PrintJSONDocument(writer, "ServiceDescription", describeServicePodsJSON(svc, pods.Items))
+			}
 
 			matchingPods := []v1.Pod{}
 			selectedPodCount := 0
@@ -1121,6 +1173,7 @@ the configuration objects that affect that service.`,
 	cmd.PersistentFlags().BoolVar(&ignoreUnmeshed, "ignoreUnmeshed", false,
 		"Suppress warnings for unmeshed pods")
 	cmd.Long += "\n\n" + ExperimentalMsg
+	supportGlobalOutput(cmd)
 	return cmd
 }
 
@@ -1331,3 +1384,114 @@ func getMeshConfig(kubeClient kube.ExtendedClient) (*meshconfig.MeshConfig, erro
 
 	return cfg, nil
 }
+
+// podDescription is the description of a pod printed by describe pod with the JSON output.
+type podDescription struct {
+	Name      string               `json:"name"`
+	Namespace string               `json:"namespace"`
+	Revision  string               `json:"revision,omitempty"`
+	Phase     string               `json:"phase"`
+	Meshed    bool                 `json:"meshed"`
+	Ports     []podPortDescription `json:"ports"`
+	Services  []serviceDescription `json:"services"`
+}
+
+type podPortDescription struct {
+	Container string `json:"container"`
+	Port      int32  `json:"port"`
+	Protocol  string `json:"protocol"`
+}
+
+// serviceDescription is the description of a service selecting a pod, printed by describe pod with the JSON output.
+type serviceDescription struct {
+	Name      string                   `json:"name"`
+	Namespace string                   `json:"namespace"`
+	Ports     []servicePortDescription `json:"ports"`
+}
+
+// servicePodsDescription is the description of a service and of the pods it selects, printed by describe service
+// with the JSON output.
+type servicePodsDescription struct {
+	serviceDescription
+	Pods []servicePodDescription `json:"pods"`
+}
+
+type servicePortDescription struct {
+	Name       string `json:"name,omitempty"`
+	Port       int32  `json:"port"`
+	Protocol   string `json:"protocol"`
+	TargetPort string `json:"targetPort"`
+}
+
+type servicePodDescription struct {
+	Name       string `json:"name"`
+	Phase      string `json:"phase"`
+	Meshed     bool   `json:"meshed"`
+	ProxyReady bool   `json:"proxyReady"`
+}
+
+func describePodJSON(pod *v1.Pod, revision string, svcs []v1.Service) podDescription {
+	out := podDescription{
+		Name:      pod.Name,
+		Namespace: pod.Namespace,
+		Revision:  revision,
+		Phase:     string(pod.Status.Phase),
+		Meshed:    isMeshed(pod),
+		Ports:     []podPortDescription{},
+		Services:  make([]serviceDescription, 0, len(svcs)),
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			out.Ports = append(out.Ports, podPortDescription{
+				Container: container.Name,
+				Port:      port.ContainerPort,
+				Protocol:  string(port.Protocol),
+			})
+		}
+	}
+	for i := range svcs {
+		out.Services = append(out.Services, describeServiceJSON(&svcs[i]))
+	}
+	return out
+}
+
+func describeServiceJSON(svc *v1.Service) serviceDescription {
+	out := serviceDescription{
+		Name:      svc.Name,
+		Namespace: svc.Namespace,
+		Ports:     make([]servicePortDescription, 0, len(svc.Spec.Ports)),
+	}
+	for i := range svc.Spec.Ports {
+		port := &svc.Spec.Ports[i]
+		out.Ports = append(out.Ports, servicePortDescription{
+			Name:       port.Name,
+			Port:       port.Port,
+			Protocol:   findProtocolForPort(port),
+			TargetPort: port.TargetPort.String(),
+		})
+	}
+	return out
+}
+
+// describeServicePodsJSON describes a service and the pods it selects among the pods.
+func describeServicePodsJSON(svc *v1.Service, pods []v1.Pod) servicePodsDescription {
+	out := servicePodsDescription{serviceDescription: describeServiceJSON(svc), Pods: []servicePodDescription{}}
+	if len(svc.Spec.Selector) == 0 {
+		return out
+	}
+	svcSelector := k8s_labels.SelectorFromSet(svc.Spec.Selector)
+	for i := range pods {
+		pod := &pods[i]
+		if !svcSelector.Matches(k8s_labels.Set(pod.ObjectMeta.Labels)) {
+			continue
+		}
+		ready, _ := containerReady(pod, proxyContainerName)
+		out.Pods = append(out.Pods, servicePodDescription{
+			Name:       pod.Name,
+			Phase:      string(pod.Status.Phase),
+			Meshed:     isMeshed(pod),
+			ProxyReady: ready,
+		})
+	}
+	return out
+}
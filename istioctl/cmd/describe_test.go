@@ -25,12 +25,15 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8s_labels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 
 	apiannotation "istio.io/api/annotation"
 	"istio.io/istio/istioctl/pkg/util/configdump"
 	"istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/kube/inject"
 	"istio.io/istio/pkg/test/util/assert"
 )
 
@@ -75,6 +78,22 @@ func TestDescribe(t *testing.T) {
 			expectedString: "services \"not-a-service\" not found",
 			wantException:  true, // "istioctl experimental describe service not-a-service" should fail
 		},
+		{ // case 9 pod as a JSON document
+			k8sConfigs:     describeJSONConfigs(),
+			args:           strings.Split("experimental describe pod productpage-v1 -n default --output json", " "),
+			goldenFilename: "testdata/describe/pod.json",
+		},
+		{ // case 10 service as a JSON document
+			k8sConfigs:     describeJSONConfigs(),
+			args:           strings.Split("experimental describe service productpage -n default --output json", " "),
+			goldenFilename: "testdata/describe/service.json",
+		},
+		{ // case 11 unsupported output format
+			k8sConfigs:     describeJSONConfigs(),
+			args:           strings.Split("experimental describe pod productpage-v1 -n default --output yaml", " "),
+			expectedString: `unknown output format "yaml", must be json`,
+			wantException:  true,
+		},
 	}
 
 	for i, c := range cases {
@@ -84,6 +103,84 @@ func TestDescribe(t *testing.T) {
 	}
 }
 
+func describeJSONConfigs() []runtime.Object {
+	return []runtime.Object{
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "productpage-v1", Namespace: "default", Labels: map[string]string{"app": "productpage"}},
+			Spec: v1.PodSpec{Containers: []v1.Container{
+				{Name: "productpage", Ports: []v1.ContainerPort{{ContainerPort: 9080, Protocol: v1.ProtocolTCP}}},
+				{Name: inject.ProxyContainerName},
+			}},
+			Status: v1.PodStatus{
+				Phase:             v1.PodRunning,
+				ContainerStatuses: []v1.ContainerStatus{{Name: inject.ProxyContainerName, Ready: true}},
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "ratings-v1", Namespace: "default", Labels: map[string]string{"app": "ratings"}},
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "productpage", Namespace: "default"},
+			Spec: v1.ServiceSpec{
+				Selector: map[string]string{"app": "productpage"},
+				Ports:    []v1.ServicePort{{Name: "http", Port: 9080, Protocol: v1.ProtocolTCP, TargetPort: intstr.FromInt(9080)}},
+			},
+		},
+	}
+}
+
+func TestPrintHoldApplication(t *testing.T) {
+	const hold = constants.HoldApplicationUntilProxyStartsAnnotation
+	proxyFirst := []v1.Container{{Name: inject.ProxyContainerName}, {Name: "app"}}
+	proxyLast := []v1.Container{{Name: "app"}, {Name: inject.ProxyContainerName}}
+	cases := []struct {
+		name       string
+		pod        map[string]string
+		namespace  map[string]string
+		containers []v1.Container
+		expected   string
+	}{
+		{
+			name:       "mesh config",
+			containers: proxyLast,
+			expected:   "   Hold Application Until Proxy Starts: false (mesh config)\n",
+		},
+		{
+			name:       "namespace",
+			namespace:  map[string]string{hold: "true"},
+			containers: proxyFirst,
+			expected:   "   Hold Application Until Proxy Starts: true (namespace annotation)\n",
+		},
+		{
+			name:       "namespace changed after injection",
+			namespace:  map[string]string{hold: "true"},
+			containers: proxyLast,
+			expected: "   Hold Application Until Proxy Starts: false (namespace annotation)\n" +
+				"   WARNING: the namespace annotation now sets it to true, the pod must be recreated to apply it\n",
+		},
+		{
+			name:       "pod overrides namespace",
+			pod:        map[string]string{hold: "false"},
+			namespace:  map[string]string{hold: "true"},
+			containers: proxyLast,
+			expected:   "   Hold Application Until Proxy Starts: false (pod annotation)\n",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tc.pod},
+				Spec:       v1.PodSpec{Containers: tc.containers},
+			}
+			var out bytes.Buffer
+			printHoldApplication(&out, pod, tc.namespace)
+			if out.String() != tc.expected {
+				t.Errorf("got %q, want %q", out.String(), tc.expected)
+			}
+		})
+	}
+}
+
 func TestGetRevisionFromPodAnnotation(t *testing.T) {
 	cases := []struct {
 		anno k8s_labels.Set
@@ -0,0 +1,134 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/xds"
+	"istio.io/pkg/version"
+)
+
+func envoyFilterCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "envoyfilter",
+		Short: "Commands to inspect EnvoyFilter resources",
+	}
+	cmd.AddCommand(envoyFilterValidateCmd())
+	return cmd
+}
+
+func envoyFilterValidateCmd() *cobra.Command {
+	var (
+		filenames    []string
+		proxyVersion string
+	)
+	cmd := &cobra.Command{
+		Use:   "validate -f FILENAME [--proxy-version VERSION]",
+		Short: "Check EnvoyFilter patches against the Envoy API of a proxy version",
+		Long: `Validate checks the patches of EnvoyFilter resources offline against the Envoy API supported by the
+target proxy version. It reports the type URLs and fields that the proxy no longer accepts as errors, and the
+deprecated type URLs, fields and filter names as warnings, so EnvoyFilters can be fixed before an upgrade rather than
+after being rejected. istioctl analyze reports the same issues for the proxy versions running in the cluster.`,
+		Example: `  # Check an EnvoyFilter against the proxy version of this istioctl
+  istioctl x envoyfilter validate -f filter.yaml
+
+  # Check all EnvoyFilters in the cluster against proxy version 1.15
+  kubectl get envoyfilters -A -o yaml | istioctl x envoyfilter validate -f - --proxy-version 1.15`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if len(filenames) == 0 {
+				return fmt.Errorf("at least one file must be specified with -f")
+			}
+			v := model.ParseIstioVersion(proxyVersion)
+			if v == model.MaxIstioVersion {
+				return fmt.Errorf("invalid proxy version %q", proxyVersion)
+			}
+			var errs int
+			for _, f := range filenames {
+				n, err := validateEnvoyFilterFile(cmd.OutOrStdout(), f, v.Minor)
+				if err != nil {
+					return err
+				}
+				errs += n
+			}
+			if errs > 0 {
+				return fmt.Errorf("found %d EnvoyFilter patch(es) incompatible with proxy version %s", errs, proxyVersion)
+			}
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringSliceVarP(&filenames, "filename", "f", nil, "Names of files containing EnvoyFilters, or - for stdin")
+	cmd.PersistentFlags().StringVar(&proxyVersion, "proxy-version", version.Info.Version,
+		"Istio version of the target proxies, for example 1.15")
+	return cmd
+}
+
+// validateEnvoyFilterFile prints the compatibility issues of every EnvoyFilter in the file and returns the number
+// of patches that would be rejected.
+func validateEnvoyFilterFile(w io.Writer, filename string, minor int) (int, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if filename == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("cannot read file %q: %v", filename, err)
+	}
+	configs, _, err := crd.ParseInputs(string(b))
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse file %q: %v", filename, err)
+	}
+	rejected := 0
+	for _, c := range configs {
+		if c.GroupVersionKind != gvk.EnvoyFilter {
+			continue
+		}
+		ef := c.Spec.(*networking.EnvoyFilter)
+		found := false
+		for i, cp := range ef.ConfigPatches {
+			removed := false
+			for _, issue := range xds.CheckEnvoyFilterCompatibility(cp, minor) {
+				severity := "Warning"
+				if issue.Removed {
+					severity = "Error"
+					removed = true
+				}
+				fmt.Fprintf(w, "%s [EnvoyFilter %s/%s configPatches[%d].%s] %s\n",
+					severity, c.Namespace, c.Name, i, issue.Path, issue.Message)
+				found = true
+			}
+			if removed {
+				rejected++
+			}
+		}
+		if !found {
+			fmt.Fprintf(w, "EnvoyFilter %s/%s is compatible with proxy version 1.%d\n", c.Namespace, c.Name, minor)
+		}
+	}
+	return rejected, nil
+}
@@ -0,0 +1,139 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const envoyFilterV2TypedConfig = `apiVersion: networking.istio.io/v1alpha3
+kind: EnvoyFilter
+metadata:
+  name: lua
+  namespace: default
+spec:
+  configPatches:
+  - applyTo: HTTP_FILTER
+    patch:
+      operation: INSERT_BEFORE
+      value:
+        name: envoy.filters.http.lua
+        typed_config:
+          "@type": type.googleapis.com/envoy.config.filter.http.lua.v2.Lua
+          inlineCode: "function envoy_on_request(h) end"
+`
+
+const envoyFilterDeprecatedField = `apiVersion: networking.istio.io/v1alpha3
+kind: EnvoyFilter
+metadata:
+  name: h2
+  namespace: default
+spec:
+  configPatches:
+  - applyTo: CLUSTER
+    patch:
+      operation: MERGE
+      value:
+        http2_protocol_options: {}
+`
+
+const envoyFilterCompatible = `apiVersion: networking.istio.io/v1alpha3
+kind: EnvoyFilter
+metadata:
+  name: lua
+  namespace: default
+spec:
+  configPatches:
+  - applyTo: HTTP_FILTER
+    patch:
+      operation: INSERT_BEFORE
+      value:
+        name: envoy.filters.http.lua
+        typed_config:
+          "@type": type.googleapis.com/envoy.extensions.filters.http.lua.v3.Lua
+          defaultSourceCode:
+            inlineString: "function envoy_on_request(h) end"
+`
+
+func TestValidateEnvoyFilterFile(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		minor    int
+		rejected int
+		want     []string
+	}{
+		{
+			name:     "v2 type removed",
+			input:    envoyFilterV2TypedConfig,
+			minor:    15,
+			rejected: 1,
+			want:     []string{"Error [EnvoyFilter default/lua configPatches[0].patch.value.typed_config.@type]", "removed in proxy version 1.10"},
+		},
+		{
+			name:  "v2 type deprecated",
+			input: envoyFilterV2TypedConfig,
+			minor: 9,
+			want:  []string{"Warning [EnvoyFilter default/lua", "deprecated since proxy version 1.8"},
+		},
+		{
+			name:  "deprecated field",
+			input: envoyFilterDeprecatedField,
+			minor: 15,
+			want:  []string{"Warning [EnvoyFilter default/h2 configPatches[0].patch.value.http2_protocol_options]"},
+		},
+		{
+			name:  "compatible",
+			input: envoyFilterCompatible,
+			minor: 15,
+			want:  []string{"EnvoyFilter default/lua is compatible with proxy version 1.15"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f := filepath.Join(t.TempDir(), "envoyfilter.yaml")
+			if err := os.WriteFile(f, []byte(c.input), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			rejected, err := validateEnvoyFilterFile(&out, f, c.minor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rejected != c.rejected {
+				t.Errorf("got %d rejected patches, want %d\n%s", rejected, c.rejected, out.String())
+			}
+			for _, w := range c.want {
+				if !strings.Contains(out.String(), w) {
+					t.Errorf("output %q does not contain %q", out.String(), w)
+				}
+			}
+		})
+	}
+}
+
+func TestEnvoyFilterValidateInvalidVersion(t *testing.T) {
+	cmd := envoyFilterValidateCmd()
+	cmd.SetArgs([]string{"-f", "-", "--proxy-version", "not-a-version"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "invalid proxy version") {
+		t.Fatalf("expected invalid proxy version error, got %v", err)
+	}
+}
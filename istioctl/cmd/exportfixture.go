@@ -0,0 +1,308 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/istioctl/pkg/util/configdump"
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/simulation/fixture"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// workloadPolicyKinds are the kinds of the configs applying to the workloads of their namespace, or of all the
+// namespaces in the root namespace. The other configs apply to the namespaces they are exported to.
+var workloadPolicyKinds = sets.New(
+	gvk.Sidecar.Kind,
+	gvk.EnvoyFilter.Kind,
+	gvk.PeerAuthentication.Kind,
+	gvk.RequestAuthentication.Kind,
+	gvk.AuthorizationPolicy.Kind,
+	gvk.Telemetry.Kind,
+	gvk.WasmPlugin.Kind,
+	gvk.ProxyConfig.Kind,
+)
+
+func exportFixtureCommand() *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var pod, outputFile string
+	cmd := &cobra.Command{
+		Use:   "export-fixture",
+		Short: "Exports the configuration inputs of a workload as a simulation test fixture",
+		Long: `Exports what istiod generates the configuration of the proxy of a pod from, so that it can be reproduced
+offline: the xDS node of the proxy, the mesh config, the Istio configs relevant to the pod and the Kubernetes services.
+Configs applying to workloads, such as Sidecars and AuthorizationPolicies, are relevant if they are in the namespace of
+the pod or in the root namespace, and the other configs if they are exported to the namespace of the pod.
+
+The fixture can be attached to an issue, and loaded by the pilot simulation tests with
+simulation.NewSimulationFromFixture. It contains the configs as they are in the cluster, review it before sharing it.`,
+		Example: `  # Export the fixture of a pod to a file
+  istioctl x export-fixture --pod productpage-v1-7d9d8d8b8d-x2x6k.default -o fixture.yaml`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("export-fixture takes no arguments")
+			}
+			if pod == "" {
+				return fmt.Errorf("--pod must be specified")
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			podName, podNamespace, err := getPodName(pod)
+			if err != nil {
+				return err
+			}
+			kubeClient, err := kubeClientWithRevision(kubeconfig, configContext, opts.Revision)
+			if err != nil {
+				return fmt.Errorf("failed to create k8s client: %v", err)
+			}
+			f, err := exportFixture(kubeClient, podName, podNamespace)
+			if err != nil {
+				return err
+			}
+			out, err := yaml.Marshal(f)
+			if err != nil {
+				return err
+			}
+			if outputFile == "" {
+				_, err = c.OutOrStdout().Write(out)
+				return err
+			}
+			return os.WriteFile(outputFile, out, 0o644)
+		},
+	}
+	opts.AttachControlPlaneFlags(cmd)
+	cmd.Flags().StringVar(&pod, "pod", "", "Pod of the workload, as <pod-name[.namespace]>")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "File to write the fixture to, instead of stdout")
+	return cmd
+}
+
+// exportFixture collects the fixture of the proxy of the pod from the proxy, istiod and the Kubernetes API server.
+func exportFixture(kubeClient kube.ExtendedClient, podName, podNamespace string) (*fixture.Fixture, error) {
+	dump, err := kubeClient.EnvoyDo(context.TODO(), podName, podNamespace, "GET", "config_dump")
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute command on %s.%s sidecar: %v", podName, podNamespace, err)
+	}
+	f, err := fixtureNode(dump)
+	if err != nil {
+		return nil, fmt.Errorf("%s.%s: %v", podName, podNamespace, err)
+	}
+
+	meshConfigs, err := kubeClient.AllDiscoveryDo(context.TODO(), istioNamespace, "/debug/mesh")
+	if err != nil {
+		return nil, err
+	}
+	istiod, meshConfig := firstIstiodResponse(meshConfigs)
+	if istiod == "" {
+		return nil, fmt.Errorf("no istiod found in namespace %s", istioNamespace)
+	}
+	meshYAML, err := yaml.JSONToYAML(meshConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the mesh config of %s: %v", istiod, err)
+	}
+	f.MeshConfig = string(meshYAML)
+	rootNamespace := struct {
+		RootNamespace string `json:"rootNamespace"`
+	}{}
+	if err := json.Unmarshal(meshConfig, &rootNamespace); err != nil {
+		return nil, fmt.Errorf("failed to parse the mesh config of %s: %v", istiod, err)
+	}
+
+	// The istiods of a revision watch the same configs, any of them is used.
+	configs, err := kubeClient.AllDiscoveryDo(context.TODO(), istioNamespace, "/debug/configz")
+	if err != nil {
+		return nil, err
+	}
+	istiod, configz := firstIstiodResponse(configs)
+	var parsed []crd.IstioKind
+	if err := json.Unmarshal(configz, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse the configs of %s: %v", istiod, err)
+	}
+	if f.Configs, err = yamlDocuments(relevantConfigs(parsed, podNamespace, rootNamespace.RootNamespace)); err != nil {
+		return nil, err
+	}
+
+	services, err := kubeClient.Kube().CoreV1().Services(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	p, err := kubeClient.Kube().CoreV1().Pods(podNamespace).Get(context.TODO(), podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]any, 0, len(services.Items)+1)
+	for i := range services.Items {
+		svc := &services.Items[i]
+		svc.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Service"}
+		svc.ObjectMeta = exportedObjectMeta(svc.ObjectMeta)
+		objects = append(objects, svc)
+	}
+	p.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+	p.ObjectMeta = exportedObjectMeta(p.ObjectMeta)
+	objects = append(objects, p)
+	if f.KubernetesObjects, err = yamlDocuments(objects); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// bootstrapNode returns the xDS node of the bootstrap config in the Envoy config dump.
+func bootstrapNode(dump []byte) (*core.Node, error) {
+	cd := &configdump.Wrapper{}
+	if err := cd.UnmarshalJSON(dump); err != nil {
+		return nil, err
+	}
+	bootstrap, err := cd.GetBootstrapConfigDump()
+	if err != nil {
+		return nil, err
+	}
+	node := bootstrap.GetBootstrap().GetNode()
+	if node.GetId() == "" {
+		return nil, fmt.Errorf("the bootstrap config has no node")
+	}
+	return node, nil
+}
+
+// fixtureNode returns a fixture with the xDS node of the bootstrap config in the Envoy config dump.
+func fixtureNode(dump []byte) (*fixture.Fixture, error) {
+	node, err := bootstrapNode(dump)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := model.ParseMetadata(node.GetMetadata())
+	if err != nil {
+		return nil, err
+	}
+	f := &fixture.Fixture{Node: node.GetId(), Metadata: meta}
+	// The DNS domain of the node is <namespace>.svc.<domain suffix>.
+	if parts := strings.Split(node.GetId(), "~"); len(parts) == 4 {
+		if _, suffix, found := strings.Cut(parts[3], ".svc."); found {
+			f.DomainSuffix = suffix
+		}
+	}
+	return f, nil
+}
+
+// firstIstiodResponse returns the response of the first istiod by name, for the responses all istiods agree on.
+func firstIstiodResponse(responses map[string][]byte) (string, []byte) {
+	istiods := make([]string, 0, len(responses))
+	for istiod := range responses {
+		istiods = append(istiods, istiod)
+	}
+	if len(istiods) == 0 {
+		return "", nil
+	}
+	sort.Strings(istiods)
+	return istiods[0], responses[istiods[0]]
+}
+
+// relevantConfigs returns the configs relevant to the workloads of the namespace, ordered by kind, namespace and name.
+func relevantConfigs(configs []crd.IstioKind, namespace, rootNamespace string) []any {
+	sort.SliceStable(configs, func(i, j int) bool {
+		if configs[i].Kind != configs[j].Kind {
+			return configs[i].Kind < configs[j].Kind
+		}
+		if configs[i].Namespace != configs[j].Namespace {
+			return configs[i].Namespace < configs[j].Namespace
+		}
+		return configs[i].Name < configs[j].Name
+	})
+	out := make([]any, 0, len(configs))
+	for i := range configs {
+		cfg := &configs[i]
+		if workloadPolicyKinds.Contains(cfg.Kind) {
+			if cfg.Namespace != namespace && cfg.Namespace != rootNamespace {
+				continue
+			}
+		} else if !exportedTo(cfg, namespace) {
+			continue
+		}
+		cfg.ObjectMeta = exportedObjectMeta(cfg.ObjectMeta)
+		cfg.Status = nil
+		out = append(out, cfg)
+	}
+	return out
+}
+
+// exportedTo returns whether the config is exported to the namespace, by its exportTo field. Configs without one are
+// exported to all namespaces, unless the mesh config changes the default.
+func exportedTo(cfg *crd.IstioKind, namespace string) bool {
+	exportTo, ok := cfg.Spec["exportTo"].([]any)
+	if !ok || len(exportTo) == 0 {
+		return true
+	}
+	for _, e := range exportTo {
+		switch e {
+		case "*", namespace:
+			return true
+		case ".":
+			if cfg.Namespace == namespace {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// exportedObjectMeta returns the metadata of an object without the fields the API server manages, except the
+// creation timestamp, which orders the configs, and without the copy of the object kubectl apply annotates it with.
+func exportedObjectMeta(meta metav1.ObjectMeta) metav1.ObjectMeta {
+	var annotations map[string]string
+	for k, v := range meta.Annotations {
+		if k == corev1.LastAppliedConfigAnnotation {
+			continue
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[k] = v
+	}
+	return metav1.ObjectMeta{
+		Name:              meta.Name,
+		Namespace:         meta.Namespace,
+		Labels:            meta.Labels,
+		Annotations:       annotations,
+		CreationTimestamp: meta.CreationTimestamp,
+	}
+}
+
+// yamlDocuments marshals the objects as YAML documents.
+func yamlDocuments(objects []any) (string, error) {
+	docs := make([]string, 0, len(objects))
+	for _, o := range objects {
+		b, err := yaml.Marshal(o)
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, string(b))
+	}
+	return strings.Join(docs, "---\n"), nil
+}
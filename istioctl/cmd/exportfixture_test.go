@@ -0,0 +1,69 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestRelevantConfigs(t *testing.T) {
+	istioKind := func(kind, namespace, name string, exportTo ...any) crd.IstioKind {
+		spec := map[string]any{}
+		if len(exportTo) > 0 {
+			spec["exportTo"] = exportTo
+		}
+		return crd.IstioKind{
+			TypeMeta: metav1.TypeMeta{Kind: kind},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       namespace,
+				ResourceVersion: "1",
+				Annotations:     map[string]string{corev1.LastAppliedConfigAnnotation: "{}"},
+			},
+			Spec: spec,
+		}
+	}
+	configs := []crd.IstioKind{
+		istioKind("VirtualService", "other", "private", "."),
+		istioKind("VirtualService", "other", "public"),
+		istioKind("VirtualService", "other", "to-app", "app"),
+		istioKind("VirtualService", "app", "local", "."),
+		istioKind("Sidecar", "other", "default"),
+		istioKind("Sidecar", "app", "default"),
+		istioKind("AuthorizationPolicy", "istio-system", "deny-all"),
+		istioKind("DestinationRule", "other", "hidden", "third"),
+	}
+
+	var got []string
+	for _, c := range relevantConfigs(configs, "app", "istio-system") {
+		cfg := c.(*crd.IstioKind)
+		assert.Equal(t, cfg.ResourceVersion, "")
+		assert.Equal(t, len(cfg.Annotations), 0)
+		got = append(got, cfg.Kind+"/"+cfg.Namespace+"/"+cfg.Name)
+	}
+	assert.Equal(t, got, []string{
+		"AuthorizationPolicy/istio-system/deny-all",
+		"Sidecar/app/default",
+		"VirtualService/app/local",
+		"VirtualService/other/public",
+		"VirtualService/other/to-app",
+	})
+}
@@ -0,0 +1,274 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	networking "istio.io/api/networking/v1beta1"
+	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// certExpiryWarning is how close to expiry a certificate is reported.
+const certExpiryWarning = 30 * 24 * time.Hour
+
+type gatewaySecretArgs struct {
+	name     string
+	certFile string
+	keyFile  string
+	caFile   string
+	gateway  string
+	timeout  time.Duration
+	force    bool
+}
+
+func createGatewaySecretCmd() *cobra.Command {
+	args := &gatewaySecretArgs{}
+	cmd := &cobra.Command{
+		Use:   "create-gateway-secret <name> --cert <file> --key <file>",
+		Short: "Validate and create a TLS secret for a gateway",
+		Long: `Creates the Kubernetes TLS secret referenced by the credentialName of a Gateway server, after validating
+that the certificate chain is ordered from the leaf to the issuers, that the private key matches the leaf certificate,
+that the certificates have not expired and, with --gateway, that the certificate covers the hosts of the servers using
+the secret. With --gateway, the command then waits until the gateway pods have received the certificate over SDS.
+
+The secret is created in the namespace of the gateway pods.`,
+		Example: `  # Create the secret "httpbin-credential" for the ingress gateway
+  istioctl x create-gateway-secret httpbin-credential -n istio-system --cert httpbin.crt --key httpbin.key
+
+  # Create a secret with a CA certificate for mutual TLS and wait for the gateway to load it
+  istioctl x create-gateway-secret httpbin-credential -n istio-system --cert httpbin.crt --key httpbin.key \
+    --cacert ca.crt --gateway httpbin-gateway.default`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, positional []string) error {
+			args.name = positional[0]
+			if args.certFile == "" || args.keyFile == "" {
+				return fmt.Errorf("--cert and --key are required")
+			}
+			client, err := kubeClient(kubeconfig, configContext)
+			if err != nil {
+				return fmt.Errorf("failed to create k8s client: %w", err)
+			}
+			return createGatewaySecret(cmd.OutOrStdout(), client, handlers.HandleNamespace(namespace, defaultNamespace), args)
+		},
+	}
+	cmd.Flags().StringVar(&args.certFile, "cert", "", "PEM file with the certificate chain, leaf first")
+	cmd.Flags().StringVar(&args.keyFile, "key", "", "PEM file with the private key of the leaf certificate")
+	cmd.Flags().StringVar(&args.caFile, "cacert", "", "PEM file with the CA certificates used to verify clients, for mutual TLS")
+	cmd.Flags().StringVar(&args.gateway, "gateway", "",
+		"Gateway using the secret, as <name>[.<namespace>]. The certificate is checked against the hosts of its servers "+
+			"and the command waits for its pods to load the certificate")
+	cmd.Flags().DurationVar(&args.timeout, "timeout", 30*time.Second, "How long to wait for the gateway pods to load the certificate")
+	cmd.Flags().BoolVar(&args.force, "force", false, "Replace the secret if it already exists")
+	return cmd
+}
+
+func createGatewaySecret(w io.Writer, client kube.ExtendedClient, ns string, args *gatewaySecretArgs) error {
+	certPEM, err := os.ReadFile(args.certFile)
+	if err != nil {
+		return err
+	}
+	keyPEM, err := os.ReadFile(args.keyFile)
+	if err != nil {
+		return err
+	}
+	var caPEM []byte
+	if args.caFile != "" {
+		if caPEM, err = os.ReadFile(args.caFile); err != nil {
+			return err
+		}
+	}
+
+	var gw *networking.Gateway
+	if args.gateway != "" {
+		gwName, gwNamespace := handlers.InferPodInfo(args.gateway, ns)
+		g, err := client.Istio().NetworkingV1beta1().Gateways(gwNamespace).Get(context.TODO(), gwName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get gateway %s/%s: %v", gwNamespace, gwName, err)
+		}
+		gw = &g.Spec
+	}
+
+	warnings, err := validateGatewayCertificate(certPEM, keyPEM, caPEM, gatewayHosts(gw, args.name), time.Now())
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "Warning: %s\n", warning)
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: args.name, Namespace: ns},
+		Type:       v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			v1.TLSCertKey:       certPEM,
+			v1.TLSPrivateKeyKey: keyPEM,
+		},
+	}
+	if caPEM != nil {
+		secret.Data["ca.crt"] = caPEM
+	}
+	secrets := client.Kube().CoreV1().Secrets(ns)
+	action := "created"
+	if _, err := secrets.Create(context.TODO(), secret, metav1.CreateOptions{}); err != nil {
+		if !errors.IsAlreadyExists(err) || !args.force {
+			return fmt.Errorf("failed to create secret %s/%s: %v", ns, args.name, err)
+		}
+		if _, err := secrets.Update(context.TODO(), secret, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update secret %s/%s: %v", ns, args.name, err)
+		}
+		action = "updated"
+	}
+	fmt.Fprintf(w, "Secret %s/%s %s\n", ns, args.name, action)
+
+	if gw == nil {
+		return nil
+	}
+	return waitForGatewayCertificate(w, client, ns, gw.Selector, certPEM, args.timeout)
+}
+
+// gatewayHosts returns the hosts of the gateway servers whose credentialName is the secret.
+func gatewayHosts(gw *networking.Gateway, secret string) []string {
+	var hosts []string
+	for _, s := range gw.GetServers() {
+		if s.GetTls().GetCredentialName() != secret {
+			continue
+		}
+		for _, h := range s.Hosts {
+			// Strip the namespace of hosts in the namespace/host format.
+			if i := strings.Index(h, "/"); i >= 0 {
+				h = h[i+1:]
+			}
+			if h != "*" {
+				hosts = append(hosts, h)
+			}
+		}
+	}
+	return hosts
+}
+
+// validateGatewayCertificate returns an error if the certificate chain and key cannot be served for the hosts,
+// and warnings for certificates close to expiry.
+func validateGatewayCertificate(certPEM, keyPEM, caPEM []byte, hosts []string, now time.Time) ([]string, error) {
+	chain, err := util.ParsePemEncodedCertificateChain(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate: %v", err)
+	}
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		return nil, fmt.Errorf("private key does not match the leaf certificate: %v", err)
+	}
+	var warnings []string
+	for i, c := range chain {
+		if now.After(c.NotAfter) {
+			return nil, fmt.Errorf("certificate %d (%s) expired on %v", i, c.Subject, c.NotAfter)
+		}
+		if now.Before(c.NotBefore) {
+			return nil, fmt.Errorf("certificate %d (%s) is not valid before %v", i, c.Subject, c.NotBefore)
+		}
+		if c.NotAfter.Sub(now) < certExpiryWarning {
+			warnings = append(warnings, fmt.Sprintf("certificate %d (%s) expires on %v", i, c.Subject, c.NotAfter))
+		}
+		if i+1 < len(chain) {
+			if err := c.CheckSignatureFrom(chain[i+1]); err != nil {
+				return nil, fmt.Errorf("certificate %d (%s) is not signed by the next certificate in the chain (%s); "+
+					"the chain must be ordered from the leaf to the issuers: %v", i, c.Subject, chain[i+1].Subject, err)
+			}
+		}
+	}
+	leaf := chain[0]
+	for _, h := range hosts {
+		if !certificateCoversHost(leaf, h) {
+			return nil, fmt.Errorf("certificate SANs %v do not cover gateway host %q", leaf.DNSNames, h)
+		}
+	}
+	if caPEM != nil {
+		if _, err := util.ParsePemEncodedCertificateChain(caPEM); err != nil {
+			return nil, fmt.Errorf("invalid CA certificate: %v", err)
+		}
+	}
+	return warnings, nil
+}
+
+// certificateCoversHost returns true if the certificate is valid for the gateway host, which may be a wildcard.
+func certificateCoversHost(cert *x509.Certificate, host string) bool {
+	if strings.HasPrefix(host, "*.") {
+		for _, n := range cert.DNSNames {
+			if strings.EqualFold(n, host) {
+				return true
+			}
+		}
+		return false
+	}
+	return cert.VerifyHostname(host) == nil
+}
+
+// waitForGatewayCertificate waits until all gateway pods have the certificate in their active SDS secrets.
+func waitForGatewayCertificate(w io.Writer, client kube.ExtendedClient, ns string, selector map[string]string,
+	certPEM []byte, timeout time.Duration,
+) error {
+	pods, err := client.Kube().CoreV1().Pods(ns).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: klabels.SelectorFromSet(selector).String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list gateway pods: %v", err)
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("no gateway pods in namespace %s match the gateway selector %v", ns, selector)
+	}
+	// The config dump holds the certificate chain base64 encoded, exactly as read from the secret.
+	encoded := []byte(base64.StdEncoding.EncodeToString(certPEM))
+	deadline := time.Now().Add(timeout)
+	pending := map[string]struct{}{}
+	for _, p := range pods.Items {
+		pending[p.Name] = struct{}{}
+	}
+	for {
+		for name := range pending {
+			dump, err := client.EnvoyDo(context.TODO(), name, ns, "GET", "config_dump?resource=dynamic_active_secrets")
+			if err == nil && bytes.Contains(dump, encoded) {
+				fmt.Fprintf(w, "Gateway pod %s.%s loaded the certificate\n", name, ns)
+				delete(pending, name)
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			names := make([]string, 0, len(pending))
+			for name := range pending {
+				names = append(names, name)
+			}
+			return fmt.Errorf("timed out waiting for gateway pods %v to load the certificate", names)
+		}
+		time.Sleep(time.Second)
+	}
+}
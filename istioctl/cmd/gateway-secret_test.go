@@ -0,0 +1,181 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	networking "istio.io/api/networking/v1beta1"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func genCert(t *testing.T, opts util.CertOptions) ([]byte, []byte, *x509.Certificate) {
+	t.Helper()
+	opts.RSAKeySize = 2048
+	certPEM, keyPEM, err := util.GenCertKeyFromOptions(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certPEM, keyPEM, cert
+}
+
+func TestValidateGatewayCertificate(t *testing.T) {
+	now := time.Now()
+	caPEM, caKeyPEM, ca := genCert(t, util.CertOptions{
+		Org: "ca", NotBefore: now, TTL: 365 * 24 * time.Hour, IsCA: true, IsSelfSigned: true,
+	})
+	caKey, err := util.ParsePemEncodedKey(caKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafPEM, leafKeyPEM, _ := genCert(t, util.CertOptions{
+		Org: "leaf", NotBefore: now, TTL: 90 * 24 * time.Hour, IsServer: true,
+		SignerCert: ca, SignerPriv: caKey, DNSNames: "*.example.com,foo.test",
+	})
+	shortPEM, shortKeyPEM, _ := genCert(t, util.CertOptions{
+		Org: "short", NotBefore: now, TTL: 24 * time.Hour, IsServer: true,
+		SignerCert: ca, SignerPriv: caKey, DNSNames: "foo.test",
+	})
+	_, otherKeyPEM, _ := genCert(t, util.CertOptions{Org: "other", NotBefore: now, TTL: time.Hour, IsSelfSigned: true})
+
+	cases := []struct {
+		name     string
+		cert     []byte
+		key      []byte
+		hosts    []string
+		now      time.Time
+		warnings int
+		err      string
+	}{
+		{name: "valid chain", cert: join(leafPEM, caPEM), key: leafKeyPEM, hosts: []string{"a.example.com", "foo.test", "*.example.com"}},
+		{name: "leaf only", cert: leafPEM, key: leafKeyPEM},
+		{name: "wrong order", cert: join(caPEM, leafPEM), key: leafKeyPEM, err: "does not match"},
+		{name: "ca before leaf with ca key", cert: join(caPEM, leafPEM), key: caKeyPEM, err: "must be ordered"},
+		{name: "mismatched key", cert: leafPEM, key: otherKeyPEM, err: "does not match"},
+		{name: "uncovered host", cert: leafPEM, key: leafKeyPEM, hosts: []string{"bar.test"}, err: "do not cover"},
+		{name: "uncovered wildcard host", cert: leafPEM, key: leafKeyPEM, hosts: []string{"*.test"}, err: "do not cover"},
+		{name: "expired", cert: leafPEM, key: leafKeyPEM, now: now.Add(100 * 24 * time.Hour), err: "expired"},
+		{name: "not yet valid", cert: leafPEM, key: leafKeyPEM, now: now.Add(-time.Hour), err: "not valid before"},
+		{name: "expiring", cert: join(shortPEM, caPEM), key: shortKeyPEM, warnings: 1},
+		{name: "no certificate", cert: leafKeyPEM, key: leafKeyPEM, err: "invalid certificate"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			at := tt.now
+			if at.IsZero() {
+				at = now.Add(time.Minute)
+			}
+			warnings, err := validateGatewayCertificate(tt.cert, tt.key, nil, tt.hosts, at)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(warnings) != tt.warnings {
+				t.Fatalf("expected %d warnings, got %v", tt.warnings, warnings)
+			}
+		})
+	}
+}
+
+func TestGatewayHosts(t *testing.T) {
+	gw := &networking.Gateway{
+		Servers: []*networking.Server{
+			{
+				Hosts: []string{"default/foo.example.com", "bar.example.com"},
+				Tls:   &networking.ServerTLSSettings{CredentialName: "cred"},
+			},
+			{
+				Hosts: []string{"*"},
+				Tls:   &networking.ServerTLSSettings{CredentialName: "cred"},
+			},
+			{
+				Hosts: []string{"other.example.com"},
+				Tls:   &networking.ServerTLSSettings{CredentialName: "other"},
+			},
+			{
+				Hosts: []string{"plain.example.com"},
+			},
+		},
+	}
+	got := gatewayHosts(gw, "cred")
+	want := []string{"foo.example.com", "bar.example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func join(pems ...[]byte) []byte {
+	var out []byte
+	for _, p := range pems {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func TestCreateGatewaySecret(t *testing.T) {
+	certPEM, keyPEM, _ := genCert(t, util.CertOptions{
+		Org: "leaf", NotBefore: time.Now(), TTL: 90 * 24 * time.Hour, IsServer: true, IsSelfSigned: true, DNSNames: "foo.test",
+	})
+	dir := t.TempDir()
+	args := &gatewaySecretArgs{
+		name:     "foo-credential",
+		certFile: filepath.Join(dir, "tls.crt"),
+		keyFile:  filepath.Join(dir, "tls.key"),
+	}
+	if err := os.WriteFile(args.certFile, certPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(args.keyFile, keyPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	client := kube.NewFakeClient()
+
+	out := &bytes.Buffer{}
+	if err := createGatewaySecret(out, client, "istio-system", args); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "Secret istio-system/foo-credential created\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+	if err := createGatewaySecret(out, client, "istio-system", args); err == nil {
+		t.Error("expected an existing secret to be rejected without --force")
+	}
+
+	args.force = true
+	out.Reset()
+	if err := createGatewaySecret(out, client, "istio-system", args); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "Secret istio-system/foo-credential updated\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+}
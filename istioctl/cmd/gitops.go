@@ -0,0 +1,93 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/gitops"
+	"istio.io/istio/istioctl/pkg/util/handlers"
+)
+
+func gitopsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gitops",
+		Short: "Compare the Istio configuration of the cluster with a Git checkout",
+	}
+	cmd.AddCommand(gitopsDiffCommand())
+	return cmd
+}
+
+func gitopsDiffCommand() *cobra.Command {
+	var dir string
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Report the Istio configuration which drifted from a directory",
+		Long: `Compares the Istio configuration in the cluster with the YAML and JSON files of a directory, such as a Git
+checkout, and reports the resources which are modified in the cluster, missing from the cluster, or present in the
+cluster only. The namespaces of the resources in the directory are compared.
+
+Only the spec and the labels and annotations set in the directory are compared, so fields set by the API server or by
+controllers are not reported. Default values are normalized, so a field set to its default in one version and unset in
+the other is not reported either. The command fails if any drift is found.`,
+		Example: `  # Compare the cluster with the configuration in ./mesh-config
+  istioctl x gitops diff --dir ./mesh-config
+
+  # Place resources without a namespace in the bookinfo namespace
+  istioctl x gitops diff --dir ./mesh-config -n bookinfo`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dir == "" {
+				return fmt.Errorf("--dir must be set")
+			}
+			local, err := gitops.ReadDirectory(dir, handlers.HandleNamespace(namespace, defaultNamespace))
+			if err != nil {
+				return err
+			}
+			client, err := kubeClient(kubeconfig, configContext)
+			if err != nil {
+				return err
+			}
+			live, err := gitops.ReadCluster(client.Dynamic(), gitops.Namespaces(local))
+			if err != nil {
+				return err
+			}
+			drifts, err := gitops.Diff(local, live)
+			if err != nil {
+				return err
+			}
+			return writeDrifts(cmd.OutOrStdout(), drifts)
+		},
+	}
+	cmd.PersistentFlags().StringVar(&dir, "dir", "", "Directory containing the desired Istio configuration")
+	return cmd
+}
+
+func writeDrifts(out io.Writer, drifts []gitops.Drift) error {
+	if len(drifts) == 0 {
+		_, _ = fmt.Fprintln(out, "No drift found")
+		return nil
+	}
+	for _, d := range drifts {
+		_, _ = fmt.Fprintln(out, d)
+		if d.Diff != "" {
+			_, _ = fmt.Fprintln(out, d.Diff)
+		}
+	}
+	return fmt.Errorf("found drift in %d resources", len(drifts))
+}
@@ -0,0 +1,482 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	prommodel "github.com/prometheus/common/model"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/api/security/v1beta1"
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/pilot/pkg/config/kube/crdclient"
+	authnv1beta1 "istio.io/istio/pilot/pkg/security/authn/v1beta1"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/kube"
+	"istio.io/pkg/log"
+)
+
+func peerAuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "peerauth",
+		Short: "Commands to inspect PeerAuthentication resources",
+	}
+	cmd.AddCommand(peerAuthPreviewCmd())
+	return cmd
+}
+
+func peerAuthPreviewCmd() *cobra.Command {
+	var (
+		opts      clioptions.ControlPlaneOptions
+		filenames []string
+		window    time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "preview -f FILENAME [--window DURATION]",
+		Short: "Preview the impact of a PeerAuthentication change before applying it",
+		Long: `Preview applies the PeerAuthentication resources of the files over the ones of the cluster, and lists the
+workloads whose effective mTLS mode changes. For the workloads which start rejecting plaintext traffic, it lists the
+clients which sent plaintext traffic to them during the window, according to the telemetry of the Prometheus of the
+mesh, and the DestinationRules whose TLS settings conflict with the new mode. The command fails if the change would
+break clients, so it can gate the change in CI.`,
+		Example: `  # Preview the impact of enforcing STRICT mTLS in the foo namespace
+  istioctl x peerauth preview -f pa.yaml
+
+  # Look for plaintext clients in the telemetry of the last day
+  istioctl x peerauth preview -f pa.yaml --window 24h`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if len(filenames) == 0 {
+				return fmt.Errorf("at least one file must be specified with -f")
+			}
+			var proposed []config.Config
+			for _, f := range filenames {
+				configs, err := readConfigsOfKind(f, gvk.PeerAuthentication)
+				if err != nil {
+					return err
+				}
+				proposed = append(proposed, configs...)
+			}
+			if len(proposed) == 0 {
+				return fmt.Errorf("no PeerAuthentication resources found in %s", strings.Join(filenames, ", "))
+			}
+			client, err := kubeClientWithRevision(kubeconfig, configContext, opts.Revision)
+			if err != nil {
+				return fmt.Errorf("failed to create k8s client: %v", err)
+			}
+			meshCfg, err := getMeshConfig(client)
+			if err != nil {
+				return fmt.Errorf("failed to fetch mesh config: %v", err)
+			}
+			p, err := fetchPeerAuthPreview(client, meshCfg.GetRootNamespace(),
+				handlers.HandleNamespace(namespace, defaultNamespace), proposed)
+			if err != nil {
+				return err
+			}
+
+			changes := p.mtlsModeChanges()
+			var promAPI promv1.API
+			if rejectsPlaintext(changes) {
+				var fw kube.PortForwarder
+				promAPI, fw, err = connectPrometheus(client)
+				if err != nil {
+					fmt.Fprintf(cmd.OutOrStdout(), "Warning: the plaintext clients cannot be looked up in the telemetry: %v\n", err)
+				} else {
+					defer fw.Close()
+				}
+			}
+			broken, err := printPeerAuthPreview(cmd.OutOrStdout(), p, changes, promAPI, window)
+			if err != nil {
+				return err
+			}
+			if broken > 0 {
+				return fmt.Errorf("the proposed change would break %d client(s) or DestinationRule(s)", broken)
+			}
+			return nil
+		},
+	}
+	opts.AttachControlPlaneFlags(cmd)
+	cmd.PersistentFlags().StringSliceVarP(&filenames, "filename", "f", nil,
+		"Names of files containing the proposed PeerAuthentication resources, or - for stdin")
+	cmd.PersistentFlags().DurationVar(&window, "window", time.Hour,
+		"Time window of the telemetry plaintext clients are looked up in")
+	return cmd
+}
+
+// peerAuthPreview is the state of the cluster a PeerAuthentication change is previewed against.
+type peerAuthPreview struct {
+	rootNamespace string
+	// current are the PeerAuthentication resources of the cluster, proposed the ones of the change.
+	current, proposed []config.Config
+	// pods and services are the ones of the namespaces the change may apply to.
+	pods             []v1.Pod
+	services         []v1.Service
+	destinationRules []config.Config
+}
+
+// fetchPeerAuthPreview reads the state of the cluster the proposed PeerAuthentication resources may change. Proposed
+// resources without a namespace are in the default namespace.
+func fetchPeerAuthPreview(client kube.ExtendedClient, rootNamespace, defaultNs string, proposed []config.Config,
+) (*peerAuthPreview, error) {
+	p := &peerAuthPreview{rootNamespace: rootNamespace}
+	namespaces := map[string]struct{}{}
+	for _, c := range proposed {
+		if c.Namespace == "" {
+			c.Namespace = defaultNs
+		}
+		p.proposed = append(p.proposed, c)
+		namespaces[c.Namespace] = struct{}{}
+	}
+	// A change of the root namespace may apply to all the namespaces.
+	if _, ok := namespaces[rootNamespace]; ok {
+		namespaces = map[string]struct{}{metav1.NamespaceAll: {}}
+	}
+
+	pas, err := client.Istio().SecurityV1beta1().PeerAuthentications(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PeerAuthentication resources: %v", err)
+	}
+	for _, pa := range pas.Items {
+		p.current = append(p.current, crdclient.TranslateObject(pa, gvk.PeerAuthentication, ""))
+	}
+	drs, err := client.Istio().NetworkingV1alpha3().DestinationRules(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list DestinationRule resources: %v", err)
+	}
+	for _, dr := range drs.Items {
+		p.destinationRules = append(p.destinationRules, crdclient.TranslateObject(dr, gvk.DestinationRule, ""))
+	}
+	for ns := range namespaces {
+		pods, err := client.Kube().CoreV1().Pods(ns).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the pods of namespace %q: %v", ns, err)
+		}
+		p.pods = append(p.pods, pods.Items...)
+		services, err := client.Kube().CoreV1().Services(ns).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the services of namespace %q: %v", ns, err)
+		}
+		p.services = append(p.services, services.Items...)
+	}
+	return p, nil
+}
+
+// mtlsModeChange is the change of the effective PeerAuthentication of a workload.
+type mtlsModeChange struct {
+	name, namespace string
+	labels          map[string]string
+	before, after   *v1beta1.PeerAuthentication
+}
+
+func (c mtlsModeChange) workload() string {
+	return c.name + "." + c.namespace
+}
+
+// mtlsModeChanges returns the workloads with a sidecar whose effective mTLS mode changes, sorted by name. The pods
+// of a workload share their labels, so the first pod of each workload stands for it.
+func (p *peerAuthPreview) mtlsModeChanges() []mtlsModeChange {
+	after := applyProposedConfigs(p.current, p.proposed, "")
+	var changes []mtlsModeChange
+	seen := map[string]struct{}{}
+	for i := range p.pods {
+		pod := &p.pods[i]
+		if !hasProxyContainer(pod) {
+			continue
+		}
+		meta, _ := kube.GetDeployMetaFromPod(pod)
+		c := mtlsModeChange{name: meta.Name, namespace: pod.Namespace, labels: pod.Labels}
+		if _, ok := seen[c.workload()]; ok {
+			continue
+		}
+		seen[c.workload()] = struct{}{}
+		c.before = p.effectivePeerAuthentication(p.current, pod)
+		c.after = p.effectivePeerAuthentication(after, pod)
+		if describeMTLSMode(c.before) != describeMTLSMode(c.after) {
+			changes = append(changes, c)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].workload() < changes[j].workload()
+	})
+	return changes
+}
+
+func (p *peerAuthPreview) effectivePeerAuthentication(configs []config.Config, pod *v1.Pod) *v1beta1.PeerAuthentication {
+	var candidates []*config.Config
+	for i := range configs {
+		if configs[i].Namespace == pod.Namespace || configs[i].Namespace == p.rootNamespace {
+			candidates = append(candidates, &configs[i])
+		}
+	}
+	return authnv1beta1.ComposePeerAuthentication(p.rootNamespace, findMatchedConfigs(k8s_labels.Set(pod.Labels), candidates))
+}
+
+func hasProxyContainer(pod *v1.Pod) bool {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == proxyContainerName {
+			return true
+		}
+	}
+	return false
+}
+
+// mtlsModeForPort returns the mTLS mode of a port of the workload, or the one of the workload for port 0.
+func mtlsModeForPort(pa *v1beta1.PeerAuthentication, port uint32) v1beta1.PeerAuthentication_MutualTLS_Mode {
+	if mtls, ok := pa.PortLevelMtls[port]; ok {
+		return mtls.Mode
+	}
+	return pa.Mtls.Mode
+}
+
+// describeMTLSMode returns the mode of the workload followed by the ones of the ports, such as
+// "STRICT (port 8080: PERMISSIVE)".
+func describeMTLSMode(pa *v1beta1.PeerAuthentication) string {
+	if len(pa.PortLevelMtls) == 0 {
+		return pa.Mtls.Mode.String()
+	}
+	ports := make([]uint32, 0, len(pa.PortLevelMtls))
+	for port := range pa.PortLevelMtls {
+		ports = append(ports, port)
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+	levels := make([]string, 0, len(ports))
+	for _, port := range ports {
+		levels = append(levels, fmt.Sprintf("port %d: %s", port, pa.PortLevelMtls[port].Mode))
+	}
+	return fmt.Sprintf("%s (%s)", pa.Mtls.Mode, strings.Join(levels, ", "))
+}
+
+// startsRejectingPlaintext returns whether the workload or any of its ports is STRICT after the change only.
+func (c mtlsModeChange) startsRejectingPlaintext() bool {
+	ports := []uint32{0}
+	for port := range c.before.PortLevelMtls {
+		ports = append(ports, port)
+	}
+	for port := range c.after.PortLevelMtls {
+		ports = append(ports, port)
+	}
+	for _, port := range ports {
+		if mtlsModeForPort(c.after, port) == v1beta1.PeerAuthentication_MutualTLS_STRICT &&
+			mtlsModeForPort(c.before, port) != v1beta1.PeerAuthentication_MutualTLS_STRICT {
+			return true
+		}
+	}
+	return false
+}
+
+func rejectsPlaintext(changes []mtlsModeChange) bool {
+	for _, c := range changes {
+		if c.startsRejectingPlaintext() {
+			return true
+		}
+	}
+	return false
+}
+
+// connectPrometheus port forwards to the Prometheus of the mesh. Closing the returned port forwarder stops it.
+func connectPrometheus(client kube.ExtendedClient) (promv1.API, kube.PortForwarder, error) {
+	pl, err := client.PodsForSelector(context.TODO(), istioNamespace, "app=prometheus")
+	if err != nil {
+		return nil, nil, fmt.Errorf("not able to locate Prometheus pod: %v", err)
+	}
+	if len(pl.Items) < 1 {
+		return nil, nil, errors.New("no Prometheus pods found")
+	}
+	fw, err := client.NewPortForwarder(pl.Items[0].Name, istioNamespace, "", 0, 9090)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not build port forwarder for prometheus: %v", err)
+	}
+	if err = fw.Start(); err != nil {
+		return nil, nil, fmt.Errorf("failure running port forward process: %v", err)
+	}
+	closePortForwarderOnInterrupt(fw)
+	promAPI, err := prometheusAPI(fmt.Sprintf("http://%s", fw.Address()))
+	if err != nil {
+		fw.Close()
+		return nil, nil, err
+	}
+	return promAPI, fw, nil
+}
+
+// plaintextClients returns the workloads, as <name>.<namespace>, which sent plaintext requests or opened plaintext
+// connections to the workload during the window. Clients outside the mesh are reported as unknown.unknown.
+func plaintextClients(promAPI promv1.API, c mtlsModeChange, window time.Duration) ([]string, error) {
+	query := fmt.Sprintf(`sum(rate({__name__=~"%s|istio_tcp_connections_opened_total",reporter="destination",`+
+		`connection_security_policy="none",%s="%s",%s="%s"}[%s])) by (source_workload, source_workload_namespace)`,
+		reqTot, destWorkloadLabel, c.name, destWorkloadNamespaceLabel, c.namespace, prommodel.Duration(window))
+	val, _, err := promAPI.Query(context.Background(), query, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("query() failure for '%s': %v", query, err)
+	}
+	log.Debugf("executing query: %s  result:%s", query, val)
+	v, ok := val.(prommodel.Vector)
+	if !ok {
+		return nil, errors.New("bad metric value type returned for query")
+	}
+	var clients []string
+	for _, s := range v {
+		if s.Value > 0 {
+			clients = append(clients, string(s.Metric["source_workload"])+"."+string(s.Metric["source_workload_namespace"]))
+		}
+	}
+	sort.Strings(clients)
+	return clients, nil
+}
+
+// destinationRuleConflict is a DestinationRule whose TLS mode for a workload is incompatible with the mTLS mode of
+// the workload after the change.
+type destinationRuleConflict struct {
+	destinationRule string
+	host            string
+	port            uint32
+	reason          string
+}
+
+// destinationRuleConflicts returns the DestinationRules of the hosts of the services of the workload which conflict
+// with its mTLS mode after the change, and did not before.
+func (p *peerAuthPreview) destinationRuleConflicts(c mtlsModeChange) []destinationRuleConflict {
+	var conflicts []destinationRuleConflict
+	for _, svc := range p.services {
+		if svc.Namespace != c.namespace || len(svc.Spec.Selector) == 0 ||
+			!k8s_labels.SelectorFromSet(svc.Spec.Selector).Matches(k8s_labels.Set(c.labels)) {
+			continue
+		}
+		svcHost := host.Name(svc.Name + "." + svc.Namespace + k8sSuffix)
+		for _, cfg := range p.destinationRules {
+			dr := cfg.Spec.(*v1alpha3.DestinationRule)
+			drHost := dr.GetHost()
+			if !strings.HasPrefix(drHost, "*") && !strings.Contains(drHost, ".") {
+				drHost += "." + cfg.Namespace + k8sSuffix
+			}
+			if !svcHost.SubsetOf(host.Name(drHost)) {
+				continue
+			}
+			name := cfg.Name + "." + cfg.Namespace
+			if tls := dr.GetTrafficPolicy().GetTls(); tls != nil {
+				if reason := tlsConflict(tls.GetMode(), mtlsModeForPort(c.before, 0), mtlsModeForPort(c.after, 0)); reason != "" {
+					conflicts = append(conflicts, destinationRuleConflict{destinationRule: name, host: string(svcHost), reason: reason})
+				}
+			}
+			for _, pls := range dr.GetTrafficPolicy().GetPortLevelSettings() {
+				tls := pls.GetTls()
+				if tls == nil {
+					continue
+				}
+				target, ok := targetPort(svc, pls.GetPort().GetNumber())
+				if !ok {
+					continue
+				}
+				if reason := tlsConflict(tls.GetMode(), mtlsModeForPort(c.before, target), mtlsModeForPort(c.after, target)); reason != "" {
+					conflicts = append(conflicts, destinationRuleConflict{
+						destinationRule: name, host: string(svcHost), port: pls.GetPort().GetNumber(), reason: reason,
+					})
+				}
+			}
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].destinationRule != conflicts[j].destinationRule {
+			return conflicts[i].destinationRule < conflicts[j].destinationRule
+		}
+		return conflicts[i].port < conflicts[j].port
+	})
+	return conflicts
+}
+
+// targetPort returns the port of the workload a port of the service targets. Named target ports cannot be resolved
+// without the pods, and the service port is used for them.
+func targetPort(svc v1.Service, port uint32) (uint32, bool) {
+	for _, sp := range svc.Spec.Ports {
+		if uint32(sp.Port) != port {
+			continue
+		}
+		if sp.TargetPort.IntVal > 0 {
+			return uint32(sp.TargetPort.IntVal), true
+		}
+		return port, true
+	}
+	return 0, false
+}
+
+// tlsConflict returns why clients using the TLS mode of a DestinationRule fail with the mTLS mode after the change,
+// or an empty string if they do not, or already did before.
+func tlsConflict(tlsMode v1alpha3.ClientTLSSettings_TLSmode, before, after v1beta1.PeerAuthentication_MutualTLS_Mode) string {
+	conflict := func(mode v1beta1.PeerAuthentication_MutualTLS_Mode) string {
+		switch {
+		case tlsMode == v1alpha3.ClientTLSSettings_DISABLE && mode == v1beta1.PeerAuthentication_MutualTLS_STRICT:
+			return "clients send plaintext, which the STRICT mode rejects"
+		case tlsMode == v1alpha3.ClientTLSSettings_ISTIO_MUTUAL && mode == v1beta1.PeerAuthentication_MutualTLS_DISABLE:
+			return "clients send mutual TLS, which the DISABLE mode does not accept"
+		}
+		return ""
+	}
+	if conflict(before) != "" {
+		return ""
+	}
+	return conflict(after)
+}
+
+// printPeerAuthPreview prints the workloads whose mTLS mode changes, with the plaintext clients they would reject,
+// looked up in the telemetry if promAPI is not nil, and the conflicting DestinationRules. It returns the number of
+// clients and DestinationRules the change would break.
+func printPeerAuthPreview(w io.Writer, p *peerAuthPreview, changes []mtlsModeChange, promAPI promv1.API,
+	window time.Duration,
+) (int, error) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "The proposed change does not change the mTLS mode of any workload")
+		return 0, nil
+	}
+	broken := 0
+	fmt.Fprintln(w, "Workloads whose mTLS mode changes:")
+	for _, c := range changes {
+		fmt.Fprintf(w, "  %s: %s -> %s\n", c.workload(), describeMTLSMode(c.before), describeMTLSMode(c.after))
+		if c.startsRejectingPlaintext() && promAPI != nil {
+			clients, err := plaintextClients(promAPI, c, window)
+			if err != nil {
+				return 0, fmt.Errorf("could not look up the plaintext clients of workload %s: %v", c.workload(), err)
+			}
+			if len(clients) > 0 {
+				fmt.Fprintf(w, "    Plaintext clients in the last %s, which would be rejected: %s\n",
+					prommodel.Duration(window), strings.Join(clients, ", "))
+				broken += len(clients)
+			}
+		}
+		for _, conflict := range p.destinationRuleConflicts(c) {
+			if conflict.port != 0 {
+				fmt.Fprintf(w, "    Conflicting DestinationRule %s for %s port %d: %s\n",
+					conflict.destinationRule, conflict.host, conflict.port, conflict.reason)
+			} else {
+				fmt.Fprintf(w, "    Conflicting DestinationRule %s for %s: %s\n", conflict.destinationRule, conflict.host, conflict.reason)
+			}
+			broken++
+		}
+	}
+	return broken, nil
+}
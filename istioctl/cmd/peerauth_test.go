@@ -0,0 +1,180 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	prometheus_model "github.com/prometheus/common/model"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pkg/config"
+)
+
+const currentPeerAuthConfigs = `
+apiVersion: security.istio.io/v1beta1
+kind: PeerAuthentication
+metadata:
+  name: default
+  namespace: istio-system
+spec:
+  mtls:
+    mode: PERMISSIVE
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: httpbin
+  namespace: foo
+spec:
+  host: httpbin
+  trafficPolicy:
+    portLevelSettings:
+    - port:
+        number: 8000
+      tls:
+        mode: DISABLE
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: sleep
+  namespace: foo
+spec:
+  host: sleep.foo.svc.cluster.local
+  trafficPolicy:
+    tls:
+      mode: ISTIO_MUTUAL
+`
+
+const proposedPeerAuthentications = `
+apiVersion: security.istio.io/v1beta1
+kind: PeerAuthentication
+metadata:
+  name: default
+spec:
+  mtls:
+    mode: STRICT
+---
+apiVersion: security.istio.io/v1beta1
+kind: PeerAuthentication
+metadata:
+  name: sleep
+spec:
+  selector:
+    matchLabels:
+      app: sleep
+  mtls:
+    mode: STRICT
+  portLevelMtls:
+    8080:
+      mode: DISABLE
+`
+
+func TestPeerAuthPreview(t *testing.T) {
+	configs, _, err := crd.ParseInputs(currentPeerAuthConfigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proposed, _, err := crd.ParseInputs(proposedPeerAuthentications)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range proposed {
+		proposed[i].Namespace = "foo"
+	}
+	withSidecar := v1.PodSpec{Containers: []v1.Container{{Name: "app"}, {Name: proxyContainerName}}}
+	p := &peerAuthPreview{
+		rootNamespace:    "istio-system",
+		current:          configs[:1],
+		proposed:         proposed,
+		destinationRules: []config.Config{configs[1], configs[2]},
+		pods: []v1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{Name: "httpbin", Namespace: "foo", Labels: map[string]string{"app": "httpbin"}}, Spec: withSidecar},
+			{ObjectMeta: metav1.ObjectMeta{Name: "sleep", Namespace: "foo", Labels: map[string]string{"app": "sleep"}}, Spec: withSidecar},
+			{ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "foo", Labels: map[string]string{"app": "legacy"}}},
+		},
+		services: []v1.Service{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "httpbin", Namespace: "foo"},
+				Spec: v1.ServiceSpec{
+					Selector: map[string]string{"app": "httpbin"},
+					Ports:    []v1.ServicePort{{Port: 8000, TargetPort: intstr.FromInt(80)}},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "sleep", Namespace: "foo"},
+				Spec:       v1.ServiceSpec{Selector: map[string]string{"app": "sleep"}},
+			},
+		},
+	}
+	promAPI := mockPromAPI{
+		cannedResponse: map[string]prometheus_model.Value{
+			`sum(rate({__name__=~"istio_requests_total|istio_tcp_connections_opened_total",reporter="destination",connection_security_policy="none",destination_workload="httpbin",destination_workload_namespace="foo"}[1h])) by (source_workload, source_workload_namespace)`: prometheus_model.Vector{ // nolint: lll
+				&prometheus_model.Sample{
+					Metric: prometheus_model.Metric{"source_workload": "unknown", "source_workload_namespace": "unknown"},
+					Value:  0.5,
+				},
+				&prometheus_model.Sample{
+					Metric: prometheus_model.Metric{"source_workload": "curl", "source_workload_namespace": "legacy"},
+					Value:  0.01,
+				},
+			},
+		},
+	}
+
+	changes := p.mtlsModeChanges()
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2", len(changes))
+	}
+	var out bytes.Buffer
+	broken, err := printPeerAuthPreview(&out, p, changes, promAPI, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Workloads whose mTLS mode changes:
+  httpbin.foo: PERMISSIVE -> STRICT
+    Plaintext clients in the last 1h, which would be rejected: curl.legacy, unknown.unknown
+    Conflicting DestinationRule httpbin.foo for httpbin.foo.svc.cluster.local port 8000: clients send plaintext, which the STRICT mode rejects
+  sleep.foo: PERMISSIVE -> STRICT (port 8080: DISABLE)
+`
+	if out.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if broken != 3 {
+		t.Fatalf("got %d broken clients and DestinationRules, want 3", broken)
+	}
+
+	// Without telemetry, only the conflicting DestinationRules are known.
+	out.Reset()
+	if broken, err = printPeerAuthPreview(&out, p, changes, nil, time.Hour); err != nil || broken != 1 {
+		t.Fatalf("got %d broken clients and DestinationRules, err %v, want 1", broken, err)
+	}
+
+	// Applying the current resources changes nothing.
+	p.proposed = p.current
+	out.Reset()
+	if _, err := printPeerAuthPreview(&out, p, p.mtlsModeChanges(), promAPI, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if want := "The proposed change does not change the mTLS mode of any workload\n"; out.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
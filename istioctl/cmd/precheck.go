@@ -60,8 +60,15 @@ func preCheck() *cobra.Command {
   istioctl x precheck
 
   # Check only a single namespace
-  istioctl x precheck --namespace default`,
+  istioctl x precheck --namespace default
+
+  # Print the messages as a JSON document
+  istioctl x precheck --output json`,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			jsonDocument, err := jsonDocumentOutput()
+			if err != nil {
+				return err
+			}
 			cli, err := kube.NewExtendedClient(kube.BuildClientCmd(kubeconfig, configContext), revision)
 			if err != nil {
 				return err
@@ -81,6 +88,12 @@ func preCheck() *cobra.Command {
 			msgs.Add(nsmsgs...)
 			// Print all the messages to stdout in the specified format
 			msgs = msgs.SortedDedupedCopy()
+			if jsonDocument {
+				if err := formatting.PrintJSONDocument(cmd.OutOrStdout(), "PrecheckMessageList", msgs); err != nil {
+					return err
+				}
+				return precheckResult(msgs)
+			}
 			output, err := formatting.Print(msgs, msgOutputFormat, colorize)
 			if err != nil {
 				return err
@@ -91,21 +104,27 @@ func preCheck() *cobra.Command {
 			} else {
 				fmt.Fprintln(cmd.OutOrStdout(), output)
 			}
-			for _, m := range msgs {
-				if m.Type.Level().IsWorseThanOrEqualTo(diag.Warning) {
-					e := fmt.Sprintf(`Issues found when checking the cluster. Istio may not be safe to install or upgrade.
-See %s for more information about causes and resolutions.`, url.ConfigAnalysis)
-					return errors.New(e)
-				}
-			}
-			return nil
+			return precheckResult(msgs)
 		},
 	}
 	cmd.PersistentFlags().BoolVar(&skipControlPlane, "skip-controlplane", false, "skip checking the control plane")
 	opts.AttachControlPlaneFlags(cmd)
+	supportGlobalOutput(cmd)
 	return cmd
 }
 
+// precheckResult returns an error if any of the messages is a warning or an error.
+func precheckResult(msgs diag.Messages) error {
+	for _, m := range msgs {
+		if m.Type.Level().IsWorseThanOrEqualTo(diag.Warning) {
+			e := fmt.Sprintf(`Issues found when checking the cluster. Istio may not be safe to install or upgrade.
+See %s for more information about causes and resolutions.`, url.ConfigAnalysis)
+			return errors.New(e)
+		}
+	}
+	return nil
+}
+
 func checkControlPlane(cli kube.ExtendedClient) (diag.Messages, error) {
 	msgs := diag.Messages{}
 
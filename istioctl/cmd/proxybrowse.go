@@ -0,0 +1,144 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"istio.io/istio/istioctl/pkg/writer/envoy/configdump"
+)
+
+const browsePrompt = "> "
+
+func proxyBrowseCommand() *cobra.Command {
+	var dumpFile string
+	cmd := &cobra.Command{
+		Use:   "proxy-browse [<pod-name[.namespace]>]",
+		Short: "Interactively browse the clusters, listeners, routes and endpoints of an Envoy",
+		Long: `Fetches the configuration of the Envoy instance in the specified pod, with its endpoints, and opens a prompt
+to list the clusters, listeners, routes and endpoints, filter them by name, and open them. An opened resource is shown
+with the numbered resources it references and the ones referencing it, such as the routes of a listener, the clusters
+of a route or the endpoints of a cluster, to follow a request through the configuration. Type help at the prompt for
+the list of commands.`,
+		Example: `  # Browse the configuration of the Envoy in a pod
+  istioctl x proxy-browse productpage-v1-bb8d5cbc7-k7qbm.default
+
+  # Browse a configuration dump, such as the one of a bug report
+  istioctl x proxy-browse --file envoy-config.json`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if (len(args) == 1) != (dumpFile == "") {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("proxy-browse requires pod name or --file parameter")
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			var reload func() ([]byte, error)
+			var dump []byte
+			var err error
+			if len(args) == 1 {
+				podName, podNamespace, err := getPodName(args[0])
+				if err != nil {
+					return err
+				}
+				reload = func() ([]byte, error) {
+					return extractConfigDump(podName, podNamespace, true)
+				}
+				dump, err = reload()
+				if err != nil {
+					return err
+				}
+			} else if dump, _, err = readConfigDumpFile(dumpFile, c.OutOrStdout()); err != nil {
+				return err
+			}
+
+			if in, ok := c.InOrStdin().(*os.File); ok && term.IsTerminal(int(in.Fd())) {
+				return browseTerminal(in, dump, reload)
+			}
+			return browseLines(c.InOrStdin(), c.OutOrStdout(), dump, reload)
+		},
+		ValidArgsFunction: validPodsNameArgs,
+	}
+	cmd.PersistentFlags().StringVarP(&dumpFile, "file", "f", "",
+		"Envoy config dump JSON file, - for stdin")
+	return cmd
+}
+
+// browseTerminal runs the browser in the terminal, with line editing and the history of the commands.
+func browseTerminal(in *os.File, dump []byte, reload func() ([]byte, error)) error {
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = term.Restore(int(in.Fd()), state)
+	}()
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{in, os.Stdout}, browsePrompt)
+	if width, height, err := term.GetSize(int(in.Fd())); err == nil {
+		_ = terminal.SetSize(width, height)
+	}
+	browser, err := newProxyBrowser(terminal, dump, reload)
+	if err != nil {
+		return err
+	}
+	for {
+		line, err := terminal.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !browser.Handle(line) {
+			return nil
+		}
+	}
+}
+
+// browseLines runs the browser on the commands read from in, one per line, when it is not a terminal.
+func browseLines(in io.Reader, out io.Writer, dump []byte, reload func() ([]byte, error)) error {
+	browser, err := newProxyBrowser(out, dump, reload)
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(in)
+	fmt.Fprint(out, browsePrompt)
+	for scanner.Scan() {
+		if !browser.Handle(scanner.Text()) {
+			return nil
+		}
+		fmt.Fprint(out, browsePrompt)
+	}
+	return scanner.Err()
+}
+
+func newProxyBrowser(out io.Writer, dump []byte, reload func() ([]byte, error)) (*configdump.Browser, error) {
+	cw, err := setupConfigdumpEnvoyConfigWriter(dump, out)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprint(out, "Type help for the list of commands.\n")
+	return configdump.NewBrowser(cw, reload), nil
+}
@@ -0,0 +1,44 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBrowseLines(t *testing.T) {
+	dump := []byte(`{"configs": [{
+		"@type": "type.googleapis.com/envoy.admin.v3.ClustersConfigDump",
+		"static_clusters": [
+			{"cluster": {"@type": "type.googleapis.com/envoy.config.cluster.v3.Cluster", "name": "agent", "type": "STATIC"}},
+			{"cluster": {"@type": "type.googleapis.com/envoy.config.cluster.v3.Cluster", "name": "prometheus_stats", "type": "STATIC"}}
+		]
+	}]}`)
+	out := &bytes.Buffer{}
+	// The commands after quit are not run.
+	if err := browseLines(strings.NewReader("clusters agent\n1\nquit\nclusters\n"), out, dump, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Type help for the list of commands.", "1   agent   STATIC", `"name": "agent"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "prometheus_stats") {
+		t.Errorf("commands after quit were run:\n%s", out.String())
+	}
+}
@@ -16,20 +16,31 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 	"sigs.k8s.io/yaml"
 
 	"istio.io/istio/istioctl/pkg/util/handlers"
+	sdscompare "istio.io/istio/istioctl/pkg/writer/compare/sds"
 	"istio.io/istio/istioctl/pkg/writer/envoy/clusters"
 	"istio.io/istio/istioctl/pkg/writer/envoy/configdump"
+	"istio.io/istio/istioctl/pkg/writer/envoy/health"
+	envoystats "istio.io/istio/istioctl/pkg/writer/envoy/stats"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/pkg/log"
 )
@@ -37,7 +48,12 @@ import (
 const (
 	jsonOutput             = "json"
 	yamlOutput             = "yaml"
+	protoOutput            = "proto"
 	summaryOutput          = "short"
+	briefOutput            = "brief"
+	filtersOutput          = "filters"
+	dotOutput              = "dot"
+	customColumnsOutput    = "custom-columns="
 	prometheusOutput       = "prom"
 	prometheusMergedOutput = "prom-merged"
 )
@@ -45,16 +61,28 @@ const (
 var (
 	fqdn, direction, subset string
 	port                    int
+	unusedClusters          bool
 	verboseProxyConfig      bool
 
 	address, listenerType, statsType string
 
-	routeName string
+	// sni, applicationProtocol and destinationCIDR select the listener filter chains by their match.
+	sni, applicationProtocol, destinationCIDR string
+
+	// routeName, and nameFilter for clusters and listeners, select the resources by a glob or a /regular expression/ of
+	// their names.
+	routeName, nameFilter string
 
 	clusterName, status string
 
+	// updatedSince selects the resources last updated at or after a time, or within a duration before now.
+	updatedSince string
+
 	// output format (yaml or short)
 	outputFormat string
+
+	// checkSync compares the configuration versions the proxy accepted with the version of the current push of istiod.
+	checkSync bool
 )
 
 // Level is an enumeration of all supported log levels.
@@ -168,6 +196,40 @@ func extractConfigDump(podName, podNamespace string, eds bool) ([]byte, error) {
 	return debug, err
 }
 
+// istiodVersions returns the version of the current push context of the istiod the proxy of the pod is connected to,
+// and the versions of the configuration of each xDS type it last sent to the proxy, by type URL, from the sync status
+// of the istiods.
+func istiodVersions(podName, podNamespace string) (string, map[string]string, error) {
+	kubeClient, err := kubeClient(kubeconfig, configContext)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create k8s client: %v", err)
+	}
+	res, err := kubeClient.AllDiscoveryDo(context.TODO(), istioNamespace, "/debug/syncz")
+	if err != nil {
+		return "", nil, err
+	}
+	proxyID := podName + "." + podNamespace
+	for istiod, body := range res {
+		var statuses []xds.SyncStatus
+		if err := json.Unmarshal(body, &statuses); err != nil {
+			return "", nil, fmt.Errorf("failed to parse the sync status of %s: %v", istiod, err)
+		}
+		for _, ss := range statuses {
+			if ss.ProxyID != proxyID {
+				continue
+			}
+			return ss.PushVersion, map[string]string{
+				v3.ClusterType:                ss.ClusterVersionSent,
+				v3.ListenerType:               ss.ListenerVersionSent,
+				v3.RouteType:                  ss.RouteVersionSent,
+				v3.EndpointType:               ss.EndpointVersionSent,
+				v3.ExtensionConfigurationType: ss.ExtensionConfigVersionSent,
+			}, nil
+		}
+	}
+	return "", nil, fmt.Errorf("%s is not connected to any istiod in namespace %s", proxyID, istioNamespace)
+}
+
 func setupPodConfigdumpWriter(podName, podNamespace string, includeEds bool, out io.Writer) (*configdump.ConfigWriter, error) {
 	debug, err := extractConfigDump(podName, podNamespace, includeEds)
 	if err != nil {
@@ -176,6 +238,64 @@ func setupPodConfigdumpWriter(podName, podNamespace string, includeEds bool, out
 	return setupConfigdumpEnvoyConfigWriter(debug, out)
 }
 
+// proxyAdminDump holds the responses of the Envoy admin endpoints summarized by proxy-config all.
+type proxyAdminDump struct {
+	configDump []byte
+	clusters   []byte
+	listeners  []byte
+	stats      []byte
+	serverInfo []byte
+}
+
+// fetchProxyAdminDump fetches the admin endpoints summarized by proxy-config all in parallel, over a single port
+// forward to the Envoy in the pod.
+func fetchProxyAdminDump(podName, podNamespace string) (*proxyAdminDump, error) {
+	kubeClient, err := kubeClient(kubeconfig, configContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create k8s client: %v", err)
+	}
+	fw, err := kubeClient.NewPortForwarder(podName, podNamespace, "127.0.0.1", 0, 15000)
+	if err != nil {
+		return nil, err
+	}
+	if err := fw.Start(); err != nil {
+		return nil, fmt.Errorf("failure running port forward process: %v", err)
+	}
+	defer fw.Close()
+
+	dump := &proxyAdminDump{}
+	paths := map[string]*[]byte{
+		"config_dump?include_eds=true":                        &dump.configDump,
+		"clusters?format=json":                                &dump.clusters,
+		"listeners?format=json":                               &dump.listeners,
+		"stats?filter=" + url.QueryEscape(health.StatsFilter): &dump.stats,
+		"server_info":                                         &dump.serverInfo,
+	}
+	g, ctx := errgroup.WithContext(context.Background())
+	for path, out := range paths {
+		path, out := path, out
+		g.Go(func() error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s/%s", fw.Address(), path), nil)
+			if err != nil {
+				return err
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return fmt.Errorf("failed to execute command on %s.%s sidecar: %v", podName, podNamespace, err)
+			}
+			defer resp.Body.Close()
+			if *out, err = io.ReadAll(resp.Body); err != nil {
+				return fmt.Errorf("failed to read %s from %s.%s sidecar: %v", path, podName, podNamespace, err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
 func readFile(filename string) ([]byte, error) {
 	file := os.Stdin
 	if filename != "-" {
@@ -194,11 +314,33 @@ func readFile(filename string) ([]byte, error) {
 }
 
 func setupFileConfigdumpWriter(filename string, out io.Writer) (*configdump.ConfigWriter, error) {
+	_, cw, err := readConfigDumpFile(filename, out)
+	return cw, err
+}
+
+// readConfigDumpFile reads a config dump from a file, or stdin for -, and checks that it is a well-formed Envoy admin
+// config_dump, such as the ones captured in bug reports.
+func readConfigDumpFile(filename string, out io.Writer) ([]byte, *configdump.ConfigWriter, error) {
 	data, err := readFile(filename)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	cw, err := setupConfigdumpEnvoyConfigWriter(data, out)
+	if err == nil {
+		err = cw.Validate()
 	}
-	return setupConfigdumpEnvoyConfigWriter(data, out)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", fileDisplayName(filename), err)
+	}
+	return data, cw, nil
+}
+
+// fileDisplayName returns the name of the file in errors, stdin for -.
+func fileDisplayName(filename string) string {
+	if filename == "-" {
+		return "stdin"
+	}
+	return filename
 }
 
 func setupConfigdumpEnvoyConfigWriter(debug []byte, out io.Writer) (*configdump.ConfigWriter, error) {
@@ -251,6 +393,19 @@ func setupEnvoyServerStatsConfig(podName, podNamespace string, outputFormat stri
 	return string(result), nil
 }
 
+// extractEnvoyStats returns the text output of the stats admin endpoint of the Envoy in the pod.
+func extractEnvoyStats(podName, podNamespace string) ([]byte, error) {
+	kubeClient, err := kubeClient(kubeconfig, configContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	stats, err := kubeClient.EnvoyDo(context.Background(), podName, podNamespace, "GET", "stats")
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute command on %s.%s sidecar: %v", podName, podNamespace, err)
+	}
+	return stats, nil
+}
+
 func setupEnvoyLogConfig(param, podName, podNamespace string) (string, error) {
 	kubeClient, err := kubeClient(kubeconfig, configContext)
 	if err != nil {
@@ -301,20 +456,18 @@ func setupPodClustersWriter(podName, podNamespace string, out io.Writer) (*clust
 }
 
 func setupFileClustersWriter(filename string, out io.Writer) (*clusters.ConfigWriter, error) {
-	file, err := os.Open(filename)
+	data, err := readFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			log.Errorf("failed to close %s: %s", filename, err)
-		}
-	}()
-	data, err := io.ReadAll(file)
+	cw, err := setupClustersEnvoyConfigWriter(data, out)
+	if err == nil {
+		err = cw.Validate()
+	}
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %v", fileDisplayName(filename), err)
 	}
-	return setupClustersEnvoyConfigWriter(data, out)
+	return cw, nil
 }
 
 // TODO(fisherxu): migrate this to config dump when implemented in Envoy
@@ -341,12 +494,27 @@ func clusterConfigCmd() *cobra.Command {
   # Retrieve cluster summary for clusters with port 9080.
   istioctl proxy-config clusters <pod-name[.namespace]> --port 9080
 
+  # Retrieve cluster summary for the clusters of port 443 of the subsets of reviews.
+  istioctl proxy-config clusters <pod-name[.namespace]> --name 'outbound|443|*reviews*'
+
+  # Retrieve cluster summary for the clusters of subsets v1 or v2, with a regular expression between slashes.
+  istioctl proxy-config clusters <pod-name[.namespace]> --name '/\|v[12]\|/'
+
   # Retrieve full cluster dump for clusters that are inbound with a FQDN of details.default.svc.cluster.local.
   istioctl proxy-config clusters <pod-name[.namespace]> --fqdn details.default.svc.cluster.local --direction inbound -o json
 
+  # Retrieve the name, type and transport socket of the clusters, with JSON field paths as kubectl custom-columns.
+  istioctl proxy-config clusters <pod-name[.namespace]> -o custom-columns=NAME:.name,TYPE:.type,TLS:.transportSocket.name
+
   # Retrieve cluster summary without using Kubernetes API
   ssh <user@hostname> 'curl localhost:15000/config_dump' > envoy-config.json
   istioctl proxy-config clusters --file envoy-config.json
+
+  # Retrieve clusters not referenced by any route, listener filter chain or other cluster.
+  istioctl proxy-config clusters <pod-name[.namespace]> --unused
+
+  # Retrieve clusters updated in the last 5 minutes.
+  istioctl proxy-config clusters <pod-name[.namespace]> --updated-since 5m
 `,
 		Aliases: []string{"clusters", "c"},
 		Args: func(cmd *cobra.Command, args []string) error {
@@ -370,31 +538,51 @@ func clusterConfigCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			since, err := parseUpdatedSince(updatedSince)
+			if err != nil {
+				return err
+			}
+			if err := configdump.ValidateNamePattern(nameFilter); err != nil {
+				return fmt.Errorf("invalid --name %q: %v", nameFilter, err)
+			}
 			filter := configdump.ClusterFilter{
-				FQDN:      host.Name(fqdn),
-				Port:      port,
-				Subset:    subset,
-				Direction: model.TrafficDirection(direction),
+				Name:         nameFilter,
+				FQDN:         host.Name(fqdn),
+				Port:         port,
+				Subset:       subset,
+				Direction:    model.TrafficDirection(direction),
+				Unused:       unusedClusters,
+				UpdatedSince: since,
 			}
 			switch outputFormat {
 			case summaryOutput:
 				return configWriter.PrintClusterSummary(filter)
-			case jsonOutput, yamlOutput:
+			case jsonOutput, yamlOutput, protoOutput:
 				return configWriter.PrintClusterDump(filter, outputFormat)
 			default:
+				if strings.HasPrefix(outputFormat, customColumnsOutput) {
+					return configWriter.PrintClusterDump(filter, outputFormat)
+				}
 				return fmt.Errorf("output format %q not supported", outputFormat)
 			}
 		},
 		ValidArgsFunction: validPodsNameArgs,
 	}
 
-	clusterConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short")
+	clusterConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput,
+		"Output format: one of json|yaml|short|proto|custom-columns=<HEADER>:.<field>,...")
+	clusterConfigCmd.PersistentFlags().StringVar(&nameFilter, "name", "",
+		"Filter clusters by name, as a glob such as 'outbound|443|*reviews*' or a /regular expression/")
 	clusterConfigCmd.PersistentFlags().StringVar(&fqdn, "fqdn", "", "Filter clusters by substring of Service FQDN field")
 	clusterConfigCmd.PersistentFlags().StringVar(&direction, "direction", "", "Filter clusters by Direction field")
 	clusterConfigCmd.PersistentFlags().StringVar(&subset, "subset", "", "Filter clusters by substring of Subset field")
 	clusterConfigCmd.PersistentFlags().IntVar(&port, "port", 0, "Filter clusters by Port field")
+	clusterConfigCmd.PersistentFlags().BoolVar(&unusedClusters, "unused", false,
+		"Only show clusters not referenced by any route, listener filter chain or other cluster, "+
+			"typically left over from a stale Sidecar scope or ServiceEntry")
+	clusterConfigCmd.PersistentFlags().StringVar(&updatedSince, "updated-since", "", updatedSinceUsage("clusters"))
 	clusterConfigCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
-		"Envoy config dump JSON file")
+		"Envoy config dump JSON file, or - for stdin")
 
 	return clusterConfigCmd
 }
@@ -403,7 +591,11 @@ func allConfigCmd() *cobra.Command {
 	allConfigCmd := &cobra.Command{
 		Use:   "all [<type>/]<name>[.<namespace>]",
 		Short: "Retrieves all configuration for the Envoy in the specified pod",
-		Long:  `Retrieve information about all configuration for the Envoy instance in the specified pod.`,
+		Long: `Retrieve information about all configuration for the Envoy instance in the specified pod.
+
+The summary for a pod starts with the health of the Envoy, based on its server state, warming and rejected
+configuration, and endpoint health. The Envoy admin endpoints it is built from are fetched in parallel over a
+single port forward.`,
 		Example: `  # Retrieve summary about all configuration for a given pod from Envoy.
   istioctl proxy-config all <pod-name[.namespace]>
 
@@ -416,6 +608,18 @@ func allConfigCmd() *cobra.Command {
   # Retrieve cluster summary without using Kubernetes API
   ssh <user@hostname> 'curl localhost:15000/config_dump' > envoy-config.json
   istioctl proxy-config all --file envoy-config.json
+
+  # Retrieve cluster summary from a previously captured config dump, read from stdin
+  cat envoy-config.json | istioctl proxy-config all --file -
+
+  # Retrieve only the number of resources, the version and the last update time of each type
+  istioctl proxy-config all <pod-name[.namespace]> -o brief
+
+  # Render the listeners, routes, clusters and endpoints of the port 8080 listeners of a gateway as a graph
+  istioctl proxy-config all <pod-name[.namespace]> -o dot --port 8080 | dot -Tsvg > gateway.svg
+
+  # Check whether the proxy runs with the configuration of each type generated from the current push of istiod
+  istioctl proxy-config all <pod-name[.namespace]> --check-sync
 `,
 		Aliases: []string{"a"},
 		Args: func(cmd *cobra.Command, args []string) error {
@@ -426,6 +630,24 @@ func allConfigCmd() *cobra.Command {
 			return nil
 		},
 		RunE: func(c *cobra.Command, args []string) error {
+			if checkSync {
+				if len(args) != 1 {
+					return fmt.Errorf("--check-sync requires a pod name, istiod does not know the proxy of a config dump file")
+				}
+				podName, podNamespace, err := getPodName(args[0])
+				if err != nil {
+					return err
+				}
+				current, sent, err := istiodVersions(podName, podNamespace)
+				if err != nil {
+					return err
+				}
+				configWriter, err := setupPodConfigdumpWriter(podName, podNamespace, true, c.OutOrStdout())
+				if err != nil {
+					return err
+				}
+				return configWriter.PrintSyncStatus(current, sent)
+			}
 			switch outputFormat {
 			case jsonOutput, yamlOutput:
 				var dump []byte
@@ -440,7 +662,7 @@ func allConfigCmd() *cobra.Command {
 						return err
 					}
 				} else {
-					dump, err = readFile(configDumpFile)
+					dump, _, err = readConfigDumpFile(configDumpFile, c.OutOrStdout())
 					if err != nil {
 						return err
 					}
@@ -452,7 +674,27 @@ func allConfigCmd() *cobra.Command {
 				}
 				fmt.Fprintln(c.OutOrStdout(), string(dump))
 
-			case summaryOutput:
+			case briefOutput:
+				var configWriter *configdump.ConfigWriter
+				if len(args) == 1 {
+					podName, podNamespace, err := getPodName(args[0])
+					if err != nil {
+						return err
+					}
+					configWriter, err = setupPodConfigdumpWriter(podName, podNamespace, false, c.OutOrStdout())
+					if err != nil {
+						return err
+					}
+				} else {
+					var err error
+					configWriter, err = setupFileConfigdumpWriter(configDumpFile, c.OutOrStdout())
+					if err != nil {
+						return err
+					}
+				}
+				return configWriter.PrintBriefSummary()
+
+			case dotOutput:
 				var configWriter *configdump.ConfigWriter
 				if len(args) == 1 {
 					podName, podNamespace, err := getPodName(args[0])
@@ -470,6 +712,59 @@ func allConfigCmd() *cobra.Command {
 						return err
 					}
 				}
+				if err := configdump.ValidateNamePattern(routeName); err != nil {
+					return fmt.Errorf("invalid --name %q: %v", routeName, err)
+				}
+				// The port selects the listeners, the clusters they reach usually have other ports.
+				return configWriter.PrintGraph(
+					configdump.ClusterFilter{
+						FQDN:      host.Name(fqdn),
+						Subset:    subset,
+						Direction: model.TrafficDirection(direction),
+					},
+					configdump.ListenerFilter{
+						Address: address,
+						Port:    uint32(port),
+						Type:    listenerType,
+					},
+					configdump.RouteFilter{
+						Name: routeName,
+					},
+				)
+
+			case summaryOutput:
+				var configWriter *configdump.ConfigWriter
+				if len(args) == 1 {
+					podName, podNamespace, err := getPodName(args[0])
+					if err != nil {
+						return err
+					}
+					dump, err := fetchProxyAdminDump(podName, podNamespace)
+					if err != nil {
+						return err
+					}
+					healthWriter := &health.Writer{Stdout: c.OutOrStdout()}
+					if err := healthWriter.Prime(dump.serverInfo, dump.clusters, dump.listeners, dump.stats); err != nil {
+						return err
+					}
+					configWriter, err = setupConfigdumpEnvoyConfigWriter(dump.configDump, c.OutOrStdout())
+					if err != nil {
+						return err
+					}
+					if err := healthWriter.PrintHealthSummary(); err != nil {
+						return err
+					}
+					fmt.Fprintln(c.OutOrStdout())
+				} else {
+					var err error
+					configWriter, err = setupFileConfigdumpWriter(configDumpFile, c.OutOrStdout())
+					if err != nil {
+						return err
+					}
+				}
+				if err := configdump.ValidateNamePattern(routeName); err != nil {
+					return fmt.Errorf("invalid --name %q: %v", routeName, err)
+				}
 				return configWriter.PrintFullSummary(
 					configdump.ClusterFilter{
 						FQDN:      host.Name(fqdn),
@@ -496,10 +791,13 @@ func allConfigCmd() *cobra.Command {
 		ValidArgsFunction: validPodsNameArgs,
 	}
 
-	allConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short")
+	allConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short|brief|dot")
 	allConfigCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
-		"Envoy config dump file")
+		"Envoy config dump JSON file, or - for stdin")
 	allConfigCmd.PersistentFlags().BoolVar(&verboseProxyConfig, "verbose", true, "Output more information")
+	allConfigCmd.PersistentFlags().BoolVar(&checkSync, "check-sync", false,
+		"Compare the version of the configuration of each xDS type the proxy accepted with the version of the current "+
+			"push of istiod, and report it as SYNCED or STALE")
 
 	// cluster
 	allConfigCmd.PersistentFlags().StringVar(&fqdn, "fqdn", "", "Filter clusters by substring of Service FQDN field")
@@ -519,6 +817,29 @@ func allConfigCmd() *cobra.Command {
 	return allConfigCmd
 }
 
+func updatedSinceUsage(resources string) string {
+	return fmt.Sprintf("Only show the %s last updated at or after a time, as a duration before now such as 10m "+
+		"or an RFC3339 timestamp such as 2023-01-02T15:04:05Z", resources)
+}
+
+// parseUpdatedSince parses the value of the --updated-since flag, returning the zero time when it is empty.
+func parseUpdatedSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("invalid --updated-since %q: duration must not be negative", value)
+		}
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --updated-since %q: must be a duration such as 10m or an RFC3339 timestamp", value)
+	}
+	return t, nil
+}
+
 func listenerConfigCmd() *cobra.Command {
 	var podName, podNamespace string
 
@@ -532,9 +853,25 @@ func listenerConfigCmd() *cobra.Command {
   # Retrieve listener summary for listeners with port 9080.
   istioctl proxy-config listeners <pod-name[.namespace]> --port 9080
 
+  # Retrieve listener summary for the listeners of port 9080 of any address.
+  istioctl proxy-config listeners <pod-name[.namespace]> --name '*_9080'
+
   # Retrieve full listener dump for HTTP listeners with a wildcard address (0.0.0.0).
   istioctl proxy-config listeners <pod-name[.namespace]> --type HTTP --address 0.0.0.0 -o json
 
+  # Retrieve the name of the listeners and the names of all their filter chains.
+  istioctl proxy-config listeners <pod-name[.namespace]> -o 'custom-columns=NAME:.name,CHAINS:.filterChains[*].name'
+
+  # Retrieve listener summary of only the filter chains matching the SNI foo.example.com.
+  istioctl proxy-config listeners <pod-name[.namespace]> --sni foo.example.com
+
+  # Retrieve the ordered listener, network and HTTP filters of the listeners with port 9080, showing which ones
+  # are not generated by Istio.
+  istioctl proxy-config listeners <pod-name[.namespace]> --port 9080 -o filters
+
+  # Retrieve listener summary of the inbound listeners only.
+  istioctl proxy-config listeners <pod-name[.namespace]> --direction inbound
+
   # Retrieve listener summary without using Kubernetes API
   ssh <user@hostname> 'curl localhost:15000/config_dump' > envoy-config.json
   istioctl proxy-config listeners --file envoy-config.json
@@ -561,32 +898,73 @@ func listenerConfigCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			since, err := parseUpdatedSince(updatedSince)
+			if err != nil {
+				return err
+			}
+			switch strings.ToUpper(direction) {
+			case "", configdump.ListenerDirectionInbound, configdump.ListenerDirectionOutbound, configdump.ListenerDirectionGateway:
+			default:
+				return fmt.Errorf("invalid direction %q: must be one of inbound|outbound|gateway", direction)
+			}
+			if destinationCIDR != "" && net.ParseIP(destinationCIDR) == nil {
+				if _, _, err := net.ParseCIDR(destinationCIDR); err != nil {
+					return fmt.Errorf("invalid destination CIDR %q: %v", destinationCIDR, err)
+				}
+			}
+			if err := configdump.ValidateNamePattern(nameFilter); err != nil {
+				return fmt.Errorf("invalid --name %q: %v", nameFilter, err)
+			}
 			filter := configdump.ListenerFilter{
-				Address: address,
-				Port:    uint32(port),
-				Type:    listenerType,
-				Verbose: verboseProxyConfig,
+				Name:                nameFilter,
+				Address:             address,
+				Port:                uint32(port),
+				Type:                listenerType,
+				Verbose:             verboseProxyConfig,
+				UpdatedSince:        since,
+				SNI:                 sni,
+				ApplicationProtocol: applicationProtocol,
+				DestinationCIDR:     destinationCIDR,
+				Direction:           direction,
 			}
 
 			switch outputFormat {
 			case summaryOutput:
 				return configWriter.PrintListenerSummary(filter)
-			case jsonOutput, yamlOutput:
+			case filtersOutput:
+				return configWriter.PrintFilterChainSummary(filter)
+			case jsonOutput, yamlOutput, protoOutput:
 				return configWriter.PrintListenerDump(filter, outputFormat)
 			default:
+				if strings.HasPrefix(outputFormat, customColumnsOutput) {
+					return configWriter.PrintListenerDump(filter, outputFormat)
+				}
 				return fmt.Errorf("output format %q not supported", outputFormat)
 			}
 		},
 		ValidArgsFunction: validPodsNameArgs,
 	}
 
-	listenerConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short")
+	listenerConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput,
+		"Output format: one of json|yaml|short|proto|filters|custom-columns=<HEADER>:.<field>,...")
+	listenerConfigCmd.PersistentFlags().StringVar(&nameFilter, "name", "",
+		"Filter listeners by name, as a glob such as '*_9080' or a /regular expression/")
 	listenerConfigCmd.PersistentFlags().StringVar(&address, "address", "", "Filter listeners by address field")
 	listenerConfigCmd.PersistentFlags().StringVar(&listenerType, "type", "", "Filter listeners by type field")
 	listenerConfigCmd.PersistentFlags().IntVar(&port, "port", 0, "Filter listeners by Port field")
+	listenerConfigCmd.PersistentFlags().StringVar(&direction, "direction", "",
+		"Filter listeners by direction, one of inbound|outbound|gateway")
+	listenerConfigCmd.PersistentFlags().StringVar(&sni, "sni", "",
+		"Filter listeners by the server names of their filter chain matches, showing only the matching filter chains")
+	listenerConfigCmd.PersistentFlags().StringVar(&applicationProtocol, "application-protocol", "",
+		"Filter listeners by the application protocols of their filter chain matches, showing only the matching filter chains")
+	listenerConfigCmd.PersistentFlags().StringVar(&destinationCIDR, "destination-cidr", "",
+		"Filter listeners by the destination prefix ranges of their filter chain matches containing this address or CIDR, "+
+			"showing only the matching filter chains")
 	listenerConfigCmd.PersistentFlags().BoolVar(&verboseProxyConfig, "verbose", true, "Output more information")
+	listenerConfigCmd.PersistentFlags().StringVar(&updatedSince, "updated-since", "", updatedSinceUsage("listeners"))
 	listenerConfigCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
-		"Envoy config dump JSON file")
+		"Envoy config dump JSON file, or - for stdin")
 
 	return listenerConfigCmd
 }
@@ -819,6 +1197,9 @@ func routeConfigCmd() *cobra.Command {
   # Retrieve full route dump for route 9080
   istioctl proxy-config route <pod-name[.namespace]> --name 9080 -o json
 
+  # Retrieve the name of the routes and the domains of their first virtual host.
+  istioctl proxy-config route <pod-name[.namespace]> -o 'custom-columns=NAME:.name,DOMAINS:.virtualHosts[0].domains'
+
   # Retrieve route summary without using Kubernetes API
   ssh <user@hostname> 'curl localhost:15000/config_dump' > envoy-config.json
   istioctl proxy-config routes --file envoy-config.json
@@ -845,27 +1226,41 @@ func routeConfigCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			since, err := parseUpdatedSince(updatedSince)
+			if err != nil {
+				return err
+			}
+			if err := configdump.ValidateNamePattern(routeName); err != nil {
+				return fmt.Errorf("invalid --name %q: %v", routeName, err)
+			}
 			filter := configdump.RouteFilter{
-				Name:    routeName,
-				Verbose: verboseProxyConfig,
+				Name:         routeName,
+				Verbose:      verboseProxyConfig,
+				UpdatedSince: since,
 			}
 			switch outputFormat {
 			case summaryOutput:
 				return configWriter.PrintRouteSummary(filter)
-			case jsonOutput, yamlOutput:
+			case jsonOutput, yamlOutput, protoOutput:
 				return configWriter.PrintRouteDump(filter, outputFormat)
 			default:
+				if strings.HasPrefix(outputFormat, customColumnsOutput) {
+					return configWriter.PrintRouteDump(filter, outputFormat)
+				}
 				return fmt.Errorf("output format %q not supported", outputFormat)
 			}
 		},
 		ValidArgsFunction: validPodsNameArgs,
 	}
 
-	routeConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short")
-	routeConfigCmd.PersistentFlags().StringVar(&routeName, "name", "", "Filter listeners by route name field")
+	routeConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput,
+		"Output format: one of json|yaml|short|proto|custom-columns=<HEADER>:.<field>,...")
+	routeConfigCmd.PersistentFlags().StringVar(&routeName, "name", "",
+		"Filter routes by name, as a glob such as 'inbound|*' or a /regular expression/")
 	routeConfigCmd.PersistentFlags().BoolVar(&verboseProxyConfig, "verbose", true, "Output more information")
+	routeConfigCmd.PersistentFlags().StringVar(&updatedSince, "updated-since", "", updatedSinceUsage("routes"))
 	routeConfigCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
-		"Envoy config dump JSON file")
+		"Envoy config dump JSON file, or - for stdin")
 
 	return routeConfigCmd
 }
@@ -928,7 +1323,7 @@ func endpointConfigCmd() *cobra.Command {
 			switch outputFormat {
 			case summaryOutput:
 				return configWriter.PrintEndpointsSummary(filter)
-			case jsonOutput, yamlOutput:
+			case jsonOutput, yamlOutput, protoOutput:
 				return configWriter.PrintEndpoints(filter, outputFormat)
 			default:
 				return fmt.Errorf("output format %q not supported", outputFormat)
@@ -937,13 +1332,13 @@ func endpointConfigCmd() *cobra.Command {
 		ValidArgsFunction: validPodsNameArgs,
 	}
 
-	endpointConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short")
+	endpointConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short|proto")
 	endpointConfigCmd.PersistentFlags().StringVar(&address, "address", "", "Filter endpoints by address field")
 	endpointConfigCmd.PersistentFlags().IntVar(&port, "port", 0, "Filter endpoints by Port field")
 	endpointConfigCmd.PersistentFlags().StringVar(&clusterName, "cluster", "", "Filter endpoints by cluster name field")
 	endpointConfigCmd.PersistentFlags().StringVar(&status, "status", "", "Filter endpoints by status field")
 	endpointConfigCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
-		"Envoy config dump JSON file")
+		"Envoy config dump JSON file, or - for stdin")
 
 	return endpointConfigCmd
 }
@@ -1011,7 +1406,7 @@ func edsConfigCmd() *cobra.Command {
 			switch outputFormat {
 			case summaryOutput:
 				return configWriter.PrintEndpointsSummary(filter)
-			case jsonOutput, yamlOutput:
+			case jsonOutput, yamlOutput, protoOutput:
 				return configWriter.PrintEndpoints(filter, outputFormat)
 			default:
 				return fmt.Errorf("output format %q not supported", outputFormat)
@@ -1020,19 +1415,20 @@ func edsConfigCmd() *cobra.Command {
 		ValidArgsFunction: validPodsNameArgs,
 	}
 
-	endpointConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short")
+	endpointConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short|proto")
 	endpointConfigCmd.PersistentFlags().StringVar(&address, "address", "", "Filter endpoints by address field")
 	endpointConfigCmd.PersistentFlags().IntVar(&port, "port", 0, "Filter endpoints by Port field")
 	endpointConfigCmd.PersistentFlags().StringVar(&clusterName, "cluster", "", "Filter endpoints by cluster name field")
 	endpointConfigCmd.PersistentFlags().StringVar(&status, "status", "", "Filter endpoints by status field")
 	endpointConfigCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
-		"Envoy config dump JSON file")
+		"Envoy config dump JSON file, or - for stdin")
 
 	return endpointConfigCmd
 }
 
 func bootstrapConfigCmd() *cobra.Command {
 	var podName, podNamespace string
+	var showFlags, showVersions bool
 
 	// Shadow outputVariable since this command uses a different default value
 	var outputFormat string
@@ -1050,6 +1446,12 @@ func bootstrapConfigCmd() *cobra.Command {
 
   # Show a human-readable Istio and Envoy version summary
   istioctl proxy-config bootstrap -o short
+
+  # Show the Istio and Envoy versions of the proxy as JSON
+  istioctl proxy-config bootstrap <pod-name[.namespace]> --versions -o json
+
+  # Show the Envoy runtime flags and Istio feature flags of the proxy
+  istioctl proxy-config bootstrap <pod-name[.namespace]> --flags
 `,
 		Aliases: []string{"b"},
 		Args: func(cmd *cobra.Command, args []string) error {
@@ -1057,6 +1459,9 @@ func bootstrapConfigCmd() *cobra.Command {
 				cmd.Println(cmd.UsageString())
 				return fmt.Errorf("bootstrap requires pod name or --file parameter")
 			}
+			if showFlags && showVersions {
+				return fmt.Errorf("--flags and --versions are mutually exclusive")
+			}
 			return nil
 		},
 		RunE: func(c *cobra.Command, args []string) error {
@@ -1074,10 +1479,31 @@ func bootstrapConfigCmd() *cobra.Command {
 				return err
 			}
 
+			if showFlags {
+				if !c.Flags().Changed("output") {
+					outputFormat = summaryOutput
+				}
+				switch outputFormat {
+				case summaryOutput, jsonOutput, yamlOutput:
+					return configWriter.PrintFlags(outputFormat)
+				default:
+					return fmt.Errorf("output format %q not supported", outputFormat)
+				}
+			}
+			if showVersions {
+				switch outputFormat {
+				case summaryOutput:
+					return configWriter.PrintVersionSummary()
+				case jsonOutput, yamlOutput:
+					return configWriter.PrintVersionDump(outputFormat)
+				default:
+					return fmt.Errorf("output format %q not supported", outputFormat)
+				}
+			}
 			switch outputFormat {
 			case summaryOutput:
 				return configWriter.PrintVersionSummary()
-			case jsonOutput, yamlOutput:
+			case jsonOutput, yamlOutput, protoOutput:
 				return configWriter.PrintBootstrapDump(outputFormat)
 			default:
 				return fmt.Errorf("output format %q not supported", outputFormat)
@@ -1086,15 +1512,25 @@ func bootstrapConfigCmd() *cobra.Command {
 		ValidArgsFunction: validPodsNameArgs,
 	}
 
-	bootstrapConfigCmd.Flags().StringVarP(&outputFormat, "output", "o", jsonOutput, "Output format: one of json|yaml|short")
+	bootstrapConfigCmd.Flags().StringVarP(&outputFormat, "output", "o", jsonOutput, "Output format: one of json|yaml|short|proto")
+	bootstrapConfigCmd.Flags().BoolVar(&showFlags, "flags", false,
+		"Show the Envoy runtime flags and the Istio feature flags of the node metadata instead of the bootstrap, "+
+			"as a table unless an output format is set")
+	bootstrapConfigCmd.Flags().BoolVar(&showVersions, "versions", false,
+		"Show the Istio, Envoy and TLS library versions of the proxy instead of the bootstrap, "+
+			"as JSON unless another output format is set")
 	bootstrapConfigCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
-		"Envoy config dump JSON file")
+		"Envoy config dump JSON file, or - for stdin")
 
 	return bootstrapConfigCmd
 }
 
 func secretConfigCmd() *cobra.Command {
-	var podName, podNamespace string
+	var (
+		podName, podNamespace, verifyAgainstPeer string
+		showChain                                bool
+		expirationWarningDays                    int
+	)
 
 	secretConfigCmd := &cobra.Command{
 		Use:   "secret [<type>/]<name>[.<namespace>]",
@@ -1105,8 +1541,15 @@ func secretConfigCmd() *cobra.Command {
 
   # Retrieve full bootstrap without using Kubernetes API
   ssh <user@hostname> 'curl localhost:15000/config_dump' > envoy-config.json
-  istioctl proxy-config secret --file envoy-config.json`,
-		Aliases: []string{"secrets", "s"},
+  istioctl proxy-config secret --file envoy-config.json
+
+  # Show the SANs, issuer, key and OCSP status of each certificate of the chains, and mark the certificates
+  # expiring within 7 days.
+  istioctl proxy-config secret <pod-name[.namespace]> --show-chain --expiration-warning 7
+
+  # Verify that two pods trust each other's workload certificates, including their trust domain aliases.
+  istioctl proxy-config certificates <pod-name[.namespace]> --verify-against-peer <peer-name[.namespace]>`,
+		Aliases: []string{"secrets", "s", "certificates"},
 		Args: func(cmd *cobra.Command, args []string) error {
 			if (len(args) == 1) != (configDumpFile == "") {
 				cmd.Println(cmd.UsageString())
@@ -1117,10 +1560,12 @@ func secretConfigCmd() *cobra.Command {
 		RunE: func(c *cobra.Command, args []string) error {
 			var configWriter *configdump.ConfigWriter
 			var err error
+			name := configDumpFile
 			if len(args) == 1 {
 				if podName, podNamespace, err = getPodName(args[0]); err != nil {
 					return err
 				}
+				name = podName + "." + podNamespace
 				configWriter, err = setupPodConfigdumpWriter(podName, podNamespace, false, c.OutOrStdout())
 			} else {
 				configWriter, err = setupFileConfigdumpWriter(configDumpFile, c.OutOrStdout())
@@ -1128,10 +1573,24 @@ func secretConfigCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if verifyAgainstPeer != "" {
+				peerName, peerNamespace, err := getPodName(verifyAgainstPeer)
+				if err != nil {
+					return err
+				}
+				peerWriter, err := setupPodConfigdumpWriter(peerName, peerNamespace, false, c.OutOrStdout())
+				if err != nil {
+					return err
+				}
+				return configWriter.PrintPeerTrust(name, peerWriter, peerName+"."+peerNamespace, time.Now())
+			}
 			switch outputFormat {
 			case summaryOutput:
-				return configWriter.PrintSecretSummary()
-			case jsonOutput, yamlOutput:
+				return configWriter.PrintSecretSummary(sdscompare.SDSWriterOptions{
+					ShowChain:         showChain,
+					ExpirationWarning: time.Duration(expirationWarningDays) * 24 * time.Hour,
+				})
+			case jsonOutput, yamlOutput, protoOutput:
 				return configWriter.PrintSecretDump(outputFormat)
 			default:
 				return fmt.Errorf("output format %q not supported", outputFormat)
@@ -1140,13 +1599,132 @@ func secretConfigCmd() *cobra.Command {
 		ValidArgsFunction: validPodsNameArgs,
 	}
 
-	secretConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short")
+	secretConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short|proto")
 	secretConfigCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
-		"Envoy config dump JSON file")
+		"Envoy config dump JSON file, or - for stdin")
+	secretConfigCmd.PersistentFlags().StringVar(&verifyAgainstPeer, "verify-against-peer", "",
+		"Verify that the workload certificates of the pod and of the given peer pod chain to roots trusted by the other, "+
+			"and that their trust domains are accepted by the other")
+	secretConfigCmd.PersistentFlags().BoolVar(&showChain, "show-chain", false,
+		"Show the subject, issuer, SANs, serial number, key type and size of each certificate of the secrets, "+
+			"and the status of their stapled OCSP response")
+	secretConfigCmd.PersistentFlags().IntVar(&expirationWarningDays, "expiration-warning", 0,
+		"Mark the certificates expiring within the given number of days, 0 to disable")
 	secretConfigCmd.Long += "\n\n" + ExperimentalMsg
 	return secretConfigCmd
 }
 
+func ecdsConfigCmd() *cobra.Command {
+	var podName, podNamespace string
+
+	ecdsConfigCmd := &cobra.Command{
+		Use:   "ecds [<type>/]<name>[.<namespace>]",
+		Short: "Retrieves extension configuration for the Envoy in the specified pod",
+		Long: `Retrieve information about the extension configurations received over ECDS, such as the filters of ` +
+			`WasmPlugins, by the Envoy instance in the specified pod.`,
+		Example: `  # Retrieve summary about the extension configurations for a given pod from Envoy.
+  istioctl proxy-config ecds <pod-name[.namespace]>
+
+  # Retrieve full extension configurations in YAML format for a given pod from Envoy.
+  istioctl proxy-config ecds <pod-name[.namespace]> -o yaml
+
+  # Retrieve extension configurations without using Kubernetes API
+  ssh <user@hostname> 'curl localhost:15000/config_dump' > envoy-config.json
+  istioctl proxy-config ecds --file envoy-config.json`,
+		Aliases: []string{"ec"},
+		Args: func(cmd *cobra.Command, args []string) error {
+			if (len(args) == 1) != (configDumpFile == "") {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("ecds requires pod name or --file parameter")
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			var configWriter *configdump.ConfigWriter
+			var err error
+			if len(args) == 1 {
+				if podName, podNamespace, err = getPodName(args[0]); err != nil {
+					return err
+				}
+				configWriter, err = setupPodConfigdumpWriter(podName, podNamespace, false, c.OutOrStdout())
+			} else {
+				configWriter, err = setupFileConfigdumpWriter(configDumpFile, c.OutOrStdout())
+			}
+			if err != nil {
+				return err
+			}
+			switch outputFormat {
+			case summaryOutput:
+				return configWriter.PrintEcdsSummary()
+			case jsonOutput, yamlOutput, protoOutput:
+				return configWriter.PrintEcdsDump(outputFormat)
+			default:
+				return fmt.Errorf("output format %q not supported", outputFormat)
+			}
+		},
+		ValidArgsFunction: validPodsNameArgs,
+	}
+
+	ecdsConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short|proto")
+	ecdsConfigCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
+		"Envoy config dump JSON file, or - for stdin")
+	ecdsConfigCmd.Long += "\n\n" + ExperimentalMsg
+	return ecdsConfigCmd
+}
+
+func traceConfigCmd() *cobra.Command {
+	var podName, podNamespace, traceHost string
+	var tracePort int
+
+	traceConfigCmd := &cobra.Command{
+		Use:   "trace [<type>/]<name>[.<namespace>]",
+		Short: "Traces how the Envoy in the specified pod routes the traffic to a host and port",
+		Long: `Trace how the Envoy instance in the specified pod routes the traffic to a host and port, from the listeners ` +
+			`receiving it, to the routes of the virtual host of the host, with their weights, timeouts and retry policies, ` +
+			`to the clusters they send it to, with their TLS settings and endpoints.`,
+		Example: `  # Trace how a pod routes the traffic to port 9080 of the reviews service.
+  istioctl proxy-config trace <pod-name[.namespace]> --host reviews.default.svc.cluster.local --port 9080
+
+  # Trace without using Kubernetes API
+  ssh <user@hostname> 'curl "localhost:15000/config_dump?include_eds"' > envoy-config.json
+  istioctl proxy-config trace --file envoy-config.json --host reviews.default.svc.cluster.local --port 9080`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if (len(args) == 1) != (configDumpFile == "") {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("trace requires pod name or --file parameter")
+			}
+			if traceHost == "" || tracePort <= 0 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("trace requires --host and --port")
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			var configWriter *configdump.ConfigWriter
+			var err error
+			if len(args) == 1 {
+				if podName, podNamespace, err = getPodName(args[0]); err != nil {
+					return err
+				}
+				configWriter, err = setupPodConfigdumpWriter(podName, podNamespace, true, c.OutOrStdout())
+			} else {
+				configWriter, err = setupFileConfigdumpWriter(configDumpFile, c.OutOrStdout())
+			}
+			if err != nil {
+				return err
+			}
+			return configWriter.PrintTrace(traceHost, uint32(tracePort))
+		},
+		ValidArgsFunction: validPodsNameArgs,
+	}
+
+	traceConfigCmd.PersistentFlags().StringVar(&traceHost, "host", "", "Host the traffic is sent to")
+	traceConfigCmd.PersistentFlags().IntVar(&tracePort, "port", 0, "Port the traffic is sent to")
+	traceConfigCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
+		"Envoy config dump JSON file, including the endpoints, or - for stdin")
+	return traceConfigCmd
+}
+
 func rootCACompareConfigCmd() *cobra.Command {
 	var podName1, podName2, podNamespace1, podNamespace2 string
 
@@ -1217,6 +1795,126 @@ func rootCACompareConfigCmd() *cobra.Command {
 	return rootCACompareConfigCmd
 }
 
+func diffConfigCmd() *cobra.Command {
+	var fromFile, toFile string
+
+	diffConfigCmd := &cobra.Command{
+		Use:   "diff [[pod/]<name-1>[.<namespace-1>]] [[pod/]<name-2>[.<namespace-2>]]",
+		Short: "Compares the configuration of two Envoys",
+		Long: `Prints the listeners, clusters, routes and secrets added, removed or modified from the config dump of the
+first Envoy to the one of the second, with the diff of each modified resource. Resources are matched by name, their
+order and the version and update time of the dumps are ignored. Either config dump can be read from a file instead of
+a pod, for instance to compare a pod with a dump saved before an upgrade.`,
+		Example: `  # Compare the configuration of two pods.
+  istioctl proxy-config diff <pod-name-1[.namespace]> <pod-name-2[.namespace]>
+
+  # Compare a config dump saved earlier with the current configuration of the pod.
+  istioctl proxy-config diff --from-file envoy-config.json <pod-name[.namespace]>`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			expected := 2
+			if fromFile != "" {
+				expected--
+			}
+			if toFile != "" {
+				expected--
+			}
+			if len(args) != expected {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("diff requires two pods or files, with a pod argument for each missing --from-file or --to-file")
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			writer := func(file string) (*configdump.ConfigWriter, string, error) {
+				if file != "" {
+					w, err := setupFileConfigdumpWriter(file, c.OutOrStdout())
+					return w, file, err
+				}
+				podName, podNamespace, err := getPodName(args[0])
+				if err != nil {
+					return nil, "", err
+				}
+				args = args[1:]
+				w, err := setupPodConfigdumpWriter(podName, podNamespace, false, c.OutOrStdout())
+				return w, podName + "." + podNamespace, err
+			}
+			from, fromName, err := writer(fromFile)
+			if err != nil {
+				return err
+			}
+			to, toName, err := writer(toFile)
+			if err != nil {
+				return err
+			}
+			return configdump.NewDiffWriter(c.OutOrStdout(), from, fromName, to, toName).PrintDiff()
+		},
+		ValidArgsFunction: validPodsNameArgs,
+	}
+
+	diffConfigCmd.PersistentFlags().StringVar(&fromFile, "from-file", "",
+		"Envoy config dump JSON file to compare from, instead of the first pod")
+	diffConfigCmd.PersistentFlags().StringVar(&toFile, "to-file", "",
+		"Envoy config dump JSON file to compare to, instead of the second pod")
+
+	return diffConfigCmd
+}
+
+func groupedStatsConfigCmd() *cobra.Command {
+	var podName, podNamespace string
+
+	groupedStatsConfigCmd := &cobra.Command{
+		Use:   "stats [<type>/]<name>[.<namespace>]",
+		Short: "Retrieves the Envoy stats of the specified pod grouped by service, listener and certificate",
+		Long: `Retrieve the stats of the Envoy instance in the specified pod, grouped by upstream service, listener and
+certificate rather than by raw stat name: requests, response codes, retries and connection failures of the subsets of
+each service, the connections and TLS errors of each listener, and the SDS updates of each certificate.`,
+		Example: `  # Retrieve the stats of a pod grouped by service, listener and certificate.
+  istioctl proxy-config stats <pod-name[.namespace]>
+
+  # Retrieve the grouped stats as JSON.
+  istioctl proxy-config stats <pod-name[.namespace]> -o json
+
+  # Retrieve the grouped stats without using Kubernetes API
+  ssh <user@hostname> 'curl localhost:15000/stats' > envoy-stats.txt
+  istioctl proxy-config stats --file envoy-stats.txt
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if (len(args) == 1) != (configDumpFile == "") {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("stats requires pod name or --file parameter")
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			var stats []byte
+			var err error
+			if len(args) == 1 {
+				if podName, podNamespace, err = getPodName(args[0]); err != nil {
+					return err
+				}
+				stats, err = extractEnvoyStats(podName, podNamespace)
+			} else {
+				stats, err = readFile(configDumpFile)
+			}
+			if err != nil {
+				return err
+			}
+			w := &envoystats.Writer{Stdout: c.OutOrStdout()}
+			if err := w.Prime(stats); err != nil {
+				return err
+			}
+			return w.PrintStats(outputFormat)
+		},
+		ValidArgsFunction: validPodsNameArgs,
+	}
+
+	groupedStatsConfigCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short")
+	groupedStatsConfigCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
+		"Envoy stats text file, or - for stdin")
+
+	return groupedStatsConfigCmd
+}
+
 func proxyConfig() *cobra.Command {
 	configCmd := &cobra.Command{
 		Use:   "proxy-config",
@@ -1238,7 +1936,11 @@ func proxyConfig() *cobra.Command {
 	configCmd.AddCommand(endpointConfigCmd())
 	configCmd.AddCommand(edsConfigCmd())
 	configCmd.AddCommand(secretConfigCmd())
+	configCmd.AddCommand(ecdsConfigCmd())
+	configCmd.AddCommand(traceConfigCmd())
 	configCmd.AddCommand(rootCACompareConfigCmd())
+	configCmd.AddCommand(diffConfigCmd())
+	configCmd.AddCommand(groupedStatsConfigCmd())
 
 	return configCmd
 }
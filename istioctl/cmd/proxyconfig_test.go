@@ -109,6 +109,21 @@ func TestProxyConfig(t *testing.T) {
 			expectedString: "unable to retrieve Pod: pods \"invalid\" not found",
 			wantException:  true, // "istioctl proxy-config secret invalid" should fail
 		},
+		{ // trace without host
+			args:           strings.Split("proxy-config trace invalid --port 9080", " "),
+			expectedString: "trace requires --host and --port",
+			wantException:  true,
+		},
+		{ // trace invalid
+			args:           strings.Split("proxy-config trace invalid --host reviews --port 9080", " "),
+			expectedString: "unable to retrieve Pod: pods \"invalid\" not found",
+			wantException:  true,
+		},
+		{ // ecds invalid
+			args:           strings.Split("proxy-config ecds invalid", " "),
+			expectedString: "unable to retrieve Pod: pods \"invalid\" not found",
+			wantException:  true, // "istioctl proxy-config ecds invalid" should fail
+		},
 		{ // endpoint invalid
 			args:           strings.Split("proxy-config endpoint invalid", " "),
 			expectedString: "unable to retrieve Pod: pods \"invalid\" not found",
@@ -159,6 +174,24 @@ func TestProxyConfig(t *testing.T) {
 			expectedString:   `config dump has no configuration type`,
 			wantException:    true,
 		},
+		{ // config dump read from a file
+			args:           strings.Split("pc bootstrap -o short --file ../pkg/writer/envoy/configdump/testdata/configdump.json", " "),
+			expectedString: "Istio Version",
+		},
+		{ // file which is not a config dump
+			args:           strings.Split("pc all -o json --file ../pkg/writer/envoy/configdump/testdata/versiondump.json", " "),
+			expectedString: "config dump has no configs, it is not the output of the Envoy admin config_dump endpoint",
+			wantException:  true,
+		},
+		{ // config dump instead of the output of the clusters endpoint
+			args:           strings.Split("pc endpoint --file ../pkg/writer/envoy/configdump/testdata/configdump.json", " "),
+			expectedString: "no cluster statuses found",
+			wantException:  true,
+		},
+		{ // stats read from a file, grouped by service
+			args:           strings.Split("pc stats --file ../pkg/writer/envoy/stats/testdata/stats.txt", " "),
+			expectedString: "reviews.default.svc.cluster.local   outbound    9080   v1,v2",
+		},
 	}
 
 	for i, c := range cases {
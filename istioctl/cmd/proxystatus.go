@@ -21,6 +21,7 @@ import (
 	"os"
 
 	xdsapi "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/prometheus/client_golang/prometheus/push"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/rest"
 
@@ -36,6 +37,7 @@ import (
 
 func statusCommand() *cobra.Command {
 	var opts clioptions.ControlPlaneOptions
+	var outputFormat, pushgateway string
 
 	statusCmd := &cobra.Command{
 		Use:   "proxy-status [<type>/]<name>[.<namespace>]",
@@ -57,6 +59,15 @@ Retrieves last sent and last acknowledged xDS sync from Istiod to each Envoy in
   kubectl port-forward -n istio-system istio-egressgateway-59585c5b9c-ndc59 15000 &
   curl localhost:15000/config_dump > cd.json
   istioctl proxy-status istio-egressgateway-59585c5b9c-ndc59.istio-system --file cd.json
+
+  # Retrieve sync status for all Envoys as a JSON document
+  istioctl proxy-status -o json
+
+  # Export the sync status, version skew and certificate expiry of all Envoys as OpenMetrics
+  istioctl proxy-status -o openmetrics > proxy-status.prom
+
+  # Push the same metrics to a Prometheus Pushgateway
+  istioctl proxy-status --pushgateway http://pushgateway.monitoring:9091
 `,
 		Aliases: []string{"ps"},
 		Args: func(cmd *cobra.Command, args []string) error {
@@ -64,6 +75,12 @@ Retrieves last sent and last acknowledged xDS sync from Istiod to each Envoy in
 				cmd.Println(cmd.UsageString())
 				return fmt.Errorf("--file can only be used when pod-name is specified")
 			}
+			if outputFormat != "" && outputFormat != jsonOutput && outputFormat != openMetricsOutput {
+				return fmt.Errorf("unknown output format %q, must be one of %s|%s", outputFormat, jsonOutput, openMetricsOutput)
+			}
+			if len(args) > 0 && (outputFormat != "" || pushgateway != "") {
+				return fmt.Errorf("--output and --pushgateway can only be used when pod-name is not specified")
+			}
 			return nil
 		},
 		RunE: func(c *cobra.Command, args []string) error {
@@ -104,18 +121,67 @@ Retrieves last sent and last acknowledged xDS sync from Istiod to each Envoy in
 			if err != nil {
 				return err
 			}
-			sw := pilot.StatusWriter{Writer: c.OutOrStdout()}
-			return sw.PrintAll(statuses)
+			if outputFormat != openMetricsOutput && pushgateway == "" {
+				sw := pilot.StatusWriter{Writer: c.OutOrStdout()}
+				if outputFormat == jsonOutput {
+					return sw.PrintJSON(statuses)
+				}
+				return sw.PrintAll(statuses)
+			}
+			mw, err := newProxyStatusMetricsWriter(kubeClient, c.OutOrStdout())
+			if err != nil {
+				return err
+			}
+			if pushgateway != "" {
+				reg, err := mw.Registry(statuses)
+				if err != nil {
+					return err
+				}
+				if err := push.New(pushgateway, "istioctl_proxy_status").Gatherer(reg).Push(); err != nil {
+					return fmt.Errorf("failed to push metrics to %s: %v", pushgateway, err)
+				}
+			}
+			switch outputFormat {
+			case openMetricsOutput:
+				return mw.PrintAll(statuses)
+			case jsonOutput:
+				sw := pilot.StatusWriter{Writer: c.OutOrStdout()}
+				return sw.PrintJSON(statuses)
+			}
+			return nil
 		},
 	}
 
 	opts.AttachControlPlaneFlags(statusCmd)
 	statusCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
 		"Envoy config dump JSON file")
+	statusCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "",
+		"Output format for the status of all Envoys: json prints a versioned JSON document, "+
+			"openmetrics exports it as OpenMetrics samples")
+	statusCmd.PersistentFlags().StringVar(&pushgateway, "pushgateway", "",
+		"URL of a Prometheus Pushgateway to push the status of all Envoys to, as metrics")
 
 	return statusCmd
 }
 
+const openMetricsOutput = "openmetrics"
+
+// newProxyStatusMetricsWriter fetches the istiod versions and the certificates istiod issued, which the proxy status
+// metrics are computed from. Certificates are optional, as the istiod CA may be disabled.
+func newProxyStatusMetricsWriter(kubeClient kube.ExtendedClient, out io.Writer) (*pilot.MetricsWriter, error) {
+	versions, err := kubeClient.AllDiscoveryDo(context.TODO(), istioNamespace, "/version")
+	if err != nil {
+		return nil, err
+	}
+	mw := &pilot.MetricsWriter{Writer: out, IstiodVersions: versions}
+	if res, err := kubeClient.AllDiscoveryDo(context.TODO(), istioNamespace, "/debug/certz"); err == nil {
+		if mw.Certificates, err = parseIssuedCertificates(res); err != nil {
+			log.Warnf("ignoring certificates issued by istiod: %v", err)
+		}
+	}
+	return mw, nil
+}
+
 func readConfigFile(filename string) ([]byte, error) {
 	file := os.Stdin
 	if filename != "-" {
@@ -0,0 +1,258 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// proxyVersionGroup is a set of pods running the same proxy image, injected by the same control plane revision.
+type proxyVersionGroup struct {
+	Revision string `json:"revision"`
+	Image    string `json:"image"`
+	// ExpectedImage is the proxy image the revision currently injects, empty if the revision is not installed.
+	ExpectedImage string   `json:"expectedImage,omitempty"`
+	Pods          int      `json:"pods"`
+	Namespaces    []string `json:"namespaces"`
+	Skewed        bool     `json:"skewed"`
+}
+
+// proxyRestart is a workload to restart for its pods to be injected with the current proxy image of their revision.
+type proxyRestart struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	SkewedPods int    `json:"skewedPods"`
+	Command    string `json:"command"`
+}
+
+// namespaceRestarts are the restarts of a step of the plan, one namespace at a time.
+type namespaceRestarts struct {
+	Namespace string         `json:"namespace"`
+	Workloads []proxyRestart `json:"workloads"`
+}
+
+type proxyVersionReport struct {
+	Groups []proxyVersionGroup `json:"groups"`
+	// Plan restarts the namespaces with the fewest skewed pods first, to limit the impact of a bad rollout.
+	Plan []namespaceRestarts `json:"plan"`
+}
+
+func proxyVersionsCommand() *cobra.Command {
+	output := summaryOutput
+	cmd := &cobra.Command{
+		Use:   "proxy-versions",
+		Short: "Report the proxy versions of the data plane and a restart plan to converge them",
+		Long: `Groups the pods with a sidecar by the proxy image they run and the control plane revision which injected
+them, and compares the image to the one the revision currently injects. Workloads with outdated proxies are listed in
+a restart plan, one namespace at a time starting with the namespaces with the fewest outdated pods, as the commands to
+run. Nothing is restarted, the plan is meant for review and change tickets.`,
+		Example: `  # Show the proxy version skew of the cluster and the restart plan
+  istioctl x proxy-versions
+
+  # Export the restart plan for a change ticket
+  istioctl x proxy-versions -o yaml > plan.yaml`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("proxy-versions takes no arguments")
+			}
+			if output != summaryOutput && output != jsonOutput && output != yamlOutput {
+				return fmt.Errorf("unknown output format %q, must be one of short|json|yaml", output)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := kubeClient(kubeconfig, configContext)
+			if err != nil {
+				return fmt.Errorf("failed to create k8s client: %v", err)
+			}
+			ctx := context.Background()
+			pods, err := getPods(ctx, client)
+			if err != nil {
+				return err
+			}
+			images, err := getInjectedImages(ctx, client)
+			if err != nil {
+				return err
+			}
+			return writeProxyVersions(cmd.OutOrStdout(), buildProxyVersionReport(pods, images), output)
+		},
+	}
+	cmd.PersistentFlags().StringVarP(&output, "output", "o", summaryOutput, "Output format: one of short|json|yaml")
+	return cmd
+}
+
+// buildProxyVersionReport groups the pods with a sidecar by revision and proxy image, and plans the restart of the
+// workloads whose proxy image is not the one their revision injects.
+func buildProxyVersionReport(allPods map[resource.Namespace][]v1.Pod, injectedImages map[string]string) proxyVersionReport {
+	type groupKey struct{ revision, image string }
+	groups := map[groupKey]*proxyVersionGroup{}
+	groupNamespaces := map[groupKey]sets.Set{}
+	restarts := map[string]map[string]*proxyRestart{}
+	for ns, pods := range allPods {
+		if util.IsSystemNamespace(ns) {
+			continue
+		}
+		for i := range pods {
+			pod := &pods[i]
+			image := proxyImage(pod)
+			if image == "" || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+				continue
+			}
+			revision := extractRevisionFromPod(pod)
+			if revision == "" {
+				revision = "default"
+			}
+			key := groupKey{revision, image}
+			g, ok := groups[key]
+			if !ok {
+				g = &proxyVersionGroup{Revision: revision, Image: image, ExpectedImage: injectedImages[revision]}
+				g.Skewed = g.ExpectedImage != "" && g.ExpectedImage != image
+				groups[key] = g
+				groupNamespaces[key] = sets.New()
+			}
+			g.Pods++
+			groupNamespaces[key].Insert(string(ns))
+			if !g.Skewed {
+				continue
+			}
+
+			deployMeta, typeMeta := kube.GetDeployMetaFromPod(pod)
+			kind, name := typeMeta.Kind, deployMeta.Name
+			command := fmt.Sprintf("kubectl rollout restart %s/%s -n %s", strings.ToLower(kind), name, ns)
+			if kind != "Deployment" && kind != "StatefulSet" && kind != "DaemonSet" {
+				// Other workloads can not be restarted as a whole, recreate their pods one by one.
+				kind, name = "Pod", pod.Name
+				command = fmt.Sprintf("kubectl delete pod %s -n %s", name, ns)
+			}
+			if restarts[string(ns)] == nil {
+				restarts[string(ns)] = map[string]*proxyRestart{}
+			}
+			r, ok := restarts[string(ns)][kind+"/"+name]
+			if !ok {
+				r = &proxyRestart{Kind: kind, Name: name, Command: command}
+				restarts[string(ns)][kind+"/"+name] = r
+			}
+			r.SkewedPods++
+		}
+	}
+
+	report := proxyVersionReport{Groups: []proxyVersionGroup{}, Plan: []namespaceRestarts{}}
+	for key, g := range groups {
+		g.Namespaces = groupNamespaces[key].SortedList()
+		report.Groups = append(report.Groups, *g)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if a.Revision != b.Revision {
+			return a.Revision < b.Revision
+		}
+		return a.Image < b.Image
+	})
+
+	skewedPods := map[string]int{}
+	for ns, workloads := range restarts {
+		step := namespaceRestarts{Namespace: ns}
+		for _, r := range workloads {
+			step.Workloads = append(step.Workloads, *r)
+			skewedPods[ns] += r.SkewedPods
+		}
+		sort.Slice(step.Workloads, func(i, j int) bool {
+			if step.Workloads[i].Kind != step.Workloads[j].Kind {
+				return step.Workloads[i].Kind < step.Workloads[j].Kind
+			}
+			return step.Workloads[i].Name < step.Workloads[j].Name
+		})
+		report.Plan = append(report.Plan, step)
+	}
+	sort.Slice(report.Plan, func(i, j int) bool {
+		a, b := report.Plan[i].Namespace, report.Plan[j].Namespace
+		if skewedPods[a] != skewedPods[b] {
+			return skewedPods[a] < skewedPods[b]
+		}
+		return a < b
+	})
+	return report
+}
+
+// proxyImage returns the image of the istio-proxy sidecar of the pod, empty if it has none.
+func proxyImage(pod *v1.Pod) string {
+	for _, c := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		if c.Name == "istio-proxy" {
+			return c.Image
+		}
+	}
+	return ""
+}
+
+func writeProxyVersions(out io.Writer, report proxyVersionReport, format string) error {
+	switch format {
+	case jsonOutput:
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(b))
+		return err
+	case yamlOutput:
+		b, err := yaml.Marshal(report)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(b)
+		return err
+	}
+	w := new(tabwriter.Writer).Init(out, 0, 8, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "REVISION\tPROXY IMAGE\tEXPECTED IMAGE\tPODS\tNAMESPACES\tSTATUS")
+	for _, g := range report.Groups {
+		expected, status := g.ExpectedImage, "up to date"
+		switch {
+		case expected == "":
+			expected, status = "-", "revision not installed"
+		case g.Skewed:
+			status = "needs restart"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", g.Revision, g.Image, expected, g.Pods, strings.Join(g.Namespaces, ","), status)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(report.Plan) == 0 {
+		_, err := fmt.Fprintln(out, "\nAll proxies run the image of their revision, there is nothing to restart.")
+		return err
+	}
+	_, _ = fmt.Fprintln(out, "\nRestart plan (dry run), one namespace at a time:")
+	for i, step := range report.Plan {
+		_, _ = fmt.Fprintf(out, "%d. Namespace %s:\n", i+1, step.Namespace)
+		for _, r := range step.Workloads {
+			_, _ = fmt.Fprintf(out, "   %s  # %d outdated pods\n", r.Command, r.SkewedPods)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/api/annotation"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestBuildProxyVersionReport(t *testing.T) {
+	proxyPod := func(namespace, name, replicaSet, revision, image string) v1.Pod {
+		pod := coveragePod(namespace, name, replicaSet, true, map[string]string{
+			annotation.SidecarStatus.Name: `{"revision":"` + revision + `"}`,
+		})
+		pod.Spec.Containers[1].Image = image
+		return pod
+	}
+	pods := map[resource.Namespace][]v1.Pod{
+		"big": {
+			proxyPod("big", "a-abc-1", "a-abc", "", "proxyv2:1.16.0"),
+			proxyPod("big", "a-abc-2", "a-abc", "", "proxyv2:1.16.0"),
+			proxyPod("big", "b-abc-1", "b-abc", "", "proxyv2:1.16.1"),
+		},
+		"small": {
+			proxyPod("small", "c-abc-1", "c-abc", "canary", "proxyv2:1.16.0"),
+			coveragePod("small", "d-abc-1", "d-abc", false, nil),
+		},
+		"other": {
+			proxyPod("other", "e-abc-1", "e-abc", "old", "proxyv2:1.15.0"),
+		},
+		"kube-system": {
+			proxyPod("kube-system", "f-abc-1", "f-abc", "", "proxyv2:1.16.0"),
+		},
+	}
+	images := map[string]string{"default": "proxyv2:1.16.1", "canary": "proxyv2:1.17.0"}
+
+	report := buildProxyVersionReport(pods, images)
+	assert.Equal(t, report, proxyVersionReport{
+		Groups: []proxyVersionGroup{
+			{Revision: "canary", Image: "proxyv2:1.16.0", ExpectedImage: "proxyv2:1.17.0", Pods: 1, Namespaces: []string{"small"}, Skewed: true},
+			{Revision: "default", Image: "proxyv2:1.16.0", ExpectedImage: "proxyv2:1.16.1", Pods: 2, Namespaces: []string{"big"}, Skewed: true},
+			{Revision: "default", Image: "proxyv2:1.16.1", ExpectedImage: "proxyv2:1.16.1", Pods: 1, Namespaces: []string{"big"}},
+			{Revision: "old", Image: "proxyv2:1.15.0", Pods: 1, Namespaces: []string{"other"}},
+		},
+		Plan: []namespaceRestarts{
+			{Namespace: "small", Workloads: []proxyRestart{
+				{Kind: "Deployment", Name: "c", SkewedPods: 1, Command: "kubectl rollout restart deployment/c -n small"},
+			}},
+			{Namespace: "big", Workloads: []proxyRestart{
+				{Kind: "Deployment", Name: "a", SkewedPods: 2, Command: "kubectl rollout restart deployment/a -n big"},
+			}},
+		},
+	})
+
+	var out bytes.Buffer
+	assert.NoError(t, writeProxyVersions(&out, report, summaryOutput))
+	for _, want := range []string{
+		"revision not installed",
+		"1. Namespace small:\n   kubectl rollout restart deployment/c -n small  # 1 outdated pods\n",
+		"2. Namespace big:\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output %q does not contain %q", out.String(), want)
+		}
+	}
+}
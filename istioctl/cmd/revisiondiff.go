@@ -0,0 +1,145 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/util/configdump"
+	configdumpwriter "istio.io/istio/istioctl/pkg/writer/envoy/configdump"
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+func revisionDiffCommand() *cobra.Command {
+	var pod, from, to string
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare the configuration two revisions generate for a workload",
+		Long: `Asks an istiod of each revision to generate the listeners, clusters and routes of the proxy of a pod, and
+prints the resources added, removed or modified from the first revision to the second, with the diff of each modified
+resource. This previews the data plane changes of moving the workload to another revision, before changing its
+revision label. The configuration is generated for the proxy as it runs now, with its current version.`,
+		Example: `  # Preview the configuration changes of moving a pod from revision 1-19 to 1-20
+  istioctl x revision diff --pod productpage-v1-7d9d8d8b8d-x2x6k.default --from 1-19 --to 1-20`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("diff takes no arguments")
+			}
+			if pod == "" || from == "" || to == "" {
+				return fmt.Errorf("--pod, --from and --to must be specified")
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			podName, podNamespace, err := getPodName(pod)
+			if err != nil {
+				return err
+			}
+			dump, err := extractConfigDump(podName, podNamespace, false)
+			if err != nil {
+				return err
+			}
+			node, err := proxyNode(dump)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %v", podName, podNamespace, err)
+			}
+			fromWriter, err := revisionConfigDump(from, node, c.OutOrStdout())
+			if err != nil {
+				return err
+			}
+			toWriter, err := revisionConfigDump(to, node, c.OutOrStdout())
+			if err != nil {
+				return err
+			}
+			return configdumpwriter.NewDiffWriter(c.OutOrStdout(), fromWriter, "revision "+from, toWriter, "revision "+to).PrintDiff()
+		},
+	}
+	diffCmd.Flags().StringVar(&pod, "pod", "", "Pod of the workload, as <pod-name[.namespace]>")
+	diffCmd.Flags().StringVar(&from, "from", "", "Revision to compare from, usually the current revision of the pod")
+	diffCmd.Flags().StringVar(&to, "to", "", "Revision to compare to")
+	return diffCmd
+}
+
+// proxyNode returns the xDS node of the bootstrap config in the Envoy config dump, as JSON.
+func proxyNode(dump []byte) ([]byte, error) {
+	cd := &configdump.Wrapper{}
+	if err := cd.UnmarshalJSON(dump); err != nil {
+		return nil, err
+	}
+	bootstrap, err := cd.GetBootstrapConfigDump()
+	if err != nil {
+		return nil, err
+	}
+	node := bootstrap.GetBootstrap().GetNode()
+	if node.GetId() == "" {
+		return nil, fmt.Errorf("the bootstrap config has no node")
+	}
+	return protomarshal.Marshal(node)
+}
+
+// revisionConfigDump returns the config dump an istiod of the revision generates for the proxy of the xDS node.
+func revisionConfigDump(revision string, node []byte, out io.Writer) (*configdumpwriter.ConfigWriter, error) {
+	kubeClient, err := kubeClientWithRevision(kubeconfig, configContext, revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create k8s client: %v", err)
+	}
+	istiods, err := kubeClient.GetIstioPods(context.TODO(), istioNamespace, map[string]string{
+		"labelSelector": "app=istiod",
+		"fieldSelector": "status.phase=Running",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(istiods) == 0 {
+		return nil, fmt.Errorf("no running istiod of revision %s in namespace %s", revision, istioNamespace)
+	}
+	fw, err := kubeClient.NewPortForwarder(istiods[0].Name, istiods[0].Namespace, "127.0.0.1", 0, 15014)
+	if err != nil {
+		return nil, err
+	}
+	if err := fw.Start(); err != nil {
+		return nil, fmt.Errorf("failure running port forward process: %v", err)
+	}
+	defer fw.Close()
+	dump, err := postNodeConfigDump(fw.Address(), node)
+	if err != nil {
+		return nil, fmt.Errorf("revision %s: %v", revision, err)
+	}
+	return setupConfigdumpEnvoyConfigWriter(dump, out)
+}
+
+// postNodeConfigDump posts the xDS node to the config dump debug endpoint of the istiod at the address, which
+// returns the config it generates for the proxy of the node.
+func postNodeConfigDump(address string, node []byte) ([]byte, error) {
+	resp, err := http.Post(fmt.Sprintf("http://%s/debug/config_dump", address), "application/json", bytes.NewReader(node))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to generate the config of the proxy: %s: %s", resp.Status, body)
+	}
+	return body, nil
+}
@@ -0,0 +1,64 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProxyNode(t *testing.T) {
+	node, err := proxyNode([]byte(`{"configs": [{
+		"@type": "type.googleapis.com/envoy.admin.v3.BootstrapConfigDump",
+		"bootstrap": {"node": {"id": "sidecar~10.0.0.1~productpage.default~default.svc.cluster.local",
+			"metadata": {"NAMESPACE": "default"}}}
+	}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(node), `"id":"sidecar~10.0.0.1~productpage.default~default.svc.cluster.local"`) ||
+		!strings.Contains(string(node), `"NAMESPACE":"default"`) {
+		t.Errorf("unexpected node %s", node)
+	}
+
+	if _, err := proxyNode([]byte(`{"configs": [{"@type": "type.googleapis.com/envoy.admin.v3.BootstrapConfigDump"}]}`)); err == nil {
+		t.Errorf("expected an error for a bootstrap config without a node")
+	}
+}
+
+func TestPostNodeConfigDump(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.URL.Path != "/debug/config_dump" || string(body) != `{"id":"a"}` {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("invalid xDS node"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"configs": []}`))
+	}))
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "http://")
+
+	dump, err := postNodeConfigDump(address, []byte(`{"id":"a"}`))
+	if err != nil || string(dump) != `{"configs": []}` {
+		t.Errorf("unexpected config dump %s, err: %v", dump, err)
+	}
+	if _, err := postNodeConfigDump(address, []byte(`{}`)); err == nil || !strings.Contains(err.Error(), "invalid xDS node") {
+		t.Errorf("expected the error of istiod, got %v", err)
+	}
+}
@@ -59,6 +59,7 @@ const (
 	FlagNamespace      = "namespace"
 	FlagIstioNamespace = "istioNamespace"
 	FlagCharts         = "charts"
+	FlagOutput         = "output"
 )
 
 var (
@@ -71,6 +72,8 @@ var (
 	namespace        string
 	istioNamespace   string
 	defaultNamespace string
+	// globalOutputFormat is the output format of the commands without an output flag of their own.
+	globalOutputFormat string
 
 	// Create a kubernetes client (or mockClient) for talking to control plane components
 	kubeClientWithRevision = newKubeClientWithRevision
@@ -144,7 +147,12 @@ func GetRootCmd(args []string) *cobra.Command {
 		Long: `Istio configuration command line utility for service operators to
 debug and diagnose their Istio mesh.
 `,
-		PersistentPreRunE: configureLogging,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkGlobalOutput(cmd); err != nil {
+				return err
+			}
+			return configureLogging(cmd, args)
+		},
 	}
 
 	rootCmd.SetArgs(args)
@@ -161,6 +169,10 @@ debug and diagnose their Istio mesh.
 	rootCmd.PersistentFlags().StringVarP(&namespace, FlagNamespace, "n", v1.NamespaceAll,
 		"Config namespace")
 
+	// The flag has no shorthand, some commands use -o for other flags.
+	rootCmd.PersistentFlags().StringVar(&globalOutputFormat, FlagOutput, "",
+		"Output format: json prints a versioned JSON document. Commands not supporting it reject it")
+
 	_ = rootCmd.RegisterFlagCompletionFunc(FlagIstioNamespace, validNamespaceArgs)
 	_ = rootCmd.RegisterFlagCompletionFunc(FlagNamespace, validNamespaceArgs)
 
@@ -238,6 +250,9 @@ debug and diagnose their Istio mesh.
 	experimentalCmd.AddCommand(debugCommand())
 	experimentalCmd.AddCommand(preCheck())
 	experimentalCmd.AddCommand(statsConfigCmd())
+	experimentalCmd.AddCommand(envoyFilterCmd())
+	experimentalCmd.AddCommand(telemetryCmd())
+	experimentalCmd.AddCommand(peerAuthCmd())
 
 	analyzeCmd := Analyze()
 	hideInheritedFlags(analyzeCmd, FlagIstioNamespace)
@@ -251,6 +266,10 @@ debug and diagnose their Istio mesh.
 	hideInheritedFlags(manifestCmd, FlagNamespace, FlagIstioNamespace, FlagCharts)
 	rootCmd.AddCommand(manifestCmd)
 
+	experimentalManifestCmd := mesh.ExperimentalManifestCmd(loggingOptions)
+	hideInheritedFlags(experimentalManifestCmd, FlagNamespace, FlagIstioNamespace, FlagCharts)
+	experimentalCmd.AddCommand(experimentalManifestCmd)
+
 	operatorCmd := mesh.OperatorCmd()
 	hideInheritedFlags(operatorCmd, FlagNamespace, FlagIstioNamespace, FlagCharts)
 	rootCmd.AddCommand(operatorCmd)
@@ -282,6 +301,17 @@ debug and diagnose their Istio mesh.
 	rootCmd.AddCommand(remoteClustersCmd)
 	experimentalCmd.AddCommand(remoteSecretCmd)
 	experimentalCmd.AddCommand(remoteClustersCmd)
+	experimentalCmd.AddCommand(multicluster.NewExternalIstiodCommand())
+	experimentalCmd.AddCommand(createGatewaySecretCmd())
+	experimentalCmd.AddCommand(certificatesCommand())
+	experimentalCmd.AddCommand(serviceAccountsCommand())
+	experimentalCmd.AddCommand(gitopsCommand())
+	experimentalCmd.AddCommand(validate.NewMeshConfigCommand())
+	experimentalCmd.AddCommand(coverageCommand())
+	experimentalCmd.AddCommand(proxyVersionsCommand())
+	experimentalCmd.AddCommand(proxyBrowseCommand())
+	experimentalCmd.AddCommand(exportFixtureCommand())
+	experimentalCmd.AddCommand(simulateCommand())
 
 	rootCmd.AddCommand(collateral.CobraCommand(rootCmd, &doc.GenManHeader{
 		Title:   "Istio Control",
@@ -330,6 +360,42 @@ func hideInheritedFlags(orig *cobra.Command, hidden ...string) {
 	})
 }
 
+// globalOutputAnnotation marks the commands supporting the global --output flag.
+const globalOutputAnnotation = "istioctl.istio.io/global-output"
+
+// supportGlobalOutput marks the command as supporting the global --output flag.
+func supportGlobalOutput(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[globalOutputAnnotation] = "true"
+}
+
+// checkGlobalOutput returns an error if the global --output flag is set for a command which does not support it. The
+// commands with an output flag of their own shadow the global one.
+func checkGlobalOutput(cmd *cobra.Command) error {
+	f := cmd.Flags().Lookup(FlagOutput)
+	if f == nil || !f.Changed || f != cmd.Root().PersistentFlags().Lookup(FlagOutput) {
+		return nil
+	}
+	if cmd.Annotations[globalOutputAnnotation] == "true" {
+		return nil
+	}
+	return fmt.Errorf("%s does not support the --%s flag", cmd.CommandPath(), FlagOutput)
+}
+
+// jsonDocumentOutput returns whether the global --output flag requests a JSON document, or an error for the formats
+// the commands without an output flag of their own do not support.
+func jsonDocumentOutput() (bool, error) {
+	switch globalOutputFormat {
+	case "":
+		return false, nil
+	case jsonOutput:
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown output format %q, must be %s", globalOutputFormat, jsonOutput)
+}
+
 func configureLogging(_ *cobra.Command, _ []string) error {
 	if err := log.Configure(loggingOptions); err != nil {
 		return err
@@ -16,6 +16,8 @@ package cmd
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -80,3 +82,83 @@ func TestHideInheritedFlags(t *testing.T) {
 	checkHelpForFlag(t, got, parentFlag1, false)
 	checkHelpForFlag(t, got, childFlag2, true)
 }
+
+func TestGlobalOutputFlag(t *testing.T) {
+	// The commands with an output flag of their own shadow the global one, the others inherit it.
+	var visit func(c *cobra.Command)
+	visit = func(c *cobra.Command) {
+		if c.Flag(FlagOutput) == nil && c.InheritedFlags().Lookup(FlagOutput) == nil {
+			t.Errorf("%q has no output flag", c.CommandPath())
+		}
+		for _, child := range c.Commands() {
+			visit(child)
+		}
+	}
+	visit(GetRootCmd(nil))
+}
+
+func TestGlobalOutputUnsupported(t *testing.T) {
+	rootCmd := GetRootCmd([]string{"dashboard", "envoy", "pod", "--output", "json"})
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetErr(&bytes.Buffer{})
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "does not support the --output flag") {
+		t.Fatalf("expected the global output flag to be rejected, got %v", err)
+	}
+}
+
+func TestAnalyzeJSONDocument(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n  namespace: default\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{
+			// -o json keeps printing the bare array of messages.
+			args: []string{"-o", "json"},
+			want: "[]\n",
+		},
+		{
+			args: []string{"--output", "json"},
+			want: `{
+  "schemaVersion": "v1",
+  "kind": "AnalysisMessageList",
+  "items": []
+}
+`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			var out bytes.Buffer
+			rootCmd := GetRootCmd(append([]string{"analyze", "--use-kube=false", file}, tc.args...))
+			rootCmd.SetOut(&out)
+			rootCmd.SetErr(&bytes.Buffer{})
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tc.want {
+				t.Fatalf("got %q, want %q", out.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestVersionJSONDocument(t *testing.T) {
+	var out bytes.Buffer
+	rootCmd := GetRootCmd([]string{"version", "--remote=false", "-o", "json"})
+	rootCmd.SetOut(&out)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "schemaVersion": "v1",
+  "kind": "Version",
+  "clientVersion": {`
+	if !strings.HasPrefix(out.String(), want) {
+		t.Fatalf("got %q, want a document starting with %q", out.String(), want)
+	}
+}
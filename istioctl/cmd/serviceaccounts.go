@@ -0,0 +1,172 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/pilot/pkg/model"
+)
+
+func serviceAccountsCommand() *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	output := summaryOutput
+	cmd := &cobra.Command{
+		Use:   "service-accounts [<namespace>/<service account> | <identity>]",
+		Short: "Lists the workload instances of each service account identity.",
+		Long: `Lists the pods and WorkloadEntries known to istiod by the service account identity they run with, with
+their address, network and cluster. This shows which workloads the principals of an AuthorizationPolicy, such as
+cluster.local/ns/default/sa/sleep, match.`,
+		Example: `  # List the workload instances of all the service accounts
+  istioctl x service-accounts
+
+  # List the workload instances of the service accounts of a namespace
+  istioctl x service-accounts -n default
+
+  # List the workload instances of a service account
+  istioctl x service-accounts default/sleep`,
+		Aliases: []string{"sa"},
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return fmt.Errorf("service-accounts takes at most one service account")
+			}
+			if output != summaryOutput && output != jsonOutput {
+				return fmt.Errorf("unknown output format %q, must be one of short|json", output)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeClient, err := kubeClientWithRevision(kubeconfig, configContext, opts.Revision)
+			if err != nil {
+				return err
+			}
+			path := "/debug/serviceaccountz"
+			if namespace != "" {
+				path += "?namespace=" + url.QueryEscape(namespace)
+			}
+			res, err := kubeClient.AllDiscoveryDo(context.Background(), istioNamespace, path)
+			if err != nil {
+				return err
+			}
+			workloads, err := parseServiceAccountWorkloads(res)
+			if err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				workloads = filterServiceAccountWorkloads(workloads, args[0])
+			}
+			return writeServiceAccountWorkloads(cmd.OutOrStdout(), workloads, output)
+		},
+	}
+	opts.AttachControlPlaneFlags(cmd)
+	cmd.PersistentFlags().StringVarP(&output, "output", "o", summaryOutput, "Output format: one of short|json")
+	return cmd
+}
+
+// parseServiceAccountWorkloads merges the workload instances reported by each istiod, which watch the same
+// registries, by service account.
+func parseServiceAccountWorkloads(input map[string][]byte) (map[string][]model.ServiceAccountWorkload, error) {
+	out := map[string][]model.ServiceAccountWorkload{}
+	seen := map[model.ServiceAccountWorkload]bool{}
+	for istiod, bytes := range input {
+		var parsed map[string][]model.ServiceAccountWorkload
+		if err := json.Unmarshal(bytes, &parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse service accounts from %s: %v: %s", istiod, err, string(bytes))
+		}
+		for sa, workloads := range parsed {
+			for _, wl := range workloads {
+				if !seen[wl] {
+					seen[wl] = true
+					out[sa] = append(out[sa], wl)
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// filterServiceAccountWorkloads keeps the workload instances of the service account, given as an identity or as
+// namespace/name, in which case the trust domain of the identity is not compared.
+func filterServiceAccountWorkloads(workloads map[string][]model.ServiceAccountWorkload, serviceAccount string) map[string][]model.ServiceAccountWorkload {
+	matches := func(sa string) bool {
+		return sa == serviceAccount || strings.TrimPrefix(sa, "spiffe://") == serviceAccount
+	}
+	if ns, name, ok := strings.Cut(serviceAccount, "/"); ok && !strings.Contains(name, "/") {
+		matches = func(sa string) bool {
+			return strings.HasSuffix(sa, "/ns/"+ns+"/sa/"+name)
+		}
+	}
+	out := map[string][]model.ServiceAccountWorkload{}
+	for sa, wls := range workloads {
+		if matches(sa) {
+			out[sa] = wls
+		}
+	}
+	return out
+}
+
+func writeServiceAccountWorkloads(out io.Writer, workloads map[string][]model.ServiceAccountWorkload, format string) error {
+	serviceAccounts := make([]string, 0, len(workloads))
+	for sa, wls := range workloads {
+		serviceAccounts = append(serviceAccounts, sa)
+		sort.Slice(wls, func(i, j int) bool {
+			a, b := wls[i], wls[j]
+			if a.Cluster != b.Cluster {
+				return a.Cluster < b.Cluster
+			}
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			return a.Address < b.Address
+		})
+	}
+	sort.Strings(serviceAccounts)
+	if format == jsonOutput {
+		b, err := json.MarshalIndent(workloads, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(b))
+		return err
+	}
+	w := new(tabwriter.Writer).Init(out, 0, 8, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SERVICE ACCOUNT\tWORKLOAD\tADDRESS\tNETWORK\tCLUSTER")
+	for _, sa := range serviceAccounts {
+		for _, wl := range workloads[sa] {
+			network, cluster := string(wl.Network), string(wl.Cluster)
+			if network == "" {
+				network = "-"
+			}
+			if cluster == "" {
+				cluster = "-"
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s/%s.%s\t%s\t%s\t%s\n", sa, wl.Kind, wl.Name, wl.Namespace, wl.Address, network, cluster)
+		}
+	}
+	return w.Flush()
+}
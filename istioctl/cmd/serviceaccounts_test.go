@@ -0,0 +1,84 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteServiceAccountWorkloads(t *testing.T) {
+	sleep := `{"spiffe://cluster.local/ns/default/sa/sleep": [
+{"serviceAccount": "spiffe://cluster.local/ns/default/sa/sleep", "kind": "Pod", "name": "sleep-2", "namespace": "default",
+"address": "10.0.0.2", "network": "network-1", "cluster": "cluster-1"},
+{"serviceAccount": "spiffe://cluster.local/ns/default/sa/sleep", "kind": "Pod", "name": "sleep-1", "namespace": "default",
+"address": "10.0.0.1", "network": "network-1", "cluster": "cluster-1"}]`
+	workloads, err := parseServiceAccountWorkloads(map[string][]byte{
+		"istiod-1.istio-system": []byte(sleep + `}`),
+		"istiod-2.istio-system": []byte(sleep + `, "spiffe://cluster.local/ns/vm/sa/db": [
+{"serviceAccount": "spiffe://cluster.local/ns/vm/sa/db", "kind": "WorkloadEntry", "name": "db", "namespace": "vm",
+"address": "192.168.0.1"}]}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name   string
+		filter string
+		want   string
+	}{
+		{
+			name: "all",
+			want: `SERVICE ACCOUNT                              WORKLOAD              ADDRESS       NETWORK     CLUSTER
+spiffe://cluster.local/ns/default/sa/sleep   Pod/sleep-1.default   10.0.0.1      network-1   cluster-1
+spiffe://cluster.local/ns/default/sa/sleep   Pod/sleep-2.default   10.0.0.2      network-1   cluster-1
+spiffe://cluster.local/ns/vm/sa/db           WorkloadEntry/db.vm   192.168.0.1   -           -
+`,
+		},
+		{
+			name:   "namespace and name",
+			filter: "vm/db",
+			want: `SERVICE ACCOUNT                      WORKLOAD              ADDRESS       NETWORK   CLUSTER
+spiffe://cluster.local/ns/vm/sa/db   WorkloadEntry/db.vm   192.168.0.1   -         -
+`,
+		},
+		{
+			name:   "principal",
+			filter: "cluster.local/ns/vm/sa/db",
+			want: `SERVICE ACCOUNT                      WORKLOAD              ADDRESS       NETWORK   CLUSTER
+spiffe://cluster.local/ns/vm/sa/db   WorkloadEntry/db.vm   192.168.0.1   -         -
+`,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := workloads
+			if tt.filter != "" {
+				filtered = filterServiceAccountWorkloads(workloads, tt.filter)
+			}
+			out := &bytes.Buffer{}
+			if err := writeServiceAccountWorkloads(out, filtered, summaryOutput); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", out.String(), tt.want)
+			}
+		})
+	}
+
+	if _, err := parseServiceAccountWorkloads(map[string][]byte{"istiod": []byte("not found")}); err == nil {
+		t.Error("expected error for invalid response")
+	}
+}
@@ -0,0 +1,343 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/simulation"
+	"istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+func simulateCommand() *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var files, headers, deleted []string
+	var outputFormat string
+	request := simulatedRequest{}
+	cmd := &cobra.Command{
+		Use:   "simulate <pod-name[.namespace]>",
+		Short: "Simulates the routing decision of a proxy for a request, before and after proposed config changes",
+		Long: `Simulates which listener, route and cluster the proxy of a pod selects for a request, with the current
+configs and with proposed changes: configs created or replaced from files, and configs deleted. Istiod generates the
+configuration of the proxy for both, without pushing it, and the request is simulated on them locally, so a change
+can be reviewed before it is applied.
+
+VirtualServices, DestinationRules, Gateways, Sidecars, EnvoyFilters, WasmPlugins, security policies, Telemetries and
+ProxyConfigs can be changed. ServiceEntries and Kubernetes Gateway API resources cannot.`,
+		Example: `  # Check which cluster a request to reviews selects once a VirtualService is applied
+  istioctl x simulate productpage-v1-7d9d8d8b8d-x2x6k.default --port 9080 --host reviews -f reviews-vs.yaml
+
+  # Check the routing of a gateway without a VirtualService
+  istioctl x simulate istio-ingressgateway-5d8d7c8b8d-x2x6k.istio-system --port 8080 --host bookinfo.com \
+    --path /productpage --delete VirtualService/default/bookinfo`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("simulate requires a pod name")
+			}
+			if request.Port == 0 {
+				return fmt.Errorf("--port must be specified")
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			podName, podNamespace, err := getPodName(args[0])
+			if err != nil {
+				return err
+			}
+			kubeClient, err := kubeClientWithRevision(kubeconfig, configContext, opts.Revision)
+			if err != nil {
+				return fmt.Errorf("failed to create k8s client: %v", err)
+			}
+			dump, err := kubeClient.EnvoyDo(context.TODO(), podName, podNamespace, "GET", "config_dump")
+			if err != nil {
+				return fmt.Errorf("failed to execute command on %s.%s sidecar: %v", podName, podNamespace, err)
+			}
+			node, err := bootstrapNode(dump)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %v", podName, podNamespace, err)
+			}
+			nodeJSON, err := protomarshal.Marshal(node)
+			if err != nil {
+				return err
+			}
+			configs := make([]string, 0, len(files))
+			for _, f := range files {
+				b, err := os.ReadFile(f)
+				if err != nil {
+					return err
+				}
+				configs = append(configs, string(b))
+			}
+			if request.Headers, err = parseSimulatedHeaders(headers); err != nil {
+				return err
+			}
+			body, err := json.Marshal(xds.SimulationRequest{
+				Node:    nodeJSON,
+				Configs: strings.Join(configs, "\n---\n"),
+				Deleted: deleted,
+			})
+			if err != nil {
+				return err
+			}
+			responses, err := kubeClient.AllDiscoveryPost(context.TODO(), istioNamespace, "/debug/simulate", body)
+			if err != nil {
+				return err
+			}
+			// The istiods of a revision watch the same configs, any of them is used.
+			istiod, res := firstIstiodResponse(responses)
+			if istiod == "" {
+				return fmt.Errorf("no istiod found in namespace %s", istioNamespace)
+			}
+			sr := xds.SimulationResponse{}
+			if err := json.Unmarshal(res, &sr); err != nil {
+				return fmt.Errorf("%s: %s", istiod, strings.TrimSpace(string(res)))
+			}
+			out, err := simulate(sr, request)
+			if err != nil {
+				return err
+			}
+			return writeSimulation(c.OutOrStdout(), out, outputFormat)
+		},
+		ValidArgsFunction: validPodsNameArgs,
+	}
+	opts.AttachControlPlaneFlags(cmd)
+	cmd.Flags().IntVar(&request.Port, "port", 0, "Destination port of the request")
+	cmd.Flags().StringVar(&request.Address, "address", "", "Destination address of the request")
+	cmd.Flags().StringVar(&request.Host, "host", "", "Host header of the request")
+	cmd.Flags().StringVar(&request.Path, "path", "", "Path of the request")
+	cmd.Flags().StringVar(&request.Protocol, "protocol", "", "Protocol of the request: http, http2 or tcp (default http)")
+	cmd.Flags().StringVar(&request.TLS, "tls", "", "TLS of the request: plaintext, tls or mtls (default plaintext)")
+	cmd.Flags().StringVar(&request.Sni, "sni", "", "SNI of the request")
+	cmd.Flags().StringVar(&request.CallMode, "call-mode", "",
+		"How the request reaches the proxy: outbound, inbound or gateway (default gateway for gateways, outbound otherwise)")
+	cmd.Flags().StringSliceVar(&headers, "header", nil, "Header of the request, as <name>=<value>")
+	cmd.Flags().StringSliceVarP(&files, "file", "f", nil, "Proposed configs to create or replace")
+	cmd.Flags().StringSliceVar(&deleted, "delete", nil, "Proposed config to delete, as <kind>/<namespace>/<name>")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|short")
+	return cmd
+}
+
+// parseSimulatedHeaders parses the <name>=<value> headers of the request.
+func parseSimulatedHeaders(headers []string) (map[string]string, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(headers))
+	for _, h := range headers {
+		k, v, f := strings.Cut(h, "=")
+		if !f || k == "" {
+			return nil, fmt.Errorf("invalid header %q, expected <name>=<value>", h)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// simulatedRequest describes a request, as simulation.Call does.
+type simulatedRequest struct {
+	Address string
+	Port    int
+	// Protocol is one of http, http2 and tcp. Defaults to http.
+	Protocol string
+	// TLS is one of plaintext, tls and mtls. Defaults to plaintext.
+	TLS     string
+	Sni     string
+	Host    string
+	Path    string
+	Headers map[string]string
+	// CallMode is one of outbound, inbound and gateway. Defaults to gateway for gateways and outbound for sidecars.
+	CallMode string
+}
+
+// simulationResult is the routing decision of a simulated request.
+type simulationResult struct {
+	Listener    string `json:"listener,omitempty"`
+	FilterChain string `json:"filterChain,omitempty"`
+	RouteConfig string `json:"routeConfig,omitempty"`
+	VirtualHost string `json:"virtualHost,omitempty"`
+	Route       string `json:"route,omitempty"`
+	Cluster     string `json:"cluster,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// simulationOutput is the routing decision of the request with the current configs and with the proposed changes.
+type simulationOutput struct {
+	Proxy   string           `json:"proxy"`
+	Before  simulationResult `json:"before"`
+	After   simulationResult `json:"after"`
+	Changed bool             `json:"changed"`
+}
+
+// simulate simulates the request on the configurations of the proxy returned by istiod.
+func simulate(sr xds.SimulationResponse, r simulatedRequest) (simulationOutput, error) {
+	call := simulation.Call{
+		Address:    r.Address,
+		Port:       r.Port,
+		Path:       r.Path,
+		Protocol:   simulation.Protocol(r.Protocol),
+		TLS:        simulation.TLSMode(r.TLS),
+		Sni:        r.Sni,
+		HostHeader: r.Host,
+		Headers:    http.Header{},
+		CallMode:   simulation.CallMode(r.CallMode),
+	}
+	if call.Protocol == "" {
+		call.Protocol = simulation.HTTP
+	}
+	if call.CallMode == "" {
+		call.CallMode = simulation.CallModeOutbound
+		if strings.HasPrefix(sr.Proxy, string(model.Router)+"~") {
+			call.CallMode = simulation.CallModeGateway
+		}
+	}
+	for k, v := range r.Headers {
+		call.Headers.Set(k, v)
+	}
+	before, err := simulateCall(sr.Current, call)
+	if err != nil {
+		return simulationOutput{}, err
+	}
+	after, err := simulateCall(sr.Proposed, call)
+	if err != nil {
+		return simulationOutput{}, err
+	}
+	return simulationOutput{
+		Proxy:   sr.Proxy,
+		Before:  before,
+		After:   after,
+		Changed: before != after,
+	}, nil
+}
+
+// simulateCall simulates the call on the configuration of the proxy.
+func simulateCall(cfg xds.SimulationConfig, call simulation.Call) (simulationResult, error) {
+	listeners, err := unmarshalSimulationConfig(cfg.Listeners, func() *listener.Listener { return &listener.Listener{} })
+	if err != nil {
+		return simulationResult{}, err
+	}
+	clusters, err := unmarshalSimulationConfig(cfg.Clusters, func() *cluster.Cluster { return &cluster.Cluster{} })
+	if err != nil {
+		return simulationResult{}, err
+	}
+	routes, err := unmarshalSimulationConfig(cfg.Routes, func() *route.RouteConfiguration { return &route.RouteConfiguration{} })
+	if err != nil {
+		return simulationResult{}, err
+	}
+	var result simulation.Result
+	if err := test.Wrap(func(t test.Failer) {
+		result = simulation.NewSimulationFromResources(t, listeners, clusters, routes).Run(call)
+	}); err != nil {
+		return simulationResult{}, err
+	}
+	out := simulationResult{
+		Listener:    result.ListenerMatched,
+		FilterChain: result.FilterChainMatched,
+		RouteConfig: result.RouteConfigMatched,
+		VirtualHost: result.VirtualHostMatched,
+		Route:       result.RouteMatched,
+		Cluster:     result.ClusterMatched,
+	}
+	if result.Error != nil {
+		out.Error = result.Error.Error()
+	}
+	return out, nil
+}
+
+// unmarshalSimulationConfig unmarshals the serialized protos of a configuration.
+func unmarshalSimulationConfig[T proto.Message](resources [][]byte, newT func() T) ([]T, error) {
+	out := make([]T, 0, len(resources))
+	for _, b := range resources {
+		m := newT()
+		if err := proto.Unmarshal(b, m); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// writeSimulation writes the routing decisions of the simulation side by side, the changed ones marked with a *.
+func writeSimulation(w io.Writer, sr simulationOutput, outputFormat string) error {
+	switch outputFormat {
+	case jsonOutput:
+		b, err := json.MarshalIndent(sr, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case summaryOutput:
+	default:
+		return fmt.Errorf("unknown output format %q", outputFormat)
+	}
+	fmt.Fprintf(w, "Proxy: %s\n", sr.Proxy)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tBEFORE\tAFTER")
+	for _, row := range []struct {
+		name          string
+		before, after string
+	}{
+		{"LISTENER", sr.Before.Listener, sr.After.Listener},
+		{"FILTER CHAIN", sr.Before.FilterChain, sr.After.FilterChain},
+		{"ROUTE CONFIG", sr.Before.RouteConfig, sr.After.RouteConfig},
+		{"VIRTUAL HOST", sr.Before.VirtualHost, sr.After.VirtualHost},
+		{"ROUTE", sr.Before.Route, sr.After.Route},
+		{"CLUSTER", sr.Before.Cluster, sr.After.Cluster},
+		{"ERROR", sr.Before.Error, sr.After.Error},
+	} {
+		if row.before == "" && row.after == "" {
+			continue
+		}
+		name := row.name
+		if row.before != row.after {
+			name = "* " + name
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", name, valueOrDash(row.before), valueOrDash(row.after))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if sr.Changed {
+		_, err := fmt.Fprintln(w, "The proposed changes change the routing decision.")
+		return err
+	}
+	_, err := fmt.Fprintln(w, "The proposed changes do not change the routing decision.")
+	return err
+}
+
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,168 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+func TestWriteSimulation(t *testing.T) {
+	sr := simulationOutput{
+		Proxy: "sidecar~10.1.0.5~productpage.default~default.svc.cluster.local",
+		Before: simulationResult{
+			Listener:    "0.0.0.0_9080",
+			RouteConfig: "9080",
+			VirtualHost: "reviews.default.svc.cluster.local:9080",
+			Route:       "default",
+			Cluster:     "outbound|9080||reviews.default.svc.cluster.local",
+		},
+		After: simulationResult{
+			Listener:    "0.0.0.0_9080",
+			RouteConfig: "9080",
+			VirtualHost: "reviews.default.svc.cluster.local:9080",
+			Cluster:     "outbound|9080|v2|reviews.default.svc.cluster.local",
+		},
+		Changed: true,
+	}
+	var out bytes.Buffer
+	assert.NoError(t, writeSimulation(&out, sr, summaryOutput))
+	assert.Equal(t, out.String(), `Proxy: sidecar~10.1.0.5~productpage.default~default.svc.cluster.local
+              BEFORE                                            AFTER
+LISTENER      0.0.0.0_9080                                      0.0.0.0_9080
+ROUTE CONFIG  9080                                              9080
+VIRTUAL HOST  reviews.default.svc.cluster.local:9080            reviews.default.svc.cluster.local:9080
+* ROUTE       default                                           -
+* CLUSTER     outbound|9080||reviews.default.svc.cluster.local  outbound|9080|v2|reviews.default.svc.cluster.local
+The proposed changes change the routing decision.
+`)
+	assert.Error(t, writeSimulation(&out, sr, "yaml"))
+}
+
+func TestParseSimulatedHeaders(t *testing.T) {
+	headers, err := parseSimulatedHeaders([]string{"x-user=jason", "x-empty="})
+	assert.NoError(t, err)
+	assert.Equal(t, headers, map[string]string{"x-user": "jason", "x-empty": ""})
+	_, err = parseSimulatedHeaders([]string{"x-user"})
+	assert.Error(t, err)
+}
+
+func TestSimulate(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: example
+  namespace: default
+spec:
+  hosts:
+  - example.com
+  - canary.example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+`})
+	node, err := protomarshal.Marshal(&core.Node{
+		Id:       "sidecar~1.1.1.1~test.default~default.svc.cluster.local",
+		Metadata: model.NodeMetadata{Namespace: "default"}.ToStruct(),
+	})
+	assert.NoError(t, err)
+	body, err := json.Marshal(xds.SimulationRequest{Node: node, Configs: `
+apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: example
+  namespace: default
+spec:
+  hosts:
+  - example.com
+  http:
+  - route:
+    - destination:
+        host: canary.example.com
+`})
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(s.Discovery.Simulate).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/debug/simulate", bytes.NewReader(body)))
+	sr := xds.SimulationResponse{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &sr))
+
+	for _, tt := range []struct {
+		name    string
+		request simulatedRequest
+		want    simulationOutput
+	}{
+		{
+			name:    "proposed virtual service changes the cluster",
+			request: simulatedRequest{Port: 80, Host: "example.com"},
+			want: simulationOutput{
+				Proxy: "sidecar~1.1.1.1~test.default~default.svc.cluster.local",
+				Before: simulationResult{
+					Listener:    "0.0.0.0_80",
+					RouteConfig: "80",
+					VirtualHost: "example.com:80",
+					Route:       "default",
+					Cluster:     "outbound|80||example.com",
+				},
+				After: simulationResult{
+					Listener:    "0.0.0.0_80",
+					RouteConfig: "80",
+					VirtualHost: "example.com:80",
+					Cluster:     "outbound|80||canary.example.com",
+				},
+				Changed: true,
+			},
+		},
+		{
+			name:    "unchanged routing",
+			request: simulatedRequest{Port: 80, Host: "unknown.com"},
+			want: simulationOutput{
+				Proxy: "sidecar~1.1.1.1~test.default~default.svc.cluster.local",
+				Before: simulationResult{
+					Listener:    "0.0.0.0_80",
+					RouteConfig: "80",
+					VirtualHost: "allow_any",
+					Route:       "allow_any",
+					Cluster:     "PassthroughCluster",
+				},
+				After: simulationResult{
+					Listener:    "0.0.0.0_80",
+					RouteConfig: "80",
+					VirtualHost: "allow_any",
+					Route:       "allow_any",
+					Cluster:     "PassthroughCluster",
+				},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := simulate(sr, tt.request)
+			assert.NoError(t, err)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
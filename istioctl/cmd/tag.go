@@ -49,6 +49,8 @@ revision tag, use 'kubectl label namespace <NAMESPACE> istio.io/rev=%s'
 `
 	webhookNameHelpStr          = "Name to use for a revision tag's mutating webhook configuration."
 	autoInjectNamespacesHelpStr = "If set to true, the sidecars should be automatically injected into all namespaces by default"
+	forceHelpStr                = `If true, retag even if the injector of the new revision is incompatible with the injector
+of the revision the tag currently references.`
 )
 
 // options for CLI
@@ -60,6 +62,7 @@ var (
 	skipConfirmation     = false
 	webhookName          = ""
 	autoInjectNamespaces = false
+	force                = false
 )
 
 type tagDescription struct {
@@ -118,6 +121,9 @@ injection labels.`,
  # Change the revision tag to reference the "1-8-1" revision
  istioctl tag set prod --revision 1-8-1 --overwrite
 
+ # Change the revision tag even if the injection templates of "1-8-1" are incompatible with the current revision
+ istioctl tag set prod --revision 1-8-1 --overwrite --force
+
  # Make revision "1-8-1" the default revision, both resulting in that revision handling injection for "istio-injection=enabled"
  # and validating resources cluster-wide
  istioctl tag set default --revision 1-8-1
@@ -151,6 +157,7 @@ injection labels.`,
 	cmd.PersistentFlags().StringVarP(&revision, "revision", "r", "", revisionHelpStr)
 	cmd.PersistentFlags().StringVarP(&webhookName, "webhook-name", "", "", webhookNameHelpStr)
 	cmd.PersistentFlags().BoolVar(&autoInjectNamespaces, "auto-inject-namespaces", false, autoInjectNamespacesHelpStr)
+	cmd.PersistentFlags().BoolVar(&force, "force", false, forceHelpStr)
 	_ = cmd.MarkPersistentFlagRequired("revision")
 
 	return cmd
@@ -307,6 +314,10 @@ func setTag(ctx context.Context, kubeClient kube.ExtendedClient, tagName, revisi
 		return nil
 	}
 
+	if err := checkRetag(ctx, kubeClient.Kube(), tagName, revision, istioNS, w); err != nil {
+		return err
+	}
+
 	if err := tag.Create(kubeClient, tagWhYAML); err != nil {
 		return fmt.Errorf("failed to apply tag webhook MutatingWebhookConfiguration to cluster: %v", err)
 	}
@@ -314,6 +325,41 @@ func setTag(ctx context.Context, kubeClient kube.ExtendedClient, tagName, revisi
 	return nil
 }
 
+// checkRetag reports the namespaces and pods affected when an existing tag is pointed to another revision, and refuses
+// to retag without --force if the injector of the new revision is incompatible.
+func checkRetag(ctx context.Context, kubeClient kubernetes.Interface, tagName, revision, istioNS string, w io.Writer) error {
+	report, err := tag.CheckRetag(ctx, kubeClient, tagName, revision, istioNS)
+	if err != nil {
+		if force {
+			fmt.Fprintf(w, "Warning: failed to check the compatibility of revision %q: %v\n", revision, err)
+			return nil
+		}
+		return fmt.Errorf("failed to check the compatibility of revision %q, pass --force to retag anyway: %v", revision, err)
+	}
+	if report == nil {
+		return nil
+	}
+	fmt.Fprintf(w, "Revision tag %q references revision %q, retagging to %q affects %d namespace(s) and %d pod(s):\n",
+		report.Tag, report.FromRevision, report.ToRevision, len(report.Namespaces), len(report.Pods))
+	for _, ns := range report.Namespaces {
+		fmt.Fprintf(w, "  namespace %s\n", ns)
+	}
+	for _, pod := range report.Pods {
+		fmt.Fprintf(w, "  pod %s\n", pod)
+	}
+	if len(report.Incompatibilities) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "Found %d incompatibilities with the injector of revision %q:\n", len(report.Incompatibilities), revision)
+	for _, i := range report.Incompatibilities {
+		fmt.Fprintf(w, "  %s\n", i)
+	}
+	if !force {
+		return fmt.Errorf("cannot retag %q to incompatible revision %q, pass --force to retag anyway", tagName, revision)
+	}
+	return nil
+}
+
 func analyzeWebhook(name, wh, revision string, config *rest.Config) error {
 	sa := local.NewSourceAnalyzer(analysis.Combine("webhook", &webhook.Analyzer{}),
 		resource.Namespace(selectedNamespace), resource.Namespace(istioNamespace), nil, true, analysisTimeout)
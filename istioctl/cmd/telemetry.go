@@ -0,0 +1,308 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/config/kube/crdclient"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/kube"
+)
+
+func telemetryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "telemetry",
+		Short: "Commands to inspect Telemetry resources",
+	}
+	cmd.AddCommand(telemetryDescribeCmd())
+	return cmd
+}
+
+func telemetryDescribeCmd() *cobra.Command {
+	var (
+		opts      clioptions.ControlPlaneOptions
+		workload  string
+		filenames []string
+	)
+	cmd := &cobra.Command{
+		Use:   "describe --workload <pod-name>[.<namespace>] [-f FILENAME]",
+		Short: "Show the tracing, metrics and access logging configuration of a workload",
+		Long: `Describe resolves the Telemetry resources of the root namespace, of the namespace and of the workload of a
+pod, layered over the default providers of the mesh config, and prints the tracing, metrics and access logging
+configuration its proxy receives. With -f, the Telemetry resources of the files are applied over the ones of the
+cluster, and the configuration is printed before and after the proposed change.`,
+		Example: `  # Show the telemetry configuration of a pod
+  istioctl x telemetry describe --workload productpage-v1-c7765c886-7zzd4.default
+
+  # Show the telemetry configuration of a pod before and after a change of its Telemetry resources
+  istioctl x telemetry describe --workload productpage-v1-c7765c886-7zzd4 -f telemetry.yaml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if workload == "" {
+				return fmt.Errorf("a workload must be specified with --workload")
+			}
+			var proposed []config.Config
+			for _, f := range filenames {
+				configs, err := readConfigsOfKind(f, gvk.Telemetry)
+				if err != nil {
+					return err
+				}
+				proposed = append(proposed, configs...)
+			}
+			podName, ns := handlers.InferPodInfo(workload, handlers.HandleNamespace(namespace, defaultNamespace))
+			client, err := kubeClientWithRevision(kubeconfig, configContext, opts.Revision)
+			if err != nil {
+				return fmt.Errorf("failed to create k8s client: %v", err)
+			}
+			pod, err := client.Kube().CoreV1().Pods(ns).Get(context.TODO(), podName, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			meshCfg, err := getMeshConfig(client)
+			if err != nil {
+				return fmt.Errorf("failed to fetch mesh config: %v", err)
+			}
+			current, err := listTelemetries(client, meshCfg.GetRootNamespace(), ns)
+			if err != nil {
+				return err
+			}
+			describeTelemetry(cmd.OutOrStdout(), meshCfg, pod, current, proposed, len(filenames) > 0)
+			return nil
+		},
+	}
+	opts.AttachControlPlaneFlags(cmd)
+	cmd.PersistentFlags().StringVar(&workload, "workload", "", "Name of the pod of the workload, as <pod-name>[.<namespace>]")
+	cmd.PersistentFlags().StringSliceVarP(&filenames, "filename", "f", nil,
+		"Names of files containing the proposed Telemetry resources, or - for stdin")
+	return cmd
+}
+
+// listTelemetries returns the Telemetry resources of the root namespace and of the namespace, the only ones which
+// may apply to the workloads of the namespace.
+func listTelemetries(client kube.ExtendedClient, rootNamespace, namespace string) ([]config.Config, error) {
+	var out []config.Config
+	for _, ns := range []string{rootNamespace, namespace} {
+		list, err := client.Istio().TelemetryV1alpha1().Telemetries(ns).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the Telemetry resources of namespace %q: %v", ns, err)
+		}
+		for _, t := range list.Items {
+			out = append(out, crdclient.TranslateObject(t, gvk.Telemetry, ""))
+		}
+		if rootNamespace == namespace {
+			break
+		}
+	}
+	return out, nil
+}
+
+// readConfigsOfKind returns the resources of a kind in a file, or in stdin for -.
+func readConfigsOfKind(filename string, kind config.GroupVersionKind) ([]config.Config, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if filename == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file %q: %v", filename, err)
+	}
+	configs, _, err := crd.ParseInputs(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse file %q: %v", filename, err)
+	}
+	var out []config.Config
+	for _, c := range configs {
+		if c.GroupVersionKind == kind {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// applyProposedConfigs returns the resources of the cluster with the proposed ones applied: a proposed resource
+// replaces the one with the same name, keeping its creation time, or is added as if created now. Proposed resources
+// without a namespace are in the given namespace.
+func applyProposedConfigs(current, proposed []config.Config, namespace string) []config.Config {
+	out := append([]config.Config{}, current...)
+	for _, p := range proposed {
+		if p.Namespace == "" {
+			p.Namespace = namespace
+		}
+		replaced := false
+		for i, c := range out {
+			if c.Namespace == p.Namespace && c.Name == p.Name {
+				p.CreationTimestamp = c.CreationTimestamp
+				out[i] = p
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			p.CreationTimestamp = time.Now()
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// describeTelemetry prints the telemetry configuration of the pod, before and after the proposed change if any.
+func describeTelemetry(w io.Writer, meshCfg *meshconfig.MeshConfig, pod *v1.Pod, current, proposed []config.Config,
+	compare bool,
+) {
+	proxy := &model.Proxy{
+		Type:            model.SidecarProxy,
+		ConfigNamespace: pod.Namespace,
+		Metadata:        &model.NodeMetadata{Labels: pod.Labels},
+	}
+	var before bytes.Buffer
+	printEffectiveTelemetry(&before, meshCfg, model.NewTelemetries(meshCfg, current).EffectiveTelemetry(proxy))
+	if !compare {
+		_, _ = w.Write(before.Bytes())
+		return
+	}
+	var after bytes.Buffer
+	printEffectiveTelemetry(&after, meshCfg,
+		model.NewTelemetries(meshCfg, applyProposedConfigs(current, proposed, pod.Namespace)).EffectiveTelemetry(proxy))
+	if before.String() == after.String() {
+		fmt.Fprintf(w, "The proposed change does not change the telemetry configuration of %s:\n", kname(pod.ObjectMeta))
+		_, _ = w.Write(before.Bytes())
+		return
+	}
+	fmt.Fprintln(w, "Before:")
+	_, _ = w.Write(indent(before.String()))
+	fmt.Fprintln(w, "After:")
+	_, _ = w.Write(indent(after.String()))
+}
+
+func indent(s string) []byte {
+	var b strings.Builder
+	for _, l := range strings.SplitAfter(s, "\n") {
+		if l != "" {
+			b.WriteString("  " + l)
+		}
+	}
+	return []byte(b.String())
+}
+
+func printEffectiveTelemetry(w io.Writer, meshCfg *meshconfig.MeshConfig, et model.EffectiveTelemetry) {
+	if len(et.Telemetries) == 0 {
+		fmt.Fprintln(w, "Telemetry resources: none")
+	} else {
+		fmt.Fprintf(w, "Telemetry resources: %s\n", strings.Join(et.Telemetries, ", "))
+	}
+
+	if len(et.Metrics) == 0 {
+		fmt.Fprintln(w, "Metrics: none configured by the Telemetry API")
+	} else {
+		fmt.Fprintln(w, "Metrics:")
+		for _, m := range et.Metrics {
+			fmt.Fprintf(w, "  %s\n", m.Provider)
+			printMetricOverrides(w, "CLIENT", m.Client)
+			printMetricOverrides(w, "SERVER", m.Server)
+		}
+	}
+
+	switch {
+	case et.AccessLogging == nil && meshCfg.GetAccessLogFile() != "":
+		fmt.Fprintf(w, "Access logging: not configured by the Telemetry API, the mesh config logs to %s\n", meshCfg.GetAccessLogFile())
+	case et.AccessLogging == nil:
+		fmt.Fprintln(w, "Access logging: not configured by the Telemetry API, disabled in the mesh config")
+	case len(et.AccessLogging) == 0:
+		fmt.Fprintln(w, "Access logging: disabled")
+	default:
+		fmt.Fprintln(w, "Access logging:")
+		for _, l := range et.AccessLogging {
+			if l.Filter != "" {
+				fmt.Fprintf(w, "  %s %s, filter %q\n", l.Provider, l.Mode, l.Filter)
+			} else {
+				fmt.Fprintf(w, "  %s %s\n", l.Provider, l.Mode)
+			}
+		}
+	}
+
+	if et.Tracing == nil {
+		fmt.Fprintln(w, "Tracing: not configured by the Telemetry API, the tracing settings of the mesh config apply")
+	} else {
+		fmt.Fprintln(w, "Tracing:")
+		fmt.Fprintf(w, "  CLIENT %s\n", describeTracingSpec(et.Tracing.ClientSpec))
+		fmt.Fprintf(w, "  SERVER %s\n", describeTracingSpec(et.Tracing.ServerSpec))
+	}
+
+	if len(et.MissingProviders) > 0 {
+		fmt.Fprintf(w, "Warning: providers %s are not extension providers of the mesh config, and are ignored\n",
+			strings.Join(et.MissingProviders, ", "))
+	}
+}
+
+func printMetricOverrides(w io.Writer, mode string, overrides []model.EffectiveMetricOverride) {
+	for _, o := range overrides {
+		var changes []string
+		if o.Disabled {
+			changes = append(changes, "disabled")
+		}
+		for _, t := range o.Tags {
+			if t.Remove {
+				changes = append(changes, fmt.Sprintf("remove tag %s", t.Name))
+			} else {
+				changes = append(changes, fmt.Sprintf("tag %s=%s", t.Name, t.Value))
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "    %s %s: %s\n", mode, o.Metric, strings.Join(changes, ", "))
+	}
+}
+
+func describeTracingSpec(spec model.TracingSpec) string {
+	if spec.Disabled || spec.Provider == nil {
+		return "disabled"
+	}
+	out := fmt.Sprintf("provider %s", spec.Provider.GetName())
+	if spec.RandomSamplingPercentage > 0 {
+		out += fmt.Sprintf(", sampling %.2f%%", spec.RandomSamplingPercentage)
+	}
+	if len(spec.CustomTags) > 0 {
+		tags := make([]string, 0, len(spec.CustomTags))
+		for t := range spec.CustomTags {
+			tags = append(tags, t)
+		}
+		sort.Strings(tags)
+		out += fmt.Sprintf(", custom tags %s", strings.Join(tags, ", "))
+	}
+	return out
+}
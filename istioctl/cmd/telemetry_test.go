@@ -0,0 +1,144 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pkg/config/mesh"
+)
+
+const currentTelemetries = `
+apiVersion: telemetry.istio.io/v1alpha1
+kind: Telemetry
+metadata:
+  name: mesh-default
+  namespace: istio-system
+spec:
+  accessLogging:
+  - providers:
+    - name: envoy
+  metrics:
+  - providers:
+    - name: prometheus
+---
+apiVersion: telemetry.istio.io/v1alpha1
+kind: Telemetry
+metadata:
+  name: reviews
+  namespace: default
+spec:
+  selector:
+    matchLabels:
+      app: reviews
+  metrics:
+  - overrides:
+    - match:
+        metric: REQUEST_COUNT
+        mode: CLIENT
+      tagOverrides:
+        request_protocol:
+          operation: REMOVE
+`
+
+const proposedTelemetries = `
+apiVersion: telemetry.istio.io/v1alpha1
+kind: Telemetry
+metadata:
+  name: reviews
+spec:
+  selector:
+    matchLabels:
+      app: reviews
+  accessLogging:
+  - providers:
+    - name: envoy
+    filter:
+      expression: response.code >= 400
+  tracing:
+  - providers:
+    - name: zipkin
+`
+
+func TestDescribeTelemetry(t *testing.T) {
+	current, _, err := crd.ParseInputs(currentTelemetries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proposed, _, err := crd.ParseInputs(proposedTelemetries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "reviews-v1", Namespace: "default", Labels: map[string]string{"app": "reviews"}}}
+	meshCfg := mesh.DefaultMeshConfig()
+
+	cases := []struct {
+		name     string
+		proposed bool
+		want     string
+	}{
+		{
+			name: "current",
+			want: `Telemetry resources: istio-system/mesh-default, default/reviews
+Metrics:
+  prometheus
+    CLIENT REQUEST_COUNT: remove tag request_protocol
+Access logging:
+  envoy CLIENT
+  envoy SERVER
+Tracing: not configured by the Telemetry API, the tracing settings of the mesh config apply
+`,
+		},
+		{
+			name:     "proposed",
+			proposed: true,
+			want: `Before:
+  Telemetry resources: istio-system/mesh-default, default/reviews
+  Metrics:
+    prometheus
+      CLIENT REQUEST_COUNT: remove tag request_protocol
+  Access logging:
+    envoy CLIENT
+    envoy SERVER
+  Tracing: not configured by the Telemetry API, the tracing settings of the mesh config apply
+After:
+  Telemetry resources: istio-system/mesh-default, default/reviews
+  Metrics:
+    prometheus
+  Access logging:
+    envoy CLIENT, filter "response.code >= 400"
+    envoy SERVER, filter "response.code >= 400"
+  Tracing:
+    CLIENT disabled
+    SERVER disabled
+  Warning: providers zipkin are not extension providers of the mesh config, and are ignored
+`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var out bytes.Buffer
+			describeTelemetry(&out, meshCfg, pod, current, proposed, c.proposed)
+			if out.String() != c.want {
+				t.Fatalf("got:\n%s\nwant:\n%s", out.String(), c.want)
+			}
+		})
+	}
+}
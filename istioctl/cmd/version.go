@@ -15,6 +15,7 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -28,6 +29,7 @@ import (
 
 	"istio.io/istio/istioctl/pkg/clioptions"
 	"istio.io/istio/istioctl/pkg/multixds"
+	"istio.io/istio/istioctl/pkg/util/formatting"
 	"istio.io/istio/operator/cmd/mesh"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/xds"
@@ -44,6 +46,7 @@ func newVersionCommand() *cobra.Command {
 		GetProxyVersions: getProxyInfoWrapper(&opts),
 	})
 	opts.AttachControlPlaneFlags(versionCmd)
+	versionJSONDocument(versionCmd)
 
 	versionCmd.Flags().VisitAll(func(flag *pflag.Flag) {
 		if flag.Name == "short" {
@@ -62,6 +65,31 @@ func newVersionCommand() *cobra.Command {
 	return versionCmd
 }
 
+// versionJSONDocument makes the json output of a version command a versioned JSON document, with the fields of the
+// version information.
+func versionJSONDocument(versionCmd *cobra.Command) {
+	run := versionCmd.RunE
+	versionCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if f := cmd.Flags().Lookup(FlagOutput); f == nil || f.Value.String() != jsonOutput {
+			return run(cmd, args)
+		}
+		out := cmd.OutOrStdout()
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		err := run(cmd, args)
+		cmd.SetOut(out)
+		if err != nil {
+			return err
+		}
+		doc, err := formatting.AddJSONDocumentFields(buf.Bytes(), "Version")
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(doc)
+		return err
+	}
+}
+
 func getRemoteInfo(opts clioptions.ControlPlaneOptions) (*istioVersion.MeshInfo, error) {
 	kubeClient, err := kubeClientWithRevision(kubeconfig, configContext, opts.Revision)
 	if err != nil {
@@ -103,6 +131,7 @@ func xdsVersionCommand() *cobra.Command {
 	})
 	opts.AttachControlPlaneFlags(versionCmd)
 	centralOpts.AttachControlPlaneFlags(versionCmd)
+	versionJSONDocument(versionCmd)
 	versionCmd.Args = func(c *cobra.Command, args []string) error {
 		if err := cobra.NoArgs(c, args); err != nil {
 			return err
@@ -0,0 +1,309 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	security "istio.io/api/security/v1beta1"
+	"istio.io/istio/pkg/config/labels"
+)
+
+// Policy is an AuthorizationPolicy to evaluate requests against.
+type Policy struct {
+	Name      string
+	Namespace string
+	Spec      *security.AuthorizationPolicy
+}
+
+func (p Policy) String() string {
+	return p.Namespace + "/" + p.Name
+}
+
+// Workload is the workload receiving a request.
+type Workload struct {
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// Request holds the attributes of a request that AuthorizationPolicy can match on.
+type Request struct {
+	// Principal is the peer identity, for example cluster.local/ns/default/sa/frontend.
+	Principal string `json:"principal,omitempty"`
+	// Namespace is the namespace of the peer.
+	Namespace string `json:"namespace,omitempty"`
+	// IP is the source IP of the connection.
+	IP string `json:"ip,omitempty"`
+	// RemoteIP is the original client IP, defaults to IP.
+	RemoteIP string `json:"remoteIp,omitempty"`
+	// RequestPrincipal is the JWT principal, in the form <iss>/<sub>.
+	RequestPrincipal string `json:"requestPrincipal,omitempty"`
+	// Audiences and Presenter are the aud and azp claims of the JWT.
+	Audiences []string `json:"audiences,omitempty"`
+	Presenter string   `json:"presenter,omitempty"`
+	// Claims are the string claims of the JWT.
+	Claims map[string][]string `json:"claims,omitempty"`
+
+	Host    string            `json:"host,omitempty"`
+	Port    int               `json:"port,omitempty"`
+	Method  string            `json:"method,omitempty"`
+	Path    string            `json:"path,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// DestinationIP is the IP the workload received the request on.
+	DestinationIP string `json:"destinationIp,omitempty"`
+	// SNI is the server name of the TLS connection.
+	SNI string `json:"sni,omitempty"`
+}
+
+// Decision is the result of evaluating a request.
+type Decision struct {
+	Allowed bool
+	// Policy is the policy that decided the request, nil if no policy did.
+	Policy *Policy
+	// Reason explains the decision.
+	Reason string
+}
+
+// Evaluate returns the decision the AuthorizationPolicies would make for a request to the workload, following the
+// order enforced by the proxy regardless of the order of the policies: CUSTOM policies first, then DENY policies,
+// then ALLOW policies. Policies in the root namespace apply to all workloads. Requests matched by a CUSTOM policy
+// depend on the external authorizer and cannot be evaluated.
+func Evaluate(policies []Policy, rootNamespace string, wl Workload, req Request) (Decision, error) {
+	byAction := map[security.AuthorizationPolicy_Action][]Policy{}
+	for _, p := range policies {
+		if appliesTo(p, rootNamespace, wl) {
+			byAction[p.Spec.GetAction()] = append(byAction[p.Spec.GetAction()], p)
+		}
+	}
+
+	for _, p := range byAction[security.AuthorizationPolicy_CUSTOM] {
+		m, err := matchPolicy(p.Spec, req)
+		if err != nil {
+			return Decision{}, fmt.Errorf("policy %s: %v", p, err)
+		}
+		if m {
+			return Decision{}, fmt.Errorf("request matches CUSTOM policy %s, which is decided by the external authorizer", p)
+		}
+	}
+	for i := range byAction[security.AuthorizationPolicy_DENY] {
+		p := byAction[security.AuthorizationPolicy_DENY][i]
+		m, err := matchPolicy(p.Spec, req)
+		if err != nil {
+			return Decision{}, fmt.Errorf("policy %s: %v", p, err)
+		}
+		if m {
+			return Decision{Allowed: false, Policy: &p, Reason: "matched DENY policy " + p.String()}, nil
+		}
+	}
+
+	allows := byAction[security.AuthorizationPolicy_ALLOW]
+	if len(allows) == 0 {
+		return Decision{Allowed: true, Reason: "no ALLOW policy applies to the workload"}, nil
+	}
+	for i := range allows {
+		p := allows[i]
+		m, err := matchPolicy(p.Spec, req)
+		if err != nil {
+			return Decision{}, fmt.Errorf("policy %s: %v", p, err)
+		}
+		if m {
+			return Decision{Allowed: true, Policy: &p, Reason: "matched ALLOW policy " + p.String()}, nil
+		}
+	}
+	return Decision{Allowed: false, Reason: "no ALLOW policy matched"}, nil
+}
+
+func appliesTo(p Policy, rootNamespace string, wl Workload) bool {
+	if p.Namespace != rootNamespace && p.Namespace != wl.Namespace {
+		return false
+	}
+	return labels.Instance(p.Spec.GetSelector().GetMatchLabels()).SubsetOf(wl.Labels)
+}
+
+func matchPolicy(spec *security.AuthorizationPolicy, req Request) (bool, error) {
+	for _, rule := range spec.GetRules() {
+		m, err := matchRule(rule, req)
+		if err != nil || m {
+			return m, err
+		}
+	}
+	return false, nil
+}
+
+// matchRule matches if any source, any operation and all conditions match.
+func matchRule(rule *security.Rule, req Request) (bool, error) {
+	if rule == nil {
+		return false, nil
+	}
+	if len(rule.From) > 0 {
+		matched := false
+		for _, from := range rule.From {
+			if matchSource(from.GetSource(), req) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	if len(rule.To) > 0 {
+		matched := false
+		for _, to := range rule.To {
+			if matchOperation(to.GetOperation(), req) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	for _, cond := range rule.When {
+		m, err := matchCondition(cond, req)
+		if err != nil || !m {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchSource(s *security.Source, req Request) bool {
+	remoteIP := req.RemoteIP
+	if remoteIP == "" {
+		remoteIP = req.IP
+	}
+	return matchField(s.GetPrincipals(), s.GetNotPrincipals(), req.Principal, matchString) &&
+		matchField(s.GetRequestPrincipals(), s.GetNotRequestPrincipals(), req.RequestPrincipal, matchString) &&
+		matchField(s.GetNamespaces(), s.GetNotNamespaces(), req.Namespace, matchString) &&
+		matchField(s.GetIpBlocks(), s.GetNotIpBlocks(), req.IP, matchIP) &&
+		matchField(s.GetRemoteIpBlocks(), s.GetNotRemoteIpBlocks(), remoteIP, matchIP)
+}
+
+func matchOperation(o *security.Operation, req Request) bool {
+	port := ""
+	if req.Port != 0 {
+		port = strconv.Itoa(req.Port)
+	}
+	return matchField(o.GetHosts(), o.GetNotHosts(), req.Host, matchHost) &&
+		matchField(o.GetPorts(), o.GetNotPorts(), port, matchString) &&
+		matchField(o.GetMethods(), o.GetNotMethods(), req.Method, matchString) &&
+		matchField(o.GetPaths(), o.GetNotPaths(), req.Path, matchString)
+}
+
+func matchCondition(c *security.Condition, req Request) (bool, error) {
+	key := c.GetKey()
+	match := matchString
+	var values []string
+	switch {
+	case strings.HasPrefix(key, "request.headers[") && strings.HasSuffix(key, "]"):
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "request.headers["), "]")
+		for k, v := range req.Headers {
+			if strings.EqualFold(k, name) {
+				values = []string{v}
+			}
+		}
+	case strings.HasPrefix(key, "request.auth.claims[") && strings.HasSuffix(key, "]"):
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "request.auth.claims["), "]")
+		values = req.Claims[name]
+	case key == "source.ip":
+		values, match = []string{req.IP}, matchIP
+	case key == "remote.ip":
+		remoteIP := req.RemoteIP
+		if remoteIP == "" {
+			remoteIP = req.IP
+		}
+		values, match = []string{remoteIP}, matchIP
+	case key == "destination.ip":
+		values, match = []string{req.DestinationIP}, matchIP
+	case key == "source.namespace":
+		values = []string{req.Namespace}
+	case key == "source.principal":
+		values = []string{req.Principal}
+	case key == "request.auth.principal":
+		values = []string{req.RequestPrincipal}
+	case key == "request.auth.audiences":
+		values = req.Audiences
+	case key == "request.auth.presenter":
+		values = []string{req.Presenter}
+	case key == "destination.port":
+		values = []string{strconv.Itoa(req.Port)}
+	case key == "connection.sni":
+		values = []string{req.SNI}
+	default:
+		return false, fmt.Errorf("unsupported condition key %q", key)
+	}
+	for _, v := range values {
+		if matchField(c.GetValues(), c.GetNotValues(), v, match) {
+			return true, nil
+		}
+	}
+	// An attribute missing from the request only matches conditions with notValues alone.
+	return len(values) == 0 && len(c.GetValues()) == 0, nil
+}
+
+// matchField matches if the value matches any of values, when set, and none of notValues.
+func matchField(values, notValues []string, value string, match func(pattern, value string) bool) bool {
+	if len(values) > 0 {
+		matched := false
+		for _, p := range values {
+			if match(p, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, p := range notValues {
+		if match(p, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchString supports exact, prefix ("abc*"), suffix ("*abc") and presence ("*") matches.
+func matchString(pattern, value string) bool {
+	switch {
+	case pattern == "*":
+		return value != ""
+	case strings.HasPrefix(pattern, "*"):
+		return strings.HasSuffix(value, pattern[1:])
+	case strings.HasSuffix(pattern, "*"):
+		return strings.HasPrefix(value, pattern[:len(pattern)-1])
+	}
+	return pattern == value
+}
+
+func matchHost(pattern, value string) bool {
+	return matchString(strings.ToLower(pattern), strings.ToLower(value))
+}
+
+func matchIP(pattern, value string) bool {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return false
+	}
+	if !strings.Contains(pattern, "/") {
+		return ip.Equal(net.ParseIP(pattern))
+	}
+	_, cidr, err := net.ParseCIDR(pattern)
+	return err == nil && cidr.Contains(ip)
+}
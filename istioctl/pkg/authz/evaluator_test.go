@@ -0,0 +1,212 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	security "istio.io/api/security/v1beta1"
+	"istio.io/api/type/v1beta1"
+)
+
+var testPolicies = []Policy{
+	{
+		Name:      "deny-blocked",
+		Namespace: "istio-system",
+		Spec: &security.AuthorizationPolicy{
+			Action: security.AuthorizationPolicy_DENY,
+			Rules: []*security.Rule{{
+				From: []*security.Rule_From{{Source: &security.Source{IpBlocks: []string{"10.10.0.0/16"}}}},
+			}},
+		},
+	},
+	{
+		Name:      "httpbin",
+		Namespace: "foo",
+		Spec: &security.AuthorizationPolicy{
+			Selector: &v1beta1.WorkloadSelector{MatchLabels: map[string]string{"app": "httpbin"}},
+			Rules: []*security.Rule{
+				{
+					From: []*security.Rule_From{{Source: &security.Source{Namespaces: []string{"foo"}}}},
+					To: []*security.Rule_To{{Operation: &security.Operation{
+						Methods: []string{"GET"},
+						Paths:   []string{"/status/*"},
+					}}},
+				},
+				{
+					When: []*security.Condition{{Key: "request.headers[X-Token]", Values: []string{"admin"}}},
+				},
+			},
+		},
+	},
+	{
+		Name:      "ext-authz",
+		Namespace: "bar",
+		Spec: &security.AuthorizationPolicy{
+			Action: security.AuthorizationPolicy_CUSTOM,
+			Rules:  []*security.Rule{{To: []*security.Rule_To{{Operation: &security.Operation{Paths: []string{"/admin"}}}}}},
+		},
+	},
+}
+
+func TestEvaluate(t *testing.T) {
+	httpbin := Workload{Namespace: "foo", Labels: map[string]string{"app": "httpbin"}}
+	cases := []struct {
+		name     string
+		workload Workload
+		request  Request
+		allowed  bool
+		policy   string
+		err      bool
+	}{
+		{
+			name:     "allowed by operation",
+			workload: httpbin,
+			request:  Request{Namespace: "foo", Method: "GET", Path: "/status/200"},
+			allowed:  true,
+			policy:   "foo/httpbin",
+		},
+		{
+			name:     "wrong method",
+			workload: httpbin,
+			request:  Request{Namespace: "foo", Method: "POST", Path: "/status/200"},
+		},
+		{
+			name:     "allowed by header condition",
+			workload: httpbin,
+			request:  Request{Namespace: "bar", Headers: map[string]string{"x-token": "admin"}},
+			allowed:  true,
+			policy:   "foo/httpbin",
+		},
+		{
+			name:     "denied by mesh-wide policy",
+			workload: httpbin,
+			request:  Request{Namespace: "foo", Method: "GET", Path: "/status/200", IP: "10.10.1.1"},
+			policy:   "istio-system/deny-blocked",
+		},
+		{
+			name:     "no allow policy for workload",
+			workload: Workload{Namespace: "foo", Labels: map[string]string{"app": "other"}},
+			request:  Request{Namespace: "bar"},
+			allowed:  true,
+		},
+		{
+			name:     "custom policy",
+			workload: Workload{Namespace: "bar"},
+			request:  Request{Path: "/admin"},
+			err:      true,
+		},
+		{
+			name:     "custom policy not matched",
+			workload: Workload{Namespace: "bar"},
+			request:  Request{Path: "/public"},
+			allowed:  true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Evaluate(testPolicies, "istio-system", tt.workload, tt.request)
+			if (err != nil) != tt.err {
+				t.Fatalf("got err %v, want error %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if d.Allowed != tt.allowed {
+				t.Fatalf("got allowed %v, want %v: %s", d.Allowed, tt.allowed, d.Reason)
+			}
+			got := ""
+			if d.Policy != nil {
+				got = d.Policy.String()
+			}
+			if got != tt.policy {
+				t.Fatalf("got policy %q, want %q", got, tt.policy)
+			}
+		})
+	}
+}
+
+func TestEvaluateActionOrder(t *testing.T) {
+	allowAdmin := Policy{
+		Name:      "allow-admin",
+		Namespace: "foo",
+		Spec: &security.AuthorizationPolicy{
+			Rules: []*security.Rule{{To: []*security.Rule_To{{Operation: &security.Operation{Paths: []string{"/admin"}}}}}},
+		},
+	}
+	denyAdmin := Policy{
+		Name:      "deny-admin",
+		Namespace: "foo",
+		Spec: &security.AuthorizationPolicy{
+			Action: security.AuthorizationPolicy_DENY,
+			Rules:  []*security.Rule{{To: []*security.Rule_To{{Operation: &security.Operation{Paths: []string{"/admin"}}}}}},
+		},
+	}
+	customAdmin := Policy{
+		Name:      "ext-authz-admin",
+		Namespace: "foo",
+		Spec: &security.AuthorizationPolicy{
+			Action: security.AuthorizationPolicy_CUSTOM,
+			Rules:  []*security.Rule{{To: []*security.Rule_To{{Operation: &security.Operation{Paths: []string{"/admin"}}}}}},
+		},
+	}
+	wl := Workload{Namespace: "foo"}
+	req := Request{Path: "/admin"}
+
+	// The DENY policy wins over the ALLOW policy listed before it.
+	d, err := Evaluate([]Policy{allowAdmin, denyAdmin}, "istio-system", wl, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Allowed || d.Policy == nil || d.Policy.Name != "deny-admin" {
+		t.Fatalf("got %+v, want a denial by deny-admin", d)
+	}
+
+	// The CUSTOM policy is evaluated before the DENY policy listed before it.
+	if _, err := Evaluate([]Policy{allowAdmin, denyAdmin, customAdmin}, "istio-system", wl, req); err == nil ||
+		!strings.Contains(err.Error(), "CUSTOM policy foo/ext-authz-admin") {
+		t.Fatalf("got err %v, want the CUSTOM policy to be evaluated first", err)
+	}
+}
+
+func TestRunTests(t *testing.T) {
+	tf, err := ParseTestFile([]byte(`
+tests:
+- name: read status
+  workload: {namespace: foo, labels: {app: httpbin}}
+  request: {namespace: foo, method: GET, path: /status/200}
+  expect: allow
+- name: write status
+  workload: {namespace: foo, labels: {app: httpbin}}
+  request: {namespace: foo, method: POST, path: /status/200}
+  expect: allow
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &bytes.Buffer{}
+	if failed := RunTests(out, tf.Tests, testPolicies, "istio-system"); failed != 1 {
+		t.Fatalf("expected 1 failed test, got %d:\n%s", failed, out)
+	}
+	if !strings.Contains(out.String(), "FAIL  write status: expected allow, got deny") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	if _, err := ParseTestFile([]byte("tests:\n- name: a\n  workload: {namespace: foo}\n  expect: maybe\n")); err == nil {
+		t.Fatal("expected error for invalid expect")
+	}
+}
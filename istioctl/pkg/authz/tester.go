@@ -0,0 +1,86 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"fmt"
+	"io"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	ExpectAllow = "allow"
+	ExpectDeny  = "deny"
+)
+
+// TestCase declares the decision expected for a request to a workload.
+type TestCase struct {
+	Name     string   `json:"name"`
+	Workload Workload `json:"workload"`
+	Request  Request  `json:"request"`
+	// Expect is either allow or deny.
+	Expect string `json:"expect"`
+}
+
+// TestFile is a list of test cases, as read from a test file.
+type TestFile struct {
+	Tests []TestCase `json:"tests"`
+}
+
+// ParseTestFile parses and validates a YAML test file.
+func ParseTestFile(b []byte) (*TestFile, error) {
+	tf := &TestFile{}
+	if err := yaml.UnmarshalStrict(b, tf); err != nil {
+		return nil, err
+	}
+	for i, tc := range tf.Tests {
+		if tc.Name == "" {
+			return nil, fmt.Errorf("tests[%d]: name is required", i)
+		}
+		if tc.Workload.Namespace == "" {
+			return nil, fmt.Errorf("test %q: workload.namespace is required", tc.Name)
+		}
+		if tc.Expect != ExpectAllow && tc.Expect != ExpectDeny {
+			return nil, fmt.Errorf("test %q: expect must be %q or %q, got %q", tc.Name, ExpectAllow, ExpectDeny, tc.Expect)
+		}
+	}
+	return tf, nil
+}
+
+// RunTests evaluates the test cases against the policies, prints one line per test and returns the number of
+// tests that failed.
+func RunTests(w io.Writer, tests []TestCase, policies []Policy, rootNamespace string) int {
+	failed := 0
+	for _, tc := range tests {
+		d, err := Evaluate(policies, rootNamespace, tc.Workload, tc.Request)
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "ERROR %s: %v\n", tc.Name, err)
+			continue
+		}
+		got := ExpectDeny
+		if d.Allowed {
+			got = ExpectAllow
+		}
+		if got != tc.Expect {
+			failed++
+			fmt.Fprintf(w, "FAIL  %s: expected %s, got %s (%s)\n", tc.Name, tc.Expect, got, d.Reason)
+			continue
+		}
+		fmt.Fprintf(w, "PASS  %s (%s)\n", tc.Name, d.Reason)
+	}
+	return failed
+}
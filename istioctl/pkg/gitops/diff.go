@@ -0,0 +1,240 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gitops compares the Istio configuration of a cluster with the configuration kept in a directory.
+package gitops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// Status describes how a resource drifted from the directory.
+type Status string
+
+const (
+	// Modified resources exist in both the directory and the cluster, with different content.
+	Modified Status = "modified"
+	// MissingInCluster resources exist in the directory only.
+	MissingInCluster Status = "not in cluster"
+	// MissingInDirectory resources exist in the cluster only.
+	MissingInDirectory Status = "not in directory"
+)
+
+// Drift is a resource whose cluster state differs from the directory.
+type Drift struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Status    Status
+	// Diff is the unified diff from the directory to the cluster version of a modified resource.
+	Diff string
+}
+
+func (d Drift) String() string {
+	return fmt.Sprintf("%s %s/%s: %s", d.Kind, d.Namespace, d.Name, d.Status)
+}
+
+// ReadDirectory parses the Istio configuration of the YAML and JSON files in dir and its subdirectories.
+// Resources without a namespace are placed in defaultNamespace, and other kinds of resources are ignored.
+func ReadDirectory(dir, defaultNamespace string) ([]config.Config, error) {
+	var out []config.Config
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		configs, _, err := crd.ParseInputs(string(b))
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		for _, c := range configs {
+			if _, ok := collections.Pilot.FindByGroupVersionKind(c.GroupVersionKind); !ok {
+				continue
+			}
+			if c.Namespace == "" {
+				c.Namespace = defaultNamespace
+			}
+			out = append(out, c)
+		}
+		return nil
+	})
+	return out, err
+}
+
+func key(c config.Config) string {
+	return c.GroupVersionKind.Kind + "/" + c.Namespace + "/" + c.Name
+}
+
+// Diff reports the resources of live which differ from local. Only the spec and the labels and annotations set in
+// local are compared, so fields set by the API server or by controllers are not reported as drift. Defaults are
+// normalized by comparing the canonical JSON form of the specs, which omits unset and zero values.
+func Diff(local, live []config.Config) ([]Drift, error) {
+	liveByKey := map[string]config.Config{}
+	for _, c := range live {
+		liveByKey[key(c)] = c
+	}
+	var drifts []Drift
+	for _, l := range local {
+		d := Drift{Kind: l.GroupVersionKind.Kind, Namespace: l.Namespace, Name: l.Name}
+		c, ok := liveByKey[key(l)]
+		if !ok {
+			d.Status = MissingInCluster
+			drifts = append(drifts, d)
+			continue
+		}
+		delete(liveByKey, key(l))
+		want, err := normalize(l, l)
+		if err != nil {
+			return nil, err
+		}
+		got, err := normalize(c, l)
+		if err != nil {
+			return nil, err
+		}
+		if want == got {
+			continue
+		}
+		d.Status = Modified
+		d.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			FromFile: "directory",
+			A:        difflib.SplitLines(want),
+			ToFile:   "cluster",
+			B:        difflib.SplitLines(got),
+			Context:  3,
+		})
+		if err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, d)
+	}
+	for _, c := range liveByKey {
+		drifts = append(drifts, Drift{Kind: c.GroupVersionKind.Kind, Namespace: c.Namespace, Name: c.Name, Status: MissingInDirectory})
+	}
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].String() < drifts[j].String()
+	})
+	return drifts, nil
+}
+
+// normalize renders the spec of c and the labels and annotations of c which are set in reference as YAML.
+func normalize(c config.Config, reference config.Config) (string, error) {
+	spec, err := config.ToJSON(c.Spec)
+	if err != nil {
+		return "", err
+	}
+	specYAML, err := yaml.JSONToYAML(spec)
+	if err != nil {
+		return "", err
+	}
+	sb := strings.Builder{}
+	writeMap(&sb, "labels", c.Labels, reference.Labels)
+	writeMap(&sb, "annotations", c.Annotations, reference.Annotations)
+	sb.WriteString("spec:\n")
+	for _, line := range strings.SplitAfter(strings.TrimSuffix(string(specYAML), "\n"), "\n") {
+		sb.WriteString("  " + line)
+	}
+	sb.WriteString("\n")
+	return sb.String(), nil
+}
+
+func writeMap(sb *strings.Builder, name string, m, reference map[string]string) {
+	if len(reference) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(reference))
+	for k := range reference {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	sb.WriteString(name + ":\n")
+	for _, k := range keys {
+		v, ok := m[k]
+		if !ok {
+			v = "<unset>"
+		}
+		fmt.Fprintf(sb, "  %s: %s\n", k, v)
+	}
+}
+
+// ReadCluster lists the Istio configuration in the given namespaces of the cluster.
+func ReadCluster(client dynamic.Interface, namespaces []string) ([]config.Config, error) {
+	var out []config.Config
+	for _, s := range collections.Pilot.All() {
+		for _, ns := range namespaces {
+			list, err := client.Resource(s.Resource().GroupVersionResource()).Namespace(ns).List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				if kerrors.IsNotFound(err) {
+					// The CRD is not installed.
+					break
+				}
+				return nil, err
+			}
+			for _, item := range list.Items {
+				b, err := json.Marshal(item.Object)
+				if err != nil {
+					return nil, err
+				}
+				obj := &crd.IstioKind{}
+				if err := json.Unmarshal(b, obj); err != nil {
+					return nil, err
+				}
+				c, err := crd.ConvertObject(s, obj, "")
+				if err != nil {
+					return nil, fmt.Errorf("%s %s/%s: %v", s.Resource().Kind(), item.GetNamespace(), item.GetName(), err)
+				}
+				out = append(out, *c)
+			}
+		}
+	}
+	return out, nil
+}
+
+// Namespaces returns the namespaces of the configs.
+func Namespaces(configs []config.Config) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, c := range configs {
+		if !seen[c.Namespace] {
+			seen[c.Namespace] = true
+			out = append(out, c.Namespace)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,117 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitops
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+)
+
+const liveConfig = `
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: reviews
+  namespace: bookinfo
+  resourceVersion: "1234"
+  generation: 3
+  labels:
+    team: bookinfo
+    app.kubernetes.io/managed-by: argocd
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: "{}"
+spec:
+  host: reviews
+  trafficPolicy:
+    tls:
+      mode: SIMPLE
+---
+apiVersion: networking.istio.io/v1beta1
+kind: ServiceEntry
+metadata:
+  name: external
+  namespace: egress
+spec:
+  hosts:
+  - example.com
+  location: MESH_EXTERNAL
+  ports:
+  - number: 443
+    name: https
+    protocol: TLS
+  resolution: DNS
+---
+apiVersion: networking.istio.io/v1beta1
+kind: VirtualService
+metadata:
+  name: manual
+  namespace: bookinfo
+spec:
+  hosts:
+  - reviews
+  http:
+  - route:
+    - destination:
+        host: reviews
+`
+
+func TestDiff(t *testing.T) {
+	local, err := ReadDirectory("testdata", "bookinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := Namespaces(local), []string{"bookinfo", "egress"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got namespaces %v, want %v", got, want)
+	}
+	live, _, err := crd.ParseInputs(liveConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	drifts, err := Diff(local, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range drifts {
+		got = append(got, d.String())
+	}
+	want := []string{
+		"DestinationRule bookinfo/reviews: modified",
+		"VirtualService bookinfo/manual: not in directory",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for _, line := range []string{"-      mode: ISTIO_MUTUAL", "+      mode: SIMPLE"} {
+		if !strings.Contains(drifts[0].Diff, line) {
+			t.Errorf("diff missing %q:\n%s", line, drifts[0].Diff)
+		}
+	}
+	if strings.Contains(drifts[0].Diff, "managed-by") {
+		t.Errorf("diff contains labels not set in the directory:\n%s", drifts[0].Diff)
+	}
+
+	drifts, err = Diff(local, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drifts) != 2 || drifts[0].Status != MissingInCluster || drifts[1].Status != MissingInCluster {
+		t.Fatalf("expected all resources missing in cluster, got %v", drifts)
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/kube"
+)
+
+// ExternalIstiodOptions contains the options for bootstrapping an external control plane.
+type ExternalIstiodOptions struct {
+	KubeOptions
+
+	// ExternalContext is the context of the cluster running the external istiod. Defaults to --context.
+	ExternalContext string
+
+	// ConfigClusterName is the name of the config cluster, which holds the mesh configuration.
+	ConfigClusterName string
+	// ConfigContext is the context of the config cluster.
+	ConfigContext string
+
+	// RemoteContexts maps the names of additional remote clusters to their contexts.
+	RemoteContexts map[string]string
+
+	// ExternalIstiodAddress is the address, reachable from the remote clusters, of the external istiod.
+	ExternalIstiodAddress string
+
+	// Network is the network of the remote clusters, used in the injection URLs.
+	Network string
+
+	// DryRun validates the topology and prints the changes instead of applying them.
+	DryRun bool
+}
+
+func (o *ExternalIstiodOptions) addFlags(flagset *pflag.FlagSet) {
+	flagset.StringVar(&o.ExternalContext, "external-context", "",
+		"The context of the cluster running the external istiod. Defaults to --context.")
+	flagset.StringVar(&o.ConfigClusterName, "config-cluster-name", "",
+		"The name of the config cluster, which holds the mesh configuration.")
+	flagset.StringVar(&o.ConfigContext, "config-context", "",
+		"The context of the config cluster.")
+	flagset.StringToStringVar(&o.RemoteContexts, "remote-contexts", nil,
+		"Additional remote clusters managed by the external istiod, as name=context pairs.")
+	flagset.StringVar(&o.ExternalIstiodAddress, "external-istiod-address", "",
+		"The address of the external istiod, reachable from the remote clusters.")
+	flagset.StringVar(&o.Network, "network", "",
+		"The network of the remote clusters, used in the sidecar injection URLs.")
+	flagset.BoolVar(&o.DryRun, "dry-run", false,
+		"Validate the topology and print the changes without applying them.")
+}
+
+func (o *ExternalIstiodOptions) prepare(flags *pflag.FlagSet) error {
+	o.KubeOptions.prepare(flags)
+	if o.ExternalContext == "" {
+		o.ExternalContext = o.Context
+	}
+	if o.ConfigClusterName == "" || o.ConfigContext == "" {
+		return fmt.Errorf("--config-cluster-name and --config-context are required")
+	}
+	if o.ExternalIstiodAddress == "" {
+		return fmt.Errorf("--external-istiod-address is required")
+	}
+	if strings.Contains(o.ExternalIstiodAddress, "/") {
+		return fmt.Errorf("--external-istiod-address must be a host or IP address, got %q", o.ExternalIstiodAddress)
+	}
+	for _, name := range o.clusterNames() {
+		if !labels.IsDNS1123Label(name) {
+			return fmt.Errorf("%v is not a valid DNS 1123 label", name)
+		}
+	}
+	return nil
+}
+
+// clusterNames returns the names of the config cluster and of the remote clusters, in order.
+func (o *ExternalIstiodOptions) clusterNames() []string {
+	names := make([]string, 0, len(o.RemoteContexts))
+	for name := range o.RemoteContexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{o.ConfigClusterName}, names...)
+}
+
+func (o *ExternalIstiodOptions) clusterContext(name string) string {
+	if name == o.ConfigClusterName {
+		return o.ConfigContext
+	}
+	return o.RemoteContexts[name]
+}
+
+// injectionURL returns the URL of the sidecar injection webhook of the external istiod for a remote cluster.
+func (o *ExternalIstiodOptions) injectionURL(clusterName string) string {
+	url := fmt.Sprintf("https://%s/inject/cluster/%s", net.JoinHostPort(o.ExternalIstiodAddress, "15017"), clusterName)
+	if o.Network != "" {
+		url += "/net/" + o.Network
+	}
+	return url
+}
+
+// NewExternalIstiodCommand creates a new command for managing external control planes.
+func NewExternalIstiodCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "external-istiod",
+		Short: "Commands to manage an external control plane, running istiod outside of the clusters it manages",
+	}
+	c.AddCommand(newExternalIstiodBootstrapCommand())
+	return c
+}
+
+func newExternalIstiodBootstrapCommand() *cobra.Command {
+	opts := ExternalIstiodOptions{}
+	c := &cobra.Command{
+		Use:   "bootstrap",
+		Short: "Wire up an external control plane with its config and remote clusters",
+		Long: `Wire up an external control plane with its config and remote clusters.
+
+The istiod-remote chart must already be installed in the config and remote clusters. Each step
+is validated before the next one runs, and the command fails at the first step which fails:
+
+  1. the namespace of the external istiod exists, or is created, in the external cluster;
+  2. the service accounts of the external istiod are bound to their cluster roles in the config
+     and remote clusters;
+  3. the config secret of the config cluster and the remote secrets of the remote clusters are
+     applied in the external cluster;
+  4. the sidecar injection webhooks of the config and remote clusters target the external istiod.`,
+		Example: `  # Bootstrap an external istiod running in cluster external, managing config cluster c0 and remote cluster c1.
+  istioctl x external-istiod bootstrap -n external-istiod --external-context external \
+    --config-cluster-name c0 --config-context ctx-c0 --remote-contexts c1=ctx-c1 \
+    --external-istiod-address istiod.example.com
+
+  # Validate the topology and print the changes without applying them.
+  istioctl x external-istiod bootstrap -n external-istiod --external-context external \
+    --config-cluster-name c0 --config-context ctx-c0 --external-istiod-address istiod.example.com --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := opts.prepare(c.Flags()); err != nil {
+				return err
+			}
+			env, err := NewEnvironmentFromCobra(opts.Kubeconfig, opts.Context, c)
+			if err != nil {
+				return err
+			}
+			return BootstrapExternalIstiod(opts, env)
+		},
+	}
+	opts.addFlags(c.PersistentFlags())
+	return c
+}
+
+// BootstrapExternalIstiod wires up an external control plane with its config and remote clusters, validating
+// each step before running the next one.
+func BootstrapExternalIstiod(opt ExternalIstiodOptions, env Environment) error {
+	for _, kubeContext := range append([]string{opt.ExternalContext}, opt.contexts()...) {
+		if _, ok := env.GetConfig().Contexts[kubeContext]; kubeContext != "" && !ok {
+			return fmt.Errorf("context %q not found in kubeconfig", kubeContext)
+		}
+	}
+
+	external, err := env.CreateClient(opt.ExternalContext)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the external cluster: %v", err)
+	}
+	if opt.DryRun {
+		if _, err := external.Kube().CoreV1().Namespaces().Get(context.TODO(), opt.Namespace, metav1.GetOptions{}); err != nil {
+			env.Printf("Namespace %s would be created in the external cluster\n", opt.Namespace)
+		} else {
+			env.Printf("✔ Namespace %s is ready in the external cluster\n", opt.Namespace)
+		}
+	} else {
+		if err := createNamespaceIfNotExist(external, opt.Namespace); err != nil {
+			return err
+		}
+		env.Printf("✔ Namespace %s is ready in the external cluster\n", opt.Namespace)
+	}
+
+	clients := map[string]kube.ExtendedClient{}
+	for _, name := range opt.clusterNames() {
+		client, err := env.CreateClient(opt.clusterContext(name))
+		if err != nil {
+			return fmt.Errorf("failed to connect to cluster %s: %v", name, err)
+		}
+		serviceAccount := constants.DefaultServiceAccountName
+		if name == opt.ConfigClusterName {
+			serviceAccount = constants.DefaultConfigServiceAccountName
+		}
+		if err := checkServiceAccountRBAC(client, opt.Namespace, serviceAccount); err != nil {
+			return fmt.Errorf("cluster %s: %v; is the istiod-remote chart installed?", name, err)
+		}
+		clients[name] = client
+		env.Printf("✔ Service account %s/%s is bound to its cluster roles in cluster %s\n", opt.Namespace, serviceAccount, name)
+	}
+
+	for _, name := range opt.clusterNames() {
+		secretType := SecretTypeRemote
+		secretName := remoteSecretNameFromClusterName(name)
+		if name == opt.ConfigClusterName {
+			secretType = SecretTypeConfig
+			secretName = configSecretName
+		}
+		if opt.DryRun {
+			// Creating the secret may create the token secret of the service account in the cluster.
+			env.Printf("Secret %s/%s of cluster %s would be applied in the external cluster\n", opt.Namespace, secretName, name)
+			continue
+		}
+		secret, warn, err := createRemoteSecret(RemoteSecretOptions{
+			KubeOptions: KubeOptions{
+				Kubeconfig: opt.Kubeconfig,
+				Context:    opt.clusterContext(name),
+				Namespace:  opt.Namespace,
+			},
+			ClusterName: name,
+			AuthType:    RemoteSecretAuthTypeBearerToken,
+			Type:        secretType,
+		}, clients[name], env)
+		if err != nil {
+			return fmt.Errorf("failed to create the %s secret of cluster %s: %v", secretType, name, err)
+		}
+		if warn != nil {
+			env.Errorf("warn: %v\n", warn)
+		}
+		if err := applySecret(external, secret); err != nil {
+			return fmt.Errorf("failed to apply the %s secret of cluster %s: %v", secretType, name, err)
+		}
+		env.Printf("✔ Secret %s/%s of cluster %s is applied in the external cluster\n", secret.Namespace, secret.Name, name)
+	}
+
+	for _, name := range opt.clusterNames() {
+		url := opt.injectionURL(name)
+		if err := checkInjectionURL(clients[name], url); err != nil {
+			return fmt.Errorf("cluster %s: %v; install the istiod-remote chart with --set istiodRemote.injectionURL=%s",
+				name, err, url)
+		}
+		env.Printf("✔ Sidecar injection of cluster %s targets %s\n", name, url)
+	}
+	return nil
+}
+
+func (o *ExternalIstiodOptions) contexts() []string {
+	var contexts []string
+	for _, name := range o.clusterNames() {
+		contexts = append(contexts, o.clusterContext(name))
+	}
+	return contexts
+}
+
+// checkServiceAccountRBAC checks that the service account exists and is the subject of a cluster role binding.
+func checkServiceAccountRBAC(client kube.Client, namespace, serviceAccount string) error {
+	if _, err := client.Kube().CoreV1().ServiceAccounts(namespace).Get(context.TODO(), serviceAccount, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("service account %s/%s not found: %v", namespace, serviceAccount, err)
+	}
+	bindings, err := client.Kube().RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed listing cluster role bindings: %v", err)
+	}
+	for _, binding := range bindings.Items {
+		for _, subject := range binding.Subjects {
+			if subject.Kind == "ServiceAccount" && subject.Namespace == namespace && subject.Name == serviceAccount {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("service account %s/%s is not bound to any cluster role", namespace, serviceAccount)
+}
+
+// checkInjectionURL checks that the sidecar injection webhooks of the cluster target the injection URL.
+func checkInjectionURL(client kube.Client, url string) error {
+	webhooks, err := client.Kube().AdmissionregistrationV1().MutatingWebhookConfigurations().List(context.TODO(), metav1.ListOptions{
+		LabelSelector: "app=sidecar-injector",
+	})
+	if err != nil {
+		return fmt.Errorf("failed listing mutating webhook configurations: %v", err)
+	}
+	if len(webhooks.Items) == 0 {
+		return fmt.Errorf("no sidecar injector webhook found")
+	}
+	for _, config := range webhooks.Items {
+		for _, webhook := range config.Webhooks {
+			if webhook.ClientConfig.URL == nil || *webhook.ClientConfig.URL != url {
+				return fmt.Errorf("webhook %s of %s does not target %s", webhook.Name, config.Name, url)
+			}
+		}
+	}
+	return nil
+}
+
+// applySecret creates the secret, or updates it when it already exists.
+func applySecret(client kube.Client, secret *v1.Secret) error {
+	secrets := client.Kube().CoreV1().Secrets(secret.Namespace)
+	existing, err := secrets.Get(context.TODO(), secret.Name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		_, err = secrets.Create(context.TODO(), secret, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	secret.ResourceVersion = existing.ResourceVersion
+	_, err = secrets.Update(context.TODO(), secret, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,191 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multicluster
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"istio.io/istio/pkg/config/constants"
+)
+
+func TestBootstrapExternalIstiod(t *testing.T) {
+	const ns = "external-istiod"
+	serviceAccount := func(name string) *v1.ServiceAccount {
+		return &v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns}}
+	}
+	tokenSecret := func(sa string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        sa + "-token",
+				Namespace:   ns,
+				Annotations: map[string]string{v1.ServiceAccountNameKey: sa},
+			},
+			Data: map[string][]byte{
+				v1.ServiceAccountRootCAKey: []byte("caData"),
+				v1.ServiceAccountTokenKey:  []byte("token"),
+			},
+			Type: v1.SecretTypeServiceAccountToken,
+		}
+	}
+	binding := func(sa string) *rbacv1.ClusterRoleBinding {
+		return &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: sa},
+			Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: sa, Namespace: ns}},
+		}
+	}
+	webhook := func(url string) *admissionregistrationv1.MutatingWebhookConfiguration {
+		return &admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "istio-sidecar-injector", Labels: map[string]string{"app": "sidecar-injector"}},
+			Webhooks: []admissionregistrationv1.MutatingWebhook{{
+				Name:         "namespace.sidecar-injector.istio.io",
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{URL: &url},
+			}},
+		}
+	}
+	config := &api.Config{
+		CurrentContext: "external",
+		Contexts: map[string]*api.Context{
+			"external": {Cluster: "external"},
+			"ctx-c0":   {Cluster: "c0"},
+		},
+		Clusters: map[string]*api.Cluster{
+			"external": {Server: "https://external"},
+			"c0":       {Server: "https://c0"},
+		},
+	}
+	opts := ExternalIstiodOptions{
+		KubeOptions:           KubeOptions{Namespace: ns},
+		ExternalContext:       "external",
+		ConfigClusterName:     "c0",
+		ConfigContext:         "ctx-c0",
+		ExternalIstiodAddress: "istiod.example.com",
+		Network:               "network1",
+	}
+	ready := []runtime.Object{
+		kubeSystemNamespace,
+		serviceAccount(constants.DefaultConfigServiceAccountName),
+		tokenSecret(constants.DefaultConfigServiceAccountName),
+		binding(constants.DefaultConfigServiceAccountName),
+	}
+
+	cases := []struct {
+		name       string
+		opts       func(o *ExternalIstiodOptions)
+		objs       []runtime.Object
+		wantErr    string
+		wantSecret bool
+		wantWarn   string
+	}{
+		{
+			name:    "unknown context",
+			opts:    func(o *ExternalIstiodOptions) { o.ConfigContext = "missing" },
+			objs:    ready,
+			wantErr: `context "missing" not found in kubeconfig`,
+		},
+		{
+			name:    "missing RBAC",
+			objs:    ready[:3],
+			wantErr: "service account external-istiod/istiod is not bound to any cluster role",
+		},
+		{
+			name:    "webhook not targeting the external istiod",
+			objs:    append(ready, webhook("https://istiod.internal:15017/inject")),
+			wantErr: "does not target https://istiod.example.com:15017/inject/cluster/c0/net/network1",
+		},
+		{
+			name:    "no webhook",
+			objs:    ready,
+			wantErr: "no sidecar injector webhook found",
+		},
+		{
+			name:       "success",
+			objs:       append(ready, webhook("https://istiod.example.com:15017/inject/cluster/c0/net/network1")),
+			wantSecret: true,
+		},
+		{
+			name: "dry run",
+			opts: func(o *ExternalIstiodOptions) { o.DryRun = true },
+			// Without the token secret, creating the config secret would create it.
+			objs: []runtime.Object{
+				kubeSystemNamespace,
+				serviceAccount(constants.DefaultConfigServiceAccountName),
+				binding(constants.DefaultConfigServiceAccountName),
+				webhook("https://istiod.example.com:15017/inject/cluster/c0/net/network1"),
+			},
+		},
+		{
+			name:    "dry run with webhook not targeting the external istiod",
+			opts:    func(o *ExternalIstiodOptions) { o.DryRun = true },
+			objs:    append(ready, webhook("https://istiod.internal:15017/inject")),
+			wantErr: "does not target https://istiod.example.com:15017/inject/cluster/c0/net/network1",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			o := opts
+			if c.opts != nil {
+				c.opts(&o)
+			}
+			env := newFakeEnvironmentOrDie(t, "", config, c.objs...)
+			err := BootstrapExternalIstiod(o, env)
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Fatalf("got error %v, want %q", err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_, err = env.client.Kube().CoreV1().Secrets(ns).Get(context.TODO(), configSecretName, metav1.GetOptions{})
+			if gotSecret := err == nil; gotSecret != c.wantSecret {
+				t.Fatalf("config secret applied: got %v, want %v", gotSecret, c.wantSecret)
+			}
+			if o.DryRun {
+				for _, action := range env.client.Kube().(*fake.Clientset).Actions() {
+					if verb := action.GetVerb(); verb != "get" && verb != "list" && verb != "watch" {
+						t.Fatalf("unexpected %s %s in dry run", verb, action.GetResource().Resource)
+					}
+				}
+			}
+			stderr := env.Stderr().(*bytes.Buffer).String()
+			if c.wantWarn == "" && stderr != "" || !strings.Contains(stderr, c.wantWarn) {
+				t.Fatalf("got stderr %q, want %q", stderr, c.wantWarn)
+			}
+		})
+	}
+}
+
+func TestExternalIstiodInjectionURL(t *testing.T) {
+	o := ExternalIstiodOptions{ExternalIstiodAddress: "10.0.0.1"}
+	if got, want := o.injectionURL("c1"), "https://10.0.0.1:15017/inject/cluster/c1"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	o = ExternalIstiodOptions{ExternalIstiodAddress: "fd00::1", Network: "n1"}
+	if got, want := o.injectionURL("c1"), "https://[fd00::1]:15017/inject/cluster/c1/net/n1"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"istio.io/api/annotation"
+	analyzer_util "istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/kube/inject"
+	"istio.io/istio/pkg/util/sets"
+)
+
+const (
+	injectConfigMapName = "istio-sidecar-injector"
+	injectConfigMapKey  = "config"
+)
+
+// RetagReport describes the impact of pointing an existing revision tag to another revision.
+type RetagReport struct {
+	Tag          string
+	FromRevision string
+	ToRevision   string
+	// Namespaces are the namespaces injected by the tag.
+	Namespaces []string
+	// Pods are the pods of these namespaces, as namespace/name, which get the new revision's sidecar on restart.
+	Pods []string
+	// Incompatibilities lists why the new revision's injector cannot inject the pods as the current one does.
+	Incompatibilities []string
+}
+
+// CheckRetag compares the injector of the revision the tag currently references with the injector of the new revision,
+// and reports the namespaces and pods affected by the change. It returns nil if the tag does not exist yet or already
+// references the revision.
+func CheckRetag(ctx context.Context, client kubernetes.Interface, tag, revision, istioNS string) (*RetagReport, error) {
+	whs, err := GetWebhooksWithTag(ctx, client, tag)
+	if err != nil {
+		return nil, err
+	}
+	if len(whs) == 0 {
+		return nil, nil
+	}
+	current, err := GetWebhookRevision(whs[0])
+	if err != nil {
+		return nil, err
+	}
+	if current == revision {
+		return nil, nil
+	}
+	report := &RetagReport{Tag: tag, FromRevision: current, ToRevision: revision}
+
+	report.Namespaces, err = retagNamespaces(ctx, client, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	oldConfig, err := getInjectConfig(ctx, client, current, istioNS)
+	if err != nil {
+		return nil, err
+	}
+	newConfig, err := getInjectConfig(ctx, client, revision, istioNS)
+	if err != nil {
+		return nil, err
+	}
+	if !equalTemplates(oldConfig.DefaultTemplates, newConfig.DefaultTemplates) {
+		report.Incompatibilities = append(report.Incompatibilities,
+			fmt.Sprintf("default templates change from %s to %s", strings.Join(oldConfig.DefaultTemplates, ","),
+				strings.Join(newConfig.DefaultTemplates, ",")))
+	}
+	if missing := missingTemplates(newConfig, newConfig.DefaultTemplates); len(missing) > 0 {
+		report.Incompatibilities = append(report.Incompatibilities,
+			fmt.Sprintf("revision %q does not define its default templates %s", revision, strings.Join(missing, ",")))
+	}
+
+	for _, ns := range report.Namespaces {
+		pods, err := client.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range pods.Items {
+			name := pod.Namespace + "/" + pod.Name
+			report.Pods = append(report.Pods, name)
+			requested, f := pod.Annotations[annotation.InjectTemplates.Name]
+			if !f {
+				continue
+			}
+			names := []string{}
+			for _, t := range strings.Split(requested, ",") {
+				names = append(names, strings.TrimSpace(t))
+			}
+			if missing := missingTemplates(newConfig, names); len(missing) > 0 {
+				report.Incompatibilities = append(report.Incompatibilities,
+					fmt.Sprintf("pod %s requests templates %s not defined by revision %q", name, strings.Join(missing, ","), revision))
+			}
+		}
+	}
+	sort.Strings(report.Pods)
+	return report, nil
+}
+
+// retagNamespaces returns the sorted namespaces injected by the tag: the namespaces labeled with it, and for the
+// default tag the namespaces labeled istio-injection=enabled too.
+func retagNamespaces(ctx context.Context, client kubernetes.Interface, tag string) ([]string, error) {
+	namespaces, err := GetNamespacesWithTag(ctx, client, tag)
+	if err != nil {
+		return nil, err
+	}
+	if tag == DefaultRevisionName {
+		injected, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s", analyzer_util.InjectionLabelName, analyzer_util.InjectionLabelEnableValue),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range injected.Items {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+	return sets.New(namespaces...).SortedList(), nil
+}
+
+// getInjectConfig reads the injection configuration of the revision from its istio-sidecar-injector ConfigMap.
+func getInjectConfig(ctx context.Context, client kubernetes.Interface, revision, istioNS string) (inject.Config, error) {
+	name := injectConfigMapName
+	if revision != "" && revision != DefaultRevisionName {
+		name = fmt.Sprintf("%s-%s", injectConfigMapName, revision)
+	}
+	cm, err := client.CoreV1().ConfigMaps(istioNS).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return inject.Config{}, fmt.Errorf("could not read the injection configuration of revision %q: %v", revision, err)
+	}
+	data, ok := cm.Data[injectConfigMapKey]
+	if !ok {
+		return inject.Config{}, fmt.Errorf("missing configuration map key %q in %q", injectConfigMapKey, name)
+	}
+	cfg, err := inject.UnmarshalConfig([]byte(data))
+	if err != nil {
+		return inject.Config{}, fmt.Errorf("invalid injection configuration of revision %q: %v", revision, err)
+	}
+	return cfg, nil
+}
+
+// missingTemplates returns the templates, after expanding the aliases, which are not defined in the configuration.
+func missingTemplates(cfg inject.Config, names []string) []string {
+	missing := []string{}
+	for _, name := range names {
+		expanded := []string{name}
+		if alias, f := cfg.Aliases[name]; f {
+			expanded = alias
+		}
+		for _, t := range expanded {
+			if _, f := cfg.RawTemplates[t]; !f {
+				missing = append(missing, t)
+			}
+		}
+	}
+	return missing
+}
+
+func equalTemplates(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
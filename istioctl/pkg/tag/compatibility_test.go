@@ -0,0 +1,146 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tag
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	admit_v1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/api/annotation"
+	"istio.io/api/label"
+)
+
+func injectorConfigMap(revision, config string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "istio-sidecar-injector-" + revision, Namespace: "istio-system"},
+		Data:       map[string]string{"config": config},
+	}
+}
+
+func TestCheckRetag(t *testing.T) {
+	tagWebhook := &admit_v1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "istio-revision-tag-prod",
+			Labels: map[string]string{IstioTagLabel: "prod", label.IoIstioRev.Name: "1-8-0"},
+		},
+	}
+	objects := []runtime.Object{
+		tagWebhook,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app", Labels: map[string]string{label.IoIstioRev.Name: "prod"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "app"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name: "custom", Namespace: "app",
+			Annotations: map[string]string{annotation.InjectTemplates.Name: "sidecar, custom"},
+		}},
+		injectorConfigMap("1-8-0", "templates:\n  sidecar: a\n  custom: b\n"),
+		injectorConfigMap("1-8-1", "templates:\n  sidecar: a\n  custom: b\n"),
+		injectorConfigMap("1-9-0", "defaultTemplates: [sidecar, extra]\ntemplates:\n  sidecar: a\n"),
+	}
+
+	cases := []struct {
+		name              string
+		revision          string
+		report            bool
+		incompatibilities []string
+	}{
+		{
+			name:     "same revision",
+			revision: "1-8-0",
+		},
+		{
+			name:     "compatible",
+			revision: "1-8-1",
+			report:   true,
+		},
+		{
+			name:     "incompatible",
+			revision: "1-9-0",
+			report:   true,
+			incompatibilities: []string{
+				"default templates change from sidecar to sidecar,extra",
+				`revision "1-9-0" does not define its default templates extra`,
+				`pod app/custom requests templates custom not defined by revision "1-9-0"`,
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(objects...)
+			report, err := CheckRetag(context.Background(), client, "prod", tt.revision, "istio-system")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.report {
+				if report != nil {
+					t.Fatalf("expected no report, got %+v", report)
+				}
+				return
+			}
+			if report == nil {
+				t.Fatal("expected a report")
+			}
+			if report.FromRevision != "1-8-0" || report.ToRevision != tt.revision {
+				t.Errorf("unexpected revisions %q -> %q", report.FromRevision, report.ToRevision)
+			}
+			if !reflect.DeepEqual(report.Namespaces, []string{"app"}) {
+				t.Errorf("unexpected namespaces %v", report.Namespaces)
+			}
+			if !reflect.DeepEqual(report.Pods, []string{"app/custom", "app/plain"}) {
+				t.Errorf("unexpected pods %v", report.Pods)
+			}
+			if !reflect.DeepEqual(report.Incompatibilities, tt.incompatibilities) {
+				t.Errorf("unexpected incompatibilities:\n%q\nwant:\n%q", report.Incompatibilities, tt.incompatibilities)
+			}
+		})
+	}
+
+	defaultWebhook := &admit_v1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "istio-revision-tag-default",
+			Labels: map[string]string{IstioTagLabel: DefaultRevisionName, label.IoIstioRev.Name: "1-8-0"},
+		},
+	}
+	client := fake.NewSimpleClientset(
+		defaultWebhook,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tagged", Labels: map[string]string{label.IoIstioRev.Name: DefaultRevisionName}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "legacy", Labels: map[string]string{"istio-injection": "enabled"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "disabled", Labels: map[string]string{"istio-injection": "disabled"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "legacy"}},
+		injectorConfigMap("1-8-0", "templates:\n  sidecar: a\n"),
+		injectorConfigMap("1-8-1", "templates:\n  sidecar: a\n"),
+	)
+	report, err := CheckRetag(context.Background(), client, DefaultRevisionName, "1-8-1", "istio-system")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.Namespaces, []string{"legacy", "tagged"}) {
+		t.Errorf("expected the namespaces with istio-injection=enabled to be retagged, got %v", report.Namespaces)
+	}
+	if !reflect.DeepEqual(report.Pods, []string{"legacy/legacy"}) {
+		t.Errorf("unexpected pods %v", report.Pods)
+	}
+
+	client = fake.NewSimpleClientset(tagWebhook)
+	if _, err := CheckRetag(context.Background(), client, "prod", "1-8-1", "istio-system"); err == nil {
+		t.Error("expected an error without the injection configuration")
+	}
+}
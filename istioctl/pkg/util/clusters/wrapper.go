@@ -15,6 +15,8 @@
 package clusters
 
 import (
+	"fmt"
+
 	adminapi "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
 
 	"istio.io/istio/pkg/util/protomarshal"
@@ -38,3 +40,11 @@ func (w *Wrapper) UnmarshalJSON(b []byte) error {
 	*w = Wrapper{cd}
 	return err
 }
+
+// Validate checks that the clusters are the output of the Envoy admin clusters endpoint in the JSON format.
+func (w *Wrapper) Validate() error {
+	if len(w.GetClusterStatuses()) == 0 {
+		return fmt.Errorf("no cluster statuses found, it is not the output of the Envoy admin clusters?format=json endpoint")
+	}
+	return nil
+}
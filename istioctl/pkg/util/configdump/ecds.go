@@ -0,0 +1,66 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"github.com/golang/protobuf/jsonpb"
+)
+
+// EcdsConfigDump is the ECDS section of the config dump, with the extension configs Envoy received over ECDS, such
+// as the filters of WasmPlugins. The admin API of go-control-plane predates it, so it is decoded from the JSON of
+// the config dump.
+type EcdsConfigDump struct {
+	EcdsFilters []*EcdsFilterConfig `json:"ecds_filters,omitempty"`
+}
+
+// EcdsFilterConfig is an extension config received over ECDS.
+type EcdsFilterConfig struct {
+	VersionInfo string `json:"version_info,omitempty"`
+	// EcdsFilter is the TypedExtensionConfig of the filter.
+	EcdsFilter   json.RawMessage `json:"ecds_filter,omitempty"`
+	LastUpdated  string          `json:"last_updated,omitempty"`
+	ClientStatus string          `json:"client_status,omitempty"`
+	ErrorState   json.RawMessage `json:"error_state,omitempty"`
+}
+
+// GetTypedExtensionConfig decodes the TypedExtensionConfig of the filter, ignoring the unknown types in it.
+func (f *EcdsFilterConfig) GetTypedExtensionConfig() (*core.TypedExtensionConfig, error) {
+	tec := &core.TypedExtensionConfig{}
+	err := (&jsonpb.Unmarshaler{
+		AllowUnknownFields: true,
+		AnyResolver:        &envoyResolver,
+	}).Unmarshal(bytes.NewReader(f.EcdsFilter), tec)
+	if err != nil {
+		return nil, err
+	}
+	return tec, nil
+}
+
+// GetEcdsConfigDump retrieves the extension config dump from the ConfigDump
+func (w *Wrapper) GetEcdsConfigDump() (*EcdsConfigDump, error) {
+	if w.ecds == nil {
+		return nil, fmt.Errorf("config dump has no configuration type %s", ecds)
+	}
+	ecdsDump := &EcdsConfigDump{}
+	if err := json.Unmarshal(w.ecds, ecdsDump); err != nil {
+		return nil, err
+	}
+	return ecdsDump, nil
+}
@@ -16,6 +16,7 @@ package configdump
 
 import (
 	"fmt"
+	"strings"
 
 	anypb "google.golang.org/protobuf/types/known/anypb"
 )
@@ -30,8 +31,25 @@ const (
 	clusters  configTypeURL = "type.googleapis.com/envoy.admin.v3.ClustersConfigDump"
 	routes    configTypeURL = "type.googleapis.com/envoy.admin.v3.RoutesConfigDump"
 	secrets   configTypeURL = "type.googleapis.com/envoy.admin.v3.SecretsConfigDump"
+	ecds      configTypeURL = "type.googleapis.com/envoy.admin.v3.EcdsConfigDump"
+
+	adminTypeURLPrefix = "type.googleapis.com/envoy.admin.v3."
 )
 
+// Validate checks that the dump is a well-formed Envoy admin config_dump: it has configs, and all of them are admin
+// config dump sections. Sections unknown to istioctl, such as the ones of newer Envoy versions, are accepted.
+func (w *Wrapper) Validate() error {
+	if len(w.GetConfigs()) == 0 {
+		return fmt.Errorf("config dump has no configs, it is not the output of the Envoy admin config_dump endpoint")
+	}
+	for i, conf := range w.Configs {
+		if !strings.HasPrefix(conf.TypeUrl, adminTypeURLPrefix) || !strings.HasSuffix(conf.TypeUrl, "ConfigDump") {
+			return fmt.Errorf("config dump configs[%d] has type %q, which is not an Envoy admin config dump", i, conf.TypeUrl)
+		}
+	}
+	return nil
+}
+
 // getSection takes a TypeURL and returns the types.Any from the config dump corresponding to that URL
 func (w *Wrapper) getSection(sectionTypeURL configTypeURL) (*anypb.Any, error) {
 	var dumpAny *anypb.Any
@@ -16,6 +16,7 @@ package configdump
 
 import (
 	"bytes"
+	"encoding/json"
 	"reflect"
 	"strings"
 
@@ -50,6 +51,8 @@ func (m *nonstrictResolver) Resolve(typeURL string) (legacyproto.Message, error)
 // It has extra helper functions for handling any/struct/marshal protobuf pain
 type Wrapper struct {
 	*adminapi.ConfigDump
+	// ecds is the JSON of the ECDS section, which the admin API of go-control-plane cannot decode yet.
+	ecds json.RawMessage
 }
 
 // MarshalJSON is a custom marshaller to handle protobuf pain
@@ -69,6 +72,23 @@ func (w *Wrapper) UnmarshalJSON(b []byte) error {
 		AllowUnknownFields: true,
 		AnyResolver:        &envoyResolver,
 	}).Unmarshal(bytes.NewReader(b), cd)
-	*w = Wrapper{cd}
-	return err
+	*w = Wrapper{ConfigDump: cd}
+	if err != nil {
+		return err
+	}
+	raw := struct {
+		Configs []json.RawMessage `json:"configs"`
+	}{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	for _, c := range raw.Configs {
+		section := struct {
+			Type string `json:"@type"`
+		}{}
+		if err := json.Unmarshal(c, &section); err == nil && section.Type == string(ecds) {
+			w.ecds = c
+		}
+	}
+	return nil
 }
@@ -0,0 +1,71 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package formatting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SchemaVersion is the version of the schema of the JSON documents printed by istioctl commands. Fields may be added
+// within a version, it only changes when a field is removed or changes meaning.
+const SchemaVersion = "v1"
+
+// PrintJSONDocument prints v as an indented JSON document of the kind, starting with the schema version and the kind.
+// The fields of v follow when it is an object, a list or a scalar is the items field of the document.
+func PrintJSONDocument(w io.Writer, kind string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	k, err := json.Marshal(kind)
+	if err != nil {
+		return err
+	}
+	var doc bytes.Buffer
+	fmt.Fprintf(&doc, `{"schemaVersion":%q,"kind":%s`, SchemaVersion, k)
+	if b[0] == '{' {
+		if b[1] != '}' {
+			doc.WriteByte(',')
+		}
+		doc.Write(b[1:])
+	} else {
+		doc.WriteString(`,"items":`)
+		doc.Write(b)
+		doc.WriteByte('}')
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, doc.Bytes(), "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+	_, err = out.WriteTo(w)
+	return err
+}
+
+// AddJSONDocumentFields adds the schema version and the kind to a JSON object printed by another writer, such as the
+// ones of the istio.io/pkg commands.
+func AddJSONDocumentFields(in []byte, kind string) ([]byte, error) {
+	if in = bytes.TrimSpace(in); len(in) == 0 || in[0] != '{' || !json.Valid(in) {
+		return nil, fmt.Errorf("expected a JSON object, got %q", in)
+	}
+	var buf bytes.Buffer
+	if err := PrintJSONDocument(&buf, kind, json.RawMessage(in)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,108 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package formatting
+
+import (
+	"bytes"
+	"testing"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestPrintJSONDocument(t *testing.T) {
+	cases := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{
+			name: "object",
+			v: struct {
+				Name  string `json:"name"`
+				Ports []int  `json:"ports"`
+			}{Name: "a", Ports: []int{80}},
+			want: `{
+  "schemaVersion": "v1",
+  "kind": "Test",
+  "name": "a",
+  "ports": [
+    80
+  ]
+}
+`,
+		},
+		{
+			name: "empty object",
+			v:    struct{}{},
+			want: `{
+  "schemaVersion": "v1",
+  "kind": "Test"
+}
+`,
+		},
+		{
+			name: "list",
+			v:    []string{"a", "b"},
+			want: `{
+  "schemaVersion": "v1",
+  "kind": "Test",
+  "items": [
+    "a",
+    "b"
+  ]
+}
+`,
+		},
+		{
+			name: "empty list",
+			v:    []string{},
+			want: `{
+  "schemaVersion": "v1",
+  "kind": "Test",
+  "items": []
+}
+`,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			assert.NoError(t, PrintJSONDocument(&out, "Test", tt.v))
+			assert.Equal(t, out.String(), tt.want)
+		})
+	}
+}
+
+func TestAddJSONDocumentFields(t *testing.T) {
+	got, err := AddJSONDocumentFields([]byte(`{
+  "clientVersion": {"version": "1.15.0"}
+}
+`), "Version")
+	assert.NoError(t, err)
+	assert.Equal(t, string(got), `{
+  "schemaVersion": "v1",
+  "kind": "Version",
+  "clientVersion": {
+    "version": "1.15.0"
+  }
+}
+`)
+
+	for _, in := range []string{"", "[]", "{"} {
+		if _, err := AddJSONDocumentFields([]byte(in), "Version"); err == nil {
+			t.Errorf("expected an error for %q", in)
+		}
+	}
+}
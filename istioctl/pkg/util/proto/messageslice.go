@@ -17,6 +17,7 @@ package proto
 import (
 	"bytes"
 
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 
 	"istio.io/istio/pkg/util/protomarshal"
@@ -42,3 +43,18 @@ func (pSlice MessageSlice) MarshalJSON() ([]byte, error) {
 	buffer.WriteString("]")
 	return buffer.Bytes(), nil
 }
+
+// MarshalDelimited marshals the messages as binary protobuf, each prefixed by its size as a varint, so that they can be
+// read back one by one without the loss of converting the Any fields to JSON.
+func (pSlice MessageSlice) MarshalDelimited() ([]byte, error) {
+	var out []byte
+	for _, msg := range pSlice {
+		b, err := proto.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+		out = protowire.AppendVarint(out, uint64(len(b)))
+		out = append(out, b...)
+	}
+	return out, nil
+}
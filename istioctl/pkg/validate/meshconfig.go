@@ -0,0 +1,221 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"sigs.k8s.io/yaml"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/validation"
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+// meshConfigMapKey is the key of the istio ConfigMap holding the mesh config.
+const meshConfigMapKey = "mesh"
+
+// ValidateMeshConfig validates a mesh config, or an istio ConfigMap holding one in its mesh key, including the proxy
+// config in its defaultConfig. Unknown fields, invalid values, invalid extension providers and default providers which
+// are not defined as extension providers are errors. The use of deprecated fields is returned as a warning.
+func ValidateMeshConfig(in []byte) (validation.Warning, error) {
+	meshYAML, err := extractMeshConfig(in)
+	if err != nil {
+		return nil, err
+	}
+
+	// istiod ignores unknown fields, which hides typos in field names.
+	raw := &meshconfig.MeshConfig{}
+	if err := protomarshal.ApplyYAMLStrict(meshYAML, raw); err != nil {
+		return nil, err
+	}
+	var warnings error
+	for _, path := range deprecatedFields(raw.ProtoReflect(), "") {
+		warnings = multierror.Append(warnings, fmt.Errorf("%s is deprecated", path))
+	}
+
+	mc, err := mesh.ApplyMeshConfigDefaults(meshYAML)
+	if err != nil {
+		return warnings, err
+	}
+	var errs error
+	// istiod only logs invalid extension providers, since they do no harm until they are used.
+	if err := validation.ValidateExtensionProviders(mc); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	if err := validateDefaultProviders(mc); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	if sampling := mc.GetDefaultConfig().GetTracing().GetSampling(); sampling < 0 || sampling > 100 {
+		errs = multierror.Append(errs, fmt.Errorf("defaultConfig.tracing.sampling %v must be in range [0.0, 100.0]", sampling))
+	}
+	return warnings, errs
+}
+
+func extractMeshConfig(in []byte) (string, error) {
+	var cm struct {
+		Kind string            `json:"kind"`
+		Data map[string]string `json:"data"`
+	}
+	if err := yaml.Unmarshal(in, &cm); err != nil {
+		return "", err
+	}
+	if cm.Kind != "ConfigMap" {
+		return string(in), nil
+	}
+	meshYAML, ok := cm.Data[meshConfigMapKey]
+	if !ok {
+		return "", fmt.Errorf("ConfigMap has no %q key", meshConfigMapKey)
+	}
+	return meshYAML, nil
+}
+
+// deprecatedFields returns the paths of the fields set in m which are marked deprecated.
+func deprecatedFields(m protoreflect.Message, prefix string) []string {
+	var out []string
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		path := prefix + fd.JSONName()
+		if opts, ok := fd.Options().(*descriptorpb.FieldOptions); ok && opts.GetDeprecated() {
+			out = append(out, path)
+		}
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			for i := 0; i < v.List().Len(); i++ {
+				out = append(out, deprecatedFields(v.List().Get(i).Message(), fmt.Sprintf("%s[%d].", path, i))...)
+			}
+		case fd.IsMap():
+		case fd.Message() != nil:
+			out = append(out, deprecatedFields(v.Message(), path+".")...)
+		}
+		return true
+	})
+	return out
+}
+
+// validateDefaultProviders checks the default providers are defined extension providers of the right kind.
+func validateDefaultProviders(mc *meshconfig.MeshConfig) error {
+	providers := map[string]*meshconfig.MeshConfig_ExtensionProvider{}
+	for _, p := range mc.ExtensionProviders {
+		providers[p.Name] = p
+	}
+	var errs error
+	check := func(usage string, names []string, valid func(*meshconfig.MeshConfig_ExtensionProvider) bool) {
+		for _, name := range names {
+			p, ok := providers[name]
+			if !ok {
+				errs = multierror.Append(errs, fmt.Errorf("defaultProviders.%s: extension provider %q is not defined", usage, name))
+			} else if !valid(p) {
+				errs = multierror.Append(errs, fmt.Errorf("defaultProviders.%s: extension provider %q is not a %s provider", usage, name, usage))
+			}
+		}
+	}
+	check("tracing", mc.GetDefaultProviders().GetTracing(), isTracingProvider)
+	check("metrics", mc.GetDefaultProviders().GetMetrics(), isMetricsProvider)
+	check("accessLogging", mc.GetDefaultProviders().GetAccessLogging(), isAccessLoggingProvider)
+	return errs
+}
+
+func isTracingProvider(p *meshconfig.MeshConfig_ExtensionProvider) bool {
+	switch p.Provider.(type) {
+	case *meshconfig.MeshConfig_ExtensionProvider_Zipkin, *meshconfig.MeshConfig_ExtensionProvider_Lightstep,
+		*meshconfig.MeshConfig_ExtensionProvider_Datadog, *meshconfig.MeshConfig_ExtensionProvider_Opencensus,
+		*meshconfig.MeshConfig_ExtensionProvider_Skywalking, *meshconfig.MeshConfig_ExtensionProvider_Stackdriver:
+		return true
+	}
+	return false
+}
+
+func isMetricsProvider(p *meshconfig.MeshConfig_ExtensionProvider) bool {
+	switch p.Provider.(type) {
+	case *meshconfig.MeshConfig_ExtensionProvider_Prometheus, *meshconfig.MeshConfig_ExtensionProvider_Stackdriver:
+		return true
+	}
+	return false
+}
+
+func isAccessLoggingProvider(p *meshconfig.MeshConfig_ExtensionProvider) bool {
+	switch p.Provider.(type) {
+	case *meshconfig.MeshConfig_ExtensionProvider_EnvoyFileAccessLog, *meshconfig.MeshConfig_ExtensionProvider_EnvoyOtelAls,
+		*meshconfig.MeshConfig_ExtensionProvider_EnvoyHttpAls, *meshconfig.MeshConfig_ExtensionProvider_EnvoyTcpAls,
+		*meshconfig.MeshConfig_ExtensionProvider_Stackdriver:
+		return true
+	}
+	return false
+}
+
+// NewMeshConfigCommand creates the command to validate mesh config files.
+func NewMeshConfigCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "meshconfig",
+		Short: "Inspect mesh config files",
+	}
+	c.AddCommand(newMeshConfigValidateCommand())
+	return c
+}
+
+func newMeshConfigValidateCommand() *cobra.Command {
+	var filename string
+	c := &cobra.Command{
+		Use:   "validate -f FILENAME",
+		Short: "Validate a mesh config file",
+		Long: `Validates a mesh config, including the proxy config in its defaultConfig, without a cluster.
+
+The file holds either the mesh config itself, or the istio ConfigMap with the mesh config in its mesh key. Unknown
+fields, invalid values, invalid extension providers and default providers which are not defined as extension
+providers are reported as errors. Deprecated fields are reported as warnings.`,
+		Example: `  # Validate a mesh config before applying it
+  istioctl x meshconfig validate -f meshconfig.yaml
+
+  # Validate the istio ConfigMap of a cluster
+  kubectl -n istio-system get configmap istio -o yaml | istioctl x meshconfig validate -f -`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			if filename == "" {
+				return errors.New("-f must be set")
+			}
+			var in []byte
+			var err error
+			if filename == "-" {
+				in, err = io.ReadAll(c.InOrStdin())
+			} else {
+				in, err = os.ReadFile(filename)
+			}
+			if err != nil {
+				return err
+			}
+			warning, err := ValidateMeshConfig(in)
+			if warning != nil {
+				_, _ = fmt.Fprintf(c.OutOrStderr(), "%q has warnings: %v\n", filename, warningToString(warning))
+			}
+			if err != nil {
+				return err
+			}
+			if warning == nil {
+				_, _ = fmt.Fprintf(c.OutOrStderr(), "%q is valid\n", filename)
+			}
+			return nil
+		},
+	}
+	c.PersistentFlags().StringVarP(&filename, "filename", "f", "", "Name of the mesh config file to validate, or - for stdin")
+	return c
+}
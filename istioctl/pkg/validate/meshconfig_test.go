@@ -0,0 +1,123 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateMeshConfig(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		warning string
+		err     string
+	}{
+		{
+			name: "valid",
+			in: `
+defaultProviders:
+  tracing: [zipkin]
+  accessLogging: [envoy]
+extensionProviders:
+- name: zipkin
+  zipkin:
+    service: zipkin.istio-system.svc.cluster.local
+    port: 9411
+defaultConfig:
+  tracing:
+    sampling: 10
+`,
+		},
+		{
+			name: "configmap",
+			in: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: istio
+  namespace: istio-system
+data:
+  mesh: |
+    defaultConfig:
+      proxyAdminPort: 70000
+`,
+			err: "invalid proxy admin port",
+		},
+		{
+			name: "configmap without mesh",
+			in: `
+apiVersion: v1
+kind: ConfigMap
+data:
+  meshNetworks: "networks: {}"
+`,
+			err: `ConfigMap has no "mesh" key`,
+		},
+		{
+			name: "unknown field",
+			in:   `enableTracng: true`,
+			err:  "enableTracng",
+		},
+		{
+			name:    "deprecated field",
+			in:      "defaultConfig:\n  zipkinAddress: zipkin:9411\n",
+			warning: "defaultConfig.zipkinAddress is deprecated",
+		},
+		{
+			name: "undefined default provider",
+			in:   "defaultProviders:\n  tracing: [jaeger]\n",
+			err:  `defaultProviders.tracing: extension provider "jaeger" is not defined`,
+		},
+		{
+			name: "default provider of the wrong kind",
+			in:   "defaultProviders:\n  metrics: [envoy]\n",
+			err:  `defaultProviders.metrics: extension provider "envoy" is not a metrics provider`,
+		},
+		{
+			name: "invalid extension provider",
+			in: `
+extensionProviders:
+- name: authz
+  envoyExtAuthzGrpc:
+    service: authz.foo.svc.cluster.local
+`,
+			err: "invalid extension provider authz",
+		},
+		{
+			name: "sampling out of range",
+			in:   "defaultConfig:\n  tracing:\n    sampling: 150\n",
+			err:  "defaultConfig.tracing.sampling 150 must be in range [0.0, 100.0]",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			warning, err := ValidateMeshConfig([]byte(tt.in))
+			if tt.err == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("expected error containing %q, got %v", tt.err, err)
+			}
+			if tt.warning == "" && warning != nil {
+				t.Fatalf("unexpected warning: %v", warning)
+			}
+			if tt.warning != "" && (warning == nil || !strings.Contains(warning.Error(), tt.warning)) {
+				t.Fatalf("expected warning containing %q, got %v", tt.warning, warning)
+			}
+		})
+	}
+}
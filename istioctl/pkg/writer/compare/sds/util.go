@@ -15,6 +15,9 @@
 package sdscompare
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
@@ -22,6 +25,7 @@ import (
 
 	envoy_admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
 	auth "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"golang.org/x/crypto/ocsp"
 
 	"istio.io/istio/istioctl/pkg/util/configdump"
 	"istio.io/pkg/log"
@@ -52,6 +56,23 @@ type SecretMeta struct {
 	NotAfter     string `json:"not_after"`
 	NotBefore    string `json:"not_before"`
 	Type         string `json:"type"`
+	// Chain holds the details of each certificate of the secret, starting with the leaf certificate.
+	Chain []CertificateDetails `json:"chain,omitempty"`
+	// OCSPStatus is the status of the OCSP response stapled to the certificate, if any.
+	OCSPStatus string `json:"ocsp_status,omitempty"`
+}
+
+// CertificateDetails holds the fields of a certificate of a chain shown in the detailed secret summary
+type CertificateDetails struct {
+	Subject      string   `json:"subject"`
+	Issuer       string   `json:"issuer"`
+	SerialNumber string   `json:"serial_number"`
+	SANs         []string `json:"sans,omitempty"`
+	KeyType      string   `json:"key_type"`
+	KeySize      int      `json:"key_size,omitempty"`
+	NotAfter     string   `json:"not_after"`
+	NotBefore    string   `json:"not_before"`
+	IsCA         bool     `json:"is_ca"`
 }
 
 // NewSecretItemBuilder returns a new builder to create a secret item
@@ -67,6 +88,7 @@ type SecretItemBuilder interface {
 	Source(string) SecretItemBuilder
 	Destination(string) SecretItemBuilder
 	State(string) SecretItemBuilder
+	OCSPStaple([]byte) SecretItemBuilder
 	Build() (SecretItem, error)
 }
 
@@ -77,6 +99,7 @@ type secretItemBuilder struct {
 	source string
 	dest   string
 	state  string
+	ocsp   []byte
 	SecretMeta
 }
 
@@ -110,6 +133,12 @@ func (s *secretItemBuilder) State(state string) SecretItemBuilder {
 	return s
 }
 
+// OCSPStaple sets the OCSP response stapled to the certificate of the secret
+func (s *secretItemBuilder) OCSPStaple(staple []byte) SecretItemBuilder {
+	s.ocsp = staple
+	return s
+}
+
 // Build takes the set fields from the builder and constructs the actual SecretItem
 // including generating the SecretMeta from the supplied cert data, if present
 func (s *secretItemBuilder) Build() (SecretItem, error) {
@@ -133,6 +162,9 @@ func (s *secretItemBuilder) Build() (SecretItem, error) {
 		}
 		result.SecretMeta = meta
 		result.Valid = true
+		if len(s.ocsp) > 0 {
+			result.OCSPStatus = ocspStatus(s.ocsp)
+		}
 		return result, nil
 	}
 	result.Valid = false
@@ -190,6 +222,8 @@ func parseDynamicSecret(s *envoy_admin.SecretsConfigDump_DynamicSecret, state st
 		GetTrustedCa().
 		GetInlineBytes()
 
+	builder.OCSPStaple(secretTyped.GetTlsCertificate().GetOcspStaple().GetInlineBytes())
+
 	// seems as though the most straightforward way to tell whether this is a root ca or not
 	// is to check whether the inline bytes of the cert chain or the trusted ca field is zero length
 	if len(certChainSecret) > 0 {
@@ -207,7 +241,7 @@ func parseDynamicSecret(s *envoy_admin.SecretsConfigDump_DynamicSecret, state st
 }
 
 func secretMetaFromCert(rawCert []byte) (SecretMeta, error) {
-	block, _ := pem.Decode(rawCert)
+	block, rest := pem.Decode(rawCert)
 	if block == nil {
 		return SecretMeta{}, fmt.Errorf("failed to parse certificate PEM")
 	}
@@ -222,10 +256,73 @@ func secretMetaFromCert(rawCert []byte) (SecretMeta, error) {
 		certType = "Cert Chain"
 	}
 
-	return SecretMeta{
+	meta := SecretMeta{
 		SerialNumber: fmt.Sprintf("%d", cert.SerialNumber),
 		NotAfter:     cert.NotAfter.Format(time.RFC3339),
 		NotBefore:    cert.NotBefore.Format(time.RFC3339),
 		Type:         certType,
-	}, nil
+		Chain:        []CertificateDetails{certificateDetails(cert)},
+	}
+	// The certificates following the first one are its intermediate certificates, or more roots for a CA bundle.
+	for {
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if c, err := x509.ParseCertificate(block.Bytes); err == nil {
+			meta.Chain = append(meta.Chain, certificateDetails(c))
+		}
+	}
+	return meta, nil
+}
+
+func certificateDetails(cert *x509.Certificate) CertificateDetails {
+	details := CertificateDetails{
+		Subject:      cert.Subject.String(),
+		Issuer:       cert.Issuer.String(),
+		SerialNumber: fmt.Sprintf("%d", cert.SerialNumber),
+		NotAfter:     cert.NotAfter.Format(time.RFC3339),
+		NotBefore:    cert.NotBefore.Format(time.RFC3339),
+		IsCA:         cert.IsCA,
+	}
+	for _, uri := range cert.URIs {
+		details.SANs = append(details.SANs, uri.String())
+	}
+	details.SANs = append(details.SANs, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		details.SANs = append(details.SANs, ip.String())
+	}
+	details.SANs = append(details.SANs, cert.EmailAddresses...)
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		details.KeyType, details.KeySize = "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		details.KeyType, details.KeySize = "ECDSA "+key.Curve.Params().Name, key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		details.KeyType, details.KeySize = "Ed25519", 256
+	default:
+		details.KeyType = cert.PublicKeyAlgorithm.String()
+	}
+	return details
+}
+
+// ocspStatus describes the OCSP response stapled to a certificate. The signature of the response is not verified.
+func ocspStatus(staple []byte) string {
+	resp, err := ocsp.ParseResponse(staple, nil)
+	if err != nil {
+		return fmt.Sprintf("invalid staple: %v", err)
+	}
+	var status string
+	switch resp.Status {
+	case ocsp.Good:
+		status = "Good"
+	case ocsp.Revoked:
+		status = "Revoked at " + resp.RevokedAt.Format(time.RFC3339)
+	default:
+		status = "Unknown"
+	}
+	if !resp.NextUpdate.IsZero() {
+		status += ", next update " + resp.NextUpdate.Format(time.RFC3339)
+	}
+	return status
 }
@@ -20,6 +20,7 @@ import (
 	"io"
 	"strings"
 	"text/tabwriter"
+	"time"
 )
 
 // SDSWriter takes lists of SecretItem or SecretItemDiff and prints them through supplied output writer
@@ -35,11 +36,27 @@ const (
 	TABULAR
 )
 
+// SDSWriterOptions controls the details shown in the tabular output of secret items
+type SDSWriterOptions struct {
+	// ShowChain prints the details of each certificate of the secrets after the table.
+	ShowChain bool
+	// ExpirationWarning, if positive, marks the certificates expiring within the duration.
+	ExpirationWarning time.Duration
+	// Now is the time expirations are computed from, the current time if zero.
+	Now time.Time
+}
+
 // NewSDSWriter generates a new instance which conforms to SDSWriter interface
 func NewSDSWriter(w io.Writer, format Format) SDSWriter {
+	return NewSDSWriterWithOptions(w, format, SDSWriterOptions{})
+}
+
+// NewSDSWriterWithOptions generates a new instance which conforms to SDSWriter interface, with the given options
+func NewSDSWriterWithOptions(w io.Writer, format Format, opts SDSWriterOptions) SDSWriter {
 	return &sdsWriter{
 		w:      w,
 		output: format,
+		opts:   opts,
 	}
 }
 
@@ -47,6 +64,7 @@ func NewSDSWriter(w io.Writer, format Format) SDSWriter {
 type sdsWriter struct {
 	w      io.Writer
 	output Format
+	opts   SDSWriterOptions
 }
 
 // PrintSecretItems uses the user supplied output format to determine how to display the diffed secrets
@@ -73,12 +91,95 @@ func (w *sdsWriter) printSecretItemsTabular(secrets []SecretItem) error {
 		return nil
 	}
 	tw := new(tabwriter.Writer).Init(w.w, 0, 5, 5, ' ', 0)
-	fmt.Fprintln(tw, strings.Join(secretItemColumns, "\t"))
+	columns := secretItemColumns
+	if w.opts.ExpirationWarning > 0 {
+		columns = append(append([]string{}, columns...), "EXPIRATION")
+	}
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
 	for _, s := range secrets {
-		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\t%s\t%s\n",
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\t%s\t%s",
 			s.Name, s.Type, s.State, s.Valid, s.SerialNumber, s.NotAfter, s.NotBefore)
+		if w.opts.ExpirationWarning > 0 {
+			fmt.Fprintf(tw, "\t%s", w.expiration(s.NotAfter))
+		}
+		fmt.Fprintln(tw)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if w.opts.ShowChain {
+		w.printSecretChains(secrets)
+	}
+	return nil
+}
+
+// expiration describes when a certificate expiring at notAfter expires, warning if it is within the threshold.
+func (w *sdsWriter) expiration(notAfter string) string {
+	expiry, err := time.Parse(time.RFC3339, notAfter)
+	if err != nil {
+		return "-"
+	}
+	now := w.opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	remaining := expiry.Sub(now)
+	switch {
+	case remaining <= 0:
+		return "EXPIRED"
+	case remaining <= w.opts.ExpirationWarning:
+		return fmt.Sprintf("WARNING: expires in %s", describeDays(remaining))
+	default:
+		return "OK"
+	}
+}
+
+func describeDays(d time.Duration) string {
+	days := int(d.Hours() / 24)
+	switch days {
+	case 0:
+		return "less than a day"
+	case 1:
+		return "1 day"
+	default:
+		return fmt.Sprintf("%d days", days)
+	}
+}
+
+// printSecretChains prints the details of each certificate of the secrets
+func (w *sdsWriter) printSecretChains(secrets []SecretItem) {
+	for _, s := range secrets {
+		if len(s.Chain) == 0 {
+			continue
+		}
+		fmt.Fprintf(w.w, "\nSecret %s:\n", s.Name)
+		for i, c := range s.Chain {
+			fmt.Fprintf(w.w, "  Certificate %d:\n", i)
+			fmt.Fprintf(w.w, "    Subject:        %s\n", c.Subject)
+			fmt.Fprintf(w.w, "    Issuer:         %s\n", c.Issuer)
+			fmt.Fprintf(w.w, "    Serial Number:  %s\n", c.SerialNumber)
+			if len(c.SANs) > 0 {
+				fmt.Fprintf(w.w, "    SANs:           %s\n", strings.Join(c.SANs, ", "))
+			}
+			if c.KeySize > 0 {
+				fmt.Fprintf(w.w, "    Key:            %s %d\n", c.KeyType, c.KeySize)
+			} else {
+				fmt.Fprintf(w.w, "    Key:            %s\n", c.KeyType)
+			}
+			fmt.Fprintf(w.w, "    CA:             %t\n", c.IsCA)
+			fmt.Fprintf(w.w, "    Not Before:     %s\n", c.NotBefore)
+			fmt.Fprintf(w.w, "    Not After:      %s", c.NotAfter)
+			if w.opts.ExpirationWarning > 0 {
+				fmt.Fprintf(w.w, " (%s)", w.expiration(c.NotAfter))
+			}
+			fmt.Fprintln(w.w)
+		}
+		ocspStatus := s.OCSPStatus
+		if ocspStatus == "" {
+			ocspStatus = "no staple"
+		}
+		fmt.Fprintf(w.w, "  OCSP Status:      %s\n", ocspStatus)
 	}
-	return tw.Flush()
 }
 
 // printSecretItemsJSON prints secret in JSON format, and dumps the raw certificate data with the output
@@ -16,8 +16,17 @@ package sdscompare
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestSDSWriterSecretItems(t *testing.T) {
@@ -172,6 +181,82 @@ func TestSDSWriterSecretDiff(t *testing.T) {
 	}
 }
 
+func TestSDSWriterSecretChain(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{"cluster.local"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spiffeID, _ := url.Parse("spiffe://cluster.local/ns/default/sa/default")
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(36 * time.Hour),
+		URIs:         []*url.URL{spiffeID},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, rootTemplate, &leafKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER})) +
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootDER}))
+
+	item, err := NewSecretItemBuilder().Name("default").State("ACTIVE").Data(chain).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(item.Chain) != 2 || item.Chain[0].SerialNumber != "2" || item.Chain[1].SerialNumber != "1" {
+		t.Fatalf("unexpected chain %+v", item.Chain)
+	}
+
+	w := &bytes.Buffer{}
+	err = NewSDSWriterWithOptions(w, TABULAR, SDSWriterOptions{
+		ShowChain:         true,
+		ExpirationWarning: 7 * 24 * time.Hour,
+		Now:               now,
+	}).PrintSecretItems([]SecretItem{item})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkOutput(t, w.String(), []string{
+		"EXPIRATION",
+		"WARNING: expires in 1 day",
+		"Secret default:",
+		"Certificate 0:",
+		"SANs:           spiffe://cluster.local/ns/default/sa/default",
+		"Issuer:         O=cluster.local",
+		"Key:            ECDSA P-256 256",
+		"Certificate 1:",
+		"CA:             true",
+		"(OK)",
+		"OCSP Status:      no staple",
+	}, nil)
+
+	// Without options, the table is unchanged.
+	w.Reset()
+	if err := NewSDSWriter(w, TABULAR).PrintSecretItems([]SecretItem{item}); err != nil {
+		t.Fatal(err)
+	}
+	checkOutput(t, w.String(), nil, []string{"EXPIRATION", "Certificate 0:"})
+}
+
 func checkOutput(t *testing.T, output string, expected, unexpected []string) {
 	t.Helper()
 	for _, expected := range expected {
@@ -65,6 +65,15 @@ func (c *ConfigWriter) Prime(b []byte) error {
 	return nil
 }
 
+// Validate checks that the primed clusters are the output of the Envoy admin clusters endpoint, for the outputs which
+// were not just fetched from a proxy, such as the ones read from files.
+func (c *ConfigWriter) Validate() error {
+	if c.clusters == nil {
+		return fmt.Errorf("clusters writer has not been primed")
+	}
+	return c.clusters.Validate()
+}
+
 func retrieveEndpointAddress(host *adminapi.HostStatus) string {
 	addr := host.Address.GetSocketAddress()
 	if addr != nil {
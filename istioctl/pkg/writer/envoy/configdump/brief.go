@@ -0,0 +1,148 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// briefSummary is the number of resources of a type in the config dump, with the version of the configuration of
+// the type the proxy last accepted.
+type briefSummary struct {
+	kind  string
+	count int
+	// version is the version of the type, when the config dump has one, or else the version of the resource last
+	// updated.
+	version     string
+	lastVersion string
+	lastUpdated time.Time
+}
+
+// addStatic counts a resource of the bootstrap configuration, which has no version.
+func (s *briefSummary) addStatic() {
+	s.count++
+}
+
+// addDynamic counts a resource received over xDS.
+func (s *briefSummary) addDynamic(version string, updated *timestamppb.Timestamp) {
+	s.count++
+	if updated == nil {
+		return
+	}
+	if t := updated.AsTime(); !t.Before(s.lastUpdated) {
+		s.lastUpdated = t
+		s.lastVersion = version
+	}
+}
+
+// acceptedVersion returns the version of the configuration of the type the proxy last accepted.
+func (s *briefSummary) acceptedVersion() string {
+	if s.version != "" {
+		return s.version
+	}
+	return s.lastVersion
+}
+
+func (s *briefSummary) print(w *tabwriter.Writer) {
+	version := s.acceptedVersion()
+	updated := ""
+	if !s.lastUpdated.IsZero() {
+		updated = s.lastUpdated.UTC().Format(time.RFC3339)
+	}
+	fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", s.kind, s.count, valueOrNA(version), valueOrNA(updated))
+}
+
+// PrintBriefSummary prints the number of resources of each type in the config dump, with the version and the time of
+// the last update of the configuration of the type the proxy accepted. It is meant to compare proxies across a
+// fleet, to check they converged to the same configuration, without looking at individual resources. Endpoints are
+// only included if the config dump has them.
+func (c *ConfigWriter) PrintBriefSummary() error {
+	if c.configDump == nil {
+		return fmt.Errorf("config writer has not been primed")
+	}
+
+	clusterDump, err := c.configDump.GetClusterConfigDump()
+	if err != nil {
+		return err
+	}
+	clusters := briefSummary{kind: "Clusters", version: clusterDump.VersionInfo}
+	for range clusterDump.StaticClusters {
+		clusters.addStatic()
+	}
+	for _, dc := range clusterDump.DynamicActiveClusters {
+		clusters.addDynamic(dc.VersionInfo, dc.LastUpdated)
+	}
+
+	listenerDump, err := c.configDump.GetListenerConfigDump()
+	if err != nil {
+		return err
+	}
+	listeners := briefSummary{kind: "Listeners", version: listenerDump.VersionInfo}
+	for range listenerDump.StaticListeners {
+		listeners.addStatic()
+	}
+	for _, dl := range listenerDump.DynamicListeners {
+		if dl.ActiveState != nil {
+			listeners.addDynamic(dl.ActiveState.VersionInfo, dl.ActiveState.LastUpdated)
+		}
+	}
+
+	routeDump, err := c.configDump.GetRouteConfigDump()
+	if err != nil {
+		return err
+	}
+	routes := briefSummary{kind: "Routes"}
+	for range routeDump.StaticRouteConfigs {
+		routes.addStatic()
+	}
+	for _, dr := range routeDump.DynamicRouteConfigs {
+		routes.addDynamic(dr.VersionInfo, dr.LastUpdated)
+	}
+
+	secretDump, err := c.configDump.GetSecretConfigDump()
+	if err != nil {
+		return err
+	}
+	secrets := briefSummary{kind: "Secrets"}
+	for range secretDump.StaticSecrets {
+		secrets.addStatic()
+	}
+	for _, ds := range secretDump.DynamicActiveSecrets {
+		secrets.addDynamic(ds.VersionInfo, ds.LastUpdated)
+	}
+
+	summaries := []briefSummary{clusters, listeners, routes, secrets}
+	if endpointDump, err := c.configDump.GetEndpointsConfigDump(); err == nil {
+		endpoints := briefSummary{kind: "Endpoints"}
+		for range endpointDump.StaticEndpointConfigs {
+			endpoints.addStatic()
+		}
+		for _, de := range endpointDump.DynamicEndpointConfigs {
+			endpoints.addDynamic(de.VersionInfo, de.LastUpdated)
+		}
+		summaries = append(summaries, endpoints)
+	}
+
+	w := new(tabwriter.Writer).Init(c.Stdout, 0, 8, 5, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tCOUNT\tVERSION\tLAST UPDATED")
+	for _, s := range summaries {
+		s.print(w)
+	}
+	return w.Flush()
+}
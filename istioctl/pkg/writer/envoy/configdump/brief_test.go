@@ -0,0 +1,98 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+func TestConfigWriter_PrintBriefSummary(t *testing.T) {
+	anyOf := func(m proto.Message) *anypb.Any {
+		a, err := anypb.New(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	boot := timestamppb.New(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	first := timestamppb.New(time.Date(2023, 1, 1, 0, 1, 0, 0, time.UTC))
+	last := timestamppb.New(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	clusters := &admin.ClustersConfigDump{
+		VersionInfo:    "2023-01-02T00:00:00Z/7",
+		StaticClusters: []*admin.ClustersConfigDump_StaticCluster{{Cluster: anyOf(&cluster.Cluster{Name: "agent"}), LastUpdated: boot}},
+		DynamicActiveClusters: []*admin.ClustersConfigDump_DynamicCluster{
+			{VersionInfo: "2023-01-01T00:01:00Z/1", Cluster: anyOf(&cluster.Cluster{Name: "a"}), LastUpdated: first},
+			{VersionInfo: "2023-01-02T00:00:00Z/7", Cluster: anyOf(&cluster.Cluster{Name: "b"}), LastUpdated: last},
+		},
+	}
+	listeners := &admin.ListenersConfigDump{
+		VersionInfo: "2023-01-01T00:01:00Z/1",
+		DynamicListeners: []*admin.ListenersConfigDump_DynamicListener{
+			{Name: "virtualOutbound", ActiveState: &admin.ListenersConfigDump_DynamicListenerState{
+				VersionInfo: "2023-01-01T00:01:00Z/1", Listener: anyOf(&listener.Listener{Name: "virtualOutbound"}), LastUpdated: first,
+			}},
+			// Warming listeners are not counted.
+			{Name: "0.0.0.0_80", WarmingState: &admin.ListenersConfigDump_DynamicListenerState{
+				VersionInfo: "2023-01-02T00:00:00Z/7", Listener: anyOf(&listener.Listener{Name: "0.0.0.0_80"}), LastUpdated: last,
+			}},
+		},
+	}
+	// Routes have no version of the type, the version of the route last updated is shown.
+	routes := &admin.RoutesConfigDump{
+		DynamicRouteConfigs: []*admin.RoutesConfigDump_DynamicRouteConfig{
+			{VersionInfo: "2023-01-02T00:00:00Z/7", RouteConfig: anyOf(&route.RouteConfiguration{Name: "80"}), LastUpdated: last},
+			{VersionInfo: "2023-01-01T00:01:00Z/1", RouteConfig: anyOf(&route.RouteConfiguration{Name: "8080"}), LastUpdated: first},
+		},
+	}
+	secrets := &admin.SecretsConfigDump{
+		DynamicActiveSecrets: []*admin.SecretsConfigDump_DynamicSecret{{
+			Name:        "default",
+			VersionInfo: "2023-01-01 00:00:30 +0000 UTC",
+			Secret:      anyOf(&tls.Secret{Name: "default", Type: &tls.Secret_TlsCertificate{TlsCertificate: &tls.TlsCertificate{}}}),
+			LastUpdated: timestamppb.New(time.Date(2023, 1, 1, 0, 0, 30, 0, time.UTC)),
+		}},
+	}
+
+	b, err := protomarshal.Marshal(&admin.ConfigDump{
+		Configs: []*anypb.Any{anyOf(clusters), anyOf(listeners), anyOf(routes), anyOf(secrets)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotOut := &bytes.Buffer{}
+	cw := &ConfigWriter{Stdout: gotOut}
+	assert.Error(t, cw.PrintBriefSummary())
+	if err := cw.Prime(b); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, cw.PrintBriefSummary())
+	util.CompareContent(t, gotOut.Bytes(), "testdata/briefsummary.txt")
+}
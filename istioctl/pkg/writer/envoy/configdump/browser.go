@@ -0,0 +1,423 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	"google.golang.org/protobuf/proto"
+
+	"istio.io/istio/pkg/util/protomarshal"
+	"istio.io/istio/pkg/util/sets"
+)
+
+const (
+	browseClusters  = "cluster"
+	browseListeners = "listener"
+	browseRoutes    = "route"
+	browseEndpoints = "endpoints"
+)
+
+// BrowserHelp describes the commands of the Browser.
+const BrowserHelp = `Commands:
+  clusters, c [filter]    list the clusters, only those whose name contains filter if set
+  listeners, l [filter]   list the listeners
+  routes, r [filter]      list the routes
+  endpoints, e [filter]   list the endpoints of the clusters
+  <number>                open the resource with this number in the current view
+  /<filter>               filter the current list
+  back, b                 return to the previous view
+  refresh                 fetch the configuration of the proxy again
+  help, h, ?              show this help
+  quit, q                 exit
+`
+
+// Browser navigates the clusters, listeners, routes and endpoints of a config dump: it lists the resources of a
+// type, filtered by name, shows a resource with the resources it references and the ones referencing it, and
+// follows these references, keeping the history of the views to go back to.
+type Browser struct {
+	c *ConfigWriter
+	// reload fetches the config dump again, if the dump can be refreshed.
+	reload  func() ([]byte, error)
+	current *browserView
+	history []*browserView
+}
+
+// browserView is a list of resources of a type, or a resource with its references.
+type browserView struct {
+	kind string
+	// name is the resource shown, empty for a list.
+	name   string
+	filter string
+	// items are the resources the view numbers, which can be opened.
+	items []browserItem
+}
+
+type browserItem struct {
+	kind string
+	name string
+}
+
+// NewBrowser returns a Browser of the primed ConfigWriter. The refresh command calls reload, if set, and primes the
+// ConfigWriter with the returned config dump.
+func NewBrowser(c *ConfigWriter, reload func() ([]byte, error)) *Browser {
+	return &Browser{c: c, reload: reload}
+}
+
+// Handle runs a command of BrowserHelp, printing its output to the Stdout of the ConfigWriter. It returns false when
+// the user quits.
+func (b *Browser) Handle(line string) bool {
+	line = strings.TrimSpace(line)
+	command, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	var err error
+	switch command {
+	case "":
+		return true
+	case "quit", "q", "exit":
+		return false
+	case "help", "h", "?":
+		fmt.Fprint(b.c.Stdout, BrowserHelp)
+	case "clusters", "c":
+		err = b.open(&browserView{kind: browseClusters, filter: arg})
+	case "listeners", "l":
+		err = b.open(&browserView{kind: browseListeners, filter: arg})
+	case "routes", "r":
+		err = b.open(&browserView{kind: browseRoutes, filter: arg})
+	case "endpoints", "e":
+		err = b.open(&browserView{kind: browseEndpoints, filter: arg})
+	case "back", "b":
+		if len(b.history) == 0 {
+			fmt.Fprintln(b.c.Stdout, "No previous view.")
+			return true
+		}
+		b.current = b.history[len(b.history)-1]
+		b.history = b.history[:len(b.history)-1]
+		err = b.render(b.current)
+	case "refresh":
+		err = b.refresh()
+	default:
+		switch {
+		case strings.HasPrefix(line, "/"):
+			if b.current == nil || b.current.name != "" {
+				fmt.Fprintln(b.c.Stdout, "Filters apply to lists; list clusters, listeners, routes or endpoints first.")
+				return true
+			}
+			err = b.open(&browserView{kind: b.current.kind, filter: strings.TrimSpace(line[1:])})
+		default:
+			n, convErr := strconv.Atoi(line)
+			if convErr != nil {
+				fmt.Fprintf(b.c.Stdout, "Unknown command %q, type help for the list of commands.\n", line)
+				return true
+			}
+			if b.current == nil || n < 1 || n > len(b.current.items) {
+				fmt.Fprintf(b.c.Stdout, "No resource %d in the current view.\n", n)
+				return true
+			}
+			item := b.current.items[n-1]
+			err = b.open(&browserView{kind: item.kind, name: item.name})
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(b.c.Stdout, "Error: %v\n", err)
+	}
+	return true
+}
+
+// open renders the view and makes it the current one.
+func (b *Browser) open(v *browserView) error {
+	if err := b.render(v); err != nil {
+		return err
+	}
+	if b.current != nil {
+		b.history = append(b.history, b.current)
+	}
+	b.current = v
+	return nil
+}
+
+func (b *Browser) refresh() error {
+	if b.reload == nil {
+		return fmt.Errorf("the configuration was read from a file and cannot be refreshed")
+	}
+	dump, err := b.reload()
+	if err != nil {
+		return err
+	}
+	if err := b.c.Prime(dump); err != nil {
+		return err
+	}
+	fmt.Fprintln(b.c.Stdout, "Configuration refreshed.")
+	if b.current == nil {
+		return nil
+	}
+	return b.render(b.current)
+}
+
+func (b *Browser) render(v *browserView) error {
+	if v.name != "" {
+		return b.renderResource(v)
+	}
+	return b.renderList(v)
+}
+
+func (b *Browser) renderList(v *browserView) error {
+	names, describe, err := b.resources(v.kind)
+	if err != nil {
+		return err
+	}
+	v.items = nil
+	w := new(tabwriter.Writer).Init(b.c.Stdout, 0, 8, 3, ' ', 0)
+	for _, name := range names {
+		if v.filter != "" && !strings.Contains(name, v.filter) {
+			continue
+		}
+		v.items = append(v.items, browserItem{kind: v.kind, name: name})
+		fmt.Fprintf(w, "%d\t%s\t%s\n", len(v.items), name, describe(name))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	switch {
+	case len(v.items) == 0 && v.filter != "":
+		fmt.Fprintf(b.c.Stdout, "No %s matches %q.\n", v.kind, v.filter)
+	case len(v.items) == 0:
+		fmt.Fprintf(b.c.Stdout, "No %s found.\n", v.kind)
+	}
+	return nil
+}
+
+// resources returns the sorted names of the resources of the kind, and a function describing a resource in a list.
+func (b *Browser) resources(kind string) ([]string, func(string) string, error) {
+	var names []string
+	switch kind {
+	case browseClusters:
+		clusters, err := b.c.retrieveSortedClusterSlice()
+		if err != nil {
+			return nil, nil, err
+		}
+		types := map[string]string{}
+		for _, cl := range clusters {
+			names = append(names, cl.Name)
+			types[cl.Name] = describeBrowsedCluster(cl)
+		}
+		return names, func(name string) string { return types[name] }, nil
+	case browseListeners:
+		listeners, err := b.c.retrieveSortedListenerSlice()
+		if err != nil {
+			return nil, nil, err
+		}
+		addresses := map[string]string{}
+		for _, l := range listeners {
+			names = append(names, l.Name)
+			addresses[l.Name] = fmt.Sprintf("%s:%d", retrieveListenerAddress(l), retrieveListenerPort(l))
+		}
+		return names, func(name string) string { return addresses[name] }, nil
+	case browseRoutes:
+		routes, err := b.c.retrieveSortedRouteSlice()
+		if err != nil {
+			return nil, nil, err
+		}
+		hosts := map[string]string{}
+		for _, r := range routes {
+			names = append(names, r.Name)
+			hosts[r.Name] = fmt.Sprintf("%d virtual hosts", len(r.VirtualHosts))
+		}
+		return names, func(name string) string { return hosts[name] }, nil
+	case browseEndpoints:
+		endpoints, err := b.c.retrieveSortedEndpointsSlice(EndpointFilter{})
+		if err != nil {
+			return nil, nil, err
+		}
+		counts := map[string]string{}
+		for _, cla := range endpoints {
+			names = append(names, cla.ClusterName)
+			counts[cla.ClusterName] = describeBrowsedEndpoints(cla)
+		}
+		return names, func(name string) string { return counts[name] }, nil
+	}
+	return nil, nil, fmt.Errorf("unknown resource type %q", kind)
+}
+
+func describeBrowsedCluster(cl *cluster.Cluster) string {
+	if cl.GetClusterType() != nil {
+		return cl.GetClusterType().Name
+	}
+	return cl.GetType().String()
+}
+
+func describeBrowsedEndpoints(cla *endpoint.ClusterLoadAssignment) string {
+	total, healthy := 0, 0
+	for _, llb := range cla.Endpoints {
+		for _, ep := range llb.LbEndpoints {
+			total++
+			if retrieveEndpointStatus(ep) == core.HealthStatus_HEALTHY {
+				healthy++
+			}
+		}
+	}
+	return fmt.Sprintf("%d/%d healthy", healthy, total)
+}
+
+// renderResource prints the resource followed by the numbered resources it references and the ones referencing it.
+func (b *Browser) renderResource(v *browserView) error {
+	resource, err := b.resource(v.kind, v.name)
+	if err != nil {
+		return err
+	}
+	out, err := protomarshal.ToJSONWithIndent(resource, "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(b.c.Stdout, out)
+
+	references, referencedBy, err := b.references(v.kind, v.name, resource)
+	if err != nil {
+		return err
+	}
+	v.items = nil
+	for _, section := range []struct {
+		title string
+		items []browserItem
+	}{{"References:", references}, {"Referenced by:", referencedBy}} {
+		if len(section.items) == 0 {
+			continue
+		}
+		fmt.Fprintln(b.c.Stdout, section.title)
+		for _, item := range section.items {
+			v.items = append(v.items, item)
+			fmt.Fprintf(b.c.Stdout, "  %d  %s %s\n", len(v.items), item.kind, item.name)
+		}
+	}
+	return nil
+}
+
+func (b *Browser) resource(kind, name string) (proto.Message, error) {
+	var resources map[string]proto.Message
+	var err error
+	switch kind {
+	case browseClusters:
+		resources, err = b.c.namedClusters()
+	case browseListeners:
+		resources, err = b.c.namedListeners()
+	case browseRoutes:
+		resources, err = b.c.namedRoutes()
+	case browseEndpoints:
+		var endpoints []*endpoint.ClusterLoadAssignment
+		endpoints, err = b.c.retrieveSortedEndpointsSlice(EndpointFilter{})
+		resources = map[string]proto.Message{}
+		for _, cla := range endpoints {
+			resources[cla.ClusterName] = cla
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	r, f := resources[name]
+	if !f {
+		return nil, fmt.Errorf("%s %q not found", kind, name)
+	}
+	return r, nil
+}
+
+// references returns the resources referenced by the resource, and the ones referencing it: listeners reference the
+// routes of their HTTP filter chains and the clusters of their TCP filter chains, routes reference clusters, and
+// clusters reference their endpoints and the clusters they aggregate.
+func (b *Browser) references(kind, name string, resource proto.Message) ([]browserItem, []browserItem, error) {
+	var references, referencedBy []browserItem
+	clusterRefs := sets.New()
+	switch kind {
+	case browseListeners:
+		routeRefs := sets.New()
+		for _, fc := range getFilterChains(resource.(*listener.Listener)) {
+			if _, rds := httpRouteConfig(fc, nil); rds != "" && rds != "(inline)" {
+				routeRefs.Insert(rds)
+			}
+		}
+		for _, r := range routeRefs.SortedList() {
+			references = append(references, browserItem{kind: browseRoutes, name: r})
+		}
+		collectClusterReferences(resource.ProtoReflect(), clusterRefs)
+	case browseRoutes:
+		collectClusterReferences(resource.ProtoReflect(), clusterRefs)
+		listeners, err := b.c.retrieveSortedListenerSlice()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, l := range listeners {
+			for _, fc := range getFilterChains(l) {
+				if _, rds := httpRouteConfig(fc, nil); rds == name {
+					referencedBy = append(referencedBy, browserItem{kind: browseListeners, name: l.Name})
+					break
+				}
+			}
+		}
+	case browseClusters:
+		collectClusterReferences(resource.ProtoReflect(), clusterRefs)
+		// Only EDS clusters have endpoints in the endpoints dump.
+		if _, err := b.resource(browseEndpoints, name); err == nil {
+			references = append(references, browserItem{kind: browseEndpoints, name: name})
+		}
+		referencedBy = append(referencedBy, b.referencingCluster(name)...)
+	case browseEndpoints:
+		references = append(references, browserItem{kind: browseClusters, name: name})
+	}
+	clusterRefs.Delete(name)
+	for _, cl := range clusterRefs.SortedList() {
+		references = append(references, browserItem{kind: browseClusters, name: cl})
+	}
+	return references, referencedBy, nil
+}
+
+// referencingCluster returns the listeners, routes and clusters referencing the cluster.
+func (b *Browser) referencingCluster(name string) []browserItem {
+	var out []browserItem
+	references := func(m proto.Message) bool {
+		refs := sets.New()
+		collectClusterReferences(m.ProtoReflect(), refs)
+		return refs.Contains(name)
+	}
+	if listeners, err := b.c.retrieveSortedListenerSlice(); err == nil {
+		for _, l := range listeners {
+			if references(l) {
+				out = append(out, browserItem{kind: browseListeners, name: l.Name})
+			}
+		}
+	}
+	if routes, err := b.c.retrieveSortedRouteSlice(); err == nil {
+		for _, r := range routes {
+			if references(r) {
+				out = append(out, browserItem{kind: browseRoutes, name: r.Name})
+			}
+		}
+	}
+	if clusters, err := b.c.retrieveSortedClusterSlice(); err == nil {
+		for _, cl := range clusters {
+			if cl.Name != name && references(cl) {
+				out = append(out, browserItem{kind: browseClusters, name: cl.Name})
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].kind < out[j].kind })
+	return out
+}
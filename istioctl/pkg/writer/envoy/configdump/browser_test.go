@@ -0,0 +1,186 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	httpConn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+func TestBrowser(t *testing.T) {
+	anyOf := func(m proto.Message) *anypb.Any {
+		a, err := anypb.New(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	const reviews = "outbound|9080||reviews.default.svc.cluster.local"
+	l := &listener.Listener{
+		Name: "0.0.0.0_9080",
+		Address: &core.Address{Address: &core.Address_SocketAddress{SocketAddress: &core.SocketAddress{
+			Address:       "0.0.0.0",
+			PortSpecifier: &core.SocketAddress_PortValue{PortValue: 9080},
+		}}},
+		FilterChains: []*listener.FilterChain{{
+			Filters: []*listener.Filter{{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &listener.Filter_TypedConfig{TypedConfig: anyOf(&httpConn.HttpConnectionManager{
+					RouteSpecifier: &httpConn.HttpConnectionManager_Rds{Rds: &httpConn.Rds{RouteConfigName: "9080"}},
+				})},
+			}},
+		}},
+	}
+	r := &route.RouteConfiguration{
+		Name: "9080",
+		VirtualHosts: []*route.VirtualHost{{
+			Name:    "reviews.default.svc.cluster.local:9080",
+			Domains: []string{"reviews.default.svc.cluster.local"},
+			Routes: []*route.Route{{
+				Match:  &route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"}},
+				Action: &route.Route_Route{Route: &route.RouteAction{ClusterSpecifier: &route.RouteAction_Cluster{Cluster: reviews}}},
+			}},
+		}},
+	}
+	clusters := []*cluster.Cluster{
+		{
+			Name:                 reviews,
+			ClusterDiscoveryType: &cluster.Cluster_Type{Type: cluster.Cluster_EDS},
+			EdsClusterConfig:     &cluster.Cluster_EdsClusterConfig{ServiceName: reviews},
+		},
+		{Name: "PassthroughCluster", ClusterDiscoveryType: &cluster.Cluster_Type{Type: cluster.Cluster_ORIGINAL_DST}},
+	}
+	cla := &endpoint.ClusterLoadAssignment{
+		ClusterName: reviews,
+		Endpoints: []*endpoint.LocalityLbEndpoints{{LbEndpoints: []*endpoint.LbEndpoint{{
+			HostIdentifier: &endpoint.LbEndpoint_Endpoint{Endpoint: &endpoint.Endpoint{Address: &core.Address{
+				Address: &core.Address_SocketAddress{SocketAddress: &core.SocketAddress{
+					Address:       "10.0.1.1",
+					PortSpecifier: &core.SocketAddress_PortValue{PortValue: 9080},
+				}},
+			}}},
+			HealthStatus: core.HealthStatus_HEALTHY,
+		}}}},
+	}
+	clusterDump := &admin.ClustersConfigDump{}
+	for _, c := range clusters {
+		clusterDump.DynamicActiveClusters = append(clusterDump.DynamicActiveClusters, &admin.ClustersConfigDump_DynamicCluster{Cluster: anyOf(c)})
+	}
+	dump, err := protomarshal.Marshal(&admin.ConfigDump{Configs: []*anypb.Any{
+		anyOf(clusterDump),
+		anyOf(&admin.ListenersConfigDump{DynamicListeners: []*admin.ListenersConfigDump_DynamicListener{{
+			Name:        l.Name,
+			ActiveState: &admin.ListenersConfigDump_DynamicListenerState{Listener: anyOf(l)},
+		}}}),
+		anyOf(&admin.RoutesConfigDump{DynamicRouteConfigs: []*admin.RoutesConfigDump_DynamicRouteConfig{{RouteConfig: anyOf(r)}}}),
+		anyOf(&admin.EndpointsConfigDump{DynamicEndpointConfigs: []*admin.EndpointsConfigDump_DynamicEndpointConfig{{EndpointConfig: anyOf(cla)}}}),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each step runs a command, and checks the output contains, or does not contain, the strings.
+	type step struct {
+		command string
+		want    []string
+		notWant []string
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "list and filter",
+			steps: []step{
+				{command: "clusters", want: []string{"1   PassthroughCluster ", "2   " + reviews + "   EDS"}},
+				{command: "/reviews", want: []string{"1   " + reviews}, notWant: []string{"PassthroughCluster"}},
+				{command: "endpoints", want: []string{reviews + "   1/1 healthy"}},
+				{command: "l nothing", want: []string{`No listener matches "nothing".`}},
+			},
+		},
+		{
+			name: "follow references",
+			steps: []step{
+				{command: "listeners", want: []string{"1   0.0.0.0_9080   0.0.0.0:9080"}},
+				{command: "1", want: []string{`"name": "0.0.0.0_9080"`, "References:\n  1  route 9080\n"}},
+				{command: "1", want: []string{
+					`"name": "9080"`,
+					"References:\n  1  cluster " + reviews + "\n",
+					"Referenced by:\n  2  listener 0.0.0.0_9080\n",
+				}},
+				{command: "1", want: []string{
+					"References:\n  1  endpoints " + reviews + "\n",
+					"Referenced by:\n  2  route 9080\n",
+				}},
+				{command: "1", want: []string{`"address": "10.0.1.1"`, "References:\n  1  cluster " + reviews + "\n"}},
+				{command: "back", want: []string{"Referenced by:\n  2  route 9080\n"}},
+				{command: "back", want: []string{`"name": "9080"`}},
+			},
+		},
+		{
+			name: "errors",
+			steps: []step{
+				{command: "back", want: []string{"No previous view."}},
+				{command: "1", want: []string{"No resource 1 in the current view."}},
+				{command: "/x", want: []string{"Filters apply to lists"}},
+				{command: "foo", want: []string{`Unknown command "foo"`}},
+				{command: "refresh", want: []string{"Error: the configuration was read from a file and cannot be refreshed"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			cw := &ConfigWriter{Stdout: out}
+			if err := cw.Prime(dump); err != nil {
+				t.Fatal(err)
+			}
+			b := NewBrowser(cw, nil)
+			for _, s := range tt.steps {
+				out.Reset()
+				if !b.Handle(s.command) {
+					t.Fatalf("%q quit the browser", s.command)
+				}
+				for _, want := range s.want {
+					if !strings.Contains(out.String(), want) {
+						t.Errorf("%q: output does not contain %q:\n%s", s.command, want, out.String())
+					}
+				}
+				for _, notWant := range s.notWant {
+					if strings.Contains(out.String(), notWant) {
+						t.Errorf("%q: output contains %q:\n%s", s.command, notWant, out.String())
+					}
+				}
+			}
+			if b.Handle("quit") {
+				t.Fatal("quit did not quit the browser")
+			}
+		})
+	}
+}
@@ -17,33 +17,49 @@ package configdump
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
 	"sigs.k8s.io/yaml"
 
 	protio "istio.io/istio/istioctl/pkg/util/proto"
 	"istio.io/istio/pilot/pkg/model"
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/util/sets"
 )
 
 // ClusterFilter is used to pass filter information into cluster based config writer print functions
 type ClusterFilter struct {
+	// Name selects the clusters by name, with a pattern as described in ValidateNamePattern.
+	Name      string
 	FQDN      host.Name
 	Port      int
 	Subset    string
 	Direction model.TrafficDirection
+	// Unused selects only the clusters not referenced by any route, listener filter chain or other cluster.
+	Unused bool
+	// UpdatedSince selects only the clusters last updated at or after this time, if set.
+	UpdatedSince time.Time
+
+	nameRE *regexp.Regexp
 }
 
 // Verify returns true if the passed cluster matches the filter fields
 func (c *ClusterFilter) Verify(cluster *cluster.Cluster) bool {
 	name := cluster.Name
-	if c.FQDN == "" && c.Port == 0 && c.Subset == "" && c.Direction == "" {
+	if c.Name == "" && c.FQDN == "" && c.Port == 0 && c.Subset == "" && c.Direction == "" {
 		return true
 	}
+	if !matchName(c.Name, &c.nameRE, name) {
+		return false
+	}
 	if c.FQDN != "" && !strings.Contains(name, string(c.FQDN)) {
 		return false
 	}
@@ -68,19 +84,27 @@ func (c *ConfigWriter) PrintClusterSummary(filter ClusterFilter) error {
 	if err != nil {
 		return err
 	}
-	_, _ = fmt.Fprintln(w, "SERVICE FQDN\tPORT\tSUBSET\tDIRECTION\tTYPE\tDESTINATION RULE")
+	unused, err := c.unusedClusterFilter(filter)
+	if err != nil {
+		return err
+	}
+	updated, err := updatedSinceFilter(filter.UpdatedSince, c.clusterLastUpdated)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(w, "SERVICE FQDN\tPORT\tSUBSET\tDIRECTION\tTYPE\tDESTINATION RULE\tCONFIG SOURCE")
 	for _, c := range clusters {
-		if filter.Verify(c) {
+		if filter.Verify(c) && unused(c) && updated(c.Name) {
 			if len(strings.Split(c.Name, "|")) > 3 {
 				direction, subset, fqdn, port := model.ParseSubsetKey(c.Name)
 				if subset == "" {
 					subset = "-"
 				}
-				_, _ = fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%s\t%s\n", fqdn, port, subset, direction, c.GetType(),
-					describeManagement(c.GetMetadata()))
+				_, _ = fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%s\t%s\t%s\n", fqdn, port, subset, direction, c.GetType(),
+					describeManagement(c.GetMetadata()), describeConfigSource(c.GetMetadata()))
 			} else {
-				_, _ = fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%s\t%s\n", c.Name, "-", "-", "-", c.GetType(),
-					describeManagement(c.GetMetadata()))
+				_, _ = fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%s\t%s\t%s\n", c.Name, "-", "-", "-", c.GetType(),
+					describeManagement(c.GetMetadata()), describeConfigSource(c.GetMetadata()))
 			}
 		}
 	}
@@ -93,12 +117,26 @@ func (c *ConfigWriter) PrintClusterDump(filter ClusterFilter, outputFormat strin
 	if err != nil {
 		return err
 	}
+	unused, err := c.unusedClusterFilter(filter)
+	if err != nil {
+		return err
+	}
+	updated, err := updatedSinceFilter(filter.UpdatedSince, c.clusterLastUpdated)
+	if err != nil {
+		return err
+	}
 	filteredClusters := make(protio.MessageSlice, 0, len(clusters))
 	for _, cluster := range clusters {
-		if filter.Verify(cluster) {
+		if filter.Verify(cluster) && unused(cluster) && updated(cluster.Name) {
 			filteredClusters = append(filteredClusters, cluster)
 		}
 	}
+	if outputFormat == "proto" {
+		return c.printProto(filteredClusters)
+	}
+	if strings.HasPrefix(outputFormat, customColumnsPrefix) {
+		return c.printCustomColumns(filteredClusters, outputFormat)
+	}
 	out, err := json.MarshalIndent(filteredClusters, "", "    ")
 	if err != nil {
 		return err
@@ -174,6 +212,28 @@ func (c *ConfigWriter) retrieveSortedClusterSlice() ([]*cluster.Cluster, error)
 	return clusters, nil
 }
 
+// clusterLastUpdated returns the last update times of the clusters in the config dump by name.
+func (c *ConfigWriter) clusterLastUpdated() (map[string]time.Time, error) {
+	clusterDump, err := c.configDump.GetClusterConfigDump()
+	if err != nil {
+		return nil, err
+	}
+	lastUpdated := map[string]time.Time{}
+	for _, dc := range clusterDump.DynamicActiveClusters {
+		cl := &cluster.Cluster{}
+		if dc.Cluster != nil && dc.LastUpdated != nil && dc.Cluster.UnmarshalTo(cl) == nil {
+			lastUpdated[cl.Name] = dc.LastUpdated.AsTime()
+		}
+	}
+	for _, sc := range clusterDump.StaticClusters {
+		cl := &cluster.Cluster{}
+		if sc.Cluster != nil && sc.LastUpdated != nil && sc.Cluster.UnmarshalTo(cl) == nil {
+			lastUpdated[cl.Name] = sc.LastUpdated.AsTime()
+		}
+	}
+	return lastUpdated, nil
+}
+
 func safelyParseSubsetKey(key string) (model.TrafficDirection, string, host.Name, int) {
 	if len(strings.Split(key, "|")) > 3 {
 		return model.ParseSubsetKey(key)
@@ -181,3 +241,88 @@ func safelyParseSubsetKey(key string) (model.TrafficDirection, string, host.Name
 	name := host.Name(key)
 	return "", "", name, 0
 }
+
+// unusedClusterFilter returns a function selecting the clusters that pass the Unused field of the filter.
+// Static clusters are referenced from the bootstrap config, and are never reported as unused.
+func (c *ConfigWriter) unusedClusterFilter(filter ClusterFilter) (func(*cluster.Cluster) bool, error) {
+	if !filter.Unused {
+		return func(*cluster.Cluster) bool { return true }, nil
+	}
+	referenced := sets.New()
+	listeners, err := c.retrieveSortedListenerSlice()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range listeners {
+		collectClusterReferences(l.ProtoReflect(), referenced)
+	}
+	// A proxy without HTTP routes has no route dump.
+	if routes, err := c.retrieveSortedRouteSlice(); err == nil {
+		for _, r := range routes {
+			collectClusterReferences(r.ProtoReflect(), referenced)
+		}
+	}
+	clusterDump, err := c.configDump.GetClusterConfigDump()
+	if err != nil {
+		return nil, err
+	}
+	for _, sc := range clusterDump.StaticClusters {
+		cl := &cluster.Cluster{}
+		if sc.Cluster != nil && sc.Cluster.UnmarshalTo(cl) == nil {
+			referenced.Insert(cl.Name)
+		}
+	}
+	for _, dc := range clusterDump.DynamicActiveClusters {
+		cl := &cluster.Cluster{}
+		if dc.Cluster != nil && dc.Cluster.UnmarshalTo(cl) == nil {
+			// Aggregate clusters reference other clusters.
+			collectClusterReferences(cl.ProtoReflect(), referenced)
+		}
+	}
+	return func(cl *cluster.Cluster) bool {
+		return !referenced.Contains(cl.Name)
+	}, nil
+}
+
+// collectClusterReferences adds the names of the clusters referenced by the message to refs. Envoy has no
+// single field for cluster references, so fields conventionally holding one are matched by name: route actions,
+// TCP proxies, mirror policies and gRPC services use "cluster" or "cluster_name", weighted clusters use the
+// "name" of their entries, and aggregate clusters list "clusters". Typed configs are unpacked when their type
+// is known.
+func collectClusterReferences(m protoreflect.Message, refs sets.Set) {
+	weighted := strings.HasSuffix(string(m.Descriptor().FullName()), ".ClusterWeight")
+	if a, ok := m.Interface().(*anypb.Any); ok {
+		inner, err := a.UnmarshalNew()
+		if err == nil {
+			collectClusterReferences(inner.ProtoReflect(), refs)
+		}
+		return
+	}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		name := string(fd.Name())
+		switch {
+		case fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap():
+			if name == "cluster" || name == "cluster_name" || (weighted && name == "name") {
+				refs.Insert(v.String())
+			}
+		case fd.Kind() == protoreflect.StringKind && fd.IsList() && name == "clusters":
+			for i := 0; i < v.List().Len(); i++ {
+				refs.Insert(v.List().Get(i).String())
+			}
+		case fd.Kind() == protoreflect.MessageKind && fd.IsList():
+			for i := 0; i < v.List().Len(); i++ {
+				collectClusterReferences(v.List().Get(i).Message(), refs)
+			}
+		case fd.Kind() == protoreflect.MessageKind && fd.IsMap():
+			if fd.MapValue().Kind() == protoreflect.MessageKind {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					collectClusterReferences(mv.Message(), refs)
+					return true
+				})
+			}
+		case fd.Kind() == protoreflect.MessageKind:
+			collectClusterReferences(v.Message(), refs)
+		}
+		return true
+	})
+}
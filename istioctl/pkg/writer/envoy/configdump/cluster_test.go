@@ -13,3 +13,139 @@
 // limitations under the License.
 
 package configdump
+
+import (
+	"bytes"
+	"testing"
+
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	aggregate "github.com/envoyproxy/go-control-plane/envoy/extensions/clusters/aggregate/v3"
+	tcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/istio/pkg/util/protomarshal"
+	"istio.io/istio/pkg/util/sets"
+)
+
+func TestCollectClusterReferences(t *testing.T) {
+	tcpProxy, _ := anypb.New(&tcp.TcpProxy{
+		ClusterSpecifier: &tcp.TcpProxy_Cluster{Cluster: "outbound|3306||mysql.default.svc.cluster.local"},
+	})
+	l := &listener.Listener{
+		FilterChains: []*listener.FilterChain{{
+			Filters: []*listener.Filter{{
+				Name:       "envoy.filters.network.tcp_proxy",
+				ConfigType: &listener.Filter_TypedConfig{TypedConfig: tcpProxy},
+			}},
+		}},
+	}
+	r := &route.RouteConfiguration{
+		VirtualHosts: []*route.VirtualHost{{
+			Routes: []*route.Route{
+				{Action: &route.Route_Route{Route: &route.RouteAction{
+					ClusterSpecifier: &route.RouteAction_Cluster{Cluster: "outbound|80||a.default.svc.cluster.local"},
+					RequestMirrorPolicies: []*route.RouteAction_RequestMirrorPolicy{
+						{Cluster: "outbound|80||mirror.default.svc.cluster.local"},
+					},
+				}}},
+				{Action: &route.Route_Route{Route: &route.RouteAction{
+					ClusterSpecifier: &route.RouteAction_WeightedClusters{WeightedClusters: &route.WeightedCluster{
+						Clusters: []*route.WeightedCluster_ClusterWeight{
+							{Name: "outbound|80|v1|b.default.svc.cluster.local"},
+							{Name: "outbound|80|v2|b.default.svc.cluster.local"},
+						},
+					}},
+				}}},
+			},
+		}},
+	}
+	aggregateConfig, _ := anypb.New(&aggregate.ClusterConfig{Clusters: []string{"primary", "secondary"}})
+	c := &cluster.Cluster{
+		Name: "aggregate",
+		ClusterDiscoveryType: &cluster.Cluster_ClusterType{ClusterType: &cluster.Cluster_CustomClusterType{
+			Name:        "envoy.clusters.aggregate",
+			TypedConfig: aggregateConfig,
+		}},
+	}
+
+	refs := sets.New()
+	collectClusterReferences(l.ProtoReflect(), refs)
+	collectClusterReferences(r.ProtoReflect(), refs)
+	collectClusterReferences(c.ProtoReflect(), refs)
+
+	expected := sets.New(
+		"outbound|3306||mysql.default.svc.cluster.local",
+		"outbound|80||a.default.svc.cluster.local",
+		"outbound|80||mirror.default.svc.cluster.local",
+		"outbound|80|v1|b.default.svc.cluster.local",
+		"outbound|80|v2|b.default.svc.cluster.local",
+		"primary",
+		"secondary",
+	)
+	if !refs.Equals(expected) {
+		t.Errorf("got %v, want %v", refs.SortedList(), expected.SortedList())
+	}
+}
+
+func TestPrintClusterDumpProto(t *testing.T) {
+	anyOf := func(m proto.Message) *anypb.Any {
+		a, err := anypb.New(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	aggregateConfig := anyOf(&aggregate.ClusterConfig{Clusters: []string{"primary", "secondary"}})
+	// The clusters are in the order they are printed.
+	clusters := []*cluster.Cluster{
+		{Name: "outbound|80||a.default.svc.cluster.local"},
+		{
+			Name: "aggregate",
+			ClusterDiscoveryType: &cluster.Cluster_ClusterType{ClusterType: &cluster.Cluster_CustomClusterType{
+				Name:        "envoy.clusters.aggregate",
+				TypedConfig: aggregateConfig,
+			}},
+		},
+	}
+	clusterDump := &admin.ClustersConfigDump{}
+	for _, c := range clusters {
+		clusterDump.DynamicActiveClusters = append(clusterDump.DynamicActiveClusters, &admin.ClustersConfigDump_DynamicCluster{Cluster: anyOf(c)})
+	}
+	dump, err := protomarshal.Marshal(&admin.ConfigDump{Configs: []*anypb.Any{anyOf(clusterDump)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotOut := &bytes.Buffer{}
+	cw := &ConfigWriter{Stdout: gotOut}
+	if err := cw.Prime(dump); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PrintClusterDump(ClusterFilter{}, "proto"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each cluster is written as its size followed by its binary protobuf, with its Any fields kept as they are.
+	b := gotOut.Bytes()
+	for _, want := range clusters {
+		size, n := protowire.ConsumeVarint(b)
+		if n < 0 || uint64(len(b)-n) < size {
+			t.Fatalf("truncated output before cluster %s", want.Name)
+		}
+		got := &cluster.Cluster{}
+		if err := proto.Unmarshal(b[n:n+int(size)], got); err != nil {
+			t.Fatal(err)
+		}
+		if !proto.Equal(got, want) {
+			t.Errorf("cluster %s did not round trip, got %v", want.Name, got)
+		}
+		b = b[n+int(size):]
+	}
+	if len(b) != 0 {
+		t.Errorf("unexpected %d bytes after the clusters", len(b))
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	protio "istio.io/istio/istioctl/pkg/util/proto"
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+// customColumnsPrefix is the prefix of the custom-columns output format, followed by the column spec.
+const customColumnsPrefix = "custom-columns="
+
+// customColumnNone is printed for the fields missing from a resource, as kubectl does.
+const customColumnNone = "<none>"
+
+// customColumn is a column of the custom-columns output format.
+type customColumn struct {
+	header string
+	// path is the field path of the column, as JSON field names. A "*" segment selects all the elements of a list.
+	path []string
+}
+
+// parseCustomColumns parses a column spec, such as NAME:.name,TLS:.transportSocket.name. Field paths use the JSON
+// names of the fields, and list elements are selected by index, as in .filterChains[0].name, or all at once, as in
+// .filterChains[*].name.
+func parseCustomColumns(spec string) ([]customColumn, error) {
+	if spec == "" {
+		return nil, fmt.Errorf("custom-columns format requires a column spec, such as NAME:.name")
+	}
+	var columns []customColumn
+	for _, col := range strings.Split(spec, ",") {
+		header, field, f := strings.Cut(col, ":")
+		if !f || header == "" || !strings.HasPrefix(field, ".") {
+			return nil, fmt.Errorf("invalid custom column %q, expected <HEADER>:.<field path>", col)
+		}
+		var path []string
+		for _, seg := range strings.Split(strings.TrimPrefix(field, "."), ".") {
+			name, index, indexed := strings.Cut(seg, "[")
+			if name == "" && !indexed {
+				return nil, fmt.Errorf("invalid custom column %q: empty field name", col)
+			}
+			if name != "" {
+				path = append(path, name)
+			}
+			if !indexed {
+				continue
+			}
+			if !strings.HasSuffix(index, "]") {
+				return nil, fmt.Errorf("invalid custom column %q: unterminated index", col)
+			}
+			index = strings.TrimSuffix(index, "]")
+			if index != "*" {
+				if _, err := strconv.Atoi(index); err != nil {
+					return nil, fmt.Errorf("invalid custom column %q: invalid index %q", col, index)
+				}
+			}
+			path = append(path, index)
+		}
+		columns = append(columns, customColumn{header: header, path: path})
+	}
+	return columns, nil
+}
+
+// customColumnValues returns the values of the field path in the JSON value.
+func customColumnValues(v any, path []string) []any {
+	if len(path) == 0 {
+		return []any{v}
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		field, f := t[path[0]]
+		if !f {
+			return nil
+		}
+		return customColumnValues(field, path[1:])
+	case []any:
+		if path[0] == "*" {
+			var out []any
+			for _, e := range t {
+				out = append(out, customColumnValues(e, path[1:])...)
+			}
+			return out
+		}
+		i, err := strconv.Atoi(path[0])
+		if err != nil || i < 0 || i >= len(t) {
+			return nil
+		}
+		return customColumnValues(t[i], path[1:])
+	}
+	return nil
+}
+
+// formatCustomColumnValue formats a value for a column: scalars as they are, lists and objects as compact JSON.
+func formatCustomColumnValue(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case map[string]any, []any:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Sprint(t)
+		}
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// printCustomColumns prints a table of the columns of the output format of the messages to the ConfigWriter stdout.
+func (c *ConfigWriter) printCustomColumns(msgs protio.MessageSlice, outputFormat string) error {
+	columns, err := parseCustomColumns(strings.TrimPrefix(outputFormat, customColumnsPrefix))
+	if err != nil {
+		return err
+	}
+	w := new(tabwriter.Writer).Init(c.Stdout, 0, 8, 3, ' ', 0)
+	headers := make([]string, 0, len(columns))
+	for _, col := range columns {
+		headers = append(headers, col.header)
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, msg := range msgs {
+		b, err := protomarshal.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		var v any
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		cells := make([]string, 0, len(columns))
+		for _, col := range columns {
+			values := customColumnValues(v, col.path)
+			if len(values) == 0 {
+				cells = append(cells, customColumnNone)
+				continue
+			}
+			formatted := make([]string, 0, len(values))
+			for _, value := range values {
+				formatted = append(formatted, formatCustomColumnValue(value))
+			}
+			cells = append(cells, strings.Join(formatted, ","))
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	return w.Flush()
+}
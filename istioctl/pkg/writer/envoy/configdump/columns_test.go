@@ -0,0 +1,115 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+
+	protio "istio.io/istio/istioctl/pkg/util/proto"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestParseCustomColumns(t *testing.T) {
+	cases := []struct {
+		spec    string
+		want    []customColumn
+		wantErr bool
+	}{
+		{
+			spec: "NAME:.name,TLS:.transportSocket.name",
+			want: []customColumn{
+				{header: "NAME", path: []string{"name"}},
+				{header: "TLS", path: []string{"transportSocket", "name"}},
+			},
+		},
+		{
+			spec: "CHAIN:.filterChains[0].name,FILTERS:.filterChains[*].filters[*].name",
+			want: []customColumn{
+				{header: "CHAIN", path: []string{"filterChains", "0", "name"}},
+				{header: "FILTERS", path: []string{"filterChains", "*", "filters", "*", "name"}},
+			},
+		},
+		{spec: "", wantErr: true},
+		{spec: "NAME", wantErr: true},
+		{spec: "NAME:name", wantErr: true},
+		{spec: ":.name", wantErr: true},
+		{spec: "NAME:.filterChains[0", wantErr: true},
+		{spec: "NAME:.filterChains[x].name", wantErr: true},
+		{spec: "NAME:.a..b", wantErr: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseCustomColumns(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintCustomColumns(t *testing.T) {
+	msgs := protio.MessageSlice{
+		&cluster.Cluster{
+			Name:                 "outbound|80||a.default.svc.cluster.local",
+			ClusterDiscoveryType: &cluster.Cluster_Type{Type: cluster.Cluster_EDS},
+			TransportSocket:      &core.TransportSocket{Name: "envoy.transport_sockets.tls"},
+		},
+		&cluster.Cluster{
+			Name:                 "PassthroughCluster",
+			ClusterDiscoveryType: &cluster.Cluster_Type{Type: cluster.Cluster_ORIGINAL_DST},
+		},
+	}
+	gotOut := &bytes.Buffer{}
+	cw := &ConfigWriter{Stdout: gotOut}
+	if err := cw.printCustomColumns(msgs, "custom-columns=NAME:.name,TYPE:.type,TLS:.transportSocket.name"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, gotOut.String(), `NAME                                       TYPE           TLS
+outbound|80||a.default.svc.cluster.local   EDS            envoy.transport_sockets.tls
+PassthroughCluster                         ORIGINAL_DST   <none>
+`)
+
+	msgs = protio.MessageSlice{
+		&listener.Listener{
+			Name: "0.0.0.0_80",
+			FilterChains: []*listener.FilterChain{
+				{Name: "a", Filters: []*listener.Filter{{Name: "envoy.filters.network.http_connection_manager"}}},
+				{Name: "b"},
+			},
+		},
+	}
+	gotOut.Reset()
+	if err := cw.printCustomColumns(msgs, "custom-columns=CHAINS:.filterChains[*].name,FIRST:.filterChains[0].filters,LAST:.filterChains[2]"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, gotOut.String(), `CHAINS   FIRST                                                        LAST
+a,b      [{"name":"envoy.filters.network.http_connection_manager"}]   <none>
+`)
+}
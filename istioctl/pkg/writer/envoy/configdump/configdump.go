@@ -18,13 +18,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	envoy_admin_v3 "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	bootstrapv3 "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	"google.golang.org/protobuf/types/known/structpb"
 	"sigs.k8s.io/yaml"
 
 	"istio.io/istio/istioctl/pkg/util/configdump"
+	protio "istio.io/istio/istioctl/pkg/util/proto"
 	sdscompare "istio.io/istio/istioctl/pkg/writer/compare/sds"
 	"istio.io/istio/pkg/util/protomarshal"
 )
@@ -48,6 +54,15 @@ func (c *ConfigWriter) Prime(b []byte) error {
 	return nil
 }
 
+// Validate checks that the primed config dump is a well-formed Envoy admin config_dump, for the dumps which were not
+// just fetched from a proxy, such as the ones read from files.
+func (c *ConfigWriter) Validate() error {
+	if c.configDump == nil {
+		return fmt.Errorf("config writer has not been primed")
+	}
+	return c.configDump.Validate()
+}
+
 // PrintBootstrapDump prints just the bootstrap config dump to the ConfigWriter stdout
 func (c *ConfigWriter) PrintBootstrapDump(outputFormat string) error {
 	if c.configDump == nil {
@@ -57,6 +72,9 @@ func (c *ConfigWriter) PrintBootstrapDump(outputFormat string) error {
 	if err != nil {
 		return err
 	}
+	if outputFormat == "proto" {
+		return c.printProto(protio.MessageSlice{bootstrapDump})
+	}
 	out, err := protomarshal.ToJSONWithIndent(bootstrapDump, "    ")
 	if err != nil {
 		return fmt.Errorf("unable to marshal bootstrap in Envoy config dump")
@@ -81,6 +99,9 @@ func (c *ConfigWriter) PrintSecretDump(outputFormat string) error {
 	if err != nil {
 		return fmt.Errorf("sidecar doesn't support secrets: %v", err)
 	}
+	if outputFormat == "proto" {
+		return c.printProto(protio.MessageSlice{secretDump})
+	}
 	out, err := protomarshal.ToJSONWithIndent(secretDump, "    ")
 	if err != nil {
 		return fmt.Errorf("unable to marshal secrets in Envoy config dump")
@@ -96,8 +117,19 @@ func (c *ConfigWriter) PrintSecretDump(outputFormat string) error {
 	return nil
 }
 
-// PrintSecretSummary prints a summary of dynamic active secrets from the config dump
-func (c *ConfigWriter) PrintSecretSummary() error {
+// printProto prints the messages to the ConfigWriter stdout as length-delimited binary protobuf
+func (c *ConfigWriter) printProto(msgs protio.MessageSlice) error {
+	out, err := msgs.MarshalDelimited()
+	if err != nil {
+		return err
+	}
+	_, err = c.Stdout.Write(out)
+	return err
+}
+
+// PrintSecretSummary prints a summary of dynamic active secrets from the config dump, with the details of their
+// certificate chains and expiration warnings as set in the options
+func (c *ConfigWriter) PrintSecretSummary(opts sdscompare.SDSWriterOptions) error {
 	secretDump, err := c.configDump.GetSecretConfigDump()
 	if err != nil {
 		return err
@@ -112,10 +144,27 @@ func (c *ConfigWriter) PrintSecretSummary() error {
 		return err
 	}
 
-	secretWriter := sdscompare.NewSDSWriter(c.Stdout, sdscompare.TABULAR)
+	secretWriter := sdscompare.NewSDSWriterWithOptions(c.Stdout, sdscompare.TABULAR, opts)
 	return secretWriter.PrintSecretItems(secretItems)
 }
 
+// updatedSinceFilter returns a function selecting the resources, by name, last updated at or after since, given the
+// last update times of the resources in the config dump. Resources without a last update time are not selected, and
+// all resources are selected when since is zero.
+func updatedSinceFilter(since time.Time, lastUpdated func() (map[string]time.Time, error)) (func(name string) bool, error) {
+	if since.IsZero() {
+		return func(string) bool { return true }, nil
+	}
+	updated, err := lastUpdated()
+	if err != nil {
+		return nil, err
+	}
+	return func(name string) bool {
+		t, f := updated[name]
+		return f && !t.Before(since)
+	}, nil
+}
+
 func (c *ConfigWriter) PrintFullSummary(cf ClusterFilter, lf ListenerFilter, rf RouteFilter) error {
 	if err := c.PrintClusterSummary(cf); err != nil {
 		return err
@@ -129,7 +178,7 @@ func (c *ConfigWriter) PrintFullSummary(cf ClusterFilter, lf ListenerFilter, rf
 		return err
 	}
 	_, _ = c.Stdout.Write([]byte("\n"))
-	if err := c.PrintSecretSummary(); err != nil {
+	if err := c.PrintSecretSummary(sdscompare.SDSWriterOptions{}); err != nil {
 		return err
 	}
 	return nil
@@ -148,10 +197,13 @@ func (c *ConfigWriter) PrintVersionSummary() error {
 
 	var (
 		istioVersion, istioProxySha = c.getIstioVersionInfo(bootstrapDump)
-		envoyVersion                = c.getUserAgentVersionInfo(bootstrapDump)
+		envoyVersion, tlsVersion    = c.getUserAgentVersionInfo(bootstrapDump)
 
 		tw = tabwriter.NewWriter(c.Stdout, 0, 8, 1, ' ', 0)
 	)
+	if tlsVersion != "" {
+		envoyVersion += "/" + tlsVersion
+	}
 
 	if len(istioVersion) > 0 {
 		fmt.Fprintf(tw, "Istio Version:\t%s\n", istioVersion)
@@ -166,6 +218,41 @@ func (c *ConfigWriter) PrintVersionSummary() error {
 	return tw.Flush()
 }
 
+// versionSummary is the version information of a proxy, for tools checking the versions of the proxies of a fleet.
+type versionSummary struct {
+	IstioVersion  string `json:"istioVersion"`
+	IstioProxySha string `json:"istioProxySha"`
+	EnvoyVersion  string `json:"envoyVersion"`
+	// TLSVersion is the TLS library Envoy is built with, such as BoringSSL.
+	TLSVersion string `json:"tlsVersion"`
+}
+
+// PrintVersionDump prints version information for Istio and Envoy from the config dump to the ConfigWriter stdout,
+// as JSON or YAML
+func (c *ConfigWriter) PrintVersionDump(outputFormat string) error {
+	if c.configDump == nil {
+		return fmt.Errorf("config writer has not been primed")
+	}
+	bootstrapDump, err := c.configDump.GetBootstrapConfigDump()
+	if err != nil {
+		return err
+	}
+	var summary versionSummary
+	summary.IstioVersion, summary.IstioProxySha = c.getIstioVersionInfo(bootstrapDump)
+	summary.EnvoyVersion, summary.TLSVersion = c.getUserAgentVersionInfo(bootstrapDump)
+	out, err := json.MarshalIndent(summary, "", "    ")
+	if err != nil {
+		return err
+	}
+	if outputFormat == "yaml" {
+		if out, err = yaml.JSONToYAML(out); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(c.Stdout, string(out))
+	return nil
+}
+
 // PrintPodRootCAFromDynamicSecretDump prints just pod's root ca from dynamic secret config dump to the ConfigWriter stdout
 func (c *ConfigWriter) PrintPodRootCAFromDynamicSecretDump() (string, error) {
 	if c.configDump == nil {
@@ -215,7 +302,9 @@ func (c *ConfigWriter) getIstioVersionInfo(bootstrapDump *envoy_admin_v3.Bootstr
 	return
 }
 
-func (c *ConfigWriter) getUserAgentVersionInfo(bootstrapDump *envoy_admin_v3.BootstrapConfigDump) string {
+// getUserAgentVersionInfo returns the version of Envoy, with its build label, status and type, and the version of
+// the TLS library it is built with.
+func (c *ConfigWriter) getUserAgentVersionInfo(bootstrapDump *envoy_admin_v3.BootstrapConfigDump) (version, tlsVersion string) {
 	const (
 		buildLabelKey = "build.label"
 		buildTypeKey  = "build.type"
@@ -225,13 +314,13 @@ func (c *ConfigWriter) getUserAgentVersionInfo(bootstrapDump *envoy_admin_v3.Boo
 
 	var (
 		buildVersion = bootstrapDump.GetBootstrap().GetNode().GetUserAgentBuildVersion()
-		version      = buildVersion.GetVersion()
+		semver       = buildVersion.GetVersion()
 		md           = buildVersion.GetMetadata().GetFields()
 
 		sb strings.Builder
 	)
 
-	fmt.Fprintf(&sb, "%d.%d.%d", version.GetMajorNumber(), version.GetMinorNumber(), version.GetPatch())
+	fmt.Fprintf(&sb, "%d.%d.%d", semver.GetMajorNumber(), semver.GetMinorNumber(), semver.GetPatch())
 	if label, ok := md[buildLabelKey]; ok {
 		fmt.Fprintf(&sb, "-%s", label.GetStringValue())
 	}
@@ -242,8 +331,119 @@ func (c *ConfigWriter) getUserAgentVersionInfo(bootstrapDump *envoy_admin_v3.Boo
 		fmt.Fprintf(&sb, "/%s", typ.GetStringValue())
 	}
 	if sslVersion, ok := md[sslVersionKey]; ok {
-		fmt.Fprintf(&sb, "/%s", sslVersion.GetStringValue())
+		tlsVersion = sslVersion.GetStringValue()
 	}
 
-	return sb.String()
+	return sb.String(), tlsVersion
+}
+
+// proxyFlag is a runtime flag of Envoy or a feature flag of Istio set in the bootstrap of a proxy.
+type proxyFlag struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Source is the runtime layer of a runtime flag, and for a feature flag the node metadata or the proxyMetadata
+	// of its ProxyConfig.
+	Source string `json:"source"`
+}
+
+type proxyFlags struct {
+	RuntimeFlags []proxyFlag `json:"runtimeFlags"`
+	FeatureFlags []proxyFlag `json:"featureFlags"`
+}
+
+// PrintFlags prints the Envoy runtime flags of the static layers of the bootstrap, and the Istio feature flags of
+// the node metadata: its boolean values, such as DNS_CAPTURE, and the proxyMetadata environment of the ProxyConfig.
+func (c *ConfigWriter) PrintFlags(outputFormat string) error {
+	if c.configDump == nil {
+		return fmt.Errorf("config writer has not been primed")
+	}
+	bootstrapDump, err := c.configDump.GetBootstrapConfigDump()
+	if err != nil {
+		return err
+	}
+	flags := retrieveProxyFlags(bootstrapDump.GetBootstrap())
+	switch outputFormat {
+	case "json", "yaml":
+		out, err := json.MarshalIndent(flags, "", "    ")
+		if err != nil {
+			return err
+		}
+		if outputFormat == "yaml" {
+			if out, err = yaml.JSONToYAML(out); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(c.Stdout, string(out))
+		return nil
+	}
+	w := new(tabwriter.Writer).Init(c.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "RUNTIME FLAG\tVALUE\tLAYER")
+	for _, f := range flags.RuntimeFlags {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", f.Name, f.Value, f.Source)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "FEATURE FLAG\tVALUE\tSOURCE")
+	for _, f := range flags.FeatureFlags {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", f.Name, f.Value, f.Source)
+	}
+	return w.Flush()
+}
+
+func retrieveProxyFlags(bootstrap *bootstrapv3.Bootstrap) proxyFlags {
+	flags := proxyFlags{RuntimeFlags: []proxyFlag{}, FeatureFlags: []proxyFlag{}}
+	for _, layer := range bootstrap.GetLayeredRuntime().GetLayers() {
+		var layerFlags []proxyFlag
+		flattenRuntimeLayer("", layer.GetStaticLayer().GetFields(), layer.Name, &layerFlags)
+		sort.Slice(layerFlags, func(i, j int) bool {
+			return layerFlags[i].Name < layerFlags[j].Name
+		})
+		flags.RuntimeFlags = append(flags.RuntimeFlags, layerFlags...)
+	}
+
+	md := bootstrap.GetNode().GetMetadata().GetFields()
+	for k, v := range md {
+		if s := v.GetStringValue(); s == "true" || s == "false" {
+			flags.FeatureFlags = append(flags.FeatureFlags, proxyFlag{Name: k, Value: s, Source: "node metadata"})
+		}
+	}
+	proxyMetadata := md["PROXY_CONFIG"].GetStructValue().GetFields()["proxyMetadata"].GetStructValue().GetFields()
+	for k, v := range proxyMetadata {
+		flags.FeatureFlags = append(flags.FeatureFlags, proxyFlag{Name: k, Value: describeValue(v), Source: "proxyMetadata"})
+	}
+	sort.Slice(flags.FeatureFlags, func(i, j int) bool {
+		a, b := flags.FeatureFlags[i], flags.FeatureFlags[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Source < b.Source
+	})
+	return flags
+}
+
+// flattenRuntimeLayer appends the flags of a static runtime layer, whose nested structs join their keys with dots.
+func flattenRuntimeLayer(prefix string, fields map[string]*structpb.Value, layer string, out *[]proxyFlag) {
+	for k, v := range fields {
+		name := k
+		if prefix != "" {
+			name = prefix + "." + k
+		}
+		if s, ok := v.GetKind().(*structpb.Value_StructValue); ok {
+			flattenRuntimeLayer(name, s.StructValue.GetFields(), layer, out)
+			continue
+		}
+		*out = append(*out, proxyFlag{Name: name, Value: describeValue(v), Source: layer})
+	}
+}
+
+func describeValue(v *structpb.Value) string {
+	switch k := v.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return k.StringValue
+	case *structpb.Value_NumberValue:
+		return strconv.FormatFloat(k.NumberValue, 'f', -1, 64)
+	case *structpb.Value_BoolValue:
+		return strconv.FormatBool(k.BoolValue)
+	}
+	b, _ := protomarshal.Marshal(v)
+	return string(b)
 }
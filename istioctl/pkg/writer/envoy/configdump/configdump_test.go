@@ -16,11 +16,25 @@ package configdump
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"testing"
+	"time"
+
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	bootstrapv3 "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"istio.io/istio/pilot/test/util"
 	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/protomarshal"
 )
 
 func TestConfigWriter_Prime(t *testing.T) {
@@ -133,3 +147,206 @@ func TestConfigWriter_PrintVersionSummary(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigWriter_PrintVersionDump(t *testing.T) {
+	tests := []struct {
+		name           string
+		outputFormat   string
+		wantOutputFile string
+		callPrime      bool
+		wantErr        bool
+	}{
+		{
+			name:           "returns expected version dump as json onto Stdout",
+			outputFormat:   "json",
+			callPrime:      true,
+			wantOutputFile: "testdata/versiondump.json",
+		},
+		{
+			name:           "returns expected version dump as yaml onto Stdout",
+			outputFormat:   "yaml",
+			callPrime:      true,
+			wantOutputFile: "testdata/versiondump.yaml",
+		},
+		{
+			name:    "errors if config dump is not primed",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOut := &bytes.Buffer{}
+			cw := &ConfigWriter{Stdout: gotOut}
+			cd, _ := os.ReadFile("testdata/configdump.json")
+			if tt.callPrime {
+				cw.Prime(cd)
+			}
+			err := cw.PrintVersionDump(tt.outputFormat)
+			if tt.wantOutputFile != "" {
+				util.CompareContent(t, gotOut.Bytes(), tt.wantOutputFile)
+			}
+			if err == nil && tt.wantErr {
+				t.Errorf("PrintVersionDump (%v) did not produce expected err", tt.name)
+			} else if err != nil && !tt.wantErr {
+				t.Errorf("PrintVersionDump (%v) produced unexpected err: %v", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestConfigWriter_PrintFlags(t *testing.T) {
+	proxyConfig, err := structpb.NewStruct(map[string]any{
+		"proxyMetadata": map[string]any{"ISTIO_META_DNS_CAPTURE": "true", "BOOTSTRAP_XDS_AGENT": "true"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runtime, err := structpb.NewStruct(map[string]any{
+		"overload.global_downstream_max_connections":               2147483647,
+		"envoy.reloadable_features.http_reject_path_with_fragment": false,
+		"re2": map[string]any{"max_program_size": map[string]any{"error_level": "32768"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bootstrap := &bootstrapv3.Bootstrap{
+		Node: &core.Node{Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+			"ISTIO_VERSION": structpb.NewStringValue("1.17.0"),
+			"DNS_CAPTURE":   structpb.NewStringValue("true"),
+			"PROXY_CONFIG":  structpb.NewStructValue(proxyConfig),
+		}}},
+		LayeredRuntime: &bootstrapv3.LayeredRuntime{Layers: []*bootstrapv3.RuntimeLayer{
+			{Name: "global config", LayerSpecifier: &bootstrapv3.RuntimeLayer_StaticLayer{StaticLayer: runtime}},
+			{Name: "admin", LayerSpecifier: &bootstrapv3.RuntimeLayer_AdminLayer_{AdminLayer: &bootstrapv3.RuntimeLayer_AdminLayer{}}},
+		}},
+	}
+	bootstrapDump, err := anypb.New(&admin.BootstrapConfigDump{Bootstrap: bootstrap})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := protomarshal.Marshal(&admin.ConfigDump{Configs: []*anypb.Any{bootstrapDump}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gotOut := &bytes.Buffer{}
+	cw := &ConfigWriter{Stdout: gotOut}
+	if err := cw.Prime(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PrintFlags("short"); err != nil {
+		t.Fatal(err)
+	}
+	util.CompareContent(t, gotOut.Bytes(), "testdata/flags.txt")
+
+	if err := (&ConfigWriter{Stdout: gotOut}).PrintFlags("short"); err == nil {
+		t.Errorf("PrintFlags did not fail on a config dump that is not primed")
+	}
+}
+
+func TestUpdatedSince(t *testing.T) {
+	since := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	before, after := timestamppb.New(since.Add(-time.Minute)), timestamppb.New(since.Add(time.Minute))
+	anyOf := func(m proto.Message) *anypb.Any {
+		a, err := anypb.New(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	clusters := &admin.ClustersConfigDump{
+		StaticClusters: []*admin.ClustersConfigDump_StaticCluster{
+			{Cluster: anyOf(&cluster.Cluster{Name: "agent"}), LastUpdated: before},
+		},
+		DynamicActiveClusters: []*admin.ClustersConfigDump_DynamicCluster{
+			{Cluster: anyOf(&cluster.Cluster{Name: "outbound|80||old.default.svc.cluster.local"}), LastUpdated: before},
+			{Cluster: anyOf(&cluster.Cluster{Name: "outbound|80||new.default.svc.cluster.local"}), LastUpdated: after},
+		},
+	}
+	listeners := &admin.ListenersConfigDump{
+		DynamicListeners: []*admin.ListenersConfigDump_DynamicListener{
+			{Name: "old", ActiveState: &admin.ListenersConfigDump_DynamicListenerState{
+				Listener: anyOf(&listener.Listener{Name: "old"}), LastUpdated: before,
+			}},
+			{Name: "new", ActiveState: &admin.ListenersConfigDump_DynamicListenerState{
+				Listener: anyOf(&listener.Listener{Name: "new"}), LastUpdated: after,
+			}},
+		},
+	}
+	routes := &admin.RoutesConfigDump{
+		DynamicRouteConfigs: []*admin.RoutesConfigDump_DynamicRouteConfig{
+			{RouteConfig: anyOf(&route.RouteConfiguration{Name: "80"}), LastUpdated: before},
+			{RouteConfig: anyOf(&route.RouteConfiguration{Name: "8080"}), LastUpdated: after},
+			{RouteConfig: anyOf(&route.RouteConfiguration{Name: "9090"})},
+		},
+	}
+	b, err := protomarshal.Marshal(&admin.ConfigDump{Configs: []*anypb.Any{anyOf(clusters), anyOf(listeners), anyOf(routes)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(out []byte) []string {
+		var resources []struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(out, &resources); err != nil {
+			t.Fatalf("failed to parse %s: %v", out, err)
+		}
+		var names []string
+		for _, r := range resources {
+			names = append(names, r.Name)
+		}
+		return names
+	}
+	cases := []struct {
+		name  string
+		since time.Time
+		print func(cw *ConfigWriter, since time.Time) error
+		want  []string
+	}{
+		{
+			name:  "clusters",
+			since: since,
+			print: func(cw *ConfigWriter, since time.Time) error {
+				return cw.PrintClusterDump(ClusterFilter{UpdatedSince: since}, "json")
+			},
+			want: []string{"outbound|80||new.default.svc.cluster.local"},
+		},
+		{
+			name: "clusters without filter",
+			print: func(cw *ConfigWriter, since time.Time) error {
+				return cw.PrintClusterDump(ClusterFilter{UpdatedSince: since}, "json")
+			},
+			want: []string{"agent", "outbound|80||new.default.svc.cluster.local", "outbound|80||old.default.svc.cluster.local"},
+		},
+		{
+			name:  "listeners",
+			since: since,
+			print: func(cw *ConfigWriter, since time.Time) error {
+				return cw.PrintListenerDump(ListenerFilter{UpdatedSince: since}, "json")
+			},
+			want: []string{"new"},
+		},
+		{
+			name:  "routes",
+			since: since,
+			print: func(cw *ConfigWriter, since time.Time) error {
+				return cw.PrintRouteDump(RouteFilter{UpdatedSince: since}, "json")
+			},
+			want: []string{"8080"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			cw := &ConfigWriter{Stdout: out}
+			if err := cw.Prime(b); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.print(cw, tt.since); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, names(out.Bytes()), tt.want)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"istio.io/istio/pkg/util/protomarshal"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// DiffWriter prints a semantic diff between two primed config dumps, such as the dumps of two pods or of a saved
+// file and a live pod. Listeners, clusters and routes are matched by name, so their order in the dumps does not
+// matter, and compared without the version and last update time of the dumps. Secrets are compared by name only, as
+// the certificates of two proxies always differ.
+type DiffWriter struct {
+	Stdout io.Writer
+	// Context is the number of lines of context of the diffs of the modified resources.
+	Context int
+
+	from, to         *ConfigWriter
+	fromName, toName string
+}
+
+// NewDiffWriter returns a DiffWriter of the changes from one config dump to another, named in the diffs.
+func NewDiffWriter(stdout io.Writer, from *ConfigWriter, fromName string, to *ConfigWriter, toName string) *DiffWriter {
+	return &DiffWriter{Stdout: stdout, Context: 3, from: from, fromName: fromName, to: to, toName: toName}
+}
+
+// namedResources returns the resources of a type of a config dump, by name.
+type namedResources func(c *ConfigWriter) (map[string]proto.Message, error)
+
+// PrintDiff prints the resources added, removed and modified from the first config dump to the second, with the
+// diff of each modified resource. It prints that the dumps match if they do.
+func (d *DiffWriter) PrintDiff() error {
+	if d.from.configDump == nil || d.to.configDump == nil {
+		return fmt.Errorf("config writer has not been primed")
+	}
+	match := true
+	for _, t := range []struct {
+		name      string
+		resources namedResources
+	}{
+		{"Listeners", (*ConfigWriter).namedListeners},
+		{"Clusters", (*ConfigWriter).namedClusters},
+		{"Routes", (*ConfigWriter).namedRoutes},
+		{"Secrets", (*ConfigWriter).namedSecrets},
+	} {
+		same, err := d.printResourceDiff(t.name, t.resources)
+		if err != nil {
+			return err
+		}
+		match = match && same
+	}
+	if match {
+		fmt.Fprintf(d.Stdout, "%s and %s match\n", d.fromName, d.toName)
+	}
+	return nil
+}
+
+// printResourceDiff prints the diff of a type of resources, and returns true if there is none.
+func (d *DiffWriter) printResourceDiff(kind string, resources namedResources) (bool, error) {
+	from, err := resources(d.from)
+	if err != nil {
+		return false, fmt.Errorf("%s of %s: %v", kind, d.fromName, err)
+	}
+	to, err := resources(d.to)
+	if err != nil {
+		return false, fmt.Errorf("%s of %s: %v", kind, d.toName, err)
+	}
+	names := sets.New()
+	for name := range from {
+		names.Insert(name)
+	}
+	for name := range to {
+		names.Insert(name)
+	}
+
+	var added, removed []string
+	diffs := map[string]string{}
+	for _, name := range names.SortedList() {
+		a, inFrom := from[name]
+		b, inTo := to[name]
+		switch {
+		case !inFrom:
+			added = append(added, name)
+		case !inTo:
+			removed = append(removed, name)
+		case !proto.Equal(a, b):
+			diff, err := d.resourceDiff(name, a, b)
+			if err != nil {
+				return false, err
+			}
+			diffs[name] = diff
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 && len(diffs) == 0 {
+		return true, nil
+	}
+
+	fmt.Fprintf(d.Stdout, "%s: %d added, %d removed, %d modified\n", kind, len(added), len(removed), len(diffs))
+	for _, name := range added {
+		fmt.Fprintf(d.Stdout, "+ %s\n", name)
+	}
+	for _, name := range removed {
+		fmt.Fprintf(d.Stdout, "- %s\n", name)
+	}
+	modified := make([]string, 0, len(diffs))
+	for name := range diffs {
+		modified = append(modified, name)
+	}
+	sort.Strings(modified)
+	for _, name := range modified {
+		fmt.Fprintf(d.Stdout, "~ %s\n%s", name, diffs[name])
+	}
+	fmt.Fprintln(d.Stdout)
+	return false, nil
+}
+
+func (d *DiffWriter) resourceDiff(name string, a, b proto.Message) (string, error) {
+	aJSON, err := protomarshal.ToJSONWithIndent(a, "    ")
+	if err != nil {
+		return "", err
+	}
+	bJSON, err := protomarshal.ToJSONWithIndent(b, "    ")
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		FromFile: d.fromName + " " + name,
+		A:        difflib.SplitLines(aJSON),
+		ToFile:   d.toName + " " + name,
+		B:        difflib.SplitLines(bJSON),
+		Context:  d.Context,
+	})
+}
+
+func (c *ConfigWriter) namedListeners() (map[string]proto.Message, error) {
+	listeners, err := c.retrieveSortedListenerSlice()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]proto.Message, len(listeners))
+	for _, l := range listeners {
+		out[l.Name] = l
+	}
+	return out, nil
+}
+
+func (c *ConfigWriter) namedClusters() (map[string]proto.Message, error) {
+	clusters, err := c.retrieveSortedClusterSlice()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]proto.Message, len(clusters))
+	for _, cl := range clusters {
+		out[cl.Name] = cl
+	}
+	return out, nil
+}
+
+func (c *ConfigWriter) namedRoutes() (map[string]proto.Message, error) {
+	out := map[string]proto.Message{}
+	// A proxy without HTTP routes has no route dump.
+	if routes, err := c.retrieveSortedRouteSlice(); err == nil {
+		for _, r := range routes {
+			out[r.Name] = r
+		}
+	}
+	return out, nil
+}
+
+func (c *ConfigWriter) namedSecrets() (map[string]proto.Message, error) {
+	out := map[string]proto.Message{}
+	// A proxy without SDS has no secret dump.
+	secretDump, err := c.configDump.GetSecretConfigDump()
+	if err != nil {
+		return out, nil
+	}
+	// Only the names are compared, the certificates of two proxies always differ.
+	for _, s := range secretDump.StaticSecrets {
+		out[s.Name] = &emptypb.Empty{}
+	}
+	for _, s := range secretDump.DynamicActiveSecrets {
+		out[s.Name] = &emptypb.Empty{}
+	}
+	return out, nil
+}
@@ -0,0 +1,143 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+func TestDiffWriter(t *testing.T) {
+	anyOf := func(m proto.Message) *anypb.Any {
+		a, err := anypb.New(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	type dump struct {
+		version   string
+		updated   time.Time
+		clusters  []*cluster.Cluster
+		listeners []*listener.Listener
+		routes    []*route.RouteConfiguration
+		secrets   []string
+	}
+	prime := func(d dump) *ConfigWriter {
+		updated := timestamppb.New(d.updated)
+		clusters := &admin.ClustersConfigDump{VersionInfo: d.version}
+		for _, c := range d.clusters {
+			clusters.DynamicActiveClusters = append(clusters.DynamicActiveClusters,
+				&admin.ClustersConfigDump_DynamicCluster{VersionInfo: d.version, Cluster: anyOf(c), LastUpdated: updated})
+		}
+		listeners := &admin.ListenersConfigDump{VersionInfo: d.version}
+		for _, l := range d.listeners {
+			listeners.DynamicListeners = append(listeners.DynamicListeners, &admin.ListenersConfigDump_DynamicListener{
+				Name:        l.Name,
+				ActiveState: &admin.ListenersConfigDump_DynamicListenerState{VersionInfo: d.version, Listener: anyOf(l), LastUpdated: updated},
+			})
+		}
+		routes := &admin.RoutesConfigDump{}
+		for _, r := range d.routes {
+			routes.DynamicRouteConfigs = append(routes.DynamicRouteConfigs,
+				&admin.RoutesConfigDump_DynamicRouteConfig{VersionInfo: d.version, RouteConfig: anyOf(r), LastUpdated: updated})
+		}
+		secrets := &admin.SecretsConfigDump{}
+		for _, s := range d.secrets {
+			// Each proxy has its own certificate.
+			secret := &tls.Secret{Name: s, Type: &tls.Secret_TlsCertificate{TlsCertificate: &tls.TlsCertificate{}}}
+			secrets.DynamicActiveSecrets = append(secrets.DynamicActiveSecrets,
+				&admin.SecretsConfigDump_DynamicSecret{Name: s, VersionInfo: d.updated.String(), Secret: anyOf(secret), LastUpdated: updated})
+		}
+		b, err := protomarshal.Marshal(&admin.ConfigDump{
+			Configs: []*anypb.Any{anyOf(clusters), anyOf(listeners), anyOf(routes), anyOf(secrets)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cw := &ConfigWriter{}
+		if err := cw.Prime(b); err != nil {
+			t.Fatal(err)
+		}
+		return cw
+	}
+
+	from := dump{
+		version: "2023-01-01T00:00:00Z/1",
+		updated: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		clusters: []*cluster.Cluster{
+			{Name: "outbound|80||a.default.svc.cluster.local", ConnectTimeout: durationpb.New(10 * time.Second)},
+			{Name: "outbound|80||b.default.svc.cluster.local"},
+		},
+		listeners: []*listener.Listener{{Name: "0.0.0.0_80"}, {Name: "virtualOutbound"}},
+		routes:    []*route.RouteConfiguration{{Name: "80"}},
+		secrets:   []string{"default", "ROOTCA"},
+	}
+	// The same resources in another order, with other versions and update times.
+	same := dump{
+		version:   "2023-01-02T00:00:00Z/7",
+		updated:   time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+		clusters:  []*cluster.Cluster{from.clusters[1], from.clusters[0]},
+		listeners: []*listener.Listener{from.listeners[1], from.listeners[0]},
+		routes:    from.routes,
+		secrets:   []string{"ROOTCA", "default"},
+	}
+	to := dump{
+		version: "2023-01-02T00:00:00Z/7",
+		updated: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+		clusters: []*cluster.Cluster{
+			{Name: "outbound|80||c.default.svc.cluster.local"},
+			{Name: "outbound|80||a.default.svc.cluster.local", ConnectTimeout: durationpb.New(5 * time.Second)},
+		},
+		listeners: from.listeners,
+		routes:    []*route.RouteConfiguration{{Name: "80"}, {Name: "8080"}},
+		secrets:   []string{"default", "ROOTCA", "file-root:/etc/certs/root-cert.pem"},
+	}
+
+	t.Run("match", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		if err := NewDiffWriter(out, prime(from), "a", prime(same), "b").PrintDiff(); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, out.String(), "a and b match\n")
+	})
+	t.Run("diff", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		if err := NewDiffWriter(out, prime(from), "a", prime(to), "b").PrintDiff(); err != nil {
+			t.Fatal(err)
+		}
+		util.CompareContent(t, out.Bytes(), "testdata/diff.txt")
+	})
+	t.Run("not primed", func(t *testing.T) {
+		if err := NewDiffWriter(&bytes.Buffer{}, prime(from), "a", &ConfigWriter{}, "b").PrintDiff(); err == nil {
+			t.Fatal("expected an error for a config writer which has not been primed")
+		}
+	})
+}
@@ -0,0 +1,129 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	wasm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/wasm/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+	"sigs.k8s.io/yaml"
+
+	protio "istio.io/istio/istioctl/pkg/util/proto"
+)
+
+// PrintEcdsDump prints just the extension config dump to the ConfigWriter stdout
+func (c *ConfigWriter) PrintEcdsDump(outputFormat string) error {
+	if c.configDump == nil {
+		return fmt.Errorf("config writer has not been primed")
+	}
+	ecdsDump, err := c.configDump.GetEcdsConfigDump()
+	if err != nil {
+		return err
+	}
+	if outputFormat == "proto" {
+		// The ECDS section is decoded from JSON, so only its extension configs are protobuf messages.
+		configs := make(protio.MessageSlice, 0, len(ecdsDump.EcdsFilters))
+		for _, f := range ecdsDump.EcdsFilters {
+			tec, err := f.GetTypedExtensionConfig()
+			if err != nil {
+				return err
+			}
+			configs = append(configs, tec)
+		}
+		return c.printProto(configs)
+	}
+	out, err := json.MarshalIndent(ecdsDump, "", "    ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal extension configs in Envoy config dump")
+	}
+	if outputFormat == "yaml" {
+		if out, err = yaml.JSONToYAML(out); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(c.Stdout, string(out))
+	return nil
+}
+
+// PrintEcdsSummary prints a summary of the extension configs received over ECDS from the config dump
+func (c *ConfigWriter) PrintEcdsSummary() error {
+	if c.configDump == nil {
+		return fmt.Errorf("config writer has not been primed")
+	}
+	ecdsDump, err := c.configDump.GetEcdsConfigDump()
+	if err != nil {
+		return err
+	}
+	if len(ecdsDump.EcdsFilters) == 0 {
+		fmt.Fprintln(c.Stdout, "No extension configs found.")
+		return nil
+	}
+	type row struct{ name, typ, version, sha, lastUpdated string }
+	rows := make([]row, 0, len(ecdsDump.EcdsFilters))
+	for _, f := range ecdsDump.EcdsFilters {
+		tec, err := f.GetTypedExtensionConfig()
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row{
+			name:        tec.GetName(),
+			typ:         strings.TrimPrefix(tec.GetTypedConfig().GetTypeUrl(), "type.googleapis.com/"),
+			version:     f.VersionInfo,
+			sha:         wasmSHA(tec.GetTypedConfig()),
+			lastUpdated: f.LastUpdated,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].name < rows[j].name
+	})
+
+	w := new(tabwriter.Writer).Init(c.Stdout, 0, 8, 5, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tVERSION\tSHA\tLAST UPDATED")
+	for _, r := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.name, valueOrNA(r.typ), valueOrNA(r.version), valueOrNA(r.sha),
+			valueOrNA(r.lastUpdated))
+	}
+	return w.Flush()
+}
+
+// wasmSHA returns the SHA256 of the module of a Wasm extension config, or an empty string for other extensions.
+func wasmSHA(config *anypb.Any) string {
+	w := &wasm.Wasm{}
+	if !config.MessageIs(w) || config.UnmarshalTo(w) != nil {
+		return ""
+	}
+	code := w.GetConfig().GetVmConfig().GetCode()
+	if sha := code.GetRemote().GetSha256(); sha != "" {
+		return sha
+	}
+	// The agent rewrites the remote modules to the local files it fetched them to, named after their SHA256.
+	if f := code.GetLocal().GetFilename(); strings.HasSuffix(f, ".wasm") {
+		return strings.TrimSuffix(path.Base(f), ".wasm")
+	}
+	return ""
+}
+
+func valueOrNA(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
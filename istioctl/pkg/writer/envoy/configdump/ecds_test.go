@@ -0,0 +1,73 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestConfigWriter_PrintEcds(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputFile      string
+		outputFormat   string
+		wantOutputFile string
+		wantErr        bool
+	}{
+		{
+			name:           "summary",
+			inputFile:      "testdata/ecdsdump.json",
+			outputFormat:   "short",
+			wantOutputFile: "testdata/ecdssummary.txt",
+		},
+		{
+			name:           "yaml",
+			inputFile:      "testdata/ecdsdump.json",
+			outputFormat:   "yaml",
+			wantOutputFile: "testdata/ecdsdump.yaml",
+		},
+		{
+			name:         "no ecds",
+			inputFile:    "testdata/configdump.json",
+			outputFormat: "short",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOut := &bytes.Buffer{}
+			cw := &ConfigWriter{Stdout: gotOut}
+			cd, err := os.ReadFile(tt.inputFile)
+			assert.NoError(t, err)
+			assert.NoError(t, cw.Prime(cd))
+			if tt.outputFormat == "short" {
+				err = cw.PrintEcdsSummary()
+			} else {
+				err = cw.PrintEcdsDump(tt.outputFormat)
+			}
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			util.CompareContent(t, gotOut.Bytes(), tt.wantOutputFile)
+		})
+	}
+}
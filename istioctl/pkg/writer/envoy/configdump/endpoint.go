@@ -43,7 +43,7 @@ func (e *EndpointFilter) Verify(ep *endpoint.LbEndpoint, cluster string) bool {
 	if e.Address == "" && e.Port == 0 && e.Cluster == "" && e.Status == "" {
 		return true
 	}
-	if e.Address != "" && !strings.EqualFold(retrieveEndpointAddress(ep), e.Address) {
+	if e.Address != "" && !strings.EqualFold(retrieveEndpointHost(ep), e.Address) {
 		return false
 	}
 	if e.Port != 0 && retrieveEndpointPort(ep) != e.Port {
@@ -67,6 +67,15 @@ func retrieveEndpointPort(ep *endpoint.LbEndpoint) uint32 {
 	return ep.GetEndpoint().GetAddress().GetSocketAddress().GetPortValue()
 }
 
+// retrieveEndpointHost returns the IP or the unix domain socket path of the endpoint, without port.
+func retrieveEndpointHost(ep *endpoint.LbEndpoint) string {
+	addr := ep.GetEndpoint().GetAddress()
+	if addr := addr.GetSocketAddress(); addr != nil {
+		return addr.Address
+	}
+	return addr.GetPipe().GetPath()
+}
+
 func retrieveEndpointAddress(ep *endpoint.LbEndpoint) string {
 	addr := ep.GetEndpoint().GetAddress()
 	if addr := addr.GetSocketAddress(); addr != nil {
@@ -90,6 +99,9 @@ func (c *ConfigWriter) PrintEndpoints(filter EndpointFilter, outputFormat string
 	for _, eds := range dump {
 		marshaller = append(marshaller, eds)
 	}
+	if outputFormat == "proto" {
+		return c.printProto(marshaller)
+	}
 	out, err := json.MarshalIndent(marshaller, "", "    ")
 	if err != nil {
 		return err
@@ -40,6 +40,19 @@ func TestPrintEndpointsSummary(t *testing.T) {
 				Port: 8080,
 			},
 		},
+		{
+			name: "addressfilter",
+			filter: EndpointFilter{
+				Address: "10.244.0.185",
+				Status:  "healthy",
+			},
+		},
+		{
+			name: "clusterfilter",
+			filter: EndpointFilter{
+				Cluster: "outbound|9080||details.default.svc.cluster.local",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
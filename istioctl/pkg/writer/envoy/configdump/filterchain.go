@@ -0,0 +1,163 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	udpa "github.com/cncf/xds/go/udpa/type/v1"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	httpConn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/istio/pkg/util/sets"
+)
+
+// Sources of the filters, as shown by PrintFilterChainSummary.
+const (
+	// FilterSourceIstio is the source of the filters Istio generates.
+	FilterSourceIstio = "Istio"
+	// FilterSourceEnvoyFilter is the source of the filters Istio does not generate, assumed to be inserted by
+	// EnvoyFilters.
+	FilterSourceEnvoyFilter = "EnvoyFilter"
+	// FilterSourceECDS is the source of the filters whose config is received over ECDS, such as the ones of
+	// WasmPlugins.
+	FilterSourceECDS = "ECDS"
+)
+
+// istioFilters are the names of the filters Istio generates, besides the ones named istio.* or istio_*. The config
+// dump does not record where the filters come from, so the other filters are assumed to be inserted by EnvoyFilters.
+var istioFilters = sets.New(
+	// Listener filters
+	wellknown.TlsInspector,
+	wellknown.HttpInspector,
+	wellknown.OriginalDestination,
+	wellknown.OriginalSource,
+	// Network filters
+	wellknown.HTTPConnectionManager,
+	wellknown.TCPProxy,
+	wellknown.RoleBasedAccessControl,
+	wellknown.ExternalAuthorization,
+	wellknown.MongoProxy,
+	wellknown.MySQLProxy,
+	wellknown.RedisProxy,
+	"envoy.filters.network.sni_cluster",
+	"envoy.filters.network.local_ratelimit",
+	// HTTP filters
+	wellknown.Router,
+	wellknown.CORS,
+	wellknown.Fault,
+	wellknown.GRPCWeb,
+	wellknown.HTTPGRPCStats,
+	wellknown.HTTPRoleBasedAccessControl,
+	wellknown.HTTPExternalAuthorization,
+	"envoy.filters.http.jwt_authn",
+	"envoy.filters.http.bandwidth_limit",
+)
+
+// filterSource returns where the filter of the name comes from, FilterSourceECDS if its config is received over ECDS.
+func filterSource(name string, ecds bool) string {
+	switch {
+	case ecds:
+		return FilterSourceECDS
+	case strings.HasPrefix(name, "istio.") || strings.HasPrefix(name, "istio_") || istioFilters.Contains(name):
+		return FilterSourceIstio
+	default:
+		return FilterSourceEnvoyFilter
+	}
+}
+
+// filterConfigType returns the type of the config of a filter, the type of the config wrapped in a TypedStruct, or
+// the types of the config allowed from ECDS.
+func filterConfigType(typedConfig *anypb.Any, discovery *core.ExtensionConfigSource) string {
+	if discovery != nil {
+		types := make([]string, 0, len(discovery.GetTypeUrls()))
+		for _, typeURL := range discovery.GetTypeUrls() {
+			types = append(types, strings.TrimPrefix(typeURL, "type.googleapis.com/"))
+		}
+		return "ECDS: " + strings.Join(types, ",")
+	}
+	if typedConfig == nil {
+		return "-"
+	}
+	typeURL := typedConfig.GetTypeUrl()
+	ts := &udpa.TypedStruct{}
+	if strings.HasSuffix(typeURL, "udpa.type.v1.TypedStruct") && typedConfig.UnmarshalTo(ts) == nil {
+		typeURL = ts.GetTypeUrl()
+	}
+	return strings.TrimPrefix(typeURL, "type.googleapis.com/")
+}
+
+// PrintFilterChainSummary prints the filters of the relevant listeners in the config dump to the ConfigWriter
+// stdout, in the order Envoy runs them: the listener filters, then, for each filter chain, its network filters, with
+// the HTTP filters of its HTTP connection managers after them. Each filter is shown with the type of its config and
+// whether Istio generates it, which shows where an EnvoyFilter inserted a filter.
+func (c *ConfigWriter) PrintFilterChainSummary(filter ListenerFilter) error {
+	w, listeners, err := c.setupListenerConfigWriter()
+	if err != nil {
+		return err
+	}
+	updated, err := updatedSinceFilter(filter.UpdatedSince, c.listenerLastUpdated)
+	if err != nil {
+		return err
+	}
+	filter.gateway = c.isGateway()
+	verifiedListeners := make([]*listener.Listener, 0, len(listeners))
+	for _, l := range listeners {
+		if filter.Verify(l) && updated(l.Name) {
+			verifiedListeners = append(verifiedListeners, l)
+		}
+	}
+	sort.SliceStable(verifiedListeners, func(i, j int) bool {
+		return verifiedListeners[i].Name < verifiedListeners[j].Name
+	})
+
+	fmt.Fprintln(w, "LISTENER\tFILTER CHAIN\tORDER\tFILTER\tTYPE\tSOURCE")
+	for _, l := range verifiedListeners {
+		for i, lf := range l.GetListenerFilters() {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", l.Name, "-", i+1, lf.Name,
+				filterConfigType(lf.GetTypedConfig(), lf.GetConfigDiscovery()), filterSource(lf.Name, lf.GetConfigDiscovery() != nil))
+		}
+		for _, fc := range filter.filterChains(l) {
+			chain := fc.Name
+			if fc == l.DefaultFilterChain {
+				chain = "default"
+				if fc.Name != "" {
+					chain = fc.Name + " (default)"
+				}
+			}
+			for i, f := range fc.GetFilters() {
+				fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", l.Name, chain, i+1, f.Name,
+					filterConfigType(f.GetTypedConfig(), f.GetConfigDiscovery()), filterSource(f.Name, f.GetConfigDiscovery() != nil))
+				if f.Name != HTTPListener {
+					continue
+				}
+				hcm := &httpConn.HttpConnectionManager{}
+				if err := f.GetTypedConfig().UnmarshalTo(hcm); err != nil {
+					continue
+				}
+				for j, hf := range hcm.GetHttpFilters() {
+					fmt.Fprintf(w, "%s\t%s\t%d.%d\t%s\t%s\t%s\n", l.Name, chain, i+1, j+1, hf.Name,
+						filterConfigType(hf.GetTypedConfig(), hf.GetConfigDiscovery()), filterSource(hf.Name, hf.GetConfigDiscovery() != nil))
+				}
+			}
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,103 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"bytes"
+	"testing"
+
+	udpa "github.com/cncf/xds/go/udpa/type/v1"
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	lua "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/lua/v3"
+	router "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	tlsinspector "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/listener/tls_inspector/v3"
+	httpConn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	tcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+func TestConfigWriter_PrintFilterChainSummary(t *testing.T) {
+	anyOf := func(m proto.Message) *anypb.Any {
+		a, err := anypb.New(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	hcm := &httpConn.HttpConnectionManager{
+		HttpFilters: []*httpConn.HttpFilter{
+			{Name: "istio.metadata_exchange", ConfigType: &httpConn.HttpFilter_TypedConfig{TypedConfig: anyOf(&udpa.TypedStruct{
+				TypeUrl: "type.googleapis.com/envoy.extensions.filters.http.wasm.v3.Wasm",
+			})}},
+			// Inserted by an EnvoyFilter.
+			{Name: "envoy.filters.http.lua", ConfigType: &httpConn.HttpFilter_TypedConfig{TypedConfig: anyOf(&lua.Lua{})}},
+			// Inserted by a WasmPlugin.
+			{Name: "default.plugin", ConfigType: &httpConn.HttpFilter_ConfigDiscovery{ConfigDiscovery: &core.ExtensionConfigSource{
+				TypeUrls: []string{"type.googleapis.com/envoy.extensions.filters.http.wasm.v3.Wasm"},
+			}}},
+			{Name: wellknown.Router, ConfigType: &httpConn.HttpFilter_TypedConfig{TypedConfig: anyOf(&router.Router{})}},
+		},
+	}
+	l := &listener.Listener{
+		Name: "0.0.0.0_9080",
+		Address: &core.Address{Address: &core.Address_SocketAddress{SocketAddress: &core.SocketAddress{
+			Address:       "0.0.0.0",
+			PortSpecifier: &core.SocketAddress_PortValue{PortValue: 9080},
+		}}},
+		ListenerFilters: []*listener.ListenerFilter{{
+			Name:       wellknown.TlsInspector,
+			ConfigType: &listener.ListenerFilter_TypedConfig{TypedConfig: anyOf(&tlsinspector.TlsInspector{})},
+		}},
+		FilterChains: []*listener.FilterChain{{
+			Name: "http",
+			Filters: []*listener.Filter{
+				{Name: wellknown.HTTPConnectionManager, ConfigType: &listener.Filter_TypedConfig{TypedConfig: anyOf(hcm)}},
+			},
+		}},
+		DefaultFilterChain: &listener.FilterChain{
+			Filters: []*listener.Filter{
+				{Name: wellknown.TCPProxy, ConfigType: &listener.Filter_TypedConfig{TypedConfig: anyOf(&tcp.TcpProxy{})}},
+			},
+		},
+	}
+	listeners := &admin.ListenersConfigDump{
+		DynamicListeners: []*admin.ListenersConfigDump_DynamicListener{
+			{Name: l.Name, ActiveState: &admin.ListenersConfigDump_DynamicListenerState{Listener: anyOf(l)}},
+			{Name: "0.0.0.0_80", ActiveState: &admin.ListenersConfigDump_DynamicListenerState{
+				Listener: anyOf(&listener.Listener{Name: "0.0.0.0_80"}),
+			}},
+		},
+	}
+	b, err := protomarshal.Marshal(&admin.ConfigDump{Configs: []*anypb.Any{anyOf(listeners)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotOut := &bytes.Buffer{}
+	cw := &ConfigWriter{Stdout: gotOut}
+	if err := cw.Prime(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PrintFilterChainSummary(ListenerFilter{Port: 9080}); err != nil {
+		t.Fatal(err)
+	}
+	util.CompareContent(t, gotOut.Bytes(), "testdata/filterchainsummary.txt")
+}
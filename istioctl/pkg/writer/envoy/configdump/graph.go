@@ -0,0 +1,179 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	httpConn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+
+	"istio.io/istio/pkg/util/sets"
+)
+
+// Kinds of the nodes of the traffic graph, also used as the prefix of their IDs.
+const (
+	graphListener = "listener"
+	graphRoute    = "route"
+	graphCluster  = "cluster"
+	graphEndpoint = "endpoint"
+)
+
+var graphShapes = map[string]string{
+	graphListener: "box",
+	graphRoute:    "ellipse",
+	graphCluster:  "hexagon",
+	graphEndpoint: "plaintext",
+}
+
+// trafficGraph is the graph of the resources of the config dump traffic flows through.
+type trafficGraph struct {
+	nodes map[string]string
+	edges sets.Set
+}
+
+func (g *trafficGraph) addNode(kind, name string) string {
+	id := kind + "/" + name
+	g.nodes[id] = kind
+	return id
+}
+
+func (g *trafficGraph) addEdge(from, to string) {
+	g.edges.Insert(from + "\x00" + to)
+}
+
+// routeConfigNames returns the names of the route configurations the HTTP connection managers of the filter chain
+// fetch over RDS.
+func routeConfigNames(fc *listener.FilterChain) []string {
+	var names []string
+	for _, f := range fc.GetFilters() {
+		if f.Name != HTTPListener {
+			continue
+		}
+		hcm := &httpConn.HttpConnectionManager{}
+		if err := f.GetTypedConfig().UnmarshalTo(hcm); err != nil {
+			continue
+		}
+		if name := hcm.GetRds().GetRouteConfigName(); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// PrintGraph prints the traffic graph of the relevant listeners in the config dump to the ConfigWriter stdout, in
+// the DOT language of Graphviz. Listeners are connected to the routes their HTTP connection managers fetch over RDS
+// and to the clusters their filter chains reference, such as the ones of TCP proxies and inline routes. Routes are
+// connected to the clusters of their routes, and clusters to their endpoints, if the config dump has them. Only the
+// routes, clusters and endpoints reachable from the relevant listeners are included, and the route and cluster
+// filters remove the ones not matching them, which keeps the graphs of gateways readable.
+func (c *ConfigWriter) PrintGraph(cf ClusterFilter, lf ListenerFilter, rf RouteFilter) error {
+	listeners, err := c.retrieveSortedListenerSlice()
+	if err != nil {
+		return err
+	}
+	// A proxy without HTTP routes has no route dump.
+	routes := map[string]*route.RouteConfiguration{}
+	if rcs, err := c.retrieveSortedRouteSlice(); err == nil {
+		for _, rc := range rcs {
+			if rf.Verify(rc) {
+				routes[rc.Name] = rc
+			}
+		}
+	}
+	sortedClusters, err := c.retrieveSortedClusterSlice()
+	if err != nil {
+		return err
+	}
+	clusters := map[string]*cluster.Cluster{}
+	for _, cl := range sortedClusters {
+		if cf.Verify(cl) {
+			clusters[cl.Name] = cl
+		}
+	}
+	endpoints := map[string][]string{}
+	if clas, err := c.retrieveSortedEndpointsSlice(EndpointFilter{}); err == nil {
+		for _, cla := range clas {
+			for _, llb := range cla.GetEndpoints() {
+				for _, ep := range llb.GetLbEndpoints() {
+					endpoints[cla.ClusterName] = append(endpoints[cla.ClusterName], retrieveEndpointAddress(ep))
+				}
+			}
+		}
+	}
+
+	g := &trafficGraph{nodes: map[string]string{}, edges: sets.New()}
+	addCluster := func(from, name string) {
+		if _, f := clusters[name]; !f {
+			return
+		}
+		id := g.addNode(graphCluster, name)
+		g.addEdge(from, id)
+		for _, ep := range endpoints[name] {
+			g.addEdge(id, g.addNode(graphEndpoint, ep))
+		}
+	}
+	lf.gateway = c.isGateway()
+	for _, l := range listeners {
+		if !lf.Verify(l) {
+			continue
+		}
+		lid := g.addNode(graphListener, l.Name)
+		for _, fc := range lf.filterChains(l) {
+			for _, name := range routeConfigNames(fc) {
+				rc, f := routes[name]
+				if !f {
+					continue
+				}
+				rid := g.addNode(graphRoute, name)
+				g.addEdge(lid, rid)
+				refs := sets.New()
+				collectClusterReferences(rc.ProtoReflect(), refs)
+				for _, cl := range refs.SortedList() {
+					addCluster(rid, cl)
+				}
+			}
+			refs := sets.New()
+			collectClusterReferences(fc.ProtoReflect(), refs)
+			for _, cl := range refs.SortedList() {
+				addCluster(lid, cl)
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph proxy {\n")
+	b.WriteString("  rankdir=LR;\n")
+	ids := make([]string, 0, len(g.nodes))
+	for id := range g.nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		kind := g.nodes[id]
+		fmt.Fprintf(&b, "  %q [label=%q, shape=%s];\n", id, strings.TrimPrefix(id, kind+"/"), graphShapes[kind])
+	}
+	for _, e := range g.edges.SortedList() {
+		from, to, _ := strings.Cut(e, "\x00")
+		fmt.Fprintf(&b, "  %q -> %q;\n", from, to)
+	}
+	b.WriteString("}\n")
+	_, err = fmt.Fprint(c.Stdout, b.String())
+	return err
+}
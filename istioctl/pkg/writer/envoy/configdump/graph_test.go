@@ -0,0 +1,162 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	httpConn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	tcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+func TestConfigWriter_PrintGraph(t *testing.T) {
+	anyOf := func(m proto.Message) *anypb.Any {
+		a, err := anypb.New(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	address := func(ip string, port uint32) *core.Address {
+		return &core.Address{Address: &core.Address_SocketAddress{SocketAddress: &core.SocketAddress{
+			Address:       ip,
+			PortSpecifier: &core.SocketAddress_PortValue{PortValue: port},
+		}}}
+	}
+	httpListener := &listener.Listener{
+		Name:    "0.0.0.0_80",
+		Address: address("0.0.0.0", 80),
+		FilterChains: []*listener.FilterChain{{
+			Filters: []*listener.Filter{{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &listener.Filter_TypedConfig{TypedConfig: anyOf(&httpConn.HttpConnectionManager{
+					RouteSpecifier: &httpConn.HttpConnectionManager_Rds{Rds: &httpConn.Rds{RouteConfigName: "80"}},
+				})},
+			}},
+		}},
+	}
+	tcpListener := &listener.Listener{
+		Name:    "10.0.0.5_5432",
+		Address: address("10.0.0.5", 5432),
+		FilterChains: []*listener.FilterChain{{
+			Filters: []*listener.Filter{{
+				Name: wellknown.TCPProxy,
+				ConfigType: &listener.Filter_TypedConfig{TypedConfig: anyOf(&tcp.TcpProxy{
+					ClusterSpecifier: &tcp.TcpProxy_Cluster{Cluster: "outbound|5432||db.default.svc.cluster.local"},
+				})},
+			}},
+		}},
+	}
+	listeners := &admin.ListenersConfigDump{
+		DynamicListeners: []*admin.ListenersConfigDump_DynamicListener{
+			{Name: httpListener.Name, ActiveState: &admin.ListenersConfigDump_DynamicListenerState{Listener: anyOf(httpListener)}},
+			{Name: tcpListener.Name, ActiveState: &admin.ListenersConfigDump_DynamicListenerState{Listener: anyOf(tcpListener)}},
+		},
+	}
+	routes := &admin.RoutesConfigDump{
+		DynamicRouteConfigs: []*admin.RoutesConfigDump_DynamicRouteConfig{{RouteConfig: anyOf(&route.RouteConfiguration{
+			Name: "80",
+			VirtualHosts: []*route.VirtualHost{{
+				Name:    "reviews.default.svc.cluster.local:80",
+				Domains: []string{"reviews.default.svc.cluster.local"},
+				Routes: []*route.Route{{
+					Match: &route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"}},
+					Action: &route.Route_Route{Route: &route.RouteAction{
+						ClusterSpecifier: &route.RouteAction_WeightedClusters{WeightedClusters: &route.WeightedCluster{
+							Clusters: []*route.WeightedCluster_ClusterWeight{
+								{Name: "outbound|80|v1|reviews.default.svc.cluster.local"},
+								{Name: "outbound|80|v2|reviews.default.svc.cluster.local"},
+							},
+						}},
+					}},
+				}},
+			}},
+		})}},
+	}
+	clusters := &admin.ClustersConfigDump{}
+	for _, name := range []string{
+		"outbound|80|v1|reviews.default.svc.cluster.local",
+		"outbound|80|v2|reviews.default.svc.cluster.local",
+		"outbound|5432||db.default.svc.cluster.local",
+		// Not referenced by any listener or route.
+		"outbound|9080||ratings.default.svc.cluster.local",
+	} {
+		clusters.DynamicActiveClusters = append(clusters.DynamicActiveClusters,
+			&admin.ClustersConfigDump_DynamicCluster{Cluster: anyOf(&cluster.Cluster{Name: name})})
+	}
+	endpoints := &admin.EndpointsConfigDump{}
+	for name, ip := range map[string]string{
+		"outbound|80|v1|reviews.default.svc.cluster.local": "10.1.0.1",
+		"outbound|80|v2|reviews.default.svc.cluster.local": "10.1.0.2",
+		"outbound|5432||db.default.svc.cluster.local":      "10.1.0.3",
+	} {
+		endpoints.DynamicEndpointConfigs = append(endpoints.DynamicEndpointConfigs, &admin.EndpointsConfigDump_DynamicEndpointConfig{
+			EndpointConfig: anyOf(&endpoint.ClusterLoadAssignment{
+				ClusterName: name,
+				Endpoints: []*endpoint.LocalityLbEndpoints{{LbEndpoints: []*endpoint.LbEndpoint{{
+					HostIdentifier: &endpoint.LbEndpoint_Endpoint{Endpoint: &endpoint.Endpoint{Address: address(ip, 8080)}},
+				}}}},
+			}),
+		})
+	}
+	b, err := protomarshal.Marshal(&admin.ConfigDump{
+		Configs: []*anypb.Any{anyOf(clusters), anyOf(listeners), anyOf(routes), anyOf(endpoints)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cw := &ConfigWriter{}
+	if err := cw.Prime(b); err != nil {
+		t.Fatal(err)
+	}
+
+	gotOut := &bytes.Buffer{}
+	cw.Stdout = gotOut
+	if err := cw.PrintGraph(ClusterFilter{}, ListenerFilter{}, RouteFilter{}); err != nil {
+		t.Fatal(err)
+	}
+	util.CompareContent(t, gotOut.Bytes(), "testdata/graph.dot")
+
+	// Filters keep only the selected part of the graph.
+	gotOut.Reset()
+	if err := cw.PrintGraph(ClusterFilter{Subset: "v2"}, ListenerFilter{Port: 80}, RouteFilter{}); err != nil {
+		t.Fatal(err)
+	}
+	got := gotOut.String()
+	for _, want := range []string{`"listener/0.0.0.0_80"`, `"route/80"`, `"cluster/outbound|80|v2|reviews.default.svc.cluster.local"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected the graph to contain %s, got:\n%s", want, got)
+		}
+	}
+	for _, notWant := range []string{"10.0.0.5_5432", "outbound|80|v1|", "db.default", "ratings"} {
+		if strings.Contains(got, notWant) {
+			t.Errorf("expected the graph not to contain %s, got:\n%s", notWant, got)
+		}
+	}
+}
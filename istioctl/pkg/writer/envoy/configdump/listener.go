@@ -17,21 +17,28 @@ package configdump
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"reflect"
+	"regexp"
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	httpConn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	tcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/proto"
 	"sigs.k8s.io/yaml"
 
 	protio "istio.io/istio/istioctl/pkg/util/proto"
+	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking/util"
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config/host"
 )
 
 const (
@@ -42,19 +49,47 @@ const (
 	TCPListener = wellknown.TCPProxy
 )
 
+// Directions of the listeners, from the point of view of the workload of the proxy.
+const (
+	ListenerDirectionInbound  = "INBOUND"
+	ListenerDirectionOutbound = "OUTBOUND"
+	ListenerDirectionGateway  = "GATEWAY"
+	// ListenerDirectionUnknown is the direction of the listeners not receiving traffic of the workload, such as the
+	// static listeners of the bootstrap configuration serving the stats and the health of the proxy.
+	ListenerDirectionUnknown = "UNKNOWN"
+)
+
 // ListenerFilter is used to pass filter information into listener based config writer print functions
 type ListenerFilter struct {
+	// Name selects the listeners by name, with a pattern as described in ValidateNamePattern.
+	Name    string
 	Address string
 	Port    uint32
 	Type    string
 	Verbose bool
+	// UpdatedSince selects only the listeners last updated at or after this time, if set.
+	UpdatedSince time.Time
+	// SNI, ApplicationProtocol and DestinationCIDR select only the filter chains whose match has the server name,
+	// application protocol or destination prefix range, and the listeners with such filter chains.
+	SNI                 string
+	ApplicationProtocol string
+	DestinationCIDR     string
+	// Direction selects only the listeners of the direction, one of inbound, outbound or gateway.
+	Direction string
+
+	// gateway is whether the listeners are the ones of a gateway, rather than a sidecar, set from the config dump.
+	gateway bool
+	nameRE  *regexp.Regexp
 }
 
 // Verify returns true if the passed listener matches the filter fields
 func (l *ListenerFilter) Verify(listener *listener.Listener) bool {
-	if l.Address == "" && l.Port == 0 && l.Type == "" {
+	if l.Name == "" && l.Address == "" && l.Port == 0 && l.Type == "" && l.Direction == "" && !l.filtersChains() {
 		return true
 	}
+	if !matchName(l.Name, &l.nameRE, listener.Name) {
+		return false
+	}
 	if l.Address != "" && !strings.EqualFold(retrieveListenerAddress(listener), l.Address) {
 		return false
 	}
@@ -64,9 +99,93 @@ func (l *ListenerFilter) Verify(listener *listener.Listener) bool {
 	if l.Type != "" && !strings.EqualFold(retrieveListenerType(listener), l.Type) {
 		return false
 	}
+	if l.Direction != "" && !strings.EqualFold(retrieveListenerDirection(listener, l.gateway), l.Direction) {
+		return false
+	}
+	if l.filtersChains() && len(l.filterChains(listener)) == 0 {
+		return false
+	}
+	return true
+}
+
+func (l *ListenerFilter) filtersChains() bool {
+	return l.SNI != "" || l.ApplicationProtocol != "" || l.DestinationCIDR != ""
+}
+
+// VerifyFilterChain returns true if the match of the passed filter chain matches the filter chain fields. A field of
+// the filter only matches the filter chains whose match sets the corresponding attribute, so that the chains matching
+// everything, such as the passthrough chains, are not selected.
+func (l *ListenerFilter) VerifyFilterChain(fc *listener.FilterChain) bool {
+	match := fc.GetFilterChainMatch()
+	if l.SNI != "" {
+		sni := host.Name(strings.ToLower(l.SNI))
+		found := false
+		for _, name := range match.GetServerNames() {
+			if sni.SubsetOf(host.Name(strings.ToLower(name))) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if l.ApplicationProtocol != "" {
+		found := false
+		for _, p := range match.GetApplicationProtocols() {
+			if p == l.ApplicationProtocol {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if l.DestinationCIDR != "" && !prefixRangesContain(match.GetPrefixRanges(), l.DestinationCIDR) {
+		return false
+	}
 	return true
 }
 
+// filterChains returns the filter chains of the listener, including the default one, matching the filter chain fields.
+func (l *ListenerFilter) filterChains(listener *listener.Listener) []*listener.FilterChain {
+	chains := getFilterChains(listener)
+	if !l.filtersChains() {
+		return chains
+	}
+	res := chains[:0:0]
+	for _, fc := range chains {
+		if l.VerifyFilterChain(fc) {
+			res = append(res, fc)
+		}
+	}
+	return res
+}
+
+// prefixRangesContain returns true if one of the ranges contains the CIDR, which may also be a single address.
+func prefixRangesContain(ranges []*core.CidrRange, cidr string) bool {
+	// A single address is contained in the ranges of any length containing it.
+	ip, ones := net.ParseIP(cidr), net.IPv6len*8
+	if _, ipNet, err := net.ParseCIDR(cidr); err == nil {
+		ip = ipNet.IP
+		ones, _ = ipNet.Mask.Size()
+	}
+	if ip == nil {
+		return false
+	}
+	for _, r := range ranges {
+		_, rNet, err := net.ParseCIDR(fmt.Sprintf("%s/%d", r.GetAddressPrefix(), r.GetPrefixLen().GetValue()))
+		if err != nil {
+			continue
+		}
+		if rOnes, _ := rNet.Mask.Size(); rOnes <= ones && rNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 func getFilterChains(l *listener.Listener) []*listener.FilterChain {
 	res := l.FilterChains
 	if l.DefaultFilterChain != nil {
@@ -103,6 +222,24 @@ func retrieveListenerType(l *listener.Listener) string {
 	return "UNKNOWN"
 }
 
+// retrieveListenerDirection classifies a Listener as INBOUND|OUTBOUND|GATEWAY|UNKNOWN, from its traffic direction
+// or else its name. The outbound listeners of a gateway receive the traffic of the servers of the gateway.
+func retrieveListenerDirection(l *listener.Listener, gateway bool) string {
+	direction := ListenerDirectionUnknown
+	switch {
+	case l.GetTrafficDirection() == core.TrafficDirection_INBOUND,
+		l.GetName() == model.VirtualInboundListenerName, strings.HasPrefix(l.GetName(), "inbound"):
+		direction = ListenerDirectionInbound
+	case l.GetTrafficDirection() == core.TrafficDirection_OUTBOUND,
+		l.GetName() == model.VirtualOutboundListenerName, strings.HasPrefix(l.GetName(), "outbound"):
+		direction = ListenerDirectionOutbound
+	}
+	if gateway && direction == ListenerDirectionOutbound {
+		return ListenerDirectionGateway
+	}
+	return direction
+}
+
 func retrieveListenerAddress(l *listener.Listener) string {
 	sockAddr := l.Address.GetSocketAddress()
 	if sockAddr != nil {
@@ -127,10 +264,15 @@ func (c *ConfigWriter) PrintListenerSummary(filter ListenerFilter) error {
 	if err != nil {
 		return err
 	}
+	updated, err := updatedSinceFilter(filter.UpdatedSince, c.listenerLastUpdated)
+	if err != nil {
+		return err
+	}
 
+	filter.gateway = c.isGateway()
 	verifiedListeners := make([]*listener.Listener, 0, len(listeners))
 	for _, l := range listeners {
-		if filter.Verify(l) {
+		if filter.Verify(l) && updated(l.Name) {
 			verifiedListeners = append(verifiedListeners, l)
 		}
 	}
@@ -153,25 +295,26 @@ func (c *ConfigWriter) PrintListenerSummary(filter ListenerFilter) error {
 	})
 
 	if filter.Verbose {
-		fmt.Fprintln(w, "ADDRESS\tPORT\tMATCH\tDESTINATION")
+		fmt.Fprintln(w, "ADDRESS\tPORT\tDIRECTION\tMATCH\tDESTINATION\tCONFIG SOURCE")
 	} else {
-		fmt.Fprintln(w, "ADDRESS\tPORT\tTYPE")
+		fmt.Fprintln(w, "ADDRESS\tPORT\tDIRECTION\tTYPE")
 	}
 	for _, l := range verifiedListeners {
 		address := retrieveListenerAddress(l)
 		port := retrieveListenerPort(l)
+		direction := retrieveListenerDirection(l, filter.gateway)
 		if filter.Verbose {
 
-			matches := retrieveListenerMatches(l)
+			matches := retrieveListenerMatches(filter.filterChains(l))
 			sort.Slice(matches, func(i, j int) bool {
 				return matches[i].destination > matches[j].destination
 			})
 			for _, match := range matches {
-				fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", address, port, match.match, match.destination)
+				fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", address, port, direction, match.match, match.destination, match.source)
 			}
 		} else {
 			listenerType := retrieveListenerType(l)
-			fmt.Fprintf(w, "%v\t%v\t%v\n", address, port, listenerType)
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", address, port, direction, listenerType)
 		}
 	}
 	return w.Flush()
@@ -180,6 +323,8 @@ func (c *ConfigWriter) PrintListenerSummary(filter ListenerFilter) error {
 type filterchain struct {
 	match       string
 	destination string
+	// source is the Istio config the filter chain is generated from, if known.
+	source string
 }
 
 var (
@@ -196,8 +341,7 @@ var (
 	}
 )
 
-func retrieveListenerMatches(l *listener.Listener) []filterchain {
-	fChains := getFilterChains(l)
+func retrieveListenerMatches(fChains []*listener.FilterChain) []filterchain {
 	resp := make([]filterchain, 0, len(fChains))
 	for _, filterChain := range fChains {
 		match := filterChain.FilterChainMatch
@@ -247,6 +391,7 @@ func retrieveListenerMatches(l *listener.Listener) []filterchain {
 		fc := filterchain{
 			destination: getFilterType(filterChain.GetFilters()),
 			match:       strings.Join(descrs, "; "),
+			source:      describeConfigSource(filterChain.GetMetadata()),
 		}
 		resp = append(resp, fc)
 	}
@@ -370,12 +515,23 @@ func (c *ConfigWriter) PrintListenerDump(filter ListenerFilter, outputFormat str
 	if err != nil {
 		return err
 	}
+	updated, err := updatedSinceFilter(filter.UpdatedSince, c.listenerLastUpdated)
+	if err != nil {
+		return err
+	}
+	filter.gateway = c.isGateway()
 	filteredListeners := protio.MessageSlice{}
 	for _, listener := range listeners {
-		if filter.Verify(listener) {
-			filteredListeners = append(filteredListeners, listener)
+		if filter.Verify(listener) && updated(listener.Name) {
+			filteredListeners = append(filteredListeners, filter.withFilterChains(listener))
 		}
 	}
+	if outputFormat == "proto" {
+		return c.printProto(filteredListeners)
+	}
+	if strings.HasPrefix(outputFormat, customColumnsPrefix) {
+		return c.printCustomColumns(filteredListeners, outputFormat)
+	}
 	out, err := json.MarshalIndent(filteredListeners, "", "    ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal listeners: %v", err)
@@ -389,6 +545,34 @@ func (c *ConfigWriter) PrintListenerDump(filter ListenerFilter, outputFormat str
 	return nil
 }
 
+// withFilterChains returns a copy of the listener with only the filter chains matching the filter chain fields.
+func (l *ListenerFilter) withFilterChains(lis *listener.Listener) *listener.Listener {
+	if !l.filtersChains() {
+		return lis
+	}
+	res := proto.Clone(lis).(*listener.Listener)
+	res.FilterChains = nil
+	for _, fc := range lis.FilterChains {
+		if l.VerifyFilterChain(fc) {
+			res.FilterChains = append(res.FilterChains, fc)
+		}
+	}
+	if res.DefaultFilterChain != nil && !l.VerifyFilterChain(lis.DefaultFilterChain) {
+		res.DefaultFilterChain = nil
+	}
+	return res
+}
+
+// isGateway returns whether the config dump is the one of a gateway, from the ID of the node of its bootstrap
+// configuration, such as router~10.0.0.1~istio-ingressgateway-5d7d8b8b5b-x2x6k.istio-system~istio-system.svc.cluster.local.
+func (c *ConfigWriter) isGateway() bool {
+	bootstrapDump, err := c.configDump.GetBootstrapConfigDump()
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(bootstrapDump.GetBootstrap().GetNode().GetId(), "router~")
+}
+
 func (c *ConfigWriter) setupListenerConfigWriter() (*tabwriter.Writer, []*listener.Listener, error) {
 	listeners, err := c.retrieveSortedListenerSlice()
 	if err != nil {
@@ -437,3 +621,24 @@ func (c *ConfigWriter) retrieveSortedListenerSlice() ([]*listener.Listener, erro
 	}
 	return listeners, nil
 }
+
+// listenerLastUpdated returns the last update times of the listeners in the config dump by name.
+func (c *ConfigWriter) listenerLastUpdated() (map[string]time.Time, error) {
+	listenerDump, err := c.configDump.GetListenerConfigDump()
+	if err != nil {
+		return nil, fmt.Errorf("listener dump: %v", err)
+	}
+	lastUpdated := map[string]time.Time{}
+	for _, l := range listenerDump.DynamicListeners {
+		if l.ActiveState != nil && l.ActiveState.LastUpdated != nil {
+			lastUpdated[l.Name] = l.ActiveState.LastUpdated.AsTime()
+		}
+	}
+	for _, l := range listenerDump.StaticListeners {
+		listenerTyped := &listener.Listener{}
+		if l.Listener != nil && l.LastUpdated != nil && l.Listener.UnmarshalTo(listenerTyped) == nil {
+			lastUpdated[listenerTyped.Name] = l.LastUpdated.AsTime()
+		}
+	}
+	return lastUpdated, nil
+}
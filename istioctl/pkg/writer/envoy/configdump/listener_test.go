@@ -20,6 +20,7 @@ import (
 	v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestListenerFilter_Verify(t *testing.T) {
@@ -39,6 +40,24 @@ func TestListenerFilter_Verify(t *testing.T) {
 			inListener: &listener.Listener{},
 			expect:     true,
 		},
+		{
+			desc:       "name-glob-match",
+			inFilter:   &ListenerFilter{Name: "*_9080"},
+			inListener: &listener.Listener{Name: "10.0.0.1_9080"},
+			expect:     true,
+		},
+		{
+			desc:       "name-glob-dont-match",
+			inFilter:   &ListenerFilter{Name: "*_9080"},
+			inListener: &listener.Listener{Name: "10.0.0.1_90800"},
+			expect:     false,
+		},
+		{
+			desc:       "name-regex-match",
+			inFilter:   &ListenerFilter{Name: "/^10\\.0\\./"},
+			inListener: &listener.Listener{Name: "10.0.0.1_9080"},
+			expect:     true,
+		},
 		{
 			desc: "addrs-dont-match",
 			inFilter: &ListenerFilter{
@@ -163,3 +182,121 @@ func TestListenerFilter_Verify(t *testing.T) {
 		})
 	}
 }
+
+func TestListenerFilter_VerifyFilterChain(t *testing.T) {
+	chain := &listener.FilterChain{
+		FilterChainMatch: &listener.FilterChainMatch{
+			ServerNames:          []string{"*.example.com", "bar.example.org"},
+			ApplicationProtocols: []string{"istio-peer-exchange", "istio"},
+			PrefixRanges: []*v3.CidrRange{
+				{AddressPrefix: "10.0.0.0", PrefixLen: wrapperspb.UInt32(16)},
+				{AddressPrefix: "2001:db8::", PrefixLen: wrapperspb.UInt32(32)},
+			},
+		},
+	}
+	tests := []struct {
+		desc     string
+		inFilter *ListenerFilter
+		expect   bool
+	}{
+		{desc: "sni", inFilter: &ListenerFilter{SNI: "bar.example.org"}, expect: true},
+		{desc: "sni-wildcard", inFilter: &ListenerFilter{SNI: "Foo.example.com"}, expect: true},
+		{desc: "sni-dont-match", inFilter: &ListenerFilter{SNI: "foo.example.org"}, expect: false},
+		{desc: "application-protocol", inFilter: &ListenerFilter{ApplicationProtocol: "istio"}, expect: true},
+		{desc: "application-protocol-dont-match", inFilter: &ListenerFilter{ApplicationProtocol: "h2"}, expect: false},
+		{desc: "cidr-address", inFilter: &ListenerFilter{DestinationCIDR: "10.0.3.4"}, expect: true},
+		{desc: "cidr", inFilter: &ListenerFilter{DestinationCIDR: "10.0.3.0/24"}, expect: true},
+		{desc: "cidr-ipv6", inFilter: &ListenerFilter{DestinationCIDR: "2001:db8::1"}, expect: true},
+		{desc: "cidr-wider", inFilter: &ListenerFilter{DestinationCIDR: "10.0.0.0/8"}, expect: false},
+		{desc: "cidr-dont-match", inFilter: &ListenerFilter{DestinationCIDR: "10.1.0.1"}, expect: false},
+		{desc: "all-fields", inFilter: &ListenerFilter{SNI: "foo.example.com", ApplicationProtocol: "istio", DestinationCIDR: "10.0.0.1"}, expect: true},
+		{desc: "one-field-dont-match", inFilter: &ListenerFilter{SNI: "foo.example.com", ApplicationProtocol: "h2"}, expect: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := tt.inFilter.VerifyFilterChain(chain); got != tt.expect {
+				t.Errorf("%s: expect %v got %v", tt.desc, tt.expect, got)
+			}
+		})
+	}
+
+	// A chain matching everything does not match a filter chain field.
+	if (&ListenerFilter{SNI: "foo.example.com"}).VerifyFilterChain(&listener.FilterChain{}) {
+		t.Errorf("expected the chain without a match not to match the SNI")
+	}
+
+	l := &listener.Listener{
+		FilterChains:       []*listener.FilterChain{chain, {FilterChainMatch: &listener.FilterChainMatch{ServerNames: []string{"foo.example.org"}}}},
+		DefaultFilterChain: &listener.FilterChain{},
+	}
+	filter := &ListenerFilter{SNI: "foo.example.org"}
+	if !filter.Verify(l) {
+		t.Errorf("expected the listener with a chain matching the SNI to match")
+	}
+	if got := filter.withFilterChains(l); len(got.FilterChains) != 1 || got.DefaultFilterChain != nil || len(l.FilterChains) != 2 {
+		t.Errorf("expected only the chain matching the SNI to be kept, got %v", got)
+	}
+	if (&ListenerFilter{SNI: "foo.example.net"}).Verify(l) {
+		t.Errorf("expected the listener without a chain matching the SNI not to match")
+	}
+}
+
+func TestListenerFilter_VerifyDirection(t *testing.T) {
+	tests := []struct {
+		desc       string
+		inFilter   *ListenerFilter
+		inListener *listener.Listener
+		expect     bool
+	}{
+		{
+			desc:       "virtual-inbound",
+			inFilter:   &ListenerFilter{Direction: "inbound"},
+			inListener: &listener.Listener{Name: "virtualInbound"},
+			expect:     true,
+		},
+		{
+			desc:       "traffic-direction-inbound",
+			inFilter:   &ListenerFilter{Direction: "inbound"},
+			inListener: &listener.Listener{Name: "0.0.0.0_15006", TrafficDirection: v3.TrafficDirection_INBOUND},
+			expect:     true,
+		},
+		{
+			desc:       "outbound-dont-match",
+			inFilter:   &ListenerFilter{Direction: "inbound"},
+			inListener: &listener.Listener{Name: "virtualOutbound"},
+			expect:     false,
+		},
+		{
+			desc:       "traffic-direction-outbound",
+			inFilter:   &ListenerFilter{Direction: "outbound"},
+			inListener: &listener.Listener{Name: "0.0.0.0_8080", TrafficDirection: v3.TrafficDirection_OUTBOUND},
+			expect:     true,
+		},
+		{
+			desc:       "gateway",
+			inFilter:   &ListenerFilter{Direction: "gateway", gateway: true},
+			inListener: &listener.Listener{Name: "0.0.0.0_8080", TrafficDirection: v3.TrafficDirection_OUTBOUND},
+			expect:     true,
+		},
+		{
+			desc:       "gateway-not-outbound",
+			inFilter:   &ListenerFilter{Direction: "outbound", gateway: true},
+			inListener: &listener.Listener{Name: "0.0.0.0_8080", TrafficDirection: v3.TrafficDirection_OUTBOUND},
+			expect:     false,
+		},
+		{
+			desc:       "unknown-dont-match",
+			inFilter:   &ListenerFilter{Direction: "outbound"},
+			inListener: &listener.Listener{},
+			expect:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := tt.inFilter.Verify(tt.inListener); got != tt.expect {
+				t.Errorf("%s: expect %v got %v", tt.desc, tt.expect, got)
+			}
+		})
+	}
+}
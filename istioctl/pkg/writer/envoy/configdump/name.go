@@ -0,0 +1,65 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ValidateNamePattern returns an error if the pattern of the Name field of a filter is invalid. The pattern is
+// either a glob matching the whole name, where * matches any characters and ? a single one, such as
+// outbound|443|*reviews*, or a regular expression between slashes matching a part of the name, such as
+// /^outbound\|443\|v[12]\|/.
+func ValidateNamePattern(pattern string) error {
+	_, err := compileNamePattern(pattern)
+	return err
+}
+
+func compileNamePattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		return regexp.Compile(pattern[1 : len(pattern)-1])
+	}
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// matchName returns whether the name matches the name pattern, which is compiled into re on first use. An empty
+// pattern matches all the names, and an invalid one none.
+func matchName(pattern string, re **regexp.Regexp, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	if *re == nil {
+		compiled, err := compileNamePattern(pattern)
+		if err != nil {
+			return false
+		}
+		*re = compiled
+	}
+	return (*re).MatchString(name)
+}
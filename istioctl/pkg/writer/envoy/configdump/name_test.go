@@ -0,0 +1,81 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"testing"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+)
+
+func TestNameFilter(t *testing.T) {
+	names := []string{
+		"outbound|443||reviews.default.svc.cluster.local",
+		"outbound|443|v1|reviews.default.svc.cluster.local",
+		"outbound|443|v2|reviews.default.svc.cluster.local",
+		"outbound|9080|v1|reviews.default.svc.cluster.local",
+		"outbound|443||ratings.default.svc.cluster.local",
+	}
+	tests := []struct {
+		desc    string
+		pattern string
+		// expect are the indexes of the names matching the pattern.
+		expect []int
+	}{
+		{desc: "empty", pattern: "", expect: []int{0, 1, 2, 3, 4}},
+		{desc: "exact", pattern: "outbound|443||ratings.default.svc.cluster.local", expect: []int{4}},
+		{desc: "glob", pattern: "outbound|443|*reviews*", expect: []int{0, 1, 2}},
+		{desc: "glob single character", pattern: "outbound|443|v?|*", expect: []int{1, 2}},
+		{desc: "glob matches the whole name", pattern: "reviews*", expect: nil},
+		{desc: "glob quotes regexp characters", pattern: "outbound|443||reviews.default.svc.cluster.loca.", expect: nil},
+		{desc: "regex", pattern: `/\|v[12]\|/`, expect: []int{1, 2, 3}},
+		{desc: "anchored regex", pattern: `/^outbound\|9080\|/`, expect: []int{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if err := ValidateNamePattern(tt.pattern); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := map[int]bool{}
+			for _, i := range tt.expect {
+				want[i] = true
+			}
+			cf := &ClusterFilter{Name: tt.pattern}
+			rf := &RouteFilter{Name: tt.pattern}
+			for i, name := range names {
+				if got := cf.Verify(&cluster.Cluster{Name: name}); got != want[i] {
+					t.Errorf("cluster %s: expect %v got %v", name, want[i], got)
+				}
+				if got := rf.Verify(&route.RouteConfiguration{Name: name}); got != want[i] {
+					t.Errorf("route %s: expect %v got %v", name, want[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateNamePattern(t *testing.T) {
+	if err := ValidateNamePattern("/v[12/"); err == nil {
+		t.Errorf("expected an error for an invalid regular expression")
+	}
+	// A single slash is a glob, not an empty regular expression.
+	if err := ValidateNamePattern("/"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if (&RouteFilter{Name: "/v[12/"}).Verify(&route.RouteConfiguration{Name: "v1"}) {
+		t.Errorf("expected an invalid pattern to match no route")
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/pkg/util/sets"
+)
+
+const (
+	workloadSecretName = "default"
+	rootSecretName     = "ROOTCA"
+)
+
+// peerTrust is what a proxy presents to and trusts from its peers in mTLS: its workload certificate chain, the
+// roots it validates peer certificates against, and the trust domains it accepts from its clients.
+type peerTrust struct {
+	chain        []*x509.Certificate
+	roots        []*x509.Certificate
+	trustDomains sets.Set
+}
+
+// PrintPeerTrust verifies that the proxy and its peer trust each other's certificates in both directions, and
+// prints which link of a certificate chain would fail otherwise. It returns an error if any direction fails.
+func (c *ConfigWriter) PrintPeerTrust(name string, peer *ConfigWriter, peerName string, now time.Time) error {
+	if c.configDump == nil || peer.configDump == nil {
+		return fmt.Errorf("config writer has not been primed")
+	}
+	trust, err := c.peerTrust()
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	peersTrust, err := peer.peerTrust()
+	if err != nil {
+		return fmt.Errorf("%s: %v", peerName, err)
+	}
+
+	failed := false
+	for _, d := range []struct {
+		from, to         string
+		fromTrust, trust *peerTrust
+	}{
+		{name, peerName, trust, peersTrust},
+		{peerName, name, peersTrust, trust},
+	} {
+		problems := verifyPeerTrust(d.fromTrust, d.trust, d.to, now)
+		if len(problems) == 0 {
+			fmt.Fprintf(c.Stdout, "%s -> %s: OK, %s is trusted\n", d.from, d.to, certName(d.fromTrust.chain[0]))
+			continue
+		}
+		failed = true
+		fmt.Fprintf(c.Stdout, "%s -> %s: FAILED\n", d.from, d.to)
+		for _, p := range problems {
+			fmt.Fprintf(c.Stdout, "  %s\n", p)
+		}
+	}
+	if failed {
+		return fmt.Errorf("%s and %s do not trust each other", name, peerName)
+	}
+	return nil
+}
+
+// verifyPeerTrust returns the reasons why a peer would reject the certificate chain of a proxy: a certificate is
+// not valid at the time, is not signed by the next certificate of the chain, or the chain does not end in a root
+// the peer trusts; or the trust domain of the proxy is not accepted by the peer.
+func verifyPeerTrust(from, to *peerTrust, peerName string, now time.Time) []string {
+	if len(from.chain) == 0 {
+		return []string{"no workload certificate"}
+	}
+	var problems []string
+	for i, cert := range from.chain {
+		if now.Before(cert.NotBefore) {
+			problems = append(problems, fmt.Sprintf("link %d: certificate %s is not valid before %s", i, certName(cert), cert.NotBefore.Format(time.RFC3339)))
+		}
+		if now.After(cert.NotAfter) {
+			problems = append(problems, fmt.Sprintf("link %d: certificate %s expired on %s", i, certName(cert), cert.NotAfter.Format(time.RFC3339)))
+		}
+	}
+	chainsToRoot := false
+	for i, cert := range from.chain {
+		if root := signingRoot(cert, to.roots); root != nil {
+			chainsToRoot = true
+			if now.After(root.NotAfter) {
+				problems = append(problems, fmt.Sprintf("link %d: root %s trusted by %s expired on %s",
+					i+1, certName(root), peerName, root.NotAfter.Format(time.RFC3339)))
+			}
+			break
+		}
+		if i == len(from.chain)-1 {
+			problems = append(problems, fmt.Sprintf("link %d: certificate %s, issued by %s, is not signed by any of the %d roots trusted by %s",
+				i, certName(cert), issuerName(cert), len(to.roots), peerName))
+			break
+		}
+		if err := cert.CheckSignatureFrom(from.chain[i+1]); err != nil {
+			problems = append(problems, fmt.Sprintf("link %d: certificate %s is not signed by the next certificate of the chain, %s: %v",
+				i, certName(cert), certName(from.chain[i+1]), err))
+			break
+		}
+	}
+	if !chainsToRoot && len(to.roots) == 0 {
+		problems = append(problems, fmt.Sprintf("%s trusts no root", peerName))
+	}
+
+	if td := trustDomain(from.chain[0]); td != "" && !to.trustDomains.IsEmpty() && !to.trustDomains.Contains(td) {
+		problems = append(problems, fmt.Sprintf("trust domain %q is not accepted by %s, which accepts %s",
+			td, peerName, strings.Join(to.trustDomains.SortedList(), ", ")))
+	}
+	return problems
+}
+
+// signingRoot returns the root which is, or signed, the certificate.
+func signingRoot(cert *x509.Certificate, roots []*x509.Certificate) *x509.Certificate {
+	for _, root := range roots {
+		if bytes.Equal(cert.Raw, root.Raw) {
+			return root
+		}
+	}
+	for _, root := range roots {
+		if cert.CheckSignatureFrom(root) == nil {
+			return root
+		}
+	}
+	return nil
+}
+
+// peerTrust returns the workload certificate chain and the roots from the secrets of the proxy, and the trust
+// domains its inbound mTLS filter chains accept.
+func (c *ConfigWriter) peerTrust() (*peerTrust, error) {
+	secretDump, err := c.configDump.GetSecretConfigDump()
+	if err != nil {
+		return nil, fmt.Errorf("sidecar doesn't support secrets: %v", err)
+	}
+	trust := &peerTrust{trustDomains: sets.New()}
+	for _, s := range secretDump.DynamicActiveSecrets {
+		if s.Name != workloadSecretName && s.Name != rootSecretName {
+			continue
+		}
+		secret := &tls.Secret{}
+		if err := s.GetSecret().UnmarshalTo(secret); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal secret %s: %v", s.Name, err)
+		}
+		if s.Name == workloadSecretName {
+			trust.chain, err = parseCertificates(secret.GetTlsCertificate().GetCertificateChain().GetInlineBytes())
+		} else {
+			trust.roots, err = parseCertificates(secret.GetValidationContext().GetTrustedCa().GetInlineBytes())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse secret %s: %v", s.Name, err)
+		}
+	}
+
+	listenerDump, err := c.configDump.GetDynamicListenerDump(true)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range listenerDump.DynamicListeners {
+		if l.ActiveState == nil || l.ActiveState.Listener == nil {
+			continue
+		}
+		l.ActiveState.Listener.TypeUrl = v3.ListenerType
+		listenerTyped := &listener.Listener{}
+		if err := l.ActiveState.Listener.UnmarshalTo(listenerTyped); err != nil {
+			return nil, err
+		}
+		for _, fc := range listenerTyped.FilterChains {
+			ts := fc.GetTransportSocket().GetTypedConfig()
+			if ts == nil || ts.TypeUrl != "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.DownstreamTlsContext" {
+				continue
+			}
+			tlsContext := &tls.DownstreamTlsContext{}
+			if err := ts.UnmarshalTo(tlsContext); err != nil {
+				continue
+			}
+			validation := tlsContext.GetCommonTlsContext().GetCombinedValidationContext().GetDefaultValidationContext()
+			for _, san := range validation.GetMatchSubjectAltNames() {
+				if td := strings.TrimPrefix(san.GetPrefix(), spiffe.URIPrefix); td != san.GetPrefix() {
+					trust.trustDomains.Insert(strings.TrimSuffix(td, "/"))
+				}
+			}
+		}
+	}
+	return trust, nil
+}
+
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+}
+
+// trustDomain returns the trust domain of the SPIFFE identity of the certificate, if it has one.
+func trustDomain(cert *x509.Certificate) string {
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "spiffe" {
+			return uri.Host
+		}
+	}
+	return ""
+}
+
+// certName names a certificate by its subject, or its URI SANs for workload certificates without subject.
+func certName(cert *x509.Certificate) string {
+	if s := cert.Subject.String(); s != "" {
+		return fmt.Sprintf("%q", s)
+	}
+	var uris []string
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+	sort.Strings(uris)
+	return fmt.Sprintf("%q", strings.Join(uris, ","))
+}
+
+func issuerName(cert *x509.Certificate) string {
+	return fmt.Sprintf("%q", cert.Issuer.String())
+}
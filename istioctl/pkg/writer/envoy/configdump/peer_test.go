@@ -0,0 +1,137 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/util/sets"
+)
+
+func TestVerifyPeerTrust(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	newCert := func(name, uri string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, notAfter time.Time) (*x509.Certificate, *ecdsa.PrivateKey) {
+		t.Helper()
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(now.UnixNano()),
+			NotBefore:             now.Add(-time.Hour),
+			NotAfter:              notAfter,
+			IsCA:                  uri == "",
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		}
+		if name != "" {
+			template.Subject = pkix.Name{Organization: []string{name}}
+		}
+		if uri != "" {
+			u, _ := url.Parse(uri)
+			template.URIs = []*url.URL{u}
+		}
+		if parent == nil {
+			parent, parentKey = template, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert, key
+	}
+	valid := now.Add(time.Hour)
+	rootA, rootAKey := newCert("root-a", "", nil, nil, valid)
+	intermediate, intermediateKey := newCert("intermediate-a", "", rootA, rootAKey, valid)
+	rootB, rootBKey := newCert("root-b", "", nil, nil, valid)
+	leafA, _ := newCert("", "spiffe://cluster.local/ns/a/sa/a", intermediate, intermediateKey, valid)
+	leafB, _ := newCert("", "spiffe://other.local/ns/b/sa/b", rootB, rootBKey, valid)
+	expiredLeaf, _ := newCert("", "spiffe://cluster.local/ns/a/sa/a", intermediate, intermediateKey, now.Add(-time.Minute))
+	foreignLeaf, _ := newCert("", "spiffe://cluster.local/ns/a/sa/a", rootB, rootBKey, valid)
+
+	cases := []struct {
+		name string
+		from *peerTrust
+		to   *peerTrust
+		want []string
+	}{
+		{
+			name: "trusted through intermediate",
+			from: &peerTrust{chain: []*x509.Certificate{leafA, intermediate}},
+			to:   &peerTrust{roots: []*x509.Certificate{rootA}, trustDomains: sets.New("cluster.local")},
+		},
+		{
+			name: "trust domain alias",
+			from: &peerTrust{chain: []*x509.Certificate{leafB}},
+			to:   &peerTrust{roots: []*x509.Certificate{rootA, rootB}, trustDomains: sets.New("cluster.local", "other.local")},
+		},
+		{
+			name: "untrusted root",
+			from: &peerTrust{chain: []*x509.Certificate{leafA, intermediate}},
+			to:   &peerTrust{roots: []*x509.Certificate{rootB}, trustDomains: sets.New()},
+			want: []string{`link 1: certificate "O=intermediate-a", issued by "O=root-a", is not signed by any of the 1 roots trusted by peer`},
+		},
+		{
+			name: "expired leaf",
+			from: &peerTrust{chain: []*x509.Certificate{expiredLeaf, intermediate}},
+			to:   &peerTrust{roots: []*x509.Certificate{rootA}, trustDomains: sets.New()},
+			want: []string{`link 0: certificate "spiffe://cluster.local/ns/a/sa/a" expired on 2022-12-31T23:59:00Z`},
+		},
+		{
+			name: "broken chain",
+			from: &peerTrust{chain: []*x509.Certificate{foreignLeaf, intermediate}},
+			to:   &peerTrust{roots: []*x509.Certificate{rootA}, trustDomains: sets.New()},
+			want: []string{`link 0: certificate "spiffe://cluster.local/ns/a/sa/a" is not signed by the next certificate of the chain, "O=intermediate-a"`},
+		},
+		{
+			name: "trust domain not accepted",
+			from: &peerTrust{chain: []*x509.Certificate{leafB}},
+			to:   &peerTrust{roots: []*x509.Certificate{rootB}, trustDomains: sets.New("cluster.local")},
+			want: []string{`trust domain "other.local" is not accepted by peer, which accepts cluster.local`},
+		},
+		{
+			name: "no workload certificate",
+			from: &peerTrust{},
+			to:   &peerTrust{roots: []*x509.Certificate{rootA}, trustDomains: sets.New()},
+			want: []string{"no workload certificate"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := verifyPeerTrust(tt.from, tt.to, "peer", now)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got problems %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !strings.HasPrefix(got[i], tt.want[i]) {
+					t.Errorf("got problem %q, want %q", got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
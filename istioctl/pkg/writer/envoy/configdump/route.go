@@ -17,10 +17,12 @@ package configdump
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
@@ -30,20 +32,23 @@ import (
 	pilot_util "istio.io/istio/pilot/pkg/networking/util"
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/pkg/util/strcase"
 )
 
 // RouteFilter is used to pass filter information into route based config writer print functions
 type RouteFilter struct {
+	// Name selects the routes by name, with a pattern as described in ValidateNamePattern.
 	Name    string
 	Verbose bool
+	// UpdatedSince selects only the routes last updated at or after this time, if set.
+	UpdatedSince time.Time
+
+	nameRE *regexp.Regexp
 }
 
 // Verify returns true if the passed route matches the filter fields
 func (r *RouteFilter) Verify(route *route.RouteConfiguration) bool {
-	if r.Name != "" && r.Name != route.Name {
-		return false
-	}
-	return true
+	return matchName(r.Name, &r.nameRE, route.Name)
 }
 
 // PrintRouteSummary prints a summary of the relevant routes in the config dump to the ConfigWriter stdout
@@ -52,26 +57,31 @@ func (c *ConfigWriter) PrintRouteSummary(filter RouteFilter) error {
 	if err != nil {
 		return err
 	}
+	updated, err := updatedSinceFilter(filter.UpdatedSince, c.routeLastUpdated)
+	if err != nil {
+		return err
+	}
 	if filter.Verbose {
-		fmt.Fprintln(w, "NAME\tDOMAINS\tMATCH\tVIRTUAL SERVICE")
+		fmt.Fprintln(w, "NAME\tDOMAINS\tMATCH\tVIRTUAL SERVICE\tCONFIG SOURCE")
 	} else {
 		fmt.Fprintln(w, "NAME\tVIRTUAL HOSTS")
 	}
 	for _, route := range routes {
-		if filter.Verify(route) {
+		if filter.Verify(route) && updated(route.Name) {
 			if filter.Verbose {
 				for _, vhosts := range route.GetVirtualHosts() {
 					for _, r := range vhosts.Routes {
 						if !isPassthrough(r.GetAction()) {
-							fmt.Fprintf(w, "%v\t%s\t%s\t%s\n",
+							fmt.Fprintf(w, "%v\t%s\t%s\t%s\t%s\n",
 								route.Name,
 								describeRouteDomains(vhosts.GetDomains()),
 								describeMatch(r.GetMatch()),
-								describeManagement(r.GetMetadata()))
+								describeManagement(r.GetMetadata()),
+								describeConfigSource(r.GetMetadata()))
 						}
 					}
 					if len(vhosts.Routes) == 0 {
-						fmt.Fprintf(w, "%v\t%s\t%s\t%s\n",
+						fmt.Fprintf(w, "%v\t%s\t%s\t%s\t\n",
 							route.Name,
 							describeRouteDomains(vhosts.GetDomains()),
 							"/*",
@@ -140,18 +150,11 @@ func unexpandDomains(domains []string) []string {
 }
 
 func describeManagement(metadata *envoy_config_core_v3.Metadata) string {
-	if metadata == nil {
-		return ""
-	}
-	istioMetadata, ok := metadata.FilterMetadata[pilot_util.IstioMetadataKey]
+	configPath, ok := istioConfigPath(metadata)
 	if !ok {
 		return ""
 	}
-	config, ok := istioMetadata.Fields["config"]
-	if !ok {
-		return ""
-	}
-	return renderConfig(config.GetStringValue())
+	return renderConfig(configPath)
 }
 
 func renderConfig(configPath string) string {
@@ -165,18 +168,64 @@ func renderConfig(configPath string) string {
 	return "<unknown>"
 }
 
+// describeConfigSource returns the Istio config an Envoy resource is generated from, according to its metadata, as
+// Kind/name.namespace such as VirtualService/reviews.default, or an empty string if it is unknown.
+func describeConfigSource(metadata *envoy_config_core_v3.Metadata) string {
+	configPath, ok := istioConfigPath(metadata)
+	if !ok {
+		return ""
+	}
+	return renderConfigSource(configPath)
+}
+
+// istioConfigPath returns the path of the Istio config in the metadata of an Envoy resource, if it has one.
+func istioConfigPath(metadata *envoy_config_core_v3.Metadata) (string, bool) {
+	if metadata == nil {
+		return "", false
+	}
+	istioMetadata, ok := metadata.FilterMetadata[pilot_util.IstioMetadataKey]
+	if !ok {
+		return "", false
+	}
+	config, ok := istioMetadata.Fields["config"]
+	if !ok {
+		return "", false
+	}
+	return config.GetStringValue(), true
+}
+
+// renderConfigSource renders a config path, /apis/<group>/<version>/namespaces/<namespace>/<kebab-case kind>/<name>,
+// as Kind/name.namespace.
+func renderConfigSource(configPath string) string {
+	pieces := strings.Split(configPath, "/")
+	if len(pieces) != 8 || pieces[1] != "apis" || pieces[4] != "namespaces" {
+		return "<unknown>"
+	}
+	return fmt.Sprintf("%s/%s.%s", strcase.CamelCase(pieces[6]), pieces[7], pieces[5])
+}
+
 // PrintRouteDump prints the relevant routes in the config dump to the ConfigWriter stdout
 func (c *ConfigWriter) PrintRouteDump(filter RouteFilter, outputFormat string) error {
 	_, routes, err := c.setupRouteConfigWriter()
 	if err != nil {
 		return err
 	}
+	updated, err := updatedSinceFilter(filter.UpdatedSince, c.routeLastUpdated)
+	if err != nil {
+		return err
+	}
 	filteredRoutes := make(protio.MessageSlice, 0, len(routes))
 	for _, route := range routes {
-		if filter.Verify(route) {
+		if filter.Verify(route) && updated(route.Name) {
 			filteredRoutes = append(filteredRoutes, route)
 		}
 	}
+	if outputFormat == "proto" {
+		return c.printProto(filteredRoutes)
+	}
+	if strings.HasPrefix(outputFormat, customColumnsPrefix) {
+		return c.printCustomColumns(filteredRoutes, outputFormat)
+	}
 	out, err := json.MarshalIndent(filteredRoutes, "", "    ")
 	if err != nil {
 		return err
@@ -249,6 +298,28 @@ func (c *ConfigWriter) retrieveSortedRouteSlice() ([]*route.RouteConfiguration,
 	return routes, nil
 }
 
+// routeLastUpdated returns the last update times of the routes in the config dump by name.
+func (c *ConfigWriter) routeLastUpdated() (map[string]time.Time, error) {
+	routeDump, err := c.configDump.GetRouteConfigDump()
+	if err != nil {
+		return nil, err
+	}
+	lastUpdated := map[string]time.Time{}
+	for _, r := range routeDump.DynamicRouteConfigs {
+		routeTyped := &route.RouteConfiguration{}
+		if r.RouteConfig != nil && r.LastUpdated != nil && r.RouteConfig.UnmarshalTo(routeTyped) == nil {
+			lastUpdated[routeTyped.Name] = r.LastUpdated.AsTime()
+		}
+	}
+	for _, r := range routeDump.StaticRouteConfigs {
+		routeTyped := &route.RouteConfiguration{}
+		if r.RouteConfig != nil && r.LastUpdated != nil && r.RouteConfig.UnmarshalTo(routeTyped) == nil {
+			lastUpdated[routeTyped.Name] = r.LastUpdated.AsTime()
+		}
+	}
+	return lastUpdated, nil
+}
+
 func isPassthrough(action any) bool {
 	a, ok := action.(*route.Route_Route)
 	if !ok {
@@ -16,6 +16,11 @@ package configdump
 
 import (
 	"testing"
+
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pilot_util "istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pkg/config"
 )
 
 func TestDescribeRouteDomains(t *testing.T) {
@@ -64,3 +69,53 @@ func TestDescribeRouteDomains(t *testing.T) {
 		})
 	}
 }
+
+func TestDescribeConfigSource(t *testing.T) {
+	tests := []struct {
+		desc     string
+		config   string
+		expected string
+		// management is the name.namespace of the DESTINATION RULE and VIRTUAL SERVICE columns.
+		management string
+	}{
+		{
+			desc:       "virtual service",
+			config:     "/apis/networking.istio.io/v1alpha3/namespaces/default/virtual-service/reviews",
+			expected:   "VirtualService/reviews.default",
+			management: "reviews.default",
+		},
+		{
+			desc:       "destination rule",
+			config:     "/apis/networking.istio.io/v1alpha3/namespaces/default/destination-rule/reviews",
+			expected:   "DestinationRule/reviews.default",
+			management: "reviews.default",
+		},
+		{
+			desc:       "gateway",
+			config:     "/apis/networking.istio.io/v1alpha3/namespaces/istio-system/gateway/ingress",
+			expected:   "Gateway/ingress.istio-system",
+			management: "ingress.istio-system",
+		},
+		{
+			desc:       "invalid path",
+			config:     "reviews.default",
+			expected:   "<unknown>",
+			management: "<unknown>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			metadata := pilot_util.AddConfigInfoMetadata(nil, config.Meta{})
+			metadata.FilterMetadata[pilot_util.IstioMetadataKey].Fields["config"] = structpb.NewStringValue(tt.config)
+			if got := describeConfigSource(metadata); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+			if got := describeManagement(metadata); got != tt.management {
+				t.Errorf("expected management %q, got %q", tt.management, got)
+			}
+		})
+	}
+	if got := describeConfigSource(nil); got != "" {
+		t.Errorf("expected no config source without metadata, got %q", got)
+	}
+}
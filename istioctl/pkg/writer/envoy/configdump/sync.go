@@ -0,0 +1,144 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+)
+
+// Sync statuses of an xDS type, as shown by PrintSyncStatus.
+const (
+	SyncStatusSynced  = "SYNCED"
+	SyncStatusStale   = "STALE"
+	SyncStatusNotSent = "NOT SENT"
+)
+
+// syncTypes are the xDS types PrintSyncStatus compares, in the order of the proxy-status columns.
+var syncTypes = []struct {
+	name    string
+	typeURL string
+}{
+	{"CDS", v3.ClusterType},
+	{"LDS", v3.ListenerType},
+	{"EDS", v3.EndpointType},
+	{"RDS", v3.RouteType},
+	{"ECDS", v3.ExtensionConfigurationType},
+}
+
+// syncStatus returns whether the proxy accepted the version of the configuration of a type generated from the
+// current push context of istiod, given the version istiod last sent to it.
+func syncStatus(accepted, sent, current string) string {
+	switch {
+	case sent == "":
+		return SyncStatusNotSent
+	case accepted == current:
+		return SyncStatusSynced
+	default:
+		return SyncStatusStale
+	}
+}
+
+// acceptedVersions returns the version of the configuration of each xDS type the proxy last accepted, by type URL.
+// The endpoints are only included if the config dump has them.
+func (c *ConfigWriter) acceptedVersions() (map[string]string, error) {
+	versions := map[string]string{}
+
+	clusterDump, err := c.configDump.GetClusterConfigDump()
+	if err != nil {
+		return nil, err
+	}
+	clusters := briefSummary{version: clusterDump.VersionInfo}
+	for _, dc := range clusterDump.DynamicActiveClusters {
+		clusters.addDynamic(dc.VersionInfo, dc.LastUpdated)
+	}
+	versions[v3.ClusterType] = clusters.acceptedVersion()
+
+	listenerDump, err := c.configDump.GetListenerConfigDump()
+	if err != nil {
+		return nil, err
+	}
+	listeners := briefSummary{version: listenerDump.VersionInfo}
+	for _, dl := range listenerDump.DynamicListeners {
+		if dl.ActiveState != nil {
+			listeners.addDynamic(dl.ActiveState.VersionInfo, dl.ActiveState.LastUpdated)
+		}
+	}
+	versions[v3.ListenerType] = listeners.acceptedVersion()
+
+	routeDump, err := c.configDump.GetRouteConfigDump()
+	if err != nil {
+		return nil, err
+	}
+	routes := briefSummary{}
+	for _, dr := range routeDump.DynamicRouteConfigs {
+		routes.addDynamic(dr.VersionInfo, dr.LastUpdated)
+	}
+	versions[v3.RouteType] = routes.acceptedVersion()
+
+	if endpointDump, err := c.configDump.GetEndpointsConfigDump(); err == nil {
+		endpoints := briefSummary{}
+		for _, de := range endpointDump.DynamicEndpointConfigs {
+			endpoints.addDynamic(de.VersionInfo, de.LastUpdated)
+		}
+		versions[v3.EndpointType] = endpoints.acceptedVersion()
+	}
+
+	if ecdsDump, err := c.configDump.GetEcdsConfigDump(); err == nil {
+		extensions := briefSummary{}
+		for _, f := range ecdsDump.EcdsFilters {
+			// The ECDS section is decoded from JSON, where the time of the last update is a string.
+			if updated, err := time.Parse(time.RFC3339Nano, f.LastUpdated); err == nil {
+				extensions.addDynamic(f.VersionInfo, timestamppb.New(updated))
+			}
+		}
+		versions[v3.ExtensionConfigurationType] = extensions.acceptedVersion()
+	}
+	return versions, nil
+}
+
+// PrintSyncStatus prints, for each xDS type, the version of the configuration the proxy last accepted, from the
+// config dump, next to the version of the current push context of istiod, and whether the proxy is SYNCED or STALE.
+// The types istiod never sent to the proxy, per sent by type URL, are NOT SENT. Unlike proxy-status, which compares
+// the nonces istiod sent and the proxy acknowledged, this shows whether the proxy runs with the latest configuration.
+// Routes and endpoints have no version of their own type, so the version of the last updated resource is used, which
+// stays at the previous version if a push did not change it, as does every type of a proxy a push skipped. The
+// endpoints are only compared if the config dump has them.
+func (c *ConfigWriter) PrintSyncStatus(current string, sent map[string]string) error {
+	if c.configDump == nil {
+		return fmt.Errorf("config writer has not been primed")
+	}
+	accepted, err := c.acceptedVersions()
+	if err != nil {
+		return err
+	}
+
+	w := new(tabwriter.Writer).Init(c.Stdout, 0, 8, 5, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tPROXY VERSION\tISTIOD VERSION\tSTATUS")
+	for _, t := range syncTypes {
+		proxyVersion, f := accepted[t.typeURL]
+		if !f {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.name, valueOrNA(proxyVersion), valueOrNA(current),
+			syncStatus(proxyVersion, sent[t.typeURL], current))
+	}
+	return w.Flush()
+}
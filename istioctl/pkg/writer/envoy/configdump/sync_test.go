@@ -0,0 +1,104 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+func TestConfigWriter_PrintSyncStatus(t *testing.T) {
+	anyOf := func(m proto.Message) *anypb.Any {
+		a, err := anypb.New(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	first := timestamppb.New(time.Date(2023, 1, 1, 0, 1, 0, 0, time.UTC))
+	last := timestamppb.New(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	clusters := &admin.ClustersConfigDump{
+		VersionInfo: "2023-01-02T00:00:00Z/7",
+		DynamicActiveClusters: []*admin.ClustersConfigDump_DynamicCluster{
+			{VersionInfo: "2023-01-02T00:00:00Z/7", Cluster: anyOf(&cluster.Cluster{Name: "a"}), LastUpdated: last},
+		},
+	}
+	listeners := &admin.ListenersConfigDump{
+		VersionInfo: "2023-01-01T00:01:00Z/1",
+		DynamicListeners: []*admin.ListenersConfigDump_DynamicListener{
+			{Name: "virtualOutbound", ActiveState: &admin.ListenersConfigDump_DynamicListenerState{
+				VersionInfo: "2023-01-01T00:01:00Z/1", Listener: anyOf(&listener.Listener{Name: "virtualOutbound"}), LastUpdated: first,
+			}},
+		},
+	}
+	routes := &admin.RoutesConfigDump{
+		DynamicRouteConfigs: []*admin.RoutesConfigDump_DynamicRouteConfig{
+			{VersionInfo: "2023-01-02T00:00:00Z/7", RouteConfig: anyOf(&route.RouteConfiguration{Name: "80"}), LastUpdated: last},
+			{VersionInfo: "2023-01-01T00:01:00Z/1", RouteConfig: anyOf(&route.RouteConfiguration{Name: "8080"}), LastUpdated: first},
+		},
+	}
+	endpoints := &admin.EndpointsConfigDump{
+		DynamicEndpointConfigs: []*admin.EndpointsConfigDump_DynamicEndpointConfig{
+			{
+				VersionInfo:    "2023-01-01T00:01:00Z/1",
+				EndpointConfig: anyOf(&endpoint.ClusterLoadAssignment{ClusterName: "a"}),
+				LastUpdated:    first,
+			},
+		},
+	}
+
+	b, err := protomarshal.Marshal(&admin.ConfigDump{
+		Configs: []*anypb.Any{anyOf(clusters), anyOf(listeners), anyOf(routes), anyOf(endpoints)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotOut := &bytes.Buffer{}
+	cw := &ConfigWriter{Stdout: gotOut}
+	assert.Error(t, cw.PrintSyncStatus("", nil))
+	if err := cw.Prime(b); err != nil {
+		t.Fatal(err)
+	}
+	// The listeners were last sent at an older push, which the proxy did not accept yet.
+	assert.NoError(t, cw.PrintSyncStatus("2023-01-02T00:00:00Z/7", map[string]string{
+		v3.ClusterType:  "2023-01-02T00:00:00Z/7",
+		v3.ListenerType: "2023-01-01T12:00:00Z/4",
+		v3.RouteType:    "2023-01-02T00:00:00Z/7",
+	}))
+	want := []string{
+		"TYPE     PROXY VERSION              ISTIOD VERSION             STATUS",
+		"CDS      2023-01-02T00:00:00Z/7     2023-01-02T00:00:00Z/7     SYNCED",
+		"LDS      2023-01-01T00:01:00Z/1     2023-01-02T00:00:00Z/7     STALE",
+		"EDS      2023-01-01T00:01:00Z/1     2023-01-02T00:00:00Z/7     NOT SENT",
+		"RDS      2023-01-02T00:00:00Z/7     2023-01-02T00:00:00Z/7     SYNCED",
+	}
+	assert.Equal(t, strings.Split(strings.TrimSpace(gotOut.String()), "\n"), want)
+}
@@ -0,0 +1,359 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	httpConn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	tcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/host"
+)
+
+// traceDestination is a cluster a route or a TCP proxy sends traffic to, with its weight.
+type traceDestination struct {
+	cluster string
+	weight  uint32
+}
+
+// PrintTrace prints how the proxy routes the traffic to the hostname and port: the listeners and filter chains
+// receiving it, the routes of the virtual host matching the hostname, with their weights, timeouts and retry
+// policies, and the clusters they send it to, with their TLS settings and endpoints. The endpoints of EDS clusters
+// are only known if the config dump includes them.
+func (c *ConfigWriter) PrintTrace(hostname string, port uint32) error {
+	listeners, err := c.retrieveSortedListenerSlice()
+	if err != nil {
+		return err
+	}
+	// A proxy may have no routes, if it only has TCP services, or no EDS clusters.
+	routes, _ := c.retrieveSortedRouteSlice()
+	routesByName := map[string]*route.RouteConfiguration{}
+	for _, r := range routes {
+		routesByName[r.Name] = r
+	}
+	clusters, err := c.retrieveSortedClusterSlice()
+	if err != nil {
+		return err
+	}
+	clustersByName := map[string]*cluster.Cluster{}
+	for _, cl := range clusters {
+		clustersByName[cl.Name] = cl
+	}
+	endpoints, _ := c.retrieveSortedEndpointsSlice(EndpointFilter{})
+	endpointsByName := map[string]*endpoint.ClusterLoadAssignment{}
+	for _, cla := range endpoints {
+		endpointsByName[cla.ClusterName] = cla
+	}
+
+	var traced []string
+	seen := map[string]bool{}
+	addCluster := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			traced = append(traced, name)
+		}
+	}
+	for _, l := range listeners {
+		if retrieveListenerPort(l) != port || l.Name == model.VirtualInboundListenerName || l.Name == model.VirtualOutboundListenerName {
+			continue
+		}
+		for _, fc := range getFilterChains(l) {
+			if !serverNamesMatch(fc.GetFilterChainMatch().GetServerNames(), hostname) {
+				continue
+			}
+			match := retrieveListenerMatches([]*listener.FilterChain{fc})[0].match
+			if rc, rcName := httpRouteConfig(fc, routesByName); rcName != "" {
+				vh := matchVirtualHost(rc, hostname, port)
+				if vh == nil {
+					continue
+				}
+				fmt.Fprintf(c.Stdout, "Listener %s, filter chain %s\n", l.Name, match)
+				fmt.Fprintf(c.Stdout, "  Route configuration %s, virtual host %s\n", rcName, vh.Name)
+				for _, r := range vh.GetRoutes() {
+					fmt.Fprintf(c.Stdout, "    Route %s\n", describeTraceRoute(r))
+					if r.GetRoute() == nil {
+						continue
+					}
+					for _, d := range routeDestinations(r.GetRoute()) {
+						fmt.Fprintf(c.Stdout, "      -> %s (weight %d)\n", d.cluster, d.weight)
+						addCluster(d.cluster)
+					}
+					if timeout := r.GetRoute().GetTimeout(); timeout != nil {
+						fmt.Fprintf(c.Stdout, "      Timeout: %v\n", timeout.AsDuration())
+					}
+					retries := r.GetRoute().GetRetryPolicy()
+					if retries == nil {
+						retries = vh.GetRetryPolicy()
+					}
+					fmt.Fprintf(c.Stdout, "      Retries: %s\n", describeRetryPolicy(retries))
+				}
+				continue
+			}
+			destinations := tcpDestinations(fc)
+			if !destinationsInclude(destinations, hostname) {
+				continue
+			}
+			fmt.Fprintf(c.Stdout, "Listener %s, filter chain %s\n", l.Name, match)
+			fmt.Fprintf(c.Stdout, "  TCP proxy\n")
+			for _, d := range destinations {
+				fmt.Fprintf(c.Stdout, "    -> %s (weight %d)\n", d.cluster, d.weight)
+				addCluster(d.cluster)
+			}
+		}
+	}
+	if len(traced) == 0 {
+		return fmt.Errorf("no listener routes the traffic to %s:%d", hostname, port)
+	}
+
+	for _, name := range traced {
+		fmt.Fprintf(c.Stdout, "\nCluster %s\n", name)
+		cl, f := clustersByName[name]
+		if !f {
+			fmt.Fprintf(c.Stdout, "  Not found in the config dump\n")
+			continue
+		}
+		fmt.Fprintf(c.Stdout, "  Discovery: %s, load balancing: %s\n", describeDiscoveryType(cl), cl.GetLbPolicy())
+		fmt.Fprintf(c.Stdout, "  TLS: %s\n", describeClusterTLS(cl))
+		cla := cl.GetLoadAssignment()
+		if cl.GetType() == cluster.Cluster_EDS {
+			serviceName := cl.GetEdsClusterConfig().GetServiceName()
+			if serviceName == "" {
+				serviceName = cl.Name
+			}
+			cla = endpointsByName[serviceName]
+			if cla == nil && endpoints == nil {
+				fmt.Fprintf(c.Stdout, "  Endpoints: not in the config dump\n")
+				continue
+			}
+		}
+		c.printTraceEndpoints(cla)
+	}
+	return nil
+}
+
+func (c *ConfigWriter) printTraceEndpoints(cla *endpoint.ClusterLoadAssignment) {
+	count := 0
+	for _, llb := range cla.GetEndpoints() {
+		count += len(llb.GetLbEndpoints())
+	}
+	if count == 0 {
+		fmt.Fprintf(c.Stdout, "  Endpoints: none\n")
+		return
+	}
+	fmt.Fprintf(c.Stdout, "  Endpoints:\n")
+	for _, llb := range cla.GetEndpoints() {
+		locality := ""
+		if l := llb.GetLocality(); l != nil {
+			locality = ", locality " + strings.TrimRight(l.Region+"/"+l.Zone+"/"+l.SubZone, "/")
+		}
+		for _, ep := range llb.GetLbEndpoints() {
+			weight := uint32(1)
+			if ep.GetLoadBalancingWeight() != nil {
+				weight = ep.GetLoadBalancingWeight().GetValue()
+			}
+			fmt.Fprintf(c.Stdout, "    %s %s, weight %d, priority %d%s\n", retrieveEndpointAddress(ep), retrieveEndpointStatus(ep),
+				weight, llb.GetPriority(), locality)
+		}
+	}
+}
+
+// serverNamesMatch returns true if the filter chain matching the server names receives the traffic to the hostname.
+func serverNamesMatch(serverNames []string, hostname string) bool {
+	if len(serverNames) == 0 {
+		return true
+	}
+	for _, sn := range serverNames {
+		if host.Name(hostname).SubsetOf(host.Name(sn)) {
+			return true
+		}
+	}
+	return false
+}
+
+// httpRouteConfig returns the route configuration of the HTTP connection manager of the filter chain and its name,
+// or an empty name if the filter chain is not HTTP.
+func httpRouteConfig(fc *listener.FilterChain, routes map[string]*route.RouteConfiguration) (*route.RouteConfiguration, string) {
+	for _, filter := range fc.GetFilters() {
+		if filter.Name != HTTPListener {
+			continue
+		}
+		hcm := &httpConn.HttpConnectionManager{}
+		// Allow Unmarshal to work even if Envoy and istioctl are different
+		filter.GetTypedConfig().TypeUrl = "type.googleapis.com/envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager"
+		if err := filter.GetTypedConfig().UnmarshalTo(hcm); err != nil {
+			return nil, ""
+		}
+		if rc := hcm.GetRouteConfig(); rc != nil {
+			return rc, "(inline)"
+		}
+		name := hcm.GetRds().GetRouteConfigName()
+		return routes[name], name
+	}
+	return nil, ""
+}
+
+// tcpDestinations returns the clusters the TCP proxy of the filter chain sends traffic to.
+func tcpDestinations(fc *listener.FilterChain) []traceDestination {
+	for _, filter := range fc.GetFilters() {
+		if filter.Name != TCPListener {
+			continue
+		}
+		proxy := &tcp.TcpProxy{}
+		// Allow Unmarshal to work even if Envoy and istioctl are different
+		filter.GetTypedConfig().TypeUrl = "type.googleapis.com/envoy.extensions.filters.network.tcp_proxy.v3.TcpProxy"
+		if err := filter.GetTypedConfig().UnmarshalTo(proxy); err != nil {
+			return nil
+		}
+		if proxy.GetCluster() != "" {
+			return []traceDestination{{cluster: proxy.GetCluster(), weight: 100}}
+		}
+		var out []traceDestination
+		for _, wc := range proxy.GetWeightedClusters().GetClusters() {
+			out = append(out, traceDestination{cluster: wc.Name, weight: wc.Weight})
+		}
+		return out
+	}
+	return nil
+}
+
+// destinationsInclude returns true if one of the destinations is a cluster of the hostname.
+func destinationsInclude(destinations []traceDestination, hostname string) bool {
+	for _, d := range destinations {
+		if _, _, h, _ := safelyParseSubsetKey(d.cluster); h == host.Name(hostname) {
+			return true
+		}
+	}
+	return false
+}
+
+func routeDestinations(action *route.RouteAction) []traceDestination {
+	if action.GetCluster() != "" {
+		return []traceDestination{{cluster: action.GetCluster(), weight: 100}}
+	}
+	var out []traceDestination
+	for _, wc := range action.GetWeightedClusters().GetClusters() {
+		out = append(out, traceDestination{cluster: wc.Name, weight: wc.GetWeight().GetValue()})
+	}
+	return out
+}
+
+// matchVirtualHost returns the virtual host of the route configuration Envoy selects for the hostname, with or
+// without the port: an exact domain first, then the longest suffix wildcard, the longest prefix wildcard and "*".
+func matchVirtualHost(rc *route.RouteConfiguration, hostname string, port uint32) *route.VirtualHost {
+	candidates := []string{hostname, hostname + ":" + strconv.Itoa(int(port))}
+	var best *route.VirtualHost
+	bestRank := -1
+	for _, vh := range rc.GetVirtualHosts() {
+		for _, domain := range vh.GetDomains() {
+			for _, candidate := range candidates {
+				rank := -1
+				switch {
+				case domain == candidate:
+					rank = 3000
+				case domain == "*":
+					rank = 0
+				case strings.HasPrefix(domain, "*") && strings.HasSuffix(candidate, domain[1:]):
+					rank = 2000 + len(domain)
+				case strings.HasSuffix(domain, "*") && strings.HasPrefix(candidate, domain[:len(domain)-1]):
+					rank = 1000 + len(domain)
+				}
+				if rank > bestRank {
+					best, bestRank = vh, rank
+				}
+			}
+		}
+	}
+	return best
+}
+
+func describeTraceRoute(r *route.Route) string {
+	match := describeMatch(r.GetMatch())
+	if len(r.GetMatch().GetHeaders()) > 0 {
+		match += " with headers"
+	}
+	name := r.GetName()
+	if name == "" {
+		name = "-"
+	}
+	switch {
+	case r.GetRedirect() != nil:
+		return fmt.Sprintf("%s (match %s): redirect", name, match)
+	case r.GetDirectResponse() != nil:
+		return fmt.Sprintf("%s (match %s): direct response %d", name, match, r.GetDirectResponse().GetStatus())
+	}
+	return fmt.Sprintf("%s (match %s)", name, match)
+}
+
+func describeRetryPolicy(policy *route.RetryPolicy) string {
+	if policy == nil || policy.GetNumRetries().GetValue() == 0 {
+		return "none"
+	}
+	out := fmt.Sprintf("%d on %s", policy.GetNumRetries().GetValue(), policy.GetRetryOn())
+	if policy.GetPerTryTimeout() != nil {
+		out += fmt.Sprintf(", per try timeout %v", policy.GetPerTryTimeout().AsDuration())
+	}
+	return out
+}
+
+func describeDiscoveryType(cl *cluster.Cluster) string {
+	if cl.GetClusterType() != nil {
+		return cl.GetClusterType().GetName()
+	}
+	return cl.GetType().String()
+}
+
+// describeClusterTLS returns the TLS settings of the connections of the cluster to its endpoints.
+func describeClusterTLS(cl *cluster.Cluster) string {
+	for _, m := range cl.GetTransportSocketMatches() {
+		if m.Name == "tlsMode-istio" {
+			return "ISTIO_MUTUAL to the endpoints supporting it (auto mTLS), plaintext to the others"
+		}
+	}
+	ts := cl.GetTransportSocket()
+	if ts == nil {
+		return "none"
+	}
+	if ts.Name != wellknown.TransportSocketTLS {
+		return ts.Name
+	}
+	ctx := &tls.UpstreamTlsContext{}
+	if err := ts.GetTypedConfig().UnmarshalTo(ctx); err != nil {
+		return err.Error()
+	}
+	mode := "SIMPLE"
+	for _, sds := range ctx.GetCommonTlsContext().GetTlsCertificateSdsSecretConfigs() {
+		mode = "MUTUAL"
+		if sds.GetName() == "default" {
+			mode = "ISTIO_MUTUAL"
+		}
+	}
+	if len(ctx.GetCommonTlsContext().GetTlsCertificates()) > 0 {
+		mode = "MUTUAL"
+	}
+	if ctx.GetSni() != "" {
+		return fmt.Sprintf("%s, SNI %s", mode, ctx.GetSni())
+	}
+	return mode
+}
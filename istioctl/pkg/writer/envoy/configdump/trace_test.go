@@ -0,0 +1,253 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdump
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	httpConn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	tcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+func TestConfigWriter_PrintTrace(t *testing.T) {
+	anyOf := func(m proto.Message) *anypb.Any {
+		a, err := anypb.New(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	address := func(ip string, port uint32) *core.Address {
+		return &core.Address{Address: &core.Address_SocketAddress{SocketAddress: &core.SocketAddress{
+			Address:       ip,
+			PortSpecifier: &core.SocketAddress_PortValue{PortValue: port},
+		}}}
+	}
+	lbEndpoint := func(ip string, port uint32) *endpoint.LbEndpoint {
+		return &endpoint.LbEndpoint{
+			HostIdentifier:      &endpoint.LbEndpoint_Endpoint{Endpoint: &endpoint.Endpoint{Address: address(ip, port)}},
+			HealthStatus:        core.HealthStatus_HEALTHY,
+			LoadBalancingWeight: wrapperspb.UInt32(1),
+		}
+	}
+	eds := func(name string) *cluster.Cluster {
+		return &cluster.Cluster{
+			Name:                 name,
+			ClusterDiscoveryType: &cluster.Cluster_Type{Type: cluster.Cluster_EDS},
+			EdsClusterConfig:     &cluster.Cluster_EdsClusterConfig{ServiceName: name},
+			LbPolicy:             cluster.Cluster_LEAST_REQUEST,
+			TransportSocketMatches: []*cluster.Cluster_TransportSocketMatch{
+				{Name: "tlsMode-istio"},
+				{Name: "tlsMode-disabled"},
+			},
+		}
+	}
+
+	listeners := []*listener.Listener{
+		{
+			Name:    "0.0.0.0_9080",
+			Address: address("0.0.0.0", 9080),
+			FilterChains: []*listener.FilterChain{{
+				Filters: []*listener.Filter{{
+					Name: wellknown.HTTPConnectionManager,
+					ConfigType: &listener.Filter_TypedConfig{TypedConfig: anyOf(&httpConn.HttpConnectionManager{
+						RouteSpecifier: &httpConn.HttpConnectionManager_Rds{Rds: &httpConn.Rds{RouteConfigName: "9080"}},
+					})},
+				}},
+			}},
+		},
+		{
+			Name:    "10.0.0.5_3306",
+			Address: address("10.0.0.5", 3306),
+			FilterChains: []*listener.FilterChain{{
+				Filters: []*listener.Filter{{
+					Name: wellknown.TCPProxy,
+					ConfigType: &listener.Filter_TypedConfig{TypedConfig: anyOf(&tcp.TcpProxy{
+						ClusterSpecifier: &tcp.TcpProxy_Cluster{Cluster: "outbound|3306||mysql.default.svc.cluster.local"},
+					})},
+				}},
+			}},
+		},
+	}
+	routes := []*route.RouteConfiguration{{
+		Name: "9080",
+		VirtualHosts: []*route.VirtualHost{
+			{
+				Name:    "reviews.default.svc.cluster.local:9080",
+				Domains: []string{"reviews.default.svc.cluster.local", "reviews.default.svc.cluster.local:9080", "reviews", "reviews:9080"},
+				Routes: []*route.Route{
+					{
+						Name:  "v2",
+						Match: &route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/v2"}},
+						Action: &route.Route_Route{Route: &route.RouteAction{
+							ClusterSpecifier: &route.RouteAction_Cluster{Cluster: "outbound|9080|v2|reviews.default.svc.cluster.local"},
+							Timeout:          durationpb.New(5 * time.Second),
+						}},
+					},
+					{
+						Name:  "default",
+						Match: &route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"}},
+						Action: &route.Route_Route{Route: &route.RouteAction{
+							ClusterSpecifier: &route.RouteAction_WeightedClusters{WeightedClusters: &route.WeightedCluster{
+								Clusters: []*route.WeightedCluster_ClusterWeight{
+									{Name: "outbound|9080|v1|reviews.default.svc.cluster.local", Weight: wrapperspb.UInt32(90)},
+									{Name: "outbound|9080|v2|reviews.default.svc.cluster.local", Weight: wrapperspb.UInt32(10)},
+								},
+							}},
+							RetryPolicy: &route.RetryPolicy{
+								RetryOn:       "connect-failure,refused-stream,unavailable",
+								NumRetries:    wrapperspb.UInt32(2),
+								PerTryTimeout: durationpb.New(time.Second),
+							},
+						}},
+					},
+				},
+			},
+			{
+				Name:    "allow_any",
+				Domains: []string{"*"},
+				Routes: []*route.Route{{
+					Match:  &route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"}},
+					Action: &route.Route_Route{Route: &route.RouteAction{ClusterSpecifier: &route.RouteAction_Cluster{Cluster: "PassthroughCluster"}}},
+				}},
+			},
+		},
+	}}
+	mysql := &cluster.Cluster{
+		Name:                 "outbound|3306||mysql.default.svc.cluster.local",
+		ClusterDiscoveryType: &cluster.Cluster_Type{Type: cluster.Cluster_STRICT_DNS},
+		LoadAssignment: &endpoint.ClusterLoadAssignment{
+			ClusterName: "outbound|3306||mysql.default.svc.cluster.local",
+			Endpoints:   []*endpoint.LocalityLbEndpoints{{LbEndpoints: []*endpoint.LbEndpoint{lbEndpoint("10.1.0.1", 3306)}}},
+		},
+		TransportSocket: &core.TransportSocket{
+			Name: wellknown.TransportSocketTLS,
+			ConfigType: &core.TransportSocket_TypedConfig{TypedConfig: anyOf(&tls.UpstreamTlsContext{
+				Sni: "mysql.example.com",
+			})},
+		},
+	}
+	clusters := []*cluster.Cluster{
+		eds("outbound|9080|v1|reviews.default.svc.cluster.local"),
+		eds("outbound|9080|v2|reviews.default.svc.cluster.local"),
+		mysql,
+	}
+	endpoints := []*endpoint.ClusterLoadAssignment{
+		{
+			ClusterName: "outbound|9080|v1|reviews.default.svc.cluster.local",
+			Endpoints: []*endpoint.LocalityLbEndpoints{{
+				Locality:    &core.Locality{Region: "us-west1", Zone: "us-west1-a"},
+				LbEndpoints: []*endpoint.LbEndpoint{lbEndpoint("10.0.1.1", 9080), lbEndpoint("10.0.1.2", 9080)},
+			}},
+		},
+	}
+
+	prime := func(withEndpoints bool) *ConfigWriter {
+		listenerDump := &admin.ListenersConfigDump{}
+		for _, l := range listeners {
+			listenerDump.DynamicListeners = append(listenerDump.DynamicListeners, &admin.ListenersConfigDump_DynamicListener{
+				Name:        l.Name,
+				ActiveState: &admin.ListenersConfigDump_DynamicListenerState{Listener: anyOf(l)},
+			})
+		}
+		routeDump := &admin.RoutesConfigDump{}
+		for _, r := range routes {
+			routeDump.DynamicRouteConfigs = append(routeDump.DynamicRouteConfigs, &admin.RoutesConfigDump_DynamicRouteConfig{RouteConfig: anyOf(r)})
+		}
+		clusterDump := &admin.ClustersConfigDump{}
+		for _, c := range clusters {
+			clusterDump.DynamicActiveClusters = append(clusterDump.DynamicActiveClusters, &admin.ClustersConfigDump_DynamicCluster{Cluster: anyOf(c)})
+		}
+		configs := []*anypb.Any{anyOf(clusterDump), anyOf(listenerDump), anyOf(routeDump)}
+		if withEndpoints {
+			endpointDump := &admin.EndpointsConfigDump{}
+			for _, e := range endpoints {
+				endpointDump.DynamicEndpointConfigs = append(endpointDump.DynamicEndpointConfigs,
+					&admin.EndpointsConfigDump_DynamicEndpointConfig{EndpointConfig: anyOf(e)})
+			}
+			configs = append(configs, anyOf(endpointDump))
+		}
+		b, err := protomarshal.Marshal(&admin.ConfigDump{Configs: configs})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cw := &ConfigWriter{}
+		if err := cw.Prime(b); err != nil {
+			t.Fatal(err)
+		}
+		return cw
+	}
+
+	tests := []struct {
+		name           string
+		host           string
+		port           uint32
+		withEndpoints  bool
+		wantOutputFile string
+		wantErr        bool
+	}{
+		{
+			name:           "http",
+			host:           "reviews.default.svc.cluster.local",
+			port:           9080,
+			withEndpoints:  true,
+			wantOutputFile: "testdata/trace_http.txt",
+		},
+		{
+			name:           "tcp without endpoints",
+			host:           "mysql.default.svc.cluster.local",
+			port:           3306,
+			wantOutputFile: "testdata/trace_tcp.txt",
+		},
+		{
+			name:    "no route",
+			host:    "mysql.default.svc.cluster.local",
+			port:    9090,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cw := prime(tt.withEndpoints)
+			out := &bytes.Buffer{}
+			cw.Stdout = out
+			err := cw.PrintTrace(tt.host, tt.port)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			util.CompareContent(t, out.Bytes(), tt.wantOutputFile)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	adminapi "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+// StatsFilter is the filter of the stats the health summary uses, for the stats admin endpoint.
+const StatsFilter = `^(cluster_manager|listener_manager)\.`
+
+// Writer summarizes the health of an Envoy from the responses of its server_info, clusters?format=json,
+// listeners?format=json and stats admin endpoints.
+type Writer struct {
+	Stdout     io.Writer
+	serverInfo *adminapi.ServerInfo
+	clusters   *adminapi.Clusters
+	listeners  *adminapi.Listeners
+	stats      map[string]uint64
+}
+
+// Prime loads the admin endpoint responses into the writer ready for printing
+func (w *Writer) Prime(serverInfo, clusters, listeners, stats []byte) error {
+	w.serverInfo = &adminapi.ServerInfo{}
+	if err := protomarshal.UnmarshalAllowUnknown(serverInfo, w.serverInfo); err != nil {
+		return fmt.Errorf("error unmarshalling server info response from Envoy: %v", err)
+	}
+	w.clusters = &adminapi.Clusters{}
+	if err := protomarshal.UnmarshalAllowUnknown(clusters, w.clusters); err != nil {
+		return fmt.Errorf("error unmarshalling clusters response from Envoy: %v", err)
+	}
+	w.listeners = &adminapi.Listeners{}
+	if err := protomarshal.UnmarshalAllowUnknown(listeners, w.listeners); err != nil {
+		return fmt.Errorf("error unmarshalling listeners response from Envoy: %v", err)
+	}
+	w.stats = parseStats(stats)
+	return nil
+}
+
+// parseStats parses the counters and gauges of the text stats output, in the form "name: value".
+func parseStats(b []byte) map[string]uint64 {
+	stats := map[string]uint64{}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), ": ")
+		if !ok {
+			continue
+		}
+		// Histograms have non numeric values and are skipped.
+		if v, err := strconv.ParseUint(value, 10, 64); err == nil {
+			stats[name] = v
+		}
+	}
+	return stats
+}
+
+// Problems returns the reasons the Envoy is not healthy, empty if it is.
+func (w *Writer) Problems() []string {
+	var problems []string
+	if state := w.serverInfo.GetState(); state != adminapi.ServerInfo_LIVE {
+		problems = append(problems, fmt.Sprintf("server state is %s", state))
+	}
+	if n := w.stats["cluster_manager.warming_clusters"]; n > 0 {
+		problems = append(problems, fmt.Sprintf("%d clusters warming", n))
+	}
+	if n := w.stats["listener_manager.total_listeners_warming"]; n > 0 {
+		problems = append(problems, fmt.Sprintf("%d listeners warming", n))
+	}
+	if n := w.stats["cluster_manager.cds.update_rejected"]; n > 0 {
+		problems = append(problems, fmt.Sprintf("%d CDS updates rejected", n))
+	}
+	if n := w.stats["listener_manager.lds.update_rejected"]; n > 0 {
+		problems = append(problems, fmt.Sprintf("%d LDS updates rejected", n))
+	}
+	if _, unhealthy := w.endpoints(); unhealthy > 0 {
+		problems = append(problems, fmt.Sprintf("%d endpoints unhealthy", unhealthy))
+	}
+	return problems
+}
+
+// endpoints returns the number of healthy and unhealthy endpoints of all clusters.
+func (w *Writer) endpoints() (healthy, unhealthy int) {
+	for _, c := range w.clusters.GetClusterStatuses() {
+		for _, h := range c.GetHostStatuses() {
+			if h.GetHealthStatus().GetEdsHealthStatus() == core.HealthStatus_HEALTHY && !h.GetHealthStatus().GetFailedOutlierCheck() {
+				healthy++
+			} else {
+				unhealthy++
+			}
+		}
+	}
+	return healthy, unhealthy
+}
+
+// PrintHealthSummary prints the overall health of the Envoy, followed by the details it is based on.
+func (w *Writer) PrintHealthSummary() error {
+	if w.serverInfo == nil {
+		return fmt.Errorf("health writer has not been primed")
+	}
+	tw := tabwriter.NewWriter(w.Stdout, 0, 8, 1, ' ', 0)
+	health := "HEALTHY"
+	if problems := w.Problems(); len(problems) > 0 {
+		health = "DEGRADED (" + strings.Join(problems, ", ") + ")"
+	}
+	healthy, unhealthy := w.endpoints()
+	fmt.Fprintf(tw, "Health:\t%s\n", health)
+	fmt.Fprintf(tw, "Envoy Version:\t%s\n", w.serverInfo.GetVersion())
+	fmt.Fprintf(tw, "State:\t%s\n", w.serverInfo.GetState())
+	fmt.Fprintf(tw, "Uptime:\t%s\n", w.serverInfo.GetUptimeCurrentEpoch().AsDuration())
+	fmt.Fprintf(tw, "Clusters:\t%d (%d warming)\n",
+		len(w.clusters.GetClusterStatuses()), w.stats["cluster_manager.warming_clusters"])
+	fmt.Fprintf(tw, "Endpoints:\t%d healthy, %d unhealthy\n", healthy, unhealthy)
+	fmt.Fprintf(tw, "Listeners:\t%d (%d warming)\n",
+		len(w.listeners.GetListenerStatuses()), w.stats["listener_manager.total_listeners_warming"])
+	return tw.Flush()
+}
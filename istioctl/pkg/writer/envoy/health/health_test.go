@@ -0,0 +1,115 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	liveServerInfo = `{"version": "envoy/1.23.0", "state": "LIVE", "uptime_current_epoch": "3600s", "unknown": {}}`
+	drainingInfo   = `{"version": "envoy/1.23.0", "state": "DRAINING", "uptime_current_epoch": "60s"}`
+	clustersJSON   = `{"cluster_statuses": [
+  {"name": "outbound|80||a.default.svc.cluster.local", "host_statuses": [
+    {"health_status": {"eds_health_status": "HEALTHY"}},
+    {"health_status": {"eds_health_status": "HEALTHY", "failed_outlier_check": true}}
+  ]},
+  {"name": "outbound|80||b.default.svc.cluster.local", "host_statuses": [
+    {"health_status": {"eds_health_status": "UNHEALTHY"}}
+  ]}
+]}`
+	healthyClustersJSON = `{"cluster_statuses": [
+  {"name": "outbound|80||a.default.svc.cluster.local", "host_statuses": [
+    {"health_status": {"eds_health_status": "HEALTHY"}}
+  ]}
+]}`
+	listenersJSON = `{"listener_statuses": [{"name": "virtualOutbound"}, {"name": "virtualInbound"}]}`
+)
+
+func TestProblems(t *testing.T) {
+	cases := []struct {
+		name       string
+		serverInfo string
+		clusters   string
+		stats      string
+		want       []string
+	}{
+		{
+			name:       "healthy",
+			serverInfo: liveServerInfo,
+			clusters:   healthyClustersJSON,
+			stats:      "cluster_manager.warming_clusters: 0\nlistener_manager.total_listeners_warming: 0\n",
+		},
+		{
+			name:       "degraded",
+			serverInfo: drainingInfo,
+			clusters:   clustersJSON,
+			stats: `cluster_manager.cds.update_rejected: 2
+cluster_manager.warming_clusters: 1
+listener_manager.lds.update_rejected: 0
+listener_manager.total_listeners_warming: 3
+listener_manager.lds.update_duration: P0(nan,1) P25(nan,1.025)
+`,
+			want: []string{
+				"server state is DRAINING",
+				"1 clusters warming",
+				"3 listeners warming",
+				"2 CDS updates rejected",
+				"2 endpoints unhealthy",
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Writer{}
+			if err := w.Prime([]byte(tt.serverInfo), []byte(tt.clusters), []byte(listenersJSON), []byte(tt.stats)); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Problems(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintHealthSummary(t *testing.T) {
+	out := &bytes.Buffer{}
+	w := &Writer{Stdout: out}
+	if err := w.PrintHealthSummary(); err == nil {
+		t.Fatal("expected error for unprimed writer")
+	}
+	if err := w.Prime([]byte(liveServerInfo), []byte(clustersJSON), []byte(listenersJSON), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.PrintHealthSummary(); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Health:        DEGRADED (2 endpoints unhealthy)",
+		"Envoy Version: envoy/1.23.0",
+		"State:         LIVE",
+		"Uptime:        1h0m0s",
+		"Clusters:      2 (0 warming)",
+		"Endpoints:     1 healthy, 2 unhealthy",
+		"Listeners:     2 (0 warming)",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,346 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pilot/pkg/model"
+)
+
+// ServiceStats are the stats of the clusters of an upstream service, summed over its subsets. Clusters which are not
+// of a service, such as PassthroughCluster, are reported under their name.
+type ServiceStats struct {
+	Service           string   `json:"service"`
+	Direction         string   `json:"direction,omitempty"`
+	Port              int      `json:"port,omitempty"`
+	Subsets           []string `json:"subsets,omitempty"`
+	Requests          uint64   `json:"requests"`
+	Responses2xx      uint64   `json:"responses2xx"`
+	Responses4xx      uint64   `json:"responses4xx"`
+	Responses5xx      uint64   `json:"responses5xx"`
+	Timeouts          uint64   `json:"timeouts"`
+	Retries           uint64   `json:"retries"`
+	ActiveConnections uint64   `json:"activeConnections"`
+	ConnectFailures   uint64   `json:"connectFailures"`
+	HealthyEndpoints  uint64   `json:"healthyEndpoints"`
+	Endpoints         uint64   `json:"endpoints"`
+	EjectedEndpoints  uint64   `json:"ejectedEndpoints"`
+	TLSErrors         uint64   `json:"tlsErrors"`
+}
+
+// ListenerStats are the downstream connection stats of a listener.
+type ListenerStats struct {
+	Listener           string `json:"listener"`
+	ActiveConnections  uint64 `json:"activeConnections"`
+	TotalConnections   uint64 `json:"totalConnections"`
+	NoFilterChainMatch uint64 `json:"noFilterChainMatch"`
+	TLSHandshakes      uint64 `json:"tlsHandshakes"`
+	TLSErrors          uint64 `json:"tlsErrors"`
+}
+
+// CertificateStats are the SDS updates of a secret, such as the workload certificate (default) or the root
+// certificate (ROOTCA).
+type CertificateStats struct {
+	Secret          string `json:"secret"`
+	Updates         uint64 `json:"updates"`
+	RejectedUpdates uint64 `json:"rejectedUpdates"`
+	FailedUpdates   uint64 `json:"failedUpdates"`
+}
+
+// Summary groups the stats of an Envoy by upstream service, listener and certificate.
+type Summary struct {
+	Services     []*ServiceStats     `json:"services"`
+	Listeners    []*ListenerStats    `json:"listeners"`
+	Certificates []*CertificateStats `json:"certificates"`
+	// DaysUntilFirstCertExpiring is the number of days until the first certificate the Envoy uses expires, if it
+	// has certificates.
+	DaysUntilFirstCertExpiring *uint64 `json:"daysUntilFirstCertExpiring,omitempty"`
+}
+
+// Writer groups the stats of the Envoy stats admin endpoint by Istio concept, as the raw stat names require knowing
+// how Envoy names them.
+type Writer struct {
+	Stdout io.Writer
+	stats  map[string]uint64
+}
+
+// Prime loads the text output of the stats admin endpoint into the writer ready for printing
+func (w *Writer) Prime(b []byte) error {
+	w.stats = map[string]uint64{}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), ": ")
+		if !ok {
+			continue
+		}
+		// Histograms have non numeric values and are skipped.
+		if v, err := strconv.ParseUint(value, 10, 64); err == nil {
+			w.stats[name] = v
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading stats response from Envoy: %v", err)
+	}
+	return nil
+}
+
+// The stats of the clusters and listeners are named <prefix>.<name>.<stat>, where the names of clusters and listeners
+// contain dots, so the stats are matched by suffix.
+var (
+	clusterStats = map[string]func(s *ServiceStats, v uint64){
+		"upstream_rq_total":                   func(s *ServiceStats, v uint64) { s.Requests += v },
+		"upstream_rq_2xx":                     func(s *ServiceStats, v uint64) { s.Responses2xx += v },
+		"upstream_rq_4xx":                     func(s *ServiceStats, v uint64) { s.Responses4xx += v },
+		"upstream_rq_5xx":                     func(s *ServiceStats, v uint64) { s.Responses5xx += v },
+		"upstream_rq_timeout":                 func(s *ServiceStats, v uint64) { s.Timeouts += v },
+		"upstream_rq_retry":                   func(s *ServiceStats, v uint64) { s.Retries += v },
+		"upstream_cx_active":                  func(s *ServiceStats, v uint64) { s.ActiveConnections += v },
+		"upstream_cx_connect_fail":            func(s *ServiceStats, v uint64) { s.ConnectFailures += v },
+		"membership_healthy":                  func(s *ServiceStats, v uint64) { s.HealthyEndpoints += v },
+		"membership_total":                    func(s *ServiceStats, v uint64) { s.Endpoints += v },
+		"outlier_detection.ejections_active":  func(s *ServiceStats, v uint64) { s.EjectedEndpoints += v },
+		"ssl.connection_error":                func(s *ServiceStats, v uint64) { s.TLSErrors += v },
+		"ssl.fail_verify_san":                 func(s *ServiceStats, v uint64) { s.TLSErrors += v },
+		"ssl.fail_verify_error":               func(s *ServiceStats, v uint64) { s.TLSErrors += v },
+		"ssl.fail_verify_cert_hash":           func(s *ServiceStats, v uint64) { s.TLSErrors += v },
+		"ssl.fail_verify_no_cert":             func(s *ServiceStats, v uint64) { s.TLSErrors += v },
+		"upstream_cx_connect_attempts_exceed": func(s *ServiceStats, v uint64) { s.ConnectFailures += v },
+	}
+	listenerStats = map[string]func(s *ListenerStats, v uint64){
+		"downstream_cx_active":      func(s *ListenerStats, v uint64) { s.ActiveConnections += v },
+		"downstream_cx_total":       func(s *ListenerStats, v uint64) { s.TotalConnections += v },
+		"no_filter_chain_match":     func(s *ListenerStats, v uint64) { s.NoFilterChainMatch += v },
+		"ssl.handshake":             func(s *ListenerStats, v uint64) { s.TLSHandshakes += v },
+		"ssl.connection_error":      func(s *ListenerStats, v uint64) { s.TLSErrors += v },
+		"ssl.fail_verify_no_cert":   func(s *ListenerStats, v uint64) { s.TLSErrors += v },
+		"ssl.fail_verify_error":     func(s *ListenerStats, v uint64) { s.TLSErrors += v },
+		"ssl.fail_verify_san":       func(s *ListenerStats, v uint64) { s.TLSErrors += v },
+		"ssl.fail_verify_cert_hash": func(s *ListenerStats, v uint64) { s.TLSErrors += v },
+		"ssl.ocsp_staple_failed":    func(s *ListenerStats, v uint64) { s.TLSErrors += v },
+	}
+	certificateStats = map[string]func(s *CertificateStats, v uint64){
+		"update_success":  func(s *CertificateStats, v uint64) { s.Updates += v },
+		"update_rejected": func(s *CertificateStats, v uint64) { s.RejectedUpdates += v },
+		"update_failure":  func(s *CertificateStats, v uint64) { s.FailedUpdates += v },
+	}
+)
+
+// splitStat splits the stat name, without its prefix, into the name of the resource and the stat, for the stats of
+// the resources of the known names. The response code stats Envoy also reports by origin, such as
+// cluster.<name>.internal.upstream_rq_2xx, are not resource stats.
+func splitStat[T any](name string, known map[string]T) (string, T, bool) {
+	var zero T
+	for stat, v := range known {
+		if !strings.HasSuffix(name, "."+stat) {
+			continue
+		}
+		resource := strings.TrimSuffix(name, "."+stat)
+		for _, origin := range []string{".internal", ".external", ".canary"} {
+			if strings.HasSuffix(resource, origin) {
+				return "", zero, false
+			}
+		}
+		return resource, v, resource != ""
+	}
+	return "", zero, false
+}
+
+// Summary groups the primed stats by upstream service, listener and certificate.
+func (w *Writer) Summary() (*Summary, error) {
+	if w.stats == nil {
+		return nil, fmt.Errorf("stats writer has not been primed")
+	}
+	services := map[string]*ServiceStats{}
+	subsets := map[string]map[string]bool{}
+	listeners := map[string]*ListenerStats{}
+	certificates := map[string]*CertificateStats{}
+	summary := &Summary{}
+	for name, value := range w.stats {
+		switch {
+		case strings.HasPrefix(name, "cluster."):
+			cluster, add, ok := splitStat(strings.TrimPrefix(name, "cluster."), clusterStats)
+			if !ok {
+				continue
+			}
+			key, s := serviceOf(cluster)
+			if existing, f := services[key]; f {
+				s = existing
+			} else {
+				services[key] = s
+				subsets[key] = map[string]bool{}
+			}
+			if _, subset, _, _ := model.ParseSubsetKey(cluster); subset != "" {
+				subsets[key][subset] = true
+			}
+			add(s, value)
+		case strings.HasPrefix(name, "listener."):
+			l, add, ok := splitStat(strings.TrimPrefix(name, "listener."), listenerStats)
+			// The admin listener is not part of the configuration of the proxy.
+			if !ok || l == "admin" {
+				continue
+			}
+			s, f := listeners[l]
+			if !f {
+				s = &ListenerStats{Listener: l}
+				listeners[l] = s
+			}
+			add(s, value)
+		case strings.HasPrefix(name, "sds."):
+			secret, add, ok := splitStat(strings.TrimPrefix(name, "sds."), certificateStats)
+			if !ok {
+				continue
+			}
+			s, f := certificates[secret]
+			if !f {
+				s = &CertificateStats{Secret: secret}
+				certificates[secret] = s
+			}
+			add(s, value)
+		case name == "server.days_until_first_cert_expiring":
+			days := value
+			summary.DaysUntilFirstCertExpiring = &days
+		}
+	}
+
+	for key, s := range services {
+		for subset := range subsets[key] {
+			s.Subsets = append(s.Subsets, subset)
+		}
+		sort.Strings(s.Subsets)
+		summary.Services = append(summary.Services, s)
+	}
+	sort.Slice(summary.Services, func(i, j int) bool {
+		a, b := summary.Services[i], summary.Services[j]
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		if a.Direction != b.Direction {
+			return a.Direction < b.Direction
+		}
+		return a.Port < b.Port
+	})
+	for _, s := range listeners {
+		summary.Listeners = append(summary.Listeners, s)
+	}
+	sort.Slice(summary.Listeners, func(i, j int) bool { return summary.Listeners[i].Listener < summary.Listeners[j].Listener })
+	for _, s := range certificates {
+		summary.Certificates = append(summary.Certificates, s)
+	}
+	sort.Slice(summary.Certificates, func(i, j int) bool { return summary.Certificates[i].Secret < summary.Certificates[j].Secret })
+	return summary, nil
+}
+
+// serviceOf returns the key grouping the cluster with the other subsets of its service, and new stats for the
+// service.
+func serviceOf(cluster string) (string, *ServiceStats) {
+	direction, _, hostname, port := model.ParseSubsetKey(cluster)
+	if direction == "" {
+		return cluster, &ServiceStats{Service: cluster}
+	}
+	service := string(hostname)
+	if service == "" {
+		// Inbound clusters of the ports of the workload have no hostname.
+		service = cluster
+	}
+	return fmt.Sprintf("%s|%d|%s", direction, port, hostname),
+		&ServiceStats{Service: service, Direction: string(direction), Port: port}
+}
+
+// PrintStats prints the stats grouped by upstream service, listener and certificate, as tables for the short output
+// or as json or yaml.
+func (w *Writer) PrintStats(outputFormat string) error {
+	summary, err := w.Summary()
+	if err != nil {
+		return err
+	}
+	switch outputFormat {
+	case "json", "yaml":
+		out, err := json.MarshalIndent(summary, "", "    ")
+		if err != nil {
+			return err
+		}
+		if outputFormat == "yaml" {
+			if out, err = yaml.JSONToYAML(out); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(w.Stdout, string(out))
+		return nil
+	case "short":
+		return w.printTables(summary)
+	default:
+		return fmt.Errorf("output format %q not supported", outputFormat)
+	}
+}
+
+func (w *Writer) printTables(summary *Summary) error {
+	tw := new(tabwriter.Writer).Init(w.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(tw, "SERVICE\tDIRECTION\tPORT\tSUBSETS\tREQUESTS\t2XX\t4XX\t5XX\tTIMEOUTS\tRETRIES\t"+
+		"ACTIVE CX\tCX FAILURES\tHEALTHY ENDPOINTS\tEJECTED\tTLS ERRORS")
+	for _, s := range summary.Services {
+		port := "-"
+		if s.Port != 0 {
+			port = strconv.Itoa(s.Port)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d/%d\t%d\t%d\n",
+			s.Service, orDash(s.Direction), port, orDash(strings.Join(s.Subsets, ",")), s.Requests, s.Responses2xx,
+			s.Responses4xx, s.Responses5xx, s.Timeouts, s.Retries, s.ActiveConnections, s.ConnectFailures,
+			s.HealthyEndpoints, s.Endpoints, s.EjectedEndpoints, s.TLSErrors)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w.Stdout)
+	fmt.Fprintln(tw, "LISTENER\tACTIVE CX\tTOTAL CX\tNO FILTER CHAIN MATCH\tTLS HANDSHAKES\tTLS ERRORS")
+	for _, s := range summary.Listeners {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\n",
+			s.Listener, s.ActiveConnections, s.TotalConnections, s.NoFilterChainMatch, s.TLSHandshakes, s.TLSErrors)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w.Stdout)
+	fmt.Fprintln(tw, "CERTIFICATE\tUPDATES\tREJECTED\tFAILED")
+	for _, s := range summary.Certificates {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", s.Secret, s.Updates, s.RejectedUpdates, s.FailedUpdates)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if summary.DaysUntilFirstCertExpiring != nil {
+		fmt.Fprintf(w.Stdout, "\nFirst certificate expires in %d days\n", *summary.DaysUntilFirstCertExpiring)
+	}
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
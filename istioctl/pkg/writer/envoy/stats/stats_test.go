@@ -0,0 +1,81 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestWriter_PrintStats(t *testing.T) {
+	stats, err := os.ReadFile("testdata/stats.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotOut := &bytes.Buffer{}
+	w := &Writer{Stdout: gotOut}
+	assert.Error(t, w.PrintStats("short"))
+	if err := w.Prime(stats); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, w.PrintStats("short"))
+	util.CompareContent(t, gotOut.Bytes(), "testdata/summary.txt")
+
+	gotOut.Reset()
+	assert.NoError(t, w.PrintStats("json"))
+	got := &Summary{}
+	if err := json.Unmarshal(gotOut.Bytes(), got); err != nil {
+		t.Fatal(err)
+	}
+	days := uint64(0)
+	assert.Equal(t, got, &Summary{
+		Services: []*ServiceStats{
+			{Service: "PassthroughCluster", Requests: 5, Timeouts: 1},
+			{Service: "inbound|9080||", Direction: "inbound", Port: 9080, Requests: 40, Responses2xx: 39, Responses4xx: 1, Endpoints: 1},
+			{
+				Service:           "reviews.default.svc.cluster.local",
+				Direction:         "outbound",
+				Port:              9080,
+				Subsets:           []string{"v1", "v2"},
+				Requests:          100,
+				Responses2xx:      88,
+				Responses5xx:      12,
+				Retries:           1,
+				ActiveConnections: 3,
+				ConnectFailures:   4,
+				HealthyEndpoints:  2,
+				Endpoints:         3,
+				EjectedEndpoints:  1,
+				TLSErrors:         2,
+			},
+		},
+		Listeners: []*ListenerStats{
+			{Listener: "0.0.0.0_15006", ActiveConnections: 2, TotalConnections: 41, NoFilterChainMatch: 1, TLSHandshakes: 40, TLSErrors: 1},
+			{Listener: "10.96.0.10_9080", TotalConnections: 7},
+		},
+		Certificates: []*CertificateStats{
+			{Secret: "ROOTCA", Updates: 1, FailedUpdates: 1},
+			{Secret: "default", Updates: 3},
+		},
+		DaysUntilFirstCertExpiring: &days,
+	})
+
+	assert.Error(t, w.PrintStats("prom"))
+}
@@ -0,0 +1,143 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+
+	"istio.io/istio/pilot/pkg/model"
+	caserver "istio.io/istio/security/pkg/server/ca"
+)
+
+// MetricsWriter exports the sync status of proxies, their version skew from istiod and the expiry of their
+// certificates as metrics.
+type MetricsWriter struct {
+	Writer io.Writer
+	// IstiodVersions are the raw /version responses of each istiod, used to compute the version skew.
+	IstiodVersions map[string][]byte
+	// Certificates are the certificates issued by istiod, matched to proxies by IP address.
+	Certificates []caserver.IssuedCertificate
+}
+
+var proxyLabels = []string{"proxy", "cluster", "istiod"}
+
+// Registry builds a registry with the metrics of the proxies in the Pilot syncz responses.
+func (m *MetricsWriter) Registry(statuses map[string][]byte) (*prometheus.Registry, error) {
+	synced := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "istio_proxy_xds_synced",
+		Help: "Whether the last xDS response of the type sent to the proxy was acknowledged.",
+	}, append(proxyLabels, "type"))
+	info := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "istio_proxy_info",
+		Help: "Version of the proxy and of the istiod it is connected to.",
+	}, append(proxyLabels, "proxy_version", "istiod_version"))
+	skew := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "istio_proxy_version_skew_minor",
+		Help: "Number of minor versions between the proxy and the istiod it is connected to.",
+	}, proxyLabels)
+	expiry := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "istio_proxy_cert_expiry_timestamp_seconds",
+		Help: "Expiry of the workload certificate issued to the proxy, in seconds since the epoch.",
+	}, proxyLabels)
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(synced, info, skew, expiry)
+
+	certExpiry := map[string]float64{}
+	for _, c := range m.Certificates {
+		ts := float64(c.NotAfter.Unix())
+		if cur, ok := certExpiry[c.Requester]; !ok || ts > cur {
+			certExpiry[c.Requester] = ts
+		}
+	}
+
+	for istiod, status := range statuses {
+		var ss []*writerStatus
+		if err := json.Unmarshal(status, &ss); err != nil {
+			return nil, err
+		}
+		istiodVersion := strings.TrimSpace(string(m.IstiodVersions[istiod]))
+		for _, s := range ss {
+			labels := []string{s.ProxyID, s.ClusterID, istiod}
+			for typ, nonces := range map[string][2]string{
+				"cds":  {s.ClusterSent, s.ClusterAcked},
+				"lds":  {s.ListenerSent, s.ListenerAcked},
+				"eds":  {s.EndpointSent, s.EndpointAcked},
+				"rds":  {s.RouteSent, s.RouteAcked},
+				"ecds": {s.ExtensionConfigSent, s.ExtensionConfigAcked},
+			} {
+				// Types never sent to the proxy have no sync state.
+				if nonces[0] == "" {
+					continue
+				}
+				synced.WithLabelValues(append(labels, typ)...).Set(boolToFloat(nonces[0] == nonces[1]))
+			}
+			info.WithLabelValues(append(labels, s.IstioVersion, istiodVersion)...).Set(1)
+			if s.IstioVersion != "" && istiodVersion != "" {
+				skew.WithLabelValues(labels...).Set(minorVersionSkew(s.IstioVersion, istiodVersion))
+			}
+			// The proxy uses the certificate issued last, for any of its IP addresses.
+			var proxyExpiry float64
+			for _, ip := range s.ProxyIPs {
+				proxyExpiry = math.Max(proxyExpiry, certExpiry[ip])
+			}
+			if proxyExpiry > 0 {
+				expiry.WithLabelValues(labels...).Set(proxyExpiry)
+			}
+		}
+	}
+	return reg, nil
+}
+
+// PrintAll writes the metrics of the proxies in the Pilot syncz responses in the OpenMetrics text format.
+func (m *MetricsWriter) PrintAll(statuses map[string][]byte) error {
+	reg, err := m.Registry(statuses)
+	if err != nil {
+		return err
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		return err
+	}
+	for _, mf := range families {
+		if _, err := expfmt.MetricFamilyToOpenMetrics(m.Writer, mf); err != nil {
+			return fmt.Errorf("failed to write metric %s: %v", mf.GetName(), err)
+		}
+	}
+	_, err = expfmt.FinalizeOpenMetrics(m.Writer)
+	return err
+}
+
+func minorVersionSkew(proxyVersion, istiodVersion string) float64 {
+	p, i := model.ParseIstioVersion(proxyVersion), model.ParseIstioVersion(istiodVersion)
+	if p.Major != i.Major {
+		// Major versions are not expected to be mixed; report them as far apart.
+		return math.Inf(1)
+	}
+	return math.Abs(float64(p.Minor - i.Minor))
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
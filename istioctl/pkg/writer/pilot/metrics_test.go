@@ -0,0 +1,68 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"istio.io/istio/pilot/pkg/xds"
+	caserver "istio.io/istio/security/pkg/server/ca"
+	"istio.io/istio/tests/util"
+)
+
+func TestMetricsWriter_PrintAll(t *testing.T) {
+	statuses, err := json.Marshal([]xds.SyncStatus{
+		{
+			ProxyID:       "proxy1.default",
+			ClusterID:     "cluster1",
+			IstioVersion:  "1.15.1",
+			ProxyIPs:      []string{"10.0.0.1"},
+			ClusterSent:   "nonce1",
+			ClusterAcked:  "nonce1",
+			ListenerSent:  "nonce2",
+			ListenerAcked: "nonce1",
+		},
+		{
+			ProxyID:      "proxy2.default",
+			ClusterID:    "cluster1",
+			IstioVersion: "1.16.0",
+			ProxyIPs:     []string{"10.0.0.2"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiry := time.Unix(1700000000, 0)
+	got := &bytes.Buffer{}
+	mw := MetricsWriter{
+		Writer:         got,
+		IstiodVersions: map[string][]byte{"istiod1": []byte("1.16.0-abc-Clean")},
+		Certificates: []caserver.IssuedCertificate{
+			{Requester: "10.0.0.1", NotAfter: expiry.Add(-time.Hour)},
+			{Requester: "10.0.0.1", NotAfter: expiry},
+		},
+	}
+	if err := mw.PrintAll(map[string][]byte{"istiod1": statuses}); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := os.ReadFile("testdata/metrics.txt")
+	if err := util.Compare(got.Bytes(), want); err != nil {
+		t.Errorf(err.Error())
+	}
+}
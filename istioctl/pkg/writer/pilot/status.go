@@ -22,10 +22,12 @@ import (
 	"strings"
 	"text/tabwriter"
 
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
 	xdsapi "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	xdsstatus "github.com/envoyproxy/go-control-plane/envoy/service/status/v3"
 
 	"istio.io/istio/istioctl/pkg/multixds"
+	"istio.io/istio/istioctl/pkg/util/formatting"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/xds"
 	xdsresource "istio.io/istio/pilot/pkg/xds/v3"
@@ -42,6 +44,21 @@ type writerStatus struct {
 	xds.SyncStatus
 }
 
+// ProxyStatus is the sync status of a proxy, as printed by PrintJSON.
+type ProxyStatus struct {
+	Proxy   string `json:"proxy"`
+	Cluster string `json:"cluster"`
+	Istiod  string `json:"istiod"`
+	// IstioVersion is the Istio version of the proxy, empty for the proxies connected to a 1.1 istiod.
+	IstioVersion string `json:"istioVersion,omitempty"`
+	ProxyVersion string `json:"proxyVersion,omitempty"`
+	CDS          string `json:"cds"`
+	LDS          string `json:"lds"`
+	EDS          string `json:"eds"`
+	RDS          string `json:"rds"`
+	ECDS         string `json:"ecds"`
+}
+
 // XdsStatusWriter enables printing of sync status using multiple xdsapi.DiscoveryResponse Istiod responses
 type XdsStatusWriter struct {
 	Writer                 io.Writer
@@ -58,6 +75,8 @@ type xdsWriterStatus struct {
 	routeStatus          string
 	endpointStatus       string
 	extensionconfigStaus string
+	// nacks are the details of the configurations rejected by the proxy.
+	nacks []string
 }
 
 // PrintAll takes a slice of Pilot syncz responses and outputs them using a tabwriter
@@ -90,15 +109,48 @@ func (s *StatusWriter) PrintSingle(statuses map[string][]byte, proxyName string)
 	return w.Flush()
 }
 
+// PrintJSON takes a slice of Pilot syncz responses and outputs them as a ProxyStatusList JSON document
+func (s *StatusWriter) PrintJSON(statuses map[string][]byte) error {
+	fullStatus, err := sortedStatuses(statuses)
+	if err != nil {
+		return err
+	}
+	out := make([]ProxyStatus, 0, len(fullStatus))
+	for _, status := range fullStatus {
+		out = append(out, ProxyStatus{
+			Proxy:        status.ProxyID,
+			Cluster:      status.ClusterID,
+			Istiod:       status.pilot,
+			IstioVersion: status.IstioVersion,
+			ProxyVersion: status.ProxyVersion,
+			CDS:          xdsStatus(status.ClusterSent, status.ClusterAcked),
+			LDS:          xdsStatus(status.ListenerSent, status.ListenerAcked),
+			EDS:          xdsStatus(status.EndpointSent, status.EndpointAcked),
+			RDS:          xdsStatus(status.RouteSent, status.RouteAcked),
+			ECDS:         xdsStatus(status.ExtensionConfigSent, status.ExtensionConfigAcked),
+		})
+	}
+	return formatting.PrintJSONDocument(s.Writer, "ProxyStatusList", out)
+}
+
 func (s *StatusWriter) setupStatusPrint(statuses map[string][]byte) (*tabwriter.Writer, []*writerStatus, error) {
+	fullStatus, err := sortedStatuses(statuses)
+	if err != nil {
+		return nil, nil, err
+	}
 	w := new(tabwriter.Writer).Init(s.Writer, 0, 9, 5, ' ', 0)
 	_, _ = fmt.Fprintln(w, "NAME\tCLUSTER\tCDS\tLDS\tEDS\tRDS\tECDS\tISTIOD\tVERSION")
+	return w, fullStatus, nil
+}
+
+// sortedStatuses returns the statuses of the Pilot syncz responses, sorted by cluster and proxy.
+func sortedStatuses(statuses map[string][]byte) ([]*writerStatus, error) {
 	fullStatus := make([]*writerStatus, 0, len(statuses))
 	for pilot, status := range statuses {
 		var ss []*writerStatus
 		err := json.Unmarshal(status, &ss)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 		for _, s := range ss {
 			s.pilot = pilot
@@ -111,7 +163,7 @@ func (s *StatusWriter) setupStatusPrint(statuses map[string][]byte) (*tabwriter.
 		}
 		return fullStatus[i].ProxyID < fullStatus[j].ProxyID
 	})
-	return w, fullStatus, nil
+	return fullStatus, nil
 }
 
 func statusPrintln(w io.Writer, status *writerStatus) error {
@@ -161,11 +213,30 @@ func (s *XdsStatusWriter) PrintAll(statuses map[string]*xdsapi.DiscoveryResponse
 		}
 	}
 	if w != nil {
-		return w.Flush()
+		if err := w.Flush(); err != nil {
+			return err
+		}
 	}
+	printNacks(s.Writer, fullStatus)
 	return nil
 }
 
+// printNacks prints the details of the configurations rejected by the proxies, after the sync status table.
+func printNacks(w io.Writer, fullStatus []*xdsWriterStatus) {
+	header := false
+	for _, status := range fullStatus {
+		for _, n := range status.nacks {
+			if !header {
+				_, _ = fmt.Fprintln(w, "\nNACKED CONFIGURATIONS:")
+				header = true
+			}
+			for _, l := range strings.Split(n, "\n") {
+				_, _ = fmt.Fprintf(w, "%s: %s\n", status.proxyID, l)
+			}
+		}
+	}
+}
+
 func (s *XdsStatusWriter) setupStatusPrint(drs map[string]*xdsapi.DiscoveryResponse) (*tabwriter.Writer, []*xdsWriterStatus, error) {
 	// Gather the statuses before printing so they may be sorted
 	var fullStatus []*xdsWriterStatus
@@ -180,7 +251,7 @@ func (s *XdsStatusWriter) setupStatusPrint(drs map[string]*xdsapi.DiscoveryRespo
 				if err != nil {
 					return nil, nil, fmt.Errorf("could not unmarshal ClientConfig: %w", err)
 				}
-				cds, lds, eds, rds, ecds := getSyncStatus(&clientConfig)
+				cds, lds, eds, rds, ecds, nacks := getSyncStatus(&clientConfig)
 				cp := multixds.CpInfo(dr)
 				meta, err := model.ParseMetadata(clientConfig.GetNode().GetMetadata())
 				if err != nil {
@@ -196,6 +267,7 @@ func (s *XdsStatusWriter) setupStatusPrint(drs map[string]*xdsapi.DiscoveryRespo
 					routeStatus:          rds,
 					endpointStatus:       eds,
 					extensionconfigStaus: ecds,
+					nacks:                nacks,
 				})
 				if len(fullStatus) == 0 {
 					return nil, nil, fmt.Errorf("no proxies found (checked %d istiods)", len(drs))
@@ -241,21 +313,24 @@ func xdsStatusPrintln(w io.Writer, status *xdsWriterStatus) error {
 	return err
 }
 
-func getSyncStatus(clientConfig *xdsstatus.ClientConfig) (cds, lds, eds, rds, ecds string) {
+func getSyncStatus(clientConfig *xdsstatus.ClientConfig) (cds, lds, eds, rds, ecds string, nacks []string) {
 	configs := handleAndGetXdsConfigs(clientConfig)
 	for _, config := range configs {
 		cfgType := config.GetTypeUrl()
+		if config.GetErrorState().GetDetails() != "" {
+			nacks = append(nacks, config.GetErrorState().GetDetails())
+		}
 		switch cfgType {
 		case xdsresource.ListenerType:
-			lds = config.GetConfigStatus().String()
+			lds = configStatus(config)
 		case xdsresource.ClusterType:
-			cds = config.GetConfigStatus().String()
+			cds = configStatus(config)
 		case xdsresource.RouteType:
-			rds = config.GetConfigStatus().String()
+			rds = configStatus(config)
 		case xdsresource.EndpointType:
-			eds = config.GetConfigStatus().String()
+			eds = configStatus(config)
 		case xdsresource.ExtensionConfigurationType:
-			ecds = config.GetConfigStatus().String()
+			ecds = configStatus(config)
 		default:
 			log.Infof("GenericXdsConfig unexpected type %s\n", xdsresource.GetShortType(cfgType))
 		}
@@ -263,6 +338,14 @@ func getSyncStatus(clientConfig *xdsstatus.ClientConfig) (cds, lds, eds, rds, ec
 	return
 }
 
+// configStatus returns the sync status of the configuration of a type, or NACKED if the proxy rejected it.
+func configStatus(config *xdsstatus.ClientConfig_GenericXdsConfig) string {
+	if config.GetClientStatus() == admin.ClientResourceStatus_NACKED {
+		return config.GetClientStatus().String()
+	}
+	return config.GetConfigStatus().String()
+}
+
 func handleAndGetXdsConfigs(clientConfig *xdsstatus.ClientConfig) []*xdsstatus.ClientConfig_GenericXdsConfig {
 	configs := make([]*xdsstatus.ClientConfig_GenericXdsConfig, 0)
 	if clientConfig.GetGenericXdsConfigs() != nil {
@@ -20,6 +20,7 @@ import (
 	"os"
 	"testing"
 
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
 	envoycorev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	xdsapi "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	status "github.com/envoyproxy/go-control-plane/envoy/service/status/v3"
@@ -170,6 +171,26 @@ func TestStatusWriter_PrintSingle(t *testing.T) {
 	}
 }
 
+func TestStatusWriter_PrintJSON(t *testing.T) {
+	input := map[string][]byte{}
+	for key, ss := range map[string][]xds.SyncStatus{
+		"istiod1": statusInput1(),
+		"istiod2": statusInputProxyVersion(),
+	} {
+		b, _ := json.Marshal(ss)
+		input[key] = b
+	}
+	got := &bytes.Buffer{}
+	sw := StatusWriter{Writer: got}
+	assert.NoError(t, sw.PrintJSON(input))
+	want, _ := os.ReadFile("testdata/multiStatus.json")
+	if err := util.Compare(got.Bytes(), want); err != nil {
+		t.Errorf(err.Error())
+	}
+
+	assert.Error(t, sw.PrintJSON(map[string][]byte{"istiod1": []byte(`gobbledygook`)}))
+}
+
 func statusInput1() []xds.SyncStatus {
 	return []xds.SyncStatus{
 		{
@@ -311,6 +332,24 @@ func TestXdsStatusWriter_PrintAll(t *testing.T) {
 			},
 			want: "testdata/multiXdsStatusSinglePilot.txt",
 		},
+		{
+			name: "prints the details of the rejected configurations",
+			input: map[string]*xdsapi.DiscoveryResponse{
+				"istiod1": xdsResponseInput("istiod1", []clientConfigInput{
+					{
+						proxyID:        "proxy1",
+						clusterID:      "cluster1",
+						cdsSyncStatus:  status.ConfigStatus_SYNCED,
+						ldsSyncStatus:  status.ConfigStatus_STALE,
+						rdsSyncStatus:  status.ConfigStatus_SYNCED,
+						edsSyncStatus:  status.ConfigStatus_SYNCED,
+						ecdsSyncStatus: status.ConfigStatus_NOT_SENT,
+						ldsNack:        "LDS \"0.0.0.0_80\" field FilterChains[0]: invalid\nLDS \"virtualInbound\": duplicate address",
+					},
+				}),
+			},
+			want: "testdata/multiXdsStatusNack.txt",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -346,12 +385,22 @@ type clientConfigInput struct {
 	rdsSyncStatus  status.ConfigStatus
 	edsSyncStatus  status.ConfigStatus
 	ecdsSyncStatus status.ConfigStatus
+	// ldsNack is the details of the rejected listeners, if any.
+	ldsNack string
 }
 
 func newXdsClientConfig(config clientConfigInput) *status.ClientConfig {
 	meta := model.NodeMetadata{
 		ClusterID: cluster.ID(config.clusterID),
 	}
+	lds := &status.ClientConfig_GenericXdsConfig{
+		TypeUrl:      v3.ListenerType,
+		ConfigStatus: config.ldsSyncStatus,
+	}
+	if config.ldsNack != "" {
+		lds.ClientStatus = admin.ClientResourceStatus_NACKED
+		lds.ErrorState = &admin.UpdateFailureState{Details: config.ldsNack}
+	}
 	return &status.ClientConfig{
 		Node: &envoycorev3.Node{
 			Id:       config.proxyID,
@@ -362,10 +411,7 @@ func newXdsClientConfig(config clientConfigInput) *status.ClientConfig {
 				TypeUrl:      v3.ClusterType,
 				ConfigStatus: config.cdsSyncStatus,
 			},
-			{
-				TypeUrl:      v3.ListenerType,
-				ConfigStatus: config.ldsSyncStatus,
-			},
+			lds,
 			{
 				TypeUrl:      v3.RouteType,
 				ConfigStatus: config.rdsSyncStatus,
@@ -0,0 +1,308 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mesh
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	containername "github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/operator/pkg/apis/istio/v1alpha1"
+	"istio.io/istio/operator/pkg/helm"
+	"istio.io/istio/operator/pkg/manifest"
+	"istio.io/istio/operator/pkg/name"
+	"istio.io/istio/operator/pkg/object"
+	"istio.io/istio/operator/pkg/util/clog"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/kube/inject"
+	"istio.io/istio/pkg/util/protomarshal"
+	"istio.io/pkg/log"
+)
+
+type manifestImagesArgs struct {
+	// InFilenames is an array of paths to the input IstioOperator CR files.
+	InFilenames []string
+	// Set is a string with element format "path=value" where path is an IstioOperator path and the value is a
+	// value to set the node at that path to.
+	Set []string
+	// Force proceeds even if there are validation errors
+	Force bool
+	// ManifestsPath is a path to a charts and profiles directory in the local filesystem, or URL with a release tgz.
+	ManifestsPath string
+	// Revision is the Istio control plane revision the command targets.
+	Revision string
+	// Registry is the private registry the images are mirrored to.
+	Registry string
+	// PinDigests resolves every image to the digest it currently has in its source registry.
+	PinDigests bool
+	// MirrorFile is the path the mirroring manifest is written to.
+	MirrorFile string
+}
+
+func addManifestImagesFlags(cmd *cobra.Command, args *manifestImagesArgs) {
+	cmd.PersistentFlags().StringSliceVarP(&args.InFilenames, "filename", "f", nil, filenameFlagHelpStr)
+	cmd.PersistentFlags().StringArrayVarP(&args.Set, "set", "s", nil, setFlagHelpStr)
+	cmd.PersistentFlags().BoolVar(&args.Force, "force", false, ForceFlagHelpStr)
+	cmd.PersistentFlags().StringVarP(&args.ManifestsPath, "manifests", "d", "", ManifestsFlagHelpStr)
+	cmd.PersistentFlags().StringVarP(&args.Revision, "revision", "r", "", revisionFlagHelpStr)
+	cmd.PersistentFlags().StringVar(&args.Registry, "registry", "",
+		"Private registry, including any repository path, to rewrite the images to, for example registry.example.com/istio.")
+	cmd.PersistentFlags().BoolVar(&args.PinDigests, "pin-digests", false,
+		"Resolve each image to its current digest in the source registry. Requires access to the source registry.")
+	cmd.PersistentFlags().StringVar(&args.MirrorFile, "mirror-file", "",
+		"Path to write a mirroring manifest to, with one SOURCE=DESTINATION line per image. Requires --registry.")
+}
+
+// ExperimentalManifestCmd is a group of experimental commands related to manifests.
+func ExperimentalManifestCmd(logOpts *log.Options) *cobra.Command {
+	mc := &cobra.Command{
+		Use:   "manifest",
+		Short: "Experimental commands related to Istio manifests",
+	}
+	miArgs := &manifestImagesArgs{}
+	mic := manifestImagesCmd(miArgs, logOpts)
+	addManifestImagesFlags(mic, miArgs)
+	mc.AddCommand(mic)
+	return mc
+}
+
+func manifestImagesCmd(miArgs *manifestImagesArgs, logOpts *log.Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "images",
+		Short: "Lists the container images an Istio installation uses",
+		Long: "The images subcommand lists all container images used by the manifest of the selected profile and " +
+			"revision, including the sidecar images injected into workloads. The images can be rewritten to a private " +
+			"registry, pinned to their digests, and written to a mirroring manifest for air-gapped installations.",
+		Example: `  # List the images of the default profile
+  istioctl x manifest images
+
+  # List the pinned images of the demo profile as mirrored to a private registry
+  istioctl x manifest images --set profile=demo --registry registry.example.com/istio --pin-digests
+
+  # Write a mirroring manifest, for example for "oc image mirror -f" or a similar tool
+  istioctl x manifest images --registry registry.example.com/istio --pin-digests --mirror-file mirror.txt`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if miArgs.MirrorFile != "" && miArgs.Registry == "" {
+				return fmt.Errorf("--mirror-file requires --registry")
+			}
+			l := clog.NewConsoleLogger(cmd.OutOrStdout(), cmd.ErrOrStderr(), installerScope)
+			return manifestImages(cmd.OutOrStdout(), miArgs, logOpts, l)
+		},
+	}
+}
+
+func manifestImages(w io.Writer, miArgs *manifestImagesArgs, logOpts *log.Options, l clog.Logger) error {
+	if err := configLogs(logOpts); err != nil {
+		return fmt.Errorf("could not configure logs: %s", err)
+	}
+	manifests, _, err := manifest.GenManifests(miArgs.InFilenames,
+		applyFlagAliases(miArgs.Set, miArgs.ManifestsPath, miArgs.Revision), miArgs.Force, nil, nil, l)
+	if err != nil {
+		return err
+	}
+	images, err := imagesFromManifests(manifests)
+	if err != nil {
+		return err
+	}
+	mirrors, err := mirrorImages(images, miArgs.Registry, miArgs.PinDigests, imageDigest)
+	if err != nil {
+		return err
+	}
+	for _, m := range mirrors {
+		if m.Pinned != "" {
+			fmt.Fprintln(w, m.Pinned)
+		} else {
+			fmt.Fprintln(w, m.Destination)
+		}
+	}
+	if miArgs.MirrorFile != "" {
+		var b strings.Builder
+		for _, m := range mirrors {
+			b.WriteString(m.Source + "=" + m.Destination + "\n")
+		}
+		if err := os.WriteFile(miArgs.MirrorFile, []byte(b.String()), 0o644); err != nil {
+			return fmt.Errorf("could not write mirroring manifest: %v", err)
+		}
+	}
+	if miArgs.Registry != "" {
+		installerScope.Infof("Install from the mirror with --set hub=%s", miArgs.Registry)
+	}
+	return nil
+}
+
+// imageMirror maps an image used by the installation to the reference it is mirrored to.
+type imageMirror struct {
+	// Source is the image in its original registry, pinned to its digest if requested.
+	Source string
+	// Destination is the image in the private registry, or the source image if there is none.
+	Destination string
+	// Pinned is the destination pinned to the source digest, if requested.
+	Pinned string
+}
+
+// imageDigest returns the digest of an image in its registry.
+var imageDigest = func(ref containername.Reference) (string, error) {
+	desc, err := remote.Head(ref, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return "", err
+	}
+	return desc.Digest.String(), nil
+}
+
+// mirrorImages rewrites the images to the registry, keeping the last path element of each repository, and pins
+// them to the source digest if pin is set.
+func mirrorImages(images []string, registry string, pin bool,
+	digest func(containername.Reference) (string, error),
+) ([]imageMirror, error) {
+	out := make([]imageMirror, 0, len(images))
+	for _, image := range images {
+		ref, err := containername.ParseReference(image)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image %q: %v", image, err)
+		}
+		m := imageMirror{Source: image, Destination: image}
+		repo := ref.Context().Name()
+		if registry != "" {
+			repo = strings.TrimSuffix(registry, "/") + "/" + path.Base(ref.Context().RepositoryStr())
+			m.Destination = repo + separator(ref) + ref.Identifier()
+		}
+		if pin {
+			d, err := digest(ref)
+			if err != nil {
+				return nil, fmt.Errorf("could not resolve digest of %s: %v", image, err)
+			}
+			m.Source = ref.Context().Name() + "@" + d
+			// Mirroring copies the content unchanged, so the digest identifies the image in the destination too.
+			m.Pinned = repo + "@" + d
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func separator(ref containername.Reference) string {
+	if _, ok := ref.(containername.Digest); ok {
+		return "@"
+	}
+	return ":"
+}
+
+// imagesFromManifests returns the sorted images of all containers in the manifests, plus the sidecar and init
+// images the sidecar injector of the installation injects into workloads.
+func imagesFromManifests(manifests name.ManifestMap) ([]string, error) {
+	var all []string
+	for _, m := range manifests {
+		all = append(all, m...)
+	}
+	objects, err := object.ParseK8sObjectsFromYAMLManifest(strings.Join(all, helm.YAMLSeparator))
+	if err != nil {
+		return nil, err
+	}
+	images := map[string]struct{}{}
+	var injectorValues, meshConfig string
+	for _, o := range objects {
+		u := o.UnstructuredObject().Object
+		collectContainerImages(u, images)
+		if o.Kind != name.CMStr {
+			continue
+		}
+		data, _ := u["data"].(map[string]any)
+		switch {
+		case strings.HasPrefix(o.Name, "istio-sidecar-injector"):
+			injectorValues, _ = data["values"].(string)
+		case o.Name == "istio" || strings.HasPrefix(o.Name, "istio-"):
+			if m, ok := data["mesh"].(string); ok {
+				meshConfig = m
+			}
+		}
+	}
+	if injectorValues != "" {
+		injected, err := injectedImages(injectorValues, meshConfig)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range injected {
+			images[i] = struct{}{}
+		}
+	}
+	out := make([]string, 0, len(images))
+	for i := range images {
+		// Gateway deployments using injection have the placeholder image "auto".
+		if i != "auto" {
+			out = append(out, i)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// collectContainerImages walks an object and adds the images of all containers and init containers in it.
+func collectContainerImages(v any, images map[string]struct{}) {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, f := range t {
+			if k == "containers" || k == "initContainers" {
+				if l, ok := f.([]any); ok {
+					for _, c := range l {
+						if cm, ok := c.(map[string]any); ok {
+							if image, ok := cm["image"].(string); ok && image != "" {
+								images[image] = struct{}{}
+							}
+						}
+					}
+				}
+			}
+			collectContainerImages(f, images)
+		}
+	case []any:
+		for _, e := range t {
+			collectContainerImages(e, images)
+		}
+	}
+}
+
+// injectedImages returns the images injected with the given sidecar injector values and mesh config.
+func injectedImages(values, meshConfig string) ([]string, error) {
+	v := &v1alpha1.Values{}
+	if err := protomarshal.ApplyYAML(values, v); err != nil {
+		return nil, fmt.Errorf("could not parse sidecar injector values: %v", err)
+	}
+	mc := mesh.DefaultMeshConfig()
+	if meshConfig != "" {
+		var err error
+		if mc, err = mesh.ApplyMeshConfigDefaults(meshConfig); err != nil {
+			return nil, fmt.Errorf("could not parse mesh config: %v", err)
+		}
+	}
+	proxyImage := inject.ProxyImage(v, mc.GetDefaultConfig().GetImage(), nil)
+	images := []string{proxyImage}
+	// Images containing a "/" are used as is by the injection template.
+	if i := v.GetGlobal().GetProxy().GetImage(); strings.Contains(i, "/") {
+		images[0] = i
+	}
+	if i := v.GetGlobal().GetProxyInit().GetImage(); strings.Contains(i, "/") {
+		images = append(images, i)
+	}
+	return images, nil
+}
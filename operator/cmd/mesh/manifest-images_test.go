@@ -0,0 +1,112 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mesh
+
+import (
+	"testing"
+
+	containername "github.com/google/go-containerregistry/pkg/name"
+
+	"istio.io/istio/operator/pkg/name"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+const imagesTestManifest = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: istiod
+  namespace: istio-system
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: docker.io/istio/proxyv2:1.15.0
+      containers:
+      - name: discovery
+        image: docker.io/istio/pilot:1.15.0
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: istio-ingressgateway
+  namespace: istio-system
+spec:
+  template:
+    spec:
+      containers:
+      - name: istio-proxy
+        image: auto
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: istio
+  namespace: istio-system
+data:
+  mesh: |-
+    defaultConfig:
+      image:
+        imageType: distroless
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: istio-sidecar-injector
+  namespace: istio-system
+data:
+  values: |-
+    {"global": {"hub": "docker.io/istio", "tag": "1.15.0", "proxy": {"image": "proxyv2"}}}
+`
+
+func TestImagesFromManifests(t *testing.T) {
+	images, err := imagesFromManifests(name.ManifestMap{name.PilotComponentName: {imagesTestManifest}})
+	assert.NoError(t, err)
+	assert.Equal(t, images, []string{
+		"docker.io/istio/pilot:1.15.0",
+		"docker.io/istio/proxyv2:1.15.0",
+		"docker.io/istio/proxyv2:1.15.0-distroless",
+	})
+}
+
+func TestMirrorImages(t *testing.T) {
+	digest := func(ref containername.Reference) (string, error) {
+		return "sha256:" + ref.Context().RepositoryStr(), nil
+	}
+	images := []string{"docker.io/istio/pilot:1.15.0", "gcr.io/istio-release/proxyv2:1.15.0-distroless"}
+
+	got, err := mirrorImages(images, "", false, digest)
+	assert.NoError(t, err)
+	assert.Equal(t, got, []imageMirror{
+		{Source: images[0], Destination: images[0]},
+		{Source: images[1], Destination: images[1]},
+	})
+
+	got, err = mirrorImages(images, "registry.example.com/mirror/", true, digest)
+	assert.NoError(t, err)
+	assert.Equal(t, got, []imageMirror{
+		{
+			Source:      "index.docker.io/istio/pilot@sha256:istio/pilot",
+			Destination: "registry.example.com/mirror/pilot:1.15.0",
+			Pinned:      "registry.example.com/mirror/pilot@sha256:istio/pilot",
+		},
+		{
+			Source:      "gcr.io/istio-release/proxyv2@sha256:istio-release/proxyv2",
+			Destination: "registry.example.com/mirror/proxyv2:1.15.0-distroless",
+			Pinned:      "registry.example.com/mirror/proxyv2@sha256:istio-release/proxyv2",
+		},
+	})
+}
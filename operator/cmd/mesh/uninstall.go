@@ -33,6 +33,7 @@ import (
 	"istio.io/istio/operator/pkg/util/clog"
 	"istio.io/istio/operator/pkg/util/progress"
 	proxyinfo "istio.io/istio/pkg/proxy"
+	"istio.io/istio/pkg/util/sets"
 	"istio.io/pkg/log"
 )
 
@@ -61,6 +62,9 @@ type uninstallArgs struct {
 	manifestsPath string
 	// verbose generates verbose output.
 	verbose bool
+	// cascade also removes the resources which would be left depending on the removed ones, such as webhook
+	// configurations calling the removed control plane, and the injection labels of the namespaces.
+	cascade bool
 }
 
 const (
@@ -69,6 +73,8 @@ const (
 	GatewaysRemovedWarning     = "You are about to remove the following gateways: %s." +
 		" To avoid downtime, please quit this command and reinstall the gateway(s) with a revision that is not being removed from the cluster.\n"
 	PurgeWithRevisionOrOperatorSpecifiedWarning = "Purge uninstall will remove all Istio resources, ignoring the specified revision or operator file"
+	DependentResourcesWarning                   = "Resources which are not removed depend on the removed ones, see above." +
+		" Remove them first, or rerun with --cascade to remove them too.\n"
 )
 
 func addUninstallFlags(cmd *cobra.Command, args *uninstallArgs) {
@@ -85,6 +91,10 @@ func addUninstallFlags(cmd *cobra.Command, args *uninstallArgs) {
 	cmd.PersistentFlags().StringVarP(&args.manifestsPath, "manifests", "d", "", ManifestsFlagHelpStr)
 	cmd.PersistentFlags().StringArrayVarP(&args.set, "set", "s", nil, setFlagHelpStr)
 	cmd.PersistentFlags().BoolVarP(&args.verbose, "verbose", "v", false, "Verbose output.")
+	cmd.PersistentFlags().BoolVar(&args.cascade, "cascade", false,
+		"Also remove the webhook configurations which call the removed control plane, such as revision tags, the "+
+			"custom resources of the removed CustomResourceDefinitions, and the injection labels of the namespaces "+
+			"labeled for the removed control plane. Injected pods keep their sidecar until they are restarted")
 }
 
 // UninstallCmd command uninstalls Istio from a cluster
@@ -102,7 +112,10 @@ func UninstallCmd(logOpts *log.Options) *cobra.Command {
   istioctl uninstall -f iop.yaml
   
   # Uninstall all control planes and shared resources
-  istioctl uninstall --purge`,
+  istioctl uninstall --purge
+
+  # Uninstall a control plane along with the revision tags pointing to it
+  istioctl uninstall --revision foo --cascade`,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if uiArgs.revision == "" && manifest.GetValueForSetFlag(uiArgs.set, "revision") == "" && uiArgs.filename == "" && !uiArgs.purge {
 				return fmt.Errorf("at least one of the --revision (or --set revision=<revision>), --filename or --purge flags must be set")
@@ -156,8 +169,22 @@ func uninstall(cmd *cobra.Command, rootArgs *RootArgs, uiArgs *uninstallArgs, lo
 		if err != nil {
 			return err
 		}
-		preCheckWarnings(cmd, uiArgs, uiArgs.revision, objectsList, nil, l)
+		var items []unstructured.Unstructured
+		for _, ul := range objectsList {
+			items = append(items, ul.Items...)
+		}
+		plan, err := buildUninstallPlan(kubeClient, items, removedRevisions(uiArgs.revision, uiArgs.purge))
+		if err != nil {
+			return err
+		}
+		preCheckWarnings(cmd, uiArgs, uiArgs.revision, objectsList, nil, plan, rootArgs.DryRun, l)
 
+		if uiArgs.cascade {
+			if err := plan.removeInjectionLabels(kubeClient, rootArgs.DryRun, l); err != nil {
+				return err
+			}
+			objectsList = append(plan.cascadedResources(), objectsList...)
+		}
 		if err := h.DeleteObjectsList(objectsList, ""); err != nil {
 			return fmt.Errorf("failed to delete control plane resources by revision: %v", err)
 		}
@@ -174,11 +201,30 @@ func uninstall(cmd *cobra.Command, rootArgs *RootArgs, uiArgs *uninstallArgs, lo
 	if err != nil {
 		return err
 	}
-	preCheckWarnings(cmd, uiArgs, iop.Spec.Revision, nil, cpObjects, l)
+	removedObjects := cpObjects
+	if uiArgs.purge {
+		removedObjects, err = object.ParseK8sObjectsFromYAMLManifest(manifestMap.String())
+		if err != nil {
+			return err
+		}
+	}
+	plan, err := buildUninstallPlan(kubeClient, removedObjects.UnstructuredItems(), removedRevisions(iop.Spec.Revision, uiArgs.purge))
+	if err != nil {
+		return err
+	}
+	preCheckWarnings(cmd, uiArgs, iop.Spec.Revision, nil, cpObjects, plan, rootArgs.DryRun, l)
 	h, err = helmreconciler.NewHelmReconciler(client, kubeClient, iop, opts)
 	if err != nil {
 		return fmt.Errorf("failed to create reconciler: %v", err)
 	}
+	if uiArgs.cascade {
+		if err := plan.removeInjectionLabels(kubeClient, rootArgs.DryRun, l); err != nil {
+			return err
+		}
+		if err := h.DeleteObjectsList(plan.cascadedResources(), ""); err != nil {
+			return fmt.Errorf("failed to delete dependent resources: %v", err)
+		}
+	}
 	if err := h.DeleteControlPlaneByManifests(manifestMap, iop.Spec.Revision, uiArgs.purge); err != nil {
 		return fmt.Errorf("failed to delete control plane by manifests: %v", err)
 	}
@@ -186,11 +232,23 @@ func uninstall(cmd *cobra.Command, rootArgs *RootArgs, uiArgs *uninstallArgs, lo
 	return nil
 }
 
+// removedRevisions returns the revisions an uninstall removes, or none for purges, which remove all of them.
+func removedRevisions(revision string, purge bool) sets.Set {
+	if purge {
+		return sets.New()
+	}
+	if revision == "" {
+		return sets.New("default")
+	}
+	return sets.New(revision)
+}
+
 // preCheckWarnings checks possible breaking changes and issue warnings to users, it checks the following:
 // 1. checks proxies still pointing to the target control plane revision.
-// 2. lists to be pruned resources if user uninstall by --revision flag.
-func preCheckWarnings(cmd *cobra.Command, uiArgs *uninstallArgs,
-	rev string, resourcesList []*unstructured.UnstructuredList, objectsList object.K8sObjects, l *clog.ConsoleLogger,
+// 2. lists to be pruned resources by revision for dry runs and verbose output, and the resources depending on them.
+// 3. asks for confirmation if resources which are not removed depend on the removed ones, unless --cascade is set.
+func preCheckWarnings(cmd *cobra.Command, uiArgs *uninstallArgs, rev string, resourcesList []*unstructured.UnstructuredList,
+	objectsList object.K8sObjects, plan *uninstallPlan, dryRun bool, l *clog.ConsoleLogger,
 ) {
 	pids, err := proxyinfo.GetIDsFromProxyInfo(uiArgs.kubeConfigPath, uiArgs.context, rev, uiArgs.istioNamespace)
 	if err != nil {
@@ -200,15 +258,16 @@ func preCheckWarnings(cmd *cobra.Command, uiArgs *uninstallArgs,
 	if uiArgs.purge {
 		needConfirmation = true
 		message += AllResourcesRemovedWarning
+		message += plan.describe(uiArgs.verbose || dryRun)
 	} else {
 		rmListString, gwList := constructResourceListOutput(resourcesList, objectsList)
 		if rmListString == "" {
 			l.LogAndPrint(NoResourcesRemovedWarning)
 			return
 		}
-		if uiArgs.verbose {
-			message += fmt.Sprintf("The following resources will be pruned from the cluster: %s\n",
-				rmListString)
+		message += plan.describe(uiArgs.verbose || dryRun)
+		if len(plan.injectedWorkloads) != 0 {
+			needConfirmation = true
 		}
 
 		if len(pids) != 0 && rev != "" {
@@ -226,6 +285,14 @@ func preCheckWarnings(cmd *cobra.Command, uiArgs *uninstallArgs,
 			message += fmt.Sprintf(GatewaysRemovedWarning, gwList)
 		}
 	}
+	if plan.blocked() {
+		needConfirmation = true
+		if uiArgs.cascade {
+			message += "These dependent resources will be removed too, as --cascade is set.\n"
+		} else {
+			message += DependentResourcesWarning
+		}
+	}
 	if uiArgs.skipConfirmation {
 		l.LogAndPrint(message)
 		return
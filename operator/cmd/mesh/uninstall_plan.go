@@ -0,0 +1,347 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mesh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"istio.io/api/annotation"
+	"istio.io/api/label"
+	"istio.io/istio/operator/pkg/object"
+	"istio.io/istio/operator/pkg/util/clog"
+	analyzer_util "istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/inject"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// sharedRevision groups the removed resources which belong to no revision, such as CRDs.
+const sharedRevision = "<shared>"
+
+// uninstallPlan is what an uninstall removes from the cluster, and what it leaves behind depending on the removed
+// resources.
+type uninstallPlan struct {
+	// resources are the names of the removed resources, by revision and kind.
+	resources map[string]map[string][]string
+	// orphanedWebhooks are the webhook configurations which are not removed but call a removed service. Their
+	// failure policy may block the creation of the resources they intercept, such as pods, once the service is gone.
+	orphanedWebhooks []unstructured.Unstructured
+	// dependentResources are the numbers of remaining custom resources of the removed CustomResourceDefinitions,
+	// which Kubernetes deletes with them.
+	dependentResources map[string]int
+	// dependentItems are the remaining custom resources of the removed CustomResourceDefinitions.
+	dependentItems []unstructured.Unstructured
+	// injectedWorkloads are the pods of the namespaces labeled for injection whose sidecar was injected by a removed
+	// revision.
+	injectedWorkloads []string
+	// injectionNamespaces are the namespaces labeled for injection by a removed revision.
+	injectionNamespaces []string
+	// injectionLabels are the injection labels of the injectionNamespaces, by namespace.
+	injectionLabels map[string]string
+	// warnings are the parts of the plan which could not be computed.
+	warnings []string
+}
+
+// blocked returns whether uninstalling as planned leaves resources depending on the removed ones behind.
+func (p *uninstallPlan) blocked() bool {
+	return len(p.orphanedWebhooks) > 0 || len(p.dependentResources) > 0
+}
+
+// cascadedResources returns the resources to remove on top of the planned ones to not leave orphans behind. They
+// are removed before the planned ones, so that the webhooks stop calling the control plane before it goes away.
+func (p *uninstallPlan) cascadedResources() []*unstructured.UnstructuredList {
+	var out []*unstructured.UnstructuredList
+	if len(p.orphanedWebhooks) > 0 {
+		out = append(out, &unstructured.UnstructuredList{Items: p.orphanedWebhooks})
+	}
+	if len(p.dependentItems) > 0 {
+		out = append(out, &unstructured.UnstructuredList{Items: p.dependentItems})
+	}
+	return out
+}
+
+// removeInjectionLabels removes the injection labels of the namespaces labeled for injection by a removed revision,
+// so that new pods are not sent to a missing injector. The pods already injected keep their sidecar until they are
+// restarted.
+func (p *uninstallPlan) removeInjectionLabels(kubeClient kube.Client, dryRun bool, l *clog.ConsoleLogger) error {
+	for _, ns := range p.injectionNamespaces {
+		key := p.injectionLabels[ns]
+		if dryRun {
+			l.LogAndPrintf("Not removing label %s of namespace %s in dry run mode", key, ns)
+			continue
+		}
+		patch := fmt.Sprintf(`{"metadata":{"labels":{%q:null}}}`, key)
+		if _, err := kubeClient.Kube().CoreV1().Namespaces().Patch(context.TODO(), ns, types.MergePatchType,
+			[]byte(patch), metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("failed to remove label %s of namespace %s: %v", key, ns, err)
+		}
+		l.LogAndPrintf("Removed label %s of namespace %s", key, ns)
+	}
+	return nil
+}
+
+// buildUninstallPlan computes the plan of removing the items. The revisions are the removed revisions, or all of
+// them when empty, as for purges.
+func buildUninstallPlan(kubeClient kube.Client, items []unstructured.Unstructured, revisions sets.Set) (*uninstallPlan, error) {
+	plan := &uninstallPlan{
+		resources:          map[string]map[string][]string{},
+		dependentResources: map[string]int{},
+		injectionLabels:    map[string]string{},
+	}
+	removed := sets.New()
+	removedServices := sets.New()
+	var crds []unstructured.Unstructured
+	for _, o := range items {
+		h := object.NewK8sObject(&o, nil, nil).Hash()
+		if removed.Contains(h) {
+			continue
+		}
+		removed.Insert(h)
+		rev := o.GetLabels()[label.IoIstioRev.Name]
+		if rev == "" {
+			rev = sharedRevision
+		}
+		if plan.resources[rev] == nil {
+			plan.resources[rev] = map[string][]string{}
+		}
+		n := o.GetName()
+		if o.GetNamespace() != "" {
+			n = o.GetNamespace() + "/" + n
+		}
+		plan.resources[rev][o.GetKind()] = append(plan.resources[rev][o.GetKind()], n)
+		switch o.GetKind() {
+		case "Service":
+			removedServices.Insert(o.GetNamespace() + "/" + o.GetName())
+		case "CustomResourceDefinition":
+			crds = append(crds, o)
+		}
+	}
+
+	// The plan informs the uninstall, it does not gate it: what cannot be computed is reported instead.
+	if err := plan.findOrphanedWebhooks(kubeClient, removed, removedServices); err != nil {
+		plan.warnings = append(plan.warnings, fmt.Sprintf("cannot check for webhook configurations calling the removed control plane: %v", err))
+	}
+	plan.findDependentResources(kubeClient, removed, crds)
+	if err := plan.findInjectedWorkloads(kubeClient, revisions); err != nil {
+		plan.warnings = append(plan.warnings, fmt.Sprintf("cannot check for workloads injected by the removed control plane: %v", err))
+	}
+	return plan, nil
+}
+
+func (p *uninstallPlan) findOrphanedWebhooks(kubeClient kube.Client, removed, removedServices sets.Set) error {
+	callsRemovedService := func(ref *admissionregistrationv1.ServiceReference) bool {
+		return ref != nil && removedServices.Contains(ref.Namespace+"/"+ref.Name)
+	}
+	var orphans []runtime.Object
+	mwhs, err := kubeClient.Kube().AdmissionregistrationV1().MutatingWebhookConfigurations().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list mutating webhook configurations: %v", err)
+	}
+	for i, mwh := range mwhs.Items {
+		for _, wh := range mwh.Webhooks {
+			if callsRemovedService(wh.ClientConfig.Service) {
+				mwhs.Items[i].SetGroupVersionKind(admissionregistrationv1.SchemeGroupVersion.WithKind("MutatingWebhookConfiguration"))
+				orphans = append(orphans, &mwhs.Items[i])
+				break
+			}
+		}
+	}
+	vwhs, err := kubeClient.Kube().AdmissionregistrationV1().ValidatingWebhookConfigurations().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list validating webhook configurations: %v", err)
+	}
+	for i, vwh := range vwhs.Items {
+		for _, wh := range vwh.Webhooks {
+			if callsRemovedService(wh.ClientConfig.Service) {
+				vwhs.Items[i].SetGroupVersionKind(admissionregistrationv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfiguration"))
+				orphans = append(orphans, &vwhs.Items[i])
+				break
+			}
+		}
+	}
+	for _, o := range orphans {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(o)
+		if err != nil {
+			return err
+		}
+		us := unstructured.Unstructured{Object: u}
+		if !removed.Contains(object.NewK8sObject(&us, nil, nil).Hash()) {
+			p.orphanedWebhooks = append(p.orphanedWebhooks, us)
+		}
+	}
+	return nil
+}
+
+func (p *uninstallPlan) findDependentResources(kubeClient kube.Client, removed sets.Set, crds []unstructured.Unstructured) {
+	for _, crd := range crds {
+		group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+		resource, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+		versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+		version := ""
+		for _, v := range versions {
+			v, ok := v.(map[string]any)
+			if !ok {
+				continue
+			}
+			if served, _, _ := unstructured.NestedBool(v, "served"); served {
+				version, _, _ = unstructured.NestedString(v, "name")
+				break
+			}
+		}
+		if group == "" || resource == "" || version == "" {
+			continue
+		}
+		gvr := schema.GroupVersionResource{Group: group, Version: version, Resource: resource}
+		crs, err := kubeClient.Dynamic().Resource(gvr).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			// The CRD is not installed in the cluster, so there is nothing depending on it.
+			continue
+		}
+		count := 0
+		for i := range crs.Items {
+			if !removed.Contains(object.NewK8sObject(&crs.Items[i], nil, nil).Hash()) {
+				p.dependentItems = append(p.dependentItems, crs.Items[i])
+				count++
+			}
+		}
+		if count > 0 {
+			p.dependentResources[crd.GetName()] = count
+		}
+	}
+}
+
+// findInjectedWorkloads finds the namespaces labeled for injection by a removed revision, and the pods injected by a
+// removed revision in the namespaces labeled for injection by any revision, the only ones the injectors handle.
+func (p *uninstallPlan) findInjectedWorkloads(kubeClient kube.Client, revisions sets.Set) error {
+	removedRevision := func(rev string) bool {
+		return revisions.IsEmpty() || revisions.Contains(rev)
+	}
+	namespaces, err := kubeClient.Kube().CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces: %v", err)
+	}
+	var injected []string
+	for _, ns := range namespaces.Items {
+		key := label.IoIstioRev.Name
+		rev, ok := ns.Labels[key]
+		if !ok && ns.Labels[analyzer_util.InjectionLabelName] == "enabled" {
+			key, rev, ok = analyzer_util.InjectionLabelName, "default", true
+		}
+		if !ok {
+			continue
+		}
+		injected = append(injected, ns.Name)
+		if removedRevision(rev) {
+			p.injectionNamespaces = append(p.injectionNamespaces, ns.Name)
+			p.injectionLabels[ns.Name] = key
+		}
+	}
+	for _, ns := range injected {
+		pods, err := kubeClient.Kube().CoreV1().Pods(ns).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list the pods of namespace %s: %v", ns, err)
+		}
+		for _, pod := range pods.Items {
+			status, ok := pod.Annotations[annotation.SidecarStatus.Name]
+			if !ok {
+				continue
+			}
+			var injection inject.SidecarInjectionStatus
+			if err := json.Unmarshal([]byte(status), &injection); err != nil {
+				continue
+			}
+			rev := injection.Revision
+			if rev == "" {
+				rev = "default"
+			}
+			if removedRevision(rev) {
+				p.injectedWorkloads = append(p.injectedWorkloads, pod.Namespace+"/"+pod.Name)
+			}
+		}
+	}
+	sort.Strings(p.injectedWorkloads)
+	sort.Strings(p.injectionNamespaces)
+	return nil
+}
+
+// describe describes the plan: the removed resources by revision, followed by what depends on them. The removed
+// resources and the injected pods are only listed in full, for dry runs and verbose output.
+func (p *uninstallPlan) describe(full bool) string {
+	var sb strings.Builder
+	if full {
+		revisions := make([]string, 0, len(p.resources))
+		for rev := range p.resources {
+			revisions = append(revisions, rev)
+		}
+		sort.Strings(revisions)
+		sb.WriteString("The following resources will be pruned from the cluster:\n")
+		for _, rev := range revisions {
+			fmt.Fprintf(&sb, "  Revision %s:\n", rev)
+			kinds := make([]string, 0, len(p.resources[rev]))
+			for kind := range p.resources[rev] {
+				kinds = append(kinds, kind)
+			}
+			sort.Strings(kinds)
+			for _, kind := range kinds {
+				names := p.resources[rev][kind]
+				sort.Strings(names)
+				fmt.Fprintf(&sb, "    %s: %s\n", kind, strings.Join(names, ", "))
+			}
+		}
+	}
+	if len(p.orphanedWebhooks) > 0 {
+		sb.WriteString("The following webhook configurations call the removed control plane but are not part of it:\n")
+		for _, wh := range p.orphanedWebhooks {
+			fmt.Fprintf(&sb, "    %s: %s\n", wh.GetKind(), wh.GetName())
+		}
+	}
+	if len(p.dependentResources) > 0 {
+		sb.WriteString("The following custom resources will be deleted with their CustomResourceDefinitions:\n")
+		crds := make([]string, 0, len(p.dependentResources))
+		for crd := range p.dependentResources {
+			crds = append(crds, crd)
+		}
+		sort.Strings(crds)
+		for _, crd := range crds {
+			fmt.Fprintf(&sb, "    %s: %d\n", crd, p.dependentResources[crd])
+		}
+	}
+	if len(p.injectedWorkloads) > 0 {
+		fmt.Fprintf(&sb, "There are still %d pods injected by the removed control plane", len(p.injectedWorkloads))
+		if full {
+			fmt.Fprintf(&sb, ": %s", strings.Join(p.injectedWorkloads, ", "))
+		}
+		sb.WriteString("\n")
+	}
+	if len(p.injectionNamespaces) > 0 {
+		fmt.Fprintf(&sb, "The following namespaces are still labeled for injection by the removed control plane: %s\n",
+			strings.Join(p.injectionNamespaces, ", "))
+	}
+	for _, w := range p.warnings {
+		fmt.Fprintf(&sb, "Warning: %s\n", w)
+	}
+	return sb.String()
+}
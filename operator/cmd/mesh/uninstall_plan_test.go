@@ -0,0 +1,216 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mesh
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"istio.io/api/annotation"
+	"istio.io/api/label"
+	"istio.io/istio/operator/pkg/util/clog"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/util/sets"
+)
+
+func TestBuildUninstallPlan(t *testing.T) {
+	toUnstructured := func(o runtime.Object, kind string) unstructured.Unstructured {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		us := unstructured.Unstructured{Object: u}
+		us.SetKind(kind)
+		us.SetAPIVersion("v1")
+		return us
+	}
+	revLabels := func(rev string) map[string]string {
+		return map[string]string{label.IoIstioRev.Name: rev}
+	}
+	mutatingWebhook := func(name, rev, service string) *admissionregistrationv1.MutatingWebhookConfiguration {
+		return &admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: revLabels(rev)},
+			Webhooks: []admissionregistrationv1.MutatingWebhook{{
+				Name: "rev.namespace.sidecar-injector.istio.io",
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service: &admissionregistrationv1.ServiceReference{Namespace: "istio-system", Name: service},
+				},
+			}},
+		}
+	}
+	injectedPod := func(ns, name, rev string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   ns,
+			Annotations: map[string]string{annotation.SidecarStatus.Name: `{"revision":"` + rev + `"}`},
+		}}
+	}
+
+	istiod := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "istiod-canary", Namespace: "istio-system", Labels: revLabels("canary")}}
+	injector := mutatingWebhook("istio-sidecar-injector-canary", "canary", "istiod-canary")
+	tag := mutatingWebhook("istio-revision-tag-prod", "canary", "istiod-canary")
+	otherRevision := mutatingWebhook("istio-sidecar-injector-stable", "stable", "istiod-stable")
+	crd := unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]any{"name": "kind1s.testdata.istio.io"},
+		"spec": map[string]any{
+			"group":    "testdata.istio.io",
+			"names":    map[string]any{"plural": "Kind1s"},
+			"versions": []any{map[string]any{"name": "v1alpha1", "served": true}},
+		},
+	}}
+
+	kubeClient := kube.NewFakeClient(
+		istiod, injector, tag, otherRevision,
+		injectedPod("default", "canary-pod", "canary"),
+		injectedPod("default", "stable-pod", "stable"),
+		// The pods of the namespaces which are not labeled for injection are not checked.
+		injectedPod("unlabeled", "stale-pod", "canary"),
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "uninjected", Namespace: "default"}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "canary-ns", Labels: revLabels("canary")}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{"istio-injection": "enabled"}}},
+	)
+	// The fake dynamic client only lists resources which are not part of its scheme, such as this test kind.
+	cr := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "testdata.istio.io/v1alpha1",
+		"kind":       "Kind1",
+		"metadata":   map[string]any{"name": "cr", "namespace": "default"},
+	}}
+	gvr := schema.GroupVersionResource{Group: "testdata.istio.io", Version: "v1alpha1", Resource: "Kind1s"}
+	if _, err := kubeClient.Dynamic().Resource(gvr).Namespace("default").Create(context.TODO(), cr, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("revision", func(t *testing.T) {
+		items := []unstructured.Unstructured{
+			toUnstructured(istiod, "Service"),
+			toUnstructured(injector, "MutatingWebhookConfiguration"),
+		}
+		plan, err := buildUninstallPlan(kubeClient, items, sets.New("canary"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantResources := map[string]map[string][]string{
+			"canary": {
+				"Service":                      {"istio-system/istiod-canary"},
+				"MutatingWebhookConfiguration": {"istio-sidecar-injector-canary"},
+			},
+		}
+		if !reflect.DeepEqual(plan.resources, wantResources) {
+			t.Errorf("got resources %v, want %v", plan.resources, wantResources)
+		}
+		if len(plan.orphanedWebhooks) != 1 || plan.orphanedWebhooks[0].GetName() != "istio-revision-tag-prod" {
+			t.Errorf("got orphaned webhooks %v, want istio-revision-tag-prod", plan.orphanedWebhooks)
+		}
+		if got := plan.orphanedWebhooks[0].GetKind(); got != "MutatingWebhookConfiguration" {
+			t.Errorf("got orphaned webhook kind %q", got)
+		}
+		if !plan.blocked() {
+			t.Errorf("expected the plan to be blocked by the orphaned webhook")
+		}
+		if want := []string{"default/canary-pod"}; !reflect.DeepEqual(plan.injectedWorkloads, want) {
+			t.Errorf("got injected workloads %v, want %v", plan.injectedWorkloads, want)
+		}
+		if want := []string{"canary-ns"}; !reflect.DeepEqual(plan.injectionNamespaces, want) {
+			t.Errorf("got injection namespaces %v, want %v", plan.injectionNamespaces, want)
+		}
+		out := plan.describe(true)
+		for _, want := range []string{
+			"Revision canary:\n    MutatingWebhookConfiguration: istio-sidecar-injector-canary\n    Service: istio-system/istiod-canary\n",
+			"MutatingWebhookConfiguration: istio-revision-tag-prod",
+			"There are still 1 pods injected by the removed control plane: default/canary-pod",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("plan %q does not contain %q", out, want)
+			}
+		}
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		items := []unstructured.Unstructured{
+			toUnstructured(istiod, "Service"),
+			toUnstructured(injector, "MutatingWebhookConfiguration"),
+			toUnstructured(tag, "MutatingWebhookConfiguration"),
+			crd,
+		}
+		plan, err := buildUninstallPlan(kubeClient, items, sets.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(plan.orphanedWebhooks) != 0 {
+			t.Errorf("got orphaned webhooks %v, want none", plan.orphanedWebhooks)
+		}
+		if want := map[string]int{"kind1s.testdata.istio.io": 1}; !reflect.DeepEqual(plan.dependentResources, want) {
+			t.Errorf("got dependent resources %v, want %v", plan.dependentResources, want)
+		}
+		if want := []string{"default/canary-pod", "default/stable-pod"}; !reflect.DeepEqual(plan.injectedWorkloads, want) {
+			t.Errorf("got injected workloads %v, want %v", plan.injectedWorkloads, want)
+		}
+		if want := []string{"canary-ns", "default"}; !reflect.DeepEqual(plan.injectionNamespaces, want) {
+			t.Errorf("got injection namespaces %v, want %v", plan.injectionNamespaces, want)
+		}
+		if !strings.Contains(plan.describe(true), "Revision <shared>:\n    CustomResourceDefinition: kind1s.testdata.istio.io\n") {
+			t.Errorf("unexpected plan %q", plan.describe(true))
+		}
+		if strings.Contains(plan.describe(false), "will be pruned") {
+			t.Errorf("expected the removed resources to only be listed in full, got %q", plan.describe(false))
+		}
+		cascaded := plan.cascadedResources()
+		if len(cascaded) != 1 || len(cascaded[0].Items) != 1 || cascaded[0].Items[0].GetName() != "cr" {
+			t.Errorf("got cascaded resources %v, want the custom resource cr", cascaded)
+		}
+
+		if err := plan.removeInjectionLabels(kubeClient, false, clog.NewDefaultLogger()); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"canary-ns", "default"} {
+			ns, err := kubeClient.Kube().CoreV1().Namespaces().Get(context.TODO(), name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ns.Labels) != 0 {
+				t.Errorf("got labels %v of namespace %s, want none", ns.Labels, name)
+			}
+		}
+	})
+}
+
+func TestBuildUninstallPlanWebhookListFailure(t *testing.T) {
+	kubeClient := kube.NewFakeClient()
+	kubeClient.Kube().(*fake.Clientset).PrependReactor("list", "mutatingwebhookconfigurations",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("forbidden")
+		})
+	plan, err := buildUninstallPlan(kubeClient, nil, sets.New("canary"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Warning: cannot check for webhook configurations calling the removed control plane"; !strings.Contains(plan.describe(false), want) {
+		t.Errorf("plan %q does not contain %q", plan.describe(false), want)
+	}
+}
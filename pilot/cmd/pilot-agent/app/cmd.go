@@ -124,6 +124,9 @@ func newProxyCommand() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if proxy.Type == model.Router {
+				secOpts.DNSNames = config.GatewayDNSSANs()
+			}
 
 			// If security token service (STS) port is not zero, start STS server and
 			// listen on STS port for STS requests. For STS, see
@@ -63,6 +63,8 @@ var (
 			command := &request.Command{
 				Address: fmt.Sprintf("localhost:%d", debugRequestPort),
 				Client: &http.Client{
+					// The drain requests of the preStop hook of gateways wait for the drain health check delay,
+					// which the status server keeps below this timeout.
 					Timeout: 60 * time.Second,
 				},
 			}
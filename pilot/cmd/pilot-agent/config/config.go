@@ -27,6 +27,7 @@ import (
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/util/network"
 	"istio.io/istio/pkg/bootstrap"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/config/validation"
 	"istio.io/pkg/log"
@@ -152,6 +153,26 @@ func applyAnnotations(config *meshconfig.ProxyConfig, annos map[string]string) *
 	return config
 }
 
+// GatewayDNSSANs returns the DNS names requested for the certificate of a gateway by the GatewayDNSSANsAnnotation of
+// its pod.
+func GatewayDNSSANs() []string {
+	annotations, err := bootstrap.ReadPodAnnotations("")
+	if err != nil {
+		return nil
+	}
+	return parseDNSSANs(annotations[constants.GatewayDNSSANsAnnotation])
+}
+
+func parseDNSSANs(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 func GetPilotSan(discoveryAddress string) string {
 	discHost := strings.Split(discoveryAddress, ":")[0]
 	// For local debugging - the discoveryAddress is set to localhost, but the cert issued for normal SA.
@@ -62,6 +62,14 @@ var (
 		"The output directory for the key and certificate. If empty, key and certificate will not be saved. "+
 			"Must be set for VMs using provisioning certificates.").Get()
 
+	outputCertsFileMode = env.RegisterStringVar("OUTPUT_CERTS_FILE_MODE", "",
+		"The octal file mode of the key and certificates written to OUTPUT_CERTS, for example 0640. "+
+			"If empty, 0644 is used when running on Kubernetes and 0600 otherwise.").Get()
+
+	outputCertsAtomic = env.RegisterBoolVar("OUTPUT_CERTS_ATOMIC", false,
+		"If enabled, the key and certificates in OUTPUT_CERTS are symlinks into a versioned directory that is "+
+			"switched atomically on rotation, so applications never read a key that does not match the certificate chain.").Get()
+
 	caProviderEnv = env.RegisterStringVar("CA_PROVIDER", "Citadel", "name of authentication provider").Get()
 	caEndpointEnv = env.RegisterStringVar("CA_ADDR", "", "Address of the spiffe certificate provider. Defaults to discoveryAddress").Get()
 
@@ -74,6 +82,11 @@ var (
 	fileDebounceDuration = env.RegisterDurationVar("FILE_DEBOUNCE_DURATION", 100*time.Millisecond,
 		"The duration for which the file read operation is delayed once file update is detected").Get()
 
+	verifyCACRLEnv = env.RegisterBoolVar("VERIFY_CA_CRL", false,
+		"If enabled, the proxy checks the peer certificates against the CRLs published by the istiod CA in the "+
+			"istio-ca-root-cert ConfigMap, so that the revocation of namespace CAs is enforced. Peer certificates whose "+
+			"issuer has no CRL there are rejected, such as those of other clusters or of external CAs.").Get()
+
 	secretRotationGracePeriodRatioEnv = env.RegisterFloatVar("SECRET_GRACE_PERIOD_RATIO", 0.5,
 		"The grace period ratio for the cert rotation, by default 0.5.").Get()
 	workloadRSAKeySizeEnv = env.RegisterIntVar("WORKLOAD_RSA_KEY_SIZE", 2048,
@@ -144,6 +157,10 @@ var (
 		"Override the ServerName used to validate Istiod certificate. "+
 			"Can be used as an alternative to setting /etc/hosts for VMs - discovery address will be an IP:port")
 
+	xdsCompression = env.RegisterStringVar("XDS_COMPRESSION", "",
+		"If set, the xDS streams to istiod are compressed with this compressor, one of gzip|zstd, "+
+			"trading CPU for bandwidth. Disabled if empty.").Get()
+
 	minimumDrainDurationEnv = env.RegisterDurationVar("MINIMUM_DRAIN_DURATION",
 		5*time.Second,
 		"The minimum duration for which agent waits before it checks for active connections and terminates proxy"+
@@ -152,4 +169,14 @@ var (
 	exitOnZeroActiveConnectionsEnv = env.RegisterBoolVar("EXIT_ON_ZERO_ACTIVE_CONNECTIONS",
 		false,
 		"When set to true, terminates proxy when number of active connections become zero during draining").Get()
+
+	drainHealthCheckPathEnv = env.RegisterStringVar("DRAIN_HEALTH_CHECK_PATH", "",
+		"If set, the status port serves a health check on this path for external load balancers, which starts failing "+
+			"once the proxy is drained by a POST to /drain on the status port, typically from the preStop hook of gateways.").Get()
+
+	drainHealthCheckDelayEnv = env.RegisterDurationVar("DRAIN_HEALTH_CHECK_DELAY", 0,
+		"The duration a POST to /drain on the status port waits after failing the drain health check, so that external "+
+			"load balancers stop sending new connections before the proxy is terminated. It is at most 55s, for the "+
+			"request to return before the timeout of pilot-agent request, and together with MINIMUM_DRAIN_DURATION must "+
+			"be shorter than the termination grace period of the pod.").Get()
 )
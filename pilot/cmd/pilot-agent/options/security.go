@@ -16,6 +16,8 @@ package options
 
 import (
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
 
 	meshconfig "istio.io/api/mesh/v1alpha1"
@@ -37,6 +39,7 @@ func NewSecurityOptions(proxyConfig *meshconfig.ProxyConfig, stsPort int, tokenM
 		CAProviderName:                 caProviderEnv,
 		PilotCertProvider:              features.PilotCertProvider,
 		OutputKeyCertToDir:             outputKeyCertToDir,
+		OutputKeyCertAtomic:            outputCertsAtomic,
 		ProvCert:                       provCert,
 		ClusterID:                      clusterIDVar.Get(),
 		FileMountedCerts:               fileMountedCertsEnv,
@@ -57,6 +60,17 @@ func NewSecurityOptions(proxyConfig *meshconfig.ProxyConfig, stsPort int, tokenM
 		KeyFilePath:                    security.DefaultKeyFilePath,
 		RootCertFilePath:               security.DefaultRootCertFilePath,
 	}
+	if verifyCACRLEnv {
+		o.CRLFilePath = security.DefaultCRLFilePath
+	}
+
+	if outputCertsFileMode != "" {
+		mode, err := strconv.ParseUint(outputCertsFileMode, 8, 32)
+		if err != nil || mode > 0o777 {
+			return o, fmt.Errorf("invalid OUTPUT_CERTS_FILE_MODE %q: must be an octal file mode such as 0640", outputCertsFileMode)
+		}
+		o.OutputKeyCertFileMode = os.FileMode(mode)
+	}
 
 	o, err := SetupSecurityOptions(proxyConfig, o, jwtPolicy.Get(),
 		credFetcherTypeEnv, credIdentityProvider)
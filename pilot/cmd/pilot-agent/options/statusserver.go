@@ -34,5 +34,11 @@ func NewStatusServerOptions(proxy *model.Proxy, proxyConfig *meshconfig.ProxyCon
 		NoEnvoy:        agent.EnvoyDisabled(),
 		FetchDNS:       agent.GetDNSTable,
 		GRPCBootstrap:  agent.GRPCBootstrapPath(),
+		OnReady:        agent.RecordEnvoyReady,
+		FetchStartupReport: func() any {
+			return agent.StartupReport()
+		},
+		DrainHealthCheckPath:  drainHealthCheckPathEnv,
+		DrainHealthCheckDelay: drainHealthCheckDelayEnv,
 	}
 }
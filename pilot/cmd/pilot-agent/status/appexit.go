@@ -0,0 +1,131 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"istio.io/pkg/env"
+	"istio.io/pkg/log"
+)
+
+// ExitOnApplicationExit is set by the injector on the sidecars of the pods of Jobs, which share their process
+// namespace, for the pods to complete once their application exits.
+var ExitOnApplicationExit = env.RegisterBoolVar("EXIT_ON_APPLICATION_EXIT", false,
+	"If set, pilot-agent exits once the processes of the other containers of the pod have all exited, like on a "+
+		"POST to /quitquitquit on the status port. The pod must share its process namespace, and must not run "+
+		"other long running containers, which keep the proxy running.")
+
+var applicationStartTimeout = env.RegisterDurationVar("APPLICATION_START_TIMEOUT", 2*time.Minute,
+	"With EXIT_ON_APPLICATION_EXIT, how long the application containers have to start. Past it, finding no "+
+		"process of theirs means they already exited, possibly before they were first listed, and pilot-agent "+
+		"exits. If 0, pilot-agent waits for them to start indefinitely.")
+
+// applicationExitPollInterval is the interval at which the processes of the application containers are listed.
+const applicationExitPollInterval = time.Second
+
+// waitForApplicationExit calls exit once the processes of the application containers, listed in the proc directory
+// of the process namespace shared by the pod, have all exited. It first waits up to startTimeout for them to start,
+// as the application may start after the proxy.
+func waitForApplicationExit(ctx context.Context, procDir string, interval, startTimeout time.Duration, exit func()) {
+	self, err := containerOf(procDir, os.Getpid())
+	if err != nil {
+		log.Warnf("failed to find the container of pilot-agent, it will not exit with the application: %v", err)
+		return
+	}
+	pause, err := containerOf(procDir, 1)
+	if err != nil {
+		log.Warnf("failed to find the pause container, pilot-agent will not exit with the application: %v", err)
+		return
+	}
+	if self == pause {
+		// The cgroups are not visible from the container, the processes of the other containers cannot be found.
+		log.Warnf("the containers of the pod cannot be told apart, pilot-agent will not exit with the application")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	startDeadline := time.Now().Add(startTimeout)
+	started := false
+	for {
+		running, err := applicationRunning(procDir, self, pause)
+		switch {
+		case err != nil:
+			log.Warnf("failed to list the processes of the application: %v", err)
+		case running:
+			started = true
+		case started:
+			log.Infof("application containers exited, notifying pilot-agent to exit")
+			exit()
+			return
+		case startTimeout > 0 && time.Now().After(startDeadline):
+			log.Infof("no application container running %v after startup, notifying pilot-agent to exit", startTimeout)
+			exit()
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// containerOf returns the cgroups of the process pid, which identify its container: all the processes of a container,
+// including the ones started by kubectl exec or reparented to the pause process, share its cgroups.
+func containerOf(procDir string, pid int) (string, error) {
+	cgroup, err := os.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return "", err
+	}
+	return string(cgroup), nil
+}
+
+// applicationRunning returns true if a process that is neither in the container of the agent, self, nor in the
+// pause container, pause, is running.
+func applicationRunning(procDir string, self, pause string) (bool, error) {
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		container, err := containerOf(procDir, pid)
+		if err != nil || container == self || container == pause {
+			// The process exited since the directory was listed, or is not one of the application.
+			continue
+		}
+		stat, err := os.ReadFile(filepath.Join(procDir, e.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		// The command name, in parentheses, may contain spaces: the state is the field after it.
+		fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+		if len(fields) > 0 && fields[0] != "Z" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
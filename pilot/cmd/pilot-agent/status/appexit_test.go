@@ -0,0 +1,125 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// fakeProcDir returns a proc directory and a function adding a process to it, in the cgroup of the given container.
+func fakeProcDir(t *testing.T) (string, func(pid int, container, comm, state string)) {
+	procDir := t.TempDir()
+	return procDir, func(pid int, container, comm, state string) {
+		dir := filepath.Join(procDir, strconv.Itoa(pid))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		stat := fmt.Sprintf("%d (%s) %s 0 1 1 0 -1", pid, comm, state)
+		if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cgroup := fmt.Sprintf("0::/kubepods/pod1234/%s\n", container)
+		if err := os.WriteFile(filepath.Join(dir, "cgroup"), []byte(cgroup), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWaitForApplicationExit(t *testing.T) {
+	procDir, addProcess := fakeProcDir(t)
+	removeProcess := func(pid int) {
+		if err := os.RemoveAll(filepath.Join(procDir, strconv.Itoa(pid))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The pause container, the agent and envoy, and a shell started in the proxy container by kubectl exec.
+	addProcess(1, "pause", "pause", "S")
+	addProcess(os.Getpid(), "proxy", "pilot-agent", "S")
+	addProcess(100, "proxy", "envoy", "S")
+	addProcess(101, "proxy", "sh", "S")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exited := atomic.NewBool(false)
+	go waitForApplicationExit(ctx, procDir, 10*time.Millisecond, time.Hour, func() { exited.Store(true) })
+
+	// The agent waits for the application to start.
+	time.Sleep(50 * time.Millisecond)
+	if exited.Load() {
+		t.Fatal("exited before the application started")
+	}
+
+	addProcess(200, "app", "my app (worker)", "R")
+	addProcess(201, "app", "sh", "S")
+	time.Sleep(50 * time.Millisecond)
+	if exited.Load() {
+		t.Fatal("exited while the application is running")
+	}
+
+	// A daemon of the application, reparented to the pause process once its parent exited, is still running.
+	removeProcess(200)
+	time.Sleep(50 * time.Millisecond)
+	if exited.Load() {
+		t.Fatal("exited while a process of the application is running")
+	}
+
+	// A zombie application process is not running.
+	addProcess(201, "app", "sh", "Z")
+	retry.UntilOrFail(t, exited.Load, retry.Timeout(time.Second))
+}
+
+func TestWaitForApplicationExitBeforeStart(t *testing.T) {
+	// Only the pause container and the agent: the application exited before it was first listed.
+	procDir, addProcess := fakeProcDir(t)
+	addProcess(1, "pause", "pause", "S")
+	addProcess(os.Getpid(), "proxy", "pilot-agent", "S")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exited := atomic.NewBool(false)
+	go waitForApplicationExit(ctx, procDir, 10*time.Millisecond, 50*time.Millisecond, func() { exited.Store(true) })
+	retry.UntilOrFail(t, exited.Load, retry.Timeout(time.Second))
+
+	// Without a start timeout, the agent waits for the application to start.
+	exited.Store(false)
+	go waitForApplicationExit(ctx, procDir, 10*time.Millisecond, 0, func() { exited.Store(true) })
+	time.Sleep(100 * time.Millisecond)
+	if exited.Load() {
+		t.Fatal("exited before the application started")
+	}
+}
+
+func TestWaitForApplicationExitWithoutCgroups(t *testing.T) {
+	// All the processes are in the same cgroup: the containers cannot be told apart, the agent must not exit.
+	procDir, addProcess := fakeProcDir(t)
+	addProcess(1, "", "pause", "S")
+	addProcess(os.Getpid(), "", "pilot-agent", "S")
+
+	exited := atomic.NewBool(false)
+	waitForApplicationExit(context.Background(), procDir, 10*time.Millisecond, 0, func() { exited.Store(true) })
+	if exited.Load() {
+		t.Fatal("exited without telling the application apart")
+	}
+}
@@ -63,6 +63,11 @@ const (
 	readyPath = "/healthz/ready"
 	// quitPath is to notify the pilot agent to quit.
 	quitPath = "/quitquitquit"
+	// drainPath is to start failing the drain health check, from the preStop hook of gateways.
+	drainPath = "/drain"
+	// maxDrainHealthCheckDelay is the longest drain health check delay. A drain request waits for the delay, and
+	// must return before the 60s timeout of the pilot-agent request command calling it from the preStop hook.
+	maxDrainHealthCheckDelay = 55 * time.Second
 	// KubeAppProberEnvName is the name of the command line flag for pilot agent to pass app prober config.
 	// The json encoded string to pass app HTTP probe information from injector(istioctl or webhook).
 	// For example, ISTIO_KUBE_APP_PROBERS='{"/app-health/httpbin/livez":{"httpGet":{"path": "/hello", "port": 8080}}.
@@ -128,6 +133,16 @@ type Options struct {
 	FetchDNS            func() *dnsProto.NameTable
 	NoEnvoy             bool
 	GRPCBootstrap       string
+	// OnReady is called when the readiness probe succeeds.
+	OnReady func()
+	// FetchStartupReport returns the report of the startup phases of the agent, served on /debug/startupz.
+	FetchStartupReport func() any
+	// DrainHealthCheckPath is the path of a health check for external load balancers which fails once the proxy
+	// is draining, as requested by a POST to /drain. It is not served when empty.
+	DrainHealthCheckPath string
+	// DrainHealthCheckDelay is how long a drain request waits after failing the drain health check, so that external
+	// load balancers notice it before the proxy is terminated.
+	DrainHealthCheckDelay time.Duration
 }
 
 // Server provides an endpoint for handling status probes.
@@ -140,6 +155,7 @@ type Server struct {
 	appProbeClient        map[string]*http.Client
 	statusPort            uint16
 	lastProbeSuccessful   bool
+	draining              bool
 	envoyStatsPort        int
 	fetchDNS              func() *dnsProto.NameTable
 	upstreamLocalAddress  *net.TCPAddr
@@ -163,6 +179,14 @@ func init() {
 
 // NewServer creates a new status server.
 func NewServer(config Options) (*Server, error) {
+	if p := config.DrainHealthCheckPath; p != "" && (!strings.HasPrefix(p, "/") || strings.HasSuffix(p, "/") ||
+		p == readyPath || p == quitPath || p == drainPath) {
+		return nil, fmt.Errorf("invalid drain health check path %q: must be an absolute path not served already", p)
+	}
+	if config.DrainHealthCheckDelay > maxDrainHealthCheckDelay {
+		return nil, fmt.Errorf("invalid drain health check delay %v: must be at most %v, as the drain request times out",
+			config.DrainHealthCheckDelay, maxDrainHealthCheckDelay)
+	}
 	localhost := localHostIPv4
 	upstreamLocalAddress := UpstreamLocalAddressIPv4
 	if config.IPv6 {
@@ -335,6 +359,10 @@ func FormatProberURL(container string) (string, string, string) {
 func (s *Server) Run(ctx context.Context) {
 	log.Infof("Opening status port %d", s.statusPort)
 
+	if ExitOnApplicationExit.Get() {
+		go waitForApplicationExit(ctx, "/proc", applicationExitPollInterval, applicationStartTimeout.Get(), notifyExit)
+	}
+
 	mux := http.NewServeMux()
 
 	// Add the handler for ready probes.
@@ -346,6 +374,10 @@ func (s *Server) Run(ctx context.Context) {
 	mux.HandleFunc(`/stats/prometheus`, s.handleStats)
 	mux.HandleFunc(quitPath, s.handleQuit)
 	mux.HandleFunc("/app-health/", s.handleAppProbe)
+	if s.config.DrainHealthCheckPath != "" {
+		mux.HandleFunc(s.config.DrainHealthCheckPath, s.handleDrainHealthCheck)
+		mux.HandleFunc(drainPath, s.handleDrain)
+	}
 
 	// Add the handler for pprof.
 	mux.HandleFunc("/debug/pprof/", s.handlePprofIndex)
@@ -354,6 +386,7 @@ func (s *Server) Run(ctx context.Context) {
 	mux.HandleFunc("/debug/pprof/symbol", s.handlePprofSymbol)
 	mux.HandleFunc("/debug/pprof/trace", s.handlePprofTrace)
 	mux.HandleFunc("/debug/ndsz", s.handleNdsz)
+	mux.HandleFunc("/debug/startupz", s.handleStartupz)
 
 	l, err := net.Listen("tcp", fmt.Sprintf(":%d", s.statusPort))
 	if err != nil {
@@ -452,6 +485,9 @@ func (s *Server) handleReadyProbe(w http.ResponseWriter, _ *http.Request) {
 		s.lastProbeSuccessful = true
 	}
 	s.mutex.Unlock()
+	if err == nil && s.config.OnReady != nil {
+		s.config.OnReady()
+	}
 }
 
 func (s *Server) isReady() error {
@@ -648,6 +684,47 @@ func (s *Server) handleQuit(w http.ResponseWriter, r *http.Request) {
 	notifyExit()
 }
 
+// handleDrainHealthCheck serves the drain health check: it succeeds while the proxy is ready, until it starts draining.
+func (s *Server) handleDrainHealthCheck(w http.ResponseWriter, _ *http.Request) {
+	s.mutex.RLock()
+	draining := s.draining
+	s.mutex.RUnlock()
+	if draining {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("draining"))
+		return
+	}
+	if err := s.isReady(); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleDrain starts failing the drain health check, then waits for the drain health check delay so that external
+// load balancers stop sending new connections before the pod is terminated. It is meant for the preStop hook of
+// gateways, such as `pilot-agent request --debug-port 15020 POST drain`.
+func (s *Server) handleDrain(w http.ResponseWriter, r *http.Request) {
+	if !isRequestFromLocalhost(r) {
+		http.Error(w, "Only requests from localhost are allowed", http.StatusForbidden)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.mutex.Lock()
+	s.draining = true
+	s.mutex.Unlock()
+	log.Infof("handling %s, failing %s for %v", drainPath, s.config.DrainHealthCheckPath, s.config.DrainHealthCheckDelay)
+	select {
+	case <-time.After(s.config.DrainHealthCheckDelay):
+	case <-r.Context().Done():
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("OK"))
+}
+
 func (s *Server) handleAppProbe(w http.ResponseWriter, req *http.Request) {
 	// Validate the request first.
 	path := req.URL.Path
@@ -841,6 +918,21 @@ func (s *Server) handleNdsz(w http.ResponseWriter, r *http.Request) {
 	writeJSONProto(w, nametable)
 }
 
+// handleStartupz reports how long the startup phases of the agent took.
+func (s *Server) handleStartupz(w http.ResponseWriter, _ *http.Request) {
+	if s.config.FetchStartupReport == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	b, err := json.MarshalIndent(s.config.FetchStartupReport(), "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
 // writeJSONProto writes a protobuf to a json payload, handling content type, marshaling, and errors
 func writeJSONProto(w http.ResponseWriter, obj any) {
 	w.Header().Set("Content-Type", "application/json")
@@ -1536,6 +1536,36 @@ func TestAdditionalProbes(t *testing.T) {
 	}
 }
 
+func TestStartupz(t *testing.T) {
+	testServer := testserver.CreateAndStartServer(liveServerStats)
+	defer testServer.Close()
+	readyCalls := 0
+	server, err := NewServer(Options{
+		Probes:    []ready.Prober{readyProbe{}},
+		AdminPort: uint16(testServer.Listener.Addr().(*net.TCPAddr).Port),
+		OnReady: func() {
+			readyCalls++
+		},
+		FetchStartupReport: func() any {
+			return map[string]bool{"complete": true}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.handleReadyProbe(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, readyPath, nil))
+	if readyCalls != 1 {
+		t.Errorf("expected OnReady to be called once, got %d", readyCalls)
+	}
+
+	rec := httptest.NewRecorder()
+	server.handleStartupz(rec, httptest.NewRequest(http.MethodGet, "/debug/startupz", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"complete": true`) {
+		t.Errorf("unexpected startupz response %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
 type readyProbe struct{}
 
 func (s readyProbe) Check() error {
@@ -1547,3 +1577,54 @@ type unreadyProbe struct{}
 func (u unreadyProbe) Check() error {
 	return errors.New("not ready")
 }
+
+func TestDrainHealthCheck(t *testing.T) {
+	if _, err := NewServer(Options{DrainHealthCheckPath: readyPath}); err == nil {
+		t.Fatalf("expected the ready path to be rejected as drain health check path")
+	}
+	if _, err := NewServer(Options{DrainHealthCheckPath: "/healthz/drain", DrainHealthCheckDelay: time.Minute}); err == nil {
+		t.Fatalf("expected a drain health check delay past the drain request timeout to be rejected")
+	}
+
+	testServer := testserver.CreateAndStartServer(liveServerStats)
+	defer testServer.Close()
+	server, err := NewServer(Options{
+		Probes:               []ready.Prober{readyProbe{}},
+		AdminPort:            uint16(testServer.Listener.Addr().(*net.TCPAddr).Port),
+		DrainHealthCheckPath: "/healthz/drain",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	healthCheck := func() int {
+		resp := httptest.NewRecorder()
+		server.handleDrainHealthCheck(resp, httptest.NewRequest(http.MethodGet, "/healthz/drain", nil))
+		return resp.Code
+	}
+	if code := healthCheck(); code != http.StatusOK {
+		t.Fatalf("expected the drain health check to succeed before draining, got %v", code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, drainPath, nil)
+	req.RemoteAddr = "127.0.0.1:15020"
+	resp := httptest.NewRecorder()
+	server.handleDrain(resp, req)
+	if resp.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected the drain to require POST, got %v", resp.Code)
+	}
+	if code := healthCheck(); code != http.StatusOK {
+		t.Fatalf("expected the drain health check to succeed after a rejected drain, got %v", code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, drainPath, nil)
+	req.RemoteAddr = "127.0.0.1:15020"
+	resp = httptest.NewRecorder()
+	server.handleDrain(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected the drain to succeed, got %v", resp.Code)
+	}
+	if code := healthCheck(); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected the drain health check to fail once draining, got %v", code)
+	}
+}
@@ -27,17 +27,21 @@ import (
 
 	"github.com/fsnotify/fsnotify"
 	"google.golang.org/grpc"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"istio.io/api/security/v1beta1"
 	"istio.io/istio/pilot/pkg/features"
 	securityModel "istio.io/istio/pilot/pkg/security/model"
+	kubecontroller "istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/jwt"
+	"istio.io/istio/pkg/kube/controllers"
 	"istio.io/istio/pkg/security"
 	"istio.io/istio/security/pkg/cmd"
 	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/pki/kms"
 	"istio.io/istio/security/pkg/pki/ra"
 	caserver "istio.io/istio/security/pkg/server/ca"
 	"istio.io/istio/security/pkg/server/ca/authenticate"
@@ -129,6 +133,37 @@ var (
 	caRSAKeySize = env.RegisterIntVar("CITADEL_SELF_SIGNED_CA_RSA_KEY_SIZE", 2048,
 		"Specify the RSA key size to use for self-signed Istio CA certificates.")
 
+	caKeyKMSKey = env.RegisterStringVar("CITADEL_CA_KEY_KMS_KEY", "",
+		"If set, the private key of the self-signed CA is envelope-encrypted in istio-ca-secret with this KMS key, "+
+			"such as gcpkms://projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>. "+
+			"Only Google Cloud KMS keys are supported: istiod fails to start with AWS KMS or Azure Key Vault keys. "+
+			"An existing plaintext key, or a key encrypted with an older version of the KMS key, is re-encrypted.")
+
+	namespaceCAEnabled = env.RegisterBoolVar("CITADEL_ENABLE_NAMESPACE_CA", false,
+		"If true, the workload certificates of each namespace are issued from an intermediate CA certificate of the "+
+			"namespace, signed by the istiod CA, so that they can be audited and revoked per namespace. The intermediate "+
+			"CAs are stored in the istio-ca-namespace-<namespace> Secrets, and the istiod CA certificate must allow "+
+			"signing CRLs. Revocations are enforced by the proxies setting VERIFY_CA_CRL, which then only accept the "+
+			"certificates of the issuers listed in the CRLs of the cluster.")
+
+	namespaceCACertTTL = env.RegisterDurationVar("CITADEL_NAMESPACE_CA_CERT_TTL", 30*24*time.Hour,
+		"The TTL of the intermediate CA certificates of the namespaces, if CITADEL_ENABLE_NAMESPACE_CA is set. "+
+			"They are reissued past half of their lifetime, or when the root certificate rotates.")
+
+	namespaceCAActivationDelay = env.RegisterDurationVar("CITADEL_NAMESPACE_CA_ACTIVATION_DELAY", 3*time.Minute,
+		"The time an intermediate CA certificate of a namespace waits before signing, if CITADEL_ENABLE_NAMESPACE_CA is "+
+			"set, counted from when its CRL is observed in the istio-ca-root-cert ConfigMap of the namespace. It must "+
+			"cover the time the kubelets take to update the ConfigMap volumes of the pods, their sync period plus their "+
+			"ConfigMap cache TTL, one minute each by default, as proxies setting VERIFY_CA_CRL reject the "+
+			"certificates of an issuer without a CRL. Until then, the istiod CA signs short lived certificates. "+
+			"0 uses the intermediate CAs at once, when no proxy checks the CRLs.")
+
+	dnsSANAllowlist = env.RegisterStringVar("CITADEL_DNS_SAN_ALLOWLIST", "",
+		"The DNS names that workloads may request to add to the certificates issued by the istiod CA, such as "+
+			"gateways with the security.istio.io/gatewayDNSSANs annotation. A comma separated list of "+
+			"<namespace>/<service account>=<DNS name> rules, where the service account may be * and the DNS name "+
+			"may be *.<domain> to allow any name under the domain. Requests for other DNS names are denied.")
+
 	// TODO: Likely to be removed and added to mesh config
 	externalCaType = env.RegisterStringVar("EXTERNAL_CA", "",
 		"External CA Integration Type. Permitted Values are ISTIOD_RA_KUBERNETES_API or "+
@@ -169,6 +204,14 @@ func (s *Server) RunCA(grpc *grpc.Server, ca caserver.CertificateAuthority, opts
 	if startErr != nil {
 		log.Fatalf("failed to create istio ca server: %v", startErr)
 	}
+	if allowlist := dnsSANAllowlist.Get(); allowlist != "" {
+		policy, err := caserver.ParseDNSSANPolicy(allowlist)
+		if err != nil {
+			log.Errorf("invalid CITADEL_DNS_SAN_ALLOWLIST, requests for DNS names are denied: %v", err)
+		} else {
+			caServer.DNSSANPolicy = policy
+		}
+	}
 
 	// TODO: if not set, parse Istiod's own token (if present) and get the issuer. The same issuer is used
 	// for all tokens - no need to configure twice. The token may also include cluster info to auto-configure
@@ -188,10 +231,55 @@ func (s *Server) RunCA(grpc *grpc.Server, ca caserver.CertificateAuthority, opts
 	}
 
 	caServer.Register(grpc)
+	s.XDSServer.ListIssuedCertificates = caServer.Inventory.List
+	s.issuedCertificates = caServer.Inventory
 
 	log.Info("Istiod CA has started")
 }
 
+// workloadCA returns the CA signing the workload certificates: the istiod CA or, if CITADEL_ENABLE_NAMESPACE_CA
+// is set, the intermediate CAs it issues to the namespaces. Their CRLs are published with the CA bundle in the
+// istio-ca-root-cert ConfigMaps.
+func (s *Server) workloadCA(opts *caOptions, stop <-chan struct{}) (caserver.CertificateAuthority, error) {
+	if !namespaceCAEnabled.Get() {
+		return s.CA, nil
+	}
+	if s.kubeClient == nil {
+		return nil, fmt.Errorf("CITADEL_ENABLE_NAMESPACE_CA requires a Kubernetes cluster to store the namespace CAs")
+	}
+	store := ca.NewNamespaceCASecretStore(s.kubeClient.Kube(), opts.Namespace)
+	nsCA, err := ca.NewNamespaceCA(s.CA, namespaceCACertTTL.Get(), namespaceCAActivationDelay.Get(), store)
+	if err != nil {
+		return nil, err
+	}
+	store.AddHandler(func() {
+		crl, err := nsCA.CRL()
+		if err != nil {
+			log.Errorf("failed to update the CRLs of the namespace CAs: %v", err)
+			return
+		}
+		s.istiodCertBundleWatcher.SetCRLAndNotify(crl)
+	})
+	// The namespace CAs sign once their CRLs are observed in the istio-ca-root-cert ConfigMaps.
+	s.kubeClient.KubeInformer().Core().V1().ConfigMaps().Informer().AddEventHandler(controllers.FilteredObjectSpecHandler(
+		func(o controllers.Object) {
+			cm, ok := o.(*v1.ConfigMap)
+			if !ok || cm.Data[constants.CACertNamespaceConfigMapCRLDataName] == "" {
+				return
+			}
+			if err := nsCA.ObserveCRL(cm.Namespace, []byte(cm.Data[constants.CACertNamespaceConfigMapCRLDataName])); err != nil {
+				log.Errorf("failed to record the CRLs of namespace %s: %v", cm.Namespace, err)
+			}
+		}, func(o controllers.Object) bool {
+			return o.GetName() == kubecontroller.CACertNamespaceConfigMap
+		}))
+	store.Run(stop)
+	s.XDSServer.ListNamespaceCAs = nsCA.List
+	s.XDSServer.RevokeNamespaceCA = nsCA.Revoke
+	log.Info("Istiod CA delegates to namespace CAs")
+	return nsCA, nil
+}
+
 // detectAuthEnv will use the JWT token that is mounted in istiod to set the default audience
 // and trust domain for Istiod, if not explicitly defined.
 // K8S will use the same kind of tokens for the pods, and the value in istiod's own token is
@@ -434,6 +522,12 @@ func (s *Server) createIstioCA(opts *caOptions) (*ca.IstioCA, error) {
 			// Abort after 20 minutes.
 			ctx, cancel := context.WithTimeout(context.Background(), time.Minute*20)
 			defer cancel()
+			var keyWrapper kms.KeyWrapper
+			if caKeyKMSKey.Get() != "" {
+				if keyWrapper, err = kms.NewKeyWrapper(ctx, caKeyKMSKey.Get()); err != nil {
+					return nil, fmt.Errorf("failed to create KMS key wrapper: %v", err)
+				}
+			}
 			// rootCertFile will be added to "ca-cert.pem".
 			// readSigningCertOnly set to false - it doesn't seem to be used in Citadel, nor do we have a way
 			// to set it only for one job.
@@ -442,7 +536,7 @@ func (s *Server) createIstioCA(opts *caOptions) (*ca.IstioCA, error) {
 				selfSignedRootCertCheckInterval.Get(), workloadCertTTL.Get(),
 				maxWorkloadCertTTL.Get(), opts.TrustDomain, true,
 				opts.Namespace, -1, s.kubeClient.Kube().CoreV1(), fileBundle.RootCertFile,
-				enableJitterForRootCertRotator.Get(), caRSAKeySize.Get())
+				enableJitterForRootCertRotator.Get(), caRSAKeySize.Get(), keyWrapper)
 		} else {
 			log.Warnf(
 				"Use local self-signed CA certificate for testing. Will use in-memory root CA, no K8S access and no ca key file %s",
@@ -43,6 +43,7 @@ import (
 	"istio.io/istio/pilot/pkg/features"
 	istiogrpc "istio.io/istio/pilot/pkg/grpc"
 	"istio.io/istio/pilot/pkg/keycertbundle"
+	"istio.io/istio/pilot/pkg/leaderelection"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/server"
 	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
@@ -70,6 +71,7 @@ import (
 	"istio.io/istio/security/pkg/k8s/chiron"
 	"istio.io/istio/security/pkg/pki/ca"
 	"istio.io/istio/security/pkg/pki/ra"
+	caserver "istio.io/istio/security/pkg/server/ca"
 	"istio.io/istio/security/pkg/server/ca/authenticate"
 	"istio.io/istio/security/pkg/server/ca/authenticate/kubeauth"
 	"istio.io/pkg/ctrlz"
@@ -153,6 +155,9 @@ type Server struct {
 	CA             *ca.IstioCA
 	RA             ra.RegistrationAuthority
 
+	// issuedCertificates holds the workload certificates issued by the CA or RA server, once it runs.
+	issuedCertificates *caserver.Inventory
+
 	// TrustAnchors for workload to workload mTLS
 	workloadTrustBundle     *tb.TrustBundle
 	certMu                  sync.RWMutex
@@ -338,8 +343,10 @@ func NewServer(args *PilotArgs, initFuncs ...func(*Server)) (*Server, error) {
 	}
 	// The k8s JWT authenticator requires the multicluster registry to be initialized,
 	// so we build it later.
-	authenticators = append(authenticators,
-		kubeauth.NewKubeJWTAuthenticator(s.environment.Watcher, s.kubeClient.Kube(), s.clusterID, s.multiclusterController.GetRemoteKubeClient, features.JwtPolicy))
+	kubeAuthenticator := kubeauth.NewKubeJWTAuthenticator(s.environment.Watcher, s.kubeClient.Kube(), s.clusterID,
+		s.multiclusterController.GetRemoteKubeClient, features.JwtPolicy)
+	kubeAuthenticatorIndex := len(authenticators)
+	authenticators = append(authenticators, kubeAuthenticator)
 	if len(features.TrustedGatewayCIDR) > 0 {
 		authenticators = append(authenticators, &authenticate.XfccAuthenticator{})
 	}
@@ -347,9 +354,16 @@ func NewServer(args *PilotArgs, initFuncs ...func(*Server)) (*Server, error) {
 		s.XDSServer.Authenticators = authenticators
 	}
 	caOpts.Authenticators = authenticators
+	if features.CANodeAttestation {
+		// Only certificates are attested, tokens which are not bound to pods, such as the ones of istioctl, can still
+		// be used for XDS.
+		caOpts.Authenticators = append([]security.Authenticator{}, authenticators...)
+		caOpts.Authenticators[kubeAuthenticatorIndex] = kubeAuthenticator.WithNodeAttestation()
+	}
 
 	// Start CA or RA server. This should be called after CA and Istiod certs have been created.
 	s.startCA(caOpts)
+	s.initCertExpiryAlerts(args)
 
 	// TODO: don't run this if galley is started, one ctlz is enough
 	if args.CtrlZOptions != nil {
@@ -512,7 +526,11 @@ func (s *Server) initSDSServer() {
 		log.Warnf("skipping Kubernetes credential reader; PILOT_ENABLE_XDS_IDENTITY_CHECK must be set to true for this feature.")
 	} else {
 		creds := kubecredentials.NewMulticluster(s.clusterID)
+		secretGen := xds.NewSecretGen(creds, s.XDSServer.Cache, s.clusterID, s.environment.Mesh())
 		creds.AddSecretHandler(func(name string, namespace string) {
+			secretGen.SecretUpdated(name, namespace)
+			// Secrets only trigger an incremental SDS push: the listeners referencing them are left untouched,
+			// so certificates rotate without draining connections.
 			s.XDSServer.ConfigUpdate(&model.PushRequest{
 				Full: false,
 				ConfigsUpdated: map[model.ConfigKey]struct{}{
@@ -525,7 +543,7 @@ func (s *Server) initSDSServer() {
 				Reason: []model.TriggerReason{model.SecretTrigger},
 			})
 		})
-		s.XDSServer.Generators[v3.SecretType] = xds.NewSecretGen(creds, s.XDSServer.Cache, s.clusterID, s.environment.Mesh())
+		s.XDSServer.Generators[v3.SecretType] = secretGen
 		s.multiclusterController.AddHandler(creds)
 		if ecdsGen, found := s.XDSServer.Generators[v3.ExtensionConfigurationType]; found {
 			ecdsGen.(*xds.EcdsGenerator).SetCredController(creds)
@@ -1078,6 +1096,7 @@ func (s *Server) getIstiodCertificate(*tls.ClientHelloInfo) (*tls.Certificate, e
 func (s *Server) initControllers(args *PilotArgs) error {
 	log.Info("initializing controllers")
 	s.initMulticluster(args)
+	s.initProxyStartupEvents()
 	// Certificate controller is created before MCP controller in case MCP server pod
 	// waits to mount a certificate to be provisioned by the certificate controller.
 	if err := s.initCertController(args); err != nil {
@@ -1103,6 +1122,53 @@ func (s *Server) initMulticluster(args *PilotArgs) {
 	})
 }
 
+// initProxyStartupEvents records the startup phases reported by proxies as events on their pods, if enabled.
+func (s *Server) initProxyStartupEvents() {
+	if s.kubeClient == nil || !features.EnableProxyStartupEvents {
+		return
+	}
+	s.XDSServer.RecordStartupReport = xds.NewStartupEventRecorder(s.kubeClient.Kube(), s.clusterID, features.ProxySlowStartupThreshold)
+}
+
+// initCertExpiryAlerts reports the certificates of the gateway credential secrets and the workload certificates
+// issued by the istiods which are about to expire, if enabled. It must be called after startCA, for the workload
+// certificates to be published once the CA runs.
+func (s *Server) initCertExpiryAlerts(args *PilotArgs) {
+	if s.kubeClient == nil || !features.EnableCertExpiryAlerts {
+		return
+	}
+	gateways := func() []config.Config {
+		gws, err := s.configController.List(gvk.Gateway, model.NamespaceAll)
+		if err != nil {
+			log.Warnf("failed to list the gateways: %v", err)
+		}
+		return gws
+	}
+	election := leaderelection.
+		NewLeaderElection(args.Namespace, args.PodName, leaderelection.CertExpiryController, args.Revision, s.kubeClient).
+		AddRunFunction(kubecredentials.NewSecretExpiryController(s.kubeClient, s.clusterID, gateways,
+			features.CertExpiryAlertThreshold).Run)
+	if s.CA != nil || s.RA != nil {
+		publisher := kubecredentials.NewIssuedCertificatePublisher(s.kubeClient, args.Namespace, args.PodName,
+			func() []caserver.IssuedCertificate {
+				if s.issuedCertificates == nil {
+					return nil
+				}
+				return s.issuedCertificates.ListAll()
+			})
+		s.addStartFunc(func(stop <-chan struct{}) error {
+			go publisher.Run(stop)
+			return nil
+		})
+		election.AddRunFunction(kubecredentials.NewWorkloadCertExpiryController(s.kubeClient, args.Namespace,
+			features.WorkloadCertExpiryAlertThreshold).Run)
+	}
+	s.addStartFunc(func(stop <-chan struct{}) error {
+		go election.Run(stop)
+		return nil
+	})
+}
+
 // maybeCreateCA creates and initializes CA Key if needed.
 func (s *Server) maybeCreateCA(caOpts *caOptions) error {
 	// CA signing certificate must be created only if CA is enabled.
@@ -1165,7 +1231,11 @@ func (s *Server) startCA(caOpts *caOptions) {
 			s.RunCA(grpcServer, s.RA, caOpts)
 		} else if s.CA != nil {
 			log.Infof("Starting IstioD CA")
-			s.RunCA(grpcServer, s.CA, caOpts)
+			ca, err := s.workloadCA(caOpts, stop)
+			if err != nil {
+				return err
+			}
+			s.RunCA(grpcServer, ca, caOpts)
 		}
 		return nil
 	})
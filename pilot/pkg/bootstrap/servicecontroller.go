@@ -17,6 +17,8 @@ package bootstrap
 import (
 	"fmt"
 
+	"istio.io/istio/pilot/pkg/config/kube/replication"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
 	kubecontroller "istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
 	"istio.io/istio/pilot/pkg/serviceregistry/provider"
@@ -75,6 +77,15 @@ func (s *Server) initKubeRegistry(args *PilotArgs) (err error) {
 	args.RegistryOptions.KubeOptions.MeshWatcher = s.environment.Watcher
 	args.RegistryOptions.KubeOptions.SystemNamespace = args.Namespace
 	args.RegistryOptions.KubeOptions.MeshServiceController = s.ServiceController()
+	if features.ConfigReplicationKinds != "" {
+		opts, err := replication.NewOptions(s.clusterID, features.ConfigReplicationKinds, features.ConfigReplicationSelector)
+		if err != nil {
+			return fmt.Errorf("invalid config replication settings: %v", err)
+		}
+		rc := replication.NewController(s.configController, opts)
+		args.RegistryOptions.KubeOptions.ConfigReplication = rc
+		s.XDSServer.ConfigReplicationStatus = rc.Status
+	}
 
 	s.multiclusterController.AddHandler(kubecontroller.NewMulticluster(args.PodName,
 		s.kubeClient.Kube(),
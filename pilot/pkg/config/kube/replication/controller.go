@@ -0,0 +1,406 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package replication implements a controller copying selected Istio configuration
+// from the config cluster to remote clusters of a multi-primary mesh.
+package replication
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/queue"
+	istiolog "istio.io/pkg/log"
+)
+
+var log = istiolog.RegisterScope("replication", "config replication controller", 0)
+
+const (
+	// SourceClusterAnnotation is set on every replicated object and records the cluster the object was copied from.
+	// Objects in the destination cluster without this annotation are owned by someone else and are never overwritten.
+	SourceClusterAnnotation = "replication.istio.io/source-cluster"
+)
+
+// State describes the replication outcome of a single source object.
+type State string
+
+const (
+	// Synced means the destination object matches the source object.
+	Synced State = "Synced"
+	// Conflict means a destination object with the same name exists but was not created by replication.
+	Conflict State = "Conflict"
+	// Error means the last attempt to write the destination object failed.
+	Error State = "Error"
+)
+
+// Status is the replication status of a single source object in a single destination cluster.
+type Status struct {
+	Kind       string    `json:"kind"`
+	Name       string    `json:"name"`
+	Namespace  string    `json:"namespace"`
+	Cluster    string    `json:"cluster"`
+	State      State     `json:"state"`
+	Message    string    `json:"message,omitempty"`
+	LastUpdate time.Time `json:"lastUpdate"`
+}
+
+// Options provide options for creating a replication Controller.
+type Options struct {
+	// SourceCluster is the ID of the cluster owning the source configuration.
+	SourceCluster cluster.ID
+	// Kinds are the config kinds to replicate.
+	Kinds []config.GroupVersionKind
+	// Selector restricts replication to source objects with matching labels.
+	Selector klabels.Selector
+}
+
+// Controller watches the source config store and mirrors selected objects into every destination
+// cluster currently served by Run. Event handlers are registered on the source exactly once; events
+// are dispatched to the destinations active at the time of the event.
+type Controller struct {
+	Options
+
+	source model.ConfigStoreController
+
+	mu      sync.RWMutex
+	targets map[cluster.ID]*target
+}
+
+// target is a single destination cluster for the duration of one leadership term.
+type target struct {
+	clusterID cluster.ID
+	dest      model.ConfigStore
+	// namespaceFilter returns false for namespaces the destination cluster does not discover.
+	namespaceFilter func(namespace string) bool
+	queue           queue.Instance
+
+	mu     sync.RWMutex
+	status map[model.ConfigKey]Status
+}
+
+// ParseKinds resolves a comma separated list of kind names (e.g. "ServiceEntry,Sidecar") into the
+// GroupVersionKinds of the matching Istio config types.
+func ParseKinds(kinds string) ([]config.GroupVersionKind, error) {
+	var out []config.GroupVersionKind
+	for _, k := range strings.Split(kinds, ",") {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		s, found := findSchema(k)
+		if !found {
+			return nil, fmt.Errorf("unknown config kind %q", k)
+		}
+		out = append(out, s.Resource().GroupVersionKind())
+	}
+	return out, nil
+}
+
+func findSchema(name string) (collection.Schema, bool) {
+	for _, s := range collections.Pilot.All() {
+		if strings.EqualFold(s.Resource().Kind(), name) {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// NewOptions builds Options from the PILOT_CONFIG_REPLICATION_KINDS and PILOT_CONFIG_REPLICATION_SELECTOR values.
+func NewOptions(sourceCluster cluster.ID, kinds, selector string) (Options, error) {
+	gvks, err := ParseKinds(kinds)
+	if err != nil {
+		return Options{}, err
+	}
+	if len(gvks) == 0 {
+		return Options{}, fmt.Errorf("no config kinds to replicate")
+	}
+	sel, err := klabels.Parse(selector)
+	if err != nil {
+		return Options{}, fmt.Errorf("invalid replication selector %q: %v", selector, err)
+	}
+	return Options{SourceCluster: sourceCluster, Kinds: gvks, Selector: sel}, nil
+}
+
+// Schemas returns the schemas of the replicated kinds, for use when building a destination config store.
+func (o Options) Schemas() collection.Schemas {
+	b := collection.NewSchemasBuilder()
+	for _, k := range o.Kinds {
+		if s, found := collections.Pilot.FindByGroupVersionKind(k); found {
+			b.MustAdd(s)
+		}
+	}
+	return b.Build()
+}
+
+// NewController creates a new replication Controller and registers its event handlers on source.
+func NewController(source model.ConfigStoreController, opts Options) *Controller {
+	if opts.Selector == nil {
+		opts.Selector = klabels.Everything()
+	}
+	c := &Controller{
+		Options: opts,
+		source:  source,
+		targets: map[cluster.ID]*target{},
+	}
+	for _, k := range opts.Kinds {
+		source.RegisterEventHandler(k, func(_ config.Config, cfg config.Config, event model.Event) {
+			c.dispatch(cfg, event)
+		})
+	}
+	return c
+}
+
+// Run replicates into dest until stop is closed. It performs a full sync of the destination first, so objects
+// created or deleted while no term was active are reconciled. Both stores are expected to be synced.
+// namespaceFilter may be nil, in which case all namespaces are replicated.
+func (c *Controller) Run(clusterID cluster.ID, dest model.ConfigStore, namespaceFilter func(string) bool, stop <-chan struct{}) {
+	if namespaceFilter == nil {
+		namespaceFilter = func(string) bool { return true }
+	}
+	t := &target{
+		clusterID:       clusterID,
+		dest:            dest,
+		namespaceFilter: namespaceFilter,
+		queue:           queue.NewQueue(time.Second),
+		status:          map[model.ConfigKey]Status{},
+	}
+	c.mu.Lock()
+	c.targets[clusterID] = t
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		if c.targets[clusterID] == t {
+			delete(c.targets, clusterID)
+		}
+		c.mu.Unlock()
+	}()
+
+	log.Infof("%s starting replication of %v", t.logPrefix(), c.Kinds)
+	t.queue.Push(func() error {
+		return c.resync(t)
+	})
+	t.queue.Run(stop)
+}
+
+// Status returns the replication status of all tracked source objects in all active destination clusters,
+// sorted by cluster, kind, namespace and name.
+func (c *Controller) Status() []Status {
+	c.mu.RLock()
+	var out []Status
+	for _, t := range c.targets {
+		t.mu.RLock()
+		for _, s := range t.status {
+			out = append(out, s)
+		}
+		t.mu.RUnlock()
+	}
+	c.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Cluster != out[j].Cluster {
+			return out[i].Cluster < out[j].Cluster
+		}
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		if out[i].Namespace != out[j].Namespace {
+			return out[i].Namespace < out[j].Namespace
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func (c *Controller) dispatch(cfg config.Config, event model.Event) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, t := range c.targets {
+		t := t
+		t.queue.Push(func() error {
+			return c.reconcile(t, cfg, event)
+		})
+	}
+}
+
+// resync reconciles every source object and removes replicas whose source no longer exists or no longer matches.
+func (c *Controller) resync(t *target) error {
+	for _, k := range c.Kinds {
+		sources, err := c.source.List(k, "")
+		if err != nil {
+			return err
+		}
+		wanted := map[model.NamespacedName]struct{}{}
+		for _, cfg := range sources {
+			if !isReplica(&cfg) && c.Selector.Matches(klabels.Set(cfg.Labels)) {
+				wanted[model.NamespacedName{Name: cfg.Name, Namespace: cfg.Namespace}] = struct{}{}
+			}
+			if err := c.reconcile(t, cfg, model.EventUpdate); err != nil {
+				return err
+			}
+		}
+		replicas, err := t.dest.List(k, "")
+		if err != nil {
+			return err
+		}
+		for _, r := range replicas {
+			if _, f := wanted[model.NamespacedName{Name: r.Name, Namespace: r.Namespace}]; f || !c.ownedBy(&r) {
+				continue
+			}
+			if err := c.remove(t, r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Controller) reconcile(t *target, cfg config.Config, event model.Event) error {
+	// Replicas of other clusters are never replicated again: in a multi-primary mesh replicating both ways, they would
+	// be copied back and forth between the clusters.
+	if event == model.EventDelete || isReplica(&cfg) || !c.Selector.Matches(klabels.Set(cfg.Labels)) || !t.namespaceFilter(cfg.Namespace) {
+		return c.remove(t, cfg)
+	}
+
+	existing := t.dest.Get(cfg.GroupVersionKind, cfg.Name, cfg.Namespace)
+	if existing != nil && !c.ownedBy(existing) {
+		t.setStatus(cfg, Conflict, fmt.Sprintf("%s %s/%s already exists in cluster %s and is not managed by replication from %s",
+			cfg.GroupVersionKind.Kind, cfg.Namespace, cfg.Name, t.clusterID, c.SourceCluster))
+		log.Warnf("%s conflict replicating %s %s/%s", t.logPrefix(), cfg.GroupVersionKind.Kind, cfg.Namespace, cfg.Name)
+		// Conflicts are not retried; they are resolved once the user removes one side.
+		return nil
+	}
+
+	replica := c.replicaOf(cfg)
+	var err error
+	if existing == nil {
+		_, err = t.dest.Create(replica)
+	} else if !equal(existing, &replica) {
+		replica.ResourceVersion = existing.ResourceVersion
+		_, err = t.dest.Update(replica)
+	}
+	if err != nil {
+		t.setStatus(cfg, Error, err.Error())
+		log.Warnf("%s failed replicating %s %s/%s: %v", t.logPrefix(), cfg.GroupVersionKind.Kind, cfg.Namespace, cfg.Name, err)
+		return err
+	}
+	t.setStatus(cfg, Synced, "")
+	return nil
+}
+
+func (c *Controller) remove(t *target, cfg config.Config) error {
+	t.mu.Lock()
+	delete(t.status, statusKey(cfg))
+	t.mu.Unlock()
+
+	existing := t.dest.Get(cfg.GroupVersionKind, cfg.Name, cfg.Namespace)
+	if existing == nil || !c.ownedBy(existing) {
+		return nil
+	}
+	if err := t.dest.Delete(cfg.GroupVersionKind, cfg.Name, cfg.Namespace, nil); err != nil {
+		log.Warnf("%s failed removing replica %s %s/%s: %v", t.logPrefix(), cfg.GroupVersionKind.Kind, cfg.Namespace, cfg.Name, err)
+		return err
+	}
+	log.Debugf("%s removed replica %s %s/%s", t.logPrefix(), cfg.GroupVersionKind.Kind, cfg.Namespace, cfg.Name)
+	return nil
+}
+
+// replicaOf builds the destination object, dropping all server-set metadata of the source.
+func (c *Controller) replicaOf(cfg config.Config) config.Config {
+	cp := cfg.DeepCopy()
+	annotations := map[string]string{}
+	for k, v := range cp.Annotations {
+		annotations[k] = v
+	}
+	annotations[SourceClusterAnnotation] = c.SourceCluster.String()
+	return config.Config{
+		Meta: config.Meta{
+			GroupVersionKind: cp.GroupVersionKind,
+			Name:             cp.Name,
+			Namespace:        cp.Namespace,
+			Domain:           cp.Domain,
+			Labels:           cp.Labels,
+			Annotations:      annotations,
+		},
+		Spec: cp.Spec,
+	}
+}
+
+// isReplica returns true if the object was created by the replication from another cluster.
+func isReplica(cfg *config.Config) bool {
+	_, f := cfg.Annotations[SourceClusterAnnotation]
+	return f
+}
+
+func (c *Controller) ownedBy(cfg *config.Config) bool {
+	return cfg.Annotations[SourceClusterAnnotation] == c.SourceCluster.String()
+}
+
+func (t *target) setStatus(cfg config.Config, state State, msg string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status[statusKey(cfg)] = Status{
+		Kind:       cfg.GroupVersionKind.Kind,
+		Name:       cfg.Name,
+		Namespace:  cfg.Namespace,
+		Cluster:    t.clusterID.String(),
+		State:      state,
+		Message:    msg,
+		LastUpdate: time.Now(),
+	}
+}
+
+func (t *target) logPrefix() string {
+	return "ConfigReplication (cluster=" + t.clusterID.String() + ")"
+}
+
+func statusKey(cfg config.Config) model.ConfigKey {
+	return model.ConfigKey{Kind: kind.FromGvk(cfg.GroupVersionKind), Name: cfg.Name, Namespace: cfg.Namespace}
+}
+
+// equal returns true if the replica a already matches the desired replica b.
+func equal(a, b *config.Config) bool {
+	if !equalStringMaps(a.Labels, b.Labels) || !equalStringMaps(a.Annotations, b.Annotations) {
+		return false
+	}
+	pa, aok := a.Spec.(proto.Message)
+	pb, bok := b.Spec.(proto.Message)
+	if aok && bok {
+		return proto.Equal(pa, pb)
+	}
+	return reflect.DeepEqual(a.Spec, b.Spec)
+}
+
+func equalStringMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, f := b[k]; !f || bv != v {
+			return false
+		}
+	}
+	return true
+}
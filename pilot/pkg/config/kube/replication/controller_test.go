@@ -0,0 +1,345 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replication
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/queue"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+var schemas = collection.SchemasFor(collections.IstioNetworkingV1Alpha3Serviceentries)
+
+func serviceEntry(host string, labels, annotations map[string]string) config.Config {
+	return config.Config{
+		Meta: config.Meta{
+			GroupVersionKind: gvk.ServiceEntry,
+			Name:             "se",
+			Namespace:        "ns",
+			Labels:           labels,
+			Annotations:      annotations,
+		},
+		Spec: &networking.ServiceEntry{
+			Hosts:      []string{host},
+			Ports:      []*networking.Port{{Number: 443, Name: "tls", Protocol: "TLS"}},
+			Location:   networking.ServiceEntry_MESH_EXTERNAL,
+			Resolution: networking.ServiceEntry_DNS,
+		},
+	}
+}
+
+var (
+	selected = map[string]string{"istio.io/replicate": "true"}
+	owned    = map[string]string{SourceClusterAnnotation: "primary"}
+)
+
+func newTestController(t *testing.T) (*Controller, *target) {
+	t.Helper()
+	opts, err := NewOptions("primary", "ServiceEntry", "istio.io/replicate=true")
+	assert.NoError(t, err)
+	c := NewController(memory.NewSyncController(memory.Make(schemas)), opts)
+	tgt := &target{
+		clusterID:       "remote",
+		dest:            memory.Make(schemas),
+		namespaceFilter: func(ns string) bool { return ns != "excluded" },
+		queue:           queue.NewQueue(time.Millisecond),
+		status:          map[model.ConfigKey]Status{},
+	}
+	return c, tgt
+}
+
+func TestReconcile(t *testing.T) {
+	withAnnotation := func(k, v string) map[string]string {
+		return map[string]string{SourceClusterAnnotation: "primary", k: v}
+	}
+	cases := []struct {
+		name     string
+		existing *config.Config
+		source   config.Config
+		event    model.Event
+		// expected is the replica expected in the destination, nil if none.
+		expected *config.Config
+		state    State
+	}{
+		{
+			name:     "create",
+			source:   serviceEntry("a.example.com", selected, nil),
+			event:    model.EventAdd,
+			expected: ptr(serviceEntry("a.example.com", selected, owned)),
+			state:    Synced,
+		},
+		{
+			name:     "update spec",
+			existing: ptr(serviceEntry("a.example.com", selected, owned)),
+			source:   serviceEntry("b.example.com", selected, nil),
+			event:    model.EventUpdate,
+			expected: ptr(serviceEntry("b.example.com", selected, owned)),
+			state:    Synced,
+		},
+		{
+			name:     "update labels",
+			existing: ptr(serviceEntry("a.example.com", selected, owned)),
+			source:   serviceEntry("a.example.com", map[string]string{"istio.io/replicate": "true", "team": "a"}, nil),
+			event:    model.EventUpdate,
+			expected: ptr(serviceEntry("a.example.com", map[string]string{"istio.io/replicate": "true", "team": "a"}, owned)),
+			state:    Synced,
+		},
+		{
+			name:     "update annotations",
+			existing: ptr(serviceEntry("a.example.com", selected, owned)),
+			source:   serviceEntry("a.example.com", selected, map[string]string{"owner": "b"}),
+			event:    model.EventUpdate,
+			expected: ptr(serviceEntry("a.example.com", selected, withAnnotation("owner", "b"))),
+			state:    Synced,
+		},
+		{
+			name:     "no change",
+			existing: ptr(serviceEntry("a.example.com", selected, owned)),
+			source:   serviceEntry("a.example.com", selected, nil),
+			event:    model.EventUpdate,
+			expected: ptr(serviceEntry("a.example.com", selected, owned)),
+			state:    Synced,
+		},
+		{
+			name:     "conflict with unannotated object",
+			existing: ptr(serviceEntry("local.example.com", nil, nil)),
+			source:   serviceEntry("a.example.com", selected, nil),
+			event:    model.EventAdd,
+			expected: ptr(serviceEntry("local.example.com", nil, nil)),
+			state:    Conflict,
+		},
+		{
+			name:     "conflict with other source cluster",
+			existing: ptr(serviceEntry("other.example.com", selected, map[string]string{SourceClusterAnnotation: "other"})),
+			source:   serviceEntry("a.example.com", selected, nil),
+			event:    model.EventAdd,
+			expected: ptr(serviceEntry("other.example.com", selected, map[string]string{SourceClusterAnnotation: "other"})),
+			state:    Conflict,
+		},
+		{
+			name:     "delete",
+			existing: ptr(serviceEntry("a.example.com", selected, owned)),
+			source:   serviceEntry("a.example.com", selected, nil),
+			event:    model.EventDelete,
+		},
+		{
+			name:     "selector no longer matches",
+			existing: ptr(serviceEntry("a.example.com", selected, owned)),
+			source:   serviceEntry("a.example.com", nil, nil),
+			event:    model.EventUpdate,
+		},
+		{
+			name:   "replica of another cluster",
+			source: serviceEntry("a.example.com", selected, map[string]string{SourceClusterAnnotation: "other"}),
+			event:  model.EventAdd,
+		},
+		{
+			name:     "delete keeps unowned object",
+			existing: ptr(serviceEntry("local.example.com", nil, nil)),
+			source:   serviceEntry("a.example.com", selected, nil),
+			event:    model.EventDelete,
+			expected: ptr(serviceEntry("local.example.com", nil, nil)),
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			c, tgt := newTestController(t)
+			var rv string
+			if tt.existing != nil {
+				var err error
+				rv, err = tgt.dest.Create(*tt.existing)
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, c.reconcile(tgt, tt.source, tt.event))
+
+			got := tgt.dest.Get(gvk.ServiceEntry, "se", "ns")
+			if tt.expected == nil {
+				if got != nil {
+					t.Fatalf("expected no replica, got %v", got)
+				}
+			} else {
+				if got == nil {
+					t.Fatalf("expected replica, got none")
+				}
+				assert.Equal(t, got.Spec, tt.expected.Spec)
+				assert.Equal(t, got.Labels, tt.expected.Labels)
+				assert.Equal(t, got.Annotations, tt.expected.Annotations)
+				if tt.name == "no change" && got.ResourceVersion != rv {
+					t.Fatalf("expected no update, resource version changed from %v to %v", rv, got.ResourceVersion)
+				}
+			}
+
+			status := tgt.status[statusKey(tt.source)]
+			assert.Equal(t, status.State, tt.state)
+		})
+	}
+}
+
+func TestReconcileNamespaceFilter(t *testing.T) {
+	c, tgt := newTestController(t)
+	se := serviceEntry("a.example.com", selected, nil)
+	se.Namespace = "excluded"
+	assert.NoError(t, c.reconcile(tgt, se, model.EventAdd))
+	if got := tgt.dest.Get(gvk.ServiceEntry, "se", "excluded"); got != nil {
+		t.Fatalf("expected no replica in undiscovered namespace, got %v", got)
+	}
+}
+
+func TestRunResync(t *testing.T) {
+	c, _ := newTestController(t)
+	source := c.source
+	dest := memory.Make(schemas)
+
+	// Created before replication starts; the handler never sees an event for it.
+	_, err := source.Create(serviceEntry("a.example.com", selected, nil))
+	assert.NoError(t, err)
+	// A replica whose source was deleted while no term was active.
+	orphan := serviceEntry("orphan.example.com", selected, owned)
+	orphan.Name = "orphan"
+	_, err = dest.Create(orphan)
+	assert.NoError(t, err)
+	// Objects not created by replication must survive the resync.
+	unowned := serviceEntry("local.example.com", nil, nil)
+	unowned.Name = "local"
+	_, err = dest.Create(unowned)
+	assert.NoError(t, err)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go c.Run("remote", dest, nil, stop)
+
+	retry.UntilSuccessOrFail(t, func() error {
+		if dest.Get(gvk.ServiceEntry, "se", "ns") == nil {
+			return fmt.Errorf("replica not created")
+		}
+		if dest.Get(gvk.ServiceEntry, "orphan", "ns") != nil {
+			return fmt.Errorf("orphan not removed")
+		}
+		if dest.Get(gvk.ServiceEntry, "local", "ns") == nil {
+			return fmt.Errorf("unowned object removed")
+		}
+		return nil
+	}, retry.Timeout(time.Second*5))
+
+	// Events are dispatched to the active term.
+	_, err = source.Update(withResourceVersion(source, serviceEntry("b.example.com", selected, nil)))
+	assert.NoError(t, err)
+	retry.UntilSuccessOrFail(t, func() error {
+		got := dest.Get(gvk.ServiceEntry, "se", "ns")
+		if got == nil || got.Spec.(*networking.ServiceEntry).Hosts[0] != "b.example.com" {
+			return fmt.Errorf("replica not updated")
+		}
+		return nil
+	}, retry.Timeout(time.Second*5))
+	assert.Equal(t, len(c.Status()), 1)
+}
+
+func TestRunTwoClusters(t *testing.T) {
+	// Two primaries replicating to each other, as in a multi-primary mesh.
+	newCluster := func(id string) (*Controller, model.ConfigStoreController) {
+		opts, err := NewOptions(cluster.ID(id), "ServiceEntry", "istio.io/replicate=true")
+		assert.NoError(t, err)
+		store := memory.NewSyncController(memory.Make(schemas))
+		return NewController(store, opts), store
+	}
+	a, storeA := newCluster("a")
+	b, storeB := newCluster("b")
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go a.Run("b", storeB, nil, stop)
+	go b.Run("a", storeA, nil, stop)
+
+	_, err := storeA.Create(serviceEntry("a.example.com", selected, nil))
+	assert.NoError(t, err)
+	se := serviceEntry("b.example.com", selected, nil)
+	se.Name = "se-b"
+	_, err = storeB.Create(se)
+	assert.NoError(t, err)
+
+	retry.UntilSuccessOrFail(t, func() error {
+		if got := storeB.Get(gvk.ServiceEntry, "se", "ns"); got == nil || got.Annotations[SourceClusterAnnotation] != "a" {
+			return fmt.Errorf("replica of a not created in b: %v", got)
+		}
+		if got := storeA.Get(gvk.ServiceEntry, "se-b", "ns"); got == nil || got.Annotations[SourceClusterAnnotation] != "b" {
+			return fmt.Errorf("replica of b not created in a: %v", got)
+		}
+		return nil
+	}, retry.Timeout(time.Second*5))
+
+	// The replicas are not copied back: the sources keep no annotation and nothing is reported in conflict.
+	replicaVersion := storeB.Get(gvk.ServiceEntry, "se", "ns").ResourceVersion
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, storeA.Get(gvk.ServiceEntry, "se", "ns").Annotations[SourceClusterAnnotation], "")
+	assert.Equal(t, storeB.Get(gvk.ServiceEntry, "se-b", "ns").Annotations[SourceClusterAnnotation], "")
+	assert.Equal(t, storeB.Get(gvk.ServiceEntry, "se", "ns").ResourceVersion, replicaVersion)
+	for _, s := range append(a.Status(), b.Status()...) {
+		assert.Equal(t, s.State, Synced)
+	}
+	assert.Equal(t, len(a.Status()), 1)
+	assert.Equal(t, len(b.Status()), 1)
+
+	// Deleting a source removes its replica only.
+	assert.NoError(t, storeA.Delete(gvk.ServiceEntry, "se", "ns", nil))
+	retry.UntilSuccessOrFail(t, func() error {
+		if storeB.Get(gvk.ServiceEntry, "se", "ns") != nil {
+			return fmt.Errorf("replica of a not removed from b")
+		}
+		return nil
+	}, retry.Timeout(time.Second*5))
+	assert.Equal(t, storeA.Get(gvk.ServiceEntry, "se-b", "ns") != nil, true)
+}
+
+func TestDispatchWithoutActiveTerm(t *testing.T) {
+	c, _ := newTestController(t)
+	// No term is active, so events must not be queued anywhere.
+	_, err := c.source.Create(serviceEntry("a.example.com", selected, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, len(c.targets), 0)
+	assert.Equal(t, len(c.Status()), 0)
+}
+
+func TestNewOptions(t *testing.T) {
+	if _, err := NewOptions("primary", "ServiceEntry,Sidecar,AuthorizationPolicy", "istio.io/replicate=true"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewOptions("primary", "NotAKind", "istio.io/replicate=true"); err == nil {
+		t.Fatal("expected error for unknown kind")
+	}
+	if _, err := NewOptions("primary", "ServiceEntry", "a b c"); err == nil {
+		t.Fatal("expected error for invalid selector")
+	}
+}
+
+func withResourceVersion(store model.ConfigStore, cfg config.Config) config.Config {
+	cfg.ResourceVersion = store.Get(cfg.GroupVersionKind, cfg.Name, cfg.Namespace).ResourceVersion
+	return cfg
+}
+
+func ptr(c config.Config) *config.Config {
+	return &c
+}
@@ -0,0 +1,424 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model/credentials"
+	"istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/security/pkg/pki/util"
+	caserver "istio.io/istio/security/pkg/server/ca"
+	"istio.io/pkg/log"
+	"istio.io/pkg/monitoring"
+)
+
+const (
+	// CertificateExpiringReason is the reason of the event recorded when a certificate expires within the threshold.
+	CertificateExpiringReason = "CertificateExpiring"
+	// CertificateExpiredReason is the reason of the event recorded when a certificate has expired.
+	CertificateExpiredReason = "CertificateExpired"
+
+	// expiryCheckInterval is the interval at which the expiries of the certificates are checked.
+	expiryCheckInterval = time.Minute
+
+	// IssuedCertificatesLabel labels the ConfigMaps in which each istiod publishes the workload certificates it issued.
+	IssuedCertificatesLabel  = "istio.io/issued-certificates"
+	issuedCertificatesPrefix = "istio-issued-certificates-"
+	issuedCertificatesKey    = "certificates.json"
+)
+
+var (
+	certTypeTag = monitoring.MustCreateLabel("type")
+
+	expiringCertificates = monitoring.NewGauge(
+		"pilot_expiring_certificates",
+		"Number of certificates expiring within the alert threshold, or expired, by type: the certificates of the "+
+			"gateway credential secrets, or the workload certificates issued by the istiods. Only reported by the leader.",
+		monitoring.WithLabels(certTypeTag),
+	)
+)
+
+func init() {
+	monitoring.MustRegister(expiringCertificates)
+}
+
+// expiringCertificate is a certificate tracked by the ExpiryController, with the object its events are recorded on.
+type expiringCertificate struct {
+	object   v1.ObjectReference
+	serial   string
+	notAfter time.Time
+}
+
+// ExpiryController reports the certificates expiring within a threshold as Kubernetes events on their object, and
+// as the pilot_expiring_certificates metric, so that alerting does not depend on scraping the proxies. Each
+// certificate is reported once when it crosses the threshold, and once more when it expires.
+type ExpiryController struct {
+	client    kubernetes.Interface
+	certType  string
+	threshold time.Duration
+	synced    cache.InformerSynced
+	list      func() []expiringCertificate
+	now       func() time.Time
+
+	// reported is the serial and reason of the certificate last reported for each object.
+	reported map[v1.ObjectReference]string
+}
+
+// NewSecretExpiryController returns an ExpiryController for the certificates of the gateway credential secrets of
+// the cluster, the TLS secrets referenced by the credentialName of the servers of gateways. Only the leader should
+// run it, as all the istiods watch the same secrets.
+func NewSecretExpiryController(client kube.Client, clusterID cluster.ID, gateways func() []config.Config,
+	threshold time.Duration,
+) *ExpiryController {
+	creds := NewCredentialsController(client, clusterID)
+	pods := client.KubeInformer().Core().V1().Pods()
+	podsSynced := pods.Informer().HasSynced
+	return &ExpiryController{
+		client:    client.Kube(),
+		certType:  "secret",
+		threshold: threshold,
+		synced: func() bool {
+			return creds.secretInformer.HasSynced() && podsSynced()
+		},
+		list: func() []expiringCertificate {
+			return secretCertificates(creds.secretLister, gatewaySecrets(gateways(), pods.Lister()))
+		},
+		now:      time.Now,
+		reported: map[v1.ObjectReference]string{},
+	}
+}
+
+// NewWorkloadCertExpiryController returns an ExpiryController for the workload certificates issued to the pods of
+// the cluster by the istiods of namespace, as published by their IssuedCertificatePublisher. Only the latest
+// certificate of each pod is reported, whichever istiod issued it, and the certificates of the pods which no longer
+// exist are not, as they are not rotated anymore. Only the leader should run it.
+func NewWorkloadCertExpiryController(client kube.Client, namespace string, threshold time.Duration) *ExpiryController {
+	pods := client.KubeInformer().Core().V1().Pods()
+	configMaps := client.Kube().CoreV1().ConfigMaps(namespace)
+	return &ExpiryController{
+		client:    client.Kube(),
+		certType:  "workload",
+		threshold: threshold,
+		synced:    pods.Informer().HasSynced,
+		list: func() []expiringCertificate {
+			return workloadCertificates(pods.Lister(), publishedCertificates(configMaps))
+		},
+		now:      time.Now,
+		reported: map[v1.ObjectReference]string{},
+	}
+}
+
+// Run checks the expiries of the certificates until stop is closed.
+func (c *ExpiryController) Run(stop <-chan struct{}) {
+	if !cache.WaitForCacheSync(stop, c.synced) {
+		return
+	}
+	log.Infof("checking the expiry of %s certificates, with a threshold of %v", c.certType, c.threshold)
+	wait.Until(c.check, expiryCheckInterval, stop)
+}
+
+func (c *ExpiryController) check() {
+	now := c.now()
+	expiring := 0
+	seen := map[v1.ObjectReference]struct{}{}
+	for _, cert := range c.list() {
+		seen[cert.object] = struct{}{}
+		if cert.notAfter.Sub(now) > c.threshold {
+			continue
+		}
+		expiring++
+		reason := CertificateExpiringReason
+		message := fmt.Sprintf("Certificate %s expires at %s, in %v", cert.serial,
+			cert.notAfter.UTC().Format(time.RFC3339), cert.notAfter.Sub(now).Round(time.Second))
+		if !cert.notAfter.After(now) {
+			reason = CertificateExpiredReason
+			message = fmt.Sprintf("Certificate %s expired at %s", cert.serial, cert.notAfter.UTC().Format(time.RFC3339))
+		}
+		if c.reported[cert.object] == cert.serial+"/"+reason {
+			continue
+		}
+		log.Warnf("%s %s/%s: %s", cert.object.Kind, cert.object.Namespace, cert.object.Name, message)
+		if err := c.recordEvent(cert.object, cert.serial, reason, message); err != nil {
+			log.Warnf("failed to record certificate expiry event for %s/%s: %v", cert.object.Namespace, cert.object.Name, err)
+			continue
+		}
+		c.reported[cert.object] = cert.serial + "/" + reason
+	}
+	for o := range c.reported {
+		if _, f := seen[o]; !f {
+			delete(c.reported, o)
+		}
+	}
+	expiringCertificates.With(certTypeTag.Value(c.certType)).Record(float64(expiring))
+}
+
+// recordEvent records the event of the certificate of the object. The event is named after the certificate and the
+// reason, so that it is recorded once even if a new leader reports it again.
+func (c *ExpiryController) recordEvent(object v1.ObjectReference, serial, reason, message string) error {
+	now := metav1.NewTime(c.now())
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(string(object.UID) + "/" + serial + "/" + reason))
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%v.%x", object.Name, h.Sum64()),
+			Namespace: object.Namespace,
+		},
+		InvolvedObject: object,
+		Reason:         reason,
+		Message:        message,
+		Type:           v1.EventTypeWarning,
+		Source:         v1.EventSource{Component: "istiod"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	_, err := c.client.CoreV1().Events(object.Namespace).Create(context.TODO(), event, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// secretCertificates returns the leaf certificates of the TLS secrets of names, given as namespace/name, holding a
+// certificate chain and a key.
+func secretCertificates(secrets listersv1.SecretLister, names sets.Set) []expiringCertificate {
+	var out []expiringCertificate
+	for _, name := range names.SortedList() {
+		namespace, name, _ := strings.Cut(name, "/")
+		s, err := secrets.Secrets(namespace).Get(name)
+		if err != nil || (s.Type != v1.SecretTypeTLS && s.Type != v1.SecretTypeOpaque) {
+			continue
+		}
+		_, certPEM, err := extractKeyAndCert(s)
+		if err != nil {
+			continue
+		}
+		cert, err := util.ParsePemEncodedCertificate(certPEM)
+		if err != nil {
+			continue
+		}
+		out = append(out, expiringCertificate{
+			object:   v1.ObjectReference{APIVersion: "v1", Kind: "Secret", Name: s.Name, Namespace: s.Namespace, UID: s.UID},
+			serial:   cert.SerialNumber.String(),
+			notAfter: cert.NotAfter,
+		})
+	}
+	return out
+}
+
+// gatewaySecrets returns the secrets referenced by the credentialName of the servers of the gateways, as
+// namespace/name. A credentialName without a namespace refers to the secret of the namespace of each gateway pod.
+func gatewaySecrets(gateways []config.Config, pods listersv1.PodLister) sets.Set {
+	out := sets.New()
+	for _, gw := range gateways {
+		spec, ok := gw.Spec.(*networking.Gateway)
+		if !ok {
+			continue
+		}
+		var namespaces []string
+		for _, s := range spec.Servers {
+			cn := s.GetTls().GetCredentialName()
+			if cn == "" || strings.HasPrefix(cn, credentials.BuiltinGatewaySecretTypeURI) {
+				continue
+			}
+			sr, err := credentials.ParseResourceName(credentials.ToResourceName(cn), "", "", "")
+			if err != nil {
+				continue
+			}
+			if sr.Namespace != "" {
+				out.Insert(sr.Namespace + "/" + sr.Name)
+				continue
+			}
+			if namespaces == nil {
+				namespaces = gatewayPodNamespaces(gw.Namespace, spec.Selector, pods)
+			}
+			for _, ns := range namespaces {
+				out.Insert(ns + "/" + sr.Name)
+			}
+		}
+	}
+	return out
+}
+
+// gatewayPodNamespaces returns the namespaces of the pods selected by the selector of a gateway of namespace.
+func gatewayPodNamespaces(namespace string, selector map[string]string, pods listersv1.PodLister) []string {
+	var selected []*v1.Pod
+	var err error
+	if features.ScopeGatewayToNamespace {
+		selected, err = pods.Pods(namespace).List(klabels.SelectorFromSet(selector))
+	} else {
+		selected, err = pods.List(klabels.SelectorFromSet(selector))
+	}
+	if err != nil {
+		return []string{}
+	}
+	namespaces := sets.New()
+	for _, p := range selected {
+		namespaces.Insert(p.Namespace)
+	}
+	return namespaces.SortedList()
+}
+
+// publishedCertificates returns the latest certificate issued to each requester among the ones published by the
+// istiods in the ConfigMaps.
+func publishedCertificates(configMaps typedcorev1.ConfigMapInterface) []caserver.IssuedCertificate {
+	cms, err := configMaps.List(context.TODO(), metav1.ListOptions{LabelSelector: IssuedCertificatesLabel})
+	if err != nil {
+		log.Warnf("failed to list the issued certificates: %v", err)
+		return nil
+	}
+	latest := map[string]caserver.IssuedCertificate{}
+	for _, cm := range cms.Items {
+		var certs []caserver.IssuedCertificate
+		if err := json.Unmarshal([]byte(cm.Data[issuedCertificatesKey]), &certs); err != nil {
+			log.Warnf("invalid issued certificates in ConfigMap %s/%s: %v", cm.Namespace, cm.Name, err)
+			continue
+		}
+		for _, c := range certs {
+			if l, f := latest[c.Requester]; !f || c.NotBefore.After(l.NotBefore) {
+				latest[c.Requester] = c
+			}
+		}
+	}
+	out := make([]caserver.IssuedCertificate, 0, len(latest))
+	for _, c := range latest {
+		out = append(out, c)
+	}
+	return out
+}
+
+// workloadCertificates returns the issued certificates of the pods, by the IP of their requester.
+func workloadCertificates(pods listersv1.PodLister, issued []caserver.IssuedCertificate) []expiringCertificate {
+	if len(issued) == 0 {
+		return nil
+	}
+	all, err := pods.List(klabels.Everything())
+	if err != nil {
+		return nil
+	}
+	byIP := map[string]*v1.Pod{}
+	for _, p := range all {
+		if p.Spec.HostNetwork {
+			// The IP of the pods on the host network is shared with the node and its other pods.
+			continue
+		}
+		for _, ip := range p.Status.PodIPs {
+			byIP[ip.IP] = p
+		}
+	}
+	var out []expiringCertificate
+	for _, c := range issued {
+		p, f := byIP[c.Requester]
+		if !f {
+			continue
+		}
+		out = append(out, expiringCertificate{
+			object:   v1.ObjectReference{APIVersion: "v1", Kind: "Pod", Name: p.Name, Namespace: p.Namespace, UID: p.UID},
+			serial:   c.Serial,
+			notAfter: c.NotAfter,
+		})
+	}
+	return out
+}
+
+// IssuedCertificatePublisher publishes the workload certificates issued by the CA of an istiod in a ConfigMap of its
+// namespace, owned by its pod, for the workload ExpiryController of the leader to report the latest certificate of
+// each pod whichever istiod issued it. Each istiod should run it.
+type IssuedCertificatePublisher struct {
+	client    kubernetes.Interface
+	namespace string
+	podName   string
+	issued    func() []caserver.IssuedCertificate
+
+	owner []metav1.OwnerReference
+	// published is the content of the ConfigMap last written.
+	published string
+}
+
+// NewIssuedCertificatePublisher returns an IssuedCertificatePublisher for the certificates listed by issued, including
+// the recently expired ones, of the istiod pod podName of namespace.
+func NewIssuedCertificatePublisher(client kube.Client, namespace, podName string,
+	issued func() []caserver.IssuedCertificate,
+) *IssuedCertificatePublisher {
+	return &IssuedCertificatePublisher{
+		client:    client.Kube(),
+		namespace: namespace,
+		podName:   podName,
+		issued:    issued,
+	}
+}
+
+// Run publishes the certificates until stop is closed.
+func (p *IssuedCertificatePublisher) Run(stop <-chan struct{}) {
+	pod, err := p.client.CoreV1().Pods(p.namespace).Get(context.TODO(), p.podName, metav1.GetOptions{})
+	if err == nil {
+		p.owner = []metav1.OwnerReference{{APIVersion: "v1", Kind: "Pod", Name: pod.Name, UID: pod.UID}}
+	} else {
+		log.Warnf("failed to get the istiod pod %s/%s, its issued certificates will not be garbage collected: %v",
+			p.namespace, p.podName, err)
+	}
+	wait.Until(p.publish, expiryCheckInterval, stop)
+}
+
+func (p *IssuedCertificatePublisher) publish() {
+	certs, err := json.Marshal(p.issued())
+	if err != nil {
+		log.Warnf("failed to encode the issued certificates: %v", err)
+		return
+	}
+	if string(certs) == p.published {
+		return
+	}
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            issuedCertificatesPrefix + p.podName,
+			Namespace:       p.namespace,
+			Labels:          map[string]string{IssuedCertificatesLabel: "true"},
+			OwnerReferences: p.owner,
+		},
+		Data: map[string]string{issuedCertificatesKey: string(certs)},
+	}
+	configMaps := p.client.CoreV1().ConfigMaps(p.namespace)
+	_, err = configMaps.Update(context.TODO(), cm, metav1.UpdateOptions{})
+	if errors.IsNotFound(err) {
+		_, err = configMaps.Create(context.TODO(), cm, metav1.CreateOptions{})
+	}
+	if err != nil {
+		log.Warnf("failed to publish the issued certificates in ConfigMap %s/%s: %v", cm.Namespace, cm.Name, err)
+		return
+	}
+	p.published = string(certs)
+}
@@ -0,0 +1,159 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/security/pkg/pki/util"
+	caserver "istio.io/istio/security/pkg/server/ca"
+)
+
+func TestSecretExpiryController(t *testing.T) {
+	now := time.Now()
+	cert := func(ttl time.Duration) string {
+		certPEM, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+			Host:         "gateway.example.com",
+			NotBefore:    now.Add(-time.Hour),
+			TTL:          ttl + time.Hour,
+			IsSelfSigned: true,
+			ECSigAlg:     util.EcdsaSigAlg,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(certPEM)
+	}
+	gatewayPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "ingress", Namespace: "default", Labels: map[string]string{"istio": "ingress"}},
+	}
+	client := kube.NewFakeClient(
+		gatewayPod,
+		makeSecret("expiring", map[string]string{TLSSecretCert: cert(48 * time.Hour), TLSSecretKey: "key"}, corev1.SecretTypeTLS),
+		makeSecret("valid", map[string]string{GenericScrtCert: cert(30 * 24 * time.Hour), GenericScrtKey: "key"}, corev1.SecretTypeTLS),
+		makeSecret("ca-only", map[string]string{TLSSecretCaCert: cert(time.Hour)}, corev1.SecretTypeTLS),
+		// Not referenced by a gateway.
+		makeSecret("unused", map[string]string{TLSSecretCert: cert(time.Hour), TLSSecretKey: "key"}, corev1.SecretTypeTLS),
+		// Not a TLS secret.
+		makeSecret("other", map[string]string{TLSSecretCert: cert(time.Hour), TLSSecretKey: "key"}, "example.com/other"),
+	)
+	server := func(credentialName string) *networking.Server {
+		return &networking.Server{
+			Port:  &networking.Port{Number: 443, Protocol: "HTTPS", Name: "https-" + credentialName},
+			Hosts: []string{"*"},
+			Tls:   &networking.ServerTLSSettings{Mode: networking.ServerTLSSettings_SIMPLE, CredentialName: credentialName},
+		}
+	}
+	gateways := []config.Config{{
+		Meta: config.Meta{GroupVersionKind: gvk.Gateway, Name: "ingress", Namespace: "istio-system"},
+		Spec: &networking.Gateway{
+			Selector: map[string]string{"istio": "ingress"},
+			Servers:  []*networking.Server{server("expiring"), server("ca-only"), server("other")},
+		},
+	}, {
+		Meta: config.Meta{GroupVersionKind: gvk.Gateway, Name: "gateway-api", Namespace: "default"},
+		Spec: &networking.Gateway{Servers: []*networking.Server{server("kubernetes-gateway://default/valid")}},
+	}}
+	c := NewSecretExpiryController(client, "", func() []config.Config { return gateways }, 7*24*time.Hour)
+	c.now = func() time.Time { return now }
+	client.RunAndWait(test.NewStop(t))
+
+	c.check()
+	assert.Equal(t, expiryEvents(t, client), []string{"Secret/expiring/CertificateExpiring"})
+	// Each certificate is reported once.
+	c.check()
+	assert.Equal(t, expiryEvents(t, client), []string{"Secret/expiring/CertificateExpiring"})
+
+	c.now = func() time.Time { return now.Add(72 * time.Hour) }
+	c.check()
+	assert.Equal(t, expiryEvents(t, client), []string{"Secret/expiring/CertificateExpired", "Secret/expiring/CertificateExpiring"})
+}
+
+func TestWorkloadCertExpiryController(t *testing.T) {
+	now := time.Now()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "reviews", Namespace: "default", UID: "reviews"},
+		Status:     corev1.PodStatus{PodIPs: []corev1.PodIP{{IP: "10.0.0.1"}}},
+	}
+	ratings := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "ratings", Namespace: "default", UID: "ratings"},
+		Status:     corev1.PodStatus{PodIPs: []corev1.PodIP{{IP: "10.0.0.3"}}},
+	}
+	client := kube.NewFakeClient(pod, ratings)
+	issuedA := []caserver.IssuedCertificate{
+		{Requester: "10.0.0.1", Serial: "1", NotBefore: now.Add(-23 * time.Hour), NotAfter: now.Add(30 * time.Minute)},
+		// The pod of this certificate was deleted, so it is not rotated anymore.
+		{Requester: "10.0.0.2", Serial: "2", NotBefore: now.Add(-23 * time.Hour), NotAfter: now.Add(30 * time.Minute)},
+		// This pod rotated its certificate with the other istiod.
+		{Requester: "10.0.0.3", Serial: "3", NotBefore: now.Add(-23 * time.Hour), NotAfter: now.Add(30 * time.Minute)},
+	}
+	issuedB := []caserver.IssuedCertificate{
+		{Requester: "10.0.0.3", Serial: "4", NotBefore: now.Add(-11 * time.Hour), NotAfter: now.Add(13 * time.Hour)},
+	}
+	publisherA := NewIssuedCertificatePublisher(client, "istio-system", "istiod-a", func() []caserver.IssuedCertificate { return issuedA })
+	publisherB := NewIssuedCertificatePublisher(client, "istio-system", "istiod-b", func() []caserver.IssuedCertificate { return issuedB })
+	publisherA.publish()
+	publisherB.publish()
+
+	c := NewWorkloadCertExpiryController(client, "istio-system", time.Hour)
+	c.now = func() time.Time { return now }
+	client.RunAndWait(test.NewStop(t))
+
+	c.check()
+	assert.Equal(t, expiryEvents(t, client), []string{"Pod/reviews/CertificateExpiring"})
+
+	// A new leader does not report the certificate again.
+	leader := NewWorkloadCertExpiryController(client, "istio-system", time.Hour)
+	leader.now = c.now
+	leader.check()
+	assert.Equal(t, expiryEvents(t, client), []string{"Pod/reviews/CertificateExpiring"})
+
+	// The expired certificates are still published, to be reported.
+	leader.now = func() time.Time { return now.Add(time.Hour) }
+	leader.check()
+	assert.Equal(t, expiryEvents(t, client), []string{"Pod/reviews/CertificateExpired", "Pod/reviews/CertificateExpiring"})
+
+	// The rotated certificate is not reported.
+	issuedB = append(issuedB, caserver.IssuedCertificate{Requester: "10.0.0.1", Serial: "5", NotBefore: now, NotAfter: now.Add(24 * time.Hour)})
+	publisherB.publish()
+	leader.check()
+	assert.Equal(t, expiryEvents(t, client), []string{"Pod/reviews/CertificateExpired", "Pod/reviews/CertificateExpiring"})
+}
+
+// expiryEvents returns the events of the default namespace, as kind/name/reason.
+func expiryEvents(t *testing.T, client kube.Client) []string {
+	events, err := client.Kube().CoreV1().Events("default").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := []string{}
+	for _, e := range events.Items {
+		out = append(out, e.InvolvedObject.Kind+"/"+e.InvolvedObject.Name+"/"+e.Reason)
+	}
+	sort.Strings(out)
+	return out
+}
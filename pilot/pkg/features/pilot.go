@@ -245,6 +245,14 @@ var (
 	MCSAPIVersion = env.RegisterStringVar("MCS_API_VERSION", "v1alpha1",
 		"The version to be used for the Kubernets Multi-Cluster Services (MCS) API.").Get()
 
+	PublishGatewayAddresses = env.RegisterBoolVar(
+		"PILOT_PUBLISH_GATEWAY_ADDRESSES",
+		false,
+		"If enabled, istiod maintains the istio-gateway-addresses ConfigMap in its namespace of the config cluster, "+
+			"listing the external addresses of the ingress gateway Services, labeled istio=ingressgateway, of all the "+
+			"clusters, by cluster and with the zones of their endpoints, for external DNS automation.",
+	).Get()
+
 	EnableMCSAutoExport = env.RegisterBoolVar(
 		"ENABLE_MCS_AUTO_EXPORT",
 		false,
@@ -314,6 +322,14 @@ var (
 		return val
 	}()
 
+	AnalysisSuppressionFile = env.RegisterStringVar(
+		"PILOT_ANALYSIS_SUPPRESSION_FILE",
+		"",
+		"If analysis is enabled, the path of a file of suppressions and severity overrides of message codes, in the "+
+			"format of the --suppression-file of istioctl analyze, applied to the analysis messages written to the "+
+			"Status field of Istio Resources",
+	).Get()
+
 	EnableStatus = env.RegisterBoolVar(
 		"PILOT_ENABLE_STATUS",
 		false,
@@ -356,6 +372,11 @@ var (
 	JwtPolicy = env.RegisterStringVar("JWT_POLICY", jwt.PolicyThirdParty,
 		"The JWT validation policy.").Get()
 
+	CANodeAttestation = env.RegisterBoolVar("CA_NODE_ATTESTATION", false,
+		"If enabled, istiod only signs certificates for Kubernetes service account tokens bound to a pod which is "+
+			"scheduled on an existing node, and requested from the IP of that pod, or of its node for host network pods. "+
+			"This protects against stolen tokens used off-node, but requires direct connectivity from the pods to istiod.").Get()
+
 	// Default request timeout for virtual services if a timeout is not configured in virtual service. It defaults to zero
 	// which disables timeout when it is not configured, to preserve the current behavior.
 	defaultRequestTimeoutVar = env.RegisterDurationVar(
@@ -422,6 +443,19 @@ var (
 		"If enabled, service entries with selectors will select pods from the cluster. "+
 			"It is safe to disable it if you are quite sure you don't need this feature").Get()
 
+	EnableServiceEntryDNSSRV = env.RegisterBoolVar("PILOT_ENABLE_SERVICE_ENTRY_DNS_SRV", false,
+		"If enabled, pilot resolves the endpoints of STATIC ServiceEntries with the networking.istio.io/dnsSRV annotation "+
+			"from the DNS SRV record it names, refreshing them when the record expires.").Get()
+
+	EnableCrossNamespaceServiceEntrySelector = env.RegisterBoolVar("PILOT_ENABLE_CROSS_NAMESPACE_SERVICEENTRY_SELECTOR", false,
+		"If enabled, the workload selector of a ServiceEntry also selects workloads in the namespaces listed in its "+
+			"networking.istio.io/workloadSelectorNamespaces annotation, for services of shared infrastructure.").Get()
+
+	EnableShadowClusters = env.RegisterBoolVar("PILOT_ENABLE_SHADOW_CLUSTERS", false,
+		"If enabled, traffic mirrored by a VirtualService is sent to a dedicated shadow copy of the mirror cluster, "+
+			"named after the cluster with a |shadow suffix, so the statistics of shadow traffic are separate "+
+			"from the statistics of the real traffic to the same destination.").Get()
+
 	InjectionWebhookConfigName = env.RegisterStringVar("INJECTION_WEBHOOK_CONFIG_NAME", "istio-sidecar-injector",
 		"Name of the mutatingwebhookconfiguration to patch, if istioctl is not used.").Get()
 
@@ -474,6 +508,15 @@ var (
 			"Setting the timeout to 0 disables this behavior.",
 	).Get()
 
+	APIServerStalenessThreshold = env.RegisterDurationVar(
+		"PILOT_API_SERVER_STALENESS_THRESHOLD",
+		0,
+		"If set, after the API server of a cluster is unreachable for this long, pilot keeps serving the last-known "+
+			"state of the cluster, marks the cluster as stale in the control plane identifier of its xDS responses and "+
+			"reports it in the pilot_k8s_registry_stale metric. The API server is probed a few times per threshold. "+
+			"Disabled by default.",
+	).Get()
+
 	EnableTelemetryLabel = env.RegisterBoolVar("PILOT_ENABLE_TELEMETRY_LABEL", true,
 		"If true, pilot will add telemetry related metadata to cluster and endpoint resources, which will be consumed by telemetry filter.",
 	).Get()
@@ -482,6 +525,12 @@ var (
 		"If true, pilot will add telemetry related metadata to Endpoint resource, which will be consumed by telemetry filter.",
 	).Get()
 
+	TrimXDSMetadata = env.RegisterBoolVar("PILOT_XDS_TRIM_METADATA", false,
+		"If true, pilot omits metadata which is not needed by the proxies from the generated configuration, "+
+			"to reduce the size of xDS responses: the telemetry metadata of endpoints, and the reference to the "+
+			"Istio config a cluster or route was generated from, which istioctl proxy-config shows.",
+	).Get()
+
 	MetadataExchange = env.RegisterBoolVar("PILOT_ENABLE_METADATA_EXCHANGE", true,
 		"If true, pilot will add metadata exchange filters, which will be consumed by telemetry filter.",
 	).Get()
@@ -500,15 +549,59 @@ var (
 	WorkloadEntryHealthChecks = env.RegisterBoolVar("PILOT_ENABLE_WORKLOAD_ENTRY_HEALTHCHECKS", true,
 		"Enables automatic health checks of WorkloadEntries based on the config provided in the associated WorkloadGroup").Get()
 
+	EnableProxyStartupEvents = env.RegisterBoolVar("PILOT_ENABLE_PROXY_STARTUP_EVENTS", false,
+		"If enabled, pilot will record a Kubernetes event on the pod of a sidecar when it reports its startup phases. "+
+			"Only sidecars with a verified identity matching the service account of their pod are recorded.").Get()
+
+	ProxySlowStartupThreshold = env.RegisterDurationVar("PILOT_PROXY_SLOW_STARTUP_THRESHOLD", 30*time.Second,
+		"The duration of a single proxy startup phase above which the startup event of the proxy is a warning.").Get()
+
+	EnableCertExpiryAlerts = env.RegisterBoolVar("PILOT_ENABLE_CERT_EXPIRY_ALERTS", false,
+		"If enabled, the leader istiod records a Kubernetes event, and counts in the pilot_expiring_certificates metric, "+
+			"the certificates of the TLS secrets referenced by gateways and the workload certificates issued by the "+
+			"istiods expiring within PILOT_CERT_EXPIRY_ALERT_THRESHOLD and PILOT_WORKLOAD_CERT_EXPIRY_ALERT_THRESHOLD. "+
+			"Each istiod publishes the workload certificates it issued in a ConfigMap of its namespace.").Get()
+
+	CertExpiryAlertThreshold = env.RegisterDurationVar("PILOT_CERT_EXPIRY_ALERT_THRESHOLD", 7*24*time.Hour,
+		"The remaining validity of the certificate of a gateway credential secret below which it is reported as expiring.").Get()
+
+	WorkloadCertExpiryAlertThreshold = env.RegisterDurationVar("PILOT_WORKLOAD_CERT_EXPIRY_ALERT_THRESHOLD", time.Hour,
+		"The remaining validity of a workload certificate issued by istiod below which it is reported as expiring. "+
+			"Workload certificates are rotated well before they expire, so one crossing it was not rotated.").Get()
+
 	WorkloadEntryCrossCluster = env.RegisterBoolVar("PILOT_ENABLE_CROSS_CLUSTER_WORKLOAD_ENTRY", true,
 		"If enabled, pilot will read WorkloadEntry from other clusters, selectable by Services in that cluster.").Get()
 
+	ConfigReplicationKinds = env.RegisterStringVar(
+		"PILOT_CONFIG_REPLICATION_KINDS",
+		"",
+		"Comma separated list of Istio config kinds (for example ServiceEntry,Sidecar,AuthorizationPolicy) "+
+			"that istiod replicates from the config cluster to the remote clusters it manages. Only objects matching "+
+			"PILOT_CONFIG_REPLICATION_SELECTOR are replicated. If empty, replication is disabled. Invalid kinds prevent "+
+			"istiod from starting. The remote secret of each remote cluster must grant get, list, watch, create, update "+
+			"and delete on the replicated kinds in all namespaces.",
+	).Get()
+
+	ConfigReplicationSelector = env.RegisterStringVar(
+		"PILOT_CONFIG_REPLICATION_SELECTOR",
+		"istio.io/replicate=true",
+		"Label selector restricting which objects of PILOT_CONFIG_REPLICATION_KINDS are replicated to remote clusters.",
+	).Get()
+
 	EnableDestinationRuleInheritance = env.RegisterBoolVar(
 		"PILOT_ENABLE_DESTINATION_RULE_INHERITANCE",
 		false,
 		"If set, workload specific DestinationRules will inherit configurations settings from mesh and namespace level rules",
 	).Get()
 
+	EnableNamespaceTrafficPolicyDefaults = env.RegisterBoolVar(
+		"PILOT_ENABLE_NAMESPACE_TRAFFIC_POLICY_DEFAULTS",
+		false,
+		"If set, and PILOT_ENABLE_DESTINATION_RULE_INHERITANCE is not, mesh and namespace level DestinationRules only set "+
+			"connectionPool and outlierDetection defaults for the DestinationRules that do not set them, and the default "+
+			"retry policy of the routes of their namespace.",
+	).Get()
+
 	WasmRemoteLoadConversion = env.RegisterBoolVar("ISTIO_AGENT_ENABLE_WASM_REMOTE_LOAD_CONVERSION", true,
 		"If enabled, Istio agent will intercept ECDS resource update, downloads Wasm module, "+
 			"and replaces Wasm module remote load with downloaded local module file.").Get()
@@ -564,6 +657,11 @@ var (
 		"If enabled, Gateway's with ISTIO_MUTUAL mode and credentialName configured will use simple TLS. "+
 			"This is to retain legacy behavior only and not recommended for use beyond migration.").Get()
 
+	EnableGatewayWorkloadCertificate = env.RegisterBoolVar("PILOT_ENABLE_GATEWAY_WORKLOAD_CERTIFICATE", false,
+		"If enabled, Gateway servers with SIMPLE TLS and neither a credentialName nor certificate files are valid. They "+
+			"present the certificate istiod issued to the gateway, which holds the DNS names of the "+
+			"security.istio.io/gatewayDNSSANs annotation of the gateway pods.").Get()
+
 	EnableLegacyAutoPassthrough = env.RegisterBoolVar(
 		"PILOT_ENABLE_LEGACY_AUTO_PASSTHROUGH",
 		false,
@@ -585,6 +683,15 @@ var (
 	MulticlusterHeadlessEnabled = env.RegisterBoolVar("ENABLE_MULTICLUSTER_HEADLESS", true,
 		"If true, the DNS name table for a headless service will resolve to same-network endpoints in any cluster.").Get()
 
+	LocalityNodeLabels = env.RegisterStringVar("PILOT_LOCALITY_NODE_LABELS", "",
+		"Comma separated list of region=<label>, zone=<label> and subzone=<label> entries naming the node labels "+
+			"the locality of pods is derived from, for clusters that do not use the standard topology labels. "+
+			"The standard labels are used for any level that is not set or that a node does not have.").Get()
+
+	DNSLocalityOrderingEnabled = env.RegisterBoolVar("PILOT_ENABLE_DNS_LOCALITY_ORDERING", false,
+		"If true, the DNS name table for a headless service will list the endpoints closest to the proxy first, "+
+			"by locality and then by cluster, so clients picking the first addresses prefer nearby endpoints.").Get()
+
 	ResolveHostnameGateways = env.RegisterBoolVar("RESOLVE_HOSTNAME_GATEWAYS", true,
 		"If true, hostnames in the LoadBalancer addresses of a Service will be resolved at the control plane for use in cross-network gateways.").Get()
 
@@ -0,0 +1,114 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// Compressors supported on xDS streams. A gRPC server compresses its responses with the compressor the client used
+// for its requests, so istiod compresses the xDS responses of the clients which opt in, such as istio-agent with
+// XDS_COMPRESSION set.
+const (
+	GzipCompressor = gzip.Name
+	ZstdCompressor = "zstd"
+)
+
+func init() {
+	encoding.RegisterCompressor(&zstdCompressor{})
+}
+
+// ValidateCompressor checks the compressor is supported.
+func ValidateCompressor(name string) error {
+	switch name {
+	case "", GzipCompressor, ZstdCompressor:
+		return nil
+	}
+	return fmt.Errorf("unsupported xDS compressor %q, must be one of %s|%s", name, GzipCompressor, ZstdCompressor)
+}
+
+// zstdCompressor is a gRPC compressor using zstd, which compresses xDS better and faster than gzip.
+type zstdCompressor struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+var _ encoding.Compressor = &zstdCompressor{}
+
+func (z *zstdCompressor) Name() string {
+	return ZstdCompressor
+}
+
+func (z *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	if enc, ok := z.encoders.Get().(*zstd.Encoder); ok {
+		enc.Reset(w)
+		return &zstdWriter{Encoder: enc, pool: &z.encoders}, nil
+	}
+	enc, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	if err != nil {
+		return nil, err
+	}
+	return &zstdWriter{Encoder: enc, pool: &z.encoders}, nil
+}
+
+func (z *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	if dec, ok := z.decoders.Get().(*zstd.Decoder); ok {
+		if err := dec.Reset(r); err != nil {
+			return nil, err
+		}
+		return &zstdReader{Decoder: dec, pool: &z.decoders}, nil
+	}
+	dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &zstdReader{Decoder: dec, pool: &z.decoders}, nil
+}
+
+// zstdWriter returns the encoder to the pool once the message is compressed.
+type zstdWriter struct {
+	*zstd.Encoder
+	pool *sync.Pool
+}
+
+func (w *zstdWriter) Close() error {
+	err := w.Encoder.Close()
+	w.pool.Put(w.Encoder)
+	return err
+}
+
+// zstdReader returns the decoder to the pool once the message is decompressed.
+type zstdReader struct {
+	*zstd.Decoder
+	pool *sync.Pool
+}
+
+func (r *zstdReader) Read(p []byte) (int, error) {
+	if r.Decoder == nil {
+		return 0, io.EOF
+	}
+	n, err := r.Decoder.Read(p)
+	if err == io.EOF {
+		r.pool.Put(r.Decoder)
+		r.Decoder = nil
+	}
+	return n, err
+}
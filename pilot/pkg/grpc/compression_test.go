@@ -0,0 +1,70 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/encoding"
+)
+
+func TestCompressors(t *testing.T) {
+	msg := []byte(strings.Repeat("outbound|80||foo.default.svc.cluster.local", 100))
+	for _, name := range []string{GzipCompressor, ZstdCompressor} {
+		t.Run(name, func(t *testing.T) {
+			if err := ValidateCompressor(name); err != nil {
+				t.Fatal(err)
+			}
+			c := encoding.GetCompressor(name)
+			if c == nil {
+				t.Fatalf("compressor %s is not registered", name)
+			}
+			// Run twice to reuse pooled encoders and decoders.
+			for i := 0; i < 2; i++ {
+				var buf bytes.Buffer
+				w, err := c.Compress(&buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := w.Write(msg); err != nil {
+					t.Fatal(err)
+				}
+				if err := w.Close(); err != nil {
+					t.Fatal(err)
+				}
+				if buf.Len() >= len(msg) {
+					t.Errorf("compressed %d bytes to %d", len(msg), buf.Len())
+				}
+				r, err := c.Decompress(&buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, msg) {
+					t.Errorf("decompressed %q, want %q", got, msg)
+				}
+			}
+		})
+	}
+	if err := ValidateCompressor("snappy"); err == nil {
+		t.Error("expected snappy to be rejected")
+	}
+}
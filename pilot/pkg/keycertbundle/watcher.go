@@ -33,6 +33,7 @@ type Watcher struct {
 	initDone  atomic.Bool
 	mutex     sync.Mutex
 	bundle    KeyCertBundle
+	crl       []byte
 	watcherID int32
 	watchers  map[int32]chan struct{}
 }
@@ -123,6 +124,26 @@ func (w *Watcher) SetFromFilesAndNotify(keyFile, certFile, rootCert string) erro
 	return nil
 }
 
+// SetCRLAndNotify sets the CRLs to validate the certificates of the CA bundle with, and notify the watchers.
+func (w *Watcher) SetCRLAndNotify(crl []byte) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.crl = crl
+	for _, ch := range w.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// GetCRL returns the CRLs to validate the certificates of the CA bundle with, or nil if there are none.
+func (w *Watcher) GetCRL() []byte {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.crl
+}
+
 // GetCABundle returns the CABundle.
 func (w *Watcher) GetCABundle() []byte {
 	w.mutex.Lock()
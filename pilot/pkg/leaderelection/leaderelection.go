@@ -50,6 +50,12 @@ const (
 	GatewayDeploymentController = "istio-gateway-deployment-leader"
 	StatusController            = "istio-status-leader"
 	AnalyzeController           = "istio-analyze-leader"
+	// ConfigReplicationController replicates selected config from the config cluster to remote clusters.
+	ConfigReplicationController = "istio-config-replication-leader"
+	// GatewayAddressesController publishes the external addresses of the ingress gateways of all clusters.
+	GatewayAddressesController = "istio-gateway-addresses-leader"
+	// CertExpiryController reports the gateway credential secrets and the workload certificates about to expire.
+	CertExpiryController = "istio-cert-expiry-leader"
 )
 
 // Leader election key prefix for remote istiod managed clusters
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net"
 	"regexp"
 	"sort"
@@ -352,6 +353,10 @@ type WatchedResource struct {
 	// NonceAcked is the last acked message.
 	NonceAcked string
 
+	// VersionSent is the version info of the last sent response. The proxy reports it as the version of the
+	// resources of the response once it accepts them.
+	VersionSent string
+
 	// AlwaysRespond, if true, will ensure that even when a request would otherwise be treated as an
 	// ACK, it will be responded to. This typically happens when a proxy reconnects to another instance of
 	// Istiod. In that case, Envoy expects us to respond to EDS/RDS/SDS requests to finish warming of
@@ -632,6 +637,10 @@ type NodeMetadata struct {
 	// Envoy prometheus port redirecting to admin port prometheus endpoint.
 	EnvoyPrometheusPort int `json:"ENVOY_PROMETHEUS_PORT,omitempty"`
 
+	// DrainHealthCheckPath is the path of the Envoy status port redirecting to the agent drain health check, which
+	// fails once the proxy is draining.
+	DrainHealthCheckPath string `json:"DRAIN_HEALTH_CHECK_PATH,omitempty"`
+
 	// ExitOnZeroActiveConnections terminates Envoy if there are no active connections if set.
 	ExitOnZeroActiveConnections StringBool `json:"EXIT_ON_ZERO_ACTIVE_CONNECTIONS,omitempty"`
 
@@ -1128,6 +1137,37 @@ func (node *Proxy) IsVM() bool {
 	return node.Metadata != nil && node.Metadata.Labels[constants.TestVMLabel] != ""
 }
 
+// BandwidthLimit returns the bandwidth in KiB/s set by a bandwidth limit annotation of the workload, such as
+// EgressBandwidthLimitAnnotation, or 0 if it is not set.
+func (node *Proxy) BandwidthLimit(annotation string) (uint64, error) {
+	if node.Metadata == nil || node.Metadata.Annotations[annotation] == "" {
+		return 0, nil
+	}
+	return ParseBandwidthLimit(node.Metadata.Annotations[annotation])
+}
+
+var bandwidthUnits = map[string]float64{"Kbps": 1e3, "Mbps": 1e6, "Gbps": 1e9}
+
+// ParseBandwidthLimit parses a bandwidth in bits per second with a Kbps, Mbps or Gbps suffix, such as "10Mbps", into
+// KiB/s, the unit of the Envoy bandwidth limit filter.
+func ParseBandwidthLimit(value string) (uint64, error) {
+	for unit, bits := range bandwidthUnits {
+		if !strings.HasSuffix(value, unit) {
+			continue
+		}
+		n, err := strconv.ParseFloat(strings.TrimSuffix(value, unit), 64)
+		if err != nil || math.IsNaN(n) || n <= 0 {
+			return 0, fmt.Errorf("invalid bandwidth %q: must be a positive number", value)
+		}
+		kbps := math.Ceil(n * bits / 8 / 1024)
+		if kbps >= math.MaxUint64 {
+			return 0, fmt.Errorf("invalid bandwidth %q: too large", value)
+		}
+		return uint64(kbps), nil
+	}
+	return 0, fmt.Errorf("invalid bandwidth %q: must end with Kbps, Mbps or Gbps", value)
+}
+
 func (node *Proxy) IsProxylessGrpc() bool {
 	return node.Metadata != nil && node.Metadata.Generator == "grpc"
 }
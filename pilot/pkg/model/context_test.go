@@ -650,3 +650,33 @@ func TestGlobalUnicastIP(t *testing.T) {
 		})
 	}
 }
+
+func TestParseBandwidthLimit(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    uint64
+		wantErr bool
+	}{
+		{value: "10Mbps", want: 1221},
+		{value: "8Kbps", want: 1},
+		{value: "1.5Gbps", want: 183106},
+		{value: "0Mbps", wantErr: true},
+		{value: "-1Mbps", wantErr: true},
+		{value: "10MB", wantErr: true},
+		{value: "Mbps", wantErr: true},
+		{value: "NaNMbps", wantErr: true},
+		{value: "InfMbps", wantErr: true},
+		{value: "1e300Gbps", wantErr: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := model.ParseBandwidthLimit(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"sort"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/util/sets"
+)
+
+const (
+	// DependencyNodeWorkloads is a node of the workloads of a namespace sharing a Sidecar scope.
+	DependencyNodeWorkloads = "workloads"
+	// DependencyNodeService is a node of a service, or of a host routed by a VirtualService.
+	DependencyNodeService = "service"
+
+	// DependencyEdgeReaches is an edge from workloads to a service in their Sidecar scope.
+	DependencyEdgeReaches = "reaches"
+	// DependencyEdgeRoutes is an edge from a host to a destination of the VirtualService routing its requests.
+	DependencyEdgeRoutes = "routes"
+)
+
+// DependencyGraph is the static graph of which workloads can reach which services, implied by the Sidecar scopes,
+// the VirtualServices and the services of the registries, including ServiceEntries. It does not depend on the actual
+// traffic, so it shows the blast radius of a service and the scope a Sidecar would need.
+type DependencyGraph struct {
+	Nodes []DependencyNode `json:"nodes"`
+	Edges []DependencyEdge `json:"edges"`
+}
+
+// DependencyNode is the workloads of a namespace sharing a Sidecar scope, or a service.
+type DependencyNode struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// DependencyEdge is workloads reaching a service, or a VirtualService routing the requests to a host to a destination.
+type DependencyEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+	// Config is the namespace/name of the Sidecar or VirtualService implying the edge, empty for the default scope.
+	Config string `json:"config,omitempty"`
+}
+
+// DependencyGraph returns the dependency graph of the workloads of the namespace, or of all the namespaces with
+// services or Sidecars if empty. The workloads of a namespace are a node per Sidecar with a workload selector, with
+// the id sidecar/<namespace>/<name>, and a node for the other workloads, with the id namespace/<namespace>.
+func (ps *PushContext) DependencyGraph(namespace string) *DependencyGraph {
+	g := &DependencyGraph{Nodes: []DependencyNode{}, Edges: []DependencyEdge{}}
+	nodes := sets.New()
+	addNode := func(n DependencyNode) {
+		if !nodes.Contains(n.ID) {
+			nodes.Insert(n.ID)
+			g.Nodes = append(g.Nodes, n)
+		}
+	}
+	edges := map[DependencyEdge]struct{}{}
+
+	namespaces := sets.New()
+	for _, svc := range ps.GetAllServices() {
+		namespaces.Insert(svc.Attributes.Namespace)
+	}
+	for ns := range ps.sidecarIndex.sidecarsByNamespace {
+		namespaces.Insert(ns)
+	}
+	virtualServices := map[string]config.Config{}
+	for _, ns := range namespaces.SortedList() {
+		if namespace != "" && ns != namespace {
+			continue
+		}
+		var scopes []*SidecarScope
+		for _, sc := range ps.sidecarIndex.sidecarsByNamespace[ns] {
+			if sc.Sidecar.GetWorkloadSelector() != nil {
+				scopes = append(scopes, sc)
+			}
+		}
+		scopes = append(scopes, ps.getSidecarScope(&Proxy{Type: SidecarProxy, ConfigNamespace: ns}, nil))
+		for _, sc := range scopes {
+			source := DependencyNode{ID: "namespace/" + ns, Kind: DependencyNodeWorkloads, Namespace: ns}
+			if sc.Sidecar.GetWorkloadSelector() != nil {
+				source.ID = "sidecar/" + ns + "/" + sc.Name
+			}
+			addNode(source)
+			sidecar := ps.sidecarConfigName(sc)
+			for _, svc := range sc.Services() {
+				addNode(DependencyNode{ID: string(svc.Hostname), Kind: DependencyNodeService, Namespace: svc.Attributes.Namespace})
+				edges[DependencyEdge{From: source.ID, To: string(svc.Hostname), Kind: DependencyEdgeReaches, Config: sidecar}] = struct{}{}
+			}
+			for _, l := range sc.EgressListeners {
+				for _, vs := range l.VirtualServices() {
+					virtualServices[vs.Namespace+"/"+vs.Name] = vs
+				}
+			}
+		}
+	}
+
+	for name, vs := range virtualServices {
+		spec := vs.Spec.(*networking.VirtualService)
+		for _, h := range spec.Hosts {
+			for dest := range virtualServiceDestinations(spec) {
+				if dest == h {
+					continue
+				}
+				// Hosts without a service in the scopes, such as wildcards, have no namespace.
+				addNode(DependencyNode{ID: h, Kind: DependencyNodeService})
+				addNode(DependencyNode{ID: dest, Kind: DependencyNodeService})
+				edges[DependencyEdge{From: h, To: dest, Kind: DependencyEdgeRoutes, Config: name}] = struct{}{}
+			}
+		}
+	}
+	for e := range edges {
+		g.Edges = append(g.Edges, e)
+	}
+
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Config < b.Config
+	})
+	return g
+}
+
+// sidecarConfigName returns the namespace/name of the Sidecar of the scope, which may be the Sidecar of the root
+// namespace, or an empty string for the default scope.
+func (ps *PushContext) sidecarConfigName(sc *SidecarScope) string {
+	if sc.Sidecar == nil {
+		return ""
+	}
+	for _, s := range ps.sidecarIndex.sidecarsByNamespace[sc.Namespace] {
+		if s == sc {
+			return sc.Namespace + "/" + sc.Name
+		}
+	}
+	if root := ps.sidecarIndex.rootConfig; root != nil {
+		return root.Namespace + "/" + root.Name
+	}
+	return sc.Namespace + "/" + sc.Name
+}
@@ -0,0 +1,144 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestDependencyGraph(t *testing.T) {
+	env := NewEnvironment()
+	store := NewFakeStore()
+	env.ConfigStore = &istioConfigStore{ConfigStore: store}
+	service := func(name, namespace string) *Service {
+		return &Service{
+			Hostname:   host.Name(name + "." + namespace + ".svc.cluster.local"),
+			Ports:      allPorts,
+			Attributes: ServiceAttributes{Name: name, Namespace: namespace},
+		}
+	}
+	env.ServiceDiscovery = &localServiceDiscovery{
+		services: []*Service{
+			service("frontend", "web"),
+			service("reviews", "shop"),
+			service("reviews-v2", "shop"),
+			service("ratings", "shop"),
+			{
+				Hostname:   "api.example.com",
+				Ports:      allPorts,
+				Attributes: ServiceAttributes{Name: "api.example.com", Namespace: "shop"},
+			},
+		},
+	}
+	configs := []config.Config{
+		{
+			Meta: config.Meta{
+				GroupVersionKind: collections.IstioNetworkingV1Alpha3Sidecars.Resource().GroupVersionKind(),
+				Name:             "default",
+				Namespace:        "web",
+			},
+			Spec: &networking.Sidecar{
+				Egress: []*networking.IstioEgressListener{{Hosts: []string{"./*", "shop/reviews.shop.svc.cluster.local"}}},
+			},
+		},
+		{
+			Meta: config.Meta{
+				GroupVersionKind: collections.IstioNetworkingV1Alpha3Sidecars.Resource().GroupVersionKind(),
+				Name:             "ratings",
+				Namespace:        "shop",
+			},
+			Spec: &networking.Sidecar{
+				WorkloadSelector: &networking.WorkloadSelector{Labels: map[string]string{"app": "ratings"}},
+				Egress:           []*networking.IstioEgressListener{{Hosts: []string{"shop/api.example.com"}}},
+			},
+		},
+		{
+			Meta: config.Meta{
+				GroupVersionKind: collections.IstioNetworkingV1Alpha3Virtualservices.Resource().GroupVersionKind(),
+				Name:             "reviews",
+				Namespace:        "shop",
+			},
+			Spec: &networking.VirtualService{
+				Hosts: []string{"reviews.shop.svc.cluster.local"},
+				Http: []*networking.HTTPRoute{{
+					Route: []*networking.HTTPRouteDestination{
+						{Destination: &networking.Destination{Host: "reviews.shop.svc.cluster.local"}, Weight: 90},
+						{Destination: &networking.Destination{Host: "reviews-v2.shop.svc.cluster.local"}, Weight: 10},
+					},
+				}},
+			},
+		},
+	}
+	for _, c := range configs {
+		if _, err := store.Create(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	env.Watcher = mesh.NewFixedWatcher(mesh.DefaultMeshConfig())
+	env.Init()
+	ps := NewPushContext()
+	if err := ps.InitContext(env, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	edges := func(g *DependencyGraph) []DependencyEdge {
+		var out []DependencyEdge
+		for _, e := range g.Edges {
+			// Skip the edges of the default scope of shop to the other namespaces, which reaches everything.
+			if e.From == "namespace/shop" && e.To == "frontend.web.svc.cluster.local" {
+				continue
+			}
+			out = append(out, e)
+		}
+		return out
+	}
+	g := ps.DependencyGraph("")
+	assert.Equal(t, g.Nodes, []DependencyNode{
+		{ID: "api.example.com", Kind: DependencyNodeService, Namespace: "shop"},
+		{ID: "frontend.web.svc.cluster.local", Kind: DependencyNodeService, Namespace: "web"},
+		{ID: "namespace/shop", Kind: DependencyNodeWorkloads, Namespace: "shop"},
+		{ID: "namespace/web", Kind: DependencyNodeWorkloads, Namespace: "web"},
+		{ID: "ratings.shop.svc.cluster.local", Kind: DependencyNodeService, Namespace: "shop"},
+		{ID: "reviews-v2.shop.svc.cluster.local", Kind: DependencyNodeService, Namespace: "shop"},
+		{ID: "reviews.shop.svc.cluster.local", Kind: DependencyNodeService, Namespace: "shop"},
+		{ID: "sidecar/shop/ratings", Kind: DependencyNodeWorkloads, Namespace: "shop"},
+	})
+	assert.Equal(t, edges(g), []DependencyEdge{
+		{From: "namespace/shop", To: "api.example.com", Kind: DependencyEdgeReaches},
+		{From: "namespace/shop", To: "ratings.shop.svc.cluster.local", Kind: DependencyEdgeReaches},
+		{From: "namespace/shop", To: "reviews-v2.shop.svc.cluster.local", Kind: DependencyEdgeReaches},
+		{From: "namespace/shop", To: "reviews.shop.svc.cluster.local", Kind: DependencyEdgeReaches},
+		{From: "namespace/web", To: "frontend.web.svc.cluster.local", Kind: DependencyEdgeReaches, Config: "web/default"},
+		// The destinations of the VirtualServices of the scope are imported.
+		{From: "namespace/web", To: "reviews-v2.shop.svc.cluster.local", Kind: DependencyEdgeReaches, Config: "web/default"},
+		{From: "namespace/web", To: "reviews.shop.svc.cluster.local", Kind: DependencyEdgeReaches, Config: "web/default"},
+		{From: "reviews.shop.svc.cluster.local", To: "reviews-v2.shop.svc.cluster.local", Kind: DependencyEdgeRoutes, Config: "shop/reviews"},
+		{From: "sidecar/shop/ratings", To: "api.example.com", Kind: DependencyEdgeReaches, Config: "shop/ratings"},
+	})
+
+	g = ps.DependencyGraph("web")
+	for _, n := range g.Nodes {
+		if n.Kind == DependencyNodeWorkloads && n.Namespace != "web" {
+			t.Errorf("unexpected workloads of another namespace: %v", n)
+		}
+	}
+}
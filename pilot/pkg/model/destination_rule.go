@@ -147,6 +147,34 @@ func (ps *PushContext) inheritDestinationRule(parent, child *ConsolidatedDestRul
 	return out
 }
 
+// applyTrafficPolicyDefaults sets the connection pool and outlier detection of the child's top level traffic policy
+// to the ones of the defaults when the child does not set them.
+func applyTrafficPolicyDefaults(defaults, child *ConsolidatedDestRule) *ConsolidatedDestRule {
+	defaultPolicy := defaults.rule.Spec.(*networking.DestinationRule).TrafficPolicy
+	childPolicy := child.rule.Spec.(*networking.DestinationRule).GetTrafficPolicy()
+	if (childPolicy.GetConnectionPool() != nil || defaultPolicy.GetConnectionPool() == nil) &&
+		(childPolicy.GetOutlierDetection() != nil || defaultPolicy.GetOutlierDetection() == nil) {
+		return child
+	}
+
+	merged := child.rule.DeepCopy()
+	mergedDR := merged.Spec.(*networking.DestinationRule)
+	if mergedDR.TrafficPolicy == nil {
+		mergedDR.TrafficPolicy = &networking.TrafficPolicy{}
+	}
+	if mergedDR.TrafficPolicy.ConnectionPool == nil {
+		mergedDR.TrafficPolicy.ConnectionPool = defaultPolicy.GetConnectionPool().DeepCopy()
+	}
+	if mergedDR.TrafficPolicy.OutlierDetection == nil {
+		mergedDR.TrafficPolicy.OutlierDetection = defaultPolicy.GetOutlierDetection().DeepCopy()
+	}
+	out := &ConsolidatedDestRule{}
+	out.rule = &merged
+	out.from = append(out.from, defaults.from...)
+	out.from = append(out.from, child.from...)
+	return out
+}
+
 func ConvertConsolidatedDestRule(cfg *config.Config) *ConsolidatedDestRule {
 	return &ConsolidatedDestRule{
 		rule: cfg,
@@ -30,6 +30,7 @@ import (
 	"istio.io/istio/pilot/pkg/model/credentials"
 	"istio.io/istio/pilot/pkg/util/protoconv"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/util/protomarshal"
 )
 
@@ -46,12 +47,25 @@ const (
 	WasmResourceVersionEnv = "ISTIO_META_WASM_PLUGIN_RESOURCE_VERSION"
 )
 
+// WasmPluginType is the type of filter a WasmPlugin is inserted as.
+type WasmPluginType int
+
+const (
+	// WasmPluginTypeHTTP plugins are inserted as HTTP filters.
+	WasmPluginTypeHTTP WasmPluginType = iota
+	// WasmPluginTypeNetwork plugins are inserted as network filters of TCP filter chains.
+	WasmPluginTypeNetwork
+	// WasmPluginTypeAny matches plugins of any type.
+	WasmPluginTypeAny
+)
+
 type WasmPluginWrapper struct {
 	*extensions.WasmPlugin
 
 	Name         string
 	Namespace    string
 	ResourceName string
+	Type         WasmPluginType
 
 	WasmExtensionConfig *envoyWasmFilterV3.Wasm
 }
@@ -78,6 +92,17 @@ func convertToWasmPluginWrapper(originPlugin config.Config) *WasmPluginWrapper {
 		})
 	}
 
+	pluginType := WasmPluginTypeHTTP
+	switch t := plugin.Annotations[constants.WasmPluginTypeAnnotation]; strings.ToUpper(t) {
+	case "", "HTTP":
+	case "NETWORK":
+		pluginType = WasmPluginTypeNetwork
+	default:
+		log.Warnf("wasmplugin %v/%v discarded due to invalid %s annotation %q", plugin.Namespace, plugin.Name,
+			constants.WasmPluginTypeAnnotation, t)
+		return nil
+	}
+
 	u, err := url.Parse(wasmPlugin.Url)
 	if err != nil {
 		log.Warnf("wasmplugin %v/%v discarded due to failure to parse URL: %s", plugin.Namespace, plugin.Name, err)
@@ -106,6 +131,7 @@ func convertToWasmPluginWrapper(originPlugin config.Config) *WasmPluginWrapper {
 		Name:                plugin.Name,
 		Namespace:           plugin.Namespace,
 		ResourceName:        plugin.Namespace + "." + plugin.Name,
+		Type:                pluginType,
 		WasmPlugin:          wasmPlugin,
 		WasmExtensionConfig: wasmExtensionConfig,
 	}
@@ -15,15 +15,19 @@
 package model
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model/credentials"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/gateway"
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/schema/gvk"
@@ -96,6 +100,10 @@ type MergedGateway struct {
 	// Note: Secrets that are not referenced by any Gateway, but are in the same namespace as the pod, are explicitly *not*
 	// included. This ensures we don't give permission to unexpected secrets, such as the citadel root key/cert.
 	VerifiedCertificateReferences sets.Set
+
+	// ConnectionRateLimits maps from server to the rate limit of the new connections of the server, set by the
+	// ConnectionRateLimitAnnotation of its gateway.
+	ConnectionRateLimits map[*networking.Server]*ConnectionRateLimit
 }
 
 var (
@@ -140,6 +148,7 @@ func MergeGateways(gateways []gatewayWithInstances, proxy *Proxy, ps *PushContex
 	serversByRouteName := make(map[string][]*networking.Server)
 	tlsServerInfo := make(map[*networking.Server]*TLSServerInfo)
 	gatewayNameForServer := make(map[*networking.Server]string)
+	connectionRateLimits := make(map[*networking.Server]*ConnectionRateLimit)
 	verifiedCertificateReferences := sets.New()
 	http3AdvertisingRoutes := sets.New()
 	tlsHostsByPort := map[uint32]map[string]string{} // port -> host/bind map
@@ -151,6 +160,10 @@ func MergeGateways(gateways []gatewayWithInstances, proxy *Proxy, ps *PushContex
 		gatewayName := gatewayConfig.Namespace + "/" + gatewayConfig.Name // Format: %s/%s
 		gatewayCfg := gatewayConfig.Spec.(*networking.Gateway)
 		log.Debugf("MergeGateways: merging gateway %q :\n%v", gatewayName, gatewayCfg)
+		rateLimits, err := ParseConnectionRateLimits(gatewayConfig)
+		if err != nil {
+			log.Warnf("MergeGateways: ignoring the connection rate limits of gateway %q: %v", gatewayName, err)
+		}
 		snames := sets.Set{}
 		for _, s := range gatewayCfg.Servers {
 			if len(s.Name) > 0 {
@@ -169,6 +182,9 @@ func MergeGateways(gateways []gatewayWithInstances, proxy *Proxy, ps *PushContex
 			}
 			sanitizeServerHostNamespace(s, gatewayConfig.Namespace)
 			gatewayNameForServer[s] = gatewayName
+			if limit := rateLimits.ForServer(s); limit != nil {
+				connectionRateLimits[s] = limit
+			}
 			log.Debugf("MergeGateways: gateway %q processing server %s :%v", gatewayName, s.Name, s.Hosts)
 
 			cn := s.GetTls().GetCredentialName()
@@ -336,7 +352,81 @@ func MergeGateways(gateways []gatewayWithInstances, proxy *Proxy, ps *PushContex
 		ContainsAutoPassthroughGateways: autoPassthrough,
 		PortMap:                         getTargetPortMap(serversByRouteName),
 		VerifiedCertificateReferences:   verifiedCertificateReferences,
+		ConnectionRateLimits:            connectionRateLimits,
+	}
+}
+
+// minConnectionRateLimitFillInterval is the shortest fill interval of the token buckets Envoy accepts.
+const minConnectionRateLimitFillInterval = 50 * time.Millisecond
+
+// ConnectionRateLimit is the rate limit of the new connections of a gateway server: the token bucket of the limit
+// holds up to Burst connections, and is refilled with Connections connections every FillInterval. Connections over
+// the limit are closed as soon as they are accepted.
+type ConnectionRateLimit struct {
+	Connections  uint32
+	FillInterval time.Duration
+	Burst        uint32
+}
+
+// PerSecond returns the rate of new connections of the limit, to compare limits of different fill intervals.
+func (l *ConnectionRateLimit) PerSecond() float64 {
+	return float64(l.Connections) / l.FillInterval.Seconds()
+}
+
+// ConnectionRateLimits are the connection rate limits of the servers of a gateway, by server name. The limit of "*"
+// applies to the servers without a limit of their own.
+type ConnectionRateLimits map[string]*ConnectionRateLimit
+
+// ForServer returns the connection rate limit of the server, or nil if its connections are not limited.
+func (l ConnectionRateLimits) ForServer(s *networking.Server) *ConnectionRateLimit {
+	if limit, f := l[s.Name]; f && s.Name != "" {
+		return limit
+	}
+	return l["*"]
+}
+
+// ParseConnectionRateLimits returns the connection rate limits of the servers of the gateway, set by its
+// ConnectionRateLimitAnnotation, or nil if it has none.
+func ParseConnectionRateLimits(cfg config.Config) (ConnectionRateLimits, error) {
+	value, f := cfg.Annotations[constants.ConnectionRateLimitAnnotation]
+	if !f {
+		return nil, nil
+	}
+	var limits map[string]struct {
+		Connections  uint32 `json:"connections"`
+		FillInterval string `json:"fillInterval,omitempty"`
+		Burst        uint32 `json:"burst,omitempty"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&limits); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %v", constants.ConnectionRateLimitAnnotation, err)
+	}
+	out := ConnectionRateLimits{}
+	for server, l := range limits {
+		if l.Connections == 0 {
+			return nil, fmt.Errorf("invalid %s annotation: connections of server %q must be positive",
+				constants.ConnectionRateLimitAnnotation, server)
+		}
+		limit := &ConnectionRateLimit{Connections: l.Connections, FillInterval: time.Second, Burst: l.Burst}
+		if l.FillInterval != "" {
+			d, err := time.ParseDuration(l.FillInterval)
+			if err != nil || d < minConnectionRateLimitFillInterval {
+				return nil, fmt.Errorf("invalid %s annotation: fillInterval %q of server %q must be a duration of at least %v",
+					constants.ConnectionRateLimitAnnotation, l.FillInterval, server, minConnectionRateLimitFillInterval)
+			}
+			limit.FillInterval = d
+		}
+		if limit.Burst == 0 {
+			limit.Burst = limit.Connections
+		}
+		if limit.Burst < limit.Connections {
+			return nil, fmt.Errorf("invalid %s annotation: burst of server %q must be at least its connections",
+				constants.ConnectionRateLimitAnnotation, server)
+		}
+		out[server] = limit
 	}
+	return out, nil
 }
 
 func udpSupportedPort(number uint32, instances []*ServiceInstance) bool {
@@ -16,10 +16,13 @@ package model
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 )
 
 // nolint lll
@@ -231,3 +234,83 @@ func TestParseGatewayRDSRouteName(t *testing.T) {
 		})
 	}
 }
+
+func TestParseConnectionRateLimits(t *testing.T) {
+	gw := func(annotation string) config.Config {
+		return config.Config{
+			Meta: config.Meta{Annotations: map[string]string{constants.ConnectionRateLimitAnnotation: annotation}},
+			Spec: &networking.Gateway{},
+		}
+	}
+	cases := []struct {
+		name    string
+		cfg     config.Config
+		want    ConnectionRateLimits
+		wantErr bool
+		// servers maps server names to the number of connections of their limit, 0 if they have none.
+		servers map[string]uint32
+	}{
+		{
+			name:    "no annotation",
+			cfg:     config.Config{Spec: &networking.Gateway{}},
+			servers: map[string]uint32{"https": 0, "": 0},
+		},
+		{
+			name: "defaults",
+			cfg:  gw(`{"https": {"connections": 100}}`),
+			want: ConnectionRateLimits{
+				"https": {Connections: 100, FillInterval: time.Second, Burst: 100},
+			},
+			servers: map[string]uint32{"https": 100, "http": 0, "": 0},
+		},
+		{
+			name: "wildcard",
+			cfg:  gw(`{"*": {"connections": 1000}, "admin": {"connections": 10, "fillInterval": "10s", "burst": 20}}`),
+			want: ConnectionRateLimits{
+				"*":     {Connections: 1000, FillInterval: time.Second, Burst: 1000},
+				"admin": {Connections: 10, FillInterval: 10 * time.Second, Burst: 20},
+			},
+			servers: map[string]uint32{"admin": 10, "https": 1000, "": 1000},
+		},
+		{
+			name:    "no connections",
+			cfg:     gw(`{"https": {"fillInterval": "1s"}}`),
+			wantErr: true,
+		},
+		{
+			name:    "short fill interval",
+			cfg:     gw(`{"https": {"connections": 1, "fillInterval": "10ms"}}`),
+			wantErr: true,
+		},
+		{
+			name:    "burst under connections",
+			cfg:     gw(`{"https": {"connections": 10, "burst": 5}}`),
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			cfg:     gw(`{"https": {"rate": 10}}`),
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseConnectionRateLimits(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for name, connections := range tt.servers {
+				var gotConnections uint32
+				if limit := got.ForServer(&networking.Server{Name: name}); limit != nil {
+					gotConnections = limit.Connections
+				}
+				if gotConnections != connections {
+					t.Errorf("server %q: got %d connections, want %d", name, gotConnections, connections)
+				}
+			}
+		})
+	}
+}
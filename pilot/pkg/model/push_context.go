@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"go.uber.org/atomic"
+	"google.golang.org/protobuf/proto"
 	"k8s.io/apimachinery/pkg/types"
 
 	extensions "istio.io/api/extensions/v1alpha1"
@@ -39,6 +40,7 @@ import (
 	"istio.io/istio/pkg/config/schema/kind"
 	"istio.io/istio/pkg/config/visibility"
 	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/pkg/util/protomarshal"
 	"istio.io/istio/pkg/util/sets"
 	"istio.io/pkg/monitoring"
 )
@@ -116,6 +118,21 @@ type destinationRuleIndex struct {
 	rootNamespaceLocal  *consolidatedDestRules
 	// mesh/namespace dest rules to be inherited
 	inheritedByNamespace map[string]*ConsolidatedDestRule
+	// mesh config of proxies in a namespace with a default retry policy set by the mesh/namespace dest rule
+	meshByNamespace map[string]namespaceMeshConfig
+	// connection pool and outlier detection defaults of the mesh/namespace dest rules, applied to the dest rules
+	// of the namespace when they are not inherited
+	defaultsByNamespace map[string]*ConsolidatedDestRule
+	// dest rules with the defaults of a namespace applied, by namespace of the defaults and dest rule. The dest
+	// rules that already set all the defaults are not included.
+	defaultedByNamespace map[string]map[*ConsolidatedDestRule]*ConsolidatedDestRule
+}
+
+// namespaceMeshConfig is the mesh config with the defaults of a mesh/namespace dest rule applied.
+type namespaceMeshConfig struct {
+	mesh *meshconfig.MeshConfig
+	// source is the dest rule the defaults are taken from
+	source *ConsolidatedDestRule
 }
 
 func newDestinationRuleIndex() destinationRuleIndex {
@@ -123,6 +140,9 @@ func newDestinationRuleIndex() destinationRuleIndex {
 		namespaceLocal:       map[string]*consolidatedDestRules{},
 		exportedByNamespace:  map[string]*consolidatedDestRules{},
 		inheritedByNamespace: map[string]*ConsolidatedDestRule{},
+		meshByNamespace:      map[string]namespaceMeshConfig{},
+		defaultsByNamespace:  map[string]*ConsolidatedDestRule{},
+		defaultedByNamespace: map[string]map[*ConsolidatedDestRule]*ConsolidatedDestRule{},
 	}
 }
 
@@ -997,8 +1017,35 @@ func (ps *PushContext) getSidecarScope(proxy *Proxy, workloadLabels labels.Insta
 	return computed
 }
 
-// destinationRule returns a destination rule for a service name in a given namespace.
+// destinationRule returns a destination rule for a service name in a given namespace, with the connection pool and
+// outlier detection defaults of the mesh/namespace dest rule applied when they are not inherited.
 func (ps *PushContext) destinationRule(proxyNameSpace string, service *Service) []*ConsolidatedDestRule {
+	rules := ps.lookupDestinationRule(proxyNameSpace, service)
+	defaultsNamespace := proxyNameSpace
+	defaults := ps.destinationRuleIndex.defaultsByNamespace[defaultsNamespace]
+	if defaults == nil {
+		defaultsNamespace = ps.Mesh.GetRootNamespace()
+		defaults = ps.destinationRuleIndex.defaultsByNamespace[defaultsNamespace]
+	}
+	if service == nil || defaults == nil {
+		return rules
+	}
+	if len(rules) == 0 {
+		return []*ConsolidatedDestRule{defaults}
+	}
+	// the rules with the defaults applied were computed in SetDestinationRules
+	defaulted := ps.destinationRuleIndex.defaultedByNamespace[defaultsNamespace]
+	out := make([]*ConsolidatedDestRule, 0, len(rules))
+	for _, r := range rules {
+		if d, f := defaulted[r]; f {
+			r = d
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func (ps *PushContext) lookupDestinationRule(proxyNameSpace string, service *Service) []*ConsolidatedDestRule {
 	if service == nil {
 		return nil
 	}
@@ -1488,6 +1535,8 @@ func (ps *PushContext) initVirtualServices(env *Environment) error {
 	// registry DNS names in the VS.  This should cut down processing in
 	// the RDS code. See separateVSHostsAndServices in route/route.go
 	sortConfigByCreationTime(vservices)
+	// The routes of the VirtualServices bound to the same host are merged in this order.
+	sortVirtualServicesByPriority(vservices)
 
 	// convert all shortnames in virtual services into FQDNs
 	for _, r := range vservices {
@@ -1695,11 +1744,12 @@ func (ps *PushContext) SetDestinationRules(configs []config.Config) {
 	exportedDestRulesByNamespace := make(map[string]*consolidatedDestRules)
 	rootNamespaceLocalDestRules := newConsolidatedDestRules()
 	inheritedConfigs := make(map[string]*ConsolidatedDestRule)
+	defaultRetries := make(map[string]*config.Config)
 
 	for i := range configs {
 		rule := configs[i].Spec.(*networking.DestinationRule)
 
-		if features.EnableDestinationRuleInheritance && rule.Host == "" {
+		if rule.Host == "" && (features.EnableDestinationRuleInheritance || features.EnableNamespaceTrafficPolicyDefaults) {
 			if t, ok := inheritedConfigs[configs[i].Namespace]; ok {
 				log.Warnf("Namespace/mesh-level DestinationRule is already defined for %q at time %v."+
 					" Ignore %q which was created at time %v",
@@ -1707,6 +1757,13 @@ func (ps *PushContext) SetDestinationRules(configs []config.Config) {
 				continue
 			}
 			inheritedConfigs[configs[i].Namespace] = ConvertConsolidatedDestRule(&configs[i])
+			if _, f := configs[i].Annotations[constants.DefaultHTTPRetryPolicyAnnotation]; f {
+				defaultRetries[configs[i].Namespace] = &configs[i]
+			}
+			if !features.EnableDestinationRuleInheritance {
+				// only the defaults of the rule apply, it does not match any host by itself
+				continue
+			}
 		}
 
 		rule.Host = string(ResolveShortnameToFQDN(rule.Host, configs[i].Meta))
@@ -1779,7 +1836,126 @@ func (ps *PushContext) SetDestinationRules(configs []config.Config) {
 	ps.destinationRuleIndex.namespaceLocal = namespaceLocalDestRules
 	ps.destinationRuleIndex.exportedByNamespace = exportedDestRulesByNamespace
 	ps.destinationRuleIndex.rootNamespaceLocal = rootNamespaceLocalDestRules
-	ps.destinationRuleIndex.inheritedByNamespace = inheritedConfigs
+	ps.destinationRuleIndex.meshByNamespace = ps.meshConfigsWithDefaultRetries(defaultRetries)
+	if features.EnableDestinationRuleInheritance {
+		ps.destinationRuleIndex.inheritedByNamespace = inheritedConfigs
+		ps.destinationRuleIndex.defaultsByNamespace = map[string]*ConsolidatedDestRule{}
+		ps.destinationRuleIndex.defaultedByNamespace = map[string]map[*ConsolidatedDestRule]*ConsolidatedDestRule{}
+	} else {
+		ps.destinationRuleIndex.inheritedByNamespace = map[string]*ConsolidatedDestRule{}
+		ps.destinationRuleIndex.defaultsByNamespace = ps.trafficPolicyDefaults(inheritedConfigs)
+		ps.destinationRuleIndex.defaultedByNamespace = ps.applyTrafficPolicyDefaults()
+	}
+}
+
+// applyTrafficPolicyDefaults returns the dest rules with the defaults of a namespace applied, for every namespace
+// with defaults, so that looking up a dest rule does not copy it. The dest rules local to a namespace are only
+// looked up by its proxies, so only get its defaults, while the exported ones may get the defaults of any namespace.
+func (ps *PushContext) applyTrafficPolicyDefaults() map[string]map[*ConsolidatedDestRule]*ConsolidatedDestRule {
+	index := &ps.destinationRuleIndex
+	out := make(map[string]map[*ConsolidatedDestRule]*ConsolidatedDestRule, len(index.defaultsByNamespace))
+	if len(index.defaultsByNamespace) == 0 {
+		return out
+	}
+	apply := func(defaultsNamespace string, rules *consolidatedDestRules) {
+		if rules == nil {
+			return
+		}
+		defaults := index.defaultsByNamespace[defaultsNamespace]
+		defaulted := out[defaultsNamespace]
+		if defaulted == nil {
+			defaulted = map[*ConsolidatedDestRule]*ConsolidatedDestRule{}
+			out[defaultsNamespace] = defaulted
+		}
+		for _, hostRules := range rules.destRules {
+			for _, r := range hostRules {
+				if _, f := defaulted[r]; f {
+					continue
+				}
+				if d := applyTrafficPolicyDefaults(defaults, r); d != r {
+					defaulted[r] = d
+				}
+			}
+		}
+	}
+	rootNamespace := ps.Mesh.GetRootNamespace()
+	for ns, rules := range index.namespaceLocal {
+		if _, f := index.defaultsByNamespace[ns]; f {
+			apply(ns, rules)
+		} else if _, f := index.defaultsByNamespace[rootNamespace]; f {
+			apply(rootNamespace, rules)
+		}
+	}
+	if _, f := index.defaultsByNamespace[rootNamespace]; f {
+		apply(rootNamespace, index.rootNamespaceLocal)
+	}
+	for ns := range index.defaultsByNamespace {
+		for _, rules := range index.exportedByNamespace {
+			apply(ns, rules)
+		}
+	}
+	return out
+}
+
+// trafficPolicyDefaults returns the connection pool and outlier detection defaults of the mesh/namespace dest rules.
+// The defaults of a namespace fall back to the ones of the root namespace.
+func (ps *PushContext) trafficPolicyDefaults(rules map[string]*ConsolidatedDestRule) map[string]*ConsolidatedDestRule {
+	out := make(map[string]*ConsolidatedDestRule, len(rules))
+	for ns, r := range rules {
+		policy := r.rule.Spec.(*networking.DestinationRule).GetTrafficPolicy()
+		if policy.GetConnectionPool() == nil && policy.GetOutlierDetection() == nil {
+			continue
+		}
+		cfg := r.rule.DeepCopy()
+		cfg.Spec = &networking.DestinationRule{
+			TrafficPolicy: &networking.TrafficPolicy{
+				ConnectionPool:   policy.ConnectionPool,
+				OutlierDetection: policy.OutlierDetection,
+			},
+		}
+		out[ns] = ConvertConsolidatedDestRule(&cfg)
+	}
+	if root := out[ps.Mesh.GetRootNamespace()]; root != nil {
+		for ns, r := range out {
+			out[ns] = applyTrafficPolicyDefaults(root, r)
+		}
+	}
+	return out
+}
+
+// meshConfigsWithDefaultRetries returns, for every namespace whose mesh/namespace dest rule sets a default retry
+// policy, a copy of the mesh config using that policy.
+func (ps *PushContext) meshConfigsWithDefaultRetries(rules map[string]*config.Config) map[string]namespaceMeshConfig {
+	out := make(map[string]namespaceMeshConfig, len(rules))
+	if ps.Mesh == nil {
+		return out
+	}
+	for ns, cfg := range rules {
+		retries := &networking.HTTPRetry{}
+		if err := protomarshal.ApplyJSONStrict(cfg.Annotations[constants.DefaultHTTPRetryPolicyAnnotation], retries); err != nil {
+			log.Warnf("Ignoring invalid %s annotation of DestinationRule %s/%s: %v",
+				constants.DefaultHTTPRetryPolicyAnnotation, cfg.Namespace, cfg.Name, err)
+			continue
+		}
+		mc := proto.Clone(ps.Mesh).(*meshconfig.MeshConfig)
+		mc.DefaultHttpRetryPolicy = retries
+		out[ns] = namespaceMeshConfig{mesh: mc, source: ConvertConsolidatedDestRule(cfg)}
+	}
+	return out
+}
+
+// MeshConfigForNamespace returns the mesh config to use when generating routes for proxies in the namespace.
+// It differs from Mesh only when a mesh/namespace level DestinationRule sets a default retry policy, in which
+// case that DestinationRule is returned as well so callers can track it as a dependency. The policy of the
+// root namespace applies to all namespaces without their own.
+func (ps *PushContext) MeshConfigForNamespace(namespace string) (*meshconfig.MeshConfig, *ConsolidatedDestRule) {
+	if nm, f := ps.destinationRuleIndex.meshByNamespace[namespace]; f {
+		return nm.mesh, nm.source
+	}
+	if nm, f := ps.destinationRuleIndex.meshByNamespace[ps.Mesh.GetRootNamespace()]; f {
+		return nm.mesh, nm.source
+	}
+	return ps.Mesh, nil
 }
 
 func (ps *PushContext) initAuthorizationPolicies(env *Environment) error {
@@ -1828,6 +2004,11 @@ func (ps *PushContext) initWasmPlugins(env *Environment) error {
 
 // WasmPlugins return the WasmPluginWrappers of a proxy
 func (ps *PushContext) WasmPlugins(proxy *Proxy) map[extensions.PluginPhase][]*WasmPluginWrapper {
+	return ps.WasmPluginsByType(proxy, WasmPluginTypeAny)
+}
+
+// WasmPluginsByType return the WasmPluginWrappers of a proxy of the given type
+func (ps *PushContext) WasmPluginsByType(proxy *Proxy, pluginType WasmPluginType) map[extensions.PluginPhase][]*WasmPluginWrapper {
 	if proxy == nil {
 		return nil
 	}
@@ -1838,6 +2019,9 @@ func (ps *PushContext) WasmPlugins(proxy *Proxy) map[extensions.PluginPhase][]*W
 		// if there is no workload selector, the config applies to all workloads
 		// if there is a workload selector, check for matching workload labels
 		for _, plugin := range ps.wasmPluginsByNamespace[ps.Mesh.RootNamespace] {
+			if pluginType != WasmPluginTypeAny && plugin.Type != pluginType {
+				continue
+			}
 			if plugin.Selector == nil || labels.Instance(plugin.Selector.MatchLabels).SubsetOf(proxy.Metadata.Labels) {
 				matchedPlugins[plugin.Phase] = append(matchedPlugins[plugin.Phase], plugin)
 			}
@@ -1847,6 +2031,9 @@ func (ps *PushContext) WasmPlugins(proxy *Proxy) map[extensions.PluginPhase][]*W
 	// To prevent duplicate extensions in case root namespace equals proxy's namespace
 	if proxy.ConfigNamespace != ps.Mesh.RootNamespace {
 		for _, plugin := range ps.wasmPluginsByNamespace[proxy.ConfigNamespace] {
+			if pluginType != WasmPluginTypeAny && plugin.Type != pluginType {
+				continue
+			}
 			if plugin.Selector == nil || labels.Instance(plugin.Selector.MatchLabels).SubsetOf(proxy.Metadata.Labels) {
 				matchedPlugins[plugin.Phase] = append(matchedPlugins[plugin.Phase], plugin)
 			}
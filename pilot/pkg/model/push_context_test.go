@@ -541,11 +541,30 @@ func TestWasmPlugins(t *testing.T) {
 				Priority: &wrappers.Int64Value{Value: 1000},
 			},
 		},
+		"network-stats": {
+			Meta: config.Meta{
+				Name: "network-stats", Namespace: "testns-3", GroupVersionKind: gvk.WasmPlugin,
+				Annotations: map[string]string{constants.WasmPluginTypeAnnotation: "NETWORK"},
+			},
+			Spec: &extensions.WasmPlugin{
+				Phase: extensions.PluginPhase_STATS,
+			},
+		},
+		"invalid-plugin-type": {
+			Meta: config.Meta{
+				Name: "invalid-plugin-type", Namespace: "testns-3", GroupVersionKind: gvk.WasmPlugin,
+				Annotations: map[string]string{constants.WasmPluginTypeAnnotation: "UDP"},
+			},
+			Spec: &extensions.WasmPlugin{
+				Phase: extensions.PluginPhase_STATS,
+			},
+		},
 	}
 
 	testCases := []struct {
 		name               string
 		node               *Proxy
+		pluginType         WasmPluginType
 		expectedExtensions map[extensions.PluginPhase][]*WasmPluginWrapper
 	}{
 		{
@@ -615,6 +634,28 @@ func TestWasmPlugins(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "testns-3-http",
+			node: &Proxy{
+				ConfigNamespace: "testns-3",
+				Metadata:        &NodeMetadata{},
+			},
+			pluginType:         WasmPluginTypeHTTP,
+			expectedExtensions: map[extensions.PluginPhase][]*WasmPluginWrapper{},
+		},
+		{
+			name: "testns-3-network",
+			node: &Proxy{
+				ConfigNamespace: "testns-3",
+				Metadata:        &NodeMetadata{},
+			},
+			pluginType: WasmPluginTypeNetwork,
+			expectedExtensions: map[extensions.PluginPhase][]*WasmPluginWrapper{
+				extensions.PluginPhase_STATS: {
+					convertToWasmPluginWrapper(wasmPlugins["network-stats"]),
+				},
+			},
+		},
 	}
 
 	for _, config := range wasmPlugins {
@@ -634,7 +675,7 @@ func TestWasmPlugins(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := pc.WasmPlugins(tc.node)
+			result := pc.WasmPluginsByType(tc.node, tc.pluginType)
 			if !reflect.DeepEqual(tc.expectedExtensions, result) {
 				t.Errorf("WasmPlugins did not match expectations\n\ngot: %v\n\nexpected: %v", result, tc.expectedExtensions)
 			}
@@ -1442,6 +1483,174 @@ func TestSetDestinationRuleInheritance(t *testing.T) {
 	}
 }
 
+func TestMeshConfigForNamespace(t *testing.T) {
+	test.SetBoolForTest(t, &features.EnableNamespaceTrafficPolicyDefaults, true)
+	ps := NewPushContext()
+	ps.Mesh = &meshconfig.MeshConfig{
+		RootNamespace:          "istio-system",
+		DefaultHttpRetryPolicy: &networking.HTTPRetry{Attempts: 2},
+	}
+	rule := func(name, ns, retries string) config.Config {
+		return config.Config{
+			Meta: config.Meta{
+				Name:        name,
+				Namespace:   ns,
+				Annotations: map[string]string{constants.DefaultHTTPRetryPolicyAnnotation: retries},
+			},
+			Spec: &networking.DestinationRule{},
+		}
+	}
+	ps.SetDestinationRules([]config.Config{
+		rule("meshRule", "istio-system", `{"attempts": 5, "retryOn": "5xx"}`),
+		rule("nsRule", "test", `{"attempts": 1}`),
+		rule("invalidRule", "invalid", `{"attempts": "many"}`),
+	})
+
+	testCases := []struct {
+		name           string
+		proxyNs        string
+		expectedPolicy *networking.HTTPRetry
+		expectedSource string
+	}{
+		{
+			name:           "namespace dest rule",
+			proxyNs:        "test",
+			expectedPolicy: &networking.HTTPRetry{Attempts: 1},
+			expectedSource: "nsRule",
+		},
+		{
+			name:           "mesh dest rule",
+			proxyNs:        "other",
+			expectedPolicy: &networking.HTTPRetry{Attempts: 5, RetryOn: "5xx"},
+			expectedSource: "meshRule",
+		},
+		{
+			name:           "invalid annotation falls back to mesh dest rule",
+			proxyNs:        "invalid",
+			expectedPolicy: &networking.HTTPRetry{Attempts: 5, RetryOn: "5xx"},
+			expectedSource: "meshRule",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			mesh, source := ps.MeshConfigForNamespace(tt.proxyNs)
+			assert.Equal(t, mesh.DefaultHttpRetryPolicy, tt.expectedPolicy)
+			assert.Equal(t, mesh.RootNamespace, "istio-system")
+			if source == nil || source.rule.Name != tt.expectedSource {
+				t.Fatalf("expected source %v, got %v", tt.expectedSource, source)
+			}
+		})
+	}
+	// The mesh config itself is left untouched.
+	assert.Equal(t, ps.Mesh.DefaultHttpRetryPolicy, &networking.HTTPRetry{Attempts: 2})
+}
+
+func TestDestinationRuleNamespaceDefaults(t *testing.T) {
+	test.SetBoolForTest(t, &features.EnableDestinationRuleInheritance, false)
+	test.SetBoolForTest(t, &features.EnableNamespaceTrafficPolicyDefaults, true)
+	ps := NewPushContext()
+	ps.Mesh = &meshconfig.MeshConfig{RootNamespace: "istio-system"}
+	meshPool := &networking.ConnectionPoolSettings{
+		Tcp: &networking.ConnectionPoolSettings_TCPSettings{MaxConnections: 111},
+	}
+	meshOutlier := &networking.OutlierDetection{Consecutive_5XxErrors: &wrappers.UInt32Value{Value: 1}}
+	nsOutlier := &networking.OutlierDetection{Consecutive_5XxErrors: &wrappers.UInt32Value{Value: 2}}
+	svcPool := &networking.ConnectionPoolSettings{
+		Http: &networking.ConnectionPoolSettings_HTTPSettings{MaxRetries: 3},
+	}
+	rule := func(name, ns string, spec *networking.DestinationRule) config.Config {
+		return config.Config{Meta: config.Meta{Name: name, Namespace: ns}, Spec: spec}
+	}
+	configs := func() []config.Config {
+		return []config.Config{
+			rule("meshRule", "istio-system", &networking.DestinationRule{
+				TrafficPolicy: &networking.TrafficPolicy{ConnectionPool: meshPool, OutlierDetection: meshOutlier},
+			}),
+			rule("nsRule", "test", &networking.DestinationRule{
+				TrafficPolicy: &networking.TrafficPolicy{OutlierDetection: nsOutlier},
+			}),
+			rule("svcRule", "test", &networking.DestinationRule{
+				Host:          "httpbin.org",
+				TrafficPolicy: &networking.TrafficPolicy{ConnectionPool: svcPool},
+			}),
+		}
+	}
+	ps.SetDestinationRules(configs())
+
+	testCases := []struct {
+		name               string
+		proxyNs            string
+		serviceHostname    string
+		expectedSourceRule []types.NamespacedName
+		expectedPolicy     *networking.TrafficPolicy
+	}{
+		{
+			name:            "dest rule without defaults takes the namespace ones",
+			proxyNs:         "test",
+			serviceHostname: "httpbin.org",
+			expectedSourceRule: []types.NamespacedName{
+				{Namespace: "istio-system", Name: "meshRule"},
+				{Namespace: "test", Name: "nsRule"},
+				{Namespace: "test", Name: "svcRule"},
+			},
+			expectedPolicy: &networking.TrafficPolicy{ConnectionPool: svcPool, OutlierDetection: nsOutlier},
+		},
+		{
+			name:            "no dest rule takes the namespace defaults",
+			proxyNs:         "test",
+			serviceHostname: "unknown.host",
+			expectedSourceRule: []types.NamespacedName{
+				{Namespace: "istio-system", Name: "meshRule"},
+				{Namespace: "test", Name: "nsRule"},
+			},
+			expectedPolicy: &networking.TrafficPolicy{ConnectionPool: meshPool, OutlierDetection: nsOutlier},
+		},
+		{
+			name:               "no namespace rule takes the mesh defaults",
+			proxyNs:            "other",
+			serviceHostname:    "unknown.host",
+			expectedSourceRule: []types.NamespacedName{{Namespace: "istio-system", Name: "meshRule"}},
+			expectedPolicy:     &networking.TrafficPolicy{ConnectionPool: meshPool, OutlierDetection: meshOutlier},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			mergedConfigList := ps.destinationRule(tt.proxyNs,
+				&Service{
+					Hostname: host.Name(tt.serviceHostname),
+					Attributes: ServiceAttributes{
+						Namespace: "test",
+					},
+				})
+			if len(mergedConfigList) != 1 {
+				t.Fatalf("expected one dest rule, got %v", mergedConfigList)
+			}
+			assert.Equal(t, mergedConfigList[0].from, tt.expectedSourceRule)
+			assert.Equal(t, mergedConfigList[0].rule.Spec.(*networking.DestinationRule).TrafficPolicy, tt.expectedPolicy)
+		})
+	}
+
+	// The defaults are applied once, in SetDestinationRules, not on every lookup.
+	svc := &Service{Hostname: "httpbin.org", Attributes: ServiceAttributes{Namespace: "test"}}
+	if ps.destinationRule("test", svc)[0] != ps.destinationRule("test", svc)[0] {
+		t.Fatalf("expected the dest rule with the defaults applied to be computed once")
+	}
+
+	// Without the flag nor inheritance, the mesh/namespace dest rules set no defaults.
+	test.SetBoolForTest(t, &features.EnableNamespaceTrafficPolicyDefaults, false)
+	ps = NewPushContext()
+	ps.Mesh = &meshconfig.MeshConfig{RootNamespace: "istio-system"}
+	ps.SetDestinationRules(configs())
+	if got := ps.destinationRule("other", &Service{Hostname: "unknown.host"}); got != nil {
+		t.Fatalf("expected no dest rule without namespace defaults, got %v", got)
+	}
+	got := ps.destinationRule("test", svc)
+	if len(got) != 1 {
+		t.Fatalf("expected one dest rule, got %v", got)
+	}
+	assert.Equal(t, got[0].rule.Spec.(*networking.DestinationRule).TrafficPolicy, &networking.TrafficPolicy{ConnectionPool: svcPool})
+}
+
 func TestSetDestinationRuleWithWorkloadSelector(t *testing.T) {
 	ps := NewPushContext()
 	ps.Mesh = &meshconfig.MeshConfig{RootNamespace: "istio-system"}
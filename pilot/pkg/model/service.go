@@ -24,6 +24,8 @@ package model
 
 import (
 	"fmt"
+	"math"
+	"net"
 	"strconv"
 	"strings"
 	"time"
@@ -38,6 +40,7 @@ import (
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/labels"
 	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/config/validation"
 	"istio.io/istio/pkg/config/visibility"
 	"istio.io/istio/pkg/network"
 )
@@ -295,6 +298,26 @@ func (instance *WorkloadInstance) DeepCopy() *WorkloadInstance {
 	}
 }
 
+// ServiceAccountWorkload is a workload instance running with a service account identity, listed by the registries
+// implementing ServiceAccountWorkloadLister to debug the identity based policies.
+type ServiceAccountWorkload struct {
+	// ServiceAccount is the identity of the workload, such as spiffe://cluster.local/ns/default/sa/default.
+	ServiceAccount string `json:"serviceAccount"`
+	// Kind is Pod or WorkloadEntry.
+	Kind      string     `json:"kind"`
+	Name      string     `json:"name"`
+	Namespace string     `json:"namespace"`
+	Address   string     `json:"address"`
+	Network   network.ID `json:"network,omitempty"`
+	Cluster   cluster.ID `json:"cluster,omitempty"`
+}
+
+// ServiceAccountWorkloadLister is implemented by the registries which can list their workload instances with their
+// service account identity.
+type ServiceAccountWorkloadLister interface {
+	ServiceAccountWorkloads() []ServiceAccountWorkload
+}
+
 // WorkloadInstancesEqual is a custom comparison of workload instances based on the fields that we need
 // i.e. excluding the ports. Returns true if equal, false otherwise.
 func WorkloadInstancesEqual(first, second *WorkloadInstance) bool {
@@ -545,6 +568,14 @@ type ServiceAttributes struct {
 	// Applicable to both Kubernetes and ServiceEntries.
 	LabelSelectors map[string]string
 
+	// DNSTTL is the TTL of the records the sidecar DNS proxy returns for the service, set by the
+	// networking.istio.io/dnsTTL annotation. The proxy uses its default TTL when it is zero.
+	DNSTTL time.Duration
+
+	// TCPIdleTimeouts are the idle timeouts of the TCP proxies for the ports of the service, set by the
+	// networking.istio.io/tcpIdleTimeout annotation. Port 0 holds the timeout of the ports not listed.
+	TCPIdleTimeouts map[int]time.Duration
+
 	// For Kubernetes platform
 
 	// ClusterExternalAddresses is a mapping between a cluster name and the external
@@ -569,6 +600,49 @@ func (s *ServiceAttributes) DeepCopy() ServiceAttributes {
 	return copyInternal(*s).(ServiceAttributes)
 }
 
+// DNSTTLFromAnnotations returns the DNS TTL set by the networking.istio.io/dnsTTL annotation of a service, rounded to
+// seconds. It returns 0, so the default TTL of the proxy is used, when the annotation is unset or invalid. A TTL
+// rounded to 0s is invalid, since the proxy could not tell it from an unset TTL.
+func DNSTTLFromAnnotations(annotations map[string]string) time.Duration {
+	value, ok := annotations[constants.DNSTTLAnnotation]
+	if !ok {
+		return 0
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl.Round(time.Second) <= 0 || ttl.Seconds() > math.MaxUint32 {
+		log.Warnf("ignoring invalid %s annotation %q, it must be a duration of at least 1s", constants.DNSTTLAnnotation, value)
+		return 0
+	}
+	return ttl.Round(time.Second)
+}
+
+// TCPIdleTimeoutsFromAnnotations returns the TCP idle timeouts by port set by the networking.istio.io/tcpIdleTimeout
+// annotation of a service, with port 0 for the timeout of the other ports. It returns nil, so the mesh-wide timeout is
+// used, when the annotation is unset or invalid.
+func TCPIdleTimeoutsFromAnnotations(annotations map[string]string) map[int]time.Duration {
+	value, ok := annotations[constants.TCPIdleTimeoutAnnotation]
+	if !ok {
+		return nil
+	}
+	timeouts, err := validation.ParseTCPIdleTimeouts(value)
+	if err != nil {
+		log.Warnf("ignoring invalid %s annotation %q: %v", constants.TCPIdleTimeoutAnnotation, value, err)
+		return nil
+	}
+	return timeouts
+}
+
+// TCPIdleTimeout returns the TCP idle timeout set for a port of the service, or 0 if there is none.
+func (s *Service) TCPIdleTimeout(port int) time.Duration {
+	if s == nil {
+		return 0
+	}
+	if timeout, f := s.Attributes.TCPIdleTimeouts[port]; f {
+		return timeout
+	}
+	return s.Attributes.TCPIdleTimeouts[0]
+}
+
 // ServiceDiscovery enumerates Istio service instances.
 // nolint: lll
 type ServiceDiscovery interface {
@@ -682,6 +756,16 @@ func BuildSubsetKey(direction TrafficDirection, subsetName string, hostname host
 	return string(direction) + "|" + strconv.Itoa(port) + "|" + subsetName + "|" + string(hostname)
 }
 
+// ShadowClusterSuffix is appended to the name of a cluster to build the name of the cluster receiving the traffic
+// mirrored to it.
+const ShadowClusterSuffix = "|shadow"
+
+// BuildShadowClusterName returns the name of the cluster receiving the traffic mirrored to the given cluster.
+// The shadow cluster has the same endpoints as the cluster, but separate statistics.
+func BuildShadowClusterName(clusterName string) string {
+	return clusterName + ShadowClusterSuffix
+}
+
 // BuildInboundSubsetKey generates a unique string referencing service instances with port.
 func BuildInboundSubsetKey(port int) string {
 	return BuildSubsetKey(TrafficDirectionInbound, "", "", port)
@@ -751,13 +835,14 @@ func (s *Service) GetAddresses(node *Proxy) []string {
 	return []string{s.GetAddressForProxy(node)}
 }
 
-// GetAddressForProxy returns a Service's address specific to the cluster where the node resides
+// GetAddressForProxy returns a Service's address specific to the cluster where the node resides.
+// For dual-stack services, the address of the IP family of a single-stack proxy is preferred.
 func (s *Service) GetAddressForProxy(node *Proxy) string {
 	if node.Metadata != nil {
 		if node.Metadata.ClusterID != "" {
 			addresses := s.ClusterVIPs.GetAddressesFor(node.Metadata.ClusterID)
 			if len(addresses) > 0 {
-				return addresses[0]
+				return addressForIPMode(addresses, node)
 			}
 		}
 
@@ -774,6 +859,35 @@ func (s *Service) GetAddressForProxy(node *Proxy) string {
 	return s.DefaultAddress
 }
 
+// GetAllAddressesForProxy returns the addresses of a Service in the cluster where the node resides, starting with
+// GetAddressForProxy. A dual-stack proxy gets the addresses of both IP families of a dual-stack service.
+func (s *Service) GetAllAddressesForProxy(node *Proxy) []string {
+	address := s.GetAddressForProxy(node)
+	if node.Metadata == nil || node.Metadata.ClusterID == "" || node.ipMode != Dual {
+		return []string{address}
+	}
+	out := []string{address}
+	for _, a := range s.ClusterVIPs.GetAddressesFor(node.Metadata.ClusterID) {
+		if a != address {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// addressForIPMode returns the first address of the IP family of a single-stack proxy, or the first address if the
+// proxy is dual-stack or none of the addresses is of its family.
+func addressForIPMode(addresses []string, node *Proxy) string {
+	if node.ipMode == IPv4 || node.ipMode == IPv6 {
+		for _, a := range addresses {
+			if ip := net.ParseIP(a); ip != nil && (ip.To4() != nil) == (node.ipMode == IPv4) {
+				return a
+			}
+		}
+	}
+	return addresses[0]
+}
+
 // getAllAddresses returns a Service's all addresses.
 func (s *Service) getAllAddresses() []string {
 	var addresses []string
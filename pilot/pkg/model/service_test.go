@@ -15,13 +15,16 @@
 package model
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	fuzz "github.com/google/gofuzz"
 
 	"istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/labels"
 	"istio.io/istio/pkg/config/visibility"
@@ -48,6 +51,64 @@ func BenchmarkParseSubsetKey(b *testing.B) {
 	}
 }
 
+func TestDNSTTLFromAnnotations(t *testing.T) {
+	cases := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"5s", 5 * time.Second},
+		{"1m", time.Minute},
+		{"1500ms", 2 * time.Second},
+		{"-5s", 0},
+		{"0s", 0},
+		{"400ms", 0},
+		{"500ms", time.Second},
+		{"five", 0},
+	}
+	for _, tt := range cases {
+		if got := DNSTTLFromAnnotations(map[string]string{constants.DNSTTLAnnotation: tt.value}); got != tt.want {
+			t.Errorf("DNSTTLFromAnnotations(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+	if got := DNSTTLFromAnnotations(nil); got != 0 {
+		t.Errorf("DNSTTLFromAnnotations(nil) = %v, want 0", got)
+	}
+}
+
+func TestTCPIdleTimeoutsFromAnnotations(t *testing.T) {
+	cases := []struct {
+		value string
+		want  map[int]time.Duration
+	}{
+		{"4h", map[int]time.Duration{0: 4 * time.Hour}},
+		{"5432=4h", map[int]time.Duration{5432: 4 * time.Hour}},
+		{"5432=4h, 3306=2h,30m", map[int]time.Duration{5432: 4 * time.Hour, 3306: 2 * time.Hour, 0: 30 * time.Minute}},
+		{"0s", nil},
+		{"forever", nil},
+		{"5432=", nil},
+		{"70000=1h", nil},
+		{"1h,2h", nil},
+		{"5432=1h,5432=2h", nil},
+	}
+	for _, tt := range cases {
+		got := TCPIdleTimeoutsFromAnnotations(map[string]string{constants.TCPIdleTimeoutAnnotation: tt.value})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TCPIdleTimeoutsFromAnnotations(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+	if got := TCPIdleTimeoutsFromAnnotations(nil); got != nil {
+		t.Errorf("TCPIdleTimeoutsFromAnnotations(nil) = %v, want nil", got)
+	}
+
+	svc := &Service{Attributes: ServiceAttributes{TCPIdleTimeouts: map[int]time.Duration{5432: 4 * time.Hour, 0: time.Hour}}}
+	if got := svc.TCPIdleTimeout(5432); got != 4*time.Hour {
+		t.Errorf("TCPIdleTimeout(5432) = %v, want 4h", got)
+	}
+	if got := svc.TCPIdleTimeout(80); got != time.Hour {
+		t.Errorf("TCPIdleTimeout(80) = %v, want 1h", got)
+	}
+}
+
 func TestParseSubsetKey(t *testing.T) {
 	tests := []struct {
 		input      string
@@ -153,6 +214,63 @@ func TestGetLocalityOrDefault(t *testing.T) {
 	}
 }
 
+func TestGetAddressForProxyDualStack(t *testing.T) {
+	svc := &Service{
+		DefaultAddress: "10.0.0.1",
+		ClusterVIPs: AddressMap{
+			Addresses: map[cluster.ID][]string{"cluster-1": {"10.0.0.1", "fd00::1"}},
+		},
+	}
+	cases := []struct {
+		name        string
+		ips         []string
+		cluster     cluster.ID
+		expected    string
+		expectedAll []string
+	}{
+		{
+			name:        "ipv4 proxy",
+			ips:         []string{"10.244.0.1"},
+			cluster:     "cluster-1",
+			expected:    "10.0.0.1",
+			expectedAll: []string{"10.0.0.1"},
+		},
+		{
+			name:        "ipv6 proxy",
+			ips:         []string{"fd00:10:244::1"},
+			cluster:     "cluster-1",
+			expected:    "fd00::1",
+			expectedAll: []string{"fd00::1"},
+		},
+		{
+			name:        "dual-stack proxy",
+			ips:         []string{"10.244.0.1", "fd00:10:244::1"},
+			cluster:     "cluster-1",
+			expected:    "10.0.0.1",
+			expectedAll: []string{"10.0.0.1", "fd00::1"},
+		},
+		{
+			name:        "proxy of another cluster",
+			ips:         []string{"fd00:10:244::1"},
+			cluster:     "cluster-2",
+			expected:    "10.0.0.1",
+			expectedAll: []string{"10.0.0.1"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			proxy := &Proxy{IPAddresses: c.ips, Metadata: &NodeMetadata{ClusterID: c.cluster}}
+			proxy.DiscoverIPMode()
+			if got := svc.GetAddressForProxy(proxy); got != c.expected {
+				t.Errorf("expected address %s, but got %s", c.expected, got)
+			}
+			if got := svc.GetAllAddressesForProxy(proxy); !reflect.DeepEqual(got, c.expectedAll) {
+				t.Errorf("expected addresses %v, but got %v", c.expectedAll, got)
+			}
+		})
+	}
+}
+
 func TestWorkloadInstanceEqual(t *testing.T) {
 	exampleInstance := &WorkloadInstance{
 		Endpoint: &IstioEndpoint{
@@ -36,6 +36,7 @@ import (
 	tpb "istio.io/api/telemetry/v1alpha1"
 	"istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pilot/pkg/util/protoconv"
+	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/labels"
 	"istio.io/istio/pkg/config/schema/collections"
 	"istio.io/istio/pkg/config/xds"
@@ -51,6 +52,8 @@ type Telemetry struct {
 	Name      string         `json:"name"`
 	Namespace string         `json:"namespace"`
 	Spec      *tpb.Telemetry `json:"spec"`
+	// AccessLogFormat overrides the format of file access logs, from the annotations of the Telemetry.
+	AccessLogFormat *meshconfig.MeshConfig_ExtensionProvider_EnvoyFileAccessLogProvider_LogFormat `json:"accessLogFormat,omitempty"`
 }
 
 // Telemetries organizes Telemetry configuration by namespace.
@@ -105,29 +108,40 @@ type metricsKey struct {
 
 // getTelemetries returns the Telemetry configurations for the given environment.
 func getTelemetries(env *Environment) (*Telemetries, error) {
+	fromEnv, err := env.List(collections.IstioTelemetryV1Alpha1Telemetries.Resource().GroupVersionKind(), NamespaceAll)
+	if err != nil {
+		return nil, err
+	}
+	return NewTelemetries(env.Mesh(), fromEnv), nil
+}
+
+// NewTelemetries organizes the Telemetry configs by namespace, for the given mesh config. It allows computing the
+// telemetry configuration of a proxy outside of a push context, such as for a proposed change of the Telemetries.
+func NewTelemetries(mesh *meshconfig.MeshConfig, configs []config.Config) *Telemetries {
 	telemetries := &Telemetries{
 		NamespaceToTelemetries: map[string][]Telemetry{},
-		RootNamespace:          env.Mesh().GetRootNamespace(),
-		meshConfig:             env.Mesh(),
+		RootNamespace:          mesh.GetRootNamespace(),
+		meshConfig:             mesh,
 		computedMetricsFilters: map[metricsKey]any{},
 		computedLoggingConfig:  map[loggingKey][]LoggingConfig{},
 	}
 
-	fromEnv, err := env.List(collections.IstioTelemetryV1Alpha1Telemetries.Resource().GroupVersionKind(), NamespaceAll)
-	if err != nil {
-		return nil, err
-	}
-	sortConfigByCreationTime(fromEnv)
-	for _, config := range fromEnv {
+	sortConfigByCreationTime(configs)
+	for _, cfg := range configs {
 		telemetry := Telemetry{
-			Name:      config.Name,
-			Namespace: config.Namespace,
-			Spec:      config.Spec.(*tpb.Telemetry),
+			Name:      cfg.Name,
+			Namespace: cfg.Namespace,
+			Spec:      cfg.Spec.(*tpb.Telemetry),
 		}
-		telemetries.NamespaceToTelemetries[config.Namespace] = append(telemetries.NamespaceToTelemetries[config.Namespace], telemetry)
+		format, err := accessLogFormatFromAnnotations(cfg.Annotations)
+		if err != nil {
+			telemetryLog.Warnf("ignoring access log format of Telemetry %s/%s: %v", cfg.Namespace, cfg.Name, err)
+		}
+		telemetry.AccessLogFormat = format
+		telemetries.NamespaceToTelemetries[cfg.Namespace] = append(telemetries.NamespaceToTelemetries[cfg.Namespace], telemetry)
 	}
 
-	return telemetries, nil
+	return telemetries
 }
 
 type metricsConfig struct {
@@ -183,6 +197,7 @@ type computedTelemetries struct {
 type computedAccessLogging struct {
 	telemetryKey
 	Logging []*tpb.AccessLogging
+	Format  *meshconfig.MeshConfig_ExtensionProvider_EnvoyFileAccessLogProvider_LogFormat
 }
 
 type TracingConfig struct {
@@ -242,6 +257,7 @@ func (t *Telemetries) AccessLogging(push *PushContext, proxy *Proxy, class netwo
 	}
 
 	providers := mergeLogs(ct.Logging, t.meshConfig, workloadMode(class))
+	format := mergeLogFormat(ct.Logging)
 	cfgs := make([]LoggingConfig, 0, len(providers))
 	for p, f := range providers {
 		fp := t.fetchProvider(p)
@@ -254,7 +270,7 @@ func (t *Telemetries) AccessLogging(push *PushContext, proxy *Proxy, class netwo
 			Filter:   f,
 		}
 
-		al := telemetryAccessLog(push, fp)
+		al := telemetryAccessLog(push, fp, format)
 		if al == nil {
 			// stackdriver will be handled in HTTPFilters/TCPFilters
 			continue
@@ -393,6 +409,7 @@ func (t *Telemetries) applicableTelemetries(proxy *Proxy) computedTelemetries {
 					Root: key.Root,
 				},
 				Logging: telemetry.Spec.GetAccessLogging(),
+				Format:  telemetry.AccessLogFormat,
 			})
 			ts = append(ts, telemetry.Spec.GetTracing()...)
 		}
@@ -408,6 +425,7 @@ func (t *Telemetries) applicableTelemetries(proxy *Proxy) computedTelemetries {
 					Namespace: key.Namespace,
 				},
 				Logging: telemetry.Spec.GetAccessLogging(),
+				Format:  telemetry.AccessLogFormat,
 			})
 			ts = append(ts, telemetry.Spec.GetTracing()...)
 		}
@@ -427,6 +445,7 @@ func (t *Telemetries) applicableTelemetries(proxy *Proxy) computedTelemetries {
 					Workload: NamespacedName{Name: telemetry.Name, Namespace: telemetry.Namespace},
 				},
 				Logging: telemetry.Spec.GetAccessLogging(),
+				Format:  telemetry.AccessLogFormat,
 			})
 			ts = append(ts, spec.GetTracing()...)
 			break
@@ -513,6 +532,17 @@ func (t *Telemetries) telemetryFilters(proxy *Proxy, class networking.ListenerCl
 	return res
 }
 
+// mergeLogFormat returns the file access log format override of the most specific Telemetry setting one, if any.
+func mergeLogFormat(logs []*computedAccessLogging) *meshconfig.MeshConfig_ExtensionProvider_EnvoyFileAccessLogProvider_LogFormat {
+	var format *meshconfig.MeshConfig_ExtensionProvider_EnvoyFileAccessLogProvider_LogFormat
+	for _, l := range logs {
+		if l.Format != nil {
+			format = l.Format
+		}
+	}
+	return format
+}
+
 // mergeLogs returns the set of providers for the given logging configuration.
 // The provider names are mapped to any applicable access logging filter that has been applied in provider configuration.
 func mergeLogs(logs []*computedAccessLogging, mesh *meshconfig.MeshConfig, mode tpb.WorkloadMode) map[string]*tpb.AccessLogging_Filter {
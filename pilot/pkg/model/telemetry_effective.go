@@ -0,0 +1,151 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"sort"
+
+	tpb "istio.io/api/telemetry/v1alpha1"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// EffectiveTelemetry is the telemetry configuration of a proxy, resulting from the Telemetry resources of the root
+// namespace, of its namespace and of its workload, layered over the default providers of the mesh config.
+type EffectiveTelemetry struct {
+	// Telemetries are the namespace/name of the Telemetry resources applying to the proxy, from the least to the
+	// most specific.
+	Telemetries []string `json:"telemetries"`
+	// Metrics are nil if neither a Telemetry nor the mesh config configure metrics providers.
+	Metrics []EffectiveMetrics `json:"metrics"`
+	// AccessLogging is nil if neither a Telemetry nor the mesh config configure access logging providers, in which
+	// case the access log settings of the mesh config apply.
+	AccessLogging []EffectiveAccessLogging `json:"accessLogging"`
+	// Tracing is nil if neither a Telemetry nor the mesh config configure tracing providers, in which case the
+	// tracing settings of the mesh config and of the proxy config apply.
+	Tracing *TracingConfig `json:"tracing,omitempty"`
+	// MissingProviders are the providers referenced by the Telemetry resources which are not extension providers of
+	// the mesh config, and so are ignored.
+	MissingProviders []string `json:"missingProviders,omitempty"`
+}
+
+// EffectiveMetrics is the metrics configuration of a provider.
+type EffectiveMetrics struct {
+	Provider string                    `json:"provider"`
+	Client   []EffectiveMetricOverride `json:"client,omitempty"`
+	Server   []EffectiveMetricOverride `json:"server,omitempty"`
+}
+
+// EffectiveMetricOverride is the override of a metric, in the client or server mode.
+type EffectiveMetricOverride struct {
+	Metric   string                 `json:"metric"`
+	Disabled bool                   `json:"disabled,omitempty"`
+	Tags     []EffectiveTagOverride `json:"tags,omitempty"`
+}
+
+// EffectiveTagOverride is the removal of a metric tag, or the expression of its value.
+type EffectiveTagOverride struct {
+	Name   string `json:"name"`
+	Remove bool   `json:"remove,omitempty"`
+	Value  string `json:"value,omitempty"`
+}
+
+// EffectiveAccessLogging is an access logging provider, in the client or server mode.
+type EffectiveAccessLogging struct {
+	Provider string `json:"provider"`
+	Mode     string `json:"mode"`
+	// Filter is the CEL expression of the access logs to keep, if any.
+	Filter string `json:"filter,omitempty"`
+}
+
+// EffectiveTelemetry returns the telemetry configuration of the proxy, as the metrics and access logging filters and
+// the tracing configuration would be built for it.
+func (t *Telemetries) EffectiveTelemetry(proxy *Proxy) EffectiveTelemetry {
+	ct := t.applicableTelemetries(proxy)
+	out := EffectiveTelemetry{Telemetries: []string{}}
+	for _, n := range []NamespacedName{ct.Root, ct.Namespace, ct.Workload} {
+		if n.Name != "" {
+			out.Telemetries = append(out.Telemetries, n.Namespace+"/"+n.Name)
+		}
+	}
+	missing := sets.New()
+
+	metrics := mergeMetrics(ct.Metrics, t.meshConfig)
+	providers := make([]string, 0, len(metrics))
+	for p := range metrics {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+	for _, p := range providers {
+		if t.fetchProvider(p) == nil {
+			missing.Insert(p)
+			continue
+		}
+		out.Metrics = append(out.Metrics, EffectiveMetrics{
+			Provider: p,
+			Client:   effectiveMetricOverrides(metrics[p].ClientMetrics),
+			Server:   effectiveMetricOverrides(metrics[p].ServerMetrics),
+		})
+	}
+
+	if len(ct.Logging) > 0 || len(t.meshConfig.GetDefaultProviders().GetAccessLogging()) > 0 {
+		out.AccessLogging = []EffectiveAccessLogging{}
+		for _, mode := range []tpb.WorkloadMode{tpb.WorkloadMode_CLIENT, tpb.WorkloadMode_SERVER} {
+			logs := mergeLogs(ct.Logging, t.meshConfig, mode)
+			for p, f := range logs {
+				if t.fetchProvider(p) == nil {
+					missing.Insert(p)
+					continue
+				}
+				out.AccessLogging = append(out.AccessLogging, EffectiveAccessLogging{
+					Provider: p,
+					Mode:     mode.String(),
+					Filter:   f.GetExpression(),
+				})
+			}
+		}
+		sort.Slice(out.AccessLogging, func(i, j int) bool {
+			a, b := out.AccessLogging[i], out.AccessLogging[j]
+			if a.Provider != b.Provider {
+				return a.Provider < b.Provider
+			}
+			return a.Mode < b.Mode
+		})
+	}
+
+	out.Tracing = t.Tracing(proxy)
+	for _, tr := range ct.Tracing {
+		for _, p := range getProviderNames(tr.Providers) {
+			if t.fetchProvider(p) == nil {
+				missing.Insert(p)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		out.MissingProviders = missing.SortedList()
+	}
+	return out
+}
+
+func effectiveMetricOverrides(metrics []metricsOverride) []EffectiveMetricOverride {
+	var out []EffectiveMetricOverride
+	for _, m := range metrics {
+		o := EffectiveMetricOverride{Metric: m.Name, Disabled: m.Disabled}
+		for _, t := range m.Tags {
+			o.Tags = append(o.Tags, EffectiveTagOverride{Name: t.Name, Remove: t.Remove, Value: t.Value})
+		}
+		out = append(out, o)
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	tpb "istio.io/api/telemetry/v1alpha1"
+	"istio.io/api/type/v1beta1"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestEffectiveTelemetry(t *testing.T) {
+	sidecar := &Proxy{ConfigNamespace: "default", Metadata: &NodeMetadata{Labels: map[string]string{"app": "test"}}}
+	root := newTelemetry("istio-system", &tpb.Telemetry{
+		Metrics:       []*tpb.Metrics{{Providers: []*tpb.ProviderRef{{Name: "prometheus"}}}},
+		AccessLogging: []*tpb.AccessLogging{{Providers: []*tpb.ProviderRef{{Name: "envoy"}}}},
+	})
+	namespace := newTelemetry("default", &tpb.Telemetry{
+		AccessLogging: []*tpb.AccessLogging{{
+			Providers: []*tpb.ProviderRef{{Name: "envoy"}},
+			Filter:    &tpb.AccessLogging_Filter{Expression: "response.code >= 400"},
+		}},
+		Tracing: []*tpb.Tracing{{Providers: []*tpb.ProviderRef{{Name: "zipkin"}}}},
+	})
+	workload := newTelemetry("default", &tpb.Telemetry{
+		Selector: &v1beta1.WorkloadSelector{MatchLabels: map[string]string{"app": "test"}},
+		Metrics: []*tpb.Metrics{{
+			Overrides: []*tpb.MetricsOverrides{{
+				Match: &tpb.MetricSelector{
+					MetricMatch: &tpb.MetricSelector_Metric{Metric: tpb.MetricSelector_REQUEST_COUNT},
+					Mode:        tpb.WorkloadMode_CLIENT,
+				},
+				Disabled: &wrapperspb.BoolValue{Value: true},
+			}},
+		}},
+	})
+	workload.Name = "test"
+
+	telemetries := NewTelemetries(mesh.DefaultMeshConfig(), []config.Config{root, namespace, workload})
+	assert.Equal(t, telemetries.EffectiveTelemetry(sidecar), EffectiveTelemetry{
+		Telemetries: []string{"istio-system/default", "default/default", "default/test"},
+		Metrics: []EffectiveMetrics{{
+			Provider: "prometheus",
+			Client:   []EffectiveMetricOverride{{Metric: "REQUEST_COUNT", Disabled: true}},
+		}},
+		AccessLogging: []EffectiveAccessLogging{
+			{Provider: "envoy", Mode: "CLIENT", Filter: "response.code >= 400"},
+			{Provider: "envoy", Mode: "SERVER", Filter: "response.code >= 400"},
+		},
+		Tracing: &TracingConfig{
+			ClientSpec: TracingSpec{Disabled: true, UseRequestIDForTraceSampling: true},
+			ServerSpec: TracingSpec{Disabled: true, UseRequestIDForTraceSampling: true},
+		},
+		MissingProviders: []string{"zipkin"},
+	})
+
+	// Without Telemetry resources nor default providers, the mesh config settings apply.
+	assert.Equal(t, NewTelemetries(mesh.DefaultMeshConfig(), nil).EffectiveTelemetry(sidecar), EffectiveTelemetry{Telemetries: []string{}})
+}
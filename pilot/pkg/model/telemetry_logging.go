@@ -30,6 +30,7 @@ import (
 
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	"istio.io/istio/pilot/pkg/util/protoconv"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/util/protomarshal"
 )
@@ -112,12 +113,20 @@ var (
 	}
 )
 
-func telemetryAccessLog(push *PushContext, fp *meshconfig.MeshConfig_ExtensionProvider) *accesslog.AccessLog {
+// telemetryAccessLog builds the access log of the provider. format, if set, overrides the format of file access logs.
+func telemetryAccessLog(push *PushContext, fp *meshconfig.MeshConfig_ExtensionProvider,
+	format *meshconfig.MeshConfig_ExtensionProvider_EnvoyFileAccessLogProvider_LogFormat,
+) *accesslog.AccessLog {
 	var al *accesslog.AccessLog
 	switch prov := fp.Provider.(type) {
 	case *meshconfig.MeshConfig_ExtensionProvider_EnvoyFileAccessLog:
-		// For built-in provider, fallback to Mesh Config for formatting options.
-		if fp.Name == defaultEnvoyAccessLogProvider {
+		if format != nil {
+			al = fileAccessLogFromTelemetry(&meshconfig.MeshConfig_ExtensionProvider_EnvoyFileAccessLogProvider{
+				Path:      prov.EnvoyFileAccessLog.GetPath(),
+				LogFormat: format,
+			})
+		} else if fp.Name == defaultEnvoyAccessLogProvider {
+			// For built-in provider, fallback to Mesh Config for formatting options.
 			al = fileAccessLogFromMeshConfig(prov.EnvoyFileAccessLog.Path, push.Mesh)
 		} else {
 			al = fileAccessLogFromTelemetry(prov.EnvoyFileAccessLog)
@@ -298,6 +307,29 @@ func httpGrpcAccessLogFromTelemetry(push *PushContext, prov *meshconfig.MeshConf
 	}
 }
 
+// accessLogFormatFromAnnotations returns the file access log format set by the annotations of a Telemetry, if any.
+func accessLogFormatFromAnnotations(annotations map[string]string) (*meshconfig.MeshConfig_ExtensionProvider_EnvoyFileAccessLogProvider_LogFormat, error) {
+	text, hasText := annotations[constants.AccessLogFormatAnnotation]
+	labels, hasLabels := annotations[constants.AccessLogLabelsAnnotation]
+	switch {
+	case hasText && hasLabels:
+		return nil, fmt.Errorf("only one of %s and %s can be set", constants.AccessLogFormatAnnotation, constants.AccessLogLabelsAnnotation)
+	case hasText:
+		return &meshconfig.MeshConfig_ExtensionProvider_EnvoyFileAccessLogProvider_LogFormat{
+			LogFormat: &meshconfig.MeshConfig_ExtensionProvider_EnvoyFileAccessLogProvider_LogFormat_Text{Text: text},
+		}, nil
+	case hasLabels:
+		s := &structpb.Struct{}
+		if err := protomarshal.Unmarshal([]byte(labels), s); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", constants.AccessLogLabelsAnnotation, err)
+		}
+		return &meshconfig.MeshConfig_ExtensionProvider_EnvoyFileAccessLogProvider_LogFormat{
+			LogFormat: &meshconfig.MeshConfig_ExtensionProvider_EnvoyFileAccessLogProvider_LogFormat_Labels{Labels: s},
+		}, nil
+	}
+	return nil, nil
+}
+
 func fileAccessLogFromMeshConfig(path string, mesh *meshconfig.MeshConfig) *accesslog.AccessLog {
 	// We need to build access log. This is needed either on first access or when mesh config changes.
 	fl := &fileaccesslog.FileAccessLog{
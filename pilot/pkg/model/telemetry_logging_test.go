@@ -36,6 +36,7 @@ import (
 	"istio.io/istio/pilot/pkg/serviceregistry/provider"
 	"istio.io/istio/pilot/pkg/util/protoconv"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/test/util/assert"
 	"istio.io/istio/pkg/util/protomarshal"
@@ -786,6 +787,44 @@ func TestAccessLoggingCache(t *testing.T) {
 	}
 }
 
+func TestAccessLoggingFormatOverride(t *testing.T) {
+	root := newTelemetry("istio-system", &tpb.Telemetry{
+		AccessLogging: []*tpb.AccessLogging{{Providers: []*tpb.ProviderRef{{Name: "envoy"}}}},
+	})
+	workload := newTelemetry("default", &tpb.Telemetry{
+		Selector: &v1beta1.WorkloadSelector{MatchLabels: map[string]string{"app": "json"}},
+	})
+	workload.Name = "json"
+	workload.Annotations = map[string]string{constants.AccessLogLabelsAnnotation: `{"code":"%RESPONSE_CODE%"}`}
+	namespace := newTelemetry("text", &tpb.Telemetry{})
+	namespace.Annotations = map[string]string{constants.AccessLogFormatAnnotation: "%RESPONSE_CODE%"}
+	telemetry, ctx := createTestTelemetries([]config.Config{root, workload, namespace}, t)
+
+	format := func(proxy *Proxy) *core.SubstitutionFormatString {
+		t.Helper()
+		cfgs := telemetry.AccessLogging(ctx, proxy, networking.ListenerClassSidecarOutbound)
+		if len(cfgs) != 1 {
+			t.Fatalf("expected a single access log, got %v", cfgs)
+		}
+		fl := &fileaccesslog.FileAccessLog{}
+		if err := cfgs[0].AccessLog.GetTypedConfig().UnmarshalTo(fl); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, fl.Path, "/dev/stdout")
+		return fl.GetLogFormat()
+	}
+
+	jsonFormat := format(&Proxy{ConfigNamespace: "default", Metadata: &NodeMetadata{Labels: map[string]string{"app": "json"}}})
+	assert.Equal(t, jsonFormat.GetJsonFormat().GetFields()["code"].GetStringValue(), "%RESPONSE_CODE%")
+
+	textFormat := format(&Proxy{ConfigNamespace: "text", Metadata: &NodeMetadata{Labels: map[string]string{"app": "json"}}})
+	assert.Equal(t, textFormat.GetTextFormatSource().GetInlineString(), "%RESPONSE_CODE%\n")
+
+	// Workloads without an override keep the format of the mesh config.
+	other := format(&Proxy{ConfigNamespace: "default", Metadata: &NodeMetadata{Labels: map[string]string{"app": "other"}}})
+	assert.Equal(t, other.GetTextFormatSource().GetInlineString(), EnvoyTextLogFormat)
+}
+
 func TestBuildOpenTelemetryAccessLogConfig(t *testing.T) {
 	fakeCluster := "outbound|55680||otel-collector.monitoring.svc.cluster.local"
 	fakeAuthority := "otel-collector.monitoring.svc.cluster.local"
@@ -1304,7 +1343,7 @@ func TestTelemetryAccessLog(t *testing.T) {
 			}
 			push.Mesh = tc.meshConfig
 
-			got := telemetryAccessLog(push, tc.fp)
+			got := telemetryAccessLog(push, tc.fp, nil)
 			if got == nil {
 				t.Fatalf("get nil accesslog")
 			}
@@ -15,6 +15,11 @@
 package model
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/gogo/protobuf/jsonpb"
@@ -199,6 +204,7 @@ func mergeVirtualServicesIfNeeded(
 		rootConfigKey := ConfigKey{Kind: kind.VirtualService, Name: root.Name, Namespace: root.Namespace}
 		rootVs := root.Spec.(*networking.VirtualService)
 		mergedRoutes := []*networking.HTTPRoute{}
+		var delegated delegatedRoutes
 		for _, route := range rootVs.Http {
 			// it is root vs with delegate
 			if delegate := route.Delegate; delegate != nil {
@@ -226,13 +232,30 @@ func mergeVirtualServicesIfNeeded(
 				// when multiple routes delegate to one single VS.
 				copiedDelegate := delegateVS.DeepCopy()
 				vs := copiedDelegate.Spec.(*networking.VirtualService)
+				rootRouteName := route.Name
 				merged := mergeHTTPRoutes(route, vs.Http)
 				mergedRoutes = append(mergedRoutes, merged...)
+				if hasRoutePolicies(root) {
+					if delegated == nil {
+						delegated = delegatedRoutes{}
+					}
+					for _, m := range merged {
+						delegated[m.Name] = delegatedRoute{Root: rootRouteName, Delegate: delegateNamespace + "/" + delegate.Name}
+					}
+				}
 			} else {
 				mergedRoutes = append(mergedRoutes, route)
 			}
 		}
 		rootVs.Http = mergedRoutes
+		if len(delegated) > 0 {
+			if b, err := json.Marshal(delegated); err == nil {
+				if root.Annotations == nil {
+					root.Annotations = map[string]string{}
+				}
+				root.Annotations[constants.InternalDelegatedRoutes] = string(b)
+			}
+		}
 		if log.DebugEnabled() {
 			jsonm := &jsonpb.Marshaler{Indent: "   "}
 			vsString, _ := jsonm.MarshalToString(rootVs)
@@ -554,3 +577,269 @@ func UseIngressSemantics(cfg config.Config) bool {
 func UseGatewaySemantics(cfg config.Config) bool {
 	return cfg.Annotations[constants.InternalRouteSemantics] == constants.RouteSemanticsGateway
 }
+
+// VirtualServicePriority returns the route priority of the VirtualService, set by its RoutePriorityAnnotation, or 0 if
+// it has none or it is invalid.
+func VirtualServicePriority(cfg config.Config) int {
+	value, f := cfg.Annotations[constants.RoutePriorityAnnotation]
+	if !f {
+		return 0
+	}
+	priority, err := strconv.Atoi(value)
+	if err != nil {
+		log.Warnf("ignoring the invalid %s annotation %q of virtual service %s/%s", constants.RoutePriorityAnnotation, value,
+			cfg.Namespace, cfg.Name)
+		return 0
+	}
+	return priority
+}
+
+// sortVirtualServicesByPriority sorts the VirtualServices by decreasing route priority, keeping the order of the
+// VirtualServices of the same priority.
+func sortVirtualServicesByPriority(vss []config.Config) {
+	priorities := make(map[string]int, len(vss))
+	for _, vs := range vss {
+		if p := VirtualServicePriority(vs); p != 0 {
+			priorities[vs.Namespace+"/"+vs.Name] = p
+		}
+	}
+	if len(priorities) == 0 {
+		return
+	}
+	sort.SliceStable(vss, func(i, j int) bool {
+		return priorities[vss[i].Namespace+"/"+vss[i].Name] > priorities[vss[j].Namespace+"/"+vss[j].Name]
+	})
+}
+
+// Compression algorithms supported by CompressionPolicy.
+const (
+	CompressionGzip   = "gzip"
+	CompressionBrotli = "br"
+)
+
+// CompressionPolicy is the compression policy of the HTTP routes of a VirtualService bound to gateways, set by its
+// CompressionAnnotation.
+type CompressionPolicy struct {
+	// Routes are the names of the HTTP routes the policy applies to, including the routes delegated by them, or the
+	// namespace/name of delegate VirtualServices for all the routes delegated to them. The policy applies to all the
+	// HTTP routes when empty.
+	Routes []string `json:"routes,omitempty"`
+	// Algorithms compress the responses, in order of preference when the client accepts several of them. Supported
+	// algorithms are gzip and br. Defaults to gzip.
+	Algorithms []string `json:"algorithms,omitempty"`
+	// ContentTypes are the content types of the responses compressed. Defaults to the Envoy defaults, such as
+	// application/json and text/html.
+	ContentTypes []string `json:"contentTypes,omitempty"`
+	// MinContentLength is the minimum length in bytes of the responses compressed. Defaults to 30.
+	MinContentLength uint32 `json:"minContentLength,omitempty"`
+	// DecompressRequests decompresses the request bodies compressed with one of the algorithms before they are
+	// sent upstream.
+	DecompressRequests bool `json:"decompressRequests,omitempty"`
+
+	delegated delegatedRoutes
+}
+
+// ParseCompressionPolicy returns the compression policy of the VirtualService, or nil if it has none.
+func ParseCompressionPolicy(cfg config.Config) (*CompressionPolicy, error) {
+	value, f := cfg.Annotations[constants.CompressionAnnotation]
+	if !f {
+		return nil, nil
+	}
+	policy := &CompressionPolicy{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(policy); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %v", constants.CompressionAnnotation, err)
+	}
+	if len(policy.Algorithms) == 0 {
+		policy.Algorithms = []string{CompressionGzip}
+	}
+	for _, algorithm := range policy.Algorithms {
+		if algorithm != CompressionGzip && algorithm != CompressionBrotli {
+			return nil, fmt.Errorf("invalid %s annotation: unsupported algorithm %q, must be one of %s|%s",
+				constants.CompressionAnnotation, algorithm, CompressionGzip, CompressionBrotli)
+		}
+	}
+	policy.delegated = parseDelegatedRoutes(cfg)
+	for _, name := range policy.Routes {
+		if !policy.delegated.hasRoute(cfg, name) {
+			return nil, fmt.Errorf("invalid %s annotation: no HTTP route named %q", constants.CompressionAnnotation, name)
+		}
+	}
+	return policy, nil
+}
+
+// AppliesTo returns whether the policy applies to the HTTP route with the name.
+func (p *CompressionPolicy) AppliesTo(routeName string) bool {
+	if len(p.Routes) == 0 {
+		return true
+	}
+	for _, name := range p.Routes {
+		if p.delegated.matches(routeName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRoutePolicies returns whether the VirtualService has an annotation with policies naming its HTTP routes.
+func hasRoutePolicies(cfg config.Config) bool {
+	_, compression := cfg.Annotations[constants.CompressionAnnotation]
+	_, jwtForwarding := cfg.Annotations[constants.JwtForwardingAnnotation]
+	return compression || jwtForwarding
+}
+
+// delegatedRoute is the origin of an HTTP route of a root VirtualService merged from a delegate.
+type delegatedRoute struct {
+	// Root is the name of the route of the root VirtualService delegating to the delegate.
+	Root string `json:"root"`
+	// Delegate is the namespace/name of the delegate VirtualService.
+	Delegate string `json:"delegate"`
+}
+
+// delegatedRoutes are the delegatedRoute of the HTTP routes of a merged root VirtualService, by route name.
+type delegatedRoutes map[string]delegatedRoute
+
+// parseDelegatedRoutes returns the routes of the merged VirtualService recorded by its InternalDelegatedRoutes
+// annotation, or nil if it has none.
+func parseDelegatedRoutes(cfg config.Config) delegatedRoutes {
+	value, f := cfg.Annotations[constants.InternalDelegatedRoutes]
+	if !f {
+		return nil
+	}
+	var out delegatedRoutes
+	if err := json.Unmarshal([]byte(value), &out); err != nil {
+		log.Warnf("ignoring the invalid %s annotation of virtual service %s/%s: %v", constants.InternalDelegatedRoutes,
+			cfg.Namespace, cfg.Name, err)
+		return nil
+	}
+	return out
+}
+
+// matches returns whether the name in a policy selects the HTTP route. A name selects the route with the name, and
+// the routes delegated by that route or by any route to the VirtualService with the name in namespace/name form.
+func (d delegatedRoutes) matches(routeName, name string) bool {
+	if routeName == name {
+		return true
+	}
+	r, f := d[routeName]
+	return f && (r.Root == name || r.Delegate == name)
+}
+
+// hasRoute returns whether the name in a policy selects an HTTP route of the VirtualService, merged or not.
+func (d delegatedRoutes) hasRoute(cfg config.Config, name string) bool {
+	vs, ok := cfg.Spec.(*networking.VirtualService)
+	if !ok {
+		return true
+	}
+	for _, route := range vs.Http {
+		if d.matches(route.Name, name) {
+			return true
+		}
+		if delegate := route.Delegate; delegate != nil {
+			namespace := delegate.Namespace
+			if namespace == "" {
+				namespace = cfg.Namespace
+			}
+			if namespace+"/"+delegate.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// JWT forwarding modes supported by JwtForwardingPolicy.
+const (
+	// JwtForwardingStrip removes the validated JWTs from the requests.
+	JwtForwardingStrip = "strip"
+	// JwtForwardingPassthrough forwards the validated JWTs as received.
+	JwtForwardingPassthrough = "passthrough"
+	// JwtForwardingPayload removes the validated JWTs from the requests and forwards their payload, the verified
+	// claims, base64url encoded in an internal header instead. The payload is not signed again, the upstreams have to
+	// trust the gateway, and only the header of requests with a validated JWT is set by the gateway.
+	JwtForwardingPayload = "payload"
+)
+
+// DefaultJwtPayloadHeader is the header of the JWT payloads forwarded by the JwtForwardingPayload mode by default.
+const DefaultJwtPayloadHeader = "x-jwt-payload"
+
+// JwtForwardingPolicy is how the gateway forwards the JWTs validated by RequestAuthentication to the upstreams of
+// the HTTP routes of a VirtualService, set by its JwtForwardingAnnotation. It overrides the forwardOriginalToken and
+// outputPayloadToHeader of the JWT rules for these routes.
+type JwtForwardingPolicy struct {
+	// Routes are the names of the HTTP routes the policy applies to, including the routes delegated by them, or the
+	// namespace/name of delegate VirtualServices for all the routes delegated to them. The policy applies to all the
+	// HTTP routes when empty.
+	Routes []string `json:"routes,omitempty"`
+	// Mode is one of strip, passthrough and payload.
+	Mode string `json:"mode"`
+	// PayloadHeader is the header of the JWT payload in the payload mode. Defaults to x-jwt-payload.
+	PayloadHeader string `json:"payloadHeader,omitempty"`
+
+	delegated delegatedRoutes
+}
+
+// ParseJwtForwardingPolicies returns the JWT forwarding policies of the VirtualService, in order of precedence, or
+// nil if it has none.
+func ParseJwtForwardingPolicies(cfg config.Config) ([]*JwtForwardingPolicy, error) {
+	value, f := cfg.Annotations[constants.JwtForwardingAnnotation]
+	if !f {
+		return nil, nil
+	}
+	var policies []*JwtForwardingPolicy
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&policies); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %v", constants.JwtForwardingAnnotation, err)
+	}
+	delegated := parseDelegatedRoutes(cfg)
+	for _, policy := range policies {
+		switch policy.Mode {
+		case JwtForwardingStrip, JwtForwardingPassthrough:
+			if policy.PayloadHeader != "" {
+				return nil, fmt.Errorf("invalid %s annotation: payloadHeader is only supported by the %s mode",
+					constants.JwtForwardingAnnotation, JwtForwardingPayload)
+			}
+		case JwtForwardingPayload:
+			if policy.PayloadHeader == "" {
+				policy.PayloadHeader = DefaultJwtPayloadHeader
+			}
+			policy.PayloadHeader = strings.ToLower(policy.PayloadHeader)
+		default:
+			return nil, fmt.Errorf("invalid %s annotation: unsupported mode %q, must be one of %s|%s|%s",
+				constants.JwtForwardingAnnotation, policy.Mode, JwtForwardingStrip, JwtForwardingPassthrough, JwtForwardingPayload)
+		}
+		policy.delegated = delegated
+		for _, name := range policy.Routes {
+			if !delegated.hasRoute(cfg, name) {
+				return nil, fmt.Errorf("invalid %s annotation: no HTTP route named %q", constants.JwtForwardingAnnotation, name)
+			}
+		}
+	}
+	return policies, nil
+}
+
+// JwtForwardingPolicyForRoute returns the first of the policies applying to the HTTP route with the name, or nil.
+func JwtForwardingPolicyForRoute(policies []*JwtForwardingPolicy, routeName string) *JwtForwardingPolicy {
+	for _, policy := range policies {
+		if len(policy.Routes) == 0 {
+			return policy
+		}
+		for _, name := range policy.Routes {
+			if policy.delegated.matches(routeName, name) {
+				return policy
+			}
+		}
+	}
+	return nil
+}
+
+// RequirementName returns the name of the requirement of the Envoy JWT filter enforcing RequestAuthentication with
+// the forwarding of the policy.
+func (p *JwtForwardingPolicy) RequirementName() string {
+	if p.Mode == JwtForwardingPayload {
+		return p.Mode + ":" + p.PayloadHeader
+	}
+	return p.Mode
+}
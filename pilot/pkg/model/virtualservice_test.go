@@ -16,6 +16,7 @@ package model
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -26,6 +27,7 @@ import (
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/serviceregistry/provider"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/schema/collections"
@@ -2167,3 +2169,269 @@ func buildHTTPService(hostname string, v visibility.Instance, ip, namespace stri
 	service.Ports = Ports
 	return service
 }
+
+func TestParseCompressionPolicy(t *testing.T) {
+	vs := func(annotation string) config.Config {
+		return config.Config{
+			Meta: config.Meta{Annotations: map[string]string{constants.CompressionAnnotation: annotation}},
+			Spec: &networking.VirtualService{
+				Http: []*networking.HTTPRoute{{Name: "api"}, {Name: "static-assets"}},
+			},
+		}
+	}
+	cases := []struct {
+		name       string
+		cfg        config.Config
+		want       *CompressionPolicy
+		wantErr    bool
+		appliesTo  []string
+		notApplies []string
+	}{
+		{
+			name: "no annotation",
+			cfg:  config.Config{Spec: &networking.VirtualService{}},
+		},
+		{
+			name:      "defaults",
+			cfg:       vs(`{}`),
+			want:      &CompressionPolicy{Algorithms: []string{CompressionGzip}},
+			appliesTo: []string{"api", "static-assets", ""},
+		},
+		{
+			name: "routes",
+			cfg:  vs(`{"routes": ["static-assets"], "algorithms": ["br", "gzip"], "minContentLength": 1024, "decompressRequests": true}`),
+			want: &CompressionPolicy{
+				Routes:             []string{"static-assets"},
+				Algorithms:         []string{CompressionBrotli, CompressionGzip},
+				MinContentLength:   1024,
+				DecompressRequests: true,
+			},
+			appliesTo:  []string{"static-assets"},
+			notApplies: []string{"api", "static-assets-v2"},
+		},
+		{
+			name:    "unknown route",
+			cfg:     vs(`{"routes": ["web"]}`),
+			wantErr: true,
+		},
+		{
+			name:    "route name prefix",
+			cfg:     vs(`{"routes": ["static"]}`),
+			wantErr: true,
+		},
+		{
+			name:    "unsupported algorithm",
+			cfg:     vs(`{"algorithms": ["deflate"]}`),
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			cfg:     vs(`{"level": 9}`),
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCompressionPolicy(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for _, name := range tt.appliesTo {
+				if !got.AppliesTo(name) {
+					t.Errorf("expected the policy to apply to route %q", name)
+				}
+			}
+			for _, name := range tt.notApplies {
+				if got.AppliesTo(name) {
+					t.Errorf("expected the policy not to apply to route %q", name)
+				}
+			}
+		})
+	}
+}
+
+func TestParseJwtForwardingPolicies(t *testing.T) {
+	vs := func(annotation string) config.Config {
+		return config.Config{
+			Meta: config.Meta{Annotations: map[string]string{constants.JwtForwardingAnnotation: annotation}},
+			Spec: &networking.VirtualService{
+				Http: []*networking.HTTPRoute{{Name: "internal"}, {Name: "legacy"}, {Name: "public"}},
+			},
+		}
+	}
+	cases := []struct {
+		name    string
+		cfg     config.Config
+		want    []*JwtForwardingPolicy
+		wantErr bool
+		// routes are the requirement names selected by route name, empty when no policy applies.
+		routes map[string]string
+	}{
+		{
+			name: "no annotation",
+			cfg:  config.Config{Spec: &networking.VirtualService{}},
+		},
+		{
+			name: "policies",
+			cfg:  vs(`[{"routes": ["internal"], "mode": "payload", "payloadHeader": "X-JWT-Claims"}, {"routes": ["legacy"], "mode": "passthrough"}]`),
+			want: []*JwtForwardingPolicy{
+				{Routes: []string{"internal"}, Mode: JwtForwardingPayload, PayloadHeader: "x-jwt-claims"},
+				{Routes: []string{"legacy"}, Mode: JwtForwardingPassthrough},
+			},
+			routes: map[string]string{
+				"internal":       "payload:x-jwt-claims",
+				"internal-extra": "",
+				"legacy":         "passthrough",
+				"public":         "",
+			},
+		},
+		{
+			name: "catch all after specific routes",
+			cfg:  vs(`[{"routes": ["legacy"], "mode": "passthrough"}, {"mode": "payload"}]`),
+			want: []*JwtForwardingPolicy{
+				{Routes: []string{"legacy"}, Mode: JwtForwardingPassthrough},
+				{Mode: JwtForwardingPayload, PayloadHeader: DefaultJwtPayloadHeader},
+			},
+			routes: map[string]string{"legacy": "passthrough", "public": "payload:x-jwt-payload"},
+		},
+		{
+			name:    "unknown route",
+			cfg:     vs(`[{"routes": ["web"], "mode": "strip"}]`),
+			wantErr: true,
+		},
+		{
+			name:    "unsupported mode",
+			cfg:     vs(`[{"mode": "resign"}]`),
+			wantErr: true,
+		},
+		{
+			name:    "payload header without payload mode",
+			cfg:     vs(`[{"mode": "strip", "payloadHeader": "x-jwt-claims"}]`),
+			wantErr: true,
+		},
+		{
+			name:    "not a list",
+			cfg:     vs(`{"mode": "strip"}`),
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJwtForwardingPolicies(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for routeName, want := range tt.routes {
+				requirement := ""
+				if policy := JwtForwardingPolicyForRoute(got, routeName); policy != nil {
+					requirement = policy.RequirementName()
+				}
+				if requirement != want {
+					t.Errorf("got requirement %q for route %q, want %q", requirement, routeName, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRoutePoliciesOfDelegatedRoutes(t *testing.T) {
+	root := config.Config{
+		Meta: config.Meta{
+			Name:      "root",
+			Namespace: "istio-system",
+			Annotations: map[string]string{
+				constants.CompressionAnnotation:   `{"routes": ["static"]}`,
+				constants.JwtForwardingAnnotation: `[{"routes": ["web/api"], "mode": "strip"}]`,
+			},
+		},
+		Spec: &networking.VirtualService{
+			Hosts: []string{"example.com"},
+			Http: []*networking.HTTPRoute{
+				{Name: "static", Delegate: &networking.Delegate{Name: "assets", Namespace: "web"}},
+				{Name: "static-v2", Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "static-v2"}}}},
+				{Name: "backend", Delegate: &networking.Delegate{Name: "api", Namespace: "web"}},
+			},
+		},
+	}
+	delegate := func(name string, routes ...string) config.Config {
+		vs := &networking.VirtualService{}
+		for _, r := range routes {
+			vs.Http = append(vs.Http, &networking.HTTPRoute{
+				Name:  r,
+				Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: name}}},
+			})
+		}
+		return config.Config{Meta: config.Meta{Name: name, Namespace: "web"}, Spec: vs}
+	}
+
+	// The policies of the root VirtualService are validated before it is merged.
+	if _, err := ParseCompressionPolicy(root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseJwtForwardingPolicies(root); err != nil {
+		t.Fatal(err)
+	}
+
+	merged, _ := mergeVirtualServicesIfNeeded([]config.Config{root, delegate("assets", "css", "js"), delegate("api", "v1")},
+		map[visibility.Instance]bool{visibility.Public: true})
+	if len(merged) != 1 {
+		t.Fatalf("expected a single merged VirtualService, got %d", len(merged))
+	}
+	compression, err := ParseCompressionPolicy(merged[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwtForwarding, err := ParseJwtForwardingPolicies(merged[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		route       string
+		compression bool
+		jwt         bool
+	}{
+		{route: "static-css", compression: true},
+		{route: "static-js", compression: true},
+		{route: "static-v2"},
+		{route: "backend-v1", jwt: true},
+	} {
+		if got := compression.AppliesTo(tt.route); got != tt.compression {
+			t.Errorf("compression applies to route %q: got %v, want %v", tt.route, got, tt.compression)
+		}
+		if got := JwtForwardingPolicyForRoute(jwtForwarding, tt.route) != nil; got != tt.jwt {
+			t.Errorf("JWT forwarding applies to route %q: got %v, want %v", tt.route, got, tt.jwt)
+		}
+	}
+}
+
+func TestSortVirtualServicesByPriority(t *testing.T) {
+	vs := func(name, priority string) config.Config {
+		cfg := config.Config{Meta: config.Meta{Name: name, Namespace: "default"}}
+		if priority != "" {
+			cfg.Annotations = map[string]string{constants.RoutePriorityAnnotation: priority}
+		}
+		return cfg
+	}
+	// The VirtualServices are sorted by creation time beforehand, which sortVirtualServicesByPriority keeps for
+	// the VirtualServices of the same priority.
+	vss := []config.Config{
+		vs("a", ""),
+		vs("b", "-1"),
+		vs("c", "10"),
+		vs("d", "invalid"),
+		vs("e", "10"),
+		vs("f", "0"),
+	}
+	sortVirtualServicesByPriority(vss)
+	got := make([]string, 0, len(vss))
+	for _, cfg := range vss {
+		got = append(got, cfg.Name)
+	}
+	assert.Equal(t, got, []string{"c", "e", "a", "d", "f", "b"})
+}
@@ -40,11 +40,15 @@ import (
 	"istio.io/istio/pilot/pkg/networking/util"
 	"istio.io/istio/pilot/pkg/serviceregistry/provider"
 	"istio.io/istio/pilot/pkg/util/protoconv"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/config/validation"
 	"istio.io/istio/pkg/security"
 	"istio.io/istio/pkg/util/sets"
+	"istio.io/pkg/log"
 )
 
 // deltaConfigTypes are used to detect changes and trigger delta calculations. When config updates has ONLY entries
@@ -109,6 +113,10 @@ func (configgen *ConfigGeneratorImpl) BuildDeltaClusters(proxy *model.Proxy, upd
 			serviceClusters[string(svcHost)] = sets.New()
 		}
 		serviceClusters[string(svcHost)].Insert(cluster)
+		// The shadow clusters are removed with the clusters they copy, see removedShadowClusters.
+		if strings.HasSuffix(cluster, model.ShadowClusterSuffix) {
+			continue
+		}
 		if servicePorts[string(svcHost)] == nil {
 			servicePorts[string(svcHost)] = make(map[int]string)
 		}
@@ -139,9 +147,28 @@ func (configgen *ConfigGeneratorImpl) BuildDeltaClusters(proxy *model.Proxy, upd
 		}
 	}
 	clusters, log := configgen.buildClusters(proxy, updates, services)
+	deletedClusters = append(deletedClusters, removedShadowClusters(services, serviceClusters, clusters)...)
 	return clusters, deletedClusters, log, true
 }
 
+// removedShadowClusters returns the watched shadow clusters of the updated services that were not built again, because
+// traffic is no longer mirrored to them or the port of the cluster they copy was removed.
+func removedShadowClusters(services []*model.Service, serviceClusters map[string]sets.Set, built []*discovery.Resource) []string {
+	var removed []string
+	names := sets.NewWithLength(len(built))
+	for _, r := range built {
+		names.Insert(r.Name)
+	}
+	for _, service := range services {
+		for _, cluster := range serviceClusters[service.Hostname.String()].SortedList() {
+			if strings.HasSuffix(cluster, model.ShadowClusterSuffix) && !names.Contains(cluster) {
+				removed = append(removed, cluster)
+			}
+		}
+	}
+	return removed
+}
+
 // buildClusters builds clusters for the proxy with the services passed.
 func (configgen *ConfigGeneratorImpl) buildClusters(proxy *model.Proxy, req *model.PushRequest,
 	services []*model.Service,
@@ -159,6 +186,9 @@ func (configgen *ConfigGeneratorImpl) buildClusters(proxy *model.Proxy, req *mod
 		ob, cs := configgen.buildOutboundClusters(cb, proxy, outboundPatcher, services)
 		cacheStats = cacheStats.merge(cs)
 		resources = append(resources, ob...)
+		if features.EnableShadowClusters {
+			resources = append(resources, buildShadowClusters(ob, mirrorDestinations(proxy, req.Push))...)
+		}
 		// Add a blackhole and passthrough cluster for catching traffic to unresolved routes
 		clusters = outboundPatcher.conditionallyAppend(clusters, nil, cb.buildBlackHoleCluster(), cb.buildDefaultPassthroughCluster())
 		clusters = append(clusters, outboundPatcher.insertedClusters()...)
@@ -174,6 +204,9 @@ func (configgen *ConfigGeneratorImpl) buildClusters(proxy *model.Proxy, req *mod
 		ob, cs := configgen.buildOutboundClusters(cb, proxy, patcher, services)
 		cacheStats = cacheStats.merge(cs)
 		resources = append(resources, ob...)
+		if features.EnableShadowClusters {
+			resources = append(resources, buildShadowClusters(ob, mirrorDestinations(proxy, req.Push))...)
+		}
 		// Gateways do not require the default passthrough cluster as they do not have original dst listeners.
 		clusters = patcher.conditionallyAppend(clusters, nil, cb.buildBlackHoleCluster())
 		if proxy.Type == model.Router && proxy.MergedGateway != nil && proxy.MergedGateway.ContainsAutoPassthroughGateways {
@@ -196,6 +229,73 @@ func (configgen *ConfigGeneratorImpl) buildClusters(proxy *model.Proxy, req *mod
 	return resources, model.XdsLogDetails{AdditionalInfo: fmt.Sprintf("cached:%v/%v", cacheStats.hits, cacheStats.hits+cacheStats.miss)}
 }
 
+// mirrorDestinations returns the destinations traffic is mirrored to by the virtual services of the proxy.
+func mirrorDestinations(proxy *model.Proxy, push *model.PushContext) []*networking.Destination {
+	var vss []config.Config
+	if proxy.Type == model.SidecarProxy {
+		for _, egress := range proxy.SidecarScope.EgressListeners {
+			vss = append(vss, egress.VirtualServices()...)
+		}
+	} else if proxy.MergedGateway != nil {
+		for _, gw := range proxy.MergedGateway.GatewayNameForServer {
+			vss = append(vss, push.VirtualServicesForGateway(proxy.ConfigNamespace, gw)...)
+		}
+	}
+	var out []*networking.Destination
+	for _, c := range vss {
+		vs, ok := c.Spec.(*networking.VirtualService)
+		if !ok {
+			continue
+		}
+		for _, h := range vs.Http {
+			if h.Mirror != nil {
+				out = append(out, h.Mirror)
+			}
+		}
+	}
+	return out
+}
+
+// buildShadowClusters copies the outbound clusters traffic is mirrored to as shadow clusters, so the statistics of
+// mirrored traffic are kept separate. The shadow clusters share the endpoints of the original clusters.
+func buildShadowClusters(outbound []*discovery.Resource, mirrors []*networking.Destination) []*discovery.Resource {
+	if len(mirrors) == 0 {
+		return nil
+	}
+	out := make([]*discovery.Resource, 0, len(mirrors))
+	for _, r := range outbound {
+		_, subset, hostname, port := model.ParseSubsetKey(r.Name)
+		if !isMirrorDestination(mirrors, subset, hostname, port) {
+			continue
+		}
+		c := &cluster.Cluster{}
+		if err := r.Resource.UnmarshalTo(c); err != nil {
+			log.Errorf("failed to build shadow cluster for %s: %v", r.Name, err)
+			continue
+		}
+		// The EDS service name of the copy is still the name of the original cluster, so no new endpoints are watched.
+		c.Name = model.BuildShadowClusterName(r.Name)
+		if c.AltStatName != "" {
+			c.AltStatName += model.ShadowClusterSuffix
+		}
+		out = append(out, &discovery.Resource{Name: c.Name, Resource: protoconv.MessageToAny(c)})
+	}
+	return out
+}
+
+func isMirrorDestination(mirrors []*networking.Destination, subset string, hostname host.Name, port int) bool {
+	for _, m := range mirrors {
+		if host.Name(m.Host) != hostname || m.Subset != subset {
+			continue
+		}
+		// Without a port, traffic is mirrored to the port it was received on.
+		if m.Port == nil || int(m.Port.Number) == port {
+			return true
+		}
+	}
+	return false
+}
+
 func shouldUseDelta(updates *model.PushRequest) bool {
 	return updates != nil && deltaAwareConfigTypes(updates.ConfigsUpdated) && len(updates.ConfigsUpdated) > 0
 }
@@ -801,6 +901,90 @@ func setSlowStartConfig(dur *durationpb.Duration) *cluster.Cluster_SlowStartConf
 	}
 }
 
+// slowStartAggressionRuntimeKey is the runtime key Envoy requires with the aggression, which operators can use to
+// override the aggression of all the clusters of a proxy.
+const slowStartAggressionRuntimeKey = "upstream.slow_start_aggression"
+
+// applySlowStartAggression sets the aggression of the slow start of the cluster of the subset, empty for the default
+// cluster, from the DestinationRule annotation. It is a no-op for the clusters without slow start.
+func applySlowStartAggression(c *cluster.Cluster, destinationRule *config.Config, subset string) {
+	if destinationRule == nil {
+		return
+	}
+	value, f := destinationRule.Annotations[constants.SlowStartAggressionAnnotation]
+	if !f {
+		return
+	}
+	var slowStart *cluster.Cluster_SlowStartConfig
+	switch lb := c.LbConfig.(type) {
+	case *cluster.Cluster_RoundRobinLbConfig_:
+		slowStart = lb.RoundRobinLbConfig.GetSlowStartConfig()
+	case *cluster.Cluster_LeastRequestLbConfig_:
+		slowStart = lb.LeastRequestLbConfig.GetSlowStartConfig()
+	}
+	if slowStart == nil {
+		return
+	}
+	aggressions, err := parseSlowStartAggression(value)
+	if err != nil {
+		log.Warnf("Ignoring invalid %s annotation of DestinationRule %s/%s: %v",
+			constants.SlowStartAggressionAnnotation, destinationRule.Namespace, destinationRule.Name, err)
+		return
+	}
+	aggression, f := aggressions[subset]
+	if !f {
+		aggression, f = aggressions[""]
+	}
+	if f {
+		slowStart.Aggression = &core.RuntimeDouble{DefaultValue: aggression, RuntimeKey: slowStartAggressionRuntimeKey}
+	}
+}
+
+// parseSlowStartAggression parses the value of the networking.istio.io/slowStartAggression annotation into the
+// aggressions by subset, with an empty subset for the other clusters.
+func parseSlowStartAggression(value string) (map[string]float64, error) {
+	return validation.ParseSubsetValues(value, "aggression", "a positive number", func(aggression float64) bool {
+		return aggression > 0 && !math.IsNaN(aggression) && !math.IsInf(aggression, 0)
+	})
+}
+
+// applyPreconnectPolicy sets the preconnect ratios of the cluster of the subset, empty for the default cluster, from
+// the DestinationRule annotations.
+func applyPreconnectPolicy(c *cluster.Cluster, destinationRule *config.Config, subset string) {
+	if destinationRule == nil {
+		return
+	}
+	ratio := func(annotation string) *wrappers.DoubleValue {
+		value, f := destinationRule.Annotations[annotation]
+		if !f {
+			return nil
+		}
+		ratios, err := validation.ParsePreconnectRatios(value)
+		if err != nil {
+			log.Warnf("Ignoring invalid %s annotation of DestinationRule %s/%s: %v",
+				annotation, destinationRule.Namespace, destinationRule.Name, err)
+			return nil
+		}
+		r, f := ratios[subset]
+		if !f {
+			r, f = ratios[""]
+		}
+		if !f {
+			return nil
+		}
+		return &wrappers.DoubleValue{Value: r}
+	}
+	perUpstream := ratio(constants.PreconnectRatioAnnotation)
+	predictive := ratio(constants.PredictivePreconnectRatioAnnotation)
+	if perUpstream == nil && predictive == nil {
+		return
+	}
+	c.PreconnectPolicy = &cluster.Cluster_PreconnectPolicy{
+		PerUpstreamPreconnectRatio: perUpstream,
+		PredictivePreconnectRatio:  predictive,
+	}
+}
+
 // ApplyRingHashLoadBalancer will set the LbPolicy and create an LbConfig for RING_HASH if  used in LoadBalancerSettings
 func ApplyRingHashLoadBalancer(c *cluster.Cluster, lb *networking.LoadBalancerSettings) {
 	consistentHash := lb.GetConsistentHash()
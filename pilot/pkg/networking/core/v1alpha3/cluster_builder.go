@@ -216,6 +216,8 @@ func (cb *ClusterBuilder) buildSubsetCluster(opts buildClusterOpts, destRule *co
 	}
 	// Apply traffic policy for the subset cluster.
 	cb.applyTrafficPolicy(opts)
+	applySlowStartAggression(subsetCluster.cluster, destRule, subset.Name)
+	applyPreconnectPolicy(subsetCluster.cluster, destRule, subset.Name)
 
 	maybeApplyEdsConfig(subsetCluster.cluster)
 
@@ -261,6 +263,8 @@ func (cb *ClusterBuilder) applyDestinationRule(mc *MutableCluster, clusterMode C
 	}
 	// Apply traffic policy for the main default cluster.
 	cb.applyTrafficPolicy(opts)
+	applySlowStartAggression(mc.cluster, destRule, "")
+	applyPreconnectPolicy(mc.cluster, destRule, "")
 
 	// Apply EdsConfig if needed. This should be called after traffic policy is applied because, traffic policy might change
 	// discovery type.
@@ -45,6 +45,7 @@ import (
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/schema/gvk"
@@ -251,8 +252,10 @@ type clusterTest struct {
 	locality          *core.Locality
 	mesh              *meshconfig.MeshConfig
 	destRule          proto.Message
-	peerAuthn         *authn_beta.PeerAuthentication
-	externalService   bool
+	// destRuleAnnotations are the annotations of destRule.
+	destRuleAnnotations map[string]string
+	peerAuthn           *authn_beta.PeerAuthentication
+	externalService     bool
 
 	meta         *model.NodeMetadata
 	istioVersion *model.IstioVersion
@@ -363,6 +366,7 @@ func buildTestClusters(c clusterTest) []*cluster.Cluster {
 			Meta: config.Meta{
 				GroupVersionKind: gvk.DestinationRule,
 				Name:             "acme",
+				Annotations:      c.destRuleAnnotations,
 			},
 			Spec: c.destRule,
 		})
@@ -1349,6 +1353,157 @@ func TestSlowStartConfig(t *testing.T) {
 	}
 }
 
+func TestSlowStartAggression(t *testing.T) {
+	testcases := []struct {
+		name       string
+		annotation string
+		subsets    []*networking.Subset
+		expected   map[string]float64
+	}{
+		{
+			name:       "all clusters",
+			annotation: "1.5",
+			subsets:    []*networking.Subset{{Name: "canary", Labels: map[string]string{"version": "v2"}}},
+			expected:   map[string]float64{"": 1.5, "canary": 1.5},
+		},
+		{
+			name:       "subset override",
+			annotation: "canary=3, 1.5",
+			subsets: []*networking.Subset{
+				{Name: "canary", Labels: map[string]string{"version": "v2"}},
+				{Name: "stable", Labels: map[string]string{"version": "v1"}},
+			},
+			expected: map[string]float64{"": 1.5, "canary": 3, "stable": 1.5},
+		},
+		{
+			name:       "subset only",
+			annotation: "canary=3",
+			subsets:    []*networking.Subset{{Name: "canary", Labels: map[string]string{"version": "v2"}}},
+			expected:   map[string]float64{"": 0, "canary": 3},
+		},
+		{
+			name:       "subset without slow start",
+			annotation: "2",
+			subsets: []*networking.Subset{{
+				Name:          "canary",
+				Labels:        map[string]string{"version": "v2"},
+				TrafficPolicy: getSlowStartTrafficPolicy(false, networking.LoadBalancerSettings_ROUND_ROBIN),
+			}},
+			expected: map[string]float64{"": 2, "canary": 0},
+		},
+		{
+			name:       "invalid",
+			annotation: "canary=-1",
+			subsets:    []*networking.Subset{{Name: "canary", Labels: map[string]string{"version": "v2"}}},
+			expected:   map[string]float64{"": 0, "canary": 0},
+		},
+		{
+			name:       "not a number",
+			annotation: "NaN",
+			subsets:    []*networking.Subset{{Name: "canary", Labels: map[string]string{"version": "v2"}}},
+			expected:   map[string]float64{"": 0, "canary": 0},
+		},
+		{
+			name:       "infinite",
+			annotation: "canary=+Inf",
+			subsets:    []*networking.Subset{{Name: "canary", Labels: map[string]string{"version": "v2"}}},
+			expected:   map[string]float64{"": 0, "canary": 0},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			clusters := buildTestClusters(clusterTest{
+				t:               t,
+				serviceHostname: "foo.example.org",
+				nodeType:        model.SidecarProxy,
+				mesh:            testMesh(),
+				destRule: &networking.DestinationRule{
+					Host:          "foo.example.org",
+					TrafficPolicy: getSlowStartTrafficPolicy(true, networking.LoadBalancerSettings_ROUND_ROBIN),
+					Subsets:       test.subsets,
+				},
+				destRuleAnnotations: map[string]string{constants.SlowStartAggressionAnnotation: test.annotation},
+			})
+
+			for subset, aggression := range test.expected {
+				c := xdstest.ExtractCluster("outbound|8080|"+subset+"|foo.example.org", clusters)
+				got := c.GetRoundRobinLbConfig().GetSlowStartConfig().GetAggression()
+				if aggression == 0 {
+					g.Expect(got).To(BeNil())
+					continue
+				}
+				g.Expect(got.GetDefaultValue()).To(Equal(aggression))
+				g.Expect(got.GetRuntimeKey()).To(Equal(slowStartAggressionRuntimeKey))
+			}
+		})
+	}
+}
+
+func TestPreconnectPolicy(t *testing.T) {
+	testcases := []struct {
+		name        string
+		annotations map[string]string
+		// expected are the per upstream and predictive ratios by subset, 0 for none.
+		expected map[string][2]float64
+	}{
+		{
+			name:        "no annotations",
+			annotations: map[string]string{},
+			expected:    map[string][2]float64{"": {}, "canary": {}},
+		},
+		{
+			name:        "all clusters",
+			annotations: map[string]string{constants.PreconnectRatioAnnotation: "1.5"},
+			expected:    map[string][2]float64{"": {1.5, 0}, "canary": {1.5, 0}},
+		},
+		{
+			name: "subset override",
+			annotations: map[string]string{
+				constants.PreconnectRatioAnnotation:           "canary=2, 1.5",
+				constants.PredictivePreconnectRatioAnnotation: "canary=3",
+			},
+			expected: map[string][2]float64{"": {1.5, 0}, "canary": {2, 3}},
+		},
+		{
+			name: "invalid",
+			annotations: map[string]string{
+				constants.PreconnectRatioAnnotation:           "4",
+				constants.PredictivePreconnectRatioAnnotation: "2",
+			},
+			expected: map[string][2]float64{"": {0, 2}, "canary": {0, 2}},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			clusters := buildTestClusters(clusterTest{
+				t:               t,
+				serviceHostname: "foo.example.org",
+				nodeType:        model.SidecarProxy,
+				mesh:            testMesh(),
+				destRule: &networking.DestinationRule{
+					Host:    "foo.example.org",
+					Subsets: []*networking.Subset{{Name: "canary", Labels: map[string]string{"version": "v2"}}},
+				},
+				destRuleAnnotations: test.annotations,
+			})
+
+			for subset, ratios := range test.expected {
+				c := xdstest.ExtractCluster("outbound|8080|"+subset+"|foo.example.org", clusters)
+				if ratios == [2]float64{} {
+					g.Expect(c.GetPreconnectPolicy()).To(BeNil())
+					continue
+				}
+				g.Expect(c.GetPreconnectPolicy().GetPerUpstreamPreconnectRatio().GetValue()).To(Equal(ratios[0]))
+				g.Expect(c.GetPreconnectPolicy().GetPredictivePreconnectRatio().GetValue()).To(Equal(ratios[1]))
+			}
+		})
+	}
+}
+
 func getSlowStartTrafficPolicy(slowStartEnabled bool, lbType networking.LoadBalancerSettings_SimpleLB) *networking.TrafficPolicy {
 	var warmupDurationSecs *durationpb.Duration
 	if slowStartEnabled {
@@ -1933,6 +2088,78 @@ func TestEnvoyFilterPatching(t *testing.T) {
 	}
 }
 
+func TestShadowClusters(t *testing.T) {
+	test.SetBoolForTest(t, &features.EnableShadowClusters, true)
+	cg := NewConfigGenTest(t, TestOptions{ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: reviews
+  namespace: default
+spec:
+  hosts:
+  - reviews.example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: STATIC
+  endpoints:
+  - address: 1.1.1.1
+    labels:
+      version: v1
+  - address: 2.2.2.2
+    labels:
+      version: v2
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: reviews
+  namespace: default
+spec:
+  host: reviews.example.com
+  subsets:
+  - name: v1
+    labels:
+      version: v1
+  - name: v2
+    labels:
+      version: v2
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: reviews
+  namespace: default
+spec:
+  hosts:
+  - reviews.example.com
+  http:
+  - route:
+    - destination:
+        host: reviews.example.com
+        subset: v1
+    mirror:
+      host: reviews.example.com
+      subset: v2
+`})
+	clusters := xdstest.ExtractClusters(cg.Clusters(cg.SetupProxy(nil)))
+
+	shadow, ok := clusters["outbound|80|v2|reviews.example.com|shadow"]
+	if !ok {
+		t.Fatalf("shadow cluster not found in %v", xdstest.MapKeys(clusters))
+	}
+	if got, want := shadow.GetEdsClusterConfig().GetServiceName(), "outbound|80|v2|reviews.example.com"; got != want {
+		t.Errorf("got EDS service name %q, want %q", got, want)
+	}
+	for name := range clusters {
+		if name != shadow.Name && strings.HasSuffix(name, model.ShadowClusterSuffix) {
+			t.Errorf("unexpected shadow cluster %s", name)
+		}
+	}
+}
+
 func TestTelemetryMetadata(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -2559,6 +2786,24 @@ func TestBuildDeltaClusters(t *testing.T) {
 			removedClusters:      []string{"outbound|7070||test.com"},
 			expectedClusters:     []string{"BlackHoleCluster", "InboundPassthroughClusterIpv4", "PassthroughCluster", "outbound|8080||test.com"},
 		},
+		{
+			name:                 "service port with a shadow cluster is removed",
+			services:             []*model.Service{testService1},
+			configUpdated:        map[model.ConfigKey]struct{}{{Kind: kind.ServiceEntry, Name: "test.com", Namespace: TestServiceNamespace}: {}},
+			watchedResourceNames: []string{"outbound|7070||test.com", "outbound|7070||test.com|shadow"},
+			usedDelta:            true,
+			removedClusters:      []string{"outbound|7070||test.com", "outbound|7070||test.com|shadow"},
+			expectedClusters:     []string{"BlackHoleCluster", "InboundPassthroughClusterIpv4", "PassthroughCluster", "outbound|8080||test.com"},
+		},
+		{
+			name:                 "service is no longer mirrored",
+			services:             []*model.Service{testService1},
+			configUpdated:        map[model.ConfigKey]struct{}{{Kind: kind.ServiceEntry, Name: "test.com", Namespace: TestServiceNamespace}: {}},
+			watchedResourceNames: []string{"outbound|8080||test.com", "outbound|8080||test.com|shadow"},
+			usedDelta:            true,
+			removedClusters:      []string{"outbound|8080||test.com|shadow"},
+			expectedClusters:     []string{"BlackHoleCluster", "InboundPassthroughClusterIpv4", "PassthroughCluster", "outbound|8080||test.com"},
+		},
 		{
 			name:                 "config update that is not delta aware",
 			services:             []*model.Service{testService1, testService2},
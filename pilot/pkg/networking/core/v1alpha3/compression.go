@@ -0,0 +1,296 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	xdscore "github.com/cncf/xds/go/xds/core/v3"
+	xdsmatcher "github.com/cncf/xds/go/xds/type/matcher/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	matching "github.com/envoyproxy/go-control-plane/envoy/extensions/common/matching/v3"
+	brotlicompressor "github.com/envoyproxy/go-control-plane/envoy/extensions/compression/brotli/compressor/v3"
+	brotlidecompressor "github.com/envoyproxy/go-control-plane/envoy/extensions/compression/brotli/decompressor/v3"
+	gzipcompressor "github.com/envoyproxy/go-control-plane/envoy/extensions/compression/gzip/compressor/v3"
+	gzipdecompressor "github.com/envoyproxy/go-control-plane/envoy/extensions/compression/gzip/decompressor/v3"
+	matcheraction "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/common/matcher/action/v3"
+	compressor "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/compressor/v3"
+	decompressor "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/decompressor/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoymatcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/util/protoconv"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/util/sets"
+	"istio.io/pkg/log"
+)
+
+type predicate = xdsmatcher.Matcher_MatcherList_Predicate
+
+// buildGatewayCompressionFilters builds the compression filters of the VirtualServices with a compression policy
+// bound to the servers of the route. Envoy has no per route compression config, so the filters of a VirtualService
+// are wrapped in a matcher skipping them for the requests not matching the hosts and paths of its routes the policy
+// applies to. The other match conditions of the routes, such as headers, are not considered.
+func buildGatewayCompressionFilters(node *model.Proxy, push *model.PushContext, routeName string) []*hcm.HttpFilter {
+	if node.MergedGateway == nil {
+		return nil
+	}
+	var keys []string
+	policies := map[string]*model.CompressionPolicy{}
+	virtualServices := map[string]*networking.VirtualService{}
+	hosts := map[string]sets.Set{}
+	for _, server := range node.MergedGateway.ServersByRouteName[routeName] {
+		gatewayName := node.MergedGateway.GatewayNameForServer[server]
+		for _, vs := range push.VirtualServicesForGateway(node.ConfigNamespace, gatewayName) {
+			key := vs.Namespace + "/" + vs.Name
+			if _, f := policies[key]; !f {
+				policy, err := model.ParseCompressionPolicy(vs)
+				if err != nil {
+					log.Warnf("ignoring compression policy of VirtualService %s: %v", key, err)
+				}
+				policies[key] = policy
+				if policy != nil {
+					keys = append(keys, key)
+					virtualServices[key] = vs.Spec.(*networking.VirtualService)
+					hosts[key] = sets.New()
+				}
+			}
+			if policies[key] == nil {
+				continue
+			}
+			serverHosts := host.NamesForNamespace(server.Hosts, vs.Namespace)
+			for _, h := range serverHosts.Intersection(host.NewNames(vs.Spec.(*networking.VirtualService).Hosts)) {
+				hosts[key].Insert(string(h))
+			}
+		}
+	}
+
+	var filters []*hcm.HttpFilter
+	for _, key := range keys {
+		if hosts[key].IsEmpty() {
+			continue
+		}
+		filters = append(filters, buildCompressionFilters(key, hosts[key].SortedList(), virtualServices[key], policies[key])...)
+	}
+	return filters
+}
+
+// buildCompressionFilters builds the decompressor and compressor filters of a compression policy, applying to the
+// requests matching the hosts and the routes of the VirtualService the policy applies to.
+func buildCompressionFilters(key string, hosts []string, vs *networking.VirtualService, policy *model.CompressionPolicy) []*hcm.HttpFilter {
+	match := compressionPredicate(hosts, vs, policy)
+	var filters []*hcm.HttpFilter
+	if policy.DecompressRequests {
+		for _, algorithm := range policy.Algorithms {
+			filters = append(filters, wrapWithMatcher(fmt.Sprintf("istio.decompressor.%s.%s", algorithm, key),
+				"envoy.filters.http.decompressor", buildDecompressor(algorithm), match))
+		}
+	}
+	for _, algorithm := range policy.Algorithms {
+		filters = append(filters, wrapWithMatcher(fmt.Sprintf("istio.compressor.%s.%s", algorithm, key),
+			"envoy.filters.http.compressor", buildCompressor(algorithm, policy), match))
+	}
+	return filters
+}
+
+func buildCompressor(algorithm string, policy *model.CompressionPolicy) *compressor.Compressor {
+	library := &core.TypedExtensionConfig{Name: "envoy.compression.gzip.compressor"}
+	if algorithm == model.CompressionBrotli {
+		library = &core.TypedExtensionConfig{Name: "envoy.compression.brotli.compressor"}
+		library.TypedConfig = protoconv.MessageToAny(&brotlicompressor.Brotli{})
+	} else {
+		library.TypedConfig = protoconv.MessageToAny(&gzipcompressor.Gzip{})
+	}
+	common := &compressor.Compressor_CommonDirectionConfig{ContentType: policy.ContentTypes}
+	if policy.MinContentLength > 0 {
+		common.MinContentLength = wrapperspb.UInt32(policy.MinContentLength)
+	}
+	return &compressor.Compressor{
+		CompressorLibrary:       library,
+		ResponseDirectionConfig: &compressor.Compressor_ResponseDirectionConfig{CommonConfig: common},
+	}
+}
+
+// buildDecompressor builds a decompressor of request bodies. Responses are not decompressed.
+func buildDecompressor(algorithm string) *decompressor.Decompressor {
+	library := &core.TypedExtensionConfig{Name: "envoy.compression.gzip.decompressor"}
+	if algorithm == model.CompressionBrotli {
+		library = &core.TypedExtensionConfig{Name: "envoy.compression.brotli.decompressor"}
+		library.TypedConfig = protoconv.MessageToAny(&brotlidecompressor.Brotli{})
+	} else {
+		library.TypedConfig = protoconv.MessageToAny(&gzipdecompressor.Gzip{})
+	}
+	return &decompressor.Decompressor{
+		DecompressorLibrary: library,
+		RequestDirectionConfig: &decompressor.Decompressor_RequestDirectionConfig{
+			AdvertiseAcceptEncoding: wrapperspb.Bool(false),
+		},
+		ResponseDirectionConfig: &decompressor.Decompressor_ResponseDirectionConfig{
+			CommonConfig: &decompressor.Decompressor_CommonDirectionConfig{
+				Enabled: &core.RuntimeFeatureFlag{
+					DefaultValue: wrapperspb.Bool(false),
+					RuntimeKey:   "istio.decompressor.response_enabled",
+				},
+			},
+		},
+	}
+}
+
+// wrapWithMatcher returns a filter skipped for the requests not matching the predicate, or applying to all requests
+// when the predicate is nil.
+func wrapWithMatcher(name, extension string, config proto.Message, match *predicate) *hcm.HttpFilter {
+	if match == nil {
+		return &hcm.HttpFilter{
+			Name:       name,
+			ConfigType: &hcm.HttpFilter_TypedConfig{TypedConfig: protoconv.MessageToAny(config)},
+		}
+	}
+	skip := &xdsmatcher.Matcher{
+		MatcherType: &xdsmatcher.Matcher_MatcherList_{MatcherList: &xdsmatcher.Matcher_MatcherList{
+			Matchers: []*xdsmatcher.Matcher_MatcherList_FieldMatcher{{
+				Predicate: &predicate{MatchType: &xdsmatcher.Matcher_MatcherList_Predicate_NotMatcher{NotMatcher: match}},
+				OnMatch: &xdsmatcher.Matcher_OnMatch{OnMatch: &xdsmatcher.Matcher_OnMatch_Action{Action: &xdscore.TypedExtensionConfig{
+					Name:        "skip",
+					TypedConfig: protoconv.MessageToAny(&matcheraction.SkipFilter{}),
+				}}},
+			}},
+		}},
+	}
+	return &hcm.HttpFilter{
+		Name: name,
+		ConfigType: &hcm.HttpFilter_TypedConfig{TypedConfig: protoconv.MessageToAny(&matching.ExtensionWithMatcher{
+			XdsMatcher: skip,
+			ExtensionConfig: &core.TypedExtensionConfig{
+				Name:        extension,
+				TypedConfig: protoconv.MessageToAny(config),
+			},
+		})},
+	}
+}
+
+// compressionPredicate returns the predicate matching the requests to the hosts on the paths of the routes the
+// policy applies to, or nil if it applies to all requests.
+func compressionPredicate(hosts []string, vs *networking.VirtualService, policy *model.CompressionPolicy) *predicate {
+	var hostPredicates []*predicate
+	for _, h := range hosts {
+		if h == "*" {
+			hostPredicates = nil
+			break
+		}
+		hostPredicates = append(hostPredicates, headerPredicate(":authority", regexStringMatcher(hostRegex(h))))
+	}
+
+	allPaths := false
+	var pathPredicates []*predicate
+	for _, route := range vs.Http {
+		if !policy.AppliesTo(route.Name) {
+			continue
+		}
+		if len(route.Match) == 0 {
+			allPaths = true
+		}
+		for _, match := range route.Match {
+			if p := pathPredicate(match.GetUri(), match.GetIgnoreUriCase()); p != nil {
+				pathPredicates = append(pathPredicates, p)
+			} else {
+				allPaths = true
+			}
+		}
+	}
+
+	var predicates []*predicate
+	if len(hostPredicates) > 0 {
+		predicates = append(predicates, combinePredicates(hostPredicates, false))
+	}
+	if !allPaths && len(pathPredicates) > 0 {
+		predicates = append(predicates, combinePredicates(pathPredicates, false))
+	}
+	if len(predicates) == 0 {
+		return nil
+	}
+	return combinePredicates(predicates, true)
+}
+
+// pathPredicate returns the predicate matching the paths matched by a route, or nil if it matches all paths. The
+// path header includes the query, which routes ignore.
+func pathPredicate(uri *networking.StringMatch, ignoreCase bool) *predicate {
+	flags := ""
+	if ignoreCase {
+		flags = "(?i)"
+	}
+	switch m := uri.GetMatchType().(type) {
+	case *networking.StringMatch_Exact:
+		return headerPredicate(":path", regexStringMatcher(flags+regexp.QuoteMeta(m.Exact)+`(\?.*)?`))
+	case *networking.StringMatch_Prefix:
+		if m.Prefix == "" || m.Prefix == "/" {
+			return nil
+		}
+		if ignoreCase {
+			return headerPredicate(":path", regexStringMatcher(flags+regexp.QuoteMeta(m.Prefix)+".*"))
+		}
+		return headerPredicate(":path", &xdsmatcher.StringMatcher{MatchPattern: &xdsmatcher.StringMatcher_Prefix{Prefix: m.Prefix}})
+	case *networking.StringMatch_Regex:
+		return headerPredicate(":path", regexStringMatcher(flags+"(?:"+m.Regex+`)(\?.*)?`))
+	}
+	return nil
+}
+
+// hostRegex returns the regex matching the authority of the requests to a host, which may be a wildcard and may
+// include a port.
+func hostRegex(h string) string {
+	pattern := regexp.QuoteMeta(h)
+	if strings.HasPrefix(h, "*") {
+		pattern = ".+" + regexp.QuoteMeta(strings.TrimPrefix(h, "*"))
+	}
+	return "(?i)" + pattern + "(:[0-9]+)?"
+}
+
+func headerPredicate(header string, m *xdsmatcher.StringMatcher) *predicate {
+	return &predicate{MatchType: &xdsmatcher.Matcher_MatcherList_Predicate_SinglePredicate_{
+		SinglePredicate: &xdsmatcher.Matcher_MatcherList_Predicate_SinglePredicate{
+			Input: &xdscore.TypedExtensionConfig{
+				Name:        "request-headers",
+				TypedConfig: protoconv.MessageToAny(&envoymatcher.HttpRequestHeaderMatchInput{HeaderName: header}),
+			},
+			Matcher: &xdsmatcher.Matcher_MatcherList_Predicate_SinglePredicate_ValueMatch{ValueMatch: m},
+		},
+	}}
+}
+
+func regexStringMatcher(regex string) *xdsmatcher.StringMatcher {
+	return &xdsmatcher.StringMatcher{MatchPattern: &xdsmatcher.StringMatcher_SafeRegex{SafeRegex: &xdsmatcher.RegexMatcher{
+		EngineType: &xdsmatcher.RegexMatcher_GoogleRe2{GoogleRe2: &xdsmatcher.RegexMatcher_GoogleRE2{}},
+		Regex:      regex,
+	}}}
+}
+
+// combinePredicates returns the predicate matching when all (and) or any (or) of the predicates match. Predicate
+// lists must have at least two predicates.
+func combinePredicates(predicates []*predicate, and bool) *predicate {
+	if len(predicates) == 1 {
+		return predicates[0]
+	}
+	list := &xdsmatcher.Matcher_MatcherList_Predicate_PredicateList{Predicate: predicates}
+	if and {
+		return &predicate{MatchType: &xdsmatcher.Matcher_MatcherList_Predicate_AndMatcher{AndMatcher: list}}
+	}
+	return &predicate{MatchType: &xdsmatcher.Matcher_MatcherList_Predicate_OrMatcher{OrMatcher: list}}
+}
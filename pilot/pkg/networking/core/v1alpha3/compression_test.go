@@ -0,0 +1,113 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"testing"
+
+	xdsmatcher "github.com/cncf/xds/go/xds/type/matcher/v3"
+	matching "github.com/envoyproxy/go-control-plane/envoy/extensions/common/matching/v3"
+	compressor "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/compressor/v3"
+	decompressor "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/decompressor/v3"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/test/xdstest"
+)
+
+func TestBuildCompressionFilters(t *testing.T) {
+	vs := &networking.VirtualService{
+		Hosts: []string{"api.example.com"},
+		Http: []*networking.HTTPRoute{
+			{
+				Name: "api",
+				Match: []*networking.HTTPMatchRequest{
+					{Uri: &networking.StringMatch{MatchType: &networking.StringMatch_Prefix{Prefix: "/api"}}},
+					{Uri: &networking.StringMatch{MatchType: &networking.StringMatch_Exact{Exact: "/v1.json"}}},
+				},
+			},
+			{Name: "static"},
+		},
+	}
+
+	t.Run("all routes and hosts", func(t *testing.T) {
+		filters := buildCompressionFilters("ns/vs", []string{"*"}, vs, &model.CompressionPolicy{
+			Algorithms:       []string{model.CompressionGzip},
+			ContentTypes:     []string{"application/json"},
+			MinContentLength: 1024,
+		})
+		if len(filters) != 1 || filters[0].Name != "istio.compressor.gzip.ns/vs" {
+			t.Fatalf("unexpected filters %v", filters)
+		}
+		c := xdstest.UnmarshalAny[compressor.Compressor](t, filters[0].GetTypedConfig())
+		common := c.GetResponseDirectionConfig().GetCommonConfig()
+		if common.GetMinContentLength().GetValue() != 1024 || len(common.GetContentType()) != 1 {
+			t.Fatalf("unexpected compressor config %v", c)
+		}
+	})
+
+	t.Run("route scoped", func(t *testing.T) {
+		filters := buildCompressionFilters("ns/vs", []string{"api.example.com"}, vs, &model.CompressionPolicy{
+			Routes:             []string{"api"},
+			Algorithms:         []string{model.CompressionBrotli},
+			DecompressRequests: true,
+		})
+		if len(filters) != 2 || filters[0].Name != "istio.decompressor.br.ns/vs" || filters[1].Name != "istio.compressor.br.ns/vs" {
+			t.Fatalf("unexpected filters %v", filters)
+		}
+		d := xdstest.UnmarshalAny[matching.ExtensionWithMatcher](t, filters[0].GetTypedConfig())
+		xdstest.UnmarshalAny[decompressor.Decompressor](t, d.GetExtensionConfig().GetTypedConfig())
+		w := xdstest.UnmarshalAny[matching.ExtensionWithMatcher](t, filters[1].GetTypedConfig())
+		xdstest.UnmarshalAny[compressor.Compressor](t, w.GetExtensionConfig().GetTypedConfig())
+
+		// The filter is skipped unless the authority matches the host and the path matches one of the routes.
+		skip := w.GetXdsMatcher().GetMatcherList().GetMatchers()[0].GetPredicate().GetNotMatcher()
+		and := skip.GetAndMatcher().GetPredicate()
+		if len(and) != 2 {
+			t.Fatalf("expected host and path predicates, got %v", skip)
+		}
+		if got := and[0].GetSinglePredicate().GetValueMatch().GetSafeRegex().GetRegex(); got != `(?i)api\.example\.com(:[0-9]+)?` {
+			t.Fatalf("unexpected host regex %q", got)
+		}
+		paths := and[1].GetOrMatcher().GetPredicate()
+		if len(paths) != 2 {
+			t.Fatalf("expected 2 path predicates, got %v", and[1])
+		}
+		if got := paths[0].GetSinglePredicate().GetValueMatch().GetPrefix(); got != "/api" {
+			t.Fatalf("unexpected prefix %q", got)
+		}
+		if got := paths[1].GetSinglePredicate().GetValueMatch().GetSafeRegex().GetRegex(); got != `/v1\.json(\?.*)?` {
+			t.Fatalf("unexpected exact path regex %q", got)
+		}
+	})
+}
+
+func TestCompressionPredicateCatchAll(t *testing.T) {
+	vs := &networking.VirtualService{
+		Http: []*networking.HTTPRoute{{
+			Match: []*networking.HTTPMatchRequest{{Uri: &networking.StringMatch{MatchType: &networking.StringMatch_Prefix{Prefix: "/"}}}},
+		}},
+	}
+	if p := compressionPredicate([]string{"*"}, vs, &model.CompressionPolicy{}); p != nil {
+		t.Fatalf("expected no predicate, got %v", p)
+	}
+	p := compressionPredicate([]string{"*.example.com"}, vs, &model.CompressionPolicy{})
+	if _, ok := p.GetMatchType().(*xdsmatcher.Matcher_MatcherList_Predicate_SinglePredicate_); !ok {
+		t.Fatalf("expected a single host predicate, got %v", p)
+	}
+	if got := p.GetSinglePredicate().GetValueMatch().GetSafeRegex().GetRegex(); got != `(?i).+\.example\.com(:[0-9]+)?` {
+		t.Fatalf("unexpected host regex %q", got)
+	}
+}
@@ -16,8 +16,10 @@ package extension
 
 import (
 	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	extensionsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/wasm/v3"
 	hcm_filter "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	networkwasm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/wasm/v3"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -65,6 +67,31 @@ func toEnvoyHTTPFilter(wasmPlugin *model.WasmPluginWrapper) *hcm_filter.HttpFilt
 	}
 }
 
+// PopAppendNetwork takes a list of network filters and a set of network WASM plugins, keyed by phase. It will remove
+// all plugins of a provided phase from the WASM plugin set and append them to the list of filters
+func PopAppendNetwork(list []*listener.Filter,
+	filterMap map[extensions.PluginPhase][]*model.WasmPluginWrapper,
+	phase extensions.PluginPhase,
+) []*listener.Filter {
+	for _, ext := range filterMap[phase] {
+		list = append(list, toEnvoyNetworkFilter(ext))
+	}
+	delete(filterMap, phase)
+	return list
+}
+
+func toEnvoyNetworkFilter(wasmPlugin *model.WasmPluginWrapper) *listener.Filter {
+	return &listener.Filter{
+		Name: wasmPlugin.ResourceName,
+		ConfigType: &listener.Filter_ConfigDiscovery{
+			ConfigDiscovery: &envoy_config_core_v3.ExtensionConfigSource{
+				ConfigSource: defaultConfigSource,
+				TypeUrls:     []string{xds.WasmNetworkFilterType},
+			},
+		},
+	}
+}
+
 // InsertedExtensionConfigurations returns pre-generated extension configurations added via WasmPlugin.
 func InsertedExtensionConfigurations(
 	wasmPlugins map[extensions.PluginPhase][]*model.WasmPluginWrapper,
@@ -96,7 +123,13 @@ func InsertedExtensionConfigurations(
 					envs[model.WasmSecretEnv] = ""
 				}
 			}
-			typedConfig, err := anypb.New(wasmExtensionConfig)
+			var typedConfig *anypb.Any
+			var err error
+			if p.Type == model.WasmPluginTypeNetwork {
+				typedConfig, err = anypb.New(&networkwasm.Wasm{Config: wasmExtensionConfig.Config})
+			} else {
+				typedConfig, err = anypb.New(wasmExtensionConfig)
+			}
 			if err != nil {
 				log.Warnf("wasmplugin %s/%s failed to marshal to TypedExtensionConfig: %s", p.Namespace, p.Name, err)
 				continue
@@ -19,6 +19,7 @@ import (
 
 	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extensionsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/wasm/v3"
+	networkwasm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/wasm/v3"
 	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/protobuf/testing/protocmp"
@@ -26,6 +27,7 @@ import (
 
 	extensions "istio.io/api/extensions/v1alpha1"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/xds"
 )
 
 var (
@@ -45,10 +47,19 @@ var (
 			Priority: &wrappers.Int64Value{Value: 1000},
 		},
 	}
+	someNetworkFilter = &model.WasmPluginWrapper{
+		Name:                "someNetworkFilter",
+		Namespace:           "istio-system",
+		ResourceName:        "istio-system.someNetworkFilter",
+		Type:                model.WasmPluginTypeNetwork,
+		WasmPlugin:          &extensions.WasmPlugin{},
+		WasmExtensionConfig: &extensionsv3.Wasm{},
+	}
 )
 
 func TestInsertedExtensionConfigurations(t *testing.T) {
 	wasm, _ := anypb.New(&extensionsv3.Wasm{})
+	networkWasm, _ := anypb.New(&networkwasm.Wasm{})
 	testCases := []struct {
 		name        string
 		wasmPlugins map[extensions.PluginPhase][]*model.WasmPluginWrapper
@@ -77,6 +88,21 @@ func TestInsertedExtensionConfigurations(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "network",
+			wasmPlugins: map[extensions.PluginPhase][]*model.WasmPluginWrapper{
+				extensions.PluginPhase_STATS: {
+					someNetworkFilter,
+				},
+			},
+			names: []string{someNetworkFilter.ResourceName},
+			expectedECs: []*envoy_config_core_v3.TypedExtensionConfig{
+				{
+					Name:        "istio-system.someNetworkFilter",
+					TypedConfig: networkWasm,
+				},
+			},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -87,3 +113,23 @@ func TestInsertedExtensionConfigurations(t *testing.T) {
 		})
 	}
 }
+
+func TestPopAppendNetwork(t *testing.T) {
+	wasmPlugins := map[extensions.PluginPhase][]*model.WasmPluginWrapper{
+		extensions.PluginPhase_STATS: {someNetworkFilter},
+	}
+	filters := PopAppendNetwork(nil, wasmPlugins, extensions.PluginPhase_AUTHN)
+	if len(filters) != 0 {
+		t.Fatalf("expected no filters, got %v", filters)
+	}
+	filters = PopAppendNetwork(filters, wasmPlugins, extensions.PluginPhase_STATS)
+	if len(filters) != 1 || filters[0].Name != someNetworkFilter.ResourceName {
+		t.Fatalf("expected filter %s, got %v", someNetworkFilter.ResourceName, filters)
+	}
+	if got := filters[0].GetConfigDiscovery().GetTypeUrls(); len(got) != 1 || got[0] != xds.WasmNetworkFilterType {
+		t.Fatalf("expected type %s, got %v", xds.WasmNetworkFilterType, got)
+	}
+	if _, f := wasmPlugins[extensions.PluginPhase_STATS]; f {
+		t.Fatal("expected the plugins of the phase to be removed")
+	}
+}
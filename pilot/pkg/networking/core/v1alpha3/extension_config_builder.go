@@ -18,8 +18,11 @@ import (
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 
 	"istio.io/istio/pilot/pkg/model"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/envoyfilter"
 	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/extension"
+	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
+	"istio.io/istio/pkg/config/constants"
 )
 
 // BuildExtensionConfiguration returns the list of extension configuration for the given proxy and list of names.
@@ -31,5 +34,39 @@ func (configgen *ConfigGeneratorImpl) BuildExtensionConfiguration(
 	extensions := envoyfilter.InsertedExtensionConfigurations(envoyFilterPatches, extensionConfigNames)
 	wasmPlugins := push.WasmPlugins(proxy)
 	extensions = append(extensions, extension.InsertedExtensionConfigurations(wasmPlugins, extensionConfigNames, pullSecrets)...)
+	extensions = append(extensions, bandwidthLimitExtensionConfigurations(proxy, extensionConfigNames)...)
 	return extensions
 }
+
+// bandwidthLimitAnnotations are the annotations setting the bandwidth limits, by extension config name.
+var bandwidthLimitAnnotations = map[string]string{
+	xdsfilters.EgressBandwidthLimitName:  constants.EgressBandwidthLimitAnnotation,
+	xdsfilters.IngressBandwidthLimitName: constants.IngressBandwidthLimitAnnotation,
+}
+
+// bandwidthLimitName returns the name of the extension config of the bandwidth limit of the listeners of a class.
+func bandwidthLimitName(class istionetworking.ListenerClass) string {
+	switch class {
+	case istionetworking.ListenerClassSidecarOutbound:
+		return xdsfilters.EgressBandwidthLimitName
+	case istionetworking.ListenerClassSidecarInbound:
+		return xdsfilters.IngressBandwidthLimitName
+	}
+	return ""
+}
+
+// bandwidthLimitExtensionConfigurations returns the bandwidth limits of the proxy among the requested names.
+func bandwidthLimitExtensionConfigurations(proxy *model.Proxy, names []string) []*core.TypedExtensionConfig {
+	var out []*core.TypedExtensionConfig
+	for _, name := range names {
+		annotation, ok := bandwidthLimitAnnotations[name]
+		if !ok {
+			continue
+		}
+		// Invalid limits are not referenced by the listeners.
+		if kbps, err := proxy.BandwidthLimit(annotation); err == nil && kbps > 0 {
+			out = append(out, xdsfilters.BuildBandwidthLimitExtensionConfig(name, kbps))
+		}
+	}
+	return out
+}
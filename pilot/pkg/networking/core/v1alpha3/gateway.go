@@ -39,10 +39,12 @@ import (
 	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/tunnelingconfig"
 	"istio.io/istio/pilot/pkg/networking/telemetry"
 	"istio.io/istio/pilot/pkg/networking/util"
+	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/gateway"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/config/security"
 	"istio.io/istio/pkg/proto"
 	"istio.io/istio/pkg/util/istiomultierror"
@@ -201,6 +203,7 @@ func (configgen *ConfigGeneratorImpl) buildGatewayTCPBasedFilterChains(
 		}
 		newFilterChains = append(newFilterChains, istionetworking.FilterChain{
 			ListenerProtocol: istionetworking.ListenerProtocolHTTP,
+			TCP:              buildGatewayConnectionRateLimitFilters(mergedGateway, serversForPort.Servers, port.Number),
 		})
 	} else {
 		// build http connection manager with TLS context, for HTTPS servers using simple/mutual TLS
@@ -217,6 +220,7 @@ func (configgen *ConfigGeneratorImpl) buildGatewayTCPBasedFilterChains(
 					routeName, proxyConfig, istionetworking.TransportProtocolTCP, builder.push))
 				newFilterChains = append(newFilterChains, istionetworking.FilterChain{
 					ListenerProtocol: istionetworking.ListenerProtocolHTTP,
+					TCP:              buildGatewayConnectionRateLimitFilters(mergedGateway, []*networking.Server{server}, port.Number),
 				})
 			} else {
 				// This is the case of TCP or PASSTHROUGH.
@@ -226,6 +230,7 @@ func (configgen *ConfigGeneratorImpl) buildGatewayTCPBasedFilterChains(
 				for i := 0; i < len(tcpChainOpts); i++ {
 					newFilterChains = append(newFilterChains, istionetworking.FilterChain{
 						ListenerProtocol: istionetworking.ListenerProtocolTCP,
+						TCP:              buildGatewayConnectionRateLimitFilters(mergedGateway, []*networking.Server{server}, port.Number),
 					})
 				}
 			}
@@ -236,6 +241,26 @@ func (configgen *ConfigGeneratorImpl) buildGatewayTCPBasedFilterChains(
 	return newFilterChains
 }
 
+// buildGatewayConnectionRateLimitFilters returns the filters limiting the rate of the new connections of a filter chain
+// of the servers, which is the most restrictive limit of the servers sharing a plain text HTTP filter chain. The
+// stats of the limit are named after the server, or after the port for the servers sharing a filter chain.
+func buildGatewayConnectionRateLimitFilters(mergedGateway *model.MergedGateway, servers []*networking.Server, port uint32) []*listener.Filter {
+	var limit *model.ConnectionRateLimit
+	for _, s := range servers {
+		if l := mergedGateway.ConnectionRateLimits[s]; l != nil && (limit == nil || l.PerSecond() < limit.PerSecond()) {
+			limit = l
+		}
+	}
+	if limit == nil {
+		return nil
+	}
+	statPrefix := "connection_rate_limit_" + strconv.Itoa(int(port))
+	if len(servers) == 1 && servers[0].Name != "" {
+		statPrefix = "connection_rate_limit_" + servers[0].Name
+	}
+	return []*listener.Filter{xdsfilters.BuildConnectionRateLimitFilter(limit, statPrefix)}
+}
+
 func (configgen *ConfigGeneratorImpl) buildGatewayHTTP3FilterChains(
 	builder *ListenerBuilder,
 	serversForPort *model.MergedServers,
@@ -357,6 +382,9 @@ func (configgen *ConfigGeneratorImpl) buildGatewayHTTPRouteConfig(node *model.Pr
 	gatewayRoutes := make(map[string]map[string][]*route.Route)
 	gatewayVirtualServices := make(map[string][]config.Config)
 	vHostDedupMap := make(map[host.Name]*route.VirtualHost)
+	// vHostRoutes keeps the routes of each virtual service merged in a virtual host, to order them by the priority
+	// of their virtual service when the host is bound by the virtual services of several gateways.
+	vHostRoutes := make(map[host.Name][]prioritizedRoutes)
 	for _, server := range servers {
 		gatewayName := merged.GatewayNameForServer[server]
 		port := int(server.Port.Number)
@@ -404,7 +432,9 @@ func (configgen *ConfigGeneratorImpl) buildGatewayHTTPRouteConfig(node *model.Pr
 				gatewayRoutes[gatewayName][vskey] = routes
 			}
 
+			priority := model.VirtualServicePriority(virtualService)
 			for _, hostname := range intersectingHosts {
+				vHostRoutes[hostname] = append(vHostRoutes[hostname], prioritizedRoutes{priority: priority, routes: routes})
 				if vHost, exists := vHostDedupMap[hostname]; exists {
 					vHost.Routes = append(vHost.Routes, routes...)
 					if server.Tls != nil && server.Tls.HttpsRedirect {
@@ -445,6 +475,12 @@ func (configgen *ConfigGeneratorImpl) buildGatewayHTTPRouteConfig(node *model.Pr
 		}
 	}
 
+	for hostname, prioritized := range vHostRoutes {
+		if len(prioritized) > 1 {
+			vHostDedupMap[hostname].Routes = mergePrioritizedRoutes(prioritized)
+		}
+	}
+
 	var virtualHosts []*route.VirtualHost
 	if len(vHostDedupMap) == 0 {
 		port := int(servers[0].Port.Number)
@@ -593,6 +629,7 @@ func (configgen *ConfigGeneratorImpl) createGatewayHTTPFilterChainOpts(node *mod
 		// Validation is done per gateway and also during merging
 		sniHosts:   node.MergedGateway.TLSServerInfo[server].SNIHosts,
 		tlsContext: buildGatewayListenerTLSContext(server, node, transportProtocol),
+		metadata:   buildGatewayConfigInfoMetadata(node.MergedGateway.GatewayNameForServer[server]),
 		httpOpts: &httpListenerOpts{
 			rds:               routeName,
 			useRemoteAddress:  true,
@@ -711,6 +748,7 @@ func (configgen *ConfigGeneratorImpl) createGatewayTCPFilterChainOpts(
 				{
 					sniHosts:       nil,
 					tlsContext:     nil,
+					metadata:       buildGatewayConfigInfoMetadata(gatewayName),
 					networkFilters: filters,
 				},
 			}
@@ -725,6 +763,7 @@ func (configgen *ConfigGeneratorImpl) createGatewayTCPFilterChainOpts(
 				{
 					sniHosts:       node.MergedGateway.TLSServerInfo[server].SNIHosts,
 					tlsContext:     buildGatewayListenerTLSContext(server, node, istionetworking.TransportProtocolTCP),
+					metadata:       buildGatewayConfigInfoMetadata(gatewayName),
 					networkFilters: filters,
 				},
 			}
@@ -737,6 +776,35 @@ func (configgen *ConfigGeneratorImpl) createGatewayTCPFilterChainOpts(
 	return []*filterChainOpts{}
 }
 
+// prioritizedRoutes are the routes of a virtual service, with the priority of the virtual service.
+type prioritizedRoutes struct {
+	priority int
+	routes   []*route.Route
+}
+
+// mergePrioritizedRoutes merges the routes of the virtual services bound to a host by decreasing priority of their
+// virtual service, keeping the order of the routes of virtual services of the same priority.
+func mergePrioritizedRoutes(prioritized []prioritizedRoutes) []*route.Route {
+	sort.SliceStable(prioritized, func(i, j int) bool {
+		return prioritized[i].priority > prioritized[j].priority
+	})
+	var out []*route.Route
+	for _, p := range prioritized {
+		out = append(out, p.routes...)
+	}
+	return out
+}
+
+// buildGatewayConfigInfoMetadata returns the metadata referencing the Gateway of a filter chain, from its
+// namespace/name, so that the filter chains of a server can be traced back to their Gateway.
+func buildGatewayConfigInfoMetadata(gatewayName string) *core.Metadata {
+	namespace, name, ok := strings.Cut(gatewayName, "/")
+	if !ok {
+		return nil
+	}
+	return util.BuildConfigInfoMetadata(config.Meta{GroupVersionKind: gvk.Gateway, Namespace: namespace, Name: name})
+}
+
 // buildGatewayNetworkFiltersFromTCPRoutes builds tcp proxy routes for all VirtualServices with TCP blocks.
 // It first obtains all virtual services bound to the set of Gateways for this workload, filters them by this
 // server's port and hostnames, and produces network filters for each destination from the filtered services.
@@ -894,8 +962,9 @@ func builtAutoPassthroughFilterChains(push *model.PushContext, proxy *model.Prox
 			if len(push.Mesh.OutboundClusterStatName) != 0 {
 				statPrefix = telemetry.BuildStatPrefix(push.Mesh.OutboundClusterStatName, string(service.Hostname), "", port, &service.Attributes)
 			}
-			destinationRule := CastDestinationRule(proxy.SidecarScope.DestinationRule(
-				model.TrafficDirectionOutbound, proxy, service.Hostname).GetRule())
+			destinationRuleConfig := proxy.SidecarScope.DestinationRule(
+				model.TrafficDirectionOutbound, proxy, service.Hostname).GetRule()
+			destinationRule := CastDestinationRule(destinationRuleConfig)
 
 			// First, we build the standard cluster. We match on the SNI matching the cluster name
 			// (per the spec of AUTO_PASSTHROUGH), as well as all possible Istio mTLS ALPNs. This,
@@ -908,7 +977,7 @@ func builtAutoPassthroughFilterChains(push *model.PushContext, proxy *model.Prox
 				match:      &listener.FilterChainMatch{ApplicationProtocols: allIstioMtlsALPNs},
 				tlsContext: nil, // NO TLS context because this is passthrough
 				networkFilters: buildOutboundNetworkFiltersWithSingleDestination(
-					push, proxy, statPrefix, clusterName, "", port, destinationRule, tunnelingconfig.Skip),
+					push, proxy, statPrefix, clusterName, "", port, destinationRuleConfig, tunnelingconfig.Skip),
 			})
 
 			// Do the same, but for each subset
@@ -924,7 +993,7 @@ func builtAutoPassthroughFilterChains(push *model.PushContext, proxy *model.Prox
 					match:      &listener.FilterChainMatch{ApplicationProtocols: allIstioMtlsALPNs},
 					tlsContext: nil, // NO TLS context because this is passthrough
 					networkFilters: buildOutboundNetworkFiltersWithSingleDestination(
-						push, proxy, subsetStatPrefix, subsetClusterName, subset.Name, port, destinationRule, tunnelingconfig.Skip),
+						push, proxy, subsetStatPrefix, subsetClusterName, subset.Name, port, destinationRuleConfig, tunnelingconfig.Skip),
 				})
 			}
 		}
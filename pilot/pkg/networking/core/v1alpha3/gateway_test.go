@@ -23,6 +23,7 @@ import (
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	localratelimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/local_ratelimit/v3"
 	auth "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/google/go-cmp/cmp"
@@ -42,6 +43,7 @@ import (
 	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
 	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/schema/gvk"
@@ -844,6 +846,48 @@ func TestBuildGatewayListenerTlsContext(t *testing.T) {
 				RequireClientCertificate: proto.BoolFalse,
 			},
 		},
+		{
+			// without a credentialName or certificate files, the certificate issued to the proxy is served
+			name: "https server, tls SIMPLE with the workload certificate",
+			server: &networking.Server{
+				Hosts: []string{"httpbin.example.com"},
+				Port: &networking.Port{
+					Protocol: string(protocol.HTTPS),
+				},
+				Tls: &networking.ServerTLSSettings{
+					Mode: networking.ServerTLSSettings_SIMPLE,
+				},
+			},
+			result: &auth.DownstreamTlsContext{
+				CommonTlsContext: &auth.CommonTlsContext{
+					AlpnProtocols: util.ALPNHttp,
+					TlsCertificateSdsSecretConfigs: []*auth.SdsSecretConfig{
+						{
+							Name: "default",
+							SdsConfig: &core.ConfigSource{
+								InitialFetchTimeout: durationpb.New(time.Second * 0),
+								ResourceApiVersion:  core.ApiVersion_V3,
+								ConfigSourceSpecifier: &core.ConfigSource_ApiConfigSource{
+									ApiConfigSource: &core.ApiConfigSource{
+										ApiType:                   core.ApiConfigSource_GRPC,
+										SetNodeOnFirstMessageOnly: true,
+										TransportApiVersion:       core.ApiVersion_V3,
+										GrpcServices: []*core.GrpcService{
+											{
+												TargetSpecifier: &core.GrpcService_EnvoyGrpc_{
+													EnvoyGrpc: &core.GrpcService_EnvoyGrpc{ClusterName: model.SDSClusterName},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				RequireClientCertificate: proto.BoolFalse,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -2627,6 +2671,75 @@ func TestBuildGatewayListenersFilters(t *testing.T) {
 			},
 			expectedNetworkFilters: []string{wellknown.HTTPConnectionManager},
 		},
+		{
+			name: "http server with connection rate limit",
+			gateways: []config.Config{
+				{
+					Meta: config.Meta{
+						Name:             "http-server",
+						Namespace:        "testns",
+						GroupVersionKind: gvk.Gateway,
+						Annotations:      map[string]string{constants.ConnectionRateLimitAnnotation: `{"*": {"connections": 100}}`},
+					},
+					Spec: &networking.Gateway{
+						Servers: []*networking.Server{
+							{
+								Port: &networking.Port{Name: "http", Number: 80, Protocol: "HTTP"},
+							},
+						},
+					},
+				},
+			},
+			virtualServices: nil,
+			expectedHTTPFilters: []string{
+				xdsfilters.MxFilterName,
+				xdsfilters.Alpn.GetName(),
+				xdsfilters.Fault.GetName(), xdsfilters.Cors.GetName(), xdsfilters.Router.GetName(),
+			},
+			expectedNetworkFilters: []string{xdsfilters.ConnectionRateLimitFilterName, wellknown.HTTPConnectionManager},
+		},
+		{
+			name: "http server with compression",
+			gateways: []config.Config{
+				{
+					Meta: config.Meta{Name: "http-server", Namespace: "testns", GroupVersionKind: gvk.Gateway},
+					Spec: &networking.Gateway{
+						Servers: []*networking.Server{
+							{
+								Port:  &networking.Port{Name: "http", Number: 80, Protocol: "HTTP"},
+								Hosts: []string{"*.example.com"},
+							},
+						},
+					},
+				},
+			},
+			virtualServices: []config.Config{
+				{
+					Meta: config.Meta{
+						Name:             "api",
+						Namespace:        "testns",
+						GroupVersionKind: gvk.VirtualService,
+						Annotations:      map[string]string{constants.CompressionAnnotation: `{"algorithms": ["br", "gzip"]}`},
+					},
+					Spec: &networking.VirtualService{
+						Gateways: []string{"testns/http-server"},
+						Hosts:    []string{"api.example.com"},
+						Http: []*networking.HTTPRoute{
+							{
+								Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "api.testns.svc.cluster.local"}}},
+							},
+						},
+					},
+				},
+			},
+			expectedHTTPFilters: []string{
+				xdsfilters.MxFilterName,
+				xdsfilters.Alpn.GetName(),
+				"istio.compressor.br.testns/api", "istio.compressor.gzip.testns/api",
+				xdsfilters.Fault.GetName(), xdsfilters.Cors.GetName(), xdsfilters.Router.GetName(),
+			},
+			expectedNetworkFilters: []string{wellknown.HTTPConnectionManager},
+		},
 		{
 			name: "passthrough server",
 			gateways: []config.Config{
@@ -2760,6 +2873,50 @@ func TestBuildGatewayListenersFilters(t *testing.T) {
 			expectedHTTPFilters:    []string{},
 			expectedNetworkFilters: []string{xdsfilters.TCPListenerMx.GetName(), wellknown.TCPProxy},
 		},
+		{
+			name: "tcp server with connection rate limit",
+			gateways: []config.Config{
+				{
+					Meta: config.Meta{
+						Name:             "tcp-gateway",
+						Namespace:        "testns",
+						GroupVersionKind: gvk.Gateway,
+						Annotations:      map[string]string{constants.ConnectionRateLimitAnnotation: `{"mysql": {"connections": 10}}`},
+					},
+					Spec: &networking.Gateway{
+						Servers: []*networking.Server{
+							{
+								Name:  "mysql",
+								Port:  &networking.Port{Name: "tcp", Number: 3306, Protocol: "TCP"},
+								Hosts: []string{"*"},
+							},
+						},
+					},
+				},
+			},
+			virtualServices: []config.Config{
+				{
+					Meta: config.Meta{Name: uuid.NewString(), Namespace: uuid.NewString(), GroupVersionKind: gvk.VirtualService},
+					Spec: &networking.VirtualService{
+						Gateways: []string{"testns/tcp-gateway"},
+						Hosts:    []string{"*"},
+						Tcp: []*networking.TCPRoute{
+							{
+								Route: []*networking.RouteDestination{
+									{
+										Destination: &networking.Destination{
+											Host: "mysql.default.svc.cluster.local",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedHTTPFilters:    []string{},
+			expectedNetworkFilters: []string{xdsfilters.ConnectionRateLimitFilterName, wellknown.TCPProxy},
+		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -2852,3 +3009,169 @@ func TestGatewayHCMInternalAddressConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildGatewayConnectionRateLimitFilters(t *testing.T) {
+	api := &networking.Server{Name: "api", Port: &networking.Port{Number: 80}}
+	web := &networking.Server{Port: &networking.Port{Number: 80}}
+	admin := &networking.Server{Name: "admin", Port: &networking.Port{Number: 80}}
+	mergedGateway := &pilot_model.MergedGateway{
+		ConnectionRateLimits: map[*networking.Server]*pilot_model.ConnectionRateLimit{
+			api: {Connections: 100, FillInterval: time.Second, Burst: 100},
+			// 6 connections per second, more restrictive than the 100 per second of api.
+			web: {Connections: 60, FillInterval: 10 * time.Second, Burst: 60},
+		},
+	}
+	cases := []struct {
+		name           string
+		servers        []*networking.Server
+		wantStatPrefix string
+		wantTokens     uint32
+	}{
+		{name: "no limit", servers: []*networking.Server{admin}},
+		{name: "named server", servers: []*networking.Server{api}, wantStatPrefix: "connection_rate_limit_api", wantTokens: 100},
+		{name: "unnamed server", servers: []*networking.Server{web}, wantStatPrefix: "connection_rate_limit_80", wantTokens: 60},
+		{name: "shared filter chain", servers: []*networking.Server{api, web, admin}, wantStatPrefix: "connection_rate_limit_80", wantTokens: 60},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			filters := buildGatewayConnectionRateLimitFilters(mergedGateway, tt.servers, 80)
+			if tt.wantStatPrefix == "" {
+				if len(filters) != 0 {
+					t.Fatalf("expected no filters, got %v", filters)
+				}
+				return
+			}
+			if len(filters) != 1 || filters[0].Name != xdsfilters.ConnectionRateLimitFilterName {
+				t.Fatalf("expected a connection rate limit filter, got %v", filters)
+			}
+			limit := &localratelimit.LocalRateLimit{}
+			if err := filters[0].GetTypedConfig().UnmarshalTo(limit); err != nil {
+				t.Fatal(err)
+			}
+			if limit.StatPrefix != tt.wantStatPrefix {
+				t.Errorf("got stat prefix %q, want %q", limit.StatPrefix, tt.wantStatPrefix)
+			}
+			if got := limit.TokenBucket.GetTokensPerFill().GetValue(); got != tt.wantTokens {
+				t.Errorf("got %d tokens per fill, want %d", got, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestBuildGatewayListenersConfigInfoMetadata(t *testing.T) {
+	gw := config.Config{
+		Meta: config.Meta{Name: "ingress", Namespace: "istio-system", GroupVersionKind: gvk.Gateway},
+		Spec: &networking.Gateway{
+			Servers: []*networking.Server{
+				{
+					Hosts: []string{"example.org"},
+					Port:  &networking.Port{Name: "https", Number: 443, Protocol: "HTTPS"},
+					Tls:   &networking.ServerTLSSettings{Mode: networking.ServerTLSSettings_SIMPLE, CredentialName: "example-cert"},
+				},
+			},
+		},
+	}
+	cg := NewConfigGenTest(t, TestOptions{Configs: []config.Config{gw}})
+	proxy := cg.SetupProxy(&proxyGateway)
+	proxy.Metadata = &proxyGatewayMetadata
+	builder := cg.ConfigGen.buildGatewayListeners(NewListenerBuilder(proxy, cg.PushContext()))
+	if len(builder.gatewayListeners) != 1 || len(builder.gatewayListeners[0].FilterChains) != 1 {
+		t.Fatalf("expected a listener with a filter chain, got %v", builder.gatewayListeners)
+	}
+	metadata := builder.gatewayListeners[0].FilterChains[0].GetMetadata().GetFilterMetadata()[util.IstioMetadataKey]
+	want := "/apis/networking.istio.io/v1alpha3/namespaces/istio-system/gateway/ingress"
+	if got := metadata.GetFields()["config"].GetStringValue(); got != want {
+		t.Errorf("expected the filter chain to reference its Gateway %s, got %q", want, got)
+	}
+}
+
+func TestGatewayHTTPRouteConfigPriority(t *testing.T) {
+	gateway := func(name string) config.Config {
+		return config.Config{
+			Meta: config.Meta{Name: name, Namespace: "default", GroupVersionKind: gvk.Gateway},
+			Spec: &networking.Gateway{
+				Selector: map[string]string{"istio": "ingressgateway"},
+				Servers: []*networking.Server{{
+					Hosts: []string{"example.org"},
+					Port:  &networking.Port{Name: "http", Number: 80, Protocol: "HTTP"},
+				}},
+			},
+		}
+	}
+	virtualService := func(name, gateway, priority string, created time.Time) config.Config {
+		cfg := config.Config{
+			Meta: config.Meta{
+				Name: name, Namespace: "default", GroupVersionKind: gvk.VirtualService, CreationTimestamp: created,
+			},
+			Spec: &networking.VirtualService{
+				Hosts:    []string{"example.org"},
+				Gateways: []string{gateway},
+				Http: []*networking.HTTPRoute{{
+					Name: name,
+					Match: []*networking.HTTPMatchRequest{{
+						Uri: &networking.StringMatch{MatchType: &networking.StringMatch_Prefix{Prefix: "/" + name}},
+					}},
+					Route: []*networking.HTTPRouteDestination{{
+						Destination: &networking.Destination{Host: "example.org", Port: &networking.PortSelector{Number: 80}},
+					}},
+				}},
+			},
+		}
+		if priority != "" {
+			cfg.Annotations = map[string]string{constants.RoutePriorityAnnotation: priority}
+		}
+		return cfg
+	}
+	now := time.Now()
+	cases := []struct {
+		name  string
+		cfgs  []config.Config
+		order []string
+	}{
+		{
+			name: "creation time",
+			cfgs: []config.Config{
+				gateway("gateway"),
+				virtualService("new", "gateway", "", now),
+				virtualService("old", "gateway", "", now.Add(-time.Hour)),
+			},
+			order: []string{"old", "new"},
+		},
+		{
+			name: "priority",
+			cfgs: []config.Config{
+				gateway("gateway"),
+				virtualService("new", "gateway", "10", now),
+				virtualService("old", "gateway", "", now.Add(-time.Hour)),
+				virtualService("low", "gateway", "-1", now.Add(-2*time.Hour)),
+			},
+			order: []string{"new", "old", "low"},
+		},
+		{
+			name: "priority across gateways",
+			cfgs: []config.Config{
+				gateway("gateway-a"),
+				gateway("gateway-b"),
+				virtualService("old", "gateway-a", "", now.Add(-time.Hour)),
+				virtualService("new", "gateway-b", "10", now),
+			},
+			order: []string{"new", "old"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			cg := NewConfigGenTest(t, TestOptions{Configs: tt.cfgs})
+			r := cg.ConfigGen.buildGatewayHTTPRouteConfig(cg.SetupProxy(&proxyGateway), cg.PushContext(), "http.80")
+			if len(r.VirtualHosts) != 1 {
+				t.Fatalf("expected a virtual host, got %v", r.VirtualHosts)
+			}
+			var order []string
+			for _, route := range r.VirtualHosts[0].Routes {
+				order = append(order, route.Name)
+			}
+			if !reflect.DeepEqual(order, tt.order) {
+				t.Errorf("expected the routes in order %v, got %v", tt.order, order)
+			}
+		})
+	}
+}
@@ -354,7 +354,7 @@ func (lb *ListenerBuilder) buildHTTPConnectionManager(httpOpts *httpListenerOpts
 	routerFilterCtx, reqIDExtensionCtx := configureTracing(lb.push, lb.node, connectionManager, httpOpts.class)
 
 	filters := []*hcm.HttpFilter{}
-	wasm := lb.push.WasmPlugins(lb.node)
+	wasm := lb.push.WasmPluginsByType(lb.node, model.WasmPluginTypeHTTP)
 	// TODO: how to deal with ext-authz? It will be in the ordering twice
 	filters = append(filters, lb.authzCustomBuilder.BuildHTTP(httpOpts.class)...)
 	filters = extension.PopAppend(filters, wasm, extensions.PluginPhase_AUTHN)
@@ -385,6 +385,18 @@ func (lb *ListenerBuilder) buildHTTPConnectionManager(httpOpts *httpListenerOpts
 		}
 	}
 
+	if name := bandwidthLimitName(httpOpts.class); name != "" {
+		if kbps, err := lb.node.BandwidthLimit(bandwidthLimitAnnotations[name]); err != nil {
+			log.Warnf("ignoring %s annotation of proxy %s: %v", bandwidthLimitAnnotations[name], lb.node.ID, err)
+		} else if kbps > 0 {
+			filters = append(filters, xdsfilters.BuildBandwidthLimitFilter(name))
+		}
+	}
+
+	if httpOpts.class == istionetworking.ListenerClassGateway && httpOpts.rds != "" {
+		filters = append(filters, buildGatewayCompressionFilters(lb.node, lb.push, httpOpts.rds)...)
+	}
+
 	// TypedPerFilterConfig in route needs these filters.
 	filters = append(filters, xdsfilters.Fault, xdsfilters.Cors)
 	filters = append(filters, lb.push.Telemetry.HTTPFilters(lb.node, httpOpts.class)...)
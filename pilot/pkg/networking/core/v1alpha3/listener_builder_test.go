@@ -22,6 +22,7 @@ import (
 
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	bandwidthlimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/bandwidth_limit/v3"
 	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
@@ -33,10 +34,13 @@ import (
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
+	istionetworking "istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pilot/pkg/networking/plugin/authz"
 	"istio.io/istio/pilot/pkg/networking/util"
+	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
 	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/test"
@@ -882,3 +886,97 @@ func TestHCMInternalAddressConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestHCMBandwidthLimit(t *testing.T) {
+	cases := []struct {
+		name       string
+		annotation string
+		limit      string
+		class      istionetworking.ListenerClass
+		want       string
+	}{
+		{
+			name:       "outbound",
+			annotation: constants.EgressBandwidthLimitAnnotation,
+			limit:      "10Mbps",
+			class:      istionetworking.ListenerClassSidecarOutbound,
+			want:       xdsfilters.EgressBandwidthLimitName,
+		},
+		{
+			name:       "inbound",
+			annotation: constants.IngressBandwidthLimitAnnotation,
+			limit:      "10Mbps",
+			class:      istionetworking.ListenerClassSidecarInbound,
+			want:       xdsfilters.IngressBandwidthLimitName,
+		},
+		{
+			name:       "egress limit on inbound",
+			annotation: constants.EgressBandwidthLimitAnnotation,
+			limit:      "10Mbps",
+			class:      istionetworking.ListenerClassSidecarInbound,
+		},
+		{
+			name:       "gateway",
+			annotation: constants.EgressBandwidthLimitAnnotation,
+			limit:      "10Mbps",
+			class:      istionetworking.ListenerClassGateway,
+		},
+		{
+			name:       "invalid",
+			annotation: constants.EgressBandwidthLimitAnnotation,
+			limit:      "10MB",
+			class:      istionetworking.ListenerClassSidecarOutbound,
+		},
+		{
+			name:  "unset",
+			class: istionetworking.ListenerClassSidecarOutbound,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			cg := NewConfigGenTest(t, TestOptions{})
+			proxy := &model.Proxy{ConfigNamespace: "default", Metadata: &model.NodeMetadata{}}
+			if tt.limit != "" {
+				proxy.Metadata.Annotations = map[string]string{tt.annotation: tt.limit}
+			}
+			lb := &ListenerBuilder{
+				push:               cg.PushContext(),
+				node:               cg.SetupProxy(proxy),
+				authzCustomBuilder: &authz.Builder{},
+				authzBuilder:       &authz.Builder{},
+			}
+			httpConnManager := lb.buildHTTPConnectionManager(&httpListenerOpts{class: tt.class})
+			got := ""
+			for _, f := range httpConnManager.HttpFilters {
+				if f.Name == xdsfilters.EgressBandwidthLimitName || f.Name == xdsfilters.IngressBandwidthLimitName {
+					if f.GetConfigDiscovery().GetConfigSource().GetAds() == nil {
+						t.Errorf("expected the bandwidth limit to be fetched with ECDS: %v", f)
+					}
+					got = f.Name
+				}
+			}
+			if got != tt.want {
+				t.Errorf("got bandwidth limit %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBandwidthLimitExtensionConfiguration(t *testing.T) {
+	cg := NewConfigGenTest(t, TestOptions{})
+	proxy := cg.SetupProxy(&model.Proxy{ConfigNamespace: "default", Metadata: &model.NodeMetadata{
+		Annotations: map[string]string{constants.EgressBandwidthLimitAnnotation: "10Mbps"},
+	}})
+	ecs := cg.ConfigGen.BuildExtensionConfiguration(proxy, cg.PushContext(),
+		[]string{xdsfilters.EgressBandwidthLimitName, xdsfilters.IngressBandwidthLimitName}, nil)
+	if len(ecs) != 1 || ecs[0].Name != xdsfilters.EgressBandwidthLimitName {
+		t.Fatalf("expected the egress bandwidth limit only, got %v", ecs)
+	}
+	bl := &bandwidthlimit.BandwidthLimit{}
+	if err := ecs[0].TypedConfig.UnmarshalTo(bl); err != nil {
+		t.Fatal(err)
+	}
+	if bl.EnableMode != bandwidthlimit.BandwidthLimit_REQUEST || bl.LimitKbps.GetValue() != 1221 {
+		t.Errorf("unexpected bandwidth limit %v", bl)
+	}
+}
@@ -15,6 +15,7 @@
 package v1alpha3
 
 import (
+	"strconv"
 	"time"
 
 	mysql "github.com/envoyproxy/go-control-plane/contrib/envoy/extensions/filters/network/mysql_proxy/v3"
@@ -25,11 +26,14 @@ import (
 	hashpolicy "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"google.golang.org/protobuf/types/known/durationpb"
+	wrappers "google.golang.org/protobuf/types/known/wrapperspb"
 
+	extensions "istio.io/api/extensions/v1alpha1"
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	istionetworking "istio.io/istio/pilot/pkg/networking"
+	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/extension"
 	istioroute "istio.io/istio/pilot/pkg/networking/core/v1alpha3/route"
 	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/tunnelingconfig"
 	"istio.io/istio/pilot/pkg/networking/telemetry"
@@ -37,8 +41,10 @@ import (
 	"istio.io/istio/pilot/pkg/util/protoconv"
 	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/protocol"
+	"istio.io/pkg/log"
 )
 
 // redisOpTimeout is the default operation timeout for the Redis proxy filter.
@@ -83,28 +89,28 @@ func setAccessLogAndBuildTCPFilter(push *model.PushContext, node *model.Proxy, c
 // buildOutboundNetworkFiltersWithSingleDestination takes a single cluster name
 // and builds a stack of network filters.
 func buildOutboundNetworkFiltersWithSingleDestination(push *model.PushContext, node *model.Proxy,
-	statPrefix, clusterName, subsetName string, port *model.Port, destinationRule *networking.DestinationRule, applyTunnelingConfig tunnelingconfig.ApplyFunc,
+	statPrefix, clusterName, subsetName string, port *model.Port, destinationRuleConfig *config.Config, applyTunnelingConfig tunnelingconfig.ApplyFunc,
 ) []*listener.Filter {
+	destinationRule := CastDestinationRule(destinationRuleConfig)
 	tcpProxy := &tcp.TcpProxy{
 		StatPrefix:       statPrefix,
 		ClusterSpecifier: &tcp.TcpProxy_Cluster{Cluster: clusterName},
 	}
 
-	idleTimeout, err := time.ParseDuration(node.Metadata.IdleTimeout)
-	if err == nil {
-		tcpProxy.IdleTimeout = durationpb.New(idleTimeout)
-	}
+	setIdleTimeout(tcpProxy, node, clusterIdleTimeout(push, node, clusterName))
 	maxConnectionDuration := destinationRule.GetTrafficPolicy().GetConnectionPool().GetTcp().GetMaxConnectionDuration()
 	if maxConnectionDuration != nil {
 		tcpProxy.MaxDownstreamConnectionDuration = maxConnectionDuration
 	}
 	maybeSetHashPolicy(destinationRule, tcpProxy, subsetName)
+	maybeSetMaxConnectAttempts(destinationRuleConfig, tcpProxy)
 	applyTunnelingConfig(tcpProxy, destinationRule, subsetName)
 	class := model.OutboundListenerClass(node.Type)
 	tcpFilter := setAccessLogAndBuildTCPFilter(push, node, tcpProxy, class)
 
 	var filters []*listener.Filter
 	filters = append(filters, buildMetadataExchangeNetworkFilters(class)...)
+	filters = appendNetworkWasmPlugins(filters, push, node)
 	filters = append(filters, buildMetricsNetworkFilters(push, node, class)...)
 	filters = append(filters, buildNetworkFiltersStack(port.Protocol, tcpFilter, statPrefix, clusterName)...)
 	return filters
@@ -113,8 +119,9 @@ func buildOutboundNetworkFiltersWithSingleDestination(push *model.PushContext, n
 // buildOutboundNetworkFiltersWithWeightedClusters takes a set of weighted
 // destination routes and builds a stack of network filters.
 func buildOutboundNetworkFiltersWithWeightedClusters(node *model.Proxy, routes []*networking.RouteDestination,
-	push *model.PushContext, port *model.Port, configMeta config.Meta, destinationRule *networking.DestinationRule,
+	push *model.PushContext, port *model.Port, configMeta config.Meta, destinationRuleConfig *config.Config,
 ) []*listener.Filter {
+	destinationRule := CastDestinationRule(destinationRuleConfig)
 	statPrefix := configMeta.Name + "." + configMeta.Namespace
 	clusterSpecifier := &tcp.TcpProxy_WeightedClusters{
 		WeightedClusters: &tcp.TcpProxy_WeightedCluster{},
@@ -125,15 +132,13 @@ func buildOutboundNetworkFiltersWithWeightedClusters(node *model.Proxy, routes [
 		ClusterSpecifier: clusterSpecifier,
 	}
 
-	idleTimeout, err := time.ParseDuration(node.Metadata.IdleTimeout)
-	if err == nil {
-		tcpProxy.IdleTimeout = durationpb.New(idleTimeout)
-	}
 	maxConnectionDuration := destinationRule.GetTrafficPolicy().GetConnectionPool().GetTcp().GetMaxConnectionDuration()
 	if maxConnectionDuration != nil {
 		tcpProxy.MaxDownstreamConnectionDuration = maxConnectionDuration
 	}
 
+	// The connections of all the destinations share the idle timeout, so use the longest one set for them.
+	var idleTimeout time.Duration
 	for _, route := range routes {
 		service := push.ServiceForHostname(node, host.Name(route.Destination.Host))
 		if route.Weight > 0 {
@@ -142,11 +147,16 @@ func buildOutboundNetworkFiltersWithWeightedClusters(node *model.Proxy, routes [
 				Name:   clusterName,
 				Weight: uint32(route.Weight),
 			})
+			if timeout := clusterIdleTimeout(push, node, clusterName); timeout > idleTimeout {
+				idleTimeout = timeout
+			}
 		}
 	}
+	setIdleTimeout(tcpProxy, node, idleTimeout)
 
 	// For weighted clusters set hash policy if any of the upstream destinations have sourceIP.
 	maybeSetHashPolicy(destinationRule, tcpProxy, "")
+	maybeSetMaxConnectAttempts(destinationRuleConfig, tcpProxy)
 	// In case of weighted clusters, tunneling config for a subset is ignored,
 	// because it is set on listener, not on a cluster.
 	tunnelingconfig.Apply(tcpProxy, destinationRule, "")
@@ -158,6 +168,7 @@ func buildOutboundNetworkFiltersWithWeightedClusters(node *model.Proxy, routes [
 
 	var filters []*listener.Filter
 	filters = append(filters, buildMetadataExchangeNetworkFilters(class)...)
+	filters = appendNetworkWasmPlugins(filters, push, node)
 	filters = append(filters, buildMetricsNetworkFilters(push, node, class)...)
 	filters = append(filters, buildNetworkFiltersStack(port.Protocol, tcpFilter, statPrefix, clusterName)...)
 	return filters
@@ -190,6 +201,60 @@ func maybeSetHashPolicy(destinationRule *networking.DestinationRule, tcpProxy *t
 	}
 }
 
+// setIdleTimeout sets the idle timeout of the TCP proxy to the one set for its destination, if any, or else to the
+// one of the proxy.
+func setIdleTimeout(tcpProxy *tcp.TcpProxy, node *model.Proxy, destinationTimeout time.Duration) {
+	if destinationTimeout > 0 {
+		tcpProxy.IdleTimeout = durationpb.New(destinationTimeout)
+		return
+	}
+	idleTimeout, err := time.ParseDuration(node.Metadata.IdleTimeout)
+	if err == nil {
+		tcpProxy.IdleTimeout = durationpb.New(idleTimeout)
+	}
+}
+
+// clusterIdleTimeout returns the TCP idle timeout set by the networking.istio.io/tcpIdleTimeout annotation of the
+// service port of an outbound cluster, or 0 if there is none.
+func clusterIdleTimeout(push *model.PushContext, node *model.Proxy, clusterName string) time.Duration {
+	_, _, hostname, port := model.ParseSubsetKey(clusterName)
+	if hostname == "" {
+		return 0
+	}
+	return push.ServiceForHostname(node, hostname).TCPIdleTimeout(port)
+}
+
+// maybeSetMaxConnectAttempts sets the connect attempts of the TCP proxy from the DestinationRule annotation. Envoy
+// picks another endpoint of the cluster for each attempt, so connections survive endpoints that went away, for
+// example during node drains.
+func maybeSetMaxConnectAttempts(destinationRule *config.Config, tcpProxy *tcp.TcpProxy) {
+	if destinationRule == nil {
+		return
+	}
+	attempts, f := destinationRule.Annotations[constants.TCPMaxConnectAttemptsAnnotation]
+	if !f {
+		return
+	}
+	n, err := strconv.ParseUint(attempts, 10, 32)
+	if err != nil || n == 0 {
+		log.Warnf("Ignoring invalid %s annotation of DestinationRule %s/%s: %q",
+			constants.TCPMaxConnectAttemptsAnnotation, destinationRule.Namespace, destinationRule.Name, attempts)
+		return
+	}
+	tcpProxy.MaxConnectAttempts = wrappers.UInt32(uint32(n))
+}
+
+// appendNetworkWasmPlugins appends the network WasmPlugins of the proxy to the outbound filters, in the order of
+// their phases. There is no Istio authentication or authorization filter on the outbound path to order them around.
+func appendNetworkWasmPlugins(filters []*listener.Filter, push *model.PushContext, node *model.Proxy) []*listener.Filter {
+	wasm := push.WasmPluginsByType(node, model.WasmPluginTypeNetwork)
+	filters = extension.PopAppendNetwork(filters, wasm, extensions.PluginPhase_AUTHN)
+	filters = extension.PopAppendNetwork(filters, wasm, extensions.PluginPhase_AUTHZ)
+	filters = extension.PopAppendNetwork(filters, wasm, extensions.PluginPhase_STATS)
+	filters = extension.PopAppendNetwork(filters, wasm, extensions.PluginPhase_UNSPECIFIED_PHASE)
+	return filters
+}
+
 // buildNetworkFiltersStack builds a slice of network filters based on
 // the protocol in use and the given TCP filter instance.
 func buildNetworkFiltersStack(p protocol.Instance, tcpFilter *listener.Filter, statPrefix string, clusterName string) []*listener.Filter {
@@ -228,9 +293,9 @@ func buildOutboundNetworkFilters(node *model.Proxy,
 	port *model.Port, configMeta config.Meta,
 ) []*listener.Filter {
 	service := push.ServiceForHostname(node, host.Name(routes[0].Destination.Host))
-	var destinationRule *networking.DestinationRule
+	var destinationRule *config.Config
 	if service != nil {
-		destinationRule = CastDestinationRule(node.SidecarScope.DestinationRule(model.TrafficDirectionOutbound, node, service.Hostname).GetRule())
+		destinationRule = node.SidecarScope.DestinationRule(model.TrafficDirectionOutbound, node, service.Hostname).GetRule()
 	}
 	if len(routes) == 1 {
 		clusterName := istioroute.GetDestinationCluster(routes[0].Destination, service, port.Port)
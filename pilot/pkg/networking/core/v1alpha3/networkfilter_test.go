@@ -30,6 +30,7 @@ import (
 	"istio.io/istio/pilot/pkg/networking/telemetry"
 	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/schema/collections"
@@ -672,3 +673,101 @@ func TestOutboundNetworkFilterWithSourceIPHashing(t *testing.T) {
 		})
 	}
 }
+
+func TestOutboundNetworkFilterMaxConnectAttempts(t *testing.T) {
+	services := []*model.Service{
+		buildService("test.com", "10.10.0.0/24", protocol.TCP, tnow),
+		buildService("retries.com", "10.10.0.0/24", protocol.TCP, tnow),
+		buildService("invalid.com", "10.10.0.0/24", protocol.TCP, tnow),
+	}
+	destinationRule := func(host, attempts string) *config.Config {
+		return &config.Config{
+			Meta: config.Meta{
+				GroupVersionKind: collections.IstioNetworkingV1Alpha3Destinationrules.Resource().GroupVersionKind(),
+				Name:             host,
+				Namespace:        "not-default",
+				Annotations:      map[string]string{constants.TCPMaxConnectAttemptsAnnotation: attempts},
+			},
+			Spec: &networking.DestinationRule{Host: host},
+		}
+	}
+	cg := NewConfigGenTest(t, TestOptions{
+		ConfigPointers: []*config.Config{destinationRule("retries.com", "3"), destinationRule("invalid.com", "0")},
+		Services:       services,
+	})
+	proxy := cg.SetupProxy(&model.Proxy{ConfigNamespace: "not-default"})
+
+	cases := []struct {
+		host     string
+		attempts uint32
+	}{
+		{"test.com", 0},
+		{"retries.com", 3},
+		{"invalid.com", 0},
+	}
+	for _, tt := range cases {
+		t.Run(tt.host, func(t *testing.T) {
+			routes := []*networking.RouteDestination{{
+				Destination: &networking.Destination{Host: tt.host, Port: &networking.PortSelector{Number: 9999}},
+			}}
+			filters := buildOutboundNetworkFilters(proxy, routes, cg.PushContext(), &model.Port{Port: 9999},
+				config.Meta{Name: tt.host, Namespace: "ns"})
+			tcp := &tcp.TcpProxy{}
+			if err := filters[len(filters)-1].GetTypedConfig().UnmarshalTo(tcp); err != nil {
+				t.Fatal(err)
+			}
+			if got := tcp.GetMaxConnectAttempts().GetValue(); got != tt.attempts {
+				t.Fatalf("expected %d max connect attempts, got %d", tt.attempts, got)
+			}
+		})
+	}
+}
+
+func TestOutboundNetworkFilterTCPIdleTimeout(t *testing.T) {
+	withTimeouts := func(hostname string, timeouts map[int]time.Duration) *model.Service {
+		svc := buildService(hostname, "10.10.0.0/24", protocol.TCP, tnow)
+		svc.Ports[0].Port = 9999
+		svc.Attributes.TCPIdleTimeouts = timeouts
+		return svc
+	}
+	services := []*model.Service{
+		withTimeouts("test.com", nil),
+		withTimeouts("db.com", map[int]time.Duration{9999: 4 * time.Hour, 0: time.Minute}),
+		withTimeouts("other-port.com", map[int]time.Duration{5432: 4 * time.Hour}),
+		withTimeouts("all-ports.com", map[int]time.Duration{0: 2 * time.Hour}),
+	}
+	cg := NewConfigGenTest(t, TestOptions{Services: services})
+	proxy := cg.SetupProxy(&model.Proxy{Metadata: &model.NodeMetadata{IdleTimeout: "30m"}})
+
+	cases := []struct {
+		name  string
+		hosts []string
+		want  time.Duration
+	}{
+		{"proxy default", []string{"test.com"}, 30 * time.Minute},
+		{"port timeout", []string{"db.com"}, 4 * time.Hour},
+		{"other port", []string{"other-port.com"}, 30 * time.Minute},
+		{"all ports", []string{"all-ports.com"}, 2 * time.Hour},
+		{"longest of weighted destinations", []string{"all-ports.com", "db.com", "test.com"}, 4 * time.Hour},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var routes []*networking.RouteDestination
+			for _, h := range tt.hosts {
+				routes = append(routes, &networking.RouteDestination{
+					Destination: &networking.Destination{Host: h, Port: &networking.PortSelector{Number: 9999}},
+					Weight:      10,
+				})
+			}
+			filters := buildOutboundNetworkFilters(proxy, routes, cg.PushContext(), &model.Port{Port: 9999},
+				config.Meta{Name: "vs", Namespace: "ns"})
+			tcp := &tcp.TcpProxy{}
+			if err := filters[len(filters)-1].GetTypedConfig().UnmarshalTo(tcp); err != nil {
+				t.Fatal(err)
+			}
+			if got := tcp.GetIdleTimeout().AsDuration(); got != tt.want {
+				t.Fatalf("expected idle timeout %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
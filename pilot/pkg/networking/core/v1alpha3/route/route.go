@@ -25,6 +25,7 @@ import (
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	xdsfault "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/common/fault/v3"
 	xdshttpfault "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/fault/v3"
+	jwt "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/jwt_authn/v3"
 	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	xdstype "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
@@ -41,6 +42,7 @@ import (
 	"istio.io/istio/pilot/pkg/networking/telemetry"
 	"istio.io/istio/pilot/pkg/networking/util"
 	authz "istio.io/istio/pilot/pkg/security/authz/model"
+	authn_model "istio.io/istio/pilot/pkg/security/model"
 	"istio.io/istio/pilot/pkg/util/constant"
 	"istio.io/istio/pilot/pkg/util/protoconv"
 	"istio.io/istio/pkg/config"
@@ -102,6 +104,11 @@ func BuildSidecarVirtualHostWrapper(routeCache *Cache, node *model.Proxy, push *
 
 	// dependentDestinationRules includes all the destinationrules referenced by the virtualservices, which have consistent hash policy.
 	dependentDestinationRules := []*model.ConsolidatedDestRule{}
+	// the mesh/namespace destination rule may override the default retry policy of the mesh config
+	mesh, meshDefaults := push.MeshConfigForNamespace(node.ConfigNamespace)
+	if meshDefaults != nil {
+		dependentDestinationRules = append(dependentDestinationRules, meshDefaults)
+	}
 	// consistent hash policies for the http route destinations
 	hashByDestination := map[*networking.HTTPRouteDestination]*networking.LoadBalancerSettings_ConsistentHashLB{}
 	for _, virtualService := range virtualServices {
@@ -125,7 +132,7 @@ func BuildSidecarVirtualHostWrapper(routeCache *Cache, node *model.Proxy, push *
 
 	// translate all virtual service configs into virtual hosts
 	for _, virtualService := range virtualServices {
-		wrappers := buildSidecarVirtualHostsForVirtualService(node, virtualService, serviceRegistry, hashByDestination, listenPort, push.Mesh)
+		wrappers := buildSidecarVirtualHostsForVirtualService(node, virtualService, serviceRegistry, hashByDestination, listenPort, mesh)
 		out = append(out, wrappers...)
 	}
 
@@ -157,7 +164,7 @@ func BuildSidecarVirtualHostWrapper(routeCache *Cache, node *model.Proxy, push *
 	}
 
 	// append default hosts for the service missing virtual Services
-	out = append(out, buildSidecarVirtualHostsForService(serviceRegistry, hashByService, push.Mesh)...)
+	out = append(out, buildSidecarVirtualHostsForService(serviceRegistry, hashByService, mesh)...)
 	return out
 }
 
@@ -459,6 +466,9 @@ func translateRoute(
 		out.TypedPerFilterConfig = make(map[string]*anypb.Any)
 		out.TypedPerFilterConfig[wellknown.Fault] = protoconv.MessageToAny(translateFault(in.Fault))
 	}
+	if node.Type == model.Router {
+		applyJwtForwarding(out, virtualService, in.Name)
+	}
 
 	if isHTTP3AltSvcHeaderNeeded {
 		http3AltSvcHeader := buildHTTP3AltSvcHeader(listenPort, util.ALPNHttp3OverQUIC)
@@ -516,8 +526,12 @@ func applyHTTPRouteDestination(
 
 	if in.Mirror != nil {
 		if mp := mirrorPercent(in); mp != nil {
+			mirrorCluster := GetDestinationCluster(in.Mirror, serviceRegistry[host.Name(in.Mirror.Host)], listenerPort)
+			if features.EnableShadowClusters {
+				mirrorCluster = model.BuildShadowClusterName(mirrorCluster)
+			}
 			action.RequestMirrorPolicies = []*route.RouteAction_RequestMirrorPolicy{{
-				Cluster:         GetDestinationCluster(in.Mirror, serviceRegistry[host.Name(in.Mirror.Host)], listenerPort),
+				Cluster:         mirrorCluster,
 				RuntimeFraction: mp,
 				TraceSampled:    &wrappers.BoolValue{Value: false},
 			}}
@@ -686,6 +700,26 @@ func buildHTTP3AltSvcHeader(port int, h3Alpns []string) *core.HeaderValueOption
 	}
 }
 
+// applyJwtForwarding selects the requirement of the JWT filter forwarding the JWTs as the JWT forwarding policy of the
+// route, if any. The gateway JWT filter has a requirement for each policy of the VirtualServices bound to it; the
+// parse errors are reported when building it.
+func applyJwtForwarding(out *route.Route, virtualService config.Config, routeName string) {
+	policies, err := model.ParseJwtForwardingPolicies(virtualService)
+	if err != nil {
+		return
+	}
+	policy := model.JwtForwardingPolicyForRoute(policies, routeName)
+	if policy == nil {
+		return
+	}
+	if out.TypedPerFilterConfig == nil {
+		out.TypedPerFilterConfig = make(map[string]*anypb.Any)
+	}
+	out.TypedPerFilterConfig[authn_model.EnvoyJwtFilterName] = protoconv.MessageToAny(&jwt.PerRouteConfig{
+		RequirementSpecifier: &jwt.PerRouteConfig_RequirementName{RequirementName: policy.RequirementName()},
+	})
+}
+
 // SortHeaderValueOption type and the functions below (Len, Less and Swap) are for sort.Stable for type HeaderValueOption
 type SortHeaderValueOption []*core.HeaderValueOption
 
@@ -21,6 +21,7 @@ import (
 
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoyroute "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	jwt "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/jwt_authn/v3"
 	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"github.com/onsi/gomega"
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -38,6 +39,7 @@ import (
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/test"
 )
 
 func TestBuildHTTPRoutes(t *testing.T) {
@@ -83,6 +85,32 @@ func TestBuildHTTPRoutes(t *testing.T) {
 		g.Expect(routes[0].GetRoute().MaxStreamDuration.MaxStreamDuration.Seconds).To(gomega.Equal(int64(0)))
 	})
 
+	t.Run("for virtual service with JWT forwarding on gateway", func(t *testing.T) {
+		g := gomega.NewWithT(t)
+		cg := v1alpha3.NewConfigGenTest(t, v1alpha3.TestOptions{})
+		gateway := cg.SetupProxy(&model.Proxy{
+			Type:        model.Router,
+			IPAddresses: []string{"1.1.1.1"},
+			ID:          "someID",
+			DNSDomain:   "foo.com",
+		})
+		vs := virtualServicePlain.DeepCopy()
+		vs.Annotations = map[string]string{constants.JwtForwardingAnnotation: `[{"mode": "payload", "payloadHeader": "x-jwt-claims"}]`}
+
+		routes, err := route.BuildHTTPRoutesForVirtualService(gateway, vs, serviceRegistry, nil, 8080, gatewayNames, false, nil)
+		xdstest.ValidateRoutes(t, routes)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(len(routes)).To(gomega.Equal(1))
+		perRoute := &jwt.PerRouteConfig{}
+		g.Expect(routes[0].TypedPerFilterConfig["envoy.filters.http.jwt_authn"].UnmarshalTo(perRoute)).To(gomega.Succeed())
+		g.Expect(perRoute.GetRequirementName()).To(gomega.Equal("payload:x-jwt-claims"))
+
+		// Sidecars do not enforce RequestAuthentication on the routes of VirtualServices.
+		routes, err = route.BuildHTTPRoutesForVirtualService(node(cg), vs, serviceRegistry, nil, 8080, gatewayNames, false, nil)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(routes[0].TypedPerFilterConfig).To(gomega.BeEmpty())
+	})
+
 	t.Run("for virtual service with HTTP/3 discovery enabled", func(t *testing.T) {
 		g := gomega.NewWithT(t)
 		cg := v1alpha3.NewConfigGenTest(t, v1alpha3.TestOptions{})
@@ -907,6 +935,21 @@ func TestBuildHTTPRoutes(t *testing.T) {
 		g.Expect(weightedCluster.GetTotalWeight().GetValue()).To(gomega.Equal(totalWeight))
 	})
 
+	t.Run("for mirror to a subset", func(t *testing.T) {
+		g := gomega.NewWithT(t)
+		cg := v1alpha3.NewConfigGenTest(t, v1alpha3.TestOptions{})
+
+		routes, err := route.BuildHTTPRoutesForVirtualService(node(cg), virtualServiceWithMirror, serviceRegistry, nil, 8080, gatewayNames, false, nil)
+		xdstest.ValidateRoutes(t, routes)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(routes[0].GetRoute().GetRequestMirrorPolicies()[0].Cluster).To(gomega.Equal("outbound|8080|v2|*.example.org"))
+
+		test.SetBoolForTest(t, &features.EnableShadowClusters, true)
+		routes, err = route.BuildHTTPRoutesForVirtualService(node(cg), virtualServiceWithMirror, serviceRegistry, nil, 8080, gatewayNames, false, nil)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(routes[0].GetRoute().GetRequestMirrorPolicies()[0].Cluster).To(gomega.Equal("outbound|8080|v2|*.example.org|shadow"))
+	})
+
 	t.Run("for redirect code", func(t *testing.T) {
 		g := gomega.NewWithT(t)
 		cg := v1alpha3.NewConfigGenTest(t, v1alpha3.TestOptions{})
@@ -1483,6 +1526,33 @@ var virtualServiceWithHeaderOperationsForWeightedCluster = config.Config{
 	},
 }
 
+var virtualServiceWithMirror = config.Config{
+	Meta: config.Meta{
+		GroupVersionKind: gvk.VirtualService,
+		Name:             "acme",
+	},
+	Spec: &networking.VirtualService{
+		Hosts:    []string{},
+		Gateways: []string{"some-gateway"},
+		Http: []*networking.HTTPRoute{
+			{
+				Route: []*networking.HTTPRouteDestination{
+					{
+						Destination: &networking.Destination{
+							Host:   "*.example.org",
+							Subset: "v1",
+						},
+					},
+				},
+				Mirror: &networking.Destination{
+					Host:   "*.example.org",
+					Subset: "v2",
+				},
+			},
+		},
+	},
+}
+
 var virtualServiceWithRedirect = config.Config{
 	Meta: config.Meta{
 		GroupVersionKind: gvk.VirtualService,
@@ -34,6 +34,7 @@ import (
 	"istio.io/istio/pilot/pkg/networking/core/v1alpha3"
 	"istio.io/istio/pilot/pkg/networking/util"
 	"istio.io/istio/pilot/pkg/simulation"
+	"istio.io/istio/pilot/pkg/simulation/fixture"
 	"istio.io/istio/pilot/pkg/xds"
 	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config"
@@ -2471,3 +2472,23 @@ spec:
 		})
 	}
 }
+
+func TestFixture(t *testing.T) {
+	f, err := fixture.Read("testdata/fixture.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := simulation.NewSimulationFromFixture(t, f)
+	sim.RunExpectations([]simulation.Expect{{
+		Name: "virtual service subset",
+		Call: simulation.Call{
+			Address:    "10.0.0.10",
+			Port:       9080,
+			HostHeader: "reviews.default.svc.cluster.local",
+			Protocol:   simulation.HTTP,
+		},
+		Result: simulation.Result{
+			ClusterMatched: "outbound|9080|v2|reviews.default.svc.cluster.local",
+		},
+	}})
+}
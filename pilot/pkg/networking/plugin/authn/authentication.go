@@ -21,6 +21,7 @@ import (
 	"istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pilot/pkg/security/authn"
 	"istio.io/istio/pilot/pkg/security/authn/factory"
+	"istio.io/istio/pkg/util/sets"
 	"istio.io/pkg/log"
 )
 
@@ -30,18 +31,51 @@ type Builder struct {
 	applier      authn.PolicyApplier
 	trustDomains []string
 	proxy        *model.Proxy
+	// jwtForwarding are the JWT forwarding policies of the routes of the gateway.
+	jwtForwarding []*model.JwtForwardingPolicy
 }
 
 func NewBuilder(push *model.PushContext, proxy *model.Proxy) *Builder {
 	applier := factory.NewPolicyApplier(push, proxy.Metadata.Namespace, proxy.Metadata.Labels)
 	trustDomains := TrustDomainsForValidation(push.Mesh)
 	return &Builder{
-		applier:      applier,
-		proxy:        proxy,
-		trustDomains: trustDomains,
+		applier:       applier,
+		proxy:         proxy,
+		trustDomains:  trustDomains,
+		jwtForwarding: gatewayJwtForwardingPolicies(push, proxy),
 	}
 }
 
+// gatewayJwtForwardingPolicies returns the JWT forwarding policies of the VirtualServices bound to the gateway, which
+// its routes select the JWT requirements of.
+func gatewayJwtForwardingPolicies(push *model.PushContext, proxy *model.Proxy) []*model.JwtForwardingPolicy {
+	if proxy.Type != model.Router || proxy.MergedGateway == nil {
+		return nil
+	}
+	gateways := sets.New()
+	for _, gatewayName := range proxy.MergedGateway.GatewayNameForServer {
+		gateways.Insert(gatewayName)
+	}
+	var policies []*model.JwtForwardingPolicy
+	seen := sets.New()
+	for _, gatewayName := range gateways.SortedList() {
+		for _, vs := range push.VirtualServicesForGateway(proxy.ConfigNamespace, gatewayName) {
+			key := vs.Namespace + "/" + vs.Name
+			if seen.Contains(key) {
+				continue
+			}
+			seen.Insert(key)
+			vsPolicies, err := model.ParseJwtForwardingPolicies(vs)
+			if err != nil {
+				authnLog.Warnf("ignoring JWT forwarding policies of VirtualService %s: %v", key, err)
+				continue
+			}
+			policies = append(policies, vsPolicies...)
+		}
+	}
+	return policies
+}
+
 func (b *Builder) ForPort(port uint32) authn.MTLSSettings {
 	if b == nil {
 		return authn.MTLSSettings{
@@ -89,7 +123,7 @@ func (b *Builder) BuildHTTP(class networking.ListenerClass) []*httppb.HttpFilter
 		return nil
 	}
 	res := []*httppb.HttpFilter{}
-	if filter := b.applier.JwtFilter(); filter != nil {
+	if filter := b.applier.JwtFilter(b.jwtForwarding); filter != nil {
 		res = append(res, filter)
 	}
 	forSidecar := b.proxy.Type == model.SidecarProxy
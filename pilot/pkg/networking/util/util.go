@@ -344,6 +344,9 @@ func CloneLocalityLbEndpoint(ep *endpoint.LocalityLbEndpoints) *endpoint.Localit
 // BuildConfigInfoMetadata builds core.Metadata struct containing the
 // name.namespace of the config, the type, etc.
 func BuildConfigInfoMetadata(config config.Meta) *core.Metadata {
+	if features.TrimXDSMetadata {
+		return nil
+	}
 	return AddConfigInfoMetadata(nil, config)
 }
 
@@ -355,13 +358,17 @@ func AddConfigInfoMetadata(metadata *core.Metadata, config config.Meta) *core.Me
 			FilterMetadata: map[string]*structpb.Struct{},
 		}
 	}
-	s := "/apis/" + config.GroupVersionKind.Group + "/" + config.GroupVersionKind.Version + "/namespaces/" + config.Namespace + "/" +
-		strcase.CamelCaseToKebabCase(config.GroupVersionKind.Kind) + "/" + config.Name
 	if _, ok := metadata.FilterMetadata[IstioMetadataKey]; !ok {
 		metadata.FilterMetadata[IstioMetadataKey] = &structpb.Struct{
 			Fields: map[string]*structpb.Value{},
 		}
 	}
+	if features.TrimXDSMetadata {
+		// The config reference is only used for debugging, but the istio metadata may be extended by the caller.
+		return metadata
+	}
+	s := "/apis/" + config.GroupVersionKind.Group + "/" + config.GroupVersionKind.Version + "/namespaces/" + config.Namespace + "/" +
+		strcase.CamelCaseToKebabCase(config.GroupVersionKind.Kind) + "/" + config.Name
 	metadata.FilterMetadata[IstioMetadataKey].Fields["config"] = &structpb.Value{
 		Kind: &structpb.Value_StringValue{
 			StringValue: s,
@@ -428,8 +435,9 @@ func MergeAnyWithAny(dst *anypb.Any, src *anypb.Any) (*anypb.Any, error) {
 func BuildLbEndpointMetadata(networkID network.ID, tlsMode, workloadname, namespace string,
 	clusterID cluster.ID, labels labels.Instance,
 ) *core.Metadata {
+	telemetryLabel := features.EndpointTelemetryLabel && !features.TrimXDSMetadata
 	if networkID == "" && (tlsMode == "" || tlsMode == model.DisabledTLSModeLabel) &&
-		(!features.EndpointTelemetryLabel || !features.EnableTelemetryLabel) {
+		(!telemetryLabel || !features.EnableTelemetryLabel) {
 		return nil
 	}
 
@@ -450,7 +458,7 @@ func BuildLbEndpointMetadata(networkID network.ID, tlsMode, workloadname, namesp
 	// server does not have sidecar injected, and request fails to reach server and thus metadata exchange does not happen.
 	// Due to performance concern, telemetry metadata is compressed into a semicolon separted string:
 	// workload-name;namespace;canonical-service-name;canonical-service-revision;cluster-id.
-	if features.EndpointTelemetryLabel {
+	if telemetryLabel {
 		var sb strings.Builder
 		sb.WriteString(workloadname)
 		sb.WriteString(";")
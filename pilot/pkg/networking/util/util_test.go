@@ -596,6 +596,46 @@ func TestAddSubsetToMetadata(t *testing.T) {
 	}
 }
 
+func TestTrimXDSMetadata(t *testing.T) {
+	test.SetBoolForTest(t, &features.TrimXDSMetadata, true)
+	test.SetBoolForTest(t, &features.EndpointTelemetryLabel, true)
+	meta := config.Meta{
+		Name:             "svcA",
+		Namespace:        "default",
+		GroupVersionKind: collections.IstioNetworkingV1Alpha3Destinationrules.Resource().GroupVersionKind(),
+	}
+	if got := BuildConfigInfoMetadata(meta); got != nil {
+		t.Errorf("expected no config metadata, got %v", got)
+	}
+
+	// The subset is still added to the cluster metadata for telemetry.
+	md := AddConfigInfoMetadata(nil, meta)
+	AddSubsetToMetadata(md, "v1")
+	want := &core.Metadata{
+		FilterMetadata: map[string]*structpb.Struct{
+			IstioMetadataKey: {
+				Fields: map[string]*structpb.Value{
+					"subset": {Kind: &structpb.Value_StringValue{StringValue: "v1"}},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(md, want, protocmp.Transform()); diff != "" {
+		t.Errorf("unexpected cluster metadata: %s", diff)
+	}
+
+	if got := BuildLbEndpointMetadata("", model.DisabledTLSModeLabel, "workload", "default", "cluster", nil); got != nil {
+		t.Errorf("expected no endpoint metadata, got %v", got)
+	}
+	got := BuildLbEndpointMetadata("", model.IstioMutualTLSModeLabel, "workload", "default", "cluster", nil)
+	if _, ok := got.GetFilterMetadata()[IstioMetadataKey]; ok {
+		t.Errorf("expected no telemetry metadata, got %v", got)
+	}
+	if _, ok := got.GetFilterMetadata()[EnvoyTransportSocketMetadataKey]; !ok {
+		t.Errorf("expected TLS mode metadata, got %v", got)
+	}
+}
+
 func TestIsHTTPFilterChain(t *testing.T) {
 	httpFilterChain := &listener.FilterChain{
 		Filters: []*listener.Filter{
@@ -28,9 +28,10 @@ type PolicyApplier interface {
 	// InboundMTLSSettings returns inbound mTLS settings for a given workload port
 	InboundMTLSSettings(endpointPort uint32, node *model.Proxy, trustDomainAliases []string) MTLSSettings
 
-	// JwtFilter returns the JWT HTTP filter to enforce the underlying authentication policy.
+	// JwtFilter returns the JWT HTTP filter to enforce the underlying authentication policy, with a requirement
+	// for each of the JWT forwarding policies of the routes.
 	// It may return nil, if no JWT validation is needed.
-	JwtFilter() *http_conn.HttpFilter
+	JwtFilter(forwarding []*model.JwtForwardingPolicy) *http_conn.HttpFilter
 
 	// AuthNFilter returns the (authn) HTTP filter to enforce the underlying authentication policy.
 	// It may return nil, if no authentication is needed.
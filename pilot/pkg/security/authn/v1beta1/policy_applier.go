@@ -26,6 +26,7 @@ import (
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	envoy_jwt "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/jwt_authn/v3"
 	http_conn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 
@@ -61,12 +62,12 @@ type v1beta1PolicyApplier struct {
 	push *model.PushContext
 }
 
-func (a *v1beta1PolicyApplier) JwtFilter() *http_conn.HttpFilter {
+func (a *v1beta1PolicyApplier) JwtFilter(forwarding []*model.JwtForwardingPolicy) *http_conn.HttpFilter {
 	if len(a.processedJwtRules) == 0 {
 		return nil
 	}
 
-	filterConfigProto := convertToEnvoyJwtConfig(a.processedJwtRules, a.push)
+	filterConfigProto := convertToEnvoyJwtConfig(a.processedJwtRules, a.push, forwarding)
 
 	if filterConfigProto == nil {
 		return nil
@@ -201,80 +202,138 @@ func NewPolicyApplier(rootNamespace string,
 // Each rule is expected corresponding to one JWT issuer (provider).
 // The behavior of the filter should reject all requests with invalid token. On the other hand,
 // if no token provided, the request is allowed.
-func convertToEnvoyJwtConfig(jwtRules []*v1beta1.JWTRule, push *model.PushContext) *envoy_jwt.JwtAuthentication {
+// The JWT forwarding policies of the routes add requirements enforcing the same rules with a different forwarding of
+// the tokens, which the routes select by name.
+func convertToEnvoyJwtConfig(jwtRules []*v1beta1.JWTRule, push *model.PushContext,
+	forwarding []*model.JwtForwardingPolicy,
+) *envoy_jwt.JwtAuthentication {
 	if len(jwtRules) == 0 {
 		return nil
 	}
 
 	providers := map[string]*envoy_jwt.JwtProvider{}
-	// Each element of innerAndList is the requirement for each provider, in the form of
-	// {provider OR `allow_missing`}
-	// This list will be ANDed (if have more than one provider) for the final requirement.
-	innerAndList := []*envoy_jwt.JwtRequirement{}
+	names := make([]string, 0, len(jwtRules))
+	for i, jwtRule := range jwtRules {
+		name := fmt.Sprintf("origins-%d", i)
+		providers[name] = buildJwtProvider(jwtRule, push)
+		names = append(names, name)
+	}
 
-	// This is an (or) list for all providers. This will be OR with the innerAndList above so
-	// it can pass the requirement in the case that providers share the same location.
-	outterOrList := []*envoy_jwt.JwtRequirement{}
+	out := &envoy_jwt.JwtAuthentication{
+		Rules: []*envoy_jwt.RequirementRule{
+			{
+				Match: &route.RouteMatch{
+					PathSpecifier: &route.RouteMatch_Prefix{
+						Prefix: "/",
+					},
+				},
+				RequirementType: &envoy_jwt.RequirementRule_Requires{
+					Requires: buildJwtRequirement(names),
+				},
+			},
+		},
+		Providers:           providers,
+		BypassCorsPreflight: true,
+	}
 
-	for i, jwtRule := range jwtRules {
-		provider := &envoy_jwt.JwtProvider{
-			Issuer:               jwtRule.Issuer,
-			Audiences:            jwtRule.Audiences,
-			Forward:              jwtRule.ForwardOriginalToken,
-			ForwardPayloadHeader: jwtRule.OutputPayloadToHeader,
-			PayloadInMetadata:    jwtRule.Issuer,
+	for _, policy := range forwarding {
+		requirementName := policy.RequirementName()
+		if _, f := out.RequirementMap[requirementName]; f {
+			continue
 		}
-
-		for _, location := range jwtRule.FromHeaders {
-			provider.FromHeaders = append(provider.FromHeaders, &envoy_jwt.JwtHeader{
-				Name:        location.Name,
-				ValuePrefix: location.Prefix,
-			})
+		if out.RequirementMap == nil {
+			out.RequirementMap = map[string]*envoy_jwt.JwtRequirement{}
 		}
-		provider.FromParams = jwtRule.FromParams
-
-		if features.EnableRemoteJwks && jwtRule.JwksUri != "" {
-			// Use remote jwks if jwksUri is non empty. Parse the jwksUri to get the cluster name,
-			// generate the jwt filter config using remoteJwks.
-			// If failed to parse the cluster name, fallback to let istiod to fetch the jwksUri.
-			// TODO: Implement the logic to auto-generate the cluster so that when the flag is enabled,
-			// it will always let envoy to fetch the jwks for consistent behavior.
-			u, _ := url.Parse(jwtRule.JwksUri)
-			host, hostPort, _ := net.SplitHostPort(u.Host)
-			// TODO: Default port based on scheme ?
-			port := 80
-			if hostPort != "" {
-				var err error
-				if port, err = strconv.Atoi(hostPort); err != nil {
-					port = 80 // If port is not specified or there is an error in parsing default to 80.
-				}
+		policyNames := make([]string, 0, len(names))
+		for _, name := range names {
+			provider := proto.Clone(providers[name]).(*envoy_jwt.JwtProvider)
+			provider.Forward = policy.Mode == model.JwtForwardingPassthrough
+			provider.ForwardPayloadHeader = ""
+			if policy.Mode == model.JwtForwardingPayload {
+				provider.ForwardPayloadHeader = policy.PayloadHeader
 			}
-			_, cluster, err := extensionproviders.LookupCluster(push, host, port)
-
-			if err == nil && len(cluster) > 0 {
-				// This is a case of URI pointing to mesh cluster. Setup Remote Jwks and let Envoy fetch the key.
-				provider.JwksSourceSpecifier = &envoy_jwt.JwtProvider_RemoteJwks{
-					RemoteJwks: &envoy_jwt.RemoteJwks{
-						HttpUri: &core.HttpUri{
-							Uri: jwtRule.JwksUri,
-							HttpUpstreamType: &core.HttpUri_Cluster{
-								Cluster: cluster,
-							},
-							Timeout: &durationpb.Duration{Seconds: 5},
+			policyName := name + "-" + requirementName
+			providers[policyName] = provider
+			policyNames = append(policyNames, policyName)
+		}
+		out.RequirementMap[requirementName] = buildJwtRequirement(policyNames)
+	}
+	return out
+}
+
+// buildJwtProvider builds the Envoy JWT provider validating the tokens of the JWT rule.
+func buildJwtProvider(jwtRule *v1beta1.JWTRule, push *model.PushContext) *envoy_jwt.JwtProvider {
+	provider := &envoy_jwt.JwtProvider{
+		Issuer:               jwtRule.Issuer,
+		Audiences:            jwtRule.Audiences,
+		Forward:              jwtRule.ForwardOriginalToken,
+		ForwardPayloadHeader: jwtRule.OutputPayloadToHeader,
+		PayloadInMetadata:    jwtRule.Issuer,
+	}
+
+	for _, location := range jwtRule.FromHeaders {
+		provider.FromHeaders = append(provider.FromHeaders, &envoy_jwt.JwtHeader{
+			Name:        location.Name,
+			ValuePrefix: location.Prefix,
+		})
+	}
+	provider.FromParams = jwtRule.FromParams
+
+	if features.EnableRemoteJwks && jwtRule.JwksUri != "" {
+		// Use remote jwks if jwksUri is non empty. Parse the jwksUri to get the cluster name,
+		// generate the jwt filter config using remoteJwks.
+		// If failed to parse the cluster name, fallback to let istiod to fetch the jwksUri.
+		// TODO: Implement the logic to auto-generate the cluster so that when the flag is enabled,
+		// it will always let envoy to fetch the jwks for consistent behavior.
+		u, _ := url.Parse(jwtRule.JwksUri)
+		host, hostPort, _ := net.SplitHostPort(u.Host)
+		// TODO: Default port based on scheme ?
+		port := 80
+		if hostPort != "" {
+			var err error
+			if port, err = strconv.Atoi(hostPort); err != nil {
+				port = 80 // If port is not specified or there is an error in parsing default to 80.
+			}
+		}
+		_, cluster, err := extensionproviders.LookupCluster(push, host, port)
+
+		if err == nil && len(cluster) > 0 {
+			// This is a case of URI pointing to mesh cluster. Setup Remote Jwks and let Envoy fetch the key.
+			provider.JwksSourceSpecifier = &envoy_jwt.JwtProvider_RemoteJwks{
+				RemoteJwks: &envoy_jwt.RemoteJwks{
+					HttpUri: &core.HttpUri{
+						Uri: jwtRule.JwksUri,
+						HttpUpstreamType: &core.HttpUri_Cluster{
+							Cluster: cluster,
 						},
-						CacheDuration: &durationpb.Duration{Seconds: 5 * 60},
+						Timeout: &durationpb.Duration{Seconds: 5},
 					},
-				}
-			} else {
-				provider.JwksSourceSpecifier = push.JwtKeyResolver.BuildLocalJwks(jwtRule.JwksUri, jwtRule.Issuer, "")
+					CacheDuration: &durationpb.Duration{Seconds: 5 * 60},
+				},
 			}
 		} else {
-			// Use inline jwks as existing flow, either jwtRule.jwks is non empty or let istiod to fetch the jwtRule.jwksUri
-			provider.JwksSourceSpecifier = push.JwtKeyResolver.BuildLocalJwks(jwtRule.JwksUri, jwtRule.Issuer, jwtRule.Jwks)
+			provider.JwksSourceSpecifier = push.JwtKeyResolver.BuildLocalJwks(jwtRule.JwksUri, jwtRule.Issuer, "")
 		}
+	} else {
+		// Use inline jwks as existing flow, either jwtRule.jwks is non empty or let istiod to fetch the jwtRule.jwksUri
+		provider.JwksSourceSpecifier = push.JwtKeyResolver.BuildLocalJwks(jwtRule.JwksUri, jwtRule.Issuer, jwtRule.Jwks)
+	}
 
-		name := fmt.Sprintf("origins-%d", i)
-		providers[name] = provider
+	return provider
+}
+
+// buildJwtRequirement builds the requirement of the providers with the names: a token, if provided, must be valid.
+func buildJwtRequirement(names []string) *envoy_jwt.JwtRequirement {
+	// Each element of innerAndList is the requirement for each provider, in the form of
+	// {provider OR `allow_missing`}
+	// This list will be ANDed (if have more than one provider) for the final requirement.
+	innerAndList := []*envoy_jwt.JwtRequirement{}
+
+	// This is an (or) list for all providers. This will be OR with the innerAndList above so
+	// it can pass the requirement in the case that providers share the same location.
+	outterOrList := []*envoy_jwt.JwtRequirement{}
+
+	for _, name := range names {
 		innerAndList = append(innerAndList, &envoy_jwt.JwtRequirement{
 			RequiresType: &envoy_jwt.JwtRequirement_RequiresAny{
 				RequiresAny: &envoy_jwt.JwtRequirementOrList{
@@ -302,22 +361,7 @@ func convertToEnvoyJwtConfig(jwtRules []*v1beta1.JWTRule, push *model.PushContex
 
 	// If there is only one provider, simply use an OR of {provider, `allow_missing`}.
 	if len(innerAndList) == 1 {
-		return &envoy_jwt.JwtAuthentication{
-			Rules: []*envoy_jwt.RequirementRule{
-				{
-					Match: &route.RouteMatch{
-						PathSpecifier: &route.RouteMatch_Prefix{
-							Prefix: "/",
-						},
-					},
-					RequirementType: &envoy_jwt.RequirementRule_Requires{
-						Requires: innerAndList[0],
-					},
-				},
-			},
-			Providers:           providers,
-			BypassCorsPreflight: true,
-		}
+		return innerAndList[0]
 	}
 
 	// If there are more than one provider, filter should OR of
@@ -332,28 +376,12 @@ func convertToEnvoyJwtConfig(jwtRules []*v1beta1.JWTRule, push *model.PushContex
 			},
 		},
 	})
-
-	return &envoy_jwt.JwtAuthentication{
-		Rules: []*envoy_jwt.RequirementRule{
-			{
-				Match: &route.RouteMatch{
-					PathSpecifier: &route.RouteMatch_Prefix{
-						Prefix: "/",
-					},
-				},
-				RequirementType: &envoy_jwt.RequirementRule_Requires{
-					Requires: &envoy_jwt.JwtRequirement{
-						RequiresType: &envoy_jwt.JwtRequirement_RequiresAny{
-							RequiresAny: &envoy_jwt.JwtRequirementOrList{
-								Requirements: outterOrList,
-							},
-						},
-					},
-				},
+	return &envoy_jwt.JwtRequirement{
+		RequiresType: &envoy_jwt.JwtRequirement_RequiresAny{
+			RequiresAny: &envoy_jwt.JwtRequirementOrList{
+				Requirements: outterOrList,
 			},
 		},
-		Providers:           providers,
-		BypassCorsPreflight: true,
 	}
 }
 
@@ -705,7 +705,7 @@ func TestJwtFilter(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			istiotest.SetBoolForTest(t, &features.EnableRemoteJwks, c.enableRemoteJwks)
-			if got := NewPolicyApplier("root-namespace", c.in, nil, push).JwtFilter(); !reflect.DeepEqual(c.expected, got) {
+			if got := NewPolicyApplier("root-namespace", c.in, nil, push).JwtFilter(nil); !reflect.DeepEqual(c.expected, got) {
 				t.Errorf("got:\n%s\nwanted:\n%s", spew.Sdump(got), spew.Sdump(c.expected))
 			}
 		})
@@ -1019,13 +1019,70 @@ func TestConvertToEnvoyJwtConfig(t *testing.T) {
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			if got := convertToEnvoyJwtConfig(c.in, push); !reflect.DeepEqual(c.expected, got) {
+			if got := convertToEnvoyJwtConfig(c.in, push, nil); !reflect.DeepEqual(c.expected, got) {
 				t.Errorf("got:\n%s\nwanted:\n%s\n", spew.Sdump(got), spew.Sdump(c.expected))
 			}
 		})
 	}
 }
 
+func TestConvertToEnvoyJwtConfigForwarding(t *testing.T) {
+	push := &model.PushContext{}
+	push.JwtKeyResolver = model.NewJwksResolver(
+		model.JwtPubKeyEvictionDuration, model.JwtPubKeyRefreshInterval,
+		model.JwtPubKeyRefreshIntervalOnFailure, model.JwtPubKeyRetryInterval)
+	defer push.JwtKeyResolver.Close()
+
+	rules := []*v1beta1.JWTRule{
+		{Issuer: "https://a.example.com", Jwks: model.CreateFakeJwks("https://a.example.com"), ForwardOriginalToken: true},
+		{Issuer: "https://b.example.com", Jwks: model.CreateFakeJwks("https://b.example.com"), OutputPayloadToHeader: "x-b"},
+	}
+	forwarding := []*model.JwtForwardingPolicy{
+		{Mode: model.JwtForwardingStrip},
+		{Mode: model.JwtForwardingPayload, PayloadHeader: "x-jwt-claims"},
+		{Routes: []string{"other"}, Mode: model.JwtForwardingStrip},
+	}
+	got := convertToEnvoyJwtConfig(rules, push, forwarding)
+	base := convertToEnvoyJwtConfig(rules, push, nil)
+
+	if !reflect.DeepEqual(got.Rules, base.Rules) {
+		t.Errorf("expected the JWT forwarding policies not to change the default requirement")
+	}
+	if len(got.RequirementMap) != 2 {
+		t.Fatalf("got requirements %v, want strip and payload:x-jwt-claims", got.RequirementMap)
+	}
+	for name, provider := range base.Providers {
+		if !proto.Equal(provider, got.Providers[name]) {
+			t.Errorf("expected the provider %s to be unchanged", name)
+		}
+	}
+	cases := []struct {
+		provider      string
+		forward       bool
+		payloadHeader string
+	}{
+		{provider: "origins-0-strip"},
+		{provider: "origins-1-strip"},
+		{provider: "origins-0-payload:x-jwt-claims", payloadHeader: "x-jwt-claims"},
+		{provider: "origins-1-payload:x-jwt-claims", payloadHeader: "x-jwt-claims"},
+	}
+	for _, c := range cases {
+		provider, f := got.Providers[c.provider]
+		if !f {
+			t.Errorf("missing provider %s", c.provider)
+			continue
+		}
+		if provider.Forward != c.forward || provider.ForwardPayloadHeader != c.payloadHeader {
+			t.Errorf("got provider %s forwarding %v and payload header %q, want %v and %q",
+				c.provider, provider.Forward, provider.ForwardPayloadHeader, c.forward, c.payloadHeader)
+		}
+	}
+	providers := got.RequirementMap["strip"].GetRequiresAny().GetRequirements()
+	if len(providers) != 3 || providers[0].GetProviderName() != "origins-0-strip" || providers[1].GetProviderName() != "origins-1-strip" {
+		t.Errorf("unexpected strip requirement %v", got.RequirementMap["strip"])
+	}
+}
+
 func humanReadableAuthnFilterDump(filter *http_conn.HttpFilter) string {
 	if filter == nil {
 		return "<nil>"
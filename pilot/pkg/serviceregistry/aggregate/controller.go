@@ -15,6 +15,7 @@
 package aggregate
 
 import (
+	"sort"
 	"sync"
 
 	"istio.io/istio/pilot/pkg/model"
@@ -148,6 +149,21 @@ func (c *Controller) GetRegistries() []serviceregistry.Instance {
 	return out
 }
 
+// StaleClusters returns the sorted IDs of the clusters whose registry serves its last-known state, because their API
+// server has been unreachable for too long.
+func (c *Controller) StaleClusters() []string {
+	c.storeLock.RLock()
+	defer c.storeLock.RUnlock()
+	var out []string
+	for _, r := range c.registries {
+		if s, ok := r.Instance.(interface{ Stale() bool }); ok && s.Stale() {
+			out = append(out, r.Cluster().String())
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
 func (c *Controller) getRegistryIndex(clusterID cluster.ID, provider provider.ID) (int, bool) {
 	for i, r := range c.registries {
 		if r.Cluster().Equals(clusterID) && r.Provider() == provider {
@@ -245,6 +261,27 @@ func (c *Controller) MCSServices() []model.MCSServiceInfo {
 	return out
 }
 
+// ServiceAccountWorkloads merges the workload instances of the registries listing them with their service account.
+func (c *Controller) ServiceAccountWorkloads() []model.ServiceAccountWorkload {
+	c.storeLock.RLock()
+	defer c.storeLock.RUnlock()
+	var out []model.ServiceAccountWorkload
+	seen := map[model.ServiceAccountWorkload]bool{}
+	for _, r := range c.registries {
+		lister, ok := r.Instance.(model.ServiceAccountWorkloadLister)
+		if !ok {
+			continue
+		}
+		for _, wl := range lister.ServiceAccountWorkloads() {
+			if !seen[wl] {
+				seen[wl] = true
+				out = append(out, wl)
+			}
+		}
+	}
+	return out
+}
+
 // InstancesByPort retrieves instances for a service on a given port that match
 // any of the supplied labels. All instances match an empty label list.
 func (c *Controller) InstancesByPort(svc *model.Service, port int, labels labels.Instance) []*model.ServiceInstance {
@@ -30,6 +30,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	"istio.io/api/label"
+	"istio.io/istio/pilot/pkg/config/kube/replication"
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/serviceregistry"
@@ -89,12 +90,28 @@ var (
 		"pilot_k8s_endpoints_pending_pod",
 		"Number of endpoints that do not currently have any corresponding pods.",
 	)
+
+	clusterTag = monitoring.MustCreateLabel("cluster")
+
+	apiServerStale = monitoring.NewGauge(
+		"pilot_k8s_registry_stale",
+		"Whether the registry of the cluster serves its last-known state because its API server is unreachable, 1 if it does.",
+		monitoring.WithLabels(clusterTag),
+	)
+
+	apiServerLastContact = monitoring.NewGauge(
+		"pilot_k8s_api_server_last_contact_seconds",
+		"Seconds since the API server of the cluster was last reachable.",
+		monitoring.WithLabels(clusterTag),
+	)
 )
 
 func init() {
 	monitoring.MustRegister(k8sEvents)
 	monitoring.MustRegister(endpointsWithNoPods)
 	monitoring.MustRegister(endpointsPendingPodUpdate)
+	monitoring.MustRegister(apiServerStale)
+	monitoring.MustRegister(apiServerLastContact)
 }
 
 func incrementEvent(kind, event string) {
@@ -141,8 +158,15 @@ type Options struct {
 	// SyncTimeout, if set, causes HasSynced to be returned when timeout.
 	SyncTimeout time.Duration
 
+	// StalenessThreshold, if set, is how long the API server can be unreachable before the registry switches to
+	// serving the last-known state of the cluster as stale.
+	StalenessThreshold time.Duration
+
 	// If meshConfig.DiscoverySelectors are specified, the DiscoveryNamespacesFilter tracks the namespaces this controller watches.
 	DiscoveryNamespacesFilter filter.DiscoveryNamespacesFilter
+
+	// ConfigReplication, if set, replicates config from the config cluster to every remote cluster.
+	ConfigReplication *replication.Controller
 }
 
 // DetectEndpointMode determines whether to use Endpoints or EndpointSlice based on the
@@ -264,6 +288,8 @@ type Controller struct {
 	workloadInstancesIndex workloadinstances.Index
 
 	multinetwork
+	// localityLabels are the custom node labels the locality of pods is derived from.
+	localityLabels localityNodeLabels
 	// informerInit is set to true once the controller is running successfully. This ensures we do not
 	// return HasSynced=true before we are running
 	informerInit *atomic.Bool
@@ -271,6 +297,9 @@ type Controller struct {
 	beginSync *atomic.Bool
 	// initialSync is set to true after performing an initial in-order processing of all objects.
 	initialSync *atomic.Bool
+	// stale is set to true while the registry serves the last-known state of the cluster, because its API server has
+	// been unreachable for longer than the StalenessThreshold.
+	stale *atomic.Bool
 }
 
 // NewController creates a new Kubernetes controller
@@ -288,10 +317,17 @@ func NewController(kubeClient kubelib.Client, options Options) *Controller {
 		informerInit:               atomic.NewBool(false),
 		beginSync:                  atomic.NewBool(false),
 		initialSync:                atomic.NewBool(false),
+		stale:                      atomic.NewBool(false),
 
 		multinetwork: initMultinetwork(),
 	}
 
+	localityLabels, err := parseLocalityNodeLabels(features.LocalityNodeLabels)
+	if err != nil {
+		log.Errorf("ignoring invalid PILOT_LOCALITY_NODE_LABELS: %v", err)
+	}
+	c.localityLabels = localityLabels
+
 	if features.EnableMCSHost {
 		c.hostNamesForNamespacedName = func(name types.NamespacedName) []host.Name {
 			return []host.Name{
@@ -352,7 +388,7 @@ func NewController(kubeClient kubelib.Client, options Options) *Controller {
 	c.serviceInformer = filter.NewFilteredSharedIndexInformer(c.opts.DiscoveryNamespacesFilter.Filter, kubeClient.KubeInformer().Core().V1().Services().Informer())
 	c.serviceLister = listerv1.NewServiceLister(c.serviceInformer.GetIndexer())
 
-	c.registerHandlers(c.serviceInformer, "Services", c.onServiceEvent, nil)
+	c.registerHandlers(c.serviceInformer, "Services", c.onServiceEvent, c.requiredPodConditionsFilter)
 
 	switch options.EndpointMode {
 	case EndpointsOnly:
@@ -497,6 +533,25 @@ func (c *Controller) Cleanup() error {
 	return nil
 }
 
+// Stale returns true if the registry serves the last-known state of the cluster, because its API server has been
+// unreachable for longer than the StalenessThreshold.
+func (c *Controller) Stale() bool {
+	return c.stale.Load()
+}
+
+// onStalenessChange records whether the registry is stale, and pushes the proxies so that the control plane identifier
+// of the responses they get reflects it.
+func (c *Controller) onStalenessChange(stale bool) {
+	c.stale.Store(stale)
+	if c.opts.XDSUpdater == nil {
+		return
+	}
+	c.opts.XDSUpdater.ConfigUpdate(&model.PushRequest{
+		Full:   true,
+		Reason: []model.TriggerReason{model.ClusterUpdate},
+	})
+}
+
 func (c *Controller) onServiceEvent(curr any, event model.Event) error {
 	svc, err := extractService(curr)
 	if err != nil {
@@ -508,12 +563,19 @@ func (c *Controller) onServiceEvent(curr any, event model.Event) error {
 
 	// Create the standard (cluster.local) service.
 	svcConv := kube.ConvertService(*svc, c.opts.DomainSuffix, c.Cluster())
+	c.RLock()
+	prev := c.servicesMap[svcConv.Hostname]
+	c.RUnlock()
 	switch event {
 	case model.EventDelete:
 		c.deleteService(svcConv)
 	default:
 		c.addOrUpdateService(svc, svcConv, event, false)
 	}
+	// Other ExternalName services may point to this one, so where they resolve to may have changed.
+	if svc.Spec.Type == v1.ServiceTypeExternalName || (prev != nil && prev.MeshExternal) {
+		c.updateExternalNameServices(svcConv.Hostname)
+	}
 
 	return nil
 }
@@ -559,11 +621,13 @@ func (c *Controller) addOrUpdateService(svc *v1.Service, svcConv *model.Service,
 	}
 
 	// instance conversion is only required when service is added/updated.
-	instances := kube.ExternalNameServiceInstances(svc, svcConv)
+	instances := c.externalNameServiceInstances(svc, svcConv)
 	c.Lock()
 	c.servicesMap[svcConv.Hostname] = svcConv
 	if len(instances) > 0 {
 		c.externalNameSvcInstanceMap[svcConv.Hostname] = instances
+	} else {
+		delete(c.externalNameSvcInstanceMap, svcConv.Hostname)
 	}
 	c.Unlock()
 
@@ -844,6 +908,9 @@ func (c *Controller) Run(stop <-chan struct{}) {
 	}
 	c.initialSync.Store(true)
 	log.Infof("kube controller for %s synced after %v", c.opts.ClusterID, time.Since(st))
+	if c.opts.StalenessThreshold > 0 {
+		go newAPIServerMonitor(c).run(stop)
+	}
 	// after the in-order sync we can start processing the queue
 	c.queue.Run(stop)
 	log.Infof("Controller terminated")
@@ -893,9 +960,9 @@ func (c *Controller) getPodLocality(pod *v1.Pod) string {
 		return ""
 	}
 
-	region := getLabelValue(node.ObjectMeta, NodeRegionLabelGA, NodeRegionLabel)
-	zone := getLabelValue(node.ObjectMeta, NodeZoneLabelGA, NodeZoneLabel)
-	subzone := getLabelValue(node.ObjectMeta, label.TopologySubzone.Name, "")
+	region := getCustomLabelValue(node.ObjectMeta, c.localityLabels.region, NodeRegionLabelGA, NodeRegionLabel)
+	zone := getCustomLabelValue(node.ObjectMeta, c.localityLabels.zone, NodeZoneLabelGA, NodeZoneLabel)
+	subzone := getCustomLabelValue(node.ObjectMeta, c.localityLabels.subzone, label.TopologySubzone.Name, "")
 
 	if region == "" && zone == "" && subzone == "" {
 		return ""
@@ -1037,6 +1104,27 @@ func (c *Controller) collectWorkloadInstanceEndpoints(svc *model.Service) []*mod
 	return endpoints
 }
 
+// ServiceAccountWorkloads lists the pods of the cluster with an IP, with their service account identity.
+func (c *Controller) ServiceAccountWorkloads() []model.ServiceAccountWorkload {
+	var out []model.ServiceAccountWorkload
+	for _, obj := range c.pods.informer.GetIndexer().List() {
+		pod := obj.(*v1.Pod)
+		if pod.Status.PodIP == "" || !shouldPodBeInEndpoints(pod) {
+			continue
+		}
+		out = append(out, model.ServiceAccountWorkload{
+			ServiceAccount: kube.SecureNamingSAN(pod),
+			Kind:           model.PodKind.String(),
+			Name:           pod.Name,
+			Namespace:      pod.Namespace,
+			Address:        pod.Status.PodIP,
+			Network:        c.Network(pod.Status.PodIP, pod.Labels),
+			Cluster:        c.Cluster(),
+		})
+	}
+	return out
+}
+
 // GetProxyServiceInstances returns service instances co-located with a given proxy
 // TODO: this code does not return k8s service instances when the proxy's IP is a workload entry
 // To tackle this, we need a ip2instance map like what we have in service entry.
@@ -38,6 +38,7 @@ import (
 	"istio.io/api/annotation"
 	"istio.io/api/label"
 	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/serviceregistry/kube"
 	"istio.io/istio/pilot/pkg/serviceregistry/kube/controller/filter"
@@ -183,10 +184,11 @@ func TestController_GetPodLocality(t *testing.T) {
 	podOverride := generatePod("128.0.1.2", "pod2", "nsB", "",
 		"node1", map[string]string{"app": "prod-app", model.LocalityLabel: "regionOverride.zoneOverride.subzoneOverride"}, map[string]string{})
 	testCases := []struct {
-		name   string
-		pods   []*coreV1.Pod
-		nodes  []*coreV1.Node
-		wantAZ map[*coreV1.Pod]string
+		name           string
+		pods           []*coreV1.Pod
+		nodes          []*coreV1.Node
+		localityLabels localityNodeLabels
+		wantAZ         map[*coreV1.Pod]string
 	}{
 		{
 			name: "should return correct az for given address",
@@ -263,6 +265,19 @@ func TestController_GetPodLocality(t *testing.T) {
 				pod2: "//subzone2",
 			},
 		},
+		{
+			name: "should return az from custom node labels",
+			pods: []*coreV1.Pod{pod1, pod2},
+			nodes: []*coreV1.Node{
+				generateNode("node1", map[string]string{NodeZoneLabel: "zone1", NodeRegionLabel: "region1", "example.com/rack": "rack1"}),
+				generateNode("node2", map[string]string{NodeZoneLabel: "zone2", "example.com/dc": "dc2", "example.com/room": "room2"}),
+			},
+			localityLabels: localityNodeLabels{region: "example.com/dc", zone: "example.com/room", subzone: "example.com/rack"},
+			wantAZ: map[*coreV1.Pod]string{
+				pod1: "region1/zone1/rack1",
+				pod2: "dc2/room2/",
+			},
+		},
 		{
 			name: "should return correct az for given address",
 			pods: []*coreV1.Pod{podOverride},
@@ -284,6 +299,7 @@ func TestController_GetPodLocality(t *testing.T) {
 			// Setup kube caches
 			// Pod locality only matters for Endpoints
 			controller, fx := NewFakeControllerWithOptions(t, FakeControllerOptions{Mode: EndpointsOnly})
+			controller.localityLabels = tc.localityLabels
 
 			addNodes(t, controller, tc.nodes...)
 			addPods(t, controller, fx, tc.pods...)
@@ -1597,6 +1613,53 @@ func TestExternalNameServiceInstances(t *testing.T) {
 	}
 }
 
+func TestExternalNameServiceChain(t *testing.T) {
+	controller, fx := NewFakeControllerWithOptions(t, FakeControllerOptions{})
+	target := func(name string) string {
+		svc := controller.GetService(kube.ServiceHostname(name, "chain", defaultFakeDomainSuffix))
+		if svc == nil {
+			return "<no service>"
+		}
+		instances := controller.InstancesByPort(svc, 80, nil)
+		if len(instances) == 0 {
+			return ""
+		}
+		return instances[0].Endpoint.Address
+	}
+
+	// a -> b -> api.example.com is resolved to api.example.com.
+	createExternalNameService(controller, "b", "chain", []int32{80}, "api.example.com", t, fx.Events)
+	createExternalNameService(controller, "a", "chain", []int32{80}, "B.chain.svc."+defaultFakeDomainSuffix+".", t, fx.Events)
+	if got := target("a"); got != "api.example.com" {
+		t.Fatalf("expected a to resolve to api.example.com, got %q", got)
+	}
+
+	// Changing b changes where a resolves to.
+	b, _ := controller.client.Kube().CoreV1().Services("chain").Get(context.TODO(), "b", metaV1.GetOptions{})
+	b.Spec.ExternalName = "other.example.com"
+	if _, err := controller.client.Kube().CoreV1().Services("chain").Update(context.TODO(), b, metaV1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	retry.UntilSuccessOrFail(t, func() error {
+		if got := target("a"); got != "other.example.com" {
+			return fmt.Errorf("expected a to resolve to other.example.com, got %q", got)
+		}
+		return nil
+	})
+
+	// Pointing b back to a makes a loop, so neither has endpoints.
+	b.Spec.ExternalName = "a.chain.svc." + defaultFakeDomainSuffix
+	if _, err := controller.client.Kube().CoreV1().Services("chain").Update(context.TODO(), b, metaV1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	retry.UntilSuccessOrFail(t, func() error {
+		if got := target("a") + target("b"); got != "" {
+			return fmt.Errorf("expected no endpoints for the loop, got %q", got)
+		}
+		return nil
+	})
+}
+
 func TestController_ExternalNameService(t *testing.T) {
 	for mode, name := range EndpointModeNames {
 		mode := mode
@@ -2566,8 +2629,149 @@ func TestUpdateEdsCacheOnServiceUpdate(t *testing.T) {
 	}
 }
 
+func TestPodEndpointWeightUpdate(t *testing.T) {
+	for mode, name := range EndpointModeNames {
+		mode := mode
+		t.Run(name, func(t *testing.T) {
+			controller, fx := NewFakeControllerWithOptions(t, FakeControllerOptions{Mode: mode})
+
+			pod := generatePod("128.0.0.1", "pod1", "nsA", "", "node1", map[string]string{"app": "prod-app"}, map[string]string{})
+			addPods(t, controller, fx, pod)
+			createService(controller, "svc1", "nsA", nil,
+				[]int32{8080}, map[string]string{"app": "prod-app"}, t)
+			if ev := fx.Wait("service"); ev == nil {
+				t.Fatal("Timeout creating service")
+			}
+			createEndpoints(t, controller, "svc1", "nsA", []string{"tcp-port"}, []string{"128.0.0.1"}, nil, nil)
+			ev := fx.Wait("eds")
+			if ev == nil {
+				t.Fatal("Timeout incremental eds")
+			}
+			if w := ev.Endpoints[0].GetLoadBalancingWeight(); w != 1 {
+				t.Fatalf("expected the default weight 1, got %d", w)
+			}
+
+			// Lowering the weight of the pod rebuilds its endpoints without any change to the endpoints resource.
+			pod, err := controller.client.Kube().CoreV1().Pods("nsA").Get(context.TODO(), "pod1", metaV1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			pod.Annotations = map[string]string{kube.EndpointWeightAnnotation: "25"}
+			if _, err := controller.client.Kube().CoreV1().Pods("nsA").Update(context.TODO(), pod, metaV1.UpdateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if ev = fx.Wait("eds"); ev == nil {
+				t.Fatal("Timeout updating the endpoint weight")
+			}
+			if w := ev.Endpoints[0].GetLoadBalancingWeight(); w != 25 {
+				t.Fatalf("expected weight 25, got %d", w)
+			}
+		})
+	}
+}
+
+func TestRequiredPodConditionsUpdate(t *testing.T) {
+	old := features.SendUnhealthyEndpoints.Load()
+	features.SendUnhealthyEndpoints.Store(true)
+	t.Cleanup(func() { features.SendUnhealthyEndpoints.Store(old) })
+	const warm = coreV1.PodConditionType("example.com/warm")
+	for mode, name := range EndpointModeNames {
+		mode := mode
+		t.Run(name, func(t *testing.T) {
+			controller, fx := NewFakeControllerWithOptions(t, FakeControllerOptions{Mode: mode})
+
+			pod := generatePod("128.0.0.1", "pod1", "nsA", "", "node1", map[string]string{"app": "prod-app"}, map[string]string{})
+			addPods(t, controller, fx, pod)
+			createService(controller, "svc1", "nsA", nil,
+				[]int32{8080}, map[string]string{"app": "prod-app"}, t)
+			if ev := fx.Wait("service"); ev == nil {
+				t.Fatal("Timeout creating service")
+			}
+			createEndpoints(t, controller, "svc1", "nsA", []string{"tcp-port"}, []string{"128.0.0.1"}, nil, nil)
+			expectHealth := func(want model.HealthStatus) {
+				t.Helper()
+				ev := fx.Wait("eds")
+				if ev == nil {
+					t.Fatal("Timeout incremental eds")
+				}
+				if got := ev.Endpoints[0].HealthStatus; got != want {
+					t.Fatalf("expected health %v, got %v", want, got)
+				}
+			}
+			expectHealth(model.Healthy)
+
+			// Requiring a condition the pod does not have rebuilds its endpoints, without any change to the
+			// endpoints resource.
+			svc := getService(controller, "svc1", "nsA", t)
+			svc.Annotations = map[string]string{kube.RequiredPodConditionsAnnotation: string(warm)}
+			updateService(controller, svc, t)
+			expectHealth(model.UnHealthy)
+
+			// A change of the time of a condition only does not change the endpoints.
+			pod, err := controller.client.Kube().CoreV1().Pods("nsA").Get(context.TODO(), "pod1", metaV1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			pod.Status.Conditions[0].LastProbeTime = metaV1.Now()
+			if _, err := controller.client.Kube().CoreV1().Pods("nsA").UpdateStatus(context.TODO(), pod, metaV1.UpdateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if ev := fx.WaitForDuration("eds", 100*time.Millisecond); ev != nil {
+				t.Fatalf("unexpected eds update: %v", ev)
+			}
+
+			// The pod meeting the condition rebuilds its endpoints.
+			pod, err = controller.client.Kube().CoreV1().Pods("nsA").Get(context.TODO(), "pod1", metaV1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			pod.Status.Conditions = append(pod.Status.Conditions, coreV1.PodCondition{Type: warm, Status: coreV1.ConditionTrue})
+			if _, err := controller.client.Kube().CoreV1().Pods("nsA").UpdateStatus(context.TODO(), pod, metaV1.UpdateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			expectHealth(model.Healthy)
+		})
+	}
+}
+
 func clearDiscoverabilityPolicy(ep *model.IstioEndpoint) {
 	if ep != nil {
 		ep.DiscoverabilityPolicy = nil
 	}
 }
+
+func TestServiceAccountWorkloads(t *testing.T) {
+	controller, fx := NewFakeControllerWithOptions(t, FakeControllerOptions{ClusterID: "cluster-1"})
+	controller.network = "network-1"
+	addPods(t, controller, fx,
+		generatePod("128.0.0.1", "pod1", "nsa", "foo", "node1", map[string]string{}, map[string]string{}),
+		generatePod("128.0.0.2", "pod2", "nsb", "bar", "node1", map[string]string{"topology.istio.io/network": "network-2"},
+			map[string]string{}),
+	)
+
+	got := controller.ServiceAccountWorkloads()
+	sort.Slice(got, func(i, j int) bool { return got[i].Name < got[j].Name })
+	want := []model.ServiceAccountWorkload{
+		{
+			ServiceAccount: "spiffe://cluster.local/ns/nsa/sa/foo",
+			Kind:           "Pod",
+			Name:           "pod1",
+			Namespace:      "nsa",
+			Address:        "128.0.0.1",
+			Network:        "network-1",
+			Cluster:        "cluster-1",
+		},
+		{
+			ServiceAccount: "spiffe://cluster.local/ns/nsb/sa/bar",
+			Kind:           "Pod",
+			Name:           "pod2",
+			Namespace:      "nsb",
+			Address:        "128.0.0.2",
+			Network:        "network-2",
+			Cluster:        "cluster-1",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
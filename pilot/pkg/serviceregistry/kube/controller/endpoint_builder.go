@@ -38,6 +38,8 @@ type EndpointBuilder struct {
 	tlsMode        string
 	workloadName   string
 	namespace      string
+	// lbWeight is the load balancing weight of the endpoints, 0 for the default weight.
+	lbWeight uint32
 
 	// Values used to build dns name tables per pod.
 	// The hostname of the Pod, by default equals to pod name.
@@ -63,6 +65,8 @@ func NewEndpointBuilder(c controllerInterface, pod *v1.Pod) *EndpointBuilder {
 		}
 		ip = pod.Status.PodIP
 	}
+	// An invalid weight is reported when the annotation changes, the endpoints keep the default weight.
+	lbWeight, _ := kube.PodLbWeight(pod)
 	dm, _ := kubeUtil.GetDeployMetaFromPod(pod)
 	out := &EndpointBuilder{
 		controller:     c,
@@ -76,6 +80,7 @@ func NewEndpointBuilder(c controllerInterface, pod *v1.Pod) *EndpointBuilder {
 		namespace:    namespace,
 		hostname:     hostname,
 		subDomain:    subdomain,
+		lbWeight:     lbWeight,
 	}
 	networkID := out.endpointNetwork(ip)
 	out.labels = labelutil.AugmentLabels(podLabels, c.Cluster(), locality, networkID)
@@ -126,6 +131,7 @@ func (b *EndpointBuilder) buildIstioEndpoint(
 		TLSMode:               b.tlsMode,
 		Address:               endpointAddress,
 		EndpointPort:          uint32(endpointPort),
+		LbWeight:              b.lbWeight,
 		ServicePortName:       svcPortName,
 		Network:               networkID,
 		WorkloadName:          b.workloadName,
@@ -15,6 +15,7 @@
 package controller
 
 import (
+	"github.com/hashicorp/go-multierror"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -43,6 +44,8 @@ type kubeEndpointsController interface {
 	// forgetEndpoint does internal bookkeeping on a deleted endpoint
 	forgetEndpoint(endpoint any) map[host.Name][]*model.IstioEndpoint
 	getServiceNamespacedName(ep any) types.NamespacedName
+	// listEndpointsForService returns the endpoints objects of the named service
+	listEndpointsForService(name, namespace string) []any
 }
 
 // kubeEndpoints abstracts the common behavior across endpoint and endpoint slices.
@@ -141,6 +144,85 @@ func getPod(c *Controller, ip string, ep *metav1.ObjectMeta, targetRef *v1.Objec
 	return pod, expectPod
 }
 
+// requiredPodConditions returns the pod conditions the named service requires before publishing an endpoint.
+func (c *Controller) requiredPodConditions(name, namespace string) []v1.PodConditionType {
+	svc, err := c.serviceLister.Services(namespace).Get(name)
+	if err != nil || svc == nil {
+		return nil
+	}
+	return getRequiredPodConditions(svc)
+}
+
+// endpointHealth returns the health of an endpoint backed by the pod, taking the pod conditions required by the
+// service into account. The endpoint should not be published if false is returned.
+func endpointHealth(pod *v1.Pod, health model.HealthStatus, required []v1.PodConditionType) (model.HealthStatus, bool) {
+	if health == model.Healthy && !podConditionsMet(pod, required) {
+		return model.UnHealthy, features.SendUnhealthyEndpoints.Load()
+	}
+	return health, true
+}
+
+// onPodConditionsChange rebuilds the endpoints of the services selecting the pod if the change of the pod
+// conditions changes whether the pod meets the conditions required by the service.
+func (c *Controller) onPodConditionsChange(old, cur *v1.Pod) error {
+	services, err := getPodServices(c.serviceLister, cur)
+	if err != nil {
+		return err
+	}
+	var errs *multierror.Error
+	for _, svc := range services {
+		required := getRequiredPodConditions(svc)
+		if len(required) == 0 || podConditionsMet(old, required) == podConditionsMet(cur, required) {
+			continue
+		}
+		errs = multierror.Append(errs, c.resyncServiceEndpoints(svc.Name, svc.Namespace))
+	}
+	return errs.ErrorOrNil()
+}
+
+// requiredPodConditionsFilter resyncs the endpoints of a service when the pod conditions it requires change, as they
+// are only read when the endpoints are built. It never filters out the service update.
+func (c *Controller) requiredPodConditionsFilter(old, cur any) bool {
+	oldSvc, ok := old.(*v1.Service)
+	if !ok {
+		return false
+	}
+	curSvc, ok := cur.(*v1.Service)
+	if !ok {
+		return false
+	}
+	if oldSvc.Annotations[kube.RequiredPodConditionsAnnotation] != curSvc.Annotations[kube.RequiredPodConditionsAnnotation] {
+		c.queue.Push(func() error {
+			return c.resyncServiceEndpoints(curSvc.Name, curSvc.Namespace)
+		})
+	}
+	return false
+}
+
+// resyncServiceEndpoints processes the endpoints of the service again, like on their update, for the changes of
+// the service or its pods that are read when the endpoints are built.
+func (c *Controller) resyncServiceEndpoints(name, namespace string) error {
+	var errs *multierror.Error
+	for _, ep := range c.endpoints.listEndpointsForService(name, namespace) {
+		errs = multierror.Append(errs, c.endpoints.onEvent(ep, model.EventUpdate))
+	}
+	return errs.ErrorOrNil()
+}
+
+// onPodEndpointWeightChange rebuilds the endpoints of the services selecting the pod when the weight of its endpoints
+// changes.
+func (c *Controller) onPodEndpointWeightChange(pod *v1.Pod) error {
+	services, err := getPodServices(c.serviceLister, pod)
+	if err != nil {
+		return err
+	}
+	var errs *multierror.Error
+	for _, svc := range services {
+		errs = multierror.Append(errs, c.resyncServiceEndpoints(svc.Name, svc.Namespace))
+	}
+	return errs.ErrorOrNil()
+}
+
 func (c *Controller) registerEndpointResync(ep *metav1.ObjectMeta, ip string, host host.Name) {
 	// This means, the endpoint event has arrived before pod event.
 	// This might happen because PodCache is eventually consistent.
@@ -172,11 +172,12 @@ func (e *endpointsController) buildIstioEndpoints(endpoint any, host host.Name)
 	ep := endpoint.(*v1.Endpoints)
 
 	discoverabilityPolicy := e.c.exports.EndpointDiscoverabilityPolicy(e.c.GetService(host))
+	required := e.c.requiredPodConditions(ep.Name, ep.Namespace)
 
 	for _, ss := range ep.Subsets {
-		endpoints = append(endpoints, e.buildIstioEndpointFromAddress(ep, ss, ss.Addresses, host, discoverabilityPolicy, model.Healthy)...)
+		endpoints = append(endpoints, e.buildIstioEndpointFromAddress(ep, ss, ss.Addresses, host, discoverabilityPolicy, model.Healthy, required)...)
 		if features.SendUnhealthyEndpoints.Load() {
-			endpoints = append(endpoints, e.buildIstioEndpointFromAddress(ep, ss, ss.NotReadyAddresses, host, discoverabilityPolicy, model.UnHealthy)...)
+			endpoints = append(endpoints, e.buildIstioEndpointFromAddress(ep, ss, ss.NotReadyAddresses, host, discoverabilityPolicy, model.UnHealthy, required)...)
 		}
 	}
 	return endpoints
@@ -222,6 +223,7 @@ func (e *endpointsController) buildServiceInstances(ep *v1.Endpoints, ss v1.Endp
 
 func (e *endpointsController) buildIstioEndpointFromAddress(ep *v1.Endpoints, ss v1.EndpointSubset, endpoints []v1.EndpointAddress,
 	host host.Name, discoverabilityPolicy model.EndpointDiscoverabilityPolicy, health model.HealthStatus,
+	required []v1.PodConditionType,
 ) []*model.IstioEndpoint {
 	var istioEndpoints []*model.IstioEndpoint
 	for _, ea := range endpoints {
@@ -229,11 +231,15 @@ func (e *endpointsController) buildIstioEndpointFromAddress(ep *v1.Endpoints, ss
 		if pod == nil && expectedPod {
 			continue
 		}
+		podHealth, publish := endpointHealth(pod, health, required)
+		if !publish {
+			continue
+		}
 		builder := NewEndpointBuilder(e.c, pod)
 		// EDS and ServiceEntry use name for service port - ADS will need to map to numbers.
 		for _, port := range ss.Ports {
 			istioEndpoint := builder.buildIstioEndpoint(ea.IP, port.Port, port.Name, discoverabilityPolicy)
-			istioEndpoint.HealthStatus = health
+			istioEndpoint.HealthStatus = podHealth
 			istioEndpoints = append(istioEndpoints, istioEndpoint)
 		}
 	}
@@ -250,6 +256,14 @@ func (e *endpointsController) buildIstioEndpointsWithService(name, namespace str
 	return e.buildIstioEndpoints(ep, host)
 }
 
+func (e *endpointsController) listEndpointsForService(name, namespace string) []any {
+	ep, exists, err := e.informer.GetIndexer().GetByKey(kube.KeyFunc(name, namespace))
+	if err != nil || !exists {
+		return nil
+	}
+	return []any{ep}
+}
+
 func (e *endpointsController) getServiceNamespacedName(ep any) types.NamespacedName {
 	endpoint := ep.(*v1.Endpoints)
 	return kube.NamespacedNameForK8sObject(endpoint)
@@ -229,6 +229,7 @@ func (esc *endpointSliceController) updateEndpointCacheForSlice(hostName host.Na
 		return
 	}
 	discoverabilityPolicy := esc.c.exports.EndpointDiscoverabilityPolicy(esc.c.GetService(hostName))
+	required := esc.c.requiredPodConditions(serviceNameForEndpointSlice(slice.Labels), slice.Namespace)
 
 	for _, e := range slice.Endpoints() {
 		if !features.SendUnhealthyEndpoints.Load() {
@@ -237,12 +238,19 @@ func (esc *endpointSliceController) updateEndpointCacheForSlice(hostName host.Na
 				continue
 			}
 		}
-		ready := e.Conditions.Ready == nil || *e.Conditions.Ready
+		health := model.UnHealthy
+		if e.Conditions.Ready == nil || *e.Conditions.Ready {
+			health = model.Healthy
+		}
 		for _, a := range e.Addresses {
 			pod, expectedPod := getPod(esc.c, a, &metav1.ObjectMeta{Name: slice.Name, Namespace: slice.Namespace}, e.TargetRef, hostName)
 			if pod == nil && expectedPod {
 				continue
 			}
+			podHealth, publish := endpointHealth(pod, health, required)
+			if !publish {
+				continue
+			}
 			builder := NewEndpointBuilder(esc.c, pod)
 			// EDS and ServiceEntry use name for service port - ADS will need to map to numbers.
 			for _, port := range slice.Ports() {
@@ -256,11 +264,7 @@ func (esc *endpointSliceController) updateEndpointCacheForSlice(hostName host.Na
 				}
 
 				istioEndpoint := builder.buildIstioEndpoint(a, portNum, portName, discoverabilityPolicy)
-				if ready {
-					istioEndpoint.HealthStatus = model.Healthy
-				} else {
-					istioEndpoint.HealthStatus = model.UnHealthy
-				}
+				istioEndpoint.HealthStatus = podHealth
 				endpoints = append(endpoints, istioEndpoint)
 			}
 		}
@@ -286,6 +290,15 @@ func (esc *endpointSliceController) buildIstioEndpointsWithService(name, namespa
 	return esc.endpointCache.Get(hostName)
 }
 
+func (esc *endpointSliceController) listEndpointsForService(name, namespace string) []any {
+	slices, err := esc.listSlices(namespace, endpointSliceSelectorForService(name))
+	if err != nil {
+		log.Debugf("endpoint slices of (%s, %s) not found => error %v", name, namespace, err)
+		return nil
+	}
+	return slices
+}
+
 func (esc *endpointSliceController) getServiceNamespacedName(es any) types.NamespacedName {
 	slice := es.(metav1.Object)
 	return types.NamespacedName{
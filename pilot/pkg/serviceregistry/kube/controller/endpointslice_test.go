@@ -15,11 +15,15 @@
 package controller
 
 import (
+	"context"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
 	coreV1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	mcs "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
 
 	"istio.io/api/label"
@@ -79,6 +83,62 @@ func TestEndpointSliceFromMCSShouldBeIgnored(t *testing.T) {
 	}
 }
 
+func TestEndpointSliceDualStack(t *testing.T) {
+	const (
+		ns      = "nsa"
+		svcName = "svc1"
+	)
+
+	controller, fx := NewFakeControllerWithOptions(t, FakeControllerOptions{Mode: EndpointSliceOnly})
+
+	createService(controller, svcName, ns, nil, []int32{8080}, map[string]string{"app": "prod-app"}, t)
+	if ev := fx.Wait("service"); ev == nil {
+		t.Fatal("Timeout creating service")
+	}
+	hostname := kube.ServiceHostname(svcName, ns, controller.opts.DomainSuffix)
+	svc := controller.GetService(hostname)
+	if svc == nil {
+		t.Fatal("failed to get service")
+	}
+
+	// The endpoints of a dual-stack service are split in a slice per IP family.
+	portName := "tcp-port"
+	var portNum int32 = 1001
+	for name, slice := range map[string]struct {
+		addressType discovery.AddressType
+		ip          string
+	}{
+		svcName + "-ipv4": {discovery.AddressTypeIPv4, "128.0.0.1"},
+		svcName + "-ipv6": {discovery.AddressTypeIPv6, "fd00::1"},
+	} {
+		endpointSlice := &discovery.EndpointSlice{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+				Labels:    map[string]string{discovery.LabelServiceName: svcName},
+			},
+			AddressType: slice.addressType,
+			Endpoints:   []discovery.Endpoint{{Addresses: []string{slice.ip}}},
+			Ports:       []discovery.EndpointPort{{Name: &portName, Port: &portNum}},
+		}
+		if _, err := controller.client.Kube().DiscoveryV1().EndpointSlices(ns).Create(context.TODO(), endpointSlice, metaV1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if ev := fx.Wait("eds"); ev == nil {
+			t.Fatal("Timeout creating endpoint slice")
+		}
+	}
+
+	var addresses []string
+	for _, instance := range controller.InstancesByPort(svc, svc.Ports[0].Port, nil) {
+		addresses = append(addresses, instance.Endpoint.Address)
+	}
+	sort.Strings(addresses)
+	if want := []string{"128.0.0.1", "fd00::1"}; !reflect.DeepEqual(addresses, want) {
+		t.Fatalf("got endpoints %v, want the endpoints of both IP families %v", addresses, want)
+	}
+}
+
 func TestEndpointSliceCache(t *testing.T) {
 	cache := newEndpointSliceCache()
 	hostname := host.Name("foo")
@@ -0,0 +1,101 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube"
+	"istio.io/istio/pkg/config/host"
+	configkube "istio.io/istio/pkg/config/kube"
+)
+
+// externalNameServiceInstances returns the instances of an ExternalName service. If it points to other ExternalName
+// services, the chain is resolved so the traffic is sent to its end directly, rather than relying on DNS to follow
+// the chain. A chain which loops has no instances.
+func (c *Controller) externalNameServiceInstances(svc *v1.Service, svcConv *model.Service) []*model.ServiceInstance {
+	if svc == nil || svc.Spec.Type != v1.ServiceTypeExternalName || svc.Spec.ExternalName == "" {
+		return nil
+	}
+	target, chain, err := configkube.ResolveExternalName(svc.Name, svc.Namespace, svc.Spec.ExternalName,
+		c.opts.DomainSuffix, c.serviceSpec)
+	if err != nil {
+		log.Warnf("ExternalName service %s/%s has no endpoints: %v: %s", svc.Namespace, svc.Name, err,
+			strings.Join(chain, " -> "))
+		return nil
+	}
+	if len(chain) > 1 {
+		log.Debugf("ExternalName service %s/%s resolves to %s through %s", svc.Namespace, svc.Name, target,
+			strings.Join(chain, " -> "))
+	}
+	return kube.ExternalNameServiceInstances(svc, svcConv, target)
+}
+
+func (c *Controller) serviceSpec(name, namespace string) *v1.ServiceSpec {
+	svc, err := c.serviceLister.Services(namespace).Get(name)
+	if err != nil {
+		return nil
+	}
+	return &svc.Spec
+}
+
+// updateExternalNameServices resolves the ExternalName services other than the changed one again, since their chains
+// may go through it, and notifies the handlers of those resolving to a different host.
+func (c *Controller) updateExternalNameServices(changed host.Name) {
+	services, err := c.serviceLister.List(klabels.Everything())
+	if err != nil {
+		log.Errorf("failed to list services: %v", err)
+		return
+	}
+	for _, svc := range services {
+		if svc.Spec.Type != v1.ServiceTypeExternalName {
+			continue
+		}
+		hostname := kube.ServiceHostname(svc.Name, svc.Namespace, c.opts.DomainSuffix)
+		if hostname == changed {
+			continue
+		}
+		c.RLock()
+		svcConv := c.servicesMap[hostname]
+		prev := c.externalNameSvcInstanceMap[hostname]
+		c.RUnlock()
+		if svcConv == nil {
+			continue
+		}
+		instances := c.externalNameServiceInstances(svc, svcConv)
+		if externalNameTarget(instances) == externalNameTarget(prev) {
+			continue
+		}
+		c.Lock()
+		if len(instances) > 0 {
+			c.externalNameSvcInstanceMap[hostname] = instances
+		} else {
+			delete(c.externalNameSvcInstanceMap, hostname)
+		}
+		c.Unlock()
+		c.handlers.NotifyServiceHandlers(svcConv, model.EventUpdate)
+	}
+}
+
+func externalNameTarget(instances []*model.ServiceInstance) string {
+	if len(instances) == 0 {
+		return ""
+	}
+	return instances[0].Endpoint.Address
+}
@@ -0,0 +1,200 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/controllers"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// GatewayAddressesConfigMap is the name of the ConfigMap, in the istiod namespace of the config cluster, listing the
+// external addresses of the ingress gateways of all the clusters.
+const GatewayAddressesConfigMap = "istio-gateway-addresses"
+
+// GatewayAddresses are the external addresses of an ingress gateway Service in a cluster, from the load balancer
+// status and the external IPs of the Service, and the zones of its endpoints in the cluster.
+type GatewayAddresses struct {
+	Addresses []string `json:"addresses"`
+	Zones     []string `json:"zones,omitempty"`
+}
+
+// GatewayAddressController maintains the GatewayAddressesConfigMap, for external DNS automation to keep the records
+// of the ingress gateways of all the clusters in sync. The ConfigMap has a key per ingress gateway Service, labeled
+// istio=ingressgateway, named <name>.<namespace>, whose value is the JSON of its GatewayAddresses by cluster.
+type GatewayAddressController struct {
+	client    corev1.CoreV1Interface
+	namespace string
+	services  model.ServiceDiscovery
+
+	configMapInformer cache.SharedInformer
+	configMapLister   listerv1.ConfigMapLister
+
+	mu sync.Mutex
+	// queue is the queue of the current run, while leading, or nil.
+	queue *controllers.Queue
+}
+
+// NewGatewayAddressController returns a controller writing the addresses of the ingress gateways of the services to
+// the GatewayAddressesConfigMap of the namespace. It is notified of service changes by the controller. It should be
+// created once and run on each leadership term, as its handlers are only registered here.
+func NewGatewayAddressController(kubeClient kube.Client, namespace string, services model.ServiceDiscovery,
+	controller model.Controller,
+) *GatewayAddressController {
+	c := &GatewayAddressController{
+		client:    kubeClient.Kube().CoreV1(),
+		namespace: namespace,
+		services:  services,
+	}
+	c.configMapInformer = kubeClient.KubeInformer().Core().V1().ConfigMaps().Informer()
+	_ = c.configMapInformer.SetTransform(kube.StripUnusedFields)
+	c.configMapLister = kubeClient.KubeInformer().Core().V1().ConfigMaps().Lister()
+
+	c.configMapInformer.AddEventHandler(controllers.FilteredObjectHandler(func(controllers.Object) {
+		c.enqueue()
+	}, func(o controllers.Object) bool {
+		return o.GetName() == GatewayAddressesConfigMap && o.GetNamespace() == namespace
+	}))
+	controller.AppendServiceHandler(func(svc *model.Service, _ model.Event) {
+		if isIngressGateway(svc) {
+			c.enqueue()
+		}
+	})
+	return c
+}
+
+// Run starts the GatewayAddressController until a value is sent to stopCh. Changes are only handled while running.
+func (c *GatewayAddressController) Run(stopCh <-chan struct{}) {
+	if !kube.WaitForCacheSync(stopCh, c.configMapInformer.HasSynced) {
+		log.Error("Failed to sync gateway address controller cache")
+		return
+	}
+	q := controllers.NewQueue("gateway address controller", controllers.WithReconciler(c.reconcile))
+	c.mu.Lock()
+	c.queue = &q
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.queue = nil
+		c.mu.Unlock()
+	}()
+	q.Add(c.key())
+	q.Run(stopCh)
+}
+
+// enqueue reconciles the GatewayAddressesConfigMap if the controller is running.
+func (c *GatewayAddressController) enqueue() {
+	c.mu.Lock()
+	q := c.queue
+	c.mu.Unlock()
+	if q != nil {
+		q.Add(c.key())
+	}
+}
+
+func (c *GatewayAddressController) key() types.NamespacedName {
+	return types.NamespacedName{Namespace: c.namespace, Name: GatewayAddressesConfigMap}
+}
+
+func (c *GatewayAddressController) reconcile(types.NamespacedName) error {
+	data, err := c.gatewayAddressData()
+	if err != nil {
+		return err
+	}
+	existing, err := c.configMapLister.ConfigMaps(c.namespace).Get(GatewayAddressesConfigMap)
+	if errors.IsNotFound(err) {
+		_, err = c.client.ConfigMaps(c.namespace).Create(context.TODO(), &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: GatewayAddressesConfigMap, Namespace: c.namespace},
+			Data:       data,
+		}, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			// The informer has not seen the ConfigMap yet, its event will requeue the reconciliation.
+			return nil
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(existing.Data, data) || (len(existing.Data) == 0 && len(data) == 0) {
+		return nil
+	}
+	cm := existing.DeepCopy()
+	cm.Data = data
+	_, err = c.client.ConfigMaps(c.namespace).Update(context.TODO(), cm, metav1.UpdateOptions{})
+	return err
+}
+
+// gatewayAddressData returns the data of the GatewayAddressesConfigMap.
+func (c *GatewayAddressController) gatewayAddressData() (map[string]string, error) {
+	data := map[string]string{}
+	for _, svc := range c.services.Services() {
+		if !isIngressGateway(svc) {
+			continue
+		}
+		addresses := svc.Attributes.ClusterExternalAddresses.GetAddresses()
+		if len(addresses) == 0 {
+			continue
+		}
+		zones := map[cluster.ID]sets.Set{}
+		if len(svc.Ports) > 0 {
+			for _, instance := range c.services.InstancesByPort(svc, svc.Ports[0].Port, nil) {
+				_, zone, _ := model.SplitLocalityLabel(instance.Endpoint.Locality.Label)
+				if zone == "" {
+					continue
+				}
+				clusterID := instance.Endpoint.Locality.ClusterID
+				if zones[clusterID] == nil {
+					zones[clusterID] = sets.New()
+				}
+				zones[clusterID].Insert(zone)
+			}
+		}
+		byCluster := map[cluster.ID]GatewayAddresses{}
+		for clusterID, addrs := range addresses {
+			sorted := append([]string{}, addrs...)
+			sort.Strings(sorted)
+			byCluster[clusterID] = GatewayAddresses{Addresses: sorted, Zones: zones[clusterID].SortedList()}
+		}
+		b, err := json.Marshal(byCluster)
+		if err != nil {
+			return nil, err
+		}
+		data[svc.Attributes.Name+"."+svc.Attributes.Namespace] = string(b)
+	}
+	return data, nil
+}
+
+// isIngressGateway returns true for the ingress gateway Services. Only Kubernetes Services have external addresses.
+func isIngressGateway(svc *model.Service) bool {
+	return svc.Attributes.Labels[constants.IstioLabel] == constants.IstioIngressLabelValue
+}
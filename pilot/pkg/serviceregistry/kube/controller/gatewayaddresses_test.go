@@ -0,0 +1,134 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/memory"
+	"istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// serviceHandlers is a model.Controller whose service handlers are called by the test.
+type serviceHandlers struct {
+	memory.ServiceController
+	handlers []func(*model.Service, model.Event)
+}
+
+func (c *serviceHandlers) AppendServiceHandler(f func(*model.Service, model.Event)) {
+	c.handlers = append(c.handlers, f)
+}
+
+func TestGatewayAddressController(t *testing.T) {
+	gateway := &model.Service{
+		Hostname: host.Name("istio-ingressgateway.istio-system.svc.cluster.local"),
+		Ports:    model.PortList{{Name: "http", Port: 80, Protocol: protocol.HTTP}},
+		Attributes: model.ServiceAttributes{
+			Name:      "istio-ingressgateway",
+			Namespace: "istio-system",
+			Labels:    map[string]string{"istio": "ingressgateway"},
+		},
+	}
+	gateway.Attributes.ClusterExternalAddresses.SetAddressesFor("cluster1", []string{"34.1.1.2", "34.1.1.1"})
+	gateway.Attributes.ClusterExternalAddresses.SetAddressesFor("cluster2", []string{"gw.example.com"})
+	other := &model.Service{
+		Hostname: host.Name("other.default.svc.cluster.local"),
+		Ports:    model.PortList{{Name: "http", Port: 80, Protocol: protocol.HTTP}},
+		Attributes: model.ServiceAttributes{
+			Name:      "other",
+			Namespace: "default",
+		},
+	}
+	other.Attributes.ClusterExternalAddresses.SetAddressesFor("cluster1", []string{"34.1.1.3"})
+
+	sd := memory.NewServiceDiscovery(gateway, other)
+	for i, locality := range []model.Locality{
+		{ClusterID: "cluster1", Label: "us-east1/us-east1-b"},
+		{ClusterID: "cluster1", Label: "us-east1/us-east1-c"},
+		{ClusterID: "cluster1", Label: "us-east1/us-east1-b"},
+		{ClusterID: "cluster2", Label: ""},
+	} {
+		sd.AddInstance(gateway.Hostname, &model.ServiceInstance{
+			Service:     gateway,
+			ServicePort: gateway.Ports[0],
+			Endpoint: &model.IstioEndpoint{
+				Address:         "10.0.0." + string(rune('1'+i)),
+				EndpointPort:    8080,
+				ServicePortName: "http",
+				Locality:        locality,
+			},
+		})
+	}
+
+	client := kube.NewFakeClient()
+	handlers := &serviceHandlers{}
+	c := NewGatewayAddressController(client, "istio-system", sd, handlers)
+	stop := test.NewStop(t)
+	client.RunAndWait(stop)
+	running := func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.queue != nil && c.queue.HasSynced()
+	}
+	notifyServiceHandlers := func(svc *model.Service) {
+		for _, h := range handlers.handlers {
+			h(svc, model.EventUpdate)
+		}
+	}
+
+	// Changes are ignored until the controller runs, which reconciles the ConfigMap anyway.
+	notifyServiceHandlers(gateway)
+	leaderStop := make(chan struct{})
+	go c.Run(leaderStop)
+	retry.UntilOrFail(t, running)
+
+	expectConfigMap(t, c.configMapLister, GatewayAddressesConfigMap, "istio-system", map[string]string{
+		"istio-ingressgateway.istio-system": `{"cluster1":{"addresses":["34.1.1.1","34.1.1.2"],"zones":["us-east1-b","us-east1-c"]},` +
+			`"cluster2":{"addresses":["gw.example.com"]}}`,
+	})
+
+	updated := gateway.DeepCopy()
+	updated.Attributes.ClusterExternalAddresses.SetAddresses(map[cluster.ID][]string{"cluster1": {"34.1.1.1"}})
+	sd.AddService(updated)
+	notifyServiceHandlers(updated)
+	expectConfigMap(t, c.configMapLister, GatewayAddressesConfigMap, "istio-system", map[string]string{
+		"istio-ingressgateway.istio-system": `{"cluster1":{"addresses":["34.1.1.1"],"zones":["us-east1-b","us-east1-c"]}}`,
+	})
+
+	// Losing and acquiring the leadership again runs the same controller, without registering more handlers.
+	close(leaderStop)
+	retry.UntilOrFail(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.queue == nil
+	})
+	go c.Run(stop)
+	retry.UntilOrFail(t, running)
+	if len(handlers.handlers) != 1 {
+		t.Fatalf("expected a single service handler, got %d", len(handlers.handlers))
+	}
+	sd.AddService(gateway)
+	notifyServiceHandlers(gateway)
+	expectConfigMap(t, c.configMapLister, GatewayAddressesConfigMap, "istio-system", map[string]string{
+		"istio-ingressgateway.istio-system": `{"cluster1":{"addresses":["34.1.1.1","34.1.1.2"],"zones":["us-east1-b","us-east1-c"]},` +
+			`"cluster2":{"addresses":["gw.example.com"]}}`,
+	})
+}
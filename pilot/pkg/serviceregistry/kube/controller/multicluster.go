@@ -200,6 +200,7 @@ func (m *Multicluster) addCluster(cluster *multicluster.Cluster) (*Controller, *
 	options.ClusterID = cluster.ID
 	// different clusters may have different k8s version, re-apply conditional default
 	options.EndpointMode = DetectEndpointMode(client)
+	options.StalenessThreshold = features.APIServerStalenessThreshold
 	if !configCluster {
 		options.SyncTimeout = features.RemoteClusterTimeout
 	}
@@ -318,6 +319,61 @@ func (m *Multicluster) initializeCluster(cluster *multicluster.Cluster, kubeRegi
 		})
 	}
 
+	if features.PublishGatewayAddresses && configCluster && m.opts.MeshServiceController != nil {
+		log.Infof("joining leader-election for %s in %s on cluster %s",
+			leaderelection.GatewayAddressesController, options.SystemNamespace, options.ClusterID)
+		// The controller is created once, so that its service handler is registered once, and only runs while leading.
+		gc := NewGatewayAddressController(client, options.SystemNamespace, m.opts.MeshServiceController, m.opts.MeshServiceController)
+		// Block server exit on graceful termination of the leader controller.
+		m.s.RunComponentAsyncAndWait(func(_ <-chan struct{}) error {
+			leaderelection.
+				NewLeaderElectionMulticluster(options.SystemNamespace, m.serverID, leaderelection.GatewayAddressesController, m.revision, false, client).
+				AddRunFunction(func(leaderStop <-chan struct{}) {
+					// Start informers again, in case the client was started before the controller was created.
+					client.RunAndWait(clusterStopCh)
+					gc.Run(leaderStop)
+				}).Run(clusterStopCh)
+			return nil
+		})
+	}
+
+	if m.opts.ConfigReplication != nil && !configCluster {
+		if err := m.initConfigReplication(client, kubeRegistry, options, clusterStopCh); err != nil {
+			log.Errorf("failed to initialize config replication for cluster %s: %v", cluster.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// initConfigReplication starts replicating the selected config from the config cluster to the given remote cluster.
+// Replicas of this istiod elect a single writer per source cluster through a lock held in the remote cluster.
+// The destination store watches the replicated kinds in all namespaces, as orphaned replicas must be found wherever
+// they are; writes are limited to the namespaces selected by the remote cluster's discovery selectors.
+func (m *Multicluster) initConfigReplication(client kubelib.Client, kubeRegistry *Controller, options Options,
+	clusterStopCh <-chan struct{},
+) error {
+	rc := m.opts.ConfigReplication
+	dest, err := crdclient.NewForSchemas(client, m.revision, options.DomainSuffix, "config-replication-controller", rc.Schemas())
+	if err != nil {
+		return err
+	}
+	go dest.Run(clusterStopCh)
+	namespaceFilter := func(ns string) bool {
+		return kubeRegistry.opts.DiscoveryNamespacesFilter.Filter(&metav1.ObjectMeta{Namespace: ns})
+	}
+	m.s.RunComponentAsyncAndWait(func(_ <-chan struct{}) error {
+		electionID := leaderelection.ConfigReplicationController + "-" + m.opts.ClusterID.String()
+		leaderelection.
+			NewLeaderElectionMulticluster(options.SystemNamespace, m.serverID, electionID, m.revision, true, client).
+			AddRunFunction(func(leaderStop <-chan struct{}) {
+				if !kubelib.WaitForCacheSync(leaderStop, dest.HasSynced) {
+					return
+				}
+				rc.Run(options.ClusterID, dest, namespaceFilter, leaderStop)
+			}).Run(clusterStopCh)
+		return nil
+	})
 	return nil
 }
 
@@ -122,7 +122,7 @@ func (nc *NamespaceController) insertDataForNamespace(o types.NamespacedName) er
 		Namespace: ns,
 		Labels:    configMapLabel,
 	}
-	return k8s.InsertDataToConfigMap(nc.client, nc.configmapLister, meta, nc.caBundleWatcher.GetCABundle())
+	return k8s.InsertDataToConfigMap(nc.client, nc.configmapLister, meta, nc.caBundleWatcher.GetCABundle(), nc.caBundleWatcher.GetCRL())
 }
 
 // On namespace change, update the config map.
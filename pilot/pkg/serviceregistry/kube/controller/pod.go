@@ -112,6 +112,35 @@ func GetPodConditionFromList(conditions []v1.PodCondition, conditionType v1.PodC
 	return -1, nil
 }
 
+// podConditionsMet returns true if all the given conditions are true for the pod.
+// An endpoint without a pod has no conditions to check, so it always meets them.
+func podConditionsMet(pod *v1.Pod, conditions []v1.PodConditionType) bool {
+	if pod == nil {
+		return true
+	}
+	for _, t := range conditions {
+		_, condition := GetPodCondition(&pod.Status, t)
+		if condition == nil || condition.Status != v1.ConditionTrue {
+			return false
+		}
+	}
+	return true
+}
+
+// podConditionStatusesChanged returns true if the status of a condition of the pod changed, ignoring the changes of
+// their probe and transition times, reasons and messages.
+func podConditionStatusesChanged(old, cur *v1.Pod) bool {
+	if len(old.Status.Conditions) != len(cur.Status.Conditions) {
+		return true
+	}
+	for _, c := range cur.Status.Conditions {
+		if _, o := GetPodCondition(&old.Status, c.Type); o == nil || o.Status != c.Status {
+			return true
+		}
+	}
+	return false
+}
+
 func (pc *PodCache) labelFilter(old, cur interface{}) bool {
 	oldPod := old.(*v1.Pod)
 	curPod := cur.(*v1.Pod)
@@ -121,6 +150,26 @@ func (pc *PodCache) labelFilter(old, cur interface{}) bool {
 		pc.proxyUpdates(curPod.Status.PodIP)
 	}
 
+	// Endpoints only change when the pod readiness changes, so conditions required by services
+	// need to be tracked here. Only their statuses matter, the services requiring them are checked when handled.
+	if curPod.Status.PodIP != "" && podConditionStatusesChanged(oldPod, curPod) {
+		pc.c.queue.Push(func() error {
+			return pc.c.onPodConditionsChange(oldPod, curPod)
+		})
+	}
+
+	// The weight of the endpoints of the pod is only read when the endpoints are built, so rebuild them when the
+	// annotation setting it changes.
+	oldWeight, curWeight := oldPod.Annotations[kube.EndpointWeightAnnotation], curPod.Annotations[kube.EndpointWeightAnnotation]
+	if curPod.Status.PodIP != "" && oldWeight != curWeight {
+		if _, err := kube.PodLbWeight(curPod); err != nil {
+			log.Warnf("pod %s/%s: %v", curPod.Namespace, curPod.Name, err)
+		}
+		pc.c.queue.Push(func() error {
+			return pc.c.onPodEndpointWeightChange(curPod)
+		})
+	}
+
 	// always continue calling pc.onEvent
 	return false
 }
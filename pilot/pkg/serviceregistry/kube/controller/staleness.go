@@ -0,0 +1,107 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"istio.io/istio/pkg/cluster"
+)
+
+// apiServerMonitor probes the API server of a cluster, and switches the registry of the cluster to a stale-but-serving
+// mode when the API server has been unreachable for longer than the threshold. In this mode the registry keeps
+// serving the last-known state of the cluster, which the informers hold, the pushes mark the cluster as stale in the
+// control plane identifier of their responses, and the staleness is exported as metrics.
+type apiServerMonitor struct {
+	cluster   cluster.ID
+	threshold time.Duration
+	// probe returns an error if the API server is unreachable, or does not answer before the context is done.
+	probe func(ctx context.Context) error
+	// onChange is called when the registry enters or leaves the stale mode.
+	onChange func(stale bool)
+
+	lastContact time.Time
+	stale       bool
+}
+
+func newAPIServerMonitor(c *Controller) *apiServerMonitor {
+	return &apiServerMonitor{
+		cluster:   c.Cluster(),
+		threshold: c.opts.StalenessThreshold,
+		probe: func(ctx context.Context) error {
+			discovery := c.client.Kube().Discovery()
+			if discovery.RESTClient() == nil {
+				// The fake clients have no REST client.
+				_, err := discovery.ServerVersion()
+				return err
+			}
+			return discovery.RESTClient().Get().AbsPath("/version").Do(ctx).Error()
+		},
+		onChange: c.onStalenessChange,
+	}
+}
+
+// interval returns the interval of the probes, a few per threshold, which also bounds how long a probe can take.
+func (m *apiServerMonitor) interval() time.Duration {
+	interval := m.threshold / 3
+	if interval < time.Second {
+		interval = time.Second
+	}
+	return interval
+}
+
+// run probes the API server until stop is closed.
+func (m *apiServerMonitor) run(stop <-chan struct{}) {
+	m.lastContact = time.Now()
+	t := time.NewTicker(m.interval())
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			apiServerStale.With(clusterTag.Value(m.cluster.String())).Record(0)
+			return
+		case now := <-t.C:
+			m.check(now)
+		}
+	}
+}
+
+// check probes the API server and enters or leaves the stale mode.
+func (m *apiServerMonitor) check(now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.interval())
+	err := m.probe(ctx)
+	cancel()
+	if err == nil {
+		m.lastContact = now
+	}
+	apiServerLastContact.With(clusterTag.Value(m.cluster.String())).Record(now.Sub(m.lastContact).Seconds())
+
+	switch {
+	case err == nil && m.stale:
+		log.Infof("API server of cluster %s is reachable again, the registry of the cluster is no longer stale", m.cluster)
+		m.stale = false
+		apiServerStale.With(clusterTag.Value(m.cluster.String())).Record(0)
+		m.onChange(false)
+	case err != nil && !m.stale && now.Sub(m.lastContact) >= m.threshold:
+		log.Warnf("API server of cluster %s unreachable since %v, serving the last-known state of the registry of the cluster: %v",
+			m.cluster, m.lastContact.Format(time.RFC3339), err)
+		m.stale = true
+		apiServerStale.With(clusterTag.Value(m.cluster.String())).Record(1)
+		m.onChange(true)
+	case err != nil:
+		log.Debugf("API server of cluster %s unreachable: %v", m.cluster, err)
+	}
+}
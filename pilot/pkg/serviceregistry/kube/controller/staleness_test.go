@@ -0,0 +1,89 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestAPIServerMonitor(t *testing.T) {
+	var probeErr error
+	var changes []bool
+	start := time.Now()
+	m := &apiServerMonitor{
+		cluster:     "stale-cluster",
+		threshold:   time.Minute,
+		probe:       func(context.Context) error { return probeErr },
+		onChange:    func(stale bool) { changes = append(changes, stale) },
+		lastContact: start,
+	}
+
+	m.check(start.Add(20 * time.Second))
+	assert.Equal(t, m.stale, false)
+
+	// A short disconnect does not switch the registry to the stale mode.
+	probeErr = errors.New("connection refused")
+	m.check(start.Add(40 * time.Second))
+	m.check(start.Add(70 * time.Second))
+	assert.Equal(t, m.stale, false)
+	assert.Equal(t, len(changes), 0)
+
+	// The API server was last reachable a threshold ago.
+	m.check(start.Add(80 * time.Second))
+	assert.Equal(t, m.stale, true)
+	assert.Equal(t, changes, []bool{true})
+
+	// Further failures do not push again.
+	m.check(start.Add(120 * time.Second))
+	assert.Equal(t, changes, []bool{true})
+
+	probeErr = nil
+	m.check(start.Add(140 * time.Second))
+	assert.Equal(t, m.stale, false)
+	assert.Equal(t, changes, []bool{true, false})
+}
+
+func TestAPIServerMonitorProbeTimeout(t *testing.T) {
+	m := &apiServerMonitor{
+		cluster:   "stale-cluster",
+		threshold: 3 * time.Second,
+		probe: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		onChange:    func(bool) {},
+		lastContact: time.Now(),
+	}
+	start := time.Now()
+	m.check(start)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected the probe to time out after %v, took %v", m.interval(), elapsed)
+	}
+}
+
+func TestStalenessChangeWithoutXDSUpdater(t *testing.T) {
+	c := &Controller{opts: Options{ClusterID: "stale-cluster"}, stale: atomic.NewBool(false)}
+	c.onStalenessChange(true)
+	assert.Equal(t, c.Stale(), true)
+	c.onStalenessChange(false)
+	assert.Equal(t, c.Stale(), false)
+}
@@ -55,6 +55,49 @@ func getLabelValue(metadata metav1.ObjectMeta, label string, fallBackLabel strin
 	return metaLabels[fallBackLabel]
 }
 
+// getCustomLabelValue returns the value of the custom label if set on the object, and falls back to the
+// standard labels otherwise.
+func getCustomLabelValue(metadata metav1.ObjectMeta, custom string, label string, fallBackLabel string) string {
+	if custom != "" {
+		if val := metadata.GetLabels()[custom]; val != "" {
+			return val
+		}
+	}
+	return getLabelValue(metadata, label, fallBackLabel)
+}
+
+// localityNodeLabels names the node labels holding each level of the locality.
+type localityNodeLabels struct {
+	region  string
+	zone    string
+	subzone string
+}
+
+// parseLocalityNodeLabels parses a list of region=<label>, zone=<label> and subzone=<label> entries.
+func parseLocalityNodeLabels(s string) (localityNodeLabels, error) {
+	out := localityNodeLabels{}
+	if s == "" {
+		return out, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		level, name, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || name == "" {
+			return localityNodeLabels{}, fmt.Errorf("invalid entry %q, expected <level>=<label>", entry)
+		}
+		switch level {
+		case "region":
+			out.region = name
+		case "zone":
+			out.zone = name
+		case "subzone":
+			out.subzone = name
+		default:
+			return localityNodeLabels{}, fmt.Errorf("invalid locality level %q, expected region, zone or subzone", level)
+		}
+	}
+	return out, nil
+}
+
 // Forked from Kubernetes k8s.io/kubernetes/pkg/api/v1/pod
 // FindPort locates the container port for the given pod and portName.  If the
 // targetPort is a number, use that.  If the targetPort is a string, look that
@@ -193,6 +236,21 @@ func getNodeSelectorsForService(svc *v1.Service) labels.Instance {
 	return nil
 }
 
+// getRequiredPodConditions returns the pod conditions the service requires before publishing an endpoint.
+func getRequiredPodConditions(svc *v1.Service) []v1.PodConditionType {
+	value := svc.Annotations[kube.RequiredPodConditionsAnnotation]
+	if value == "" {
+		return nil
+	}
+	var conditions []v1.PodConditionType
+	for _, c := range strings.Split(value, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			conditions = append(conditions, v1.PodConditionType(c))
+		}
+	}
+	return conditions
+}
+
 func nodeEquals(a, b kubernetesNode) bool {
 	return a.address == b.address && a.labels.Equals(b.labels)
 }
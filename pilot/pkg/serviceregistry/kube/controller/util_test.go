@@ -22,6 +22,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube"
 	"istio.io/istio/pkg/config/labels"
 )
 
@@ -203,3 +204,93 @@ func makeFakeSvc(nodeSelector string) *v1.Service {
 	}
 	return svc
 }
+
+func TestRequiredPodConditions(t *testing.T) {
+	pod := func(conditions ...v1.PodCondition) *v1.Pod {
+		return &v1.Pod{Status: v1.PodStatus{Conditions: conditions}}
+	}
+	warmed := v1.PodCondition{Type: "example.com/warmed", Status: v1.ConditionTrue}
+	notWarmed := v1.PodCondition{Type: "example.com/warmed", Status: v1.ConditionFalse}
+
+	testCases := []struct {
+		name       string
+		annotation string
+		pod        *v1.Pod
+		expected   model.HealthStatus
+		publish    bool
+	}{
+		{
+			name:     "no annotation",
+			pod:      pod(notWarmed),
+			expected: model.Healthy,
+			publish:  true,
+		},
+		{
+			name:       "condition true",
+			annotation: "example.com/warmed",
+			pod:        pod(warmed),
+			expected:   model.Healthy,
+			publish:    true,
+		},
+		{
+			name:       "condition false",
+			annotation: "example.com/warmed",
+			pod:        pod(notWarmed),
+			expected:   model.UnHealthy,
+		},
+		{
+			name:       "condition missing",
+			annotation: "example.com/warmed, example.com/other",
+			pod:        pod(warmed),
+			expected:   model.UnHealthy,
+		},
+		{
+			name:       "endpoint without pod",
+			annotation: "example.com/warmed",
+			expected:   model.Healthy,
+			publish:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := makeFakeSvc("")
+			if tc.annotation != "" {
+				svc.Annotations = map[string]string{kube.RequiredPodConditionsAnnotation: tc.annotation}
+			}
+			health, publish := endpointHealth(tc.pod, model.Healthy, getRequiredPodConditions(svc))
+			if health != tc.expected || publish != tc.publish {
+				t.Errorf("expected health %v publish %v, got %v %v", tc.expected, tc.publish, health, publish)
+			}
+		})
+	}
+}
+
+func TestParseLocalityNodeLabels(t *testing.T) {
+	testCases := []struct {
+		in       string
+		expected localityNodeLabels
+		err      bool
+	}{
+		{in: ""},
+		{
+			in:       "region=example.com/dc, zone=example.com/room,subzone=example.com/rack",
+			expected: localityNodeLabels{region: "example.com/dc", zone: "example.com/room", subzone: "example.com/rack"},
+		},
+		{in: "subzone=example.com/rack", expected: localityNodeLabels{subzone: "example.com/rack"}},
+		{in: "rack=example.com/rack", err: true},
+		{in: "zone", err: true},
+		{in: "zone=", err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := parseLocalityNodeLabels(tc.in)
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			if got != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
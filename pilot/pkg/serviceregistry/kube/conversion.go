@@ -15,6 +15,8 @@
 package kube
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 
 	coreV1 "k8s.io/api/core/v1"
@@ -43,6 +45,22 @@ const (
 	// It is used for multi-cluster scenario, and with nodePort type gateway service.
 	// TODO: move to API
 	NodeSelectorAnnotation = "traffic.istio.io/nodeSelector"
+
+	// RequiredPodConditionsAnnotation is a comma separated list of pod condition types that, in addition to
+	// readiness, must be true before a pod is published as a healthy endpoint of the service.
+	// This allows workloads to signal conditions such as a warmed cache without failing their readiness probe.
+	// TODO: move to API
+	RequiredPodConditionsAnnotation = "networking.istio.io/requiredPodConditions"
+
+	// EndpointWeightAnnotation sets, on a pod, the load balancing weight of its endpoints, between 1 and
+	// MaxEndpointWeight. Endpoints without it have a weight of 1, so draining pods gradually requires setting a
+	// higher weight on the other pods first, then lowering the weight of the pods to drain.
+	// TODO: move to API
+	EndpointWeightAnnotation = "networking.istio.io/endpointWeight"
+
+	// MaxEndpointWeight is the highest weight EndpointWeightAnnotation can set. It keeps the sum of the weights of
+	// the endpoints of a locality within the limits of Envoy.
+	MaxEndpointWeight = 10000
 )
 
 func convertPort(port coreV1.ServicePort) *model.Port {
@@ -63,10 +81,16 @@ func ConvertService(svc coreV1.Service, domainSuffix string, clusterID cluster.I
 		meshExternal = true
 	}
 
+	var addrs []string
 	if svc.Spec.ClusterIP == coreV1.ClusterIPNone { // headless services should not be load balanced
 		resolution = model.Passthrough
 	} else if svc.Spec.ClusterIP != "" {
 		addr = svc.Spec.ClusterIP
+		// Dual-stack services have a cluster IP of each family, the first one being the primary cluster IP.
+		addrs = svc.Spec.ClusterIPs
+	}
+	if len(addrs) == 0 {
+		addrs = []string{addr}
 	}
 
 	ports := make([]*model.Port, 0, len(svc.Spec.Ports))
@@ -96,7 +120,7 @@ func ConvertService(svc coreV1.Service, domainSuffix string, clusterID cluster.I
 		Hostname: ServiceHostname(svc.Name, svc.Namespace, domainSuffix),
 		ClusterVIPs: model.AddressMap{
 			Addresses: map[cluster.ID][]string{
-				clusterID: {addr},
+				clusterID: addrs,
 			},
 		},
 		Ports:           ports,
@@ -113,6 +137,8 @@ func ConvertService(svc coreV1.Service, domainSuffix string, clusterID cluster.I
 			Labels:          svc.Labels,
 			ExportTo:        exportTo,
 			LabelSelectors:  svc.Spec.Selector,
+			DNSTTL:          model.DNSTTLFromAnnotations(svc.Annotations),
+			TCPIdleTimeouts: model.TCPIdleTimeoutsFromAnnotations(svc.Annotations),
 		},
 	}
 
@@ -155,7 +181,9 @@ func ConvertService(svc coreV1.Service, domainSuffix string, clusterID cluster.I
 	return istioService
 }
 
-func ExternalNameServiceInstances(k8sSvc *coreV1.Service, svc *model.Service) []*model.ServiceInstance {
+// ExternalNameServiceInstances returns the instances of an ExternalName service, sending traffic to target: the
+// externalName of the service, or the host the chain of ExternalName services it points to resolves to.
+func ExternalNameServiceInstances(k8sSvc *coreV1.Service, svc *model.Service, target string) []*model.ServiceInstance {
 	if k8sSvc == nil || k8sSvc.Spec.Type != coreV1.ServiceTypeExternalName || k8sSvc.Spec.ExternalName == "" {
 		return nil
 	}
@@ -172,7 +200,7 @@ func ExternalNameServiceInstances(k8sSvc *coreV1.Service, svc *model.Service) []
 			Service:     svc,
 			ServicePort: portEntry,
 			Endpoint: &model.IstioEndpoint{
-				Address:               k8sSvc.Spec.ExternalName,
+				Address:               target,
 				EndpointPort:          uint32(portEntry.Port),
 				ServicePortName:       portEntry.Name,
 				Labels:                k8sSvc.Labels,
@@ -219,6 +247,29 @@ func PodTLSMode(pod *coreV1.Pod) string {
 	return model.GetTLSModeFromEndpointLabels(pod.Labels)
 }
 
+// PodLbWeight returns the load balancing weight of the endpoints of the pod set by EndpointWeightAnnotation, or 0 if
+// the pod does not set it. An invalid weight is returned as an error.
+func PodLbWeight(pod *coreV1.Pod) (uint32, error) {
+	if pod == nil {
+		return 0, nil
+	}
+	value, ok := pod.Annotations[EndpointWeightAnnotation]
+	if !ok {
+		return 0, nil
+	}
+	return ParseEndpointWeight(value)
+}
+
+// ParseEndpointWeight parses the value of EndpointWeightAnnotation.
+func ParseEndpointWeight(value string) (uint32, error) {
+	weight, err := strconv.ParseUint(strings.TrimSpace(value), 10, 32)
+	if err != nil || weight == 0 || weight > MaxEndpointWeight {
+		return 0, fmt.Errorf("invalid %s annotation %q, expected a weight between 1 and %d",
+			EndpointWeightAnnotation, value, MaxEndpointWeight)
+	}
+	return uint32(weight), nil
+}
+
 // KeyFunc is the internal API key function that returns "namespace"/"name" or
 // "name" if "namespace" is empty
 func KeyFunc(name, namespace string) string {
@@ -26,6 +26,7 @@ import (
 
 	"istio.io/api/annotation"
 	"istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/kube"
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/spiffe"
@@ -149,7 +150,8 @@ func TestServiceConversion(t *testing.T) {
 			Annotations: map[string]string{
 				annotation.AlphaKubernetesServiceAccounts.Name: saA + "," + saB,
 				annotation.AlphaCanonicalServiceAccounts.Name:  saC + "," + saD,
-				"other/annotation": "test",
+				constants.DNSTTLAnnotation:                     "5s",
+				"other/annotation":                             "test",
 			},
 			CreationTimestamp: metaV1.Time{Time: tnow},
 		},
@@ -214,6 +216,10 @@ func TestServiceConversion(t *testing.T) {
 			localSvc.Spec.Selector)
 	}
 
+	if service.Attributes.DNSTTL != 5*time.Second {
+		t.Fatalf("service DNS TTL incorrect => %v, want 5s", service.Attributes.DNSTTL)
+	}
+
 	sa := service.ServiceAccounts
 	if sa == nil || len(sa) != 4 {
 		t.Fatalf("number of service accounts is incorrect")
@@ -268,6 +274,26 @@ func TestServiceConversionWithEmptyServiceAccountsAnnotation(t *testing.T) {
 	}
 }
 
+func TestDualStackServiceConversion(t *testing.T) {
+	svc := coreV1.Service{
+		ObjectMeta: metaV1.ObjectMeta{Name: "service1", Namespace: "default"},
+		Spec: coreV1.ServiceSpec{
+			ClusterIP:  "10.0.0.1",
+			ClusterIPs: []string{"10.0.0.1", "fd00::1"},
+			IPFamilies: []coreV1.IPFamily{coreV1.IPv4Protocol, coreV1.IPv6Protocol},
+			Ports:      []coreV1.ServicePort{{Name: "http", Port: 8080, Protocol: coreV1.ProtocolTCP}},
+		},
+	}
+
+	service := ConvertService(svc, domainSuffix, clusterID)
+	if service.DefaultAddress != "10.0.0.1" {
+		t.Fatalf("default address is %s, expected the primary cluster IP 10.0.0.1", service.DefaultAddress)
+	}
+	if got := service.ClusterVIPs.GetAddressesFor(clusterID); !reflect.DeepEqual(got, []string{"10.0.0.1", "fd00::1"}) {
+		t.Fatalf("cluster VIPs are %v, expected both cluster IPs", got)
+	}
+}
+
 func TestExternalServiceConversion(t *testing.T) {
 	serviceName := "service1"
 	namespace := "default"
@@ -437,3 +463,32 @@ func TestSecureNamingSAN(t *testing.T) {
 		t.Fatalf("SAN match failed, SAN:%v  expectedSAN:%v", san, expectedSAN)
 	}
 }
+
+func TestPodLbWeight(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		weight      uint32
+		err         bool
+	}{
+		{name: "no annotation"},
+		{name: "weight", annotations: map[string]string{EndpointWeightAnnotation: "25"}, weight: 25},
+		{name: "max weight", annotations: map[string]string{EndpointWeightAnnotation: "10000"}, weight: MaxEndpointWeight},
+		{name: "zero", annotations: map[string]string{EndpointWeightAnnotation: "0"}, err: true},
+		{name: "too high", annotations: map[string]string{EndpointWeightAnnotation: "10001"}, err: true},
+		{name: "negative", annotations: map[string]string{EndpointWeightAnnotation: "-1"}, err: true},
+		{name: "not a number", annotations: map[string]string{EndpointWeightAnnotation: "half"}, err: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &coreV1.Pod{ObjectMeta: metaV1.ObjectMeta{Name: "pod", Namespace: "ns", Annotations: tc.annotations}}
+			weight, err := PodLbWeight(pod)
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			if weight != tc.weight {
+				t.Fatalf("expected weight %d, got %d", tc.weight, weight)
+			}
+		})
+	}
+}
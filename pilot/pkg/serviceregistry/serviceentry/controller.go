@@ -105,6 +105,9 @@ type Controller struct {
 	// Indicates whether this controller is for workload entries.
 	workloadEntryController bool
 
+	// srv resolves the endpoints of ServiceEntries from DNS SRV records.
+	srv *srvResolver
+
 	model.NetworkGatewaysHandler
 }
 
@@ -167,7 +170,9 @@ func newController(store model.ConfigStore, xdsUpdater model.XDSUpdater, options
 			servicesBySE: map[types.NamespacedName][]*model.Service{},
 		},
 		edsQueue: queue.NewQueue(time.Second),
+		srv:      newSRVResolver(lookupSRV),
 	}
+	s.srv.onChange = s.srvEndpointsChanged
 	for _, o := range options {
 		o(s)
 	}
@@ -242,7 +247,7 @@ func (s *Controller) workloadEntryHandler(old, curr config.Config, event model.E
 		}
 	}
 
-	cfgs, _ := s.store.List(gvk.ServiceEntry, curr.Namespace)
+	cfgs := s.selectingServiceEntries(curr.Namespace)
 	currSes := getWorkloadServiceEntries(cfgs, wle)
 	var oldSes map[types.NamespacedName]*config.Config
 	if oldWle != nil {
@@ -337,6 +342,23 @@ func getUpdatedConfigs(services []*model.Service) map[model.ConfigKey]struct{} {
 
 // serviceEntryHandler defines the handler for service entries
 func (s *Controller) serviceEntryHandler(_, curr config.Config, event model.Event) {
+	s.srv.handlerMu.Lock()
+	defer s.srv.handlerMu.Unlock()
+	s.handleServiceEntry(s.srv.resolve(curr, event), event)
+}
+
+// srvEndpointsChanged updates the endpoints of a ServiceEntry when they are resolved again from its SRV record.
+func (s *Controller) srvEndpointsChanged(key types.NamespacedName, w *srvWatch) {
+	s.srv.handlerMu.Lock()
+	defer s.srv.handlerMu.Unlock()
+	cfg, ok := s.srv.current(key, w)
+	if !ok {
+		return
+	}
+	s.handleServiceEntry(s.srv.resolve(cfg, model.EventUpdate), model.EventUpdate)
+}
+
+func (s *Controller) handleServiceEntry(curr config.Config, event model.Event) {
 	log.Debugf("Handle event %s for service entry %s/%s", event, curr.Namespace, curr.Name)
 	currentServiceEntry := curr.Spec.(*networking.ServiceEntry)
 	cs := convertServices(curr)
@@ -486,8 +508,7 @@ func (s *Controller) WorkloadInstanceHandler(wi *model.WorkloadInstance, event m
 		return
 	}
 
-	// We will only select entries in the same namespace
-	cfgs, _ := s.store.List(gvk.ServiceEntry, wi.Namespace)
+	cfgs := s.selectingServiceEntries(wi.Namespace)
 	if len(cfgs) == 0 {
 		s.mutex.Unlock()
 		return
@@ -769,6 +790,27 @@ func portMatchSingle(instance *model.ServiceInstance, port int) bool {
 	return port == 0 || port == instance.ServicePort.Port
 }
 
+// ServiceAccountWorkloads lists the WorkloadEntries with their service account identity. The pods are listed by the
+// Kubernetes registries.
+func (s *Controller) ServiceAccountWorkloads() []model.ServiceAccountWorkload {
+	var out []model.ServiceAccountWorkload
+	s.workloadInstances.ForEach(func(wi *model.WorkloadInstance) {
+		if wi.Kind != model.WorkloadEntryKind {
+			return
+		}
+		out = append(out, model.ServiceAccountWorkload{
+			ServiceAccount: wi.Endpoint.ServiceAccount,
+			Kind:           wi.Kind.String(),
+			Name:           wi.Name,
+			Namespace:      wi.Namespace,
+			Address:        wi.Endpoint.Address,
+			Network:        wi.Endpoint.Network,
+			Cluster:        wi.Endpoint.Locality.ClusterID,
+		})
+	})
+	return out
+}
+
 // GetProxyServiceInstances lists service instances co-located with a given proxy
 // NOTE: The service objects in these instances do not have the auto allocated IP set.
 func (s *Controller) GetProxyServiceInstances(node *model.Proxy) []*model.ServiceInstance {
@@ -942,6 +984,22 @@ func parseHealthAnnotation(s string) bool {
 	return p
 }
 
+// selectingServiceEntries returns the ServiceEntries whose workload selector may select workloads in the namespace.
+func (s *Controller) selectingServiceEntries(namespace string) []config.Config {
+	if !features.EnableCrossNamespaceServiceEntrySelector {
+		cfgs, _ := s.store.List(gvk.ServiceEntry, namespace)
+		return cfgs
+	}
+	all, _ := s.store.List(gvk.ServiceEntry, model.NamespaceAll)
+	cfgs := make([]config.Config, 0, len(all))
+	for _, cfg := range all {
+		if selectsNamespace(cfg, namespace) {
+			cfgs = append(cfgs, cfg)
+		}
+	}
+	return cfgs
+}
+
 func (s *Controller) buildServiceInstances(
 	curr config.Config,
 	services []*model.Service,
@@ -951,8 +1009,10 @@ func (s *Controller) buildServiceInstances(
 	serviceInstancesByConfig := map[configKey][]*model.ServiceInstance{}
 	// for service entry with labels
 	if currentServiceEntry.WorkloadSelector != nil {
-		selector := workloadinstances.ByServiceSelector(curr.Namespace, currentServiceEntry.WorkloadSelector.Labels)
-		workloadInstances := workloadinstances.FindAllInIndex(s.workloadInstances, selector)
+		selector := labels.Instance(currentServiceEntry.WorkloadSelector.Labels)
+		workloadInstances := workloadinstances.FindAllInIndex(s.workloadInstances, func(wi *model.WorkloadInstance) bool {
+			return selectsNamespace(curr, wi.Namespace) && selector.SubsetOf(wi.Endpoint.Labels)
+		})
 		for _, wi := range workloadInstances {
 			if wi.DNSServiceEntryOnly && currentServiceEntry.Resolution != networking.ServiceEntry_DNS &&
 				currentServiceEntry.Resolution != networking.ServiceEntry_DNS_ROUND_ROBIN {
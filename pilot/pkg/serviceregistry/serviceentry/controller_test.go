@@ -27,6 +27,7 @@ import (
 	"istio.io/api/label"
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pkg/cluster"
 	"istio.io/istio/pkg/config"
@@ -1307,6 +1308,52 @@ func expectServiceInstances(t testing.TB, sd *Controller, cfg *config.Config, po
 	}, retry.Converge(2), retry.Timeout(time.Second*1))
 }
 
+func TestServiceDiscoveryWorkloadInstanceCrossNamespace(t *testing.T) {
+	test.SetBoolForTest(t, &features.EnableCrossNamespaceServiceEntrySelector, true)
+	store, sd, events := initServiceDiscovery(t)
+
+	shared := selector.DeepCopy()
+	shared.Annotations = map[string]string{constants.WorkloadSelectorNamespacesAnnotation: "infra"}
+	selected := &model.WorkloadInstance{
+		Name:      "shared",
+		Namespace: "infra",
+		Endpoint: &model.IstioEndpoint{
+			Address:        "4.4.4.4",
+			Labels:         map[string]string{"app": "wle"},
+			ServiceAccount: spiffe.MustGenSpiffeURI("infra", "default"),
+			TLSMode:        model.IstioMutualTLSModeLabel,
+		},
+	}
+	unselected := &model.WorkloadInstance{
+		Name:      "shared",
+		Namespace: "other",
+		Endpoint: &model.IstioEndpoint{
+			Address:        "5.5.5.5",
+			Labels:         map[string]string{"app": "wle"},
+			ServiceAccount: spiffe.MustGenSpiffeURI("other", "default"),
+			TLSMode:        model.IstioMutualTLSModeLabel,
+		},
+	}
+
+	createConfigs([]*config.Config{&shared}, store, t)
+	expectEvents(t, events,
+		Event{kind: "svcupdate", host: "selector.com", namespace: selector.Namespace},
+		Event{kind: "xds"})
+
+	callInstanceHandlers([]*model.WorkloadInstance{unselected, selected}, sd, model.EventAdd, t)
+	instances := []*model.ServiceInstance{
+		makeInstance(&shared, "4.4.4.4", 444, shared.Spec.(*networking.ServiceEntry).Ports[0], map[string]string{"app": "wle"}, MTLSUnlabelled),
+		makeInstance(&shared, "4.4.4.4", 445, shared.Spec.(*networking.ServiceEntry).Ports[1], map[string]string{"app": "wle"}, MTLSUnlabelled),
+	}
+	for _, i := range instances {
+		i.Endpoint.ServiceAccount = selected.Endpoint.ServiceAccount
+	}
+	expectProxyInstances(t, sd, instances, "4.4.4.4")
+	expectProxyInstances(t, sd, []*model.ServiceInstance{}, "5.5.5.5")
+	expectServiceInstances(t, sd, &shared, 0, instances)
+	expectEvents(t, events, Event{kind: "eds", host: "selector.com", namespace: selector.Namespace, endpoints: 2})
+}
+
 func TestServiceDiscoveryGetProxyServiceInstances(t *testing.T) {
 	store, sd, _ := initServiceDiscovery(t)
 
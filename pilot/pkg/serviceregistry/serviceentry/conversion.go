@@ -15,6 +15,7 @@
 package serviceentry
 
 import (
+	"encoding/json"
 	"net"
 	"strings"
 	"time"
@@ -201,8 +202,19 @@ func convertServices(cfg config.Config) []*model.Service {
 		}
 	}
 
-	return buildServices(hostAddresses, cfg.Name, cfg.Namespace, svcPorts, serviceEntry.Location, resolution,
+	out := buildServices(hostAddresses, cfg.Name, cfg.Namespace, svcPorts, serviceEntry.Location, resolution,
 		exportTo, labelSelectors, serviceEntry.SubjectAltNames, creationTime, cfg.Labels)
+	if ttl := model.DNSTTLFromAnnotations(cfg.Annotations); ttl > 0 {
+		for _, svc := range out {
+			svc.Attributes.DNSTTL = ttl
+		}
+	}
+	if timeouts := model.TCPIdleTimeoutsFromAnnotations(cfg.Annotations); timeouts != nil {
+		for _, svc := range out {
+			svc.Attributes.TCPIdleTimeouts = timeouts
+		}
+	}
+	return out
 }
 
 func buildServices(hostAddresses []*HostAddress, name, namespace string, ports model.PortList, location networking.ServiceEntry_Location,
@@ -322,6 +334,7 @@ func (s *Controller) convertServiceEntryToInstances(cfg config.Config, services
 	if services == nil {
 		services = convertServices(cfg)
 	}
+	portAddresses := endpointPortAddresses(cfg)
 	for _, service := range services {
 		for _, serviceEntryPort := range serviceEntry.Ports {
 			if len(serviceEntry.Endpoints) == 0 && serviceEntry.WorkloadSelector == nil &&
@@ -348,7 +361,11 @@ func (s *Controller) convertServiceEntryToInstances(cfg config.Config, services
 				})
 			} else {
 				for _, endpoint := range serviceEntry.Endpoints {
-					out = append(out, s.convertEndpoint(service, serviceEntryPort, endpoint, &configKey{}, s.clusterID))
+					instance := s.convertEndpoint(service, serviceEntryPort, endpoint, &configKey{}, s.clusterID)
+					if addr, ok := portAddresses[endpoint.Address][serviceEntryPort.Name]; ok {
+						instance.Endpoint.Address = addr
+					}
+					out = append(out, instance)
 				}
 			}
 		}
@@ -356,6 +373,33 @@ func (s *Controller) convertServiceEntryToInstances(cfg config.Config, services
 	return out
 }
 
+// endpointPortAddresses returns the addresses of the endpoints of the ServiceEntry by port name, keyed by the address
+// of the endpoint, from its networking.istio.io/endpointPortAddresses annotation. Addresses of STATIC ServiceEntries
+// must be IPs.
+func endpointPortAddresses(cfg config.Config) map[string]map[string]string {
+	value, ok := cfg.Annotations[constants.EndpointPortAddressesAnnotation]
+	if !ok {
+		return nil
+	}
+	addresses := map[string]map[string]string{}
+	if err := json.Unmarshal([]byte(value), &addresses); err != nil {
+		log.Warnf("ignoring invalid %s annotation of ServiceEntry %s/%s: %v",
+			constants.EndpointPortAddressesAnnotation, cfg.Namespace, cfg.Name, err)
+		return nil
+	}
+	static := cfg.Spec.(*networking.ServiceEntry).Resolution == networking.ServiceEntry_STATIC
+	for endpoint, ports := range addresses {
+		for port, addr := range ports {
+			if addr == "" || strings.HasPrefix(endpoint, model.UnixAddressPrefix) || static && net.ParseIP(addr) == nil {
+				log.Warnf("ignoring invalid address %q of port %s of endpoint %s in %s annotation of ServiceEntry %s/%s",
+					addr, port, endpoint, constants.EndpointPortAddressesAnnotation, cfg.Namespace, cfg.Name)
+				delete(ports, port)
+			}
+		}
+	}
+	return addresses
+}
+
 func getTLSModeFromWorkloadEntry(wle *networking.WorkloadEntry) string {
 	// * Use security.istio.io/tlsMode if its present
 	// * If not, set TLS mode if ServiceAccount is specified
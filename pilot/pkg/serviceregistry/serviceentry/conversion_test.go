@@ -114,6 +114,15 @@ var httpStatic = &config.Config{
 	},
 }
 
+var httpStaticPortAddresses = func() *config.Config {
+	c := httpStatic.DeepCopy()
+	c.Name = "httpStaticPortAddresses"
+	c.Annotations = map[string]string{
+		constants.EndpointPortAddressesAnnotation: `{"2.2.2.2": {"http-alt-port": "2.2.3.3"}, "3.3.3.3": {"http-port": "invalid"}}`,
+	}
+	return &c
+}()
+
 // Shares the same host as httpStatic, but adds some endpoints. We expect these to be merge
 var httpStaticOverlay = &config.Config{
 	Meta: config.Meta{
@@ -733,6 +742,18 @@ func TestConvertInstances(t *testing.T) {
 				makeInstance(httpStatic, "4.4.4.4", 8080, httpStatic.Spec.(*networking.ServiceEntry).Ports[1], map[string]string{"foo": "bar"}, PlainText),
 			},
 		},
+		{
+			// service entry static with per port addresses
+			externalSvc: httpStaticPortAddresses,
+			out: []*model.ServiceInstance{
+				makeInstance(httpStaticPortAddresses, "2.2.2.2", 7080, httpStatic.Spec.(*networking.ServiceEntry).Ports[0], nil, MTLS),
+				makeInstance(httpStaticPortAddresses, "2.2.3.3", 18080, httpStatic.Spec.(*networking.ServiceEntry).Ports[1], nil, MTLS),
+				makeInstance(httpStaticPortAddresses, "3.3.3.3", 1080, httpStatic.Spec.(*networking.ServiceEntry).Ports[0], nil, MTLS),
+				makeInstance(httpStaticPortAddresses, "3.3.3.3", 8080, httpStatic.Spec.(*networking.ServiceEntry).Ports[1], nil, MTLS),
+				makeInstance(httpStaticPortAddresses, "4.4.4.4", 1080, httpStatic.Spec.(*networking.ServiceEntry).Ports[0], map[string]string{"foo": "bar"}, PlainText),
+				makeInstance(httpStaticPortAddresses, "4.4.4.4", 8080, httpStatic.Spec.(*networking.ServiceEntry).Ports[1], map[string]string{"foo": "bar"}, PlainText),
+			},
+		},
 		{
 			// service entry DNS with no endpoints
 			externalSvc: httpDNSnoEndpoints,
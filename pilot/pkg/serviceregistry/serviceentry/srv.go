@@ -0,0 +1,287 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceentry
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"k8s.io/apimachinery/pkg/types"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
+)
+
+const (
+	// srvMinRefresh is the minimum interval between two lookups of the same SRV record, whatever its TTL.
+	srvMinRefresh = 5 * time.Second
+	// srvRetryInterval is the interval between lookups of a SRV record that failed or had no answers.
+	srvRetryInterval = 30 * time.Second
+)
+
+// srvRecord is an endpoint of a SRV record: an address of one of its targets and the port and weight of the target.
+type srvRecord struct {
+	Address string
+	Port    uint32
+	Weight  uint32
+}
+
+// srvLookup looks up the endpoints of the highest priority targets of a SRV record, and returns them with the
+// TTL of the answer.
+type srvLookup func(name string) ([]srvRecord, time.Duration, error)
+
+// srvWatch periodically looks up the SRV record of a ServiceEntry.
+type srvWatch struct {
+	name    string
+	cfg     config.Config
+	records []srvRecord
+	stop    chan struct{}
+}
+
+// srvResolver resolves the endpoints of ServiceEntries with the networking.istio.io/dnsSRV annotation from DNS SRV
+// records, refreshing them when their TTL expires.
+type srvResolver struct {
+	// handlerMu serializes the handling of ServiceEntry events with the updates of SRV records, so that an update
+	// racing with the deletion of its ServiceEntry does not add it back.
+	handlerMu sync.Mutex
+
+	mu       sync.Mutex
+	lookup   srvLookup
+	watches  map[types.NamespacedName]*srvWatch
+	onChange func(key types.NamespacedName, w *srvWatch)
+}
+
+func newSRVResolver(lookup srvLookup) *srvResolver {
+	return &srvResolver{
+		lookup:  lookup,
+		watches: map[types.NamespacedName]*srvWatch{},
+	}
+}
+
+// resolve starts, updates or stops the watch of the SRV record of the ServiceEntry, and returns the ServiceEntry with
+// the endpoints last resolved from its SRV record, if it has one.
+func (r *srvResolver) resolve(cfg config.Config, event model.Event) config.Config {
+	key := types.NamespacedName{Namespace: cfg.Namespace, Name: cfg.Name}
+	name := cfg.Annotations[constants.DNSSRVAnnotation]
+	se := cfg.Spec.(*networking.ServiceEntry)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w := r.watches[key]
+	if event == model.EventDelete || name == "" || !features.EnableServiceEntryDNSSRV || se.Resolution != networking.ServiceEntry_STATIC {
+		if w != nil {
+			close(w.stop)
+			delete(r.watches, key)
+		}
+		if name != "" && event != model.EventDelete {
+			log.Warnf("ignoring %s annotation of ServiceEntry %s: it requires PILOT_ENABLE_SERVICE_ENTRY_DNS_SRV and STATIC resolution",
+				constants.DNSSRVAnnotation, key)
+		}
+		return cfg
+	}
+	if w == nil || w.name != name {
+		if w != nil {
+			close(w.stop)
+		}
+		w = &srvWatch{name: name, stop: make(chan struct{})}
+		r.watches[key] = w
+		go r.run(key, w)
+	}
+	w.cfg = cfg
+	return withSRVEndpoints(cfg, w.records)
+}
+
+// current returns the ServiceEntry of the watch, if it is still the watch of the ServiceEntry.
+func (r *srvResolver) current(key types.NamespacedName, w *srvWatch) (config.Config, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.watches[key] != w {
+		return config.Config{}, false
+	}
+	return w.cfg, true
+}
+
+func (r *srvResolver) run(key types.NamespacedName, w *srvWatch) {
+	for {
+		records, ttl, err := r.lookup(w.name)
+		refresh := ttl
+		if err != nil {
+			log.Warnf("failed to look up SRV record %s of ServiceEntry %s: %v", w.name, key, err)
+			refresh = srvRetryInterval
+		} else if len(records) == 0 {
+			refresh = srvRetryInterval
+		}
+		if refresh < srvMinRefresh {
+			refresh = srvMinRefresh
+		}
+
+		if err == nil {
+			sortSRVRecords(records)
+			r.mu.Lock()
+			changed := !reflect.DeepEqual(w.records, records)
+			w.records = records
+			r.mu.Unlock()
+			if changed && r.onChange != nil {
+				r.onChange(key, w)
+			}
+		}
+
+		t := time.NewTimer(refresh)
+		select {
+		case <-w.stop:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// withSRVEndpoints returns a copy of the ServiceEntry whose endpoints are the endpoints of its SRV record, serving
+// all of its ports on the port of the SRV target.
+func withSRVEndpoints(cfg config.Config, records []srvRecord) config.Config {
+	out := cfg.DeepCopy()
+	se := out.Spec.(*networking.ServiceEntry)
+	se.Endpoints = make([]*networking.WorkloadEntry, 0, len(records))
+	for _, r := range records {
+		ports := make(map[string]uint32, len(se.Ports))
+		for _, p := range se.Ports {
+			ports[p.Name] = r.Port
+		}
+		se.Endpoints = append(se.Endpoints, &networking.WorkloadEntry{
+			Address: r.Address,
+			Ports:   ports,
+			Weight:  r.Weight,
+		})
+	}
+	return out
+}
+
+func sortSRVRecords(records []srvRecord) {
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Address != records[j].Address {
+			return records[i].Address < records[j].Address
+		}
+		return records[i].Port < records[j].Port
+	})
+}
+
+// lookupSRV looks up a SRV record with the name servers of /etc/resolv.conf. Only the targets with the highest
+// priority, that is the lowest priority value, are returned, as the other targets are only used when they are
+// unreachable. Targets are resolved to their addresses from the additional section of the answer, or with a
+// lookup when it does not include them.
+func lookupSRV(name string) ([]srvRecord, time.Duration, error) {
+	conf, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+	if err != nil {
+		return nil, 0, err
+	}
+	servers := make([]string, 0, len(conf.Servers))
+	for _, server := range conf.Servers {
+		servers = append(servers, net.JoinHostPort(server, conf.Port))
+	}
+	req := new(dns.Msg)
+	req.SetQuestion(dns.Fqdn(name), dns.TypeSRV)
+	resp, err := exchange(req, servers)
+	if err != nil {
+		return nil, 0, err
+	}
+	return srvRecords(name, resp)
+}
+
+// exchange sends the request to the first name server that answers it. Truncated answers are requested again over
+// TCP from the same name server, as the SRV records of services with many targets do not fit in a UDP message.
+func exchange(req *dns.Msg, servers []string) (*dns.Msg, error) {
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("no name servers configured")
+	}
+	udp := &dns.Client{Timeout: 5 * time.Second}
+	tcp := &dns.Client{Net: "tcp", Timeout: 5 * time.Second}
+	var err error
+	for _, server := range servers {
+		var resp *dns.Msg
+		resp, _, err = udp.Exchange(req, server)
+		if err == nil && resp.Truncated {
+			resp, _, err = tcp.Exchange(req, server)
+		}
+		if err == nil {
+			return resp, nil
+		}
+	}
+	return nil, err
+}
+
+// srvRecords returns the endpoints of the highest priority targets of the answer to a SRV lookup, and its TTL.
+func srvRecords(name string, resp *dns.Msg) ([]srvRecord, time.Duration, error) {
+	if resp.Rcode != dns.RcodeSuccess {
+		return nil, 0, fmt.Errorf("lookup failed: %s", dns.RcodeToString[resp.Rcode])
+	}
+
+	var ttl uint32
+	addresses := map[string][]string{}
+	for _, rr := range resp.Extra {
+		switch a := rr.(type) {
+		case *dns.A:
+			addresses[a.Hdr.Name] = append(addresses[a.Hdr.Name], a.A.String())
+		case *dns.AAAA:
+			addresses[a.Hdr.Name] = append(addresses[a.Hdr.Name], a.AAAA.String())
+		}
+	}
+	var srvs []*dns.SRV
+	for _, rr := range resp.Answer {
+		srv, ok := rr.(*dns.SRV)
+		if !ok {
+			continue
+		}
+		if ttl == 0 || srv.Hdr.Ttl < ttl {
+			ttl = srv.Hdr.Ttl
+		}
+		if len(srvs) > 0 && srv.Priority > srvs[0].Priority {
+			continue
+		}
+		if len(srvs) > 0 && srv.Priority < srvs[0].Priority {
+			srvs = srvs[:0]
+		}
+		srvs = append(srvs, srv)
+	}
+
+	var records []srvRecord
+	for _, srv := range srvs {
+		ips := addresses[srv.Target]
+		if len(ips) == 0 {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			resolved, err := net.DefaultResolver.LookupIPAddr(ctx, srv.Target)
+			cancel()
+			if err != nil {
+				log.Warnf("failed to resolve target %s of SRV record %s: %v", srv.Target, name, err)
+				continue
+			}
+			for _, ip := range resolved {
+				ips = append(ips, ip.IP.String())
+			}
+		}
+		for _, ip := range ips {
+			records = append(records, srvRecord{Address: ip, Port: uint32(srv.Port), Weight: uint32(srv.Weight)})
+		}
+	}
+	return records, time.Duration(ttl) * time.Second, nil
+}
@@ -0,0 +1,164 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceentry
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+func TestServiceDiscoverySRV(t *testing.T) {
+	test.SetBoolForTest(t, &features.EnableServiceEntryDNSSRV, true)
+	store, sd, _ := initServiceDiscovery(t)
+	sd.srv.lookup = func(name string) ([]srvRecord, time.Duration, error) {
+		if name != "_ldap._tcp.example.com" {
+			return nil, 0, fmt.Errorf("unexpected lookup of %s", name)
+		}
+		return []srvRecord{
+			{Address: "10.0.0.2", Port: 1389, Weight: 10},
+			{Address: "10.0.0.1", Port: 389, Weight: 90},
+		}, time.Minute, nil
+	}
+
+	ldap := &config.Config{
+		Meta: config.Meta{
+			GroupVersionKind:  gvk.ServiceEntry,
+			Name:              "ldap",
+			Namespace:         "legacy",
+			CreationTimestamp: GlobalTime,
+			Annotations:       map[string]string{constants.DNSSRVAnnotation: "_ldap._tcp.example.com"},
+		},
+		Spec: &networking.ServiceEntry{
+			Hosts:      []string{"ldap.example.com"},
+			Addresses:  []string{"240.240.0.1"},
+			Ports:      []*networking.Port{{Number: 389, Name: "tcp-ldap", Protocol: "TCP"}},
+			Location:   networking.ServiceEntry_MESH_EXTERNAL,
+			Resolution: networking.ServiceEntry_STATIC,
+		},
+	}
+	createConfigs([]*config.Config{ldap}, store, t)
+
+	svc := sd.GetService("ldap.example.com")
+	if svc == nil {
+		t.Fatal("service ldap.example.com not found")
+	}
+	want := []string{"10.0.0.1:389/90", "10.0.0.2:1389/10"}
+	retry.UntilSuccessOrFail(t, func() error {
+		var got []string
+		for _, i := range sd.InstancesByPort(svc, 389, nil) {
+			got = append(got, fmt.Sprintf("%s:%d/%d", i.Endpoint.Address, i.Endpoint.EndpointPort, i.Endpoint.LbWeight))
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			return fmt.Errorf("got endpoints %v, want %v", got, want)
+		}
+		return nil
+	}, retry.Timeout(time.Second*5))
+
+	deleteConfigs([]*config.Config{ldap}, store, t)
+	sd.srv.mu.Lock()
+	watches := len(sd.srv.watches)
+	sd.srv.mu.Unlock()
+	if watches != 0 {
+		t.Fatalf("expected the SRV watch to be stopped, got %d watches", watches)
+	}
+}
+
+func TestWithSRVEndpoints(t *testing.T) {
+	cfg := config.Config{
+		Spec: &networking.ServiceEntry{
+			Ports: []*networking.Port{
+				{Number: 80, Name: "http"},
+				{Number: 8080, Name: "http-alt"},
+			},
+			Resolution: networking.ServiceEntry_STATIC,
+		},
+	}
+	out := withSRVEndpoints(cfg, []srvRecord{{Address: "10.0.0.1", Port: 9080, Weight: 5}})
+	got := out.Spec.(*networking.ServiceEntry).Endpoints
+	if len(got) != 1 || got[0].Address != "10.0.0.1" || got[0].Weight != 5 ||
+		!reflect.DeepEqual(got[0].Ports, map[string]uint32{"http": 9080, "http-alt": 9080}) {
+		t.Fatalf("unexpected endpoints %v", got)
+	}
+	if len(cfg.Spec.(*networking.ServiceEntry).Endpoints) != 0 {
+		t.Fatal("the original ServiceEntry was modified")
+	}
+}
+
+func TestExchangeTruncated(t *testing.T) {
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var handler dns.HandlerFunc = func(w dns.ResponseWriter, r *dns.Msg) {
+		resp := new(dns.Msg).SetReply(r)
+		if w.RemoteAddr().Network() == "udp" {
+			// Pretend the answer does not fit in a UDP message.
+			resp.Truncated = true
+		} else {
+			resp.Answer = append(resp.Answer, &dns.SRV{
+				Hdr:    dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeSRV, Class: dns.ClassINET, Ttl: 30},
+				Target: "backend.example.com.",
+				Port:   8080,
+			})
+			resp.Extra = append(resp.Extra, &dns.A{
+				Hdr: dns.RR_Header{Name: "backend.example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 30},
+				A:   net.ParseIP("10.0.0.1"),
+			})
+		}
+		_ = w.WriteMsg(resp)
+	}
+	for _, s := range []*dns.Server{{PacketConn: udp, Handler: handler}, {Listener: tcp, Handler: handler}} {
+		s := s
+		go func() {
+			_ = s.ActivateAndServe()
+		}()
+		t.Cleanup(func() {
+			_ = s.Shutdown()
+		})
+	}
+
+	req := new(dns.Msg)
+	req.SetQuestion("_http._tcp.example.com.", dns.TypeSRV)
+	resp, err := exchange(req, []string{udp.LocalAddr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, ttl, err := srvRecords("_http._tcp.example.com", resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []srvRecord{{Address: "10.0.0.1", Port: 8080}}; !reflect.DeepEqual(records, want) || ttl != 30*time.Second {
+		t.Errorf("got records %v with TTL %v, want %v with TTL 30s", records, ttl, want)
+	}
+}
@@ -15,10 +15,14 @@
 package serviceentry
 
 import (
+	"strings"
+
 	"k8s.io/apimachinery/pkg/types"
 
 	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/labels"
 )
 
@@ -34,6 +38,24 @@ func getWorkloadServiceEntries(ses []config.Config, wle *networking.WorkloadEntr
 	return out
 }
 
+// selectsNamespace returns true if the workload selector of the ServiceEntry selects workloads in the namespace.
+// A ServiceEntry selects workloads in its own namespace, and in the namespaces listed in its
+// workloadSelectorNamespaces annotation if cross namespace selection is enabled.
+func selectsNamespace(cfg config.Config, namespace string) bool {
+	if cfg.Namespace == namespace {
+		return true
+	}
+	if !features.EnableCrossNamespaceServiceEntrySelector {
+		return false
+	}
+	for _, ns := range strings.Split(cfg.Annotations[constants.WorkloadSelectorNamespacesAnnotation], ",") {
+		if ns = strings.TrimSpace(ns); ns == "*" || ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
 // returns a set of objects that are in `old` but not in `curr`
 // For example:
 // old = {a1, a2, a3}
@@ -21,8 +21,11 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/test"
 )
 
 func TestGetWorkloadServiceEntries(t *testing.T) {
@@ -90,6 +93,38 @@ func TestGetWorkloadServiceEntries(t *testing.T) {
 	}
 }
 
+func TestSelectsNamespace(t *testing.T) {
+	se := func(annotation string) config.Config {
+		cfg := config.Config{Meta: config.Meta{GroupVersionKind: gvk.ServiceEntry, Namespace: "infra", Name: "se"}}
+		if annotation != "" {
+			cfg.Annotations = map[string]string{constants.WorkloadSelectorNamespacesAnnotation: annotation}
+		}
+		return cfg
+	}
+	cases := []struct {
+		name       string
+		enabled    bool
+		annotation string
+		namespace  string
+		want       bool
+	}{
+		{name: "own namespace", namespace: "infra", want: true},
+		{name: "other namespace", enabled: true, namespace: "a", want: false},
+		{name: "listed namespace", enabled: true, annotation: "a, b", namespace: "b", want: true},
+		{name: "unlisted namespace", enabled: true, annotation: "a,b", namespace: "c", want: false},
+		{name: "all namespaces", enabled: true, annotation: "*", namespace: "c", want: true},
+		{name: "disabled", annotation: "*", namespace: "c", want: false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			test.SetBoolForTest(t, &features.EnableCrossNamespaceServiceEntrySelector, tt.enabled)
+			if got := selectsNamespace(se(tt.annotation), tt.namespace); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestCompareServiceEntries(t *testing.T) {
 	oldSes := map[types.NamespacedName]*config.Config{
 		{Namespace: "default", Name: "se-1"}: {},
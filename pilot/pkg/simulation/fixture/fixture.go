@@ -0,0 +1,100 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fixture defines the fixtures exported by istioctl x export-fixture: the inputs istiod generates the
+// configuration of a proxy from, so that the configuration can be reproduced offline by the simulation tests.
+package fixture
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/mesh"
+)
+
+// Fixture is the configuration of a proxy, as exported from a cluster.
+type Fixture struct {
+	// Node is the xDS node ID of the proxy, such as sidecar~10.0.0.1~app-54d5c7b98-7x2xq.default~default.svc.cluster.local.
+	Node string `json:"node"`
+	// Metadata is the xDS node metadata of the proxy.
+	Metadata *model.NodeMetadata `json:"metadata,omitempty"`
+	// DomainSuffix is the domain suffix of the cluster, which the short host names of the configs are resolved with.
+	// cluster.local is used if empty.
+	DomainSuffix string `json:"domainSuffix,omitempty"`
+	// MeshConfig is the mesh config, as YAML. The default mesh config is used if empty.
+	MeshConfig string `json:"meshConfig,omitempty"`
+	// Configs are the Istio configs relevant to the proxy, as YAML documents.
+	Configs string `json:"configs,omitempty"`
+	// KubernetesObjects are the Kubernetes services and the pod of the proxy, as YAML documents.
+	KubernetesObjects string `json:"kubernetesObjects,omitempty"`
+}
+
+// Read reads a fixture from a YAML file.
+func Read(path string) (*Fixture, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &Fixture{}
+	if err := yaml.UnmarshalStrict(b, f); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %v", path, err)
+	}
+	return f, nil
+}
+
+// Mesh returns the mesh config of the fixture, with the defaults of the fields it does not set.
+func (f *Fixture) Mesh() (*meshconfig.MeshConfig, error) {
+	if f.MeshConfig == "" {
+		return mesh.DefaultMeshConfig(), nil
+	}
+	return mesh.ApplyMeshConfigDefaults(f.MeshConfig)
+}
+
+// ParseConfigs parses the Istio configs of the fixture. They are in the domain of the cluster, as they are when read
+// from Kubernetes.
+func (f *Fixture) ParseConfigs() ([]config.Config, error) {
+	configs, _, err := crd.ParseInputs(f.Configs)
+	if err != nil {
+		return nil, err
+	}
+	domain := f.DomainSuffix
+	if domain == "" {
+		domain = constants.DefaultClusterLocalDomain
+	}
+	for i := range configs {
+		configs[i].Domain = domain
+	}
+	return configs, nil
+}
+
+// Proxy returns the proxy of the fixture, from its node ID and metadata.
+func (f *Fixture) Proxy() (*model.Proxy, error) {
+	meta := f.Metadata
+	if meta == nil {
+		meta = &model.NodeMetadata{}
+	}
+	proxy, err := model.ParseServiceNodeWithMetadata(f.Node, meta)
+	if err != nil {
+		return nil, err
+	}
+	proxy.ConfigNamespace = meta.Namespace
+	return proxy, nil
+}
@@ -34,6 +34,7 @@ import (
 
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking/core/v1alpha3"
+	"istio.io/istio/pilot/pkg/simulation/fixture"
 	"istio.io/istio/pilot/pkg/xds"
 	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
 	"istio.io/istio/pilot/test/xdstest"
@@ -227,7 +228,7 @@ func (r Result) Matches(t *testing.T, want Result) {
 }
 
 type Simulation struct {
-	t         *testing.T
+	t         test.Failer
 	Listeners []*listener.Listener
 	Clusters  []*cluster.Cluster
 	Routes    []*route.RouteConfiguration
@@ -248,6 +249,43 @@ func NewSimulation(t *testing.T, s *xds.FakeDiscoveryServer, proxy *model.Proxy)
 	return NewSimulationFromConfigGen(t, s.ConfigGenTest, proxy)
 }
 
+// NewSimulationFromResources simulates the listeners, clusters and routes generated for a proxy, for instance by
+// /debug/simulate. Resources which cannot be simulated fail t; outside of tests, test.Wrap turns the failure into an
+// error.
+func NewSimulationFromResources(t test.Failer, listeners []*listener.Listener, clusters []*cluster.Cluster,
+	routes []*route.RouteConfiguration,
+) *Simulation {
+	return &Simulation{
+		t:         t,
+		Listeners: listeners,
+		Clusters:  clusters,
+		Routes:    routes,
+	}
+}
+
+// NewSimulationFromFixture simulates the proxy of a fixture exported by istioctl x export-fixture, with its configs,
+// Kubernetes objects and mesh config.
+func NewSimulationFromFixture(t *testing.T, f *fixture.Fixture) *Simulation {
+	m, err := f.Mesh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs, err := f.ParseConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy, err := f.Proxy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{
+		Configs:                configs,
+		KubernetesObjectString: f.KubernetesObjects,
+		MeshConfig:             m,
+	})
+	return NewSimulation(t, s, s.SetupProxy(proxy))
+}
+
 // withT swaps out the testing struct. This allows executing sub tests.
 func (sim *Simulation) withT(t *testing.T) *Simulation {
 	cpy := *sim
@@ -255,9 +293,15 @@ func (sim *Simulation) withT(t *testing.T) *Simulation {
 	return &cpy
 }
 
+// RunExpectations runs the expectations as sub tests. It fails simulations which were not created by a test.
 func (sim *Simulation) RunExpectations(es []Expect) {
+	t, ok := sim.t.(*testing.T)
+	if !ok {
+		sim.t.Fatal("expectations can only be run by a simulation created by a test")
+		return
+	}
 	for _, e := range es {
-		sim.t.Run(e.Name, func(t *testing.T) {
+		t.Run(e.Name, func(t *testing.T) {
 			sim.withT(t).Run(e.Call).Matches(t, e.Result)
 		})
 	}
@@ -195,6 +195,10 @@ func (s *DiscoveryServer) processRequest(req *discovery.DiscoveryRequest, con *C
 		s.handleWorkloadHealthcheck(con.proxy, req)
 		return nil
 	}
+	if req.TypeUrl == v3.StartupReportType {
+		s.handleStartupReport(con.proxy, req.ResourceNames)
+		return nil
+	}
 
 	// For now, don't let xDS piggyback debug requests start watchers.
 	if strings.HasPrefix(req.TypeUrl, v3.DebugType) {
@@ -362,11 +366,17 @@ func (s *DiscoveryServer) shouldRespond(con *Connection, request *discovery.Disc
 		errCode := codes.Code(request.ErrorDetail.Code)
 		log.Warnf("ADS:%s: ACK ERROR %s %s:%s", stype, con.conID, errCode.String(), request.ErrorDetail.GetMessage())
 		incrementXDSRejects(request.TypeUrl, con.proxy.ID, errCode.String())
+		s.nacks.nack(con.conID, parseNack(con.proxy.ID, request.TypeUrl, request.ResourceNames, request.VersionInfo,
+			request.ResponseNonce, request.ErrorDetail.GetMessage(), time.Now()))
 		if s.StatusGen != nil {
 			s.StatusGen.OnNack(con.proxy, request)
 		}
 		return false, emptyResourceDelta
 	}
+	if request.ResponseNonce != "" {
+		// The proxy accepted the last configuration of the type.
+		s.nacks.ack(con.conID, request.TypeUrl)
+	}
 
 	if shouldUnsubscribe(request) {
 		log.Debugf("ADS:%s: UNSUBSCRIBE %s %s %s", stype, con.conID, request.VersionInfo, request.ResponseNonce)
@@ -894,6 +904,7 @@ func (s *DiscoveryServer) removeCon(conID string) {
 	} else {
 		delete(s.adsClients, conID)
 		recordXDSClients(con.proxy.Metadata.IstioVersion, -1)
+		s.nacks.remove(conID)
 	}
 }
 
@@ -916,6 +927,7 @@ func (conn *Connection) send(res *discovery.DiscoveryResponse) error {
 				conn.proxy.WatchedResources[res.TypeUrl] = &model.WatchedResource{TypeUrl: res.TypeUrl}
 			}
 			conn.proxy.WatchedResources[res.TypeUrl].NonceSent = res.Nonce
+			conn.proxy.WatchedResources[res.TypeUrl].VersionSent = res.VersionInfo
 			conn.proxy.Unlock()
 		}
 	} else if status.Convert(err).Code() == codes.DeadlineExceeded {
@@ -945,6 +957,17 @@ func (conn *Connection) NonceSent(typeUrl string) string {
 	return ""
 }
 
+// VersionSent returns the version info of the last response of the type sent to the proxy.
+// nolint
+func (conn *Connection) VersionSent(typeUrl string) string {
+	conn.proxy.RLock()
+	defer conn.proxy.RUnlock()
+	if conn.proxy.WatchedResources != nil && conn.proxy.WatchedResources[typeUrl] != nil {
+		return conn.proxy.WatchedResources[typeUrl].VersionSent
+	}
+	return ""
+}
+
 func (conn *Connection) Clusters() []string {
 	conn.proxy.RLock()
 	defer conn.proxy.RUnlock()
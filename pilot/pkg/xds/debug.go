@@ -18,19 +18,24 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"net"
 	"net/http"
 	"net/http/pprof"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	adminapi "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	wasm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/wasm/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"google.golang.org/protobuf/proto"
 	anypb "google.golang.org/protobuf/types/known/anypb"
 
@@ -50,6 +55,8 @@ import (
 	"istio.io/istio/pkg/network"
 	"istio.io/istio/pkg/security"
 	"istio.io/istio/pkg/util/protomarshal"
+	"istio.io/istio/pkg/util/sets"
+	caserver "istio.io/istio/security/pkg/server/ca"
 	istiolog "istio.io/pkg/log"
 )
 
@@ -115,20 +122,31 @@ type AdsClients struct {
 
 // SyncStatus is the synchronization status between Pilot and a given Envoy
 type SyncStatus struct {
-	ClusterID            string `json:"cluster_id,omitempty"`
-	ProxyID              string `json:"proxy,omitempty"`
-	ProxyVersion         string `json:"proxy_version,omitempty"`
-	IstioVersion         string `json:"istio_version,omitempty"`
-	ClusterSent          string `json:"cluster_sent,omitempty"`
-	ClusterAcked         string `json:"cluster_acked,omitempty"`
-	ListenerSent         string `json:"listener_sent,omitempty"`
-	ListenerAcked        string `json:"listener_acked,omitempty"`
-	RouteSent            string `json:"route_sent,omitempty"`
-	RouteAcked           string `json:"route_acked,omitempty"`
-	EndpointSent         string `json:"endpoint_sent,omitempty"`
-	EndpointAcked        string `json:"endpoint_acked,omitempty"`
-	ExtensionConfigSent  string `json:"extensionconfig_sent,omitempty"`
-	ExtensionConfigAcked string `json:"extensionconfig_acked,omitempty"`
+	ClusterID            string   `json:"cluster_id,omitempty"`
+	ProxyID              string   `json:"proxy,omitempty"`
+	ProxyVersion         string   `json:"proxy_version,omitempty"`
+	IstioVersion         string   `json:"istio_version,omitempty"`
+	ProxyIPs             []string `json:"proxy_ips,omitempty"`
+	ClusterSent          string   `json:"cluster_sent,omitempty"`
+	ClusterAcked         string   `json:"cluster_acked,omitempty"`
+	ListenerSent         string   `json:"listener_sent,omitempty"`
+	ListenerAcked        string   `json:"listener_acked,omitempty"`
+	RouteSent            string   `json:"route_sent,omitempty"`
+	RouteAcked           string   `json:"route_acked,omitempty"`
+	EndpointSent         string   `json:"endpoint_sent,omitempty"`
+	EndpointAcked        string   `json:"endpoint_acked,omitempty"`
+	ExtensionConfigSent  string   `json:"extensionconfig_sent,omitempty"`
+	ExtensionConfigAcked string   `json:"extensionconfig_acked,omitempty"`
+	// The versions of the last responses of each type sent to the proxy, which the proxy reports in its config dump
+	// once it accepts them.
+	ClusterVersionSent         string `json:"cluster_version_sent,omitempty"`
+	ListenerVersionSent        string `json:"listener_version_sent,omitempty"`
+	RouteVersionSent           string `json:"route_version_sent,omitempty"`
+	EndpointVersionSent        string `json:"endpoint_version_sent,omitempty"`
+	ExtensionConfigVersionSent string `json:"extensionconfig_version_sent,omitempty"`
+	// PushVersion is the version of the current PushContext of istiod, which a proxy reports in its config dump once
+	// it accepts the configuration generated from it.
+	PushVersion string `json:"push_version,omitempty"`
 }
 
 // SyncedVersions shows what resourceVersion of a given resource has been acked by Envoy.
@@ -191,6 +209,7 @@ func (s *DiscoveryServer) AddDebugHandlers(mux, internalMux *http.ServeMux, enab
 
 	s.addDebugHandler(mux, internalMux, "/debug/syncz", "Synchronization status of all Envoys connected to this Pilot instance", s.Syncz)
 	s.addDebugHandler(mux, internalMux, "/debug/config_distribution", "Version status of all Envoys connected to this Pilot instance", s.distributedVersions)
+	s.addDebugHandler(mux, internalMux, "/debug/nackz", "Configurations rejected by the Envoys connected to this Pilot instance", s.nackz)
 
 	s.addDebugHandler(mux, internalMux, "/debug/registryz", "Debug support for registry", s.registryz)
 	s.addDebugHandler(mux, internalMux, "/debug/endpointz", "Debug support for endpoints", s.endpointz)
@@ -205,7 +224,8 @@ func (s *DiscoveryServer) AddDebugHandlers(mux, internalMux *http.ServeMux, enab
 
 	s.addDebugHandler(mux, internalMux, "/debug/authorizationz", "Internal authorization policies", s.authorizationz)
 	s.addDebugHandler(mux, internalMux, "/debug/telemetryz", "Debug Telemetry configuration", s.telemetryz)
-	s.addDebugHandler(mux, internalMux, "/debug/config_dump", "ConfigDump in the form of the Envoy admin config dump API for passed in proxyID", s.ConfigDump)
+	s.addDebugHandler(mux, internalMux, "/debug/config_dump",
+		"ConfigDump in the form of the Envoy admin config dump API for passed in proxyID, or for the POSTed xDS node", s.ConfigDump)
 	s.addDebugHandler(mux, internalMux, "/debug/push_status", "Last PushContext Details", s.pushStatusHandler)
 	s.addDebugHandler(mux, internalMux, "/debug/pushcontext", "Debug support for current push context", s.pushContextHandler)
 	s.addDebugHandler(mux, internalMux, "/debug/connections", "Info about the connected XDS clients", s.connectionsHandler)
@@ -215,7 +235,20 @@ func (s *DiscoveryServer) AddDebugHandlers(mux, internalMux *http.ServeMux, enab
 	s.addDebugHandler(mux, internalMux, "/debug/clusterz", "List remote clusters where istiod reads endpoints", s.clusterz)
 	s.addDebugHandler(mux, internalMux, "/debug/networkz", "List cross-network gateways", s.networkz)
 	s.addDebugHandler(mux, internalMux, "/debug/mcsz", "List information about Kubernetes MCS services", s.mcsz)
-
+	s.addDebugHandler(mux, internalMux, "/debug/replicationz", "Status of config replicated to remote clusters", s.replicationz)
+	s.addDebugHandler(mux, internalMux, "/debug/push_cost", "Push generation time and size attributed to the configs triggering pushes", s.pushCostz)
+	s.addDebugHandler(mux, internalMux, "/debug/push_cost?sort=size", "Push cost ordered by response size", s.pushCostz)
+	s.addDebugHandler(mux, internalMux, "/debug/push_cost?reset=true", "Reset the push cost statistics", s.pushCostz)
+	s.addDebugHandler(mux, internalMux, "/debug/certz", "Workload certificates issued by the istiod CA", s.certz)
+	s.addDebugHandler(mux, internalMux, "/debug/certz?format=csv", "Workload certificates issued by the istiod CA, as CSV", s.certz)
+	s.addDebugHandler(mux, internalMux, "/debug/namespacecaz", "Intermediate CA certificates issued to the namespaces. "+
+		"POST with ?revoke=<namespace> to revoke those of a namespace", s.namespaceCAz)
+
+	s.addDebugHandler(mux, internalMux, "/debug/serviceaccountz", "Workload instances by service account identity", s.serviceAccountz)
+	s.addDebugHandler(mux, internalMux, "/debug/dependencyz", "Services the workloads can reach, per Sidecar scope and VirtualService", s.dependencyz)
+	s.addDebugHandler(mux, internalMux, "/debug/dependencyz?format=dot", "Services the workloads can reach, as a Graphviz graph", s.dependencyz)
+	s.addDebugHandler(mux, internalMux, "/debug/simulate",
+		"Configuration of a proxy before and after POSTed config changes", s.Simulate)
 	s.addDebugHandler(mux, internalMux, "/debug/list", "List all supported debug commands in json", s.List)
 }
 
@@ -276,6 +309,7 @@ func isRequestFromLocalhost(r *http.Request) bool {
 // Syncz dumps the synchronization status of all Envoys connected to this Pilot instance
 func (s *DiscoveryServer) Syncz(w http.ResponseWriter, req *http.Request) {
 	syncz := make([]SyncStatus, 0)
+	pushVersion := s.globalPushContext().PushVersion
 	for _, con := range s.Clients() {
 		node := con.proxy
 		if node != nil {
@@ -283,6 +317,7 @@ func (s *DiscoveryServer) Syncz(w http.ResponseWriter, req *http.Request) {
 				ProxyID:              node.ID,
 				ClusterID:            node.Metadata.ClusterID.String(),
 				IstioVersion:         node.Metadata.IstioVersion,
+				ProxyIPs:             node.IPAddresses,
 				ClusterSent:          con.NonceSent(v3.ClusterType),
 				ClusterAcked:         con.NonceAcked(v3.ClusterType),
 				ListenerSent:         con.NonceSent(v3.ListenerType),
@@ -293,6 +328,13 @@ func (s *DiscoveryServer) Syncz(w http.ResponseWriter, req *http.Request) {
 				EndpointAcked:        con.NonceAcked(v3.EndpointType),
 				ExtensionConfigSent:  con.NonceSent(v3.ExtensionConfigurationType),
 				ExtensionConfigAcked: con.NonceAcked(v3.ExtensionConfigurationType),
+
+				ClusterVersionSent:         con.VersionSent(v3.ClusterType),
+				ListenerVersionSent:        con.VersionSent(v3.ListenerType),
+				RouteVersionSent:           con.VersionSent(v3.RouteType),
+				EndpointVersionSent:        con.VersionSent(v3.EndpointType),
+				ExtensionConfigVersionSent: con.VersionSent(v3.ExtensionConfigurationType),
+				PushVersion:                pushVersion,
 			})
 		}
 	}
@@ -667,7 +709,13 @@ func unmarshalToWasm(r *discovery.Resource) (any, error) {
 // ConfigDump returns information in the form of the Envoy admin API config dump for the specified proxy
 // The dump will only contain dynamic listeners/clusters/routes and can be used to compare what an Envoy instance
 // should look like according to Pilot vs what it currently does look like.
+// If the xDS node of a proxy is POSTed as JSON, the dump is the one generated for that proxy, whether it is
+// connected to this istiod or not, such as to preview the config of a proxy with another revision.
 func (s *DiscoveryServer) ConfigDump(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodPost {
+		s.nodeConfigDump(w, req)
+		return
+	}
 	proxyID, con := s.getDebugConnection(req)
 	if con == nil {
 		s.errorHandler(w, proxyID, con)
@@ -682,6 +730,118 @@ func (s *DiscoveryServer) ConfigDump(w http.ResponseWriter, req *http.Request) {
 	writeJSON(w, dump, req)
 }
 
+// maxNodeBytes is the maximum size of the xDS node in the body of a nodeConfigDump request.
+const maxNodeBytes = 1 << 20
+
+// nodeConfigDump writes the config dump of the listeners, clusters and routes generated for the proxy of the
+// xDS node in the body of the request.
+func (s *DiscoveryServer) nodeConfigDump(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxNodeBytes))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(fmt.Sprintf("failed to read the xDS node: %v", err)))
+		return
+	}
+	node := &core.Node{}
+	if err := protomarshal.Unmarshal(body, node); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(fmt.Sprintf("invalid xDS node: %v", err)))
+		return
+	}
+	con, err := s.nodeConnection(node)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	dump, err := s.configDump(con, false)
+	if err != nil {
+		handleHTTPError(w, err)
+		return
+	}
+	writeJSON(w, dump, req)
+}
+
+// nodeProxy returns the proxy of the xDS node, initialized against the push context as for a new connection.
+func (s *DiscoveryServer) nodeProxy(node *core.Node, push *model.PushContext) (*model.Proxy, error) {
+	proxy, err := s.initProxyMetadata(node)
+	if err != nil {
+		return nil, err
+	}
+	if alias, exists := s.ClusterAliases[proxy.Metadata.ClusterID]; exists {
+		proxy.Metadata.ClusterID = alias
+	}
+	proxy.LastPushContext = push
+	s.computeProxyState(proxy, nil)
+	proxy.DiscoverIPMode()
+	if proxy.Metadata.Generator != "" {
+		proxy.XdsResourceGenerator = s.Generators[proxy.Metadata.Generator]
+	}
+	return proxy, nil
+}
+
+// nodeConnection returns a connection of the proxy of the xDS node which is never registered, initialized as a new
+// connection would be, and watching the clusters, the listeners and the routes of the listeners.
+func (s *DiscoveryServer) nodeConnection(node *core.Node) (*Connection, error) {
+	proxy, err := s.nodeProxy(node, s.globalPushContext())
+	if err != nil {
+		return nil, err
+	}
+	proxy.WatchedResources = map[string]*model.WatchedResource{
+		v3.ClusterType:  {TypeUrl: v3.ClusterType},
+		v3.ListenerType: {TypeUrl: v3.ListenerType},
+	}
+	con := newConnection("", nil)
+	con.node = node
+	con.proxy = proxy
+
+	gen := s.findGenerator(v3.ListenerType, con)
+	if gen == nil {
+		return con, nil
+	}
+	req := &model.PushRequest{Push: proxy.LastPushContext, Start: time.Now(), Full: true}
+	listeners, _, err := gen.Generate(proxy, proxy.WatchedResources[v3.ListenerType], req)
+	if err != nil {
+		return nil, err
+	}
+	proxy.WatchedResources[v3.RouteType] = &model.WatchedResource{TypeUrl: v3.RouteType, ResourceNames: routeNames(listeners)}
+	return con, nil
+}
+
+// routeNames returns the sorted names of the RDS route configs of the HTTP connection managers of the listeners.
+func routeNames(listeners model.Resources) []string {
+	names := sets.New()
+	for _, r := range listeners {
+		l := &listener.Listener{}
+		if err := r.Resource.UnmarshalTo(l); err != nil {
+			continue
+		}
+		for _, fc := range l.GetFilterChains() {
+			listenerRouteNames(fc, names)
+		}
+		if fc := l.GetDefaultFilterChain(); fc != nil {
+			listenerRouteNames(fc, names)
+		}
+	}
+	return names.SortedList()
+}
+
+// listenerRouteNames adds the names of the RDS route configs of the HTTP connection managers of the filter chain.
+func listenerRouteNames(fc *listener.FilterChain, names sets.Set) {
+	for _, filter := range fc.GetFilters() {
+		if filter.GetName() != wellknown.HTTPConnectionManager {
+			continue
+		}
+		h := &hcm.HttpConnectionManager{}
+		if err := filter.GetTypedConfig().UnmarshalTo(h); err != nil {
+			continue
+		}
+		if name := h.GetRds().GetRouteConfigName(); name != "" {
+			names.Insert(name)
+		}
+	}
+}
+
 // configDump converts the connection internal state into an Envoy Admin API config dump proto
 // It is used in debugging to create a consistent object for comparison between Envoy and Pilot outputs
 func (s *DiscoveryServer) configDump(conn *Connection, includeEds bool) (*adminapi.ConfigDump, error) {
@@ -1079,6 +1239,169 @@ func (s *DiscoveryServer) clusterz(w http.ResponseWriter, req *http.Request) {
 	writeJSON(w, s.ListRemoteClusters(), req)
 }
 
+func (s *DiscoveryServer) replicationz(w http.ResponseWriter, req *http.Request) {
+	if s.ConfigReplicationStatus == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("config replication is not enabled"))
+		return
+	}
+	writeJSON(w, s.ConfigReplicationStatus(), req)
+}
+
+// certz lists the workload certificates issued by the istiod CA which have not expired, as JSON or,
+// with format=csv, as CSV.
+func (s *DiscoveryServer) certz(w http.ResponseWriter, req *http.Request) {
+	if s.ListIssuedCertificates == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("istiod CA is not enabled"))
+		return
+	}
+	certs := s.ListIssuedCertificates()
+	if req.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		if err := caserver.WriteCSV(w, certs); err != nil {
+			handleHTTPError(w, err)
+		}
+		return
+	}
+	writeJSON(w, certs, req)
+}
+
+// namespaceCAz lists the intermediate CA certificates issued to the namespaces, in use or revoked. A POST with the
+// revoke query parameter revokes those of the namespace instead, and returns them.
+func (s *DiscoveryServer) namespaceCAz(w http.ResponseWriter, req *http.Request) {
+	if s.ListNamespaceCAs == nil || s.RevokeNamespaceCA == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("namespace CAs are not enabled"))
+		return
+	}
+	if namespace := req.URL.Query().Get("revoke"); namespace != "" {
+		if req.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			_, _ = w.Write([]byte("revocation requires a POST request"))
+			return
+		}
+		info, err := s.RevokeNamespaceCA(namespace)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(err.Error()))
+			return
+		}
+		writeJSON(w, info, req)
+		return
+	}
+	cas, err := s.ListNamespaceCAs()
+	if err != nil {
+		handleHTTPError(w, err)
+		return
+	}
+	writeJSON(w, cas, req)
+}
+
+// serviceAccountz lists the pods and WorkloadEntries of the registries by service account identity. The
+// serviceAccount query parameter only lists the workloads of the identity, and namespace those of the namespace.
+func (s *DiscoveryServer) serviceAccountz(w http.ResponseWriter, req *http.Request) {
+	lister, ok := s.Env.ServiceDiscovery.(model.ServiceAccountWorkloadLister)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("the service registries do not list workload instances"))
+		return
+	}
+	serviceAccount, namespace := req.URL.Query().Get("serviceAccount"), req.URL.Query().Get("namespace")
+	out := map[string][]model.ServiceAccountWorkload{}
+	for _, wl := range lister.ServiceAccountWorkloads() {
+		if (serviceAccount != "" && wl.ServiceAccount != serviceAccount) || (namespace != "" && wl.Namespace != namespace) {
+			continue
+		}
+		out[wl.ServiceAccount] = append(out[wl.ServiceAccount], wl)
+	}
+	for _, workloads := range out {
+		sort.Slice(workloads, func(i, j int) bool {
+			a, b := workloads[i], workloads[j]
+			if a.Cluster != b.Cluster {
+				return a.Cluster < b.Cluster
+			}
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			return a.Kind < b.Kind
+		})
+	}
+	writeJSON(w, out, req)
+}
+
+// dependencyz exports the static dependency graph of the mesh, which workloads can reach which services according to
+// the Sidecar scopes, VirtualServices and ServiceEntries, as JSON or, with format=dot, in the DOT language of Graphviz.
+// The namespace query parameter only includes the workloads of the namespace.
+func (s *DiscoveryServer) dependencyz(w http.ResponseWriter, req *http.Request) {
+	graph := s.globalPushContext().DependencyGraph(req.URL.Query().Get("namespace"))
+	if req.URL.Query().Get("format") == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		if err := writeDependencyGraphDOT(w, graph); err != nil {
+			handleHTTPError(w, err)
+		}
+		return
+	}
+	writeJSON(w, graph, req)
+}
+
+// writeDependencyGraphDOT writes the graph in the DOT language: workloads are boxes, services ellipses, and the edges
+// of VirtualService routes are dashed and labeled with the VirtualService.
+func writeDependencyGraphDOT(w io.Writer, graph *model.DependencyGraph) error {
+	var b strings.Builder
+	b.WriteString("digraph mesh {\n")
+	for _, n := range graph.Nodes {
+		shape := "ellipse"
+		if n.Kind == model.DependencyNodeWorkloads {
+			shape = "box"
+		}
+		fmt.Fprintf(&b, "  %q [shape=%s];\n", n.ID, shape)
+	}
+	for _, e := range graph.Edges {
+		if e.Kind == model.DependencyEdgeRoutes {
+			fmt.Fprintf(&b, "  %q -> %q [style=dashed, label=%q];\n", e.From, e.To, e.Config)
+		} else {
+			fmt.Fprintf(&b, "  %q -> %q;\n", e.From, e.To)
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// pushCostz lists the configs whose changes triggered the most expensive pushes. Supported query parameters:
+// sort=size orders by total response size instead of generation time, limit=N returns only the top N entries,
+// and reset=true clears the statistics.
+func (s *DiscoveryServer) pushCostz(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Query().Get("reset") == "true" {
+		s.pushCost.reset()
+		_, _ = w.Write([]byte("push cost statistics cleared\n"))
+		return
+	}
+	costs := s.pushCost.list(req.URL.Query().Get("sort") == "size")
+	if l := req.URL.Query().Get("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil || limit < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("invalid limit\n"))
+			return
+		}
+		if limit < len(costs) {
+			costs = costs[:limit]
+		}
+	}
+	writeJSON(w, costs, req)
+}
+
+// nackz lists the resources rejected by the connected proxies, with the field which failed validation when it can be
+// identified, until the proxies accept a later configuration. The proxyID query parameter selects a single proxy.
+func (s *DiscoveryServer) nackz(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, s.nacks.list(req.URL.Query().Get("proxyID")), req)
+}
+
 // handlePushRequest handles a ?push=true query param and triggers a push.
 // A boolean response is returned to indicate if the caller should continue
 func (s *DiscoveryServer) handlePushRequest(w http.ResponseWriter, req *http.Request) bool {
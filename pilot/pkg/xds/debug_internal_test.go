@@ -0,0 +1,51 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/util/protoconv"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestRouteNames(t *testing.T) {
+	rdsFilterChain := func(name string) *listener.FilterChain {
+		return &listener.FilterChain{Filters: []*listener.Filter{{
+			Name: wellknown.HTTPConnectionManager,
+			ConfigType: &listener.Filter_TypedConfig{TypedConfig: protoconv.MessageToAny(&hcm.HttpConnectionManager{
+				RouteSpecifier: &hcm.HttpConnectionManager_Rds{Rds: &hcm.Rds{RouteConfigName: name}},
+			})},
+		}}}
+	}
+	listeners := model.Resources{
+		&discovery.Resource{Resource: protoconv.MessageToAny(&listener.Listener{
+			Name:               "virtualOutbound",
+			FilterChains:       []*listener.FilterChain{rdsFilterChain("80"), rdsFilterChain("8080")},
+			DefaultFilterChain: rdsFilterChain("9090"),
+		})},
+		&discovery.Resource{Resource: protoconv.MessageToAny(&listener.Listener{
+			Name:         "0.0.0.0_80",
+			FilterChains: []*listener.FilterChain{rdsFilterChain("80")},
+		})},
+	}
+	assert.Equal(t, routeNames(listeners), []string{"80", "8080", "9090"})
+}
@@ -19,14 +19,21 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	"istio.io/istio/istioctl/pkg/util/configdump"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/xds"
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/util/protomarshal"
+	"istio.io/istio/security/pkg/pki/ca"
 )
 
 func TestSyncz(t *testing.T) {
@@ -142,6 +149,15 @@ func verifySyncStatus(t *testing.T, s *xds.DiscoveryServer, nodeID string, wantS
 				if (ss.ExtensionConfigAcked != "") != wantAcked {
 					errorHandler("wanted ExtensionConfigAcked set %v got %v for %v", wantAcked, ss.ExtensionConfigAcked, nodeID)
 				}
+				if (ss.ClusterVersionSent != "") != wantSent {
+					errorHandler("wanted ClusterVersionSent set %v got %v for %v", wantSent, ss.ClusterVersionSent, nodeID)
+				}
+				if (ss.ListenerVersionSent != "") != wantSent {
+					errorHandler("wanted ListenerVersionSent set %v got %v for %v", wantSent, ss.ListenerVersionSent, nodeID)
+				}
+				if ss.PushVersion == "" {
+					errorHandler("wanted PushVersion set for %v", nodeID)
+				}
 				return
 			}
 		}
@@ -219,6 +235,65 @@ func getConfigDump(t *testing.T, s *xds.DiscoveryServer, proxyID string, wantCod
 	return got
 }
 
+func TestNodeConfigDump(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: example
+  namespace: default
+spec:
+  hosts:
+  - example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+`})
+	node, err := protomarshal.Marshal(&core.Node{
+		Id:       "sidecar~1.1.1.1~test.default~default.svc.cluster.local",
+		Metadata: model.NodeMetadata{Namespace: "default"}.ToStruct(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{name: "dumps the config of the node of a proxy which is not connected", body: string(node), wantCode: 200},
+		{name: "returns 400 if the node is invalid", body: "{", wantCode: 400},
+		{name: "returns 400 if the node is too large", body: string(node) + strings.Repeat(" ", 1<<20), wantCode: 400},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/config_dump", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(s.Discovery.ConfigDump).ServeHTTP(rr, req)
+			if rr.Code != tt.wantCode {
+				t.Fatalf("wanted response code %v, got %v: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if tt.wantCode > 399 {
+				return
+			}
+			wrapper := &configdump.Wrapper{}
+			if err := wrapper.UnmarshalJSON(rr.Body.Bytes()); err != nil {
+				t.Fatal(err)
+			}
+			if cs, err := wrapper.GetDynamicClusterDump(false); err != nil || len(cs.DynamicActiveClusters) == 0 {
+				t.Errorf("expected clusters to be generated, err: %v", err)
+			}
+			if rs, err := wrapper.GetDynamicRouteDump(false); err != nil || len(rs.DynamicRouteConfigs) == 0 {
+				t.Errorf("expected the routes of the listeners to be generated, err: %v", err)
+			}
+		})
+	}
+}
+
 func TestDebugHandlers(t *testing.T) {
 	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{})
 	req, err := http.NewRequest("GET", "/debug", nil)
@@ -232,3 +307,157 @@ func TestDebugHandlers(t *testing.T) {
 		t.Errorf("Error in generatating debug endpoint list")
 	}
 }
+
+func TestServiceAccountz(t *testing.T) {
+	pod := func(name, namespace, sa, ip string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       corev1.PodSpec{ServiceAccountName: sa},
+			Status:     corev1.PodStatus{PodIP: ip, Phase: corev1.PodRunning},
+		}
+	}
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{
+		KubernetesObjects: []runtime.Object{
+			pod("sleep", "default", "sleep", "10.0.0.1"),
+			pod("httpbin", "default", "httpbin", "10.0.0.2"),
+			pod("pending", "default", "sleep", ""),
+		},
+	})
+	mux := http.NewServeMux()
+	s.Discovery.AddDebugHandlers(http.NewServeMux(), mux, false, nil)
+	get := func(path string) map[string][]model.ServiceAccountWorkload {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %d: %s", rr.Code, rr.Body.String())
+		}
+		out := map[string][]model.ServiceAccountWorkload{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	got := get("/debug/serviceaccountz")
+	sleep := got["spiffe://cluster.local/ns/default/sa/sleep"]
+	if len(got) != 2 || len(sleep) != 1 || sleep[0].Name != "sleep" || sleep[0].Address != "10.0.0.1" || sleep[0].Kind != "Pod" {
+		t.Errorf("unexpected service accounts %+v", got)
+	}
+	got = get("/debug/serviceaccountz?serviceAccount=spiffe://cluster.local/ns/default/sa/httpbin")
+	if len(got) != 1 || len(got["spiffe://cluster.local/ns/default/sa/httpbin"]) != 1 {
+		t.Errorf("unexpected service accounts %+v", got)
+	}
+	if got = get("/debug/serviceaccountz?namespace=other"); len(got) != 0 {
+		t.Errorf("unexpected service accounts %+v", got)
+	}
+}
+
+func TestNamespaceCAzRevoke(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{})
+	var revoked []string
+	s.Discovery.ListNamespaceCAs = func() ([]ca.NamespaceCAInfo, error) {
+		return nil, nil
+	}
+	s.Discovery.RevokeNamespaceCA = func(namespace string) ([]ca.NamespaceCAInfo, error) {
+		revoked = append(revoked, namespace)
+		return []ca.NamespaceCAInfo{{Namespace: namespace}}, nil
+	}
+	mux := http.NewServeMux()
+	s.Discovery.AddDebugHandlers(http.NewServeMux(), mux, false, nil)
+	for _, tt := range []struct {
+		method string
+		code   int
+	}{
+		// Revoking changes state, so it is not served to GET requests.
+		{http.MethodGet, http.StatusMethodNotAllowed},
+		{http.MethodPost, http.StatusOK},
+	} {
+		req, err := http.NewRequest(tt.method, "/debug/namespacecaz?revoke=foo", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		if rr.Code != tt.code {
+			t.Errorf("%s: got status %d, want %d", tt.method, rr.Code, tt.code)
+		}
+	}
+	if len(revoked) != 1 {
+		t.Errorf("expected a single revocation, got %v", revoked)
+	}
+}
+
+func TestDependencyz(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{
+		ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: api
+  namespace: default
+spec:
+  hosts:
+  - api.example.com
+  ports:
+  - number: 443
+    name: https
+    protocol: TLS
+  resolution: DNS
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: api-v2
+  namespace: default
+spec:
+  hosts:
+  - api-v2.example.com
+  ports:
+  - number: 443
+    name: https
+    protocol: TLS
+  resolution: DNS
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: api
+  namespace: default
+spec:
+  hosts:
+  - api.example.com
+  tls:
+  - match:
+    - sniHosts:
+      - api.example.com
+    route:
+    - destination:
+        host: api-v2.example.com
+`,
+	})
+	mux := http.NewServeMux()
+	s.Discovery.AddDebugHandlers(http.NewServeMux(), mux, false, nil)
+	req, err := http.NewRequest("GET", "/debug/dependencyz?format=dot", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rr.Code, rr.Body.String())
+	}
+	for _, want := range []string{
+		`"namespace/default" [shape=box];`,
+		`"api.example.com" [shape=ellipse];`,
+		`"namespace/default" -> "api.example.com";`,
+		`"api.example.com" -> "api-v2.example.com" [style=dashed, label="default/api"];`,
+	} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("missing %s in:\n%s", want, rr.Body.String())
+		}
+	}
+}
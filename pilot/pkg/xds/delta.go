@@ -250,6 +250,7 @@ func (conn *Connection) sendDelta(res *discovery.DeltaDiscoveryResponse) error {
 				conn.proxy.WatchedResources[res.TypeUrl] = &model.WatchedResource{TypeUrl: res.TypeUrl}
 			}
 			conn.proxy.WatchedResources[res.TypeUrl].NonceSent = res.Nonce
+			conn.proxy.WatchedResources[res.TypeUrl].VersionSent = res.SystemVersionInfo
 			if features.EnableUnsafeDeltaTest {
 				conn.proxy.WatchedResources[res.TypeUrl].LastResources = applyDelta(conn.proxy.WatchedResources[res.TypeUrl].LastResources, res)
 			}
@@ -270,6 +271,10 @@ func (s *DiscoveryServer) processDeltaRequest(req *discovery.DeltaDiscoveryReque
 		s.handleWorkloadHealthcheck(con.proxy, deltaToSotwRequest(req))
 		return nil
 	}
+	if req.TypeUrl == v3.StartupReportType {
+		s.handleStartupReport(con.proxy, req.ResourceNamesSubscribe)
+		return nil
+	}
 	if strings.HasPrefix(req.TypeUrl, v3.DebugType) {
 		return s.pushXds(con,
 			&model.WatchedResource{TypeUrl: req.TypeUrl, ResourceNames: req.ResourceNamesSubscribe},
@@ -318,11 +323,17 @@ func (s *DiscoveryServer) shouldRespondDelta(con *Connection, request *discovery
 		errCode := codes.Code(request.ErrorDetail.Code)
 		deltaLog.Warnf("ADS:%s: ACK ERROR %s %s:%s", stype, con.conID, errCode.String(), request.ErrorDetail.GetMessage())
 		incrementXDSRejects(request.TypeUrl, con.proxy.ID, errCode.String())
+		s.nacks.nack(con.conID, parseNack(con.proxy.ID, request.TypeUrl, request.ResourceNamesSubscribe, "",
+			request.ResponseNonce, request.ErrorDetail.GetMessage(), time.Now()))
 		if s.StatusGen != nil {
 			s.StatusGen.OnNack(con.proxy, deltaToSotwRequest(request))
 		}
 		return false
 	}
+	if request.ResponseNonce != "" {
+		// The proxy accepted the last configuration of the type.
+		s.nacks.ack(con.conID, request.TypeUrl)
+	}
 
 	con.proxy.RLock()
 	previousInfo := con.proxy.WatchedResources[request.TypeUrl]
@@ -466,7 +477,7 @@ func (s *DiscoveryServer) pushDeltaXds(con *Connection,
 	}
 	defer func() { recordPushTime(w.TypeUrl, time.Since(t0)) }()
 	resp := &discovery.DeltaDiscoveryResponse{
-		ControlPlane: ControlPlane(),
+		ControlPlane: s.controlPlane(),
 		TypeUrl:      w.TypeUrl,
 		// TODO: send different version for incremental eds
 		SystemVersionInfo: req.Push.PushVersion,
@@ -495,6 +506,7 @@ func (s *DiscoveryServer) pushDeltaXds(con *Connection,
 
 	configSize := ResourceSize(res)
 	configSizeBytes.With(typeTag.Value(w.TypeUrl)).Record(float64(configSize))
+	s.pushCost.record(req, w.TypeUrl, time.Since(t0), configSize)
 
 	ptype := "PUSH"
 	info := ""
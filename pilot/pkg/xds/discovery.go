@@ -29,6 +29,7 @@ import (
 	"google.golang.org/grpc"
 
 	"istio.io/istio/pilot/pkg/autoregistration"
+	"istio.io/istio/pilot/pkg/config/kube/replication"
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking/apigen"
@@ -42,6 +43,8 @@ import (
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/cluster"
 	"istio.io/istio/pkg/security"
+	"istio.io/istio/security/pkg/pki/ca"
+	caserver "istio.io/istio/security/pkg/server/ca"
 )
 
 var (
@@ -151,6 +154,26 @@ type DiscoveryServer struct {
 	// ListRemoteClusters collects debug information about other clusters this istiod reads from.
 	ListRemoteClusters func() []cluster.DebugInfo
 
+	// ConfigReplicationStatus reports the status of config replicated to remote clusters, if enabled.
+	ConfigReplicationStatus func() []replication.Status
+
+	// ListIssuedCertificates lists the workload certificates issued by the istiod CA, if it is enabled.
+	ListIssuedCertificates func() []caserver.IssuedCertificate
+
+	// ListNamespaceCAs lists the intermediate CA certificates issued to the namespaces, and RevokeNamespaceCA
+	// revokes the one of a namespace, if the istiod CA delegates to namespace CAs.
+	ListNamespaceCAs  func() ([]ca.NamespaceCAInfo, error)
+	RevokeNamespaceCA func(namespace string) ([]ca.NamespaceCAInfo, error)
+
+	// RecordStartupReport records the startup phases reported by a proxy, if enabled.
+	RecordStartupReport func(proxy *model.Proxy, phases []string)
+
+	// pushCost attributes push generation cost to the configs triggering pushes.
+	pushCost *pushCostTracker
+
+	// nacks keeps the diagnostics of the configurations rejected by the connected proxies.
+	nacks *nackTracker
+
 	// ClusterAliases are aliase names for cluster. When a proxy connects with a cluster ID
 	// and if it has a different alias we should use that a cluster ID for proxy.
 	ClusterAliases map[cluster.ID]cluster.ID
@@ -177,6 +200,8 @@ func NewDiscoveryServer(env *model.Environment, instanceID string, clusterAliase
 		},
 		Cache:      model.DisabledCache{},
 		instanceID: instanceID,
+		pushCost:   newPushCostTracker(),
+		nacks:      newNackTracker(),
 	}
 
 	out.ClusterAliases = make(map[cluster.ID]cluster.ID)
@@ -327,6 +352,7 @@ func (s *DiscoveryServer) Push(req *model.PushRequest) {
 	if err != nil {
 		return
 	}
+	s.pushCost.evict(req, s.configExists)
 	initContextTime := time.Since(t0)
 	log.Debugf("InitContext %v for push took %s", versionLocal, initContextTime)
 	pushContextInitTime.Record(initContextTime.Seconds())
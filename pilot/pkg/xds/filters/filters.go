@@ -15,10 +15,13 @@
 package filters
 
 import (
+	"strings"
+
 	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	bandwidthlimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/bandwidth_limit/v3"
 	cors "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/cors/v3"
 	fault "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/fault/v3"
 	grpcstats "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/grpc_stats/v3"
@@ -30,16 +33,20 @@ import (
 	originalsrc "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/listener/original_src/v3"
 	tlsinspector "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/listener/tls_inspector/v3"
 	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	localratelimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/local_ratelimit/v3"
 	previoushost "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/host/previous_hosts/v3"
 	rawbuffer "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/raw_buffer/v3"
 	wasm "github.com/envoyproxy/go-control-plane/envoy/extensions/wasm/v3"
+	xdstype "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	alpn "istio.io/api/envoy/config/filter/http/alpn/v2alpha1"
 	"istio.io/api/envoy/config/filter/network/metadata_exchange"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/util/protoconv"
+	"istio.io/istio/pkg/config/xds"
 )
 
 const (
@@ -50,6 +57,13 @@ const (
 	RawBufferTransportProtocol = "raw_buffer"
 
 	MxFilterName = "istio.metadata_exchange"
+
+	// EgressBandwidthLimitName and IngressBandwidthLimitName are the names of the extension configs of the
+	// bandwidth limits of the outbound and inbound HTTP requests of a proxy.
+	EgressBandwidthLimitName  = "istio.egress_bandwidth_limit"
+	IngressBandwidthLimitName = "istio.ingress_bandwidth_limit"
+
+	ConnectionRateLimitFilterName = "envoy.filters.network.local_ratelimit"
 )
 
 // Define static filters to be reused across the codebase. This avoids duplicate marshaling/unmarshaling
@@ -180,6 +194,56 @@ func BuildRouterFilter(ctx *RouterFilterContext) *hcm.HttpFilter {
 	}
 }
 
+// BuildBandwidthLimitFilter builds a bandwidth limit filter fetching its config, named name, with ECDS. Envoy shares
+// the config of an ECDS resource, and so the token bucket of the limit, between all the listeners using it: the
+// limit applies to the proxy as a whole rather than to each listener.
+func BuildBandwidthLimitFilter(name string) *hcm.HttpFilter {
+	return &hcm.HttpFilter{
+		Name: name,
+		ConfigType: &hcm.HttpFilter_ConfigDiscovery{
+			ConfigDiscovery: &core.ExtensionConfigSource{
+				ConfigSource: &core.ConfigSource{
+					ConfigSourceSpecifier: &core.ConfigSource_Ads{Ads: &core.AggregatedConfigSource{}},
+					ResourceApiVersion:    core.ApiVersion_V3,
+					InitialFetchTimeout:   &durationpb.Duration{},
+				},
+				TypeUrls: []string{xds.BandwidthLimitType},
+			},
+		},
+	}
+}
+
+// BuildBandwidthLimitExtensionConfig builds the extension config, named name, limiting the bandwidth of the requests
+// to kbps KiB/s.
+func BuildBandwidthLimitExtensionConfig(name string, kbps uint64) *core.TypedExtensionConfig {
+	return &core.TypedExtensionConfig{
+		Name: name,
+		TypedConfig: protoconv.MessageToAny(&bandwidthlimit.BandwidthLimit{
+			StatPrefix: strings.TrimPrefix(name, "istio."),
+			EnableMode: bandwidthlimit.BandwidthLimit_REQUEST,
+			LimitKbps:  wrapperspb.UInt64(kbps),
+		}),
+	}
+}
+
+// BuildConnectionRateLimitFilter builds a network filter closing the new connections over the limit, with the stats
+// of the filter rooted at local_ratelimit.<statPrefix>.
+func BuildConnectionRateLimitFilter(limit *model.ConnectionRateLimit, statPrefix string) *listener.Filter {
+	return &listener.Filter{
+		Name: ConnectionRateLimitFilterName,
+		ConfigType: &listener.Filter_TypedConfig{
+			TypedConfig: protoconv.MessageToAny(&localratelimit.LocalRateLimit{
+				StatPrefix: statPrefix,
+				TokenBucket: &xdstype.TokenBucket{
+					MaxTokens:     limit.Burst,
+					TokensPerFill: wrapperspb.UInt32(limit.Connections),
+					FillInterval:  durationpb.New(limit.FillInterval),
+				},
+			}),
+		},
+	}
+}
+
 var (
 	// These ALPNs are injected in the client side by the ALPN filter.
 	// "istio" is added for each upstream protocol in order to make it
@@ -178,6 +178,12 @@ var (
 		"Total number of failures to fetch SDS key and certificate.",
 	)
 
+	pilotSDSCertificateSwapTime = monitoring.NewDistribution(
+		"pilot_sds_certificate_swap_seconds",
+		"Delay in seconds between a Secret update and the rotated certificate being pushed to a proxy over SDS.",
+		[]float64{.1, .5, 1, 3, 5, 10, 20, 30},
+	)
+
 	inboundConfigUpdates  = inboundUpdates.With(typeTag.Value("config"))
 	inboundEDSUpdates     = inboundUpdates.With(typeTag.Value("eds"))
 	inboundServiceUpdates = inboundUpdates.With(typeTag.Value("svc"))
@@ -304,6 +310,7 @@ func init() {
 		totalDelayedPushes,
 		totalDelayedPushTimeouts,
 		pilotSDSCertificateErrors,
+		pilotSDSCertificateSwapTime,
 		configSizeBytes,
 	)
 }
@@ -0,0 +1,218 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+)
+
+// NackDiagnostic is a configuration rejected by a proxy, with the resource and the field that failed validation as
+// far as they can be identified from the error detail of the NACK.
+type NackDiagnostic struct {
+	ProxyID string `json:"proxy"`
+	TypeURL string `json:"type"`
+	// Resource is the name of the rejected resource, empty if it cannot be identified.
+	Resource string `json:"resource,omitempty"`
+	// Field is the path of the field that failed validation, empty if it cannot be identified.
+	Field string `json:"field,omitempty"`
+	// Message is the error detail of the NACK for the resource.
+	Message string `json:"message"`
+	// Version is the version of the configuration of the type the proxy still runs.
+	Version string    `json:"version,omitempty"`
+	Nonce   string    `json:"nonce,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+func (d NackDiagnostic) String() string {
+	var b strings.Builder
+	if d.Resource != "" {
+		fmt.Fprintf(&b, "%s %q", v3.GetShortType(d.TypeURL), d.Resource)
+	} else {
+		b.WriteString(v3.GetShortType(d.TypeURL))
+	}
+	if d.Field != "" {
+		fmt.Fprintf(&b, " field %s", d.Field)
+	}
+	fmt.Fprintf(&b, ": %s", d.Message)
+	return b.String()
+}
+
+var (
+	// failedResourcesRegex matches the errors of LDS and CDS, which list the rejected listeners or clusters.
+	failedResourcesRegex = regexp.MustCompile(`^Error adding/updating (?:listener|cluster)\(s\) `)
+	// validationErrorRegex matches a field of a protoc-gen-validate error, such as
+	// ListenerValidationError.FilterChains[0].
+	validationErrorRegex = regexp.MustCompile(`\w+ValidationError\.(\w+(?:\[[^\]]*\])?)`)
+	// jsonFieldRegex matches the path of a field the proxy failed to parse, such as
+	// INVALID_ARGUMENT:(filter_chains[0].filters[0]) foo: Cannot find field.
+	jsonFieldRegex = regexp.MustCompile(`INVALID_ARGUMENT:\(([^)]*)\)\s*(\w+):`)
+)
+
+// parseNack returns the diagnostics of a NACK of the resources of a type. Envoy names the rejected resources in the
+// errors of LDS and CDS; for the other types, the resource is only identified if a single one was requested.
+func parseNack(proxyID, typeURL string, resourceNames []string, version, nonce, message string, now time.Time) []NackDiagnostic {
+	diagnostic := func(resource, msg string) NackDiagnostic {
+		return NackDiagnostic{
+			ProxyID:  proxyID,
+			TypeURL:  typeURL,
+			Resource: resource,
+			Field:    nackField(msg),
+			Message:  msg,
+			Version:  version,
+			Nonce:    nonce,
+			Time:     now,
+		}
+	}
+	if loc := failedResourcesRegex.FindStringIndex(message); loc != nil {
+		var out []NackDiagnostic
+		rest := message[loc[1]:]
+		// Listeners are separated by new lines, clusters by commas.
+		sep := "\n"
+		if typeURL == v3.ClusterType {
+			sep = ", "
+		}
+		for _, failure := range strings.Split(strings.TrimSpace(rest), sep) {
+			name, msg, found := strings.Cut(failure, ": ")
+			if !found {
+				continue
+			}
+			out = append(out, diagnostic(name, msg))
+		}
+		if len(out) > 0 {
+			return out
+		}
+	}
+	resource := ""
+	if len(resourceNames) == 1 {
+		resource = resourceNames[0]
+	}
+	return []NackDiagnostic{diagnostic(resource, message)}
+}
+
+// nackField returns the path of the field which failed validation or parsing in the error, if any.
+func nackField(message string) string {
+	if m := validationErrorRegex.FindAllStringSubmatch(message, -1); len(m) > 0 {
+		fields := make([]string, 0, len(m))
+		for _, f := range m {
+			fields = append(fields, f[1])
+		}
+		return strings.Join(fields, ".")
+	}
+	if m := jsonFieldRegex.FindStringSubmatch(message); m != nil {
+		if m[1] == "" {
+			return m[2]
+		}
+		return m[1] + "." + m[2]
+	}
+	return ""
+}
+
+// nackTracker keeps the diagnostics of the last NACK of each type for each connection, until the proxy accepts a
+// configuration of the type or disconnects.
+type nackTracker struct {
+	mu sync.RWMutex
+	// nacks maps a connection ID and a type URL to the diagnostics of the last NACK.
+	nacks map[string]map[string][]NackDiagnostic
+}
+
+func newNackTracker() *nackTracker {
+	return &nackTracker{nacks: map[string]map[string][]NackDiagnostic{}}
+}
+
+func (t *nackTracker) nack(conID string, diagnostics []NackDiagnostic) {
+	if t == nil || len(diagnostics) == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.nacks[conID] == nil {
+		t.nacks[conID] = map[string][]NackDiagnostic{}
+	}
+	t.nacks[conID][diagnostics[0].TypeURL] = diagnostics
+}
+
+func (t *nackTracker) ack(conID, typeURL string) {
+	if t == nil {
+		return
+	}
+	// Most ACKs have no NACK to clear, so only take the write lock if there is one.
+	t.mu.RLock()
+	_, f := t.nacks[conID][typeURL]
+	t.mu.RUnlock()
+	if !f {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.nacks[conID], typeURL)
+	if len(t.nacks[conID]) == 0 {
+		delete(t.nacks, conID)
+	}
+}
+
+func (t *nackTracker) remove(conID string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.nacks, conID)
+}
+
+// get returns the diagnostics of the last NACK of the type by the connection.
+func (t *nackTracker) get(conID, typeURL string) []NackDiagnostic {
+	if t == nil {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.nacks[conID][typeURL]
+}
+
+// list returns the diagnostics of the proxy, or of all the proxies if empty, ordered by proxy, type and resource.
+func (t *nackTracker) list(proxyID string) []NackDiagnostic {
+	out := []NackDiagnostic{}
+	if t == nil {
+		return out
+	}
+	t.mu.RLock()
+	for _, types := range t.nacks {
+		for _, diagnostics := range types {
+			for _, d := range diagnostics {
+				if proxyID == "" || d.ProxyID == proxyID {
+					out = append(out, d)
+				}
+			}
+		}
+	}
+	t.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ProxyID != out[j].ProxyID {
+			return out[i].ProxyID < out[j].ProxyID
+		}
+		if out[i].TypeURL != out[j].TypeURL {
+			return out[i].TypeURL < out[j].TypeURL
+		}
+		return out[i].Resource < out[j].Resource
+	})
+	return out
+}
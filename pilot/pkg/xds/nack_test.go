@@ -0,0 +1,153 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/genproto/googleapis/rpc/status"
+
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+func TestParseNack(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name      string
+		typeURL   string
+		resources []string
+		message   string
+		want      []NackDiagnostic
+	}{
+		{
+			name:    "listeners",
+			typeURL: v3.ListenerType,
+			message: "Error adding/updating listener(s) 0.0.0.0_8080: Proto constraint validation failed " +
+				"(ListenerValidationError.FilterChains[0]: embedded message failed validation | caused by " +
+				"FilterChainValidationError.Filters[0]: embedded message failed validation | caused by " +
+				"FilterValidationError.Name: value length must be at least 1 runes): name: \"\"\n" +
+				"virtualInbound: error adding listener: 'virtualInbound' has duplicate address\n",
+			want: []NackDiagnostic{
+				{
+					Resource: "0.0.0.0_8080",
+					Field:    "FilterChains[0].Filters[0].Name",
+					Message: "Proto constraint validation failed (ListenerValidationError.FilterChains[0]: embedded message " +
+						"failed validation | caused by FilterChainValidationError.Filters[0]: embedded message failed " +
+						"validation | caused by FilterValidationError.Name: value length must be at least 1 runes): name: \"\"",
+				},
+				{
+					Resource: "virtualInbound",
+					Message:  "error adding listener: 'virtualInbound' has duplicate address",
+				},
+			},
+		},
+		{
+			name:    "clusters",
+			typeURL: v3.ClusterType,
+			message: "Error adding/updating cluster(s) outbound|80||a.default.svc.cluster.local: unknown field, " +
+				"outbound|80||b.default.svc.cluster.local: Unable to parse JSON as proto " +
+				"(INVALID_ARGUMENT:(transport_socket.typed_config) sni_x: Cannot find field.)",
+			want: []NackDiagnostic{
+				{Resource: "outbound|80||a.default.svc.cluster.local", Message: "unknown field"},
+				{
+					Resource: "outbound|80||b.default.svc.cluster.local",
+					Field:    "transport_socket.typed_config.sni_x",
+					Message:  "Unable to parse JSON as proto (INVALID_ARGUMENT:(transport_socket.typed_config) sni_x: Cannot find field.)",
+				},
+			},
+		},
+		{
+			name:      "single route",
+			typeURL:   v3.RouteType,
+			resources: []string{"80"},
+			message:   "Only unique values for domains are permitted. Duplicate entry of domain a.com in route 80",
+			want: []NackDiagnostic{
+				{Resource: "80", Message: "Only unique values for domains are permitted. Duplicate entry of domain a.com in route 80"},
+			},
+		},
+		{
+			name:      "unidentified route",
+			typeURL:   v3.RouteType,
+			resources: []string{"80", "8080"},
+			message:   "Proto constraint validation failed (RouteConfigurationValidationError.VirtualHosts[0]: invalid)",
+			want: []NackDiagnostic{
+				{Field: "VirtualHosts[0]", Message: "Proto constraint validation failed (RouteConfigurationValidationError.VirtualHosts[0]: invalid)"},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := parseNack("proxy", c.typeURL, c.resources, "v1", "nonce", c.message, now)
+			for i := range c.want {
+				c.want[i].ProxyID = "proxy"
+				c.want[i].TypeURL = c.typeURL
+				c.want[i].Version = "v1"
+				c.want[i].Nonce = "nonce"
+				c.want[i].Time = now
+			}
+			assert.Equal(t, got, c.want)
+		})
+	}
+}
+
+func TestNackz(t *testing.T) {
+	s := NewFakeDiscoveryServer(t, FakeOptions{})
+	ads := s.ConnectADS().WithType(v3.ListenerType)
+	resp := ads.RequestResponseAck(t, nil)
+
+	ads.Request(t, &discovery.DiscoveryRequest{
+		ResponseNonce: resp.Nonce,
+		ErrorDetail:   &status.Status{Message: "Error adding/updating listener(s) 0.0.0.0_80: invalid"},
+	})
+	retry.UntilSuccessOrFail(t, func() error {
+		nacks := s.Discovery.nacks.list("test.default")
+		if len(nacks) != 1 || nacks[0].Resource != "0.0.0.0_80" || nacks[0].Message != "invalid" {
+			return fmt.Errorf("unexpected nacks: %v", nacks)
+		}
+		return nil
+	}, retry.Timeout(time.Second*5))
+
+	// Accepting a configuration of the type clears the diagnostics.
+	ads.Request(t, &discovery.DiscoveryRequest{ResponseNonce: resp.Nonce})
+	retry.UntilSuccessOrFail(t, func() error {
+		if nacks := s.Discovery.nacks.list(""); len(nacks) != 0 {
+			return fmt.Errorf("unexpected nacks: %v", nacks)
+		}
+		return nil
+	}, retry.Timeout(time.Second*5))
+}
+
+func TestNackTrackerAck(t *testing.T) {
+	tracker := newNackTracker()
+	tracker.nack("con-1", []NackDiagnostic{{ProxyID: "a", TypeURL: v3.ListenerType}})
+	tracker.nack("con-1", []NackDiagnostic{{ProxyID: "a", TypeURL: v3.ClusterType}})
+
+	// An ACK of a type without a NACK leaves the others.
+	tracker.ack("con-1", v3.RouteType)
+	tracker.ack("con-2", v3.ListenerType)
+	assert.Equal(t, len(tracker.list("")), 2)
+
+	tracker.ack("con-1", v3.ListenerType)
+	assert.Equal(t, len(tracker.get("con-1", v3.ListenerType)), 0)
+	assert.Equal(t, len(tracker.get("con-1", v3.ClusterType)), 1)
+
+	tracker.ack("con-1", v3.ClusterType)
+	assert.Equal(t, len(tracker.nacks), 0)
+}
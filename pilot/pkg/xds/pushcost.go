@@ -0,0 +1,183 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/schema/kind"
+)
+
+// ConfigPushCost is the accumulated cost of the xDS responses generated because a config resource changed. The cost of
+// a response triggered by several configs, as debounced pushes are, is split evenly between them.
+type ConfigPushCost struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Responses is the number of xDS responses generated, summed over all proxies and types.
+	Responses int64 `json:"responses"`
+	// GenerationTime is the share of the config in the time spent generating and sending the responses.
+	GenerationTime time.Duration `json:"generationTime"`
+	// Bytes is the share of the config in the size of the responses.
+	Bytes int64 `json:"bytes"`
+	// ByType breaks the cost down by xDS type.
+	ByType map[string]*TypePushCost `json:"byType"`
+	// LastPush is the time of the last response triggered by the config.
+	LastPush time.Time `json:"lastPush"`
+}
+
+// TypePushCost is the share of a ConfigPushCost attributed to a single xDS type.
+type TypePushCost struct {
+	Responses      int64         `json:"responses"`
+	GenerationTime time.Duration `json:"generationTime"`
+	Bytes          int64         `json:"bytes"`
+}
+
+// pushCostTracker attributes the cost of each xDS response to the configs whose change triggered the push.
+// Pushes without specific configs, such as full pushes on proxy connection, are not attributed, and neither are the
+// pushes of the deletion of a config, whose cost is evicted.
+type pushCostTracker struct {
+	mu    sync.Mutex
+	costs map[model.ConfigKey]*ConfigPushCost
+	// deleted are the configs of the current push which no longer exist.
+	deleted map[model.ConfigKey]struct{}
+}
+
+func newPushCostTracker() *pushCostTracker {
+	return &pushCostTracker{costs: map[model.ConfigKey]*ConfigPushCost{}}
+}
+
+// evict removes the costs of the configs which no longer exist, as reported by exists, when a full push starts.
+func (t *pushCostTracker) evict(req *model.PushRequest, exists func(model.ConfigKey) bool) {
+	if t == nil {
+		return
+	}
+	deleted := map[model.ConfigKey]struct{}{}
+	for key := range req.ConfigsUpdated {
+		if !exists(key) {
+			deleted[key] = struct{}{}
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key := range t.costs {
+		if _, f := deleted[key]; f || !exists(key) {
+			delete(t.costs, key)
+		}
+	}
+	t.deleted = deleted
+}
+
+func (t *pushCostTracker) record(req *model.PushRequest, typeURL string, d time.Duration, size int) {
+	if t == nil || req == nil || len(req.ConfigsUpdated) == 0 {
+		return
+	}
+	shortType := v3.GetShortType(typeURL)
+	now := time.Now()
+	n := len(req.ConfigsUpdated)
+	d /= time.Duration(n)
+	size /= n
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key := range req.ConfigsUpdated {
+		if _, f := t.deleted[key]; f {
+			continue
+		}
+		c, f := t.costs[key]
+		if !f {
+			c = &ConfigPushCost{
+				Kind:      key.Kind.String(),
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				ByType:    map[string]*TypePushCost{},
+			}
+			t.costs[key] = c
+		}
+		c.Responses++
+		c.GenerationTime += d
+		c.Bytes += int64(size)
+		c.LastPush = now
+		tc, f := c.ByType[shortType]
+		if !f {
+			tc = &TypePushCost{}
+			c.ByType[shortType] = tc
+		}
+		tc.Responses++
+		tc.GenerationTime += d
+		tc.Bytes += int64(size)
+	}
+}
+
+// list returns a copy of the costs, most expensive first. If bySize is set, costs are ordered by total
+// response size instead of generation time.
+func (t *pushCostTracker) list(bySize bool) []ConfigPushCost {
+	t.mu.Lock()
+	out := make([]ConfigPushCost, 0, len(t.costs))
+	for _, c := range t.costs {
+		cp := *c
+		cp.ByType = make(map[string]*TypePushCost, len(c.ByType))
+		for k, v := range c.ByType {
+			tc := *v
+			cp.ByType[k] = &tc
+		}
+		out = append(out, cp)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if bySize && out[i].Bytes != out[j].Bytes {
+			return out[i].Bytes > out[j].Bytes
+		}
+		if !bySize && out[i].GenerationTime != out[j].GenerationTime {
+			return out[i].GenerationTime > out[j].GenerationTime
+		}
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		if out[i].Namespace != out[j].Namespace {
+			return out[i].Namespace < out[j].Namespace
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func (t *pushCostTracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.costs = map[model.ConfigKey]*ConfigPushCost{}
+}
+
+// configExists returns false if the config of the key was deleted. Only the configs of the config store and the
+// services, keyed by hostname, are checked.
+func (s *DiscoveryServer) configExists(key model.ConfigKey) bool {
+	if key.Kind == kind.ServiceEntry {
+		return s.Env.ServiceDiscovery == nil || s.Env.GetService(host.Name(key.Name)) != nil
+	}
+	if s.Env.ConfigStore == nil {
+		return true
+	}
+	for _, schema := range s.Env.ConfigStore.Schemas().All() {
+		if gvk := schema.Resource().GroupVersionKind(); kind.FromGvk(gvk) == key.Kind {
+			return s.Env.ConfigStore.Get(gvk, key.Name, key.Namespace) != nil
+		}
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestPushCostTracker(t *testing.T) {
+	filter := model.ConfigKey{Kind: kind.EnvoyFilter, Name: "lua", Namespace: "istio-system"}
+	vs := model.ConfigKey{Kind: kind.VirtualService, Name: "big", Namespace: "default"}
+
+	tr := newPushCostTracker()
+	tr.record(&model.PushRequest{ConfigsUpdated: map[model.ConfigKey]struct{}{filter: {}}}, v3.ListenerType, 3*time.Millisecond, 100)
+	tr.record(&model.PushRequest{ConfigsUpdated: map[model.ConfigKey]struct{}{filter: {}}}, v3.ClusterType, 2*time.Millisecond, 100)
+	tr.record(&model.PushRequest{ConfigsUpdated: map[model.ConfigKey]struct{}{vs: {}}}, v3.RouteType, time.Millisecond, 1000)
+	// Pushes not triggered by a config change are not attributed.
+	tr.record(&model.PushRequest{Full: true}, v3.ClusterType, time.Second, 10000)
+
+	byTime := tr.list(false)
+	assert.Equal(t, len(byTime), 2)
+	assert.Equal(t, byTime[0].Name, "lua")
+	assert.Equal(t, byTime[0].Responses, int64(2))
+	assert.Equal(t, byTime[0].GenerationTime, 5*time.Millisecond)
+	assert.Equal(t, byTime[0].Bytes, int64(200))
+	assert.Equal(t, byTime[0].ByType["LDS"].Bytes, int64(100))
+
+	bySize := tr.list(true)
+	assert.Equal(t, bySize[0].Name, "big")
+
+	tr.reset()
+	assert.Equal(t, len(tr.list(false)), 0)
+}
+
+func TestPushCostTrackerDebouncedPush(t *testing.T) {
+	a := model.ConfigKey{Kind: kind.VirtualService, Name: "a", Namespace: "default"}
+	b := model.ConfigKey{Kind: kind.VirtualService, Name: "b", Namespace: "default"}
+
+	tr := newPushCostTracker()
+	tr.record(&model.PushRequest{ConfigsUpdated: map[model.ConfigKey]struct{}{a: {}, b: {}}}, v3.RouteType, 4*time.Millisecond, 1000)
+
+	costs := tr.list(false)
+	assert.Equal(t, len(costs), 2)
+	for _, c := range costs {
+		assert.Equal(t, c.Responses, int64(1))
+		assert.Equal(t, c.GenerationTime, 2*time.Millisecond)
+		assert.Equal(t, c.Bytes, int64(500))
+		assert.Equal(t, c.ByType["RDS"].Bytes, int64(500))
+	}
+}
+
+func TestPushCostTrackerEvict(t *testing.T) {
+	kept := model.ConfigKey{Kind: kind.VirtualService, Name: "kept", Namespace: "default"}
+	removed := model.ConfigKey{Kind: kind.VirtualService, Name: "removed", Namespace: "default"}
+	deleted := model.ConfigKey{Kind: kind.VirtualService, Name: "deleted", Namespace: "default"}
+	existing := map[model.ConfigKey]bool{kept: true}
+	exists := func(key model.ConfigKey) bool { return existing[key] }
+
+	tr := newPushCostTracker()
+	tr.record(&model.PushRequest{ConfigsUpdated: map[model.ConfigKey]struct{}{kept: {}}}, v3.RouteType, time.Millisecond, 100)
+	tr.record(&model.PushRequest{ConfigsUpdated: map[model.ConfigKey]struct{}{removed: {}}}, v3.RouteType, time.Millisecond, 100)
+	tr.record(&model.PushRequest{ConfigsUpdated: map[model.ConfigKey]struct{}{deleted: {}}}, v3.RouteType, time.Millisecond, 100)
+
+	// The push of the deletion is not attributed to the deleted config.
+	req := &model.PushRequest{Full: true, ConfigsUpdated: map[model.ConfigKey]struct{}{deleted: {}}}
+	tr.evict(req, exists)
+	tr.record(req, v3.RouteType, time.Millisecond, 100)
+
+	costs := tr.list(false)
+	assert.Equal(t, len(costs), 1)
+	assert.Equal(t, costs[0].Name, "kept")
+}
+
+func TestConfigExists(t *testing.T) {
+	s := NewFakeDiscoveryServer(t, FakeOptions{ConfigString: `
+apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: reviews
+  namespace: default
+spec:
+  hosts: [reviews.default.svc.cluster.local]
+  http:
+  - route:
+    - destination:
+        host: reviews.default.svc.cluster.local
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: example
+  namespace: default
+spec:
+  hosts: [example.com]
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+`})
+	ds := s.Discovery
+	assert.Equal(t, ds.configExists(model.ConfigKey{Kind: kind.VirtualService, Name: "reviews", Namespace: "default"}), true)
+	assert.Equal(t, ds.configExists(model.ConfigKey{Kind: kind.VirtualService, Name: "deleted", Namespace: "default"}), false)
+	assert.Equal(t, ds.configExists(model.ConfigKey{Kind: kind.ServiceEntry, Name: "example.com", Namespace: "default"}), true)
+	assert.Equal(t, ds.configExists(model.ConfigKey{Kind: kind.ServiceEntry, Name: "deleted.example.com", Namespace: "default"}), false)
+}
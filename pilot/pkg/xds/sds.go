@@ -19,6 +19,7 @@ import (
 	"encoding/pem"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	cryptomb "github.com/envoyproxy/go-control-plane/contrib/envoy/extensions/private_key_providers/cryptomb/v3alpha"
@@ -139,6 +140,9 @@ func (s *SecretGen) Generate(proxy *model.Proxy, w *model.WatchedResource, req *
 			// We skip cache if assertions are enabled, so that the cache will assert our eviction logic is correct
 			results = append(results, cachedItem)
 			cached++
+			if updatedSecrets != nil {
+				s.recordSwapTime(sr)
+			}
 			continue
 		}
 		regenerated++
@@ -146,6 +150,12 @@ func (s *SecretGen) Generate(proxy *model.Proxy, w *model.WatchedResource, req *
 		if res != nil {
 			s.cache.Add(sr, req, res)
 			results = append(results, res)
+			if updatedSecrets != nil {
+				s.recordSwapTime(sr)
+			}
+		} else if updatedSecrets != nil {
+			// The Secret was deleted or is no longer valid, there is no rotated certificate to measure.
+			s.forgetUpdate(sr)
 		}
 	}
 	return results, model.XdsLogDetails{
@@ -398,12 +408,21 @@ func relatedConfigs(k model.ConfigKey) []model.ConfigKey {
 	return related
 }
 
+// sdsSwapTimeRetention bounds how long the update time of a Secret is kept. The proxies referencing a Secret are
+// pushed well within it, past it the update time is dropped even if no push used it.
+const sdsSwapTimeRetention = time.Minute
+
 type SecretGen struct {
 	secrets credscontroller.MulticlusterController
 	// Cache for XDS resources
 	cache         model.XdsCache
 	configCluster cluster.ID
 	meshConfig    *mesh.MeshConfig
+
+	// updateTimes holds the time of the last update of each Secret, to measure how long the rotated
+	// certificate takes to reach the proxies.
+	updateMu    sync.Mutex
+	updateTimes map[model.ConfigKey]time.Time
 }
 
 var _ model.XdsResourceGenerator = &SecretGen{}
@@ -418,5 +437,48 @@ func NewSecretGen(sc credscontroller.MulticlusterController, cache model.XdsCach
 		cache:         cache,
 		configCluster: configCluster,
 		meshConfig:    meshConfig,
+		updateTimes:   map[model.ConfigKey]time.Time{},
+	}
+}
+
+// SecretUpdated records that a Secret changed. The certificate it holds is rotated in place over SDS, without
+// rebuilding the listeners referencing it, on the next push.
+func (s *SecretGen) SecretUpdated(name, namespace string) {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+	now := time.Now()
+	// Updates that were never pushed, such as Secrets no proxy references, would otherwise be kept forever.
+	for key, t := range s.updateTimes {
+		if now.Sub(t) > sdsSwapTimeRetention {
+			delete(s.updateTimes, key)
+		}
+	}
+	s.updateTimes[model.ConfigKey{Kind: kind.Secret, Name: name, Namespace: namespace}] = now
+}
+
+// recordSwapTime records the delay between the update of the Secret backing sr and its push to a proxy. It is
+// recorded once per pushed proxy, whether the resource was regenerated or served from the cache.
+func (s *SecretGen) recordSwapTime(sr SecretResource) {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+	for _, key := range relatedConfigs(model.ConfigKey{Kind: kind.Secret, Name: sr.Name, Namespace: sr.Namespace}) {
+		if t, f := s.updateTimes[key]; f {
+			d := time.Since(t)
+			if d > sdsSwapTimeRetention {
+				delete(s.updateTimes, key)
+				return
+			}
+			pilotSDSCertificateSwapTime.Record(d.Seconds())
+			return
+		}
+	}
+}
+
+// forgetUpdate drops the update times of the Secret backing sr.
+func (s *SecretGen) forgetUpdate(sr SecretResource) {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+	for _, key := range relatedConfigs(model.ConfigKey{Kind: kind.Secret, Name: sr.Name, Namespace: sr.Namespace}) {
+		delete(s.updateTimes, key)
 	}
 }
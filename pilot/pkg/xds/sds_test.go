@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"go.opencensus.io/stats/view"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -386,3 +387,87 @@ func TestAtMostNJoin(t *testing.T) {
 		})
 	}
 }
+
+func TestSecretUpdateOnlyPushesSDS(t *testing.T) {
+	req := &model.PushRequest{
+		Full:           false,
+		ConfigsUpdated: map[model.ConfigKey]struct{}{{Kind: kind.Secret, Name: "gateway-cert", Namespace: "istio-system"}: {}},
+		Reason:         []model.TriggerReason{model.SecretTrigger},
+	}
+	gateway := &model.Proxy{Type: model.Router}
+	if !sdsNeedsPush(req.ConfigsUpdated) {
+		t.Fatalf("expected secret update to push SDS")
+	}
+	if ldsNeedsPush(gateway, req) || cdsNeedsPush(req, gateway) || rdsNeedsPush(req) {
+		t.Fatalf("expected secret update to leave listeners, clusters and routes untouched")
+	}
+	// A full push for the same secret must not rebuild the gateway listeners either.
+	req.Full = true
+	if ldsNeedsPush(gateway, req) {
+		t.Fatalf("expected full secret update to leave listeners untouched")
+	}
+}
+
+func swapTimeCount(t *testing.T) int64 {
+	data, err := view.RetrieveData("pilot_sds_certificate_swap_seconds")
+	if err != nil {
+		t.Fatalf("failed to get value for pilot_sds_certificate_swap_seconds: %v", err)
+	}
+	if len(data) == 0 {
+		return 0
+	}
+	return data[0].Data.(*view.DistributionData).Count
+}
+
+func TestSecretSwapTime(t *testing.T) {
+	s := NewFakeDiscoveryServer(t, FakeOptions{
+		KubernetesObjects: []runtime.Object{genericCert},
+		KubeClientModifier: func(c kube.Client) {
+			cc := c.Kube().(*fake.Clientset)
+			disableAuthorizationForSecret(cc)
+		},
+	})
+	gen := s.Discovery.Generators[v3.SecretType].(*SecretGen)
+	newProxy := func() *model.Proxy {
+		return s.SetupProxy(&model.Proxy{
+			Metadata:         &model.NodeMetadata{ClusterID: "Kubernetes"},
+			VerifiedIdentity: &spiffe.Identity{Namespace: "istio-system"},
+			Type:             model.Router,
+			ConfigNamespace:  "istio-system",
+		})
+	}
+	push := func(name string) *model.PushRequest {
+		gen.SecretUpdated(name, "istio-system")
+		return &model.PushRequest{
+			Full:           false,
+			ConfigsUpdated: map[model.ConfigKey]struct{}{{Kind: kind.Secret, Name: name, Namespace: "istio-system"}: {}},
+			Start:          time.Now(),
+		}
+	}
+
+	before := swapTimeCount(t)
+	req := push("generic")
+	// The second proxy is served from the cache, the swap time is still recorded for it.
+	for i := 0; i < 2; i++ {
+		secrets, _, _ := gen.Generate(newProxy(), &model.WatchedResource{ResourceNames: []string{"kubernetes://generic"}}, req)
+		if len(secrets) != 1 {
+			t.Fatalf("expected the rotated secret to be pushed, got %v", secrets)
+		}
+	}
+	if got := swapTimeCount(t) - before; got != 2 {
+		t.Fatalf("expected the swap time to be recorded for both proxies, got %v", got)
+	}
+
+	// A Secret that no longer exists is dropped rather than kept until it is recreated.
+	req = push("missing")
+	secrets, _, _ := gen.Generate(newProxy(), &model.WatchedResource{ResourceNames: []string{"kubernetes://missing"}}, req)
+	if len(secrets) != 0 {
+		t.Fatalf("expected no secret to be pushed, got %v", secrets)
+	}
+	gen.updateMu.Lock()
+	_, found := gen.updateTimes[model.ConfigKey{Kind: kind.Secret, Name: "missing", Namespace: "istio-system"}]
+	gen.updateMu.Unlock()
+	if found {
+		t.Fatalf("expected the update time of the deleted secret to be dropped")
+	}
+}
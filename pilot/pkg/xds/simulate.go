@@ -0,0 +1,320 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"google.golang.org/protobuf/proto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/core/v1alpha3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/util/protomarshal"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// simulatedKinds are the kinds of the configs the push context reads from the config store, which proposed changes
+// can be simulated for. Service entries and Kubernetes Gateway API configs reach the push context through their
+// controllers, which the simulation does not run.
+var simulatedKinds = map[string]config.GroupVersionKind{
+	gvk.VirtualService.Kind:        gvk.VirtualService,
+	gvk.DestinationRule.Kind:       gvk.DestinationRule,
+	gvk.Gateway.Kind:               gvk.Gateway,
+	gvk.Sidecar.Kind:               gvk.Sidecar,
+	gvk.EnvoyFilter.Kind:           gvk.EnvoyFilter,
+	gvk.WasmPlugin.Kind:            gvk.WasmPlugin,
+	gvk.AuthorizationPolicy.Kind:   gvk.AuthorizationPolicy,
+	gvk.PeerAuthentication.Kind:    gvk.PeerAuthentication,
+	gvk.RequestAuthentication.Kind: gvk.RequestAuthentication,
+	gvk.Telemetry.Kind:             gvk.Telemetry,
+	gvk.ProxyConfig.Kind:           gvk.ProxyConfig,
+}
+
+// SimulationRequest is the body of the requests of /debug/simulate.
+type SimulationRequest struct {
+	// ProxyID selects a proxy connected to this istiod, as for the other debug endpoints.
+	ProxyID string `json:"proxyID,omitempty"`
+	// Node is the xDS node of the proxy, used instead of ProxyID for proxies connected to another istiod.
+	Node json.RawMessage `json:"node,omitempty"`
+	// Configs are the proposed Istio configs, as YAML documents. They are created, or replace the configs with the
+	// same kind, namespace and name.
+	Configs string `json:"configs,omitempty"`
+	// Deleted are the proposed deletions of Istio configs, as <kind>/<namespace>/<name>.
+	Deleted []string `json:"deleted,omitempty"`
+}
+
+// SimulationConfig is the configuration generated for a proxy, as serialized Envoy protos.
+type SimulationConfig struct {
+	Listeners [][]byte `json:"listeners,omitempty"`
+	Clusters  [][]byte `json:"clusters,omitempty"`
+	Routes    [][]byte `json:"routes,omitempty"`
+}
+
+// SimulationResponse is the response of /debug/simulate: the configuration of the proxy with the current configs and
+// with the proposed changes applied. Clients, such as istioctl x simulate, simulate requests on them.
+type SimulationResponse struct {
+	Proxy    string           `json:"proxy"`
+	Current  SimulationConfig `json:"current"`
+	Proposed SimulationConfig `json:"proposed"`
+}
+
+// Simulate generates the configuration of a proxy before and after proposed config changes. The configuration is
+// generated as for a push, without the XDS cache, and the push context of the proposed changes is discarded
+// afterwards: nothing is pushed or written.
+func (s *DiscoveryServer) Simulate(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		_, _ = w.Write([]byte("POST a simulation request\n"))
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		handleHTTPError(w, err)
+		return
+	}
+	sr := SimulationRequest{}
+	if err := json.Unmarshal(body, &sr); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(fmt.Sprintf("invalid simulation request: %v", err)))
+		return
+	}
+	node, err := s.simulationNode(sr)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	store, err := s.proposedConfigStore(sr)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+
+	current, err := s.simulationConfig(node, s.globalPushContext())
+	if err != nil {
+		handleHTTPError(w, err)
+		return
+	}
+	push, err := s.proposedPushContext(store)
+	if err != nil {
+		handleHTTPError(w, err)
+		return
+	}
+	proposed, err := s.simulationConfig(node, push)
+	if err != nil {
+		handleHTTPError(w, err)
+		return
+	}
+	writeJSON(w, SimulationResponse{
+		Proxy:    node.Id,
+		Current:  current,
+		Proposed: proposed,
+	}, req)
+}
+
+// simulationNode returns the xDS node of the proxy of the simulation request.
+func (s *DiscoveryServer) simulationNode(sr SimulationRequest) (*core.Node, error) {
+	if len(sr.Node) > 0 {
+		node := &core.Node{}
+		if err := protomarshal.Unmarshal(sr.Node, node); err != nil {
+			return nil, fmt.Errorf("invalid xDS node: %v", err)
+		}
+		return node, nil
+	}
+	if sr.ProxyID == "" {
+		return nil, fmt.Errorf("the simulation request must have a proxyID or a node")
+	}
+	con := s.getProxyConnection(sr.ProxyID)
+	if con == nil {
+		return nil, fmt.Errorf("proxy %s is not connected to this istiod, send its node instead", sr.ProxyID)
+	}
+	return con.node, nil
+}
+
+// proposedConfigStore returns the config store of the current configs with the proposed changes of the simulation
+// request applied.
+func (s *DiscoveryServer) proposedConfigStore(sr SimulationRequest) (*proposedConfigStore, error) {
+	store := &proposedConfigStore{ConfigStore: s.Env.ConfigStore, proposed: map[model.ConfigKey]*config.Config{}}
+	configs, _, err := crd.ParseInputs(sr.Configs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proposed configs: %v", err)
+	}
+	now := time.Now()
+	for i := range configs {
+		cfg := configs[i]
+		k, f := simulatedKinds[cfg.GroupVersionKind.Kind]
+		if !f || k.Group != cfg.GroupVersionKind.Group {
+			return nil, fmt.Errorf("changes of %v configs are not supported by the simulation", cfg.GroupVersionKind)
+		}
+		cfg.GroupVersionKind = k
+		if cfg.Namespace == "" {
+			cfg.Namespace = metav1.NamespaceDefault
+		}
+		cfg.Domain = s.Env.DomainSuffix
+		// Replaced configs keep their creation time, which orders the configs merged for the same host.
+		cfg.CreationTimestamp = now
+		if existing := s.Env.ConfigStore.Get(k, cfg.Name, cfg.Namespace); existing != nil {
+			cfg.CreationTimestamp = existing.CreationTimestamp
+		}
+		store.proposed[configKey(cfg.GroupVersionKind, cfg.Namespace, cfg.Name)] = &cfg
+	}
+	for _, d := range sr.Deleted {
+		parts := strings.Split(d, "/")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid deleted config %q, expected <kind>/<namespace>/<name>", d)
+		}
+		k, f := simulatedKinds[parts[0]]
+		if !f {
+			return nil, fmt.Errorf("deletions of %s configs are not supported by the simulation", parts[0])
+		}
+		store.proposed[configKey(k, parts[1], parts[2])] = nil
+	}
+	return store, nil
+}
+
+// proposedPushContext initializes a push context from the proposed config store, sharing everything else with the
+// environment of the server. The Kubernetes Gateway API controller is not reconciled with it, as that would replace
+// the state of the controller used for pushes: the push context reads the configs of its last reconciliation.
+func (s *DiscoveryServer) proposedPushContext(store model.ConfigStore) (*model.PushContext, error) {
+	env := *s.Env
+	env.ConfigStore = store
+	env.GatewayAPIController = nil
+	push := model.NewPushContext()
+	push.PushVersion = "simulation"
+	push.JwtKeyResolver = s.JwtKeyResolver
+	env.PushContext = push
+	if err := push.InitContext(&env, nil, nil); err != nil {
+		return nil, err
+	}
+	push.GatewayAPIController = s.Env.GatewayAPIController
+	return push, nil
+}
+
+// simulationConfig generates the listeners, clusters and routes of the proxy of the node with the push context.
+func (s *DiscoveryServer) simulationConfig(node *core.Node, push *model.PushContext) (SimulationConfig, error) {
+	proxy, err := s.nodeProxy(node, push)
+	if err != nil {
+		return SimulationConfig{}, err
+	}
+	// The XDS cache is keyed by config names, not contents: the configuration of proposed changes must not be cached.
+	cg := v1alpha3.NewConfigGenerator(model.DisabledCache{})
+	pushReq := &model.PushRequest{Push: push, Start: time.Now(), Full: true}
+	out := SimulationConfig{}
+	names := sets.New()
+	for _, l := range cg.BuildListeners(proxy, push) {
+		for _, fc := range l.GetFilterChains() {
+			listenerRouteNames(fc, names)
+		}
+		b, err := proto.Marshal(l)
+		if err != nil {
+			return SimulationConfig{}, err
+		}
+		out.Listeners = append(out.Listeners, b)
+	}
+	clusters, _ := cg.BuildClusters(proxy, pushReq)
+	out.Clusters = resourceBytes(clusters)
+	routes, _ := cg.BuildHTTPRoutes(proxy, pushReq, names.SortedList())
+	out.Routes = resourceBytes(routes)
+	return out, nil
+}
+
+// resourceBytes returns the serialized protos of the generated resources.
+func resourceBytes(resources model.Resources) [][]byte {
+	out := make([][]byte, 0, len(resources))
+	for _, r := range resources {
+		out = append(out, r.GetResource().GetValue())
+	}
+	return out
+}
+
+// proposedConfigStore is a read only config store returning the configs of a config store with proposed changes.
+type proposedConfigStore struct {
+	model.ConfigStore
+	// proposed are the proposed configs by key. Deleted configs are nil.
+	proposed map[model.ConfigKey]*config.Config
+}
+
+func configKey(k config.GroupVersionKind, namespace, name string) model.ConfigKey {
+	return model.ConfigKey{Kind: kind.FromGvk(k), Name: name, Namespace: namespace}
+}
+
+func (p *proposedConfigStore) Get(typ config.GroupVersionKind, name, namespace string) *config.Config {
+	if cfg, f := p.proposed[configKey(typ, namespace, name)]; f {
+		return cfg
+	}
+	return p.ConfigStore.Get(typ, name, namespace)
+}
+
+func (p *proposedConfigStore) List(typ config.GroupVersionKind, namespace string) ([]config.Config, error) {
+	configs, err := p.ConfigStore.List(typ, namespace)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]config.Config, 0, len(configs))
+	for _, cfg := range configs {
+		if _, f := p.proposed[configKey(typ, cfg.Namespace, cfg.Name)]; !f {
+			out = append(out, cfg)
+		}
+	}
+	keys := make([]model.ConfigKey, 0, len(p.proposed))
+	for k, cfg := range p.proposed {
+		if cfg != nil && cfg.GroupVersionKind == typ && (namespace == model.NamespaceAll || k.Namespace == namespace) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	for _, k := range keys {
+		out = append(out, *p.proposed[k])
+	}
+	return out, nil
+}
+
+var errReadOnlySimulation = errors.New("unsupported operation: the simulation config store is read only")
+
+func (p *proposedConfigStore) Create(config.Config) (string, error) {
+	return "", errReadOnlySimulation
+}
+
+func (p *proposedConfigStore) Update(config.Config) (string, error) {
+	return "", errReadOnlySimulation
+}
+
+func (p *proposedConfigStore) UpdateStatus(config.Config) (string, error) {
+	return "", errReadOnlySimulation
+}
+
+func (p *proposedConfigStore) Patch(config.Config, config.PatchFunc) (string, error) {
+	return "", errReadOnlySimulation
+}
+
+func (p *proposedConfigStore) Delete(config.GroupVersionKind, string, string, *string) error {
+	return errReadOnlySimulation
+}
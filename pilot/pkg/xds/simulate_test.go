@@ -0,0 +1,193 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"google.golang.org/protobuf/proto"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+const simulationServiceEntries = `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: example
+  namespace: default
+spec:
+  hosts:
+  - example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: canary
+  namespace: default
+spec:
+  hosts:
+  - canary.example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+`
+
+const simulationCanary = `
+apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: example
+  namespace: default
+spec:
+  hosts:
+  - example.com
+  http:
+  - route:
+    - destination:
+        host: canary.example.com
+`
+
+func simulationNode(t *testing.T) json.RawMessage {
+	node, err := protomarshal.Marshal(&core.Node{
+		Id:       "sidecar~1.1.1.1~test.default~default.svc.cluster.local",
+		Metadata: model.NodeMetadata{Namespace: "default"}.ToStruct(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
+
+func simulate(t *testing.T, s *xds.FakeDiscoveryServer, sr xds.SimulationRequest) (int, xds.SimulationResponse) {
+	body, err := json.Marshal(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, "/debug/simulate", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(s.Discovery.Simulate).ServeHTTP(rr, req)
+	got := xds.SimulationResponse{}
+	if rr.Code == http.StatusOK {
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rr.Code, got
+}
+
+// simulatedRouteCluster returns the cluster of the route of example.com:80 in the simulated configuration.
+func simulatedRouteCluster(t *testing.T, cfg xds.SimulationConfig) string {
+	for _, b := range cfg.Routes {
+		rc := &route.RouteConfiguration{}
+		if err := proto.Unmarshal(b, rc); err != nil {
+			t.Fatal(err)
+		}
+		for _, vh := range rc.GetVirtualHosts() {
+			if vh.GetName() == "example.com:80" {
+				return vh.GetRoutes()[0].GetRoute().GetCluster()
+			}
+		}
+	}
+	t.Fatal("no route for example.com:80")
+	return ""
+}
+
+func TestSimulate(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{ConfigString: simulationServiceEntries})
+	node := simulationNode(t)
+	for _, tt := range []struct {
+		name            string
+		request         xds.SimulationRequest
+		wantCode        int
+		currentCluster  string
+		proposedCluster string
+	}{
+		{
+			name:            "proposed virtual service changes the cluster",
+			request:         xds.SimulationRequest{Node: node, Configs: simulationCanary},
+			wantCode:        http.StatusOK,
+			currentCluster:  "outbound|80||example.com",
+			proposedCluster: "outbound|80||canary.example.com",
+		},
+		{
+			name:            "no proposed changes",
+			request:         xds.SimulationRequest{Node: node},
+			wantCode:        http.StatusOK,
+			currentCluster:  "outbound|80||example.com",
+			proposedCluster: "outbound|80||example.com",
+		},
+		{
+			name:     "proposed service entries are not supported",
+			request:  xds.SimulationRequest{Node: node, Configs: simulationServiceEntries},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "deletions must name the kind, namespace and name",
+			request:  xds.SimulationRequest{Node: node, Deleted: []string{"VirtualService/example"}},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "proxy not connected",
+			request:  xds.SimulationRequest{ProxyID: "test.default"},
+			wantCode: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			code, got := simulate(t, s, tt.request)
+			if code != tt.wantCode {
+				t.Fatalf("wanted response code %v, got %v", tt.wantCode, code)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			assert.Equal(t, got.Proxy, "sidecar~1.1.1.1~test.default~default.svc.cluster.local")
+			assert.Equal(t, len(got.Current.Listeners) > 0, true)
+			assert.Equal(t, len(got.Current.Clusters) > 0, true)
+			assert.Equal(t, simulatedRouteCluster(t, got.Current), tt.currentCluster)
+			assert.Equal(t, simulatedRouteCluster(t, got.Proposed), tt.proposedCluster)
+		})
+	}
+}
+
+func TestSimulateDeletion(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{ConfigString: simulationServiceEntries + "---" + simulationCanary})
+	code, got := simulate(t, s, xds.SimulationRequest{
+		Node:    simulationNode(t),
+		Deleted: []string{"VirtualService/default/example"},
+	})
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, simulatedRouteCluster(t, got.Current), "outbound|80||canary.example.com")
+	assert.Equal(t, simulatedRouteCluster(t, got.Proposed), "outbound|80||example.com")
+}
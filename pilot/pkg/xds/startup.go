@@ -0,0 +1,117 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/cluster"
+)
+
+const (
+	// ProxyStartedReason is the reason of the event recorded when a proxy reports its startup.
+	ProxyStartedReason = "ProxyStarted"
+	// SlowProxyStartupReason is the reason of the event recorded when a startup phase of a proxy
+	// took longer than the slow startup threshold.
+	SlowProxyStartupReason = "SlowProxyStartup"
+)
+
+// handleStartupReport handles the startup phases reported by a proxy, formatted as "phase=duration". Only proxies
+// with a verified identity are recorded, since the report names the pod it is recorded on.
+func (s *DiscoveryServer) handleStartupReport(proxy *model.Proxy, phases []string) {
+	log.Debugf("ADS: startup report from %s: %v", proxy.ID, phases)
+	if proxy.VerifiedIdentity == nil {
+		log.Warnf("ignoring startup report of proxy %s, its identity is not verified", proxy.ID)
+		return
+	}
+	if s.RecordStartupReport != nil {
+		s.RecordStartupReport(proxy, phases)
+	}
+}
+
+// NewStartupEventRecorder returns a function recording the startup phases reported by proxies of the cluster
+// as events on their pods. The event is a warning if any phase took at least the threshold. The pod named by the
+// proxy ID must run in the namespace and with the service account of the verified identity of the proxy.
+func NewStartupEventRecorder(client kubernetes.Interface, clusterID cluster.ID, threshold time.Duration) func(*model.Proxy, []string) {
+	return func(proxy *model.Proxy, phases []string) {
+		identity := proxy.VerifiedIdentity
+		if identity == nil || proxy.Metadata == nil || proxy.Metadata.ClusterID != clusterID {
+			return
+		}
+		name := strings.TrimSuffix(proxy.ID, "."+identity.Namespace)
+		event := startupEvent(name, identity.Namespace, phases, threshold)
+		go func() {
+			pod, err := client.CoreV1().Pods(identity.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+			if err != nil {
+				log.Warnf("failed to get pod of proxy %s to record startup event: %v", proxy.ID, err)
+				return
+			}
+			if pod.Spec.ServiceAccountName != identity.ServiceAccount {
+				log.Warnf("ignoring startup report of proxy %s, pod %s/%s does not run as %s", proxy.ID, pod.Namespace, pod.Name,
+					identity.String())
+				return
+			}
+			event.InvolvedObject.UID = pod.UID
+			if _, err := client.CoreV1().Events(event.Namespace).Create(context.TODO(), event, metav1.CreateOptions{}); err != nil {
+				log.Warnf("failed to record startup event for %s: %v", proxy.ID, err)
+			}
+		}()
+	}
+}
+
+func startupEvent(name, namespace string, phases []string, threshold time.Duration) *corev1.Event {
+	var slowest time.Duration
+	for _, phase := range phases {
+		_, value, ok := strings.Cut(phase, "=")
+		if !ok {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err == nil && d > slowest {
+			slowest = d
+		}
+	}
+	eventType, reason := corev1.EventTypeNormal, ProxyStartedReason
+	if threshold > 0 && slowest >= threshold {
+		eventType, reason = corev1.EventTypeWarning, SlowProxyStartupReason
+	}
+	now := metav1.Now()
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: name + ".",
+			Namespace:    namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Pod",
+			Name:       name,
+			Namespace:  namespace,
+		},
+		Reason:         reason,
+		Message:        fmt.Sprintf("Proxy started: %s", strings.Join(phases, ", ")),
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: "istiod"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+}
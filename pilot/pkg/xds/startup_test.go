@@ -0,0 +1,112 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+func TestStartupEventRecorder(t *testing.T) {
+	cases := []struct {
+		name      string
+		phases    []string
+		eventType string
+		reason    string
+	}{
+		{
+			name:      "fast",
+			phases:    []string{"certificate=1s", "xdsAck=2s", "envoyReady=3s"},
+			eventType: corev1.EventTypeNormal,
+			reason:    ProxyStartedReason,
+		},
+		{
+			name:      "slow",
+			phases:    []string{"certificate=1s", "xdsAck=45s", "envoyReady=46s"},
+			eventType: corev1.EventTypeWarning,
+			reason:    SlowProxyStartupReason,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "app-1", Namespace: "default", UID: "app-1-uid"},
+					Spec:       corev1.PodSpec{ServiceAccountName: "app"},
+				},
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "other-1", Namespace: "default"},
+					Spec:       corev1.PodSpec{ServiceAccountName: "other"},
+				},
+			)
+			record := NewStartupEventRecorder(client, "Kubernetes", 30*time.Second)
+			identity := &spiffe.Identity{TrustDomain: "cluster.local", Namespace: "default", ServiceAccount: "app"}
+			// Proxies of other clusters are ignored.
+			record(&model.Proxy{
+				ID:               "remote.default",
+				ConfigNamespace:  "default",
+				Metadata:         &model.NodeMetadata{ClusterID: "remote"},
+				VerifiedIdentity: identity,
+			}, tt.phases)
+			// Proxies cannot report the startup of pods running with another identity.
+			record(&model.Proxy{
+				ID:               "other-1.default",
+				ConfigNamespace:  "default",
+				Metadata:         &model.NodeMetadata{ClusterID: "Kubernetes"},
+				VerifiedIdentity: identity,
+			}, tt.phases)
+			record(&model.Proxy{
+				ID:               "app-1.default",
+				ConfigNamespace:  "default",
+				Metadata:         &model.NodeMetadata{ClusterID: "Kubernetes"},
+				VerifiedIdentity: identity,
+			}, tt.phases)
+
+			var events []corev1.Event
+			retry.UntilSuccessOrFail(t, func() error {
+				list, err := client.CoreV1().Events("default").List(context.TODO(), metav1.ListOptions{})
+				if err != nil {
+					return err
+				}
+				if len(list.Items) == 0 {
+					return fmt.Errorf("no events recorded")
+				}
+				events = list.Items
+				return nil
+			}, retry.Timeout(time.Second*5))
+			if len(events) != 1 {
+				t.Fatalf("expected 1 event, got %d", len(events))
+			}
+			event := events[0]
+			if event.InvolvedObject.Kind != "Pod" || event.InvolvedObject.Name != "app-1" || event.InvolvedObject.Namespace != "default" ||
+				event.InvolvedObject.UID != "app-1-uid" {
+				t.Errorf("unexpected involved object %+v", event.InvolvedObject)
+			}
+			if event.Type != tt.eventType || event.Reason != tt.reason {
+				t.Errorf("got %s/%s, want %s/%s", event.Type, event.Reason, tt.eventType, tt.reason)
+			}
+		})
+	}
+}
@@ -16,12 +16,15 @@ package xds
 
 import (
 	"fmt"
+	"strings"
 
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	status "github.com/envoyproxy/go-control-plane/envoy/service/status/v3"
 	"google.golang.org/protobuf/proto"
 	anypb "google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/util/protoconv"
@@ -129,6 +132,10 @@ func (sg *StatusGen) debugSyncz() model.Resources {
 				}
 
 				pxc.TypeUrl = stype
+				if nacks := sg.Server.nacks.get(con.conID, stype); len(nacks) > 0 {
+					pxc.ClientStatus = admin.ClientResourceStatus_NACKED
+					pxc.ErrorState = nackErrorState(nacks)
+				}
 
 				xdsConfigs = append(xdsConfigs, pxc)
 			}
@@ -150,6 +157,20 @@ func (sg *StatusGen) debugSyncz() model.Resources {
 	return res
 }
 
+// nackErrorState returns the failure state of the configuration of a type rejected by a proxy, detailing the
+// diagnostics of each rejected resource.
+func nackErrorState(nacks []NackDiagnostic) *admin.UpdateFailureState {
+	details := make([]string, 0, len(nacks))
+	for _, n := range nacks {
+		details = append(details, n.String())
+	}
+	return &admin.UpdateFailureState{
+		LastUpdateAttempt: timestamppb.New(nacks[0].Time),
+		Details:           strings.Join(details, "\n"),
+		VersionInfo:       nacks[0].Version,
+	}
+}
+
 func debugSyncStatus(wr *model.WatchedResource) status.ConfigStatus {
 	if wr.NonceSent == "" {
 		return status.ConfigStatus_NOT_SENT
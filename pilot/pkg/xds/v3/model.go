@@ -33,6 +33,8 @@ const (
 	NameTableType   = resource.APITypePrefix + "istio.networking.nds.v1.NameTable"
 	HealthInfoType  = resource.APITypePrefix + "istio.v1.HealthInformation"
 	ProxyConfigType = resource.APITypePrefix + "istio.mesh.v1alpha1.ProxyConfig"
+	// StartupReportType reports the startup phases of a proxy, as <phase>=<elapsed> resource names.
+	StartupReportType = resource.APITypePrefix + "istio.v1.StartupReport"
 	// DebugType requests debug info from istio, a secured implementation for istio debug interface.
 	DebugType     = "istio.io/debug"
 	BootstrapType = resource.APITypePrefix + "envoy.config.bootstrap.v3.Bootstrap"
@@ -26,6 +26,7 @@ import (
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/pkg/env"
 	istioversion "istio.io/pkg/version"
@@ -39,23 +40,53 @@ type IstioControlPlaneInstance struct {
 	ID string
 	// The Istio version
 	Info istioversion.BuildInfo
+	// The clusters whose registry serves its last-known state, because their API server has been unreachable for
+	// longer than PILOT_API_SERVER_STALENESS_THRESHOLD. The configuration pushed may be out of date for these clusters.
+	StaleClusters []string `json:",omitempty"`
 }
 
-var controlPlane *corev3.ControlPlane
+var (
+	controlPlaneInstance IstioControlPlaneInstance
+	controlPlane         *corev3.ControlPlane
+)
 
 // ControlPlane identifies the instance and Istio version.
 func ControlPlane() *corev3.ControlPlane {
 	return controlPlane
 }
 
+// controlPlane identifies the instance and Istio version, and the clusters whose registry is stale, if any.
+func (s *DiscoveryServer) controlPlane() *corev3.ControlPlane {
+	agg, ok := s.Env.ServiceDiscovery.(*aggregate.Controller)
+	if !ok {
+		return controlPlane
+	}
+	return controlPlaneWithStaleClusters(agg.StaleClusters())
+}
+
+func controlPlaneWithStaleClusters(stale []string) *corev3.ControlPlane {
+	if len(stale) == 0 {
+		return controlPlane
+	}
+	instance := controlPlaneInstance
+	instance.StaleClusters = stale
+	byVersion, err := json.Marshal(instance)
+	if err != nil {
+		log.Warnf("XDS: Could not serialize control plane id: %v", err)
+		return controlPlane
+	}
+	return &corev3.ControlPlane{Identifier: string(byVersion)}
+}
+
 func init() {
 	// The Pod Name (instance identity) is in PilotArgs, but not reachable globally nor from DiscoveryServer
 	podName := env.RegisterStringVar("POD_NAME", "", "").Get()
-	byVersion, err := json.Marshal(IstioControlPlaneInstance{
+	controlPlaneInstance = IstioControlPlaneInstance{
 		Component: "istiod",
 		ID:        podName,
 		Info:      istioversion.Info,
-	})
+	}
+	byVersion, err := json.Marshal(controlPlaneInstance)
 	if err != nil {
 		log.Warnf("XDS: Could not serialize control plane id: %v", err)
 	}
@@ -141,7 +172,7 @@ func (s *DiscoveryServer) pushXds(con *Connection, w *model.WatchedResource, req
 	defer func() { recordPushTime(w.TypeUrl, time.Since(t0)) }()
 
 	resp := &discovery.DiscoveryResponse{
-		ControlPlane: ControlPlane(),
+		ControlPlane: s.controlPlane(),
 		TypeUrl:      w.TypeUrl,
 		// TODO: send different version for incremental eds
 		VersionInfo: req.Push.PushVersion,
@@ -151,6 +182,7 @@ func (s *DiscoveryServer) pushXds(con *Connection, w *model.WatchedResource, req
 
 	configSize := ResourceSize(res)
 	configSizeBytes.With(typeTag.Value(w.TypeUrl)).Record(float64(configSize))
+	s.pushCost.record(req, w.TypeUrl, time.Since(t0), configSize)
 
 	ptype := "PUSH"
 	if logdata.Incremental {
@@ -0,0 +1,33 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"encoding/json"
+	"testing"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestControlPlaneStaleClusters(t *testing.T) {
+	assert.Equal(t, controlPlaneWithStaleClusters(nil), controlPlane)
+
+	cp := IstioControlPlaneInstance{}
+	if err := json.Unmarshal([]byte(controlPlaneWithStaleClusters([]string{"cluster-a", "cluster-b"}).Identifier), &cp); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, cp.Component, "istiod")
+	assert.Equal(t, cp.StaleClusters, []string{"cluster-a", "cluster-b"})
+}
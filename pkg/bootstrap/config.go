@@ -269,7 +269,8 @@ func getNodeMetadataOptions(node *model.Node) []option.Instance {
 		option.NodeMetadata(node.Metadata, node.RawMetadata),
 		option.RuntimeFlags(extractRuntimeFlags(node.Metadata.ProxyConfig)),
 		option.EnvoyStatusPort(node.Metadata.EnvoyStatusPort),
-		option.EnvoyPrometheusPort(node.Metadata.EnvoyPrometheusPort))
+		option.EnvoyPrometheusPort(node.Metadata.EnvoyPrometheusPort),
+		option.DrainHealthCheckPath(node.Metadata.DrainHealthCheckPath))
 	return opts
 }
 
@@ -514,6 +515,7 @@ type MetadataOptions struct {
 	EnvoyStatusPort             int
 	EnvoyPrometheusPort         int
 	ExitOnZeroActiveConnections bool
+	DrainHealthCheckPath        string
 }
 
 // GetNodeMetaData function uses an environment variable contract
@@ -554,6 +556,7 @@ func GetNodeMetaData(options MetadataOptions) (*model.Node, error) {
 	meta.EnvoyStatusPort = options.EnvoyStatusPort
 	meta.EnvoyPrometheusPort = options.EnvoyPrometheusPort
 	meta.ExitOnZeroActiveConnections = model.StringBool(options.ExitOnZeroActiveConnections)
+	meta.DrainHealthCheckPath = options.DrainHealthCheckPath
 
 	meta.ProxyConfig = (*model.NodeMetaProxyConfig)(options.ProxyConfig)
 
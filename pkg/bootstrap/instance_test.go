@@ -83,6 +83,7 @@ func TestGolden(t *testing.T) {
 		stats                      stats
 		checkLocality              bool
 		stsPort                    int
+		drainHealthCheckPath       string
 		platformMeta               map[string]string
 		setup                      func()
 		teardown                   func()
@@ -102,6 +103,10 @@ func TestGolden(t *testing.T) {
 		{
 			base: "default",
 		},
+		{
+			base:                 "drain_health_check",
+			drainHealthCheckPath: "/healthz/drain",
+		},
 		{
 			base: "running",
 			envVars: map[string]string{
@@ -318,10 +323,11 @@ func TestGolden(t *testing.T) {
 				PilotSubjectAltName: []string{
 					"spiffe://cluster.local/ns/istio-system/sa/istio-pilot-service-account",
 				},
-				OutlierLogPath:      "/dev/stdout",
-				annotationFilePath:  annoFile.Name(),
-				EnvoyPrometheusPort: 15090,
-				EnvoyStatusPort:     15021,
+				OutlierLogPath:       "/dev/stdout",
+				annotationFilePath:   annoFile.Name(),
+				EnvoyPrometheusPort:  15090,
+				EnvoyStatusPort:      15021,
+				DrainHealthCheckPath: c.drainHealthCheckPath,
 			})
 			if err != nil {
 				t.Fatal(err)
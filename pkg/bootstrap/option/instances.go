@@ -224,6 +224,10 @@ func EnvoyPrometheusPort(value int) Instance {
 	return newOption("envoy_prometheus_port", value)
 }
 
+func DrainHealthCheckPath(value string) Instance {
+	return newOptionOrSkipIfZero("drain_health_check_path", value)
+}
+
 func STSPort(value int) Instance {
 	return newOption("sts_port", value)
 }
@@ -51,18 +51,25 @@ func All() []analysis.Analyzer {
 		&injection.ImageAutoAnalyzer{},
 		&multicluster.MeshNetworksAnalyzer{},
 		&service.PortNameAnalyzer{},
+		&service.ExternalNameAnalyzer{},
+		&service.IPFamilyAnalyzer{},
 		&sidecar.DefaultSelectorAnalyzer{},
 		&sidecar.SelectorAnalyzer{},
 		&virtualservice.ConflictingMeshGatewayHostsAnalyzer{},
+		&virtualservice.RoutePriorityAnalyzer{},
 		&virtualservice.DestinationHostAnalyzer{},
 		&virtualservice.DestinationRuleAnalyzer{},
 		&virtualservice.GatewayAnalyzer{},
 		&virtualservice.JWTClaimRouteAnalyzer{},
+		&virtualservice.ProxyRoutesAnalyzer{},
 		&virtualservice.RegexAnalyzer{},
 		&destinationrule.CaCertificateAnalyzer{},
+		&destinationrule.ConflictingTLSModeAnalyzer{},
 		&serviceentry.ProtocolAddressesAnalyzer{},
+		&serviceentry.WorkloadSelectorAnalyzer{},
 		&webhook.Analyzer{},
 		&envoyfilter.EnvoyPatchAnalyzer{},
+		&envoyfilter.CompatibilityAnalyzer{},
 	}
 
 	analyzers = append(analyzers, schema.AllValidationAnalyzers()...)
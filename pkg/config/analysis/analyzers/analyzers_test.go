@@ -24,6 +24,8 @@ import (
 
 	. "github.com/onsi/gomega"
 
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/istioctl/pkg/util/configdump"
 	"istio.io/istio/pkg/config/analysis"
 	"istio.io/istio/pkg/config/analysis/analyzers/annotations"
 	"istio.io/istio/pkg/config/analysis/analyzers/authz"
@@ -44,6 +46,7 @@ import (
 	"istio.io/istio/pkg/config/analysis/diag"
 	"istio.io/istio/pkg/config/analysis/local"
 	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema/collection"
 	"istio.io/istio/pkg/config/schema/collections"
 	"istio.io/istio/pkg/util/sets"
@@ -57,8 +60,9 @@ type message struct {
 type testCase struct {
 	name             string
 	inputFiles       []string
-	meshConfigFile   string // Optional
-	meshNetworksFile string // Optional
+	meshConfigFile   string            // Optional
+	meshNetworksFile string            // Optional
+	configDumpFiles  map[string]string // Optional, the Envoy config dump files of proxies, by <pod name>.<namespace>
 	analyzer         analysis.Analyzer
 	expected         []message
 	skipAll          bool
@@ -79,6 +83,7 @@ var testGrid = []testCase{
 		expected: []message{
 			{msg.UnknownAnnotation, "Service httpbin"},
 			{msg.InvalidAnnotation, "Pod invalid-annotations"},
+			{msg.InvalidAnnotation, "Pod invalid-endpoint-weight"},
 			{msg.MisplacedAnnotation, "Pod grafana-test"},
 			{msg.MisplacedAnnotation, "Deployment fortio-deploy"},
 			{msg.MisplacedAnnotation, "Namespace staging"},
@@ -255,6 +260,27 @@ var testGrid = []testCase{
 		analyzer:   &service.PortNameAnalyzer{},
 		expected:   []message{},
 	},
+	{
+		name:       "externalNameServiceChain",
+		inputFiles: []string{"testdata/service-external-name-chain.yaml"},
+		analyzer:   &service.ExternalNameAnalyzer{},
+		expected: []message{
+			{msg.ExternalNameServiceChain, "Service chain/frontend"},
+			{msg.ExternalNameServiceLoop, "Service loop/ping"},
+			{msg.ExternalNameServiceLoop, "Service loop/pong"},
+		},
+	},
+	{
+		name:       "serviceIPFamily",
+		inputFiles: []string{"testdata/service-ip-family.yaml"},
+		analyzer:   &service.IPFamilyAnalyzer{},
+		expected: []message{
+			{msg.ServiceIPFamilyMismatch, "Service default/single-family"},
+			{msg.ServiceIPFamilyMismatch, "Service default/swapped"},
+			{msg.ServiceIPFamilyMismatch, "Service default/swapped"},
+			{msg.ServiceIPFamilyMismatch, "Service default/ipv6-only"},
+		},
+	},
 	{
 		name:       "sidecarDefaultSelector",
 		inputFiles: []string{"testdata/sidecar-default-selector.yaml"},
@@ -277,6 +303,16 @@ var testGrid = []testCase{
 			{msg.ConflictingSidecarWorkloadSelectors, "Sidecar default/overlap-2"},
 		},
 	},
+	{
+		name:       "virtualServiceRoutePriority",
+		inputFiles: []string{"testdata/virtualservice_routepriority.yaml"},
+		analyzer:   &virtualservice.RoutePriorityAnalyzer{},
+		expected: []message{
+			{msg.VirtualServiceRoutePriorityTie, "VirtualService foo/api-v1"},
+			{msg.VirtualServiceRoutePriorityTie, "VirtualService foo/api-v2"},
+			{msg.InvalidAnnotation, "VirtualService foo/invalid"},
+		},
+	},
 	{
 		name:       "virtualServiceConflictingMeshGatewayHosts",
 		inputFiles: []string{"testdata/virtualservice_conflictingmeshgatewayhosts.yaml"},
@@ -327,6 +363,19 @@ var testGrid = []testCase{
 			{msg.ReferencedResourceNotFound, "VirtualService default/reviews-mirror-bogussubset"},
 		},
 	},
+	{
+		name:       "virtualServiceDestinationRulesOfRemoteClusters",
+		inputFiles: []string{"testdata/virtualservice_destinationrules.yaml"},
+		analyzer: &virtualservice.DestinationRuleAnalyzer{RemoteDestinationRules: []*resource.Instance{{
+			Metadata: resource.Metadata{FullName: resource.NewFullName("default", "reviews-bogus")},
+			Message: &v1alpha3.DestinationRule{
+				Host:    "reviews.default.svc.cluster.local",
+				Subsets: []*v1alpha3.Subset{{Name: "bogus"}},
+			},
+		}}},
+		// The subset is defined in a remote cluster
+		expected: []message{},
+	},
 	{
 		name:       "virtualServiceGateways",
 		inputFiles: []string{"testdata/virtualservice_gateways.yaml"},
@@ -339,6 +388,17 @@ var testGrid = []testCase{
 			{msg.VirtualServiceHostNotFoundInGateway, "VirtualService httpbin"},
 		},
 	},
+	{
+		name:       "virtualServiceProxyRoutes",
+		inputFiles: []string{"testdata/virtualservice_proxyroutes.yaml"},
+		configDumpFiles: map[string]string{
+			"productpage-v1-6b746f74dc-9stvs.default": "testdata/virtualservice_proxyroutes_configdump.json",
+		},
+		analyzer: &virtualservice.ProxyRoutesAnalyzer{},
+		expected: []message{
+			{msg.VirtualServiceRoutesNotInProxy, "VirtualService default/reviews"},
+		},
+	},
 	{
 		name:       "virtualServiceJWTClaimRoute",
 		inputFiles: []string{"testdata/virtualservice_jwtclaimroute.yaml"},
@@ -410,6 +470,17 @@ var testGrid = []testCase{
 			{msg.ReferencedResourceNotFound, "AuthorizationPolicy httpbin/httpbin-bogus-not-ns"},
 		},
 	},
+	{
+		name: "destinationrule with conflicting tls modes across namespaces",
+		inputFiles: []string{
+			"testdata/destinationrule-conflicting-tls.yaml",
+		},
+		analyzer: &destinationrule.ConflictingTLSModeAnalyzer{},
+		expected: []message{
+			{msg.ConflictingDestinationRuleTLSModes, "DestinationRule bookinfo/reviews"},
+			{msg.ConflictingDestinationRuleTLSModes, "DestinationRule frontend/reviews-plaintext"},
+		},
+	},
 	{
 		name: "destinationrule with no cacert, simple at destinationlevel",
 		inputFiles: []string{
@@ -575,6 +646,17 @@ var testGrid = []testCase{
 		analyzer:       &serviceentry.ProtocolAddressesAnalyzer{},
 		expected:       []message{},
 	},
+	{
+		name: "ServiceEntry workload selector matching other namespaces",
+		inputFiles: []string{
+			"testdata/serviceentry-workload-selector-namespaces.yaml",
+		},
+		analyzer: &serviceentry.WorkloadSelectorAnalyzer{},
+		expected: []message{
+			{msg.ServiceEntryWorkloadSelectorMatchesOtherNamespace, "ServiceEntry infra/shared-all"},
+			{msg.ServiceEntryWorkloadSelectorMatchesOtherNamespace, "ServiceEntry infra/shared-all-listed"},
+		},
+	},
 	{
 		name: "certificate duplication in Gateway",
 		inputFiles: []string{
@@ -728,6 +810,18 @@ var testGrid = []testCase{
 			{msg.EnvoyFilterUsesRelativeOperation, "EnvoyFilter bookinfo/test-remove-5"},
 		},
 	},
+	{
+		name:       "EnvoyFilterCompatibility",
+		inputFiles: []string{"testdata/envoy-filter-compatibility.yaml"},
+		analyzer:   &envoyfilter.CompatibilityAnalyzer{},
+		expected: []message{
+			{msg.EnvoyFilterIncompatibleWithProxyVersion, "EnvoyFilter bookinfo/reviews-v2-type"},
+			{msg.EnvoyFilterUsesDeprecatedConfig, "EnvoyFilter bookinfo/legacy-v2-type"},
+			{msg.EnvoyFilterUsesDeprecatedConfig, "EnvoyFilter bookinfo/cluster-hosts"},
+			{msg.EnvoyFilterUsesDeprecatedConfig, "EnvoyFilter istio-system/deprecated-router-name"},
+			{msg.EnvoyFilterUsesDeprecatedConfig, "EnvoyFilter other/unknown-proxies"},
+		},
+	},
 	{
 		name:       "Analyze conflicting gateway with list type",
 		inputFiles: []string{"testdata/analyze-list-type.yaml"},
@@ -895,6 +989,24 @@ func setupAnalyzerForCase(tc testCase, cr local.CollectionReporterFn) (*local.Is
 		return nil, fmt.Errorf("error setting up file kube source on testcase %s: %v", tc.name, err)
 	}
 
+	if len(tc.configDumpFiles) > 0 {
+		var dumps []local.ProxyConfigDump
+		for proxy, f := range tc.configDumpFiles {
+			b, err := os.ReadFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("error reading config dump file: %q", f)
+			}
+			w := &configdump.Wrapper{}
+			if err := w.UnmarshalJSON(b); err != nil {
+				return nil, fmt.Errorf("error parsing config dump file %q: %v", f, err)
+			}
+			dumps = append(dumps, local.ProxyConfigDump{Proxy: proxy, ConfigDump: w.ConfigDump})
+		}
+		if err := sa.AddConfigDumpSource(dumps); err != nil {
+			return nil, fmt.Errorf("error setting up config dump source on testcase %s: %v", tc.name, err)
+		}
+	}
+
 	return sa, nil
 }
 
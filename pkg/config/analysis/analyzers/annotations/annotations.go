@@ -18,6 +18,7 @@ import (
 	"strings"
 
 	"istio.io/api/annotation"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube"
 	"istio.io/istio/pkg/config/analysis"
 	"istio.io/istio/pkg/config/analysis/analyzers/util"
 	"istio.io/istio/pkg/config/analysis/msg"
@@ -66,6 +67,14 @@ func (fa *K8sAnalyzer) Analyze(ctx analysis.Context) {
 	})
 }
 
+// podAnnotationValidation validates the Istio annotations of pods that are not defined by the API.
+var podAnnotationValidation = map[string]func(value string) error{
+	kube.EndpointWeightAnnotation: func(value string) error {
+		_, err := kube.ParseEndpointWeight(value)
+		return err
+	},
+}
+
 var deprecationExtraMessages = map[string]string{
 	annotation.SidecarInject.Name: ` in favor of the "sidecar.istio.io/inject" label`,
 }
@@ -82,6 +91,16 @@ outer:
 			continue
 		}
 
+		if validate, f := podAnnotationValidation[ann]; f && kind == "Pod" {
+			if err := validate(value); err != nil {
+				m := msg.NewInvalidAnnotation(r, ann, err.Error())
+				util.AddLineNumber(r, ann, m)
+
+				ctx.Report(collectionType, m)
+			}
+			continue
+		}
+
 		annotationDef := lookupAnnotation(ann)
 		if annotationDef == nil {
 			m := msg.NewUnknownAnnotation(r, ann)
@@ -121,6 +140,9 @@ outer:
 		}
 
 		validationFunction := inject.AnnotationValidation[ann]
+		if validationFunction == nil {
+			validationFunction = inject.AnnotationWarningValidation[ann]
+		}
 		if validationFunction != nil {
 			if err := validationFunction(value); err != nil {
 				m := msg.NewInvalidAnnotation(r, ann, err.Error())
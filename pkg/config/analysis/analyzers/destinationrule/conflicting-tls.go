@@ -0,0 +1,208 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destinationrule
+
+import (
+	"sort"
+	"strings"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ConflictingTLSModeAnalyzer checks for destination rules of different namespaces setting different TLS modes for the
+// same host. A proxy uses the destination rules of its own namespace first, so the TLS mode of the traffic to the
+// host then depends on the namespace of the client.
+type ConflictingTLSModeAnalyzer struct{}
+
+var _ analysis.Analyzer = &ConflictingTLSModeAnalyzer{}
+
+// Metadata implements Analyzer
+func (c *ConflictingTLSModeAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "destinationrule.ConflictingTLSModeAnalyzer",
+		Description: "Checks for destination rules of different namespaces setting different TLS modes for the same host",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (c *ConflictingTLSModeAnalyzer) Analyze(ctx analysis.Context) {
+	rootNamespace := constants.IstioSystemNamespace
+	ctx.ForEach(collections.IstioMeshV1Alpha1MeshConfig.Name(), func(r *resource.Instance) bool {
+		if ns := r.Message.(*meshconfig.MeshConfig).GetRootNamespace(); ns != "" {
+			rootNamespace = ns
+		}
+		return r.Metadata.FullName.Name != util.MeshConfigName
+	})
+
+	// hosts maps the FQDN of a host to the destination rules of the host.
+	hosts := map[string][]*resource.Instance{}
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(r *resource.Instance) bool {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+		// Destination rules with a workload selector only apply to the workloads of their namespace.
+		if dr.GetHost() == "" || dr.GetWorkloadSelector() != nil {
+			return true
+		}
+		fqdn := util.ConvertHostToFQDN(r.Metadata.FullName.Namespace, dr.GetHost())
+		hosts[fqdn] = append(hosts[fqdn], r)
+		return true
+	})
+
+	fqdns := make([]string, 0, len(hosts))
+	for fqdn := range hosts {
+		fqdns = append(fqdns, fqdn)
+	}
+	sort.Strings(fqdns)
+	for _, fqdn := range fqdns {
+		drs := hosts[fqdn]
+		// As istiod, merge the destination rules of a namespace in creation order.
+		sort.SliceStable(drs, func(i, j int) bool {
+			if !drs[i].Metadata.CreateTime.Equal(drs[j].Metadata.CreateTime) {
+				return drs[i].Metadata.CreateTime.Before(drs[j].Metadata.CreateTime)
+			}
+			return drs[i].Metadata.FullName.String() < drs[j].Metadata.FullName.String()
+		})
+		h := tlsHost{fqdn: fqdn, rootNamespace: rootNamespace, drs: drs}
+		for _, r := range drs {
+			mode, f := tlsMode(r)
+			if !f {
+				continue
+			}
+			var conflicting []string
+			for _, other := range drs {
+				otherMode, otherFound := tlsMode(other)
+				if otherFound && otherMode != mode && other.Metadata.FullName.Namespace != r.Metadata.FullName.Namespace {
+					conflicting = append(conflicting, describeTLSRule(other))
+				}
+			}
+			if len(conflicting) == 0 {
+				continue
+			}
+			ns := r.Metadata.FullName.Namespace.String()
+			m := msg.NewConflictingDestinationRuleTLSModes(r, mode, fqdn, strings.Join(conflicting, ", "), ns,
+				describeTLSRule(h.selected(ns)), describeTLSRule(h.selected("")))
+			if line, ok := util.ErrorLine(r, util.DestinationRuleTLSMode); ok {
+				m.Line = line
+			}
+			ctx.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), m)
+		}
+	}
+}
+
+// tlsHost holds the destination rules of a host, in creation order.
+type tlsHost struct {
+	fqdn          string
+	rootNamespace string
+	drs           []*resource.Instance
+}
+
+// selected returns the destination rule whose traffic policy the proxies of the namespace use for the host, or nil if
+// they use none of them. An empty namespace stands for a namespace without destination rules for the host.
+func (h tlsHost) selected(clientNamespace string) *resource.Instance {
+	// 1. The destination rules of the namespace of the proxy, or the private ones of the root namespace.
+	if clientNamespace != h.rootNamespace {
+		if r := h.first(func(r *resource.Instance, exportTo []string) bool {
+			return r.Metadata.FullName.Namespace.String() == clientNamespace
+		}); r != nil {
+			return r
+		}
+	} else if r := h.first(func(r *resource.Instance, exportTo []string) bool {
+		return r.Metadata.FullName.Namespace.String() == h.rootNamespace && privateOnly(r, exportTo)
+	}); r != nil {
+		return r
+	}
+	// 2. The destination rules of the namespace of the service, exported to the namespace of the proxy.
+	if svcNs := util.GetFullNameFromFQDN(h.fqdn).Namespace; svcNs != "" {
+		if r := h.first(func(r *resource.Instance, exportTo []string) bool {
+			return r.Metadata.FullName.Namespace == svcNs && exportedTo(r, exportTo, clientNamespace)
+		}); r != nil {
+			return r
+		}
+	}
+	// 3. The destination rules of the root namespace, exported to the namespace of the proxy.
+	return h.first(func(r *resource.Instance, exportTo []string) bool {
+		return r.Metadata.FullName.Namespace.String() == h.rootNamespace && exportedTo(r, exportTo, clientNamespace)
+	})
+}
+
+// first returns the first destination rule with a traffic policy among the matching ones, which is the one merged
+// destination rules take their traffic policy from, or the first matching one if none has a traffic policy.
+func (h tlsHost) first(match func(r *resource.Instance, exportTo []string) bool) *resource.Instance {
+	var out *resource.Instance
+	for _, r := range h.drs {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+		if !match(r, dr.GetExportTo()) {
+			continue
+		}
+		if dr.GetTrafficPolicy() != nil {
+			return r
+		}
+		if out == nil {
+			out = r
+		}
+	}
+	return out
+}
+
+func privateOnly(r *resource.Instance, exportTo []string) bool {
+	ns := r.Metadata.FullName.Namespace.String()
+	return len(exportTo) == 1 && (exportTo[0] == util.ExportToNamespaceLocal || exportTo[0] == ns)
+}
+
+func exportedTo(r *resource.Instance, exportTo []string, clientNamespace string) bool {
+	if privateOnly(r, exportTo) {
+		return false
+	}
+	if util.IsExportToAllNamespaces(exportTo) {
+		return true
+	}
+	for _, e := range exportTo {
+		if clientNamespace != "" && e == clientNamespace {
+			return true
+		}
+	}
+	return false
+}
+
+// tlsMode returns the TLS mode of the traffic policy of the destination rule, if it sets one.
+func tlsMode(r *resource.Instance) (string, bool) {
+	tls := r.Message.(*v1alpha3.DestinationRule).GetTrafficPolicy().GetTls()
+	if tls == nil {
+		return "", false
+	}
+	return tls.GetMode().String(), true
+}
+
+func describeTLSRule(r *resource.Instance) string {
+	if r == nil {
+		return "none of them"
+	}
+	mode, f := tlsMode(r)
+	if !f {
+		mode = "no TLS settings"
+	}
+	return r.Metadata.FullName.String() + " (" + mode + ")"
+}
@@ -0,0 +1,230 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	network "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/xds"
+)
+
+// CompatibilityAnalyzer checks the Envoy filter names, types and fields used by EnvoyFilters against the versions
+// of the proxies they apply to, so that EnvoyFilters do not silently break when the proxies are upgraded. It uses the
+// same checks as istioctl x envoyfilter validate, from xds.CheckEnvoyFilterCompatibility.
+type CompatibilityAnalyzer struct{}
+
+var _ analysis.Analyzer = &CompatibilityAnalyzer{}
+
+// proxyVersionRegex matches the version at the start of the tag of a proxy image, such as 1.15.0-distroless.
+var proxyVersionRegex = regexp.MustCompile(`^(\d+)\.(\d+)(\.\d+)?`)
+
+// Metadata implements analysis.Analyzer
+func (*CompatibilityAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "envoyfilter.CompatibilityAnalyzer",
+		Description: "Checks the Envoy filter names, types and fields used by EnvoyFilters are supported by the proxies they apply to",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Envoyfilters.Name(),
+			collections.K8SCoreV1Pods.Name(),
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+		},
+	}
+}
+
+// proxyPod is a pod running a proxy of a known version.
+type proxyPod struct {
+	namespace resource.Namespace
+	labels    labels.Instance
+	// version is the version of the proxy, as in the tag of its image, such as 1.15.0.
+	version string
+}
+
+// Analyze implements analysis.Analyzer
+func (*CompatibilityAnalyzer) Analyze(c analysis.Context) {
+	rootNamespace := resource.Namespace(constants.IstioSystemNamespace)
+	c.ForEach(collections.IstioMeshV1Alpha1MeshConfig.Name(), func(r *resource.Instance) bool {
+		if ns := r.Message.(*meshconfig.MeshConfig).GetRootNamespace(); ns != "" {
+			rootNamespace = resource.Namespace(ns)
+		}
+		return r.Metadata.FullName.Name != util.MeshConfigName
+	})
+
+	var pods []proxyPod
+	c.ForEach(collections.K8SCoreV1Pods.Name(), func(r *resource.Instance) bool {
+		if version := podProxyVersion(r.Message.(*v1.PodSpec)); version != "" {
+			pods = append(pods, proxyPod{
+				namespace: r.Metadata.FullName.Namespace,
+				labels:    labels.Instance(r.Metadata.Labels),
+				version:   version,
+			})
+		}
+		return true
+	})
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Envoyfilters.Name(), func(r *resource.Instance) bool {
+		ef := r.Message.(*network.EnvoyFilter)
+		var selector labels.Instance
+		if ef.GetWorkloadSelector() != nil {
+			selector = ef.GetWorkloadSelector().GetLabels()
+		}
+		for index, patch := range ef.GetConfigPatches() {
+			versions := targetedProxyVersions(pods, r.Metadata.FullName.Namespace, rootNamespace, selector,
+				patch.GetMatch().GetProxy().GetProxyVersion())
+			// Checking against the latest proxy version returns every change, the proxy versions are compared below.
+			for _, issue := range xds.CheckEnvoyFilterCompatibility(patch, math.MaxInt32) {
+				// Patches that cannot be decoded are reported by the validation.
+				if issue.Element != "" {
+					reportIssue(c, r, index, issue, versions)
+				}
+			}
+		}
+		return true
+	})
+}
+
+func reportIssue(c analysis.Context, r *resource.Instance, index int, issue xds.CompatibilityIssue, versions []string) {
+	usage := fmt.Sprintf("%q at %s", issue.Element, issue.Path)
+	removedIn, deprecatedIn := minorVersion(issue.Change.Removed), minorVersion(issue.Change.Deprecated)
+	replacement := issue.Change.Replacement
+	if replacement == "" {
+		replacement = "the replacement documented in the Envoy API"
+	}
+	var m diag.Message
+	if incompatible := versionsAtLeast(versions, removedIn); len(incompatible) > 0 {
+		m = msg.NewEnvoyFilterIncompatibleWithProxyVersion(r, index, usage, removedIn, strings.Join(incompatible, ", "), replacement)
+	} else if len(versions) == 0 || len(versionsAtLeast(versions, deprecatedIn)) > 0 {
+		// Without any known proxy version, the element may or may not be supported: only report the deprecation.
+		m = msg.NewEnvoyFilterUsesDeprecatedConfig(r, index, usage, deprecatedIn, replacement)
+	} else {
+		return
+	}
+	if line, ok := util.ErrorLine(r, fmt.Sprintf(util.EnvoyFilterConfigPath, index)); ok {
+		m.Line = line
+	}
+	c.Report(collections.IstioNetworkingV1Alpha3Envoyfilters.Name(), m)
+}
+
+// minorVersion returns the <major>.<minor> version of a minor version of the compatibility table, or an empty string
+// if the change did not happen.
+func minorVersion(minor int) string {
+	if minor == 0 {
+		return ""
+	}
+	return fmt.Sprintf("1.%d", minor)
+}
+
+// podProxyVersion returns the version of the proxy of a pod, from the tag of the image of its istio-proxy container,
+// or an empty string if it is unknown.
+func podProxyVersion(pod *v1.PodSpec) string {
+	for _, container := range pod.Containers {
+		if container.Name != util.IstioProxyName {
+			continue
+		}
+		image, _, _ := strings.Cut(container.Image, "@")
+		i := strings.LastIndex(image, ":")
+		if i < 0 || strings.Contains(image[i:], "/") {
+			return ""
+		}
+		return proxyVersionRegex.FindString(image[i+1:])
+	}
+	return ""
+}
+
+// targetedProxyVersions returns the sorted versions of the proxies of the pods an EnvoyFilter patch applies to.
+func targetedProxyVersions(pods []proxyPod, namespace, rootNamespace resource.Namespace, selector labels.Instance,
+	proxyVersion string,
+) []string {
+	var versionMatch *regexp.Regexp
+	if proxyVersion != "" {
+		// Invalid regexes are reported by the schema validation.
+		versionMatch, _ = regexp.Compile(proxyVersion)
+	}
+	versions := map[string]struct{}{}
+	for _, pod := range pods {
+		if namespace != rootNamespace && pod.namespace != namespace {
+			continue
+		}
+		if !selector.SubsetOf(pod.labels) {
+			continue
+		}
+		if versionMatch != nil && !versionMatch.MatchString(pod.version) {
+			continue
+		}
+		versions[pod.version] = struct{}{}
+	}
+	out := make([]string, 0, len(versions))
+	for v := range versions {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if c := compareVersions(out[i], out[j]); c != 0 {
+			return c < 0
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+// versionsAtLeast returns the versions which are at least the minimum <major>.<minor> version.
+func versionsAtLeast(versions []string, minimum string) []string {
+	if minimum == "" {
+		return nil
+	}
+	var out []string
+	for _, v := range versions {
+		if compareVersions(v, minimum) >= 0 {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// compareVersions compares the major and minor parts of two versions.
+func compareVersions(a, b string) int {
+	am, an := parseVersion(a)
+	bm, bn := parseVersion(b)
+	if am != bm {
+		return am - bm
+	}
+	return an - bn
+}
+
+func parseVersion(v string) (int, int) {
+	parts := proxyVersionRegex.FindStringSubmatch(v)
+	if parts == nil {
+		return 0, 0
+	}
+	major, _ := strconv.Atoi(parts[1])
+	minor, _ := strconv.Atoi(parts[2])
+	return major, minor
+}
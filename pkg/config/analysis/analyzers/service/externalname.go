@@ -0,0 +1,71 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/constants"
+	configKube "istio.io/istio/pkg/config/kube"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ExternalNameAnalyzer checks the chains of ExternalName services pointing to each other
+type ExternalNameAnalyzer struct{}
+
+var _ analysis.Analyzer = &ExternalNameAnalyzer{}
+
+// Metadata implements Analyzer
+func (s *ExternalNameAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "service.ExternalNameAnalyzer",
+		Description: "Checks the chains of ExternalName services pointing to other ExternalName services",
+		Inputs: collection.Names{
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (s *ExternalNameAnalyzer) Analyze(c analysis.Context) {
+	getService := func(name, namespace string) *v1.ServiceSpec {
+		r := c.Find(collections.K8SCoreV1Services.Name(), resource.NewFullName(resource.Namespace(namespace), resource.LocalName(name)))
+		if r == nil {
+			return nil
+		}
+		return r.Message.(*v1.ServiceSpec)
+	}
+	c.ForEach(collections.K8SCoreV1Services.Name(), func(r *resource.Instance) bool {
+		svc := r.Message.(*v1.ServiceSpec)
+		if svc.Type != v1.ServiceTypeExternalName || svc.ExternalName == "" {
+			return true
+		}
+		target, chain, err := configKube.ResolveExternalName(r.Metadata.FullName.Name.String(), r.Metadata.FullName.Namespace.String(),
+			svc.ExternalName, constants.DefaultClusterLocalDomain, getService)
+		switch {
+		case err != nil:
+			c.Report(collections.K8SCoreV1Services.Name(), msg.NewExternalNameServiceLoop(r, strings.Join(chain, " -> ")))
+		case len(chain) > 1:
+			c.Report(collections.K8SCoreV1Services.Name(), msg.NewExternalNameServiceChain(r, target, strings.Join(chain, " -> ")))
+		}
+		return true
+	})
+}
@@ -0,0 +1,128 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"net"
+
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// IPFamilyAnalyzer checks the IP families of dual-stack and single-stack services against their IP family policy,
+// cluster IPs and endpoints
+type IPFamilyAnalyzer struct{}
+
+var _ analysis.Analyzer = &IPFamilyAnalyzer{}
+
+// Metadata implements Analyzer
+func (s *IPFamilyAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "service.IPFamilyAnalyzer",
+		Description: "Checks the IP families of services against their IP family policy, cluster IPs and endpoints",
+		Inputs: collection.Names{
+			collections.K8SCoreV1Services.Name(),
+			collections.K8SCoreV1Endpoints.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (s *IPFamilyAnalyzer) Analyze(c analysis.Context) {
+	c.ForEach(collections.K8SCoreV1Services.Name(), func(r *resource.Instance) bool {
+		svc := r.Message.(*v1.ServiceSpec)
+		if len(svc.IPFamilies) == 0 {
+			// The API server assigns the IP families; there is nothing to check against until then.
+			return true
+		}
+		for _, detail := range ipFamilyMismatches(svc) {
+			c.Report(collections.K8SCoreV1Services.Name(), msg.NewServiceIPFamilyMismatch(r, detail))
+		}
+		if ep := c.Find(collections.K8SCoreV1Endpoints.Name(), r.Metadata.FullName); ep != nil {
+			if detail := endpointsIPFamilyMismatch(svc, ep.Message.(*v1.Endpoints)); detail != "" {
+				c.Report(collections.K8SCoreV1Services.Name(), msg.NewServiceIPFamilyMismatch(r, detail))
+			}
+		}
+		return true
+	})
+}
+
+// ipFamilyMismatches returns the inconsistencies between the IP families, the IP family policy and the cluster IPs
+// of the service.
+func ipFamilyMismatches(svc *v1.ServiceSpec) []string {
+	var out []string
+	if svc.IPFamilyPolicy != nil {
+		switch *svc.IPFamilyPolicy {
+		case v1.IPFamilyPolicySingleStack:
+			if len(svc.IPFamilies) > 1 {
+				out = append(out, fmt.Sprintf("ipFamilyPolicy is SingleStack but ipFamilies lists %v", svc.IPFamilies))
+			}
+		case v1.IPFamilyPolicyRequireDualStack:
+			if len(svc.IPFamilies) < 2 {
+				out = append(out, fmt.Sprintf("ipFamilyPolicy is RequireDualStack but ipFamilies only lists %v", svc.IPFamilies))
+			}
+		}
+	}
+	if svc.ClusterIP == v1.ClusterIPNone {
+		return out
+	}
+	for i, ip := range svc.ClusterIPs {
+		if i >= len(svc.IPFamilies) {
+			out = append(out, fmt.Sprintf("cluster IP %s has no matching entry in ipFamilies", ip))
+			continue
+		}
+		if family := ipFamilyOf(ip); family != "" && family != svc.IPFamilies[i] {
+			out = append(out, fmt.Sprintf("cluster IP %s is not of IP family %s", ip, svc.IPFamilies[i]))
+		}
+	}
+	return out
+}
+
+// endpointsIPFamilyMismatch returns the first endpoint address whose IP family is not one of the families of the
+// service, which cannot be reached through the service.
+func endpointsIPFamilyMismatch(svc *v1.ServiceSpec, ep *v1.Endpoints) string {
+	families := map[v1.IPFamily]bool{}
+	for _, f := range svc.IPFamilies {
+		families[f] = true
+	}
+	for _, subset := range ep.Subsets {
+		for _, addresses := range [][]v1.EndpointAddress{subset.Addresses, subset.NotReadyAddresses} {
+			for _, a := range addresses {
+				if family := ipFamilyOf(a.IP); family != "" && !families[family] {
+					return fmt.Sprintf("endpoint %s is of IP family %s, which the service does not have", a.IP, family)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func ipFamilyOf(address string) v1.IPFamily {
+	ip := net.ParseIP(address)
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return v1.IPv4Protocol
+	default:
+		return v1.IPv6Protocol
+	}
+}
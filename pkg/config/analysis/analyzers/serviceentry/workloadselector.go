@@ -0,0 +1,77 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceentry
+
+import (
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// WorkloadSelectorAnalyzer warns about ServiceEntries whose workload selector matches pods in other namespaces only
+// because the ServiceEntry selects workloads in all namespaces.
+type WorkloadSelectorAnalyzer struct{}
+
+var _ analysis.Analyzer = &WorkloadSelectorAnalyzer{}
+
+func (a *WorkloadSelectorAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "serviceentry.WorkloadSelectorAnalyzer",
+		Description: "Checks the workloads selected by ServiceEntries in other namespaces",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+			collections.K8SCoreV1Pods.Name(),
+		},
+	}
+}
+
+func (a *WorkloadSelectorAnalyzer) Analyze(ctx analysis.Context) {
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), func(r *resource.Instance) bool {
+		se := r.Message.(*v1alpha3.ServiceEntry)
+		listed := listedNamespaces(r.Metadata.Annotations)
+		if se.WorkloadSelector == nil || !listed["*"] {
+			return true
+		}
+		ns := r.Metadata.FullName.Namespace.String()
+		selector := labels.Instance(se.WorkloadSelector.Labels)
+		ctx.ForEach(collections.K8SCoreV1Pods.Name(), func(p *resource.Instance) bool {
+			podNs := p.Metadata.FullName.Namespace.String()
+			if podNs == ns || listed[podNs] || !selector.SubsetOf(labels.Instance(p.Metadata.Labels)) {
+				return true
+			}
+			ctx.Report(collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+				msg.NewServiceEntryWorkloadSelectorMatchesOtherNamespace(r, p.Metadata.FullName.Name.String(), podNs))
+			return true
+		})
+		return true
+	})
+}
+
+func listedNamespaces(annotations map[string]string) map[string]bool {
+	out := map[string]bool{}
+	for _, ns := range strings.Split(annotations[constants.WorkloadSelectorNamespacesAnnotation], ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			out[ns] = true
+		}
+	}
+	return out
+}
@@ -98,6 +98,10 @@ const (
 	// Required parameters: none.
 	DestinationRuleTLSCert = "{.spec.trafficPolicy.tls.caCertificates}"
 
+	// Path for DestinationRule tls mode.
+	// Required parameters: none.
+	DestinationRuleTLSMode = "{.spec.trafficPolicy.tls.mode}"
+
 	// Path for DestinationRule port-level tls certificate.
 	// Required parameters: portLevelSettings index.
 	DestinationRuleTLSPortLevelCert = "{.spec.trafficPolicy.portLevelSettings[%d].tls.caCertificates}"
@@ -27,7 +27,11 @@ import (
 )
 
 // DestinationRuleAnalyzer checks the destination rules associated with each virtual service
-type DestinationRuleAnalyzer struct{}
+type DestinationRuleAnalyzer struct {
+	// RemoteDestinationRules are the destination rules of the other clusters of the mesh. The subsets they define
+	// may be referenced by the virtual services too, since the proxies of the mesh receive them from any cluster.
+	RemoteDestinationRules []*resource.Instance
+}
 
 var _ analysis.Analyzer = &DestinationRuleAnalyzer{}
 
@@ -47,6 +51,9 @@ func (d *DestinationRuleAnalyzer) Metadata() analysis.Metadata {
 func (d *DestinationRuleAnalyzer) Analyze(ctx analysis.Context) {
 	// To avoid repeated iteration, precompute the set of existing destination host+subset combinations
 	destHostsAndSubsets := initDestHostsAndSubsets(ctx)
+	for _, r := range d.RemoteDestinationRules {
+		addDestHostsAndSubsets(r, destHostsAndSubsets)
+	}
 
 	ctx.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
 		d.analyzeVirtualService(r, ctx, destHostsAndSubsets)
@@ -59,11 +66,15 @@ func (d *DestinationRuleAnalyzer) analyzeVirtualService(r *resource.Instance, ct
 ) {
 	vs := r.Message.(*v1alpha3.VirtualService)
 	ns := r.Metadata.FullName.Namespace
+	where := "destinationrule"
+	if len(d.RemoteDestinationRules) > 0 {
+		where = "destinationrule of any cluster"
+	}
 
 	for _, ad := range getRouteDestinations(vs) {
 		if !d.checkDestinationSubset(ns, ad.Destination, destHostsAndSubsets) {
 
-			m := msg.NewReferencedResourceNotFound(r, "host+subset in destinationrule",
+			m := msg.NewReferencedResourceNotFound(r, "host+subset in "+where,
 				fmt.Sprintf("%s+%s", ad.Destination.GetHost(), ad.Destination.GetSubset()))
 
 			key := fmt.Sprintf(util.DestinationHost, ad.RouteRule, ad.ServiceIndex, ad.DestinationIndex)
@@ -78,7 +89,7 @@ func (d *DestinationRuleAnalyzer) analyzeVirtualService(r *resource.Instance, ct
 	for _, ad := range getHTTPMirrorDestinations(vs) {
 		if !d.checkDestinationSubset(ns, ad.Destination, destHostsAndSubsets) {
 
-			m := msg.NewReferencedResourceNotFound(r, "mirror+subset in destinationrule",
+			m := msg.NewReferencedResourceNotFound(r, "mirror+subset in "+where,
 				fmt.Sprintf("%s+%s", ad.Destination.GetHost(), ad.Destination.GetSubset()))
 
 			key := fmt.Sprintf(util.MirrorHost, ad.ServiceIndex)
@@ -116,17 +127,21 @@ func (d *DestinationRuleAnalyzer) checkDestinationSubset(vsNamespace resource.Na
 func initDestHostsAndSubsets(ctx analysis.Context) map[hostAndSubset]bool {
 	hostsAndSubsets := make(map[hostAndSubset]bool)
 	ctx.ForEach(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(r *resource.Instance) bool {
-		dr := r.Message.(*v1alpha3.DestinationRule)
-		drNamespace := r.Metadata.FullName.Namespace
-
-		for _, ss := range dr.GetSubsets() {
-			hs := hostAndSubset{
-				host:   util.GetResourceNameFromHost(drNamespace, dr.GetHost()),
-				subset: ss.GetName(),
-			}
-			hostsAndSubsets[hs] = true
-		}
+		addDestHostsAndSubsets(r, hostsAndSubsets)
 		return true
 	})
 	return hostsAndSubsets
 }
+
+func addDestHostsAndSubsets(r *resource.Instance, hostsAndSubsets map[hostAndSubset]bool) {
+	dr := r.Message.(*v1alpha3.DestinationRule)
+	drNamespace := r.Metadata.FullName.Namespace
+
+	for _, ss := range dr.GetSubsets() {
+		hs := hostAndSubset{
+			host:   util.GetResourceNameFromHost(drNamespace, dr.GetHost()),
+			subset: ss.GetName(),
+		}
+		hostsAndSubsets[hs] = true
+	}
+}
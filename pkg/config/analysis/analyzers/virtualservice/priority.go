@@ -0,0 +1,125 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// RoutePriorityAnalyzer checks the route priority of the virtual services bound to gateways, and reports the virtual
+// services binding the same host of a gateway with the same priority, whose routes are merged in creation order.
+type RoutePriorityAnalyzer struct{}
+
+var _ analysis.Analyzer = &RoutePriorityAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *RoutePriorityAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "virtualservice.RoutePriorityAnalyzer",
+		Description: "Checks the route priority of the virtual services bound to the same gateway host",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *RoutePriorityAnalyzer) Analyze(ctx analysis.Context) {
+	priorities := map[*resource.Instance]int{}
+	// bindings maps a gateway and host to the virtual services binding them.
+	bindings := map[string][]*resource.Instance{}
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+		priority, err := routePriority(r)
+		if err != nil {
+			m := msg.NewInvalidAnnotation(r, constants.RoutePriorityAnnotation, err.Error())
+			if line, ok := util.ErrorLine(r, fmt.Sprintf(util.MetadataName)); ok {
+				m.Line = line
+			}
+			ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), m)
+		}
+		priorities[r] = priority
+		for _, gw := range vs.Gateways {
+			if gw == util.MeshGateway {
+				continue
+			}
+			gwName := resource.NewShortOrFullName(r.Metadata.FullName.Namespace, gw).String()
+			for _, h := range vs.Hosts {
+				key := gwName + "|" + h
+				bindings[key] = append(bindings[key], r)
+			}
+		}
+		return true
+	})
+
+	keys := make([]string, 0, len(bindings))
+	for key := range bindings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	reported := map[*resource.Instance]bool{}
+	for _, key := range keys {
+		vsList := bindings[key]
+		if len(vsList) < 2 {
+			continue
+		}
+		gwName, h, _ := strings.Cut(key, "|")
+		for _, r := range vsList {
+			if reported[r] {
+				continue
+			}
+			var tied []*resource.Instance
+			for _, other := range vsList {
+				if other != r && priorities[other] == priorities[r] {
+					tied = append(tied, other)
+				}
+			}
+			if len(tied) == 0 {
+				continue
+			}
+			reported[r] = true
+			m := msg.NewVirtualServiceRoutePriorityTie(r, combineResourceEntryNames(tied), h, gwName, priorities[r])
+			if line, ok := util.ErrorLine(r, fmt.Sprintf(util.MetadataName)); ok {
+				m.Line = line
+			}
+			ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), m)
+		}
+	}
+}
+
+// routePriority returns the route priority of the virtual service, set by its RoutePriorityAnnotation.
+func routePriority(r *resource.Instance) (int, error) {
+	value, f := r.Metadata.Annotations[constants.RoutePriorityAnnotation]
+	if !f {
+		return 0, nil
+	}
+	priority, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an integer", value)
+	}
+	return priority, nil
+}
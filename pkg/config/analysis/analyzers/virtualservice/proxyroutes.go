@@ -0,0 +1,197 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"sort"
+	"strings"
+
+	adminapi "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// ProxyRoutesAnalyzer checks the config dumps of sidecars for the routes of the virtual services that apply to them.
+// A sidecar routing traffic to a host of a virtual service without its routes did not get, or rejected, the
+// configuration istiod generates for the virtual service.
+type ProxyRoutesAnalyzer struct{}
+
+var _ analysis.Analyzer = &ProxyRoutesAnalyzer{}
+
+// Metadata implements Analyzer
+func (p *ProxyRoutesAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "virtualservice.ProxyRoutesAnalyzer",
+		Description: "Checks the routes of virtual services are in the config dumps of the sidecars routing to their hosts",
+		Inputs: collection.Names{
+			collections.EnvoyAdminV3Configdumps.Name(),
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (p *ProxyRoutesAnalyzer) Analyze(ctx analysis.Context) {
+	var proxies []*proxyRoutes
+	ctx.ForEach(collections.EnvoyAdminV3Configdumps.Name(), func(r *resource.Instance) bool {
+		if pr := newProxyRoutes(r); pr != nil {
+			proxies = append(proxies, pr)
+		}
+		return true
+	})
+	if len(proxies) == 0 {
+		return
+	}
+	sort.Slice(proxies, func(i, j int) bool {
+		return proxies[i].name < proxies[j].name
+	})
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+		// Only the HTTP routes of the virtual services of the mesh gateway are routes of sidecars.
+		if len(vs.GetHttp()) == 0 || !appliesToMeshGateway(vs.GetGateways()) {
+			return true
+		}
+		config := virtualServiceConfigPath(r.Metadata.FullName)
+		for _, pr := range proxies {
+			if !exportedToNamespace(vs.GetExportTo(), r.Metadata.FullName.Namespace, pr.namespace) || pr.configs.Contains(config) {
+				continue
+			}
+			for _, h := range vs.GetHosts() {
+				fqdn := util.ConvertHostToFQDN(r.Metadata.FullName.Namespace, h)
+				if pr.domains.Contains(fqdn) {
+					m := msg.NewVirtualServiceRoutesNotInProxy(r, pr.name, fqdn)
+					if line, ok := util.ErrorLine(r, util.MetadataName); ok {
+						m.Line = line
+					}
+					ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), m)
+					break
+				}
+			}
+		}
+		return true
+	})
+}
+
+// proxyRoutes holds the routes of the config dump of a sidecar.
+type proxyRoutes struct {
+	// name is the name of the proxy, as <pod name>.<namespace>.
+	name      string
+	namespace resource.Namespace
+	// domains are the domains of the virtual hosts of the proxy, without port.
+	domains sets.Set
+	// configs are the paths of the Istio configs the routes of the proxy are generated from.
+	configs sets.Set
+}
+
+// newProxyRoutes reads the routes of a config dump, or returns nil if the config dump is not the one of a sidecar or
+// if its routes do not refer to the configs they are generated from, which happens when istiod trims the metadata of
+// the configuration it pushes.
+func newProxyRoutes(r *resource.Instance) *proxyRoutes {
+	dump := r.Message.(*adminapi.ConfigDump)
+	pr := &proxyRoutes{
+		name:      r.Metadata.FullName.Name.String() + "." + r.Metadata.FullName.Namespace.String(),
+		namespace: r.Metadata.FullName.Namespace,
+		domains:   sets.New(),
+		configs:   sets.New(),
+	}
+	sidecar := false
+	for _, c := range dump.GetConfigs() {
+		switch {
+		case c.MessageIs(&adminapi.BootstrapConfigDump{}):
+			bootstrap := &adminapi.BootstrapConfigDump{}
+			if err := c.UnmarshalTo(bootstrap); err != nil {
+				return nil
+			}
+			sidecar = strings.HasPrefix(bootstrap.GetBootstrap().GetNode().GetId(), "sidecar~")
+		case c.MessageIs(&adminapi.RoutesConfigDump{}):
+			routes := &adminapi.RoutesConfigDump{}
+			if err := c.UnmarshalTo(routes); err != nil {
+				return nil
+			}
+			for _, rc := range routes.GetDynamicRouteConfigs() {
+				pr.addRouteConfig(rc.GetRouteConfig())
+			}
+			for _, rc := range routes.GetStaticRouteConfigs() {
+				pr.addRouteConfig(rc.GetRouteConfig())
+			}
+		}
+	}
+	if !sidecar || len(pr.configs) == 0 {
+		return nil
+	}
+	return pr
+}
+
+func (pr *proxyRoutes) addRouteConfig(a *anypb.Any) {
+	rc := &route.RouteConfiguration{}
+	if a == nil || a.UnmarshalTo(rc) != nil {
+		return
+	}
+	for _, vh := range rc.GetVirtualHosts() {
+		for _, d := range vh.GetDomains() {
+			// Strip the port of host names, IPv6 addresses are of no interest.
+			if strings.Count(d, ":") == 1 {
+				d, _, _ = strings.Cut(d, ":")
+			}
+			pr.domains.Insert(d)
+		}
+		for _, rt := range vh.GetRoutes() {
+			if config := rt.GetMetadata().GetFilterMetadata()["istio"].GetFields()["config"].GetStringValue(); config != "" {
+				pr.configs.Insert(config)
+			}
+		}
+	}
+}
+
+// virtualServiceConfigPath returns the path of a virtual service in the metadata of the routes generated from it.
+func virtualServiceConfigPath(name resource.FullName) string {
+	gvk := collections.IstioNetworkingV1Alpha3Virtualservices.Resource().GroupVersionKind()
+	return "/apis/" + gvk.Group + "/" + gvk.Version + "/namespaces/" + name.Namespace.String() + "/virtual-service/" +
+		name.Name.String()
+}
+
+func appliesToMeshGateway(gateways []string) bool {
+	if len(gateways) == 0 {
+		return true
+	}
+	for _, gw := range gateways {
+		if gw == util.MeshGateway {
+			return true
+		}
+	}
+	return false
+}
+
+func exportedToNamespace(exportTo []string, configNamespace, namespace resource.Namespace) bool {
+	if util.IsExportToAllNamespaces(exportTo) {
+		return true
+	}
+	for _, e := range exportTo {
+		if e == namespace.String() || (e == util.ExportToNamespaceLocal && configNamespace == namespace) {
+			return true
+		}
+	}
+	return false
+}
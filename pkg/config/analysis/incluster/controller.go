@@ -42,6 +42,8 @@ import (
 type Controller struct {
 	analyzer  *local.IstiodAnalyzer
 	statusctl *status.Controller
+	// suppressions are the suppressions and severity overrides of PILOT_ANALYSIS_SUPPRESSION_FILE, if set.
+	suppressions *local.SuppressionFile
 }
 
 func NewController(stop <-chan struct{}, rwConfigStore model.ConfigStoreController,
@@ -50,6 +52,18 @@ func NewController(stop <-chan struct{}, rwConfigStore model.ConfigStoreControll
 	ia := local.NewIstiodAnalyzer(analyzers.AllCombined(),
 		"", resource.Namespace(namespace), func(name collection.Name) {}, true)
 	ia.AddSource(rwConfigStore)
+	var suppressions *local.SuppressionFile
+	if features.AnalysisSuppressionFile != "" {
+		f, err := local.LoadSuppressionFile(features.AnalysisSuppressionFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load analysis suppressions, releasing lease: %v", err)
+		}
+		_, _, warnings := f.Active(time.Now())
+		for _, w := range warnings {
+			log.Warnf("In-cluster analysis: %s, it no longer has any effect", w)
+		}
+		suppressions = f
+	}
 	// Filter out configs watched by rwConfigStore so we don't watch multiple times
 	store, err := crdclient.NewForSchemas(kubeClient, "default",
 		domainSuffix, "analysis-controller", collections.All.Remove(rwConfigStore.Schemas().All()...))
@@ -71,7 +85,18 @@ func NewController(stop <-chan struct{}, rwConfigStore model.ConfigStoreControll
 		}
 		return status
 	})
-	return &Controller{analyzer: ia, statusctl: ctl}, nil
+	return &Controller{analyzer: ia, statusctl: ctl, suppressions: suppressions}, nil
+}
+
+// applySuppressions sets the suppressions and severity overrides of the suppression file which have not expired yet,
+// so that expired entries stop applying without restarting istiod.
+func (c *Controller) applySuppressions(now time.Time) {
+	if c.suppressions == nil {
+		return
+	}
+	suppressions, overrides, _ := c.suppressions.Active(now)
+	c.analyzer.SetSuppressions(suppressions)
+	c.analyzer.SetSeverityOverrides(overrides)
 }
 
 // Run is blocking
@@ -81,6 +106,7 @@ func (c *Controller) Run(stop <-chan struct{}) {
 	for {
 		select {
 		case <-t.C:
+			c.applySuppressions(time.Now())
 			res, err := c.analyzer.ReAnalyze(stop)
 			if err != nil {
 				log.Errorf("In-cluster analysis has failed: %s", err)
@@ -27,6 +27,7 @@ import (
 
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/diag"
 	"istio.io/istio/pkg/config/analysis/msg"
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/config/resource"
@@ -138,6 +139,30 @@ func TestFilterOutputByNamespace(t *testing.T) {
 	g.Expect(result.Messages).To(ConsistOf(msg1))
 }
 
+func TestSeverityOverrides(t *testing.T) {
+	g := NewWithT(t)
+
+	cancel := make(chan struct{})
+
+	r := createTestResource(t, "ns", "resource", "v1")
+	a := &testAnalyzer{
+		fn: func(ctx analysis.Context) {
+			ctx.Report(K8SCollection1.Name(), msg.NewInternalError(r, "msg"))
+		},
+	}
+
+	sa := NewSourceAnalyzer(analysis.Combine("a", a), "", "", nil, false, timeout)
+	sa.SetSeverityOverrides([]SeverityOverride{{Code: msg.InternalError.Code(), ResourceName: "*", Level: diag.Info}})
+	err := sa.AddReaderKubeSource(nil)
+	g.Expect(err).To(BeNil())
+
+	result, err := sa.Analyze(cancel)
+	g.Expect(err).To(BeNil())
+	g.Expect(result.Messages).To(HaveLen(1))
+	g.Expect(result.Messages[0].Type.Level()).To(Equal(diag.Info))
+	g.Expect(result.Messages[0].Type.Code()).To(Equal(msg.InternalError.Code()))
+}
+
 func TestAddInMemorySource(t *testing.T) {
 	g := NewWithT(t)
 
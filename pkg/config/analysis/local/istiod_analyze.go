@@ -22,6 +22,7 @@ import (
 	"strings"
 	"time"
 
+	adminapi "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
 	"github.com/hashicorp/go-multierror"
 	"github.com/ryanuber/go-glob"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -67,6 +68,9 @@ type IstiodAnalyzer struct {
 	// List of code and resource suppressions to exclude messages on
 	suppressions []AnalysisSuppression
 
+	// List of code and resource severity overrides to change the level of messages on
+	severityOverrides []SeverityOverride
+
 	// Mesh config for this analyzer. This can come from multiple sources, and the last added version will take precedence.
 	meshCfg *v1alpha1.MeshConfig
 
@@ -141,6 +145,7 @@ func (sa *IstiodAnalyzer) ReAnalyze(cancel <-chan struct{}) (AnalysisResult, err
 	}
 	// TODO: analysis is run for all namespaces, even if they are requested to be filtered.
 	msgs := filterMessages(ctx.(*istiodContext).messages, namespaces, sa.suppressions)
+	msgs = applySeverityOverrides(msgs, sa.severityOverrides)
 	result.Messages = msgs.SortedDedupedCopy()
 
 	return result, nil
@@ -232,6 +237,12 @@ func (sa *IstiodAnalyzer) SetSuppressions(suppressions []AnalysisSuppression) {
 	sa.suppressions = suppressions
 }
 
+// SetSeverityOverrides will set the list of severity overrides for the analyzer. Any message that matches the
+// provided override is reported at the level of the override instead.
+func (sa *IstiodAnalyzer) SetSeverityOverrides(overrides []SeverityOverride) {
+	sa.severityOverrides = overrides
+}
+
 // AddReaderKubeSource adds a source based on the specified k8s yaml files to the current IstiodAnalyzer
 func (sa *IstiodAnalyzer) AddReaderKubeSource(readers []ReaderSource) error {
 	var src *file.KubeSource
@@ -307,6 +318,38 @@ func (sa *IstiodAnalyzer) AddSource(src model.ConfigStoreController) {
 	sa.stores = append(sa.stores, src)
 }
 
+// ProxyConfigDump is the Envoy config dump of the proxy of a pod.
+type ProxyConfigDump struct {
+	// Proxy is the pod of the proxy, as <pod name>.<namespace>.
+	Proxy      string
+	ConfigDump *adminapi.ConfigDump
+}
+
+// AddConfigDumpSource adds a source based on the Envoy config dumps of proxies to the current IstiodAnalyzer, so
+// analyzers can compare the configs with the configuration actually pushed to the proxies.
+func (sa *IstiodAnalyzer) AddConfigDumpSource(dumps []ProxyConfigDump) error {
+	store := memory.Make(collection.SchemasFor(collections.EnvoyAdminV3Configdumps))
+	for _, d := range dumps {
+		i := strings.LastIndex(d.Proxy, ".")
+		if i <= 0 || i == len(d.Proxy)-1 {
+			return fmt.Errorf("invalid proxy %q, expected <pod name>.<namespace>", d.Proxy)
+		}
+		_, err := store.Create(config.Config{
+			Meta: config.Meta{
+				Name:             d.Proxy[:i],
+				Namespace:        d.Proxy[i+1:],
+				GroupVersionKind: collections.EnvoyAdminV3Configdumps.Resource().GroupVersionKind(),
+			},
+			Spec: d.ConfigDump,
+		})
+		if err != nil {
+			return fmt.Errorf("error adding the config dump of proxy %s: %v", d.Proxy, err)
+		}
+	}
+	sa.stores = append(sa.stores, dfCache{ConfigStore: store})
+	return nil
+}
+
 // AddFileKubeMeshConfig gets mesh config from the specified yaml file
 func (sa *IstiodAnalyzer) AddFileKubeMeshConfig(file string) error {
 	by, err := os.ReadFile(file)
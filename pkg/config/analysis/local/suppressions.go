@@ -0,0 +1,197 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ryanuber/go-glob"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/scope"
+)
+
+// SuppressionFile is a file of suppressions and severity overrides of message codes on resources. It is meant to be
+// checked in next to the analyzed configuration, so that known and accepted findings do not fail CI:
+//
+//	suppressions:
+//	- code: IST0102
+//	  resource: Namespace legacy
+//	  expires: "2023-06-30"
+//	  reason: legacy namespace is migrated next quarter
+//	severityOverrides:
+//	- code: IST0118
+//	  resource: Service legacy/*
+//	  severity: Info
+type SuppressionFile struct {
+	Suppressions      []SuppressionEntry `json:"suppressions,omitempty"`
+	SeverityOverrides []SuppressionEntry `json:"severityOverrides,omitempty"`
+}
+
+// SuppressionEntry is a suppression or a severity override of a SuppressionFile.
+type SuppressionEntry struct {
+	// Code is the message code, such as IST0102.
+	Code string `json:"code"`
+	// Resource is the resource the entry applies to, in the form of AnalysisSuppression.ResourceName, such as
+	// "Service legacy/*". It matches all resources when empty.
+	Resource string `json:"resource,omitempty"`
+	// Severity is the level messages are reported at, for severity overrides.
+	Severity string `json:"severity,omitempty"`
+	// Expires is the last day, such as 2023-06-30, or the time, in RFC 3339, the entry applies.
+	Expires string `json:"expires,omitempty"`
+	// Reason documents why the finding is accepted.
+	Reason string `json:"reason,omitempty"`
+
+	level     diag.Level
+	expiresAt time.Time
+}
+
+// SeverityOverride changes the level of the messages with a code on the resources matching a glob.
+type SeverityOverride struct {
+	// Code is the analysis code to override (e.g. "IST0118").
+	Code string
+
+	// ResourceName is the name of the resource to override the level of the message for, in the same form as
+	// AnalysisSuppression.ResourceName. "*" matches all resources, including messages without a resource.
+	ResourceName string
+
+	// Level is the level the messages are reported at.
+	Level diag.Level
+}
+
+// LoadSuppressionFile reads and validates a suppression file.
+func LoadSuppressionFile(path string) (*SuppressionFile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &SuppressionFile{}
+	if err := yaml.UnmarshalStrict(b, f); err != nil {
+		return nil, fmt.Errorf("invalid suppression file %s: %v", path, err)
+	}
+
+	for i := range f.Suppressions {
+		s := &f.Suppressions[i]
+		if s.Code == "" {
+			return nil, fmt.Errorf("invalid suppression file %s: suppression without code", path)
+		}
+		if s.expiresAt, err = parseExpiry(s.Expires); err != nil {
+			return nil, fmt.Errorf("invalid suppression file %s: %v", path, err)
+		}
+	}
+
+	levels := diag.GetUppercaseStringToLevelMap()
+	for i := range f.SeverityOverrides {
+		o := &f.SeverityOverrides[i]
+		if o.Code == "" {
+			return nil, fmt.Errorf("invalid suppression file %s: severity override without code", path)
+		}
+		level, ok := levels[strings.ToUpper(o.Severity)]
+		if !ok {
+			return nil, fmt.Errorf("invalid suppression file %s: invalid severity %q of %s, valid values: %v",
+				path, o.Severity, o.Code, diag.GetAllLevelStrings())
+		}
+		o.level = level
+		if o.expiresAt, err = parseExpiry(o.Expires); err != nil {
+			return nil, fmt.Errorf("invalid suppression file %s: %v", path, err)
+		}
+	}
+	return f, nil
+}
+
+// Active returns the suppressions and severity overrides of the file which have not expired at the given time. A
+// warning is returned for each expired entry.
+func (f *SuppressionFile) Active(now time.Time) ([]AnalysisSuppression, []SeverityOverride, []string) {
+	var warnings []string
+	var suppressions []AnalysisSuppression
+	for _, s := range f.Suppressions {
+		if s.expired(now) {
+			warnings = append(warnings, fmt.Sprintf("suppression of %s on %q expired on %s", s.Code, s.resourceOrAll(), s.Expires))
+			continue
+		}
+		suppressions = append(suppressions, AnalysisSuppression{
+			Code:         s.Code,
+			ResourceName: s.resourceOrAll(),
+		})
+	}
+
+	var overrides []SeverityOverride
+	for _, o := range f.SeverityOverrides {
+		if o.expired(now) {
+			warnings = append(warnings, fmt.Sprintf("severity override of %s on %q expired on %s", o.Code, o.resourceOrAll(), o.Expires))
+			continue
+		}
+		overrides = append(overrides, SeverityOverride{
+			Code:         o.Code,
+			ResourceName: o.resourceOrAll(),
+			Level:        o.level,
+		})
+	}
+	return suppressions, overrides, warnings
+}
+
+func (e SuppressionEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+func (e SuppressionEntry) resourceOrAll() string {
+	if e.Resource == "" {
+		return "*"
+	}
+	return e.Resource
+}
+
+// parseExpiry parses an expiry, a date or a RFC 3339 time, into the time it expires at, zero if it is empty. Dates
+// expire at the end of the day, in UTC.
+func parseExpiry(expires string) (time.Time, error) {
+	if expires == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", expires); err == nil {
+		return t.AddDate(0, 0, 1), nil
+	}
+	t, err := time.Parse(time.RFC3339, expires)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry %q, expected a date such as 2006-01-02 or a RFC 3339 time", expires)
+	}
+	return t, nil
+}
+
+// applySeverityOverrides changes the level of the messages matching the overrides. The first matching override wins.
+func applySeverityOverrides(messages diag.Messages, overrides []SeverityOverride) diag.Messages {
+	if len(overrides) == 0 {
+		return messages
+	}
+	out := make(diag.Messages, 0, len(messages))
+	for _, m := range messages {
+		for _, o := range overrides {
+			if o.Code != m.Type.Code() {
+				continue
+			}
+			if o.ResourceName != "*" && (m.Resource == nil || !glob.Glob(o.ResourceName, m.Resource.Origin.FriendlyName())) {
+				continue
+			}
+			scope.Analysis.Debugf("Overriding the level of code %s to %s due to severity overrides", m.Type.Code(), o.Level)
+			m.Type = diag.NewMessageType(o.Level, m.Type.Code(), m.Type.Template())
+			break
+		}
+		out = append(out, m)
+	}
+	return out
+}
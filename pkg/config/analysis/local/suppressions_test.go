@@ -0,0 +1,118 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/legacy/source/kube"
+	"istio.io/istio/pkg/config/resource"
+)
+
+func TestLoadSuppressionFile(t *testing.T) {
+	g := NewWithT(t)
+
+	path := filepath.Join(t.TempDir(), "suppressions.yaml")
+	g.Expect(os.WriteFile(path, []byte(`
+suppressions:
+- code: IST0102
+  resource: Namespace legacy
+  reason: migrated next quarter
+- code: IST0103
+  resource: Pod legacy/*
+  expires: "2023-01-31"
+- code: IST0104
+  expires: "2023-02-01"
+severityOverrides:
+- code: IST0118
+  resource: Service legacy/*
+  severity: info
+- code: IST0108
+  severity: Error
+  expires: "2023-01-01T00:00:00Z"
+`), 0o644)).To(Succeed())
+
+	f, err := LoadSuppressionFile(path)
+	g.Expect(err).To(BeNil())
+
+	now := time.Date(2023, 2, 1, 12, 0, 0, 0, time.UTC)
+	suppressions, overrides, warnings := f.Active(now)
+	g.Expect(suppressions).To(Equal([]AnalysisSuppression{
+		{Code: "IST0102", ResourceName: "Namespace legacy"},
+		{Code: "IST0104", ResourceName: "*"},
+	}))
+	g.Expect(overrides).To(Equal([]SeverityOverride{
+		{Code: "IST0118", ResourceName: "Service legacy/*", Level: diag.Info},
+	}))
+	g.Expect(warnings).To(HaveLen(2))
+
+	// Entries expire while a long running analysis, such as the one of istiod, uses the file.
+	suppressions, overrides, warnings = f.Active(now.AddDate(0, 0, 1))
+	g.Expect(suppressions).To(Equal([]AnalysisSuppression{
+		{Code: "IST0102", ResourceName: "Namespace legacy"},
+	}))
+	g.Expect(overrides).To(HaveLen(1))
+	g.Expect(warnings).To(HaveLen(3))
+}
+
+func TestLoadSuppressionFileInvalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown field":    "suppressions:\n- code: IST0102\n  resources: Namespace legacy\n",
+		"missing code":     "suppressions:\n- resource: Namespace legacy\n",
+		"invalid severity": "severityOverrides:\n- code: IST0102\n  severity: Fatal\n",
+		"invalid expiry":   "suppressions:\n- code: IST0102\n  expires: next week\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+			path := filepath.Join(t.TempDir(), "suppressions.yaml")
+			g.Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+			_, err := LoadSuppressionFile(path)
+			g.Expect(err).NotTo(BeNil())
+		})
+	}
+}
+
+func TestApplySeverityOverrides(t *testing.T) {
+	g := NewWithT(t)
+
+	legacy := &resource.Instance{Origin: &kube.Origin{
+		Kind:     "Service",
+		FullName: resource.NewFullName("legacy", "foo"),
+	}}
+	other := &resource.Instance{Origin: &kube.Origin{
+		Kind:     "Service",
+		FullName: resource.NewFullName("default", "foo"),
+	}}
+	msgs := diag.Messages{
+		diag.NewMessage(diag.NewMessageType(diag.Warning, "IST0118", "Template: %q"), legacy, ""),
+		diag.NewMessage(diag.NewMessageType(diag.Warning, "IST0118", "Template: %q"), other, ""),
+		diag.NewMessage(diag.NewMessageType(diag.Warning, "IST0108", "Template: %q"), nil, ""),
+	}
+
+	got := applySeverityOverrides(msgs, []SeverityOverride{
+		{Code: "IST0118", ResourceName: "Service legacy/*", Level: diag.Info},
+		{Code: "IST0108", ResourceName: "*", Level: diag.Error},
+	})
+	g.Expect(got[0].Type.Level()).To(Equal(diag.Info))
+	g.Expect(got[1].Type.Level()).To(Equal(diag.Warning))
+	g.Expect(got[2].Type.Level()).To(Equal(diag.Error))
+	g.Expect(got[0].Type.Code()).To(Equal("IST0118"))
+}
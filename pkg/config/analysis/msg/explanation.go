@@ -0,0 +1,59 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/istio/pkg/url"
+)
+
+// Explanation explains a message type: what it means, what usually causes it and how to fix it, so that
+// automation can map analysis findings to runbooks.
+type Explanation struct {
+	Code        string   `json:"code"`
+	Name        string   `json:"name"`
+	Level       string   `json:"level"`
+	Description string   `json:"description"`
+	Template    string   `json:"template"`
+	Causes      []string `json:"causes,omitempty"`
+	Remediation string   `json:"remediation,omitempty"`
+	// DocumentationURL is the documentation of the message type, as linked by the messages.
+	DocumentationURL string `json:"documentationUrl"`
+}
+
+func init() {
+	for _, e := range explanations {
+		e.DocumentationURL = fmt.Sprintf("%s/%s/", url.ConfigAnalysis, strings.ToLower(e.Code))
+	}
+}
+
+// Explain returns the explanation of the message type with the code, such as IST0101, or false if there is none.
+// The code is case insensitive.
+func Explain(code string) (*Explanation, bool) {
+	code = strings.ToUpper(code)
+	for _, e := range explanations {
+		if e.Code == code {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// Catalog returns the explanations of all known message types, ordered by code.
+func Catalog() []*Explanation {
+	return append([]*Explanation(nil), explanations...)
+}
@@ -144,6 +144,29 @@ func All() []*diag.MessageType {
 	}
 }
 
+// explanations are the explanations of all known message types, ordered by code.
+var explanations = []*Explanation{
+	{{- range .Messages}}
+	{
+		Code:        "{{.Code}}",
+		Name:        "{{.Name}}",
+		Level:       "{{.Level}}",
+		Description: {{printf "%q" .Description}},
+		Template:    {{printf "%q" .Template}},
+		{{- if .Causes}}
+		Causes: []string{
+			{{- range .Causes}}
+			{{printf "%q" .}},
+			{{- end}}
+		},
+		{{- end}}
+		{{- if .Remediation}}
+		Remediation: {{printf "%q" .Remediation}},
+		{{- end}}
+	},
+	{{- end}}
+}
+
 {{range .Messages}}
 // New{{.Name}} returns a new diag.Message based on {{.Name}}.
 func New{{.Name}}(r *resource.Instance{{range .Args}}, {{.Name}} {{.Type}}{{end}}) diag.Message {
@@ -173,13 +196,15 @@ type messages struct {
 }
 
 type message struct {
-	Name        string `json:"name"`
-	Code        string `json:"code"`
-	Level       string `json:"level"`
-	Description string `json:"description"`
-	Template    string `json:"template"`
-	Url         string `json:"url"`
-	Args        []arg  `json:"args"`
+	Name        string   `json:"name"`
+	Code        string   `json:"code"`
+	Level       string   `json:"level"`
+	Description string   `json:"description"`
+	Template    string   `json:"template"`
+	Causes      []string `json:"causes"`
+	Remediation string   `json:"remediation"`
+	Url         string   `json:"url"`
+	Args        []arg    `json:"args"`
 }
 
 type arg struct {
@@ -216,6 +216,42 @@ var (
 	// EnvoyFilterUsesRelativeOperationWithProxyVersion defines a diag.MessageType for message "EnvoyFilterUsesRelativeOperationWithProxyVersion".
 	// Description: This EnvoyFilter does not have a priority and has a relative patch operation (NSTERT_BEFORE/AFTER, REPLACE, MERGE, DELETE) and proxyVersion set which can cause the EnvoyFilter not to be applied during an upgrade. Using the INSERT_FIRST or ADD option or setting the priority may help in ensuring the EnvoyFilter is applied correctly.
 	EnvoyFilterUsesRelativeOperationWithProxyVersion = diag.NewMessageType(diag.Warning, "IST0155", "This EnvoyFilter does not have a priority and has a relative patch operation (NSTERT_BEFORE/AFTER, REPLACE, MERGE, DELETE) and proxyVersion set which can cause the EnvoyFilter not to be applied during an upgrade. Using the INSERT_FIRST or ADD option or setting the priority may help in ensuring the EnvoyFilter is applied correctly.")
+
+	// ServiceEntryWorkloadSelectorMatchesOtherNamespace defines a diag.MessageType for message "ServiceEntryWorkloadSelectorMatchesOtherNamespace".
+	// Description: The workload selector of a ServiceEntry selects workloads in all namespaces, and matches a workload in a namespace other than its own.
+	ServiceEntryWorkloadSelectorMatchesOtherNamespace = diag.NewMessageType(diag.Warning, "IST0156", "The workload selector of this ServiceEntry matches pod %s in namespace %s through the '*' value of its workloadSelectorNamespaces annotation. List the selected namespaces explicitly if this match is intended.")
+
+	// ExternalNameServiceLoop defines a diag.MessageType for message "ExternalNameServiceLoop".
+	// Description: An ExternalName service points to a chain of ExternalName services which loops, so it has no endpoints.
+	ExternalNameServiceLoop = diag.NewMessageType(diag.Error, "IST0157", "The chain of ExternalName services %s loops, so this service has no endpoints.")
+
+	// ExternalNameServiceChain defines a diag.MessageType for message "ExternalNameServiceChain".
+	// Description: An ExternalName service points to another ExternalName service. Istio sends its traffic to the end of the chain directly.
+	ExternalNameServiceChain = diag.NewMessageType(diag.Info, "IST0158", "This ExternalName service resolves to %s through the chain of ExternalName services %s.")
+
+	// ServiceIPFamilyMismatch defines a diag.MessageType for message "ServiceIPFamilyMismatch".
+	// Description: The IP families of a service do not match its IP family policy, its cluster IPs or its endpoints.
+	ServiceIPFamilyMismatch = diag.NewMessageType(diag.Error, "IST0159", "The IP families of this service do not match: %s.")
+
+	// VirtualServiceRoutePriorityTie defines a diag.MessageType for message "VirtualServiceRoutePriorityTie".
+	// Description: Several virtual services bind the same host of a gateway with the same route priority, so their routes are merged in creation order.
+	VirtualServiceRoutePriorityTie = diag.NewMessageType(diag.Info, "IST0160", "This virtual service and %s bind host %s of gateway %s with the same route priority %d, so their routes are merged in creation order. Set the networking.istio.io/routePriority annotation to order them explicitly.")
+
+	// ConflictingDestinationRuleTLSModes defines a diag.MessageType for message "ConflictingDestinationRuleTLSModes".
+	// Description: DestinationRules of several namespaces set different TLS modes for the same host, so the TLS mode of the traffic to the host depends on the namespace of the client.
+	ConflictingDestinationRuleTLSModes = diag.NewMessageType(diag.Warning, "IST0161", "This destination rule sets TLS mode %s for host %s, but %s set different TLS modes. Proxies in namespace %s use %s, and proxies in other namespaces use %s.")
+
+	// VirtualServiceRoutesNotInProxy defines a diag.MessageType for message "VirtualServiceRoutesNotInProxy".
+	// Description: A proxy routes traffic to a host of a VirtualService without the routes of the VirtualService, according to its config dump.
+	VirtualServiceRoutesNotInProxy = diag.NewMessageType(diag.Warning, "IST0162", "The config dump of proxy %s routes traffic to host %s without the routes of this VirtualService. The proxy may be disconnected from istiod or may have rejected the configuration, or the config dump may be stale.")
+
+	// EnvoyFilterIncompatibleWithProxyVersion defines a diag.MessageType for message "EnvoyFilterIncompatibleWithProxyVersion".
+	// Description: An EnvoyFilter uses an Envoy filter name, type or field that the versions of the proxies it applies to no longer support, so the proxies reject or ignore the patch.
+	EnvoyFilterIncompatibleWithProxyVersion = diag.NewMessageType(diag.Error, "IST0163", "Patch %d of this EnvoyFilter uses %s, which proxies of version %s and later no longer support, but it applies to proxies of versions %s. Use %s instead.")
+
+	// EnvoyFilterUsesDeprecatedConfig defines a diag.MessageType for message "EnvoyFilterUsesDeprecatedConfig".
+	// Description: An EnvoyFilter uses a deprecated Envoy filter name, type or field, which may break when the proxies it applies to are upgraded.
+	EnvoyFilterUsesDeprecatedConfig = diag.NewMessageType(diag.Warning, "IST0164", "Patch %d of this EnvoyFilter uses %s, which is deprecated since proxy version %s. Use %s instead.")
 )
 
 // All returns a list of all known message types.
@@ -273,9 +309,523 @@ func All() []*diag.MessageType {
 		EnvoyFilterUsesAddOperationIncorrectly,
 		EnvoyFilterUsesRemoveOperationIncorrectly,
 		EnvoyFilterUsesRelativeOperationWithProxyVersion,
+		ServiceEntryWorkloadSelectorMatchesOtherNamespace,
+		ExternalNameServiceLoop,
+		ExternalNameServiceChain,
+		ServiceIPFamilyMismatch,
+		VirtualServiceRoutePriorityTie,
+		ConflictingDestinationRuleTLSModes,
+		VirtualServiceRoutesNotInProxy,
+		EnvoyFilterIncompatibleWithProxyVersion,
+		EnvoyFilterUsesDeprecatedConfig,
 	}
 }
 
+// explanations are the explanations of all known message types, ordered by code.
+var explanations = []*Explanation{
+	{
+		Code:        "IST0001",
+		Name:        "InternalError",
+		Level:       "Error",
+		Description: "There was an internal error in the toolchain. This is almost always a bug in the implementation.",
+		Template:    "Internal error: %v",
+	},
+	{
+		Code:        "IST0002",
+		Name:        "Deprecated",
+		Level:       "Warning",
+		Description: "A feature that the configuration is depending on is now deprecated.",
+		Template:    "Deprecated: %s",
+	},
+	{
+		Code:        "IST0101",
+		Name:        "ReferencedResourceNotFound",
+		Level:       "Error",
+		Description: "A resource being referenced does not exist.",
+		Template:    "Referenced %s not found: %q",
+		Causes: []string{
+			"The referenced resource is misspelled, or lives in another namespace than the one it is looked up in.",
+			"The referenced resource was deleted, or is not applied yet.",
+		},
+		Remediation: "Create the referenced resource, or fix the reference to point to an existing resource, using its fully qualified name when it is in another namespace.",
+	},
+	{
+		Code:        "IST0102",
+		Name:        "NamespaceNotInjected",
+		Level:       "Info",
+		Description: "A namespace is not enabled for Istio injection.",
+		Template:    "The namespace is not enabled for Istio injection. Run 'kubectl label namespace %s istio-injection=enabled' to enable it, or 'kubectl label namespace %s istio-injection=disabled' to explicitly mark it as not needing injection.",
+		Causes: []string{
+			"The namespace has neither the istio-injection nor the istio.io/rev label.",
+		},
+		Remediation: "Label the namespace with istio-injection=enabled, or istio.io/rev=<revision>, and restart its workloads. Label it istio-injection=disabled if it should not be part of the mesh.",
+	},
+	{
+		Code:        "IST0103",
+		Name:        "PodMissingProxy",
+		Level:       "Warning",
+		Description: "A pod is missing the Istio proxy.",
+		Template:    "The pod %s is missing the Istio proxy. This can often be resolved by restarting or redeploying the workload.",
+		Causes: []string{
+			"The pod was created before its namespace was enabled for injection.",
+			"The injection webhook was not reachable when the pod was created.",
+			"The pod opts out of injection with the sidecar.istio.io/inject annotation or label.",
+		},
+		Remediation: "Restart the workload once injection is enabled for its namespace, for example with 'kubectl rollout restart deployment <name>'.",
+	},
+	{
+		Code:        "IST0104",
+		Name:        "GatewayPortNotOnWorkload",
+		Level:       "Warning",
+		Description: "Unhandled gateway port",
+		Template:    "The gateway refers to a port that is not exposed on the workload (pod selector %s; port %d)",
+		Causes: []string{
+			"The gateway server port is not exposed by any container port of the selected gateway pods.",
+		},
+		Remediation: "Use a port the gateway workload exposes, or expose the port on the gateway deployment and service.",
+	},
+	{
+		Code:        "IST0105",
+		Name:        "IstioProxyImageMismatch",
+		Level:       "Warning",
+		Description: "The image of the Istio proxy running on the pod does not match the image defined in the injection configuration.",
+		Template:    "The image of the Istio proxy running on the pod does not match the image defined in the injection configuration (pod image: %s; injection configuration image: %s). This often happens after upgrading the Istio control-plane and can be fixed by redeploying the pod.",
+		Causes: []string{
+			"The control plane was upgraded but the pod still runs the proxy injected by the previous version.",
+		},
+		Remediation: "Restart the workload so that it is injected with the current proxy image.",
+	},
+	{
+		Code:        "IST0106",
+		Name:        "SchemaValidationError",
+		Level:       "Error",
+		Description: "The resource has a schema validation error.",
+		Template:    "Schema validation error: %v",
+		Causes: []string{
+			"A field has an invalid value, or a required field is missing.",
+		},
+		Remediation: "Fix the resource according to the error, see the API reference of its kind.",
+	},
+	{
+		Code:        "IST0107",
+		Name:        "MisplacedAnnotation",
+		Level:       "Warning",
+		Description: "An Istio annotation is applied to the wrong kind of resource.",
+		Template:    "Misplaced annotation: %s can only be applied to %s",
+		Causes: []string{
+			"The annotation is set on a kind of resource which does not support it, for example a pod annotation set on a deployment.",
+		},
+		Remediation: "Move the annotation to a resource kind it applies to, such as the pod template of the deployment.",
+	},
+	{
+		Code:        "IST0108",
+		Name:        "UnknownAnnotation",
+		Level:       "Warning",
+		Description: "An Istio annotation is not recognized for any kind of resource",
+		Template:    "Unknown annotation: %s",
+		Causes: []string{
+			"The annotation is misspelled.",
+			"The annotation was removed in this version of Istio.",
+		},
+		Remediation: "Fix the name of the annotation, or remove it.",
+	},
+	{
+		Code:        "IST0109",
+		Name:        "ConflictingMeshGatewayVirtualServiceHosts",
+		Level:       "Error",
+		Description: "Conflicting hosts on VirtualServices associated with mesh gateway",
+		Template:    "The VirtualServices %s associated with mesh gateway define the same host %s which can lead to undefined behavior. This can be fixed by merging the conflicting VirtualServices into a single resource.",
+		Causes: []string{
+			"Several VirtualServices bound to the mesh gateway define routes for the same host.",
+		},
+		Remediation: "Merge the routes of the host into a single VirtualService, or use delegate VirtualServices.",
+	},
+	{
+		Code:        "IST0110",
+		Name:        "ConflictingSidecarWorkloadSelectors",
+		Level:       "Error",
+		Description: "A Sidecar resource selects the same workloads as another Sidecar resource",
+		Template:    "The Sidecars %v in namespace %q select the same workload pod %q, which can lead to undefined behavior.",
+		Causes: []string{
+			"Several Sidecar resources have workload selectors matching the same workloads.",
+		},
+		Remediation: "Make the workload selectors of the Sidecar resources disjoint, or merge the resources.",
+	},
+	{
+		Code:        "IST0111",
+		Name:        "MultipleSidecarsWithoutWorkloadSelectors",
+		Level:       "Error",
+		Description: "More than one sidecar resource in a namespace has no workload selector",
+		Template:    "The Sidecars %v in namespace %q have no workload selector, which can lead to undefined behavior.",
+	},
+	{
+		Code:        "IST0112",
+		Name:        "VirtualServiceDestinationPortSelectorRequired",
+		Level:       "Error",
+		Description: "A VirtualService routes to a service with more than one port exposed, but does not specify which to use.",
+		Template:    "This VirtualService routes to a service %q that exposes multiple ports %v. Specifying a port in the destination is required to disambiguate.",
+		Causes: []string{
+			"The destination of the route has no port and its service exposes several ports.",
+		},
+		Remediation: "Set the port of the destination in the VirtualService route.",
+	},
+	{
+		Code:        "IST0113",
+		Name:        "MTLSPolicyConflict",
+		Level:       "Error",
+		Description: "A DestinationRule and Policy are in conflict with regards to mTLS.",
+		Template:    "A DestinationRule and Policy are in conflict with regards to mTLS for host %s. The DestinationRule %q specifies that mTLS must be %t but the Policy object %q specifies %s.",
+	},
+	{
+		Code:        "IST0116",
+		Name:        "DeploymentAssociatedToMultipleServices",
+		Level:       "Warning",
+		Description: "The resulting pods of a service mesh deployment can't be associated with multiple services using the same port but different protocols.",
+		Template:    "This deployment %s is associated with multiple services using port %d but different protocols: %v",
+	},
+	{
+		Code:        "IST0117",
+		Name:        "DeploymentRequiresServiceAssociated",
+		Level:       "Warning",
+		Description: "The resulting pods of a service mesh deployment must be associated with at least one service.",
+		Template:    "No service associated with this deployment. Service mesh deployments must be associated with a service.",
+	},
+	{
+		Code:        "IST0118",
+		Name:        "PortNameIsNotUnderNamingConvention",
+		Level:       "Info",
+		Description: "Port name is not under naming convention. Protocol detection is applied to the port.",
+		Template:    "Port name %s (port: %d, targetPort: %s) doesn't follow the naming convention of Istio port.",
+		Causes: []string{
+			"The service port name does not start with a known protocol prefix, and has no appProtocol either.",
+		},
+		Remediation: "Name the port <protocol>[-<suffix>], such as http-web, or set its appProtocol, so that the protocol is not detected.",
+	},
+	{
+		Code:        "IST0119",
+		Name:        "JwtFailureDueToInvalidServicePortPrefix",
+		Level:       "Warning",
+		Description: "Authentication policy with JWT targets Service with invalid port specification.",
+		Template:    "Authentication policy with JWT targets Service with invalid port specification (port: %d, name: %s, protocol: %s, targetPort: %s).",
+	},
+	{
+		Code:        "IST0122",
+		Name:        "InvalidRegexp",
+		Level:       "Warning",
+		Description: "Invalid Regex",
+		Template:    "Field %q regular expression invalid: %q (%s)",
+	},
+	{
+		Code:        "IST0123",
+		Name:        "NamespaceMultipleInjectionLabels",
+		Level:       "Warning",
+		Description: "A namespace has both new and legacy injection labels",
+		Template:    "The namespace has both new and legacy injection labels. Run 'kubectl label namespace %s istio.io/rev-' or 'kubectl label namespace %s istio-injection-'",
+	},
+	{
+		Code:        "IST0125",
+		Name:        "InvalidAnnotation",
+		Level:       "Warning",
+		Description: "An Istio annotation that is not valid",
+		Template:    "Invalid annotation %s: %s",
+	},
+	{
+		Code:        "IST0126",
+		Name:        "UnknownMeshNetworksServiceRegistry",
+		Level:       "Error",
+		Description: "A service registry in Mesh Networks is unknown",
+		Template:    "Unknown service registry %s in network %s",
+	},
+	{
+		Code:        "IST0127",
+		Name:        "NoMatchingWorkloadsFound",
+		Level:       "Warning",
+		Description: "There aren't workloads matching the resource labels",
+		Template:    "No matching workloads for this resource with the following labels: %s",
+		Causes: []string{
+			"The workload selector of the resource has a typo, or its workloads are not deployed yet.",
+			"The workloads are in another namespace than the resource.",
+		},
+		Remediation: "Fix the workload selector to match the labels of the intended pods, in the namespace of the resource.",
+	},
+	{
+		Code:        "IST0128",
+		Name:        "NoServerCertificateVerificationDestinationLevel",
+		Level:       "Error",
+		Description: "No caCertificates are set in DestinationRule, this results in no verification of presented server certificate.",
+		Template:    "DestinationRule %s in namespace %s has TLS mode set to %s but no caCertificates are set to validate server identity for host: %s",
+		Causes: []string{
+			"The DestinationRule uses SIMPLE or MUTUAL TLS without caCertificates.",
+		},
+		Remediation: "Set caCertificates in the TLS settings of the DestinationRule so that the server certificate is verified.",
+	},
+	{
+		Code:        "IST0129",
+		Name:        "NoServerCertificateVerificationPortLevel",
+		Level:       "Warning",
+		Description: "No caCertificates are set in DestinationRule, this results in no verification of presented server certificate for traffic to a given port.",
+		Template:    "DestinationRule %s in namespace %s has TLS mode set to %s but no caCertificates are set to validate server identity for host: %s at port %s",
+	},
+	{
+		Code:        "IST0130",
+		Name:        "VirtualServiceUnreachableRule",
+		Level:       "Warning",
+		Description: "A VirtualService rule will never be used because a previous rule uses the same match.",
+		Template:    "VirtualService rule %v not used (%s).",
+		Causes: []string{
+			"A previous rule of the VirtualService has the same match, or no match at all.",
+		},
+		Remediation: "Remove the unreachable rule, or reorder the rules so that the more specific matches come first.",
+	},
+	{
+		Code:        "IST0131",
+		Name:        "VirtualServiceIneffectiveMatch",
+		Level:       "Info",
+		Description: "A VirtualService rule match duplicates a match in a previous rule.",
+		Template:    "VirtualService rule %v match %v is not used (duplicate/overlapping match in rule %v).",
+	},
+	{
+		Code:        "IST0132",
+		Name:        "VirtualServiceHostNotFoundInGateway",
+		Level:       "Warning",
+		Description: "Host defined in VirtualService not found in Gateway.",
+		Template:    "one or more host %v defined in VirtualService %s not found in Gateway %s.",
+		Causes: []string{
+			"The VirtualService is bound to a gateway whose servers do not list the host.",
+		},
+		Remediation: "Add the host to a server of the gateway, or remove the gateway from the VirtualService.",
+	},
+	{
+		Code:        "IST0133",
+		Name:        "SchemaWarning",
+		Level:       "Warning",
+		Description: "The resource has a schema validation warning.",
+		Template:    "Schema validation warning: %v",
+	},
+	{
+		Code:        "IST0134",
+		Name:        "ServiceEntryAddressesRequired",
+		Level:       "Warning",
+		Description: "Virtual IP addresses are required for ports serving TCP (or unset) protocol",
+		Template:    "ServiceEntry addresses are required for this protocol.",
+	},
+	{
+		Code:        "IST0135",
+		Name:        "DeprecatedAnnotation",
+		Level:       "Info",
+		Description: "A resource is using a deprecated Istio annotation.",
+		Template:    "Annotation %q has been deprecated%s and may not work in future Istio versions.",
+	},
+	{
+		Code:        "IST0136",
+		Name:        "AlphaAnnotation",
+		Level:       "Info",
+		Description: "An Istio annotation may not be suitable for production.",
+		Template:    "Annotation %q is part of an alpha-phase feature and may be incompletely supported.",
+	},
+	{
+		Code:        "IST0137",
+		Name:        "DeploymentConflictingPorts",
+		Level:       "Warning",
+		Description: "Two services selecting the same workload with the same targetPort MUST refer to the same port.",
+		Template:    "This deployment %s is associated with multiple services %v using targetPort %q but different ports: %v.",
+	},
+	{
+		Code:        "IST0138",
+		Name:        "GatewayDuplicateCertificate",
+		Level:       "Warning",
+		Description: "Duplicate certificate in multiple gateways may cause 404s if clients re-use HTTP2 connections.",
+		Template:    "Duplicate certificate in multiple gateways %v may cause 404s if clients re-use HTTP2 connections.",
+	},
+	{
+		Code:        "IST0139",
+		Name:        "InvalidWebhook",
+		Level:       "Error",
+		Description: "Webhook is invalid or references a control plane service that does not exist.",
+		Template:    "%v",
+		Causes: []string{
+			"The webhook calls a control plane service which was removed, for example after an uninstall or a revision upgrade.",
+			"The webhook is not valid, for example it has an invalid namespace selector.",
+		},
+		Remediation: "Remove the stale webhook configuration, or reinstall the control plane revision it belongs to.",
+	},
+	{
+		Code:        "IST0140",
+		Name:        "IngressRouteRulesNotAffected",
+		Level:       "Warning",
+		Description: "Route rules have no effect on ingress gateway requests",
+		Template:    "Subset in virtual service %s has no effect on ingress gateway %s requests",
+	},
+	{
+		Code:        "IST0141",
+		Name:        "InsufficientPermissions",
+		Level:       "Error",
+		Description: "Required permissions to install Istio are missing.",
+		Template:    "Missing required permission to create resource %v (%v)",
+	},
+	{
+		Code:        "IST0142",
+		Name:        "UnsupportedKubernetesVersion",
+		Level:       "Error",
+		Description: "The Kubernetes version is not supported",
+		Template:    "The Kubernetes Version %q is lower than the minimum version: %v",
+	},
+	{
+		Code:        "IST0143",
+		Name:        "LocalhostListener",
+		Level:       "Error",
+		Description: "A port exposed in a Service is bound to a localhost address",
+		Template:    "Port %v is exposed in a Service but listens on localhost. It will not be exposed to other pods.",
+	},
+	{
+		Code:        "IST0144",
+		Name:        "InvalidApplicationUID",
+		Level:       "Warning",
+		Description: "Application pods should not run as user ID (UID) 1337",
+		Template:    "User ID (UID) 1337 is reserved for the sidecar proxy.",
+	},
+	{
+		Code:        "IST0145",
+		Name:        "ConflictingGateways",
+		Level:       "Error",
+		Description: "Gateway should not have the same selector, port and matched hosts of server",
+		Template:    "Conflict with gateways %s (workload selector %s, port %s, hosts %v).",
+	},
+	{
+		Code:        "IST0146",
+		Name:        "ImageAutoWithoutInjectionWarning",
+		Level:       "Warning",
+		Description: "Deployments with `image: auto` should be targeted for injection.",
+		Template:    "%s %s contains `image: auto` but does not match any Istio injection webhook selectors.",
+	},
+	{
+		Code:        "IST0147",
+		Name:        "ImageAutoWithoutInjectionError",
+		Level:       "Error",
+		Description: "Pods with `image: auto` should be targeted for injection.",
+		Template:    "%s %s contains `image: auto` but does not match any Istio injection webhook selectors.",
+	},
+	{
+		Code:        "IST0148",
+		Name:        "NamespaceInjectionEnabledByDefault",
+		Level:       "Info",
+		Description: "user namespace should be injectable if Istio is installed with enableNamespacesByDefault enabled and neither injection label is set.",
+		Template:    "is enabled for Istio injection, as Istio is installed with enableNamespacesByDefault as true.",
+	},
+	{
+		Code:        "IST0149",
+		Name:        "JwtClaimBasedRoutingWithoutRequestAuthN",
+		Level:       "Error",
+		Description: "Virtual service using JWT claim based routing without request authentication.",
+		Template:    "The virtual service uses the JWT claim based routing (key: %s) but found no request authentication for the gateway (%s) pod (%s). The request authentication must first be applied for the gateway pods to validate the JWT token and make the claims available for routing.",
+	},
+	{
+		Code:        "IST0150",
+		Name:        "ExternalNameServiceTypeInvalidPortName",
+		Level:       "Warning",
+		Description: "Proxy may prevent tcp named ports and unmatched traffic for ports serving TCP protocol from being forwarded correctly for ExternalName services.",
+		Template:    "Port name for ExternalName service is invalid. Proxy may prevent tcp named ports and unmatched traffic for ports serving TCP protocol from being forwarded correctly",
+	},
+	{
+		Code:        "IST0151",
+		Name:        "EnvoyFilterUsesRelativeOperation",
+		Level:       "Warning",
+		Description: "This EnvoyFilter does not have a priority and has a relative patch operation set which can cause the EnvoyFilter not to be applied. Using the INSERT_FIRST or ADD option or setting the priority may help in ensuring the EnvoyFilter is applied correctly.",
+		Template:    "This EnvoyFilter does not have a priority and has a relative patch operation set which can cause the EnvoyFilter not to be applied. Using the INSERT_FIRST of ADD option or setting the priority may help in ensuring the EnvoyFilter is applied correctly.",
+	},
+	{
+		Code:        "IST0152",
+		Name:        "EnvoyFilterUsesReplaceOperationIncorrectly",
+		Level:       "Error",
+		Description: "The REPLACE operation is only valid for HTTP_FILTER and NETWORK_FILTER.",
+		Template:    "The REPLACE operation is only valid for HTTP_FILTER and NETWORK_FILTER.",
+	},
+	{
+		Code:        "IST0153",
+		Name:        "EnvoyFilterUsesAddOperationIncorrectly",
+		Level:       "Error",
+		Description: "The ADD operation will be ignored when applyTo is set to ROUTE_CONFIGURATION, or HTTP_ROUTE.",
+		Template:    "The ADD operation will be ignored when applyTo is set to ROUTE_CONFIGURATION, or HTTP_ROUTE.",
+	},
+	{
+		Code:        "IST0154",
+		Name:        "EnvoyFilterUsesRemoveOperationIncorrectly",
+		Level:       "Error",
+		Description: "The REMOVE operation will be ignored when applyTo is set to ROUTE_CONFIGURATION, or HTTP_ROUTE.",
+		Template:    "The REMOVE operation will be ignored when applyTo is set to ROUTE_CONFIGURATION, or HTTP_ROUTE.",
+	},
+	{
+		Code:        "IST0155",
+		Name:        "EnvoyFilterUsesRelativeOperationWithProxyVersion",
+		Level:       "Warning",
+		Description: "This EnvoyFilter does not have a priority and has a relative patch operation (NSTERT_BEFORE/AFTER, REPLACE, MERGE, DELETE) and proxyVersion set which can cause the EnvoyFilter not to be applied during an upgrade. Using the INSERT_FIRST or ADD option or setting the priority may help in ensuring the EnvoyFilter is applied correctly.",
+		Template:    "This EnvoyFilter does not have a priority and has a relative patch operation (NSTERT_BEFORE/AFTER, REPLACE, MERGE, DELETE) and proxyVersion set which can cause the EnvoyFilter not to be applied during an upgrade. Using the INSERT_FIRST or ADD option or setting the priority may help in ensuring the EnvoyFilter is applied correctly.",
+	},
+	{
+		Code:        "IST0156",
+		Name:        "ServiceEntryWorkloadSelectorMatchesOtherNamespace",
+		Level:       "Warning",
+		Description: "The workload selector of a ServiceEntry selects workloads in all namespaces, and matches a workload in a namespace other than its own.",
+		Template:    "The workload selector of this ServiceEntry matches pod %s in namespace %s through the '*' value of its workloadSelectorNamespaces annotation. List the selected namespaces explicitly if this match is intended.",
+	},
+	{
+		Code:        "IST0157",
+		Name:        "ExternalNameServiceLoop",
+		Level:       "Error",
+		Description: "An ExternalName service points to a chain of ExternalName services which loops, so it has no endpoints.",
+		Template:    "The chain of ExternalName services %s loops, so this service has no endpoints.",
+	},
+	{
+		Code:        "IST0158",
+		Name:        "ExternalNameServiceChain",
+		Level:       "Info",
+		Description: "An ExternalName service points to another ExternalName service. Istio sends its traffic to the end of the chain directly.",
+		Template:    "This ExternalName service resolves to %s through the chain of ExternalName services %s.",
+	},
+	{
+		Code:        "IST0159",
+		Name:        "ServiceIPFamilyMismatch",
+		Level:       "Error",
+		Description: "The IP families of a service do not match its IP family policy, its cluster IPs or its endpoints.",
+		Template:    "The IP families of this service do not match: %s.",
+	},
+	{
+		Code:        "IST0160",
+		Name:        "VirtualServiceRoutePriorityTie",
+		Level:       "Info",
+		Description: "Several virtual services bind the same host of a gateway with the same route priority, so their routes are merged in creation order.",
+		Template:    "This virtual service and %s bind host %s of gateway %s with the same route priority %d, so their routes are merged in creation order. Set the networking.istio.io/routePriority annotation to order them explicitly.",
+	},
+	{
+		Code:        "IST0161",
+		Name:        "ConflictingDestinationRuleTLSModes",
+		Level:       "Warning",
+		Description: "DestinationRules of several namespaces set different TLS modes for the same host, so the TLS mode of the traffic to the host depends on the namespace of the client.",
+		Template:    "This destination rule sets TLS mode %s for host %s, but %s set different TLS modes. Proxies in namespace %s use %s, and proxies in other namespaces use %s.",
+	},
+	{
+		Code:        "IST0162",
+		Name:        "VirtualServiceRoutesNotInProxy",
+		Level:       "Warning",
+		Description: "A proxy routes traffic to a host of a VirtualService without the routes of the VirtualService, according to its config dump.",
+		Template:    "The config dump of proxy %s routes traffic to host %s without the routes of this VirtualService. The proxy may be disconnected from istiod or may have rejected the configuration, or the config dump may be stale.",
+	},
+	{
+		Code:        "IST0163",
+		Name:        "EnvoyFilterIncompatibleWithProxyVersion",
+		Level:       "Error",
+		Description: "An EnvoyFilter uses an Envoy filter name, type or field that the versions of the proxies it applies to no longer support, so the proxies reject or ignore the patch.",
+		Template:    "Patch %d of this EnvoyFilter uses %s, which proxies of version %s and later no longer support, but it applies to proxies of versions %s. Use %s instead.",
+	},
+	{
+		Code:        "IST0164",
+		Name:        "EnvoyFilterUsesDeprecatedConfig",
+		Level:       "Warning",
+		Description: "An EnvoyFilter uses a deprecated Envoy filter name, type or field, which may break when the proxies it applies to are upgraded.",
+		Template:    "Patch %d of this EnvoyFilter uses %s, which is deprecated since proxy version %s. Use %s instead.",
+	},
+}
+
 // NewInternalError returns a new diag.Message based on InternalError.
 func NewInternalError(r *resource.Instance, detail string) diag.Message {
 	return diag.NewMessage(
@@ -785,3 +1335,102 @@ func NewEnvoyFilterUsesRelativeOperationWithProxyVersion(r *resource.Instance) d
 		r,
 	)
 }
+
+// NewServiceEntryWorkloadSelectorMatchesOtherNamespace returns a new diag.Message based on ServiceEntryWorkloadSelectorMatchesOtherNamespace.
+func NewServiceEntryWorkloadSelectorMatchesOtherNamespace(r *resource.Instance, pod string, namespace string) diag.Message {
+	return diag.NewMessage(
+		ServiceEntryWorkloadSelectorMatchesOtherNamespace,
+		r,
+		pod,
+		namespace,
+	)
+}
+
+// NewExternalNameServiceLoop returns a new diag.Message based on ExternalNameServiceLoop.
+func NewExternalNameServiceLoop(r *resource.Instance, chain string) diag.Message {
+	return diag.NewMessage(
+		ExternalNameServiceLoop,
+		r,
+		chain,
+	)
+}
+
+// NewExternalNameServiceChain returns a new diag.Message based on ExternalNameServiceChain.
+func NewExternalNameServiceChain(r *resource.Instance, target string, chain string) diag.Message {
+	return diag.NewMessage(
+		ExternalNameServiceChain,
+		r,
+		target,
+		chain,
+	)
+}
+
+// NewServiceIPFamilyMismatch returns a new diag.Message based on ServiceIPFamilyMismatch.
+func NewServiceIPFamilyMismatch(r *resource.Instance, detail string) diag.Message {
+	return diag.NewMessage(
+		ServiceIPFamilyMismatch,
+		r,
+		detail,
+	)
+}
+
+// NewVirtualServiceRoutePriorityTie returns a new diag.Message based on VirtualServiceRoutePriorityTie.
+func NewVirtualServiceRoutePriorityTie(r *resource.Instance, virtualServices string, host string, gateway string, priority int) diag.Message {
+	return diag.NewMessage(
+		VirtualServiceRoutePriorityTie,
+		r,
+		virtualServices,
+		host,
+		gateway,
+		priority,
+	)
+}
+
+// NewConflictingDestinationRuleTLSModes returns a new diag.Message based on ConflictingDestinationRuleTLSModes.
+func NewConflictingDestinationRuleTLSModes(r *resource.Instance, mode string, host string, destinationRules string, namespace string, namespaceWinner string, otherWinner string) diag.Message {
+	return diag.NewMessage(
+		ConflictingDestinationRuleTLSModes,
+		r,
+		mode,
+		host,
+		destinationRules,
+		namespace,
+		namespaceWinner,
+		otherWinner,
+	)
+}
+
+// NewVirtualServiceRoutesNotInProxy returns a new diag.Message based on VirtualServiceRoutesNotInProxy.
+func NewVirtualServiceRoutesNotInProxy(r *resource.Instance, proxy string, host string) diag.Message {
+	return diag.NewMessage(
+		VirtualServiceRoutesNotInProxy,
+		r,
+		proxy,
+		host,
+	)
+}
+
+// NewEnvoyFilterIncompatibleWithProxyVersion returns a new diag.Message based on EnvoyFilterIncompatibleWithProxyVersion.
+func NewEnvoyFilterIncompatibleWithProxyVersion(r *resource.Instance, patch int, usage string, removedIn string, proxyVersions string, replacement string) diag.Message {
+	return diag.NewMessage(
+		EnvoyFilterIncompatibleWithProxyVersion,
+		r,
+		patch,
+		usage,
+		removedIn,
+		proxyVersions,
+		replacement,
+	)
+}
+
+// NewEnvoyFilterUsesDeprecatedConfig returns a new diag.Message based on EnvoyFilterUsesDeprecatedConfig.
+func NewEnvoyFilterUsesDeprecatedConfig(r *resource.Instance, patch int, usage string, deprecatedIn string, replacement string) diag.Message {
+	return diag.NewMessage(
+		EnvoyFilterUsesDeprecatedConfig,
+		r,
+		patch,
+		usage,
+		deprecatedIn,
+		replacement,
+	)
+}
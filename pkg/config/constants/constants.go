@@ -86,6 +86,9 @@ const (
 	// The data name in the ConfigMap of each namespace storing the root cert of non-Kube CA.
 	CACertNamespaceConfigMapDataName = "root-cert.pem"
 
+	// The data name in the ConfigMap of each namespace storing the CRLs of the CA, if it publishes any.
+	CACertNamespaceConfigMapCRLDataName = "ca-crl.pem"
+
 	// PodInfoLabelsPath is the filepath that pod labels will be stored
 	// This is typically set by the downward API
 	PodInfoLabelsPath = "./etc/istio/pod/labels"
@@ -123,9 +126,129 @@ const (
 	// InternalParentName declares the original resource of an internally-generate config. This is used by ingress and the gateway-api.
 	InternalParentName     = "internal.istio.io/parent"
 	InternalRouteSemantics = "internal.istio.io/route-semantics"
+	// InternalDelegatedRoutes records, on a merged root VirtualService with route policies, the HTTP routes merged
+	// from delegates, with the route and the VirtualService they were delegated by.
+	InternalDelegatedRoutes = "internal.istio.io/delegated-routes"
 	RouteSemanticsIngress  = "ingress"
 	RouteSemanticsGateway  = "gateway"
 
+	// DefaultHTTPRetryPolicyAnnotation sets, on a mesh/namespace level DestinationRule, the HTTP retry policy in
+	// JSON form used by routes of proxies in that namespace that have no retry policy in their VirtualService.
+	// It overrides the defaultHttpRetryPolicy of the mesh config.
+	DefaultHTTPRetryPolicyAnnotation = "networking.istio.io/defaultHttpRetryPolicy"
+
+	// TCPMaxConnectAttemptsAnnotation sets, on a DestinationRule, the number of times the TCP proxy of clients of the
+	// destination attempts to connect to an upstream endpoint, trying other endpoints after connection failures.
+	TCPMaxConnectAttemptsAnnotation = "networking.istio.io/tcpMaxConnectAttempts"
+
+	// WorkloadSelectorNamespacesAnnotation lists, on a ServiceEntry, the comma separated namespaces other than its own
+	// whose workloads its workload selector selects, or "*" for all namespaces. It is only honored when
+	// PILOT_ENABLE_CROSS_NAMESPACE_SERVICEENTRY_SELECTOR is enabled.
+	WorkloadSelectorNamespacesAnnotation = "networking.istio.io/workloadSelectorNamespaces"
+
+	// EgressBandwidthLimitAnnotation caps, on a pod, the bandwidth of the HTTP requests its sidecar sends to other
+	// services, such as "10Mbps". Kbps, Mbps and Gbps suffixes are supported. The limit is shared by all the
+	// outbound listeners of the sidecar. TCP traffic is not limited, as Envoy only limits the bandwidth of HTTP.
+	EgressBandwidthLimitAnnotation = "networking.istio.io/egressBandwidthLimit"
+	// IngressBandwidthLimitAnnotation caps, on a pod, the bandwidth of the HTTP requests its sidecar receives from
+	// other services, in the format of EgressBandwidthLimitAnnotation. The limit is shared by all the inbound
+	// listeners of the sidecar. TCP traffic is not limited either.
+	IngressBandwidthLimitAnnotation = "networking.istio.io/ingressBandwidthLimit"
+
+	// DNSTTLAnnotation sets, on a Service or ServiceEntry, the TTL of the records the sidecar DNS proxy returns for
+	// its hostnames, as a duration of at least 1s such as "5s".
+	DNSTTLAnnotation = "networking.istio.io/dnsTTL"
+
+	// TCPIdleTimeoutAnnotation sets, on a Service or ServiceEntry, the idle timeout of the TCP proxies of its clients
+	// and its workloads, overriding the mesh-wide default. It is a duration applying to all ports, such as "4h", or a
+	// comma separated list of port=duration entries, optionally with a duration for the other ports, such as
+	// "5432=4h,30m".
+	TCPIdleTimeoutAnnotation = "networking.istio.io/tcpIdleTimeout"
+
+	// SlowStartAggressionAnnotation sets, on a DestinationRule, the aggression of the slow start of its clusters, how
+	// fast the traffic to new endpoints ramps up during the warmupDurationSecs of their load balancer settings. 1.0 is
+	// linear, higher values send more traffic early in the window. It is a value applying to all subsets, such as
+	// "1.5", or a comma separated list of subset=aggression entries, optionally with a value for the other clusters,
+	// such as "canary=3,1.5". The clusters without warmupDurationSecs ignore it.
+	SlowStartAggressionAnnotation = "networking.istio.io/slowStartAggression"
+
+	// PreconnectRatioAnnotation sets, on a DestinationRule, how many connections its clients establish ahead of the
+	// requests to each endpoint of its clusters, as a ratio of the connections the requests in flight need, so that
+	// bursts of requests do not wait for new connections. It is a number from 1, no preconnect, to 3, applying to all
+	// subsets, such as "1.5", or a comma separated list of subset=ratio entries, optionally with a ratio for the other
+	// clusters, such as "canary=2,1.5".
+	PreconnectRatioAnnotation = "networking.istio.io/preconnectRatio"
+
+	// PredictivePreconnectRatioAnnotation sets, on a DestinationRule, the ratio of the connections its clients
+	// establish ahead of the requests to its clusters as a whole, before picking endpoints, with the syntax of
+	// PreconnectRatioAnnotation. It mostly helps low traffic clusters, where connections to an endpoint are rarely
+	// reused.
+	PredictivePreconnectRatioAnnotation = "networking.istio.io/predictivePreconnectRatio"
+
+	// EndpointPortAddressesAnnotation sets, on a ServiceEntry, different addresses per port name for its endpoints,
+	// such as external appliances exposing their control and data planes on different IPs. It is a JSON object of
+	// the port addresses by endpoint address, such as '{"10.0.0.1": {"control": "10.1.0.1"}}'. The ports without
+	// address keep the address of the endpoint.
+	EndpointPortAddressesAnnotation = "networking.istio.io/endpointPortAddresses"
+
+	// DNSSRVAnnotation names, on a ServiceEntry with STATIC resolution, a DNS SRV record such as
+	// "_ldap._tcp.example.com" from which pilot resolves its endpoints, with the port and weight of each target.
+	// It is only honored when PILOT_ENABLE_SERVICE_ENTRY_DNS_SRV is enabled.
+	DNSSRVAnnotation = "networking.istio.io/dnsSRV"
+
+	// WasmPluginTypeAnnotation sets, on a WasmPlugin, the type of filter it is inserted as: HTTP, the default, inserts
+	// an HTTP filter in HTTP connection managers, and NETWORK inserts a network filter in TCP filter chains.
+	WasmPluginTypeAnnotation = "extensions.istio.io/wasmPluginType"
+
+	// AccessLogFormatAnnotation overrides, on a Telemetry, the text format of the file access logs of the workloads
+	// the Telemetry applies to.
+	AccessLogFormatAnnotation = "telemetry.istio.io/accessLogFormat"
+
+	// AccessLogLabelsAnnotation overrides, on a Telemetry, the format of the file access logs of the workloads the
+	// Telemetry applies to with JSON logs, given as a JSON object mapping the keys of the logs to Envoy format strings.
+	AccessLogLabelsAnnotation = "telemetry.istio.io/accessLogLabels"
+
+	// CompressionAnnotation sets, on a VirtualService, the compression policy of its HTTP routes on the gateways it
+	// is bound to, in JSON form, such as {"routes": ["api"], "algorithms": ["br", "gzip"], "minContentLength": 1024}.
+	CompressionAnnotation = "networking.istio.io/compression"
+
+	// JwtForwardingAnnotation sets, on a VirtualService, how the gateways it is bound to forward the JWTs validated by
+	// RequestAuthentication to the upstreams of its HTTP routes, overriding the forwarding of the JWT rules. It is a
+	// JSON list of policies, such as '[{"routes": ["internal"], "mode": "payload", "payloadHeader": "x-jwt-claims"}]'.
+	JwtForwardingAnnotation = "security.istio.io/jwtForwarding"
+
+	// ConnectionRateLimitAnnotation limits, on a Gateway, the rate of the new connections the gateway accepts on its
+	// servers. It is a JSON object mapping the names of the servers, or "*" for the servers not listed, to a number of
+	// connections per fill interval, such as '{"*": {"connections": 1000}, "admin": {"connections": 10,
+	// "fillInterval": "10s", "burst": 20}}'.
+	ConnectionRateLimitAnnotation = "networking.istio.io/connectionRateLimit"
+
+	// RoutePriorityAnnotation sets, on a VirtualService, the order its HTTP routes are merged in with the routes of
+	// the other VirtualServices bound to the same gateway host: the routes of the VirtualServices of higher priority
+	// come first. It is an integer, 0 by default. The VirtualServices of the same priority are ordered by creation
+	// time, then by name and namespace.
+	RoutePriorityAnnotation = "networking.istio.io/routePriority"
+
+	// SidecarJobModeAnnotation sets, on the pods of a Job or CronJob, how their sidecar handles the completion of
+	// the job: "none", the default, keeps the sidecar running like in any other pod, and "terminate" shares the
+	// process namespace of the pod and stops the sidecar once the processes of the other containers exit, so that
+	// the pod completes. All the other containers must run to completion. It is ignored on other pods, including
+	// the pods of Argo Workflows, which stops their sidecars itself.
+	SidecarJobModeAnnotation = "sidecar.istio.io/jobMode"
+
+	// HoldApplicationUntilProxyStartsAnnotation sets, on a namespace, whether the application containers of its pods
+	// wait for their sidecar to start before starting, overriding holdApplicationUntilProxyStarts of the mesh config,
+	// and on a pod, whether its application containers wait, overriding the setting of its namespace. It is "true" or
+	// "false".
+	HoldApplicationUntilProxyStartsAnnotation = "sidecar.istio.io/holdApplicationUntilProxyStarts"
+
+	// GatewayDNSSANsAnnotation requests, on a gateway pod, DNS names to add as SANs to the certificate issued to the
+	// gateway by istiod, so that its SIMPLE TLS servers without a credentialName or certificate files present the
+	// hostnames of the gateway, if PILOT_ENABLE_GATEWAY_WORKLOAD_CERTIFICATE is set. It is a comma separated list of
+	// DNS names, such as "api.internal.example.com,admin.internal.example.com". The istiod CA only issues the
+	// certificate if CITADEL_DNS_SAN_ALLOWLIST allows all of them to the service account of the pod.
+	GatewayDNSSANsAnnotation = "security.istio.io/gatewayDNSSANs"
+
 	// TrustworthyJWTPath is the default 3P token to authenticate with third party services
 	TrustworthyJWTPath = "./var/run/secrets/tokens/istio-token"
 
@@ -88,3 +88,11 @@ func IsTCPServerWithTLSTermination(server *v1alpha3.Server) bool {
 	}
 	return false
 }
+
+// UsesWorkloadCertificate returns true if the TLS settings terminate TLS with the certificate istiod issues to the
+// gateway: SIMPLE TLS without a credentialName, a server certificate or a private key. The certificate holds the DNS
+// names requested by the security.istio.io/gatewayDNSSANs annotation of the gateway pod.
+func UsesWorkloadCertificate(tls *v1alpha3.ServerTLSSettings) bool {
+	return tls.GetMode() == v1alpha3.ServerTLSSettings_SIMPLE && tls.GetCredentialName() == "" &&
+		tls.GetServerCertificate() == "" && tls.GetPrivateKey() == ""
+}
@@ -0,0 +1,75 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"errors"
+	"strings"
+
+	coreV1 "k8s.io/api/core/v1"
+)
+
+// ErrExternalNameLoop is returned when a chain of ExternalName services loops.
+var ErrExternalNameLoop = errors.New("ExternalName services form a loop")
+
+// ResolveExternalName follows the chain of ExternalName services starting at the service name/namespace, whose
+// externalName is given, through the ExternalName services of the cluster it points to. It returns the host the chain
+// ends at: an external host, or the hostname of a service which is not an ExternalName service or does not exist.
+// The chain lists the hostnames of the ExternalName services traversed, starting with the given service.
+// getService returns the spec of a service of the cluster, or nil if it does not exist.
+func ResolveExternalName(name, namespace, externalName, domainSuffix string,
+	getService func(name, namespace string) *coreV1.ServiceSpec,
+) (target string, chain []string, err error) {
+	host := serviceHostname(name, namespace, domainSuffix)
+	visited := map[string]bool{host: true}
+	chain = []string{host}
+	target = externalName
+	for {
+		name, namespace, ok := splitServiceHostname(target, domainSuffix)
+		if !ok {
+			return target, chain, nil
+		}
+		spec := getService(name, namespace)
+		if spec == nil || spec.Type != coreV1.ServiceTypeExternalName || spec.ExternalName == "" {
+			return serviceHostname(name, namespace, domainSuffix), chain, nil
+		}
+		host = serviceHostname(name, namespace, domainSuffix)
+		chain = append(chain, host)
+		if visited[host] {
+			return "", chain, ErrExternalNameLoop
+		}
+		visited[host] = true
+		target = spec.ExternalName
+	}
+}
+
+func serviceHostname(name, namespace, domainSuffix string) string {
+	return name + "." + namespace + ".svc." + domainSuffix
+}
+
+// splitServiceHostname returns the name and namespace of the service with the hostname, such as
+// foo.bar.svc.cluster.local, if it is the hostname of a service of the cluster.
+func splitServiceHostname(hostname, domainSuffix string) (name, namespace string, ok bool) {
+	hostname = strings.TrimSuffix(strings.ToLower(hostname), ".")
+	prefix := strings.TrimSuffix(hostname, ".svc."+domainSuffix)
+	if prefix == hostname {
+		return "", "", false
+	}
+	parts := strings.Split(prefix, ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,90 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	coreV1 "k8s.io/api/core/v1"
+)
+
+func TestResolveExternalName(t *testing.T) {
+	services := map[string]*coreV1.ServiceSpec{
+		"a/ns":        {Type: coreV1.ServiceTypeExternalName, ExternalName: "b.ns.svc.cluster.local"},
+		"b/ns":        {Type: coreV1.ServiceTypeExternalName, ExternalName: "api.example.com"},
+		"c/ns":        {Type: coreV1.ServiceTypeExternalName, ExternalName: "D.other.svc.cluster.local."},
+		"d/other":     {Type: coreV1.ServiceTypeClusterIP},
+		"loop1/ns":    {Type: coreV1.ServiceTypeExternalName, ExternalName: "loop2.ns.svc.cluster.local"},
+		"loop2/ns":    {Type: coreV1.ServiceTypeExternalName, ExternalName: "loop1.ns.svc.cluster.local"},
+		"self/ns":     {Type: coreV1.ServiceTypeExternalName, ExternalName: "self.ns.svc.cluster.local"},
+		"missing/ns":  {Type: coreV1.ServiceTypeExternalName, ExternalName: "nope.ns.svc.cluster.local"},
+		"external/ns": {Type: coreV1.ServiceTypeExternalName, ExternalName: "example.com"},
+	}
+	getService := func(name, namespace string) *coreV1.ServiceSpec {
+		return services[name+"/"+namespace]
+	}
+	cases := []struct {
+		name   string
+		target string
+		chain  []string
+		err    error
+	}{
+		{
+			name:   "external",
+			target: "example.com",
+			chain:  []string{"external.ns.svc.cluster.local"},
+		},
+		{
+			name:   "a",
+			target: "api.example.com",
+			chain:  []string{"a.ns.svc.cluster.local", "b.ns.svc.cluster.local"},
+		},
+		{
+			name:   "c",
+			target: "d.other.svc.cluster.local",
+			chain:  []string{"c.ns.svc.cluster.local"},
+		},
+		{
+			name:   "missing",
+			target: "nope.ns.svc.cluster.local",
+			chain:  []string{"missing.ns.svc.cluster.local"},
+		},
+		{
+			name:  "loop1",
+			chain: []string{"loop1.ns.svc.cluster.local", "loop2.ns.svc.cluster.local", "loop1.ns.svc.cluster.local"},
+			err:   ErrExternalNameLoop,
+		},
+		{
+			name:  "self",
+			chain: []string{"self.ns.svc.cluster.local", "self.ns.svc.cluster.local"},
+			err:   ErrExternalNameLoop,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			target, chain, err := ResolveExternalName(tt.name, "ns", services[tt.name+"/ns"].ExternalName, "cluster.local", getService)
+			if err != tt.err {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if target != tt.target {
+				t.Errorf("got target %q, want %q", target, tt.target)
+			}
+			if !reflect.DeepEqual(chain, tt.chain) {
+				t.Errorf("got chain %v, want %v", chain, tt.chain)
+			}
+		})
+	}
+}
@@ -9,6 +9,8 @@ package collections
 import (
 	"reflect"
 
+	githubcomenvoyproxygocontrolplaneenvoyadminv3 "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+
 	istioioapiextensionsv1alpha1 "istio.io/api/extensions/v1alpha1"
 	istioioapimeshv1alpha1 "istio.io/api/mesh/v1alpha1"
 	istioioapimetav1alpha1 "istio.io/api/meta/v1alpha1"
@@ -23,6 +25,24 @@ import (
 
 var (
 
+	// EnvoyAdminV3Configdumps describes the collection
+	// envoy/admin/v3/configdumps
+	EnvoyAdminV3Configdumps = collection.Builder{
+		Name:         "envoy/admin/v3/configdumps",
+		VariableName: "EnvoyAdminV3Configdumps",
+		Resource: resource.Builder{
+			Group:         "",
+			Kind:          "ConfigDump",
+			Plural:        "configdumps",
+			Version:       "v3",
+			Proto:         "envoy.admin.v3.ConfigDump",
+			ReflectType:   reflect.TypeOf(&githubcomenvoyproxygocontrolplaneenvoyadminv3.ConfigDump{}).Elem(),
+			ProtoPackage:  "github.com/envoyproxy/go-control-plane/envoy/admin/v3",
+			ClusterScoped: false,
+			ValidateProto: validation.EmptyValidate,
+		}.MustBuild(),
+	}.MustBuild()
+
 	// IstioExtensionsV1Alpha1Wasmplugins describes the collection
 	// istio/extensions/v1alpha1/wasmplugins
 	IstioExtensionsV1Alpha1Wasmplugins = collection.Builder{
@@ -337,6 +357,7 @@ var (
 
 	// All contains all collections in the system.
 	All = collection.NewSchemasBuilder().
+		MustAdd(EnvoyAdminV3Configdumps).
 		MustAdd(IstioExtensionsV1Alpha1Wasmplugins).
 		MustAdd(IstioMeshV1Alpha1MeshConfig).
 		MustAdd(IstioMeshV1Alpha1MeshNetworks).
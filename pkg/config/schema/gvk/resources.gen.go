@@ -10,6 +10,7 @@ import (
 
 var (
 	AuthorizationPolicy = config.GroupVersionKind{Group: "security.istio.io", Version: "v1beta1", Kind: "AuthorizationPolicy"}
+	ConfigDump = config.GroupVersionKind{Group: "", Version: "v3", Kind: "ConfigDump"}
 	ConfigMap = config.GroupVersionKind{Group: "", Version: "v1", Kind: "ConfigMap"}
 	CustomResourceDefinition = config.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}
 	Deployment = config.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
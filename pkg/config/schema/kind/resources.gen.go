@@ -10,6 +10,7 @@ import (
 
 const (
 	AuthorizationPolicy Kind = iota
+	ConfigDump
 	ConfigMap
 	CustomResourceDefinition
 	Deployment
@@ -49,6 +50,8 @@ func (k Kind) String() string {
 	switch k {
 	case AuthorizationPolicy:
 		return "AuthorizationPolicy"
+	case ConfigDump:
+		return "ConfigDump"
 	case ConfigMap:
 		return "ConfigMap"
 	case CustomResourceDefinition:
@@ -124,6 +127,9 @@ func FromGvk(gvk config.GroupVersionKind) Kind {
 	if gvk.Kind == "AuthorizationPolicy" && gvk.Group == "security.istio.io" && gvk.Version == "v1beta1" {
 		return AuthorizationPolicy
 	}
+	if gvk.Kind == "ConfigDump" && gvk.Group == "" && gvk.Version == "v3" {
+		return ConfigDump
+	}
 	if gvk.Kind == "ConfigMap" && gvk.Group == "" && gvk.Version == "v1" {
 		return ConfigMap
 	}
@@ -218,7 +218,8 @@ func ValidateExtensionProviderEnvoyTCPAls(provider *meshconfig.MeshConfig_Extens
 	return
 }
 
-func validateExtensionProvider(config *meshconfig.MeshConfig) (errs error) {
+// ValidateExtensionProviders checks the extension providers of the mesh config are well-formed and uniquely named.
+func ValidateExtensionProviders(config *meshconfig.MeshConfig) (errs error) {
 	definedProviders := map[string]struct{}{}
 	for _, c := range config.ExtensionProviders {
 		var currentErrs error
@@ -37,6 +37,7 @@ import (
 	"google.golang.org/protobuf/types/descriptorpb"
 	anypb "google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	"istio.io/api/annotation"
 	extensions "istio.io/api/extensions/v1alpha1"
@@ -524,6 +525,9 @@ func validateServer(server *networking.Server) (v Validation) {
 		p := protocol.Parse(server.Port.Protocol)
 		if p.IsTLS() && server.Tls == nil {
 			v = appendValidation(v, fmt.Errorf("server must have TLS settings for HTTPS/TLS protocols"))
+		} else if p.IsTLS() && features.EnableGatewayWorkloadCertificate && gateway.UsesWorkloadCertificate(server.Tls) {
+			v = appendWarningf(v, "SIMPLE TLS without a credentialName or a server certificate presents the certificate issued to "+
+				"the gateway by istiod, with the DNS names of the %s annotation of its pods", constants.GatewayDNSSANsAnnotation)
 		} else if !p.IsTLS() && server.Tls != nil {
 			// only tls redirect is allowed if this is a HTTP server
 			if p.IsHTTP() {
@@ -632,6 +636,10 @@ func validateTLSOptions(tls *networking.ServerTLSSettings) (v Validation) {
 		// remotely. ServerCertificate and CaCertificates fields are not required.
 		return
 	}
+	if features.EnableGatewayWorkloadCertificate && gateway.UsesWorkloadCertificate(tls) {
+		// Gateways present the certificate issued to them by istiod.
+		return
+	}
 	if tls.Mode == networking.ServerTLSSettings_SIMPLE {
 		if tls.ServerCertificate == "" {
 			v = appendValidation(v, fmt.Errorf("SIMPLE TLS requires a server certificate"))
@@ -653,6 +661,18 @@ func validateTLSOptions(tls *networking.ServerTLSSettings) (v Validation) {
 	return
 }
 
+// onlySetsNamespaceDefaults returns true if the traffic policy of a mesh/namespace destination rule only sets the
+// fields applied as defaults without destination rule inheritance.
+func onlySetsNamespaceDefaults(policy *networking.TrafficPolicy) bool {
+	if policy == nil {
+		return true
+	}
+	p := policy.DeepCopy()
+	p.ConnectionPool = nil
+	p.OutlierDetection = nil
+	return proto.Equal(p, &networking.TrafficPolicy{})
+}
+
 // ValidateDestinationRule checks proxy policies
 var ValidateDestinationRule = registerValidateFunc("ValidateDestinationRule",
 	func(cfg config.Config) (Warning, error) {
@@ -661,32 +681,53 @@ var ValidateDestinationRule = registerValidateFunc("ValidateDestinationRule",
 			return nil, fmt.Errorf("cannot cast to destination rule")
 		}
 		v := Validation{}
-		if features.EnableDestinationRuleInheritance {
-			if rule.Host == "" {
-				if rule.GetWorkloadSelector() != nil {
-					v = appendValidation(v,
-						fmt.Errorf("mesh/namespace destination rule cannot have workloadSelector configured"))
-				}
-				if len(rule.Subsets) != 0 {
-					v = appendValidation(v,
-						fmt.Errorf("mesh/namespace destination rule cannot have subsets"))
-				}
-				if len(rule.ExportTo) != 0 {
-					v = appendValidation(v,
-						fmt.Errorf("mesh/namespace destination rule cannot have exportTo configured"))
-				}
-				if rule.TrafficPolicy != nil && len(rule.TrafficPolicy.PortLevelSettings) != 0 {
-					v = appendValidation(v,
-						fmt.Errorf("mesh/namespace destination rule cannot have portLevelSettings configured"))
+		if rule.Host == "" && (features.EnableDestinationRuleInheritance || features.EnableNamespaceTrafficPolicyDefaults) {
+			if !features.EnableDestinationRuleInheritance && !onlySetsNamespaceDefaults(rule.TrafficPolicy) {
+				v = appendValidation(v, fmt.Errorf("mesh/namespace destination rule can only set connectionPool and "+
+					"outlierDetection defaults unless destination rule inheritance is enabled"))
+			}
+			if rule.GetWorkloadSelector() != nil {
+				v = appendValidation(v,
+					fmt.Errorf("mesh/namespace destination rule cannot have workloadSelector configured"))
+			}
+			if len(rule.Subsets) != 0 {
+				v = appendValidation(v,
+					fmt.Errorf("mesh/namespace destination rule cannot have subsets"))
+			}
+			if len(rule.ExportTo) != 0 {
+				v = appendValidation(v,
+					fmt.Errorf("mesh/namespace destination rule cannot have exportTo configured"))
+			}
+			if rule.TrafficPolicy != nil && len(rule.TrafficPolicy.PortLevelSettings) != 0 {
+				v = appendValidation(v,
+					fmt.Errorf("mesh/namespace destination rule cannot have portLevelSettings configured"))
+			}
+			if policy, f := cfg.Annotations[constants.DefaultHTTPRetryPolicyAnnotation]; f {
+				retries := &networking.HTTPRetry{}
+				if err := protomarshal.ApplyJSONStrict(policy, retries); err != nil {
+					v = appendValidation(v, fmt.Errorf("invalid %s annotation: %v", constants.DefaultHTTPRetryPolicyAnnotation, err))
+				} else {
+					v = appendValidation(v, validateHTTPRetry(retries))
 				}
-			} else {
-				v = appendValidation(v, ValidateWildcardDomain(rule.Host))
 			}
 		} else {
 			v = appendValidation(v, ValidateWildcardDomain(rule.Host))
 		}
 
 		v = appendValidation(v, validateTrafficPolicy(rule.TrafficPolicy))
+		if attempts, f := cfg.Annotations[constants.TCPMaxConnectAttemptsAnnotation]; f {
+			if n, err := strconv.ParseUint(attempts, 10, 32); err != nil || n == 0 {
+				v = appendValidation(v, fmt.Errorf("invalid %s annotation %q: must be a positive integer",
+					constants.TCPMaxConnectAttemptsAnnotation, attempts))
+			}
+		}
+		for _, annotation := range []string{constants.PreconnectRatioAnnotation, constants.PredictivePreconnectRatioAnnotation} {
+			if ratios, f := cfg.Annotations[annotation]; f {
+				if _, err := ParsePreconnectRatios(ratios); err != nil {
+					v = appendValidation(v, fmt.Errorf("invalid %s annotation: %v", annotation, err))
+				}
+			}
+		}
 
 		for _, subset := range rule.Subsets {
 			if subset == nil {
@@ -703,6 +744,50 @@ var ValidateDestinationRule = registerValidateFunc("ValidateDestinationRule",
 		return v.Unwrap()
 	})
 
+// The range of the preconnect ratios Envoy accepts.
+const (
+	minPreconnectRatio = 1
+	maxPreconnectRatio = 3
+)
+
+// ParsePreconnectRatios parses the value of the networking.istio.io/preconnectRatio or
+// networking.istio.io/predictivePreconnectRatio annotation into the ratios by subset, with an empty subset for the
+// other clusters.
+func ParsePreconnectRatios(value string) (map[string]float64, error) {
+	return ParseSubsetValues(value, "ratio", "a number from 1 to 3", func(ratio float64) bool {
+		return ratio >= minPreconnectRatio && ratio <= maxPreconnectRatio
+	})
+}
+
+// ParseSubsetValues parses an annotation value applying to the clusters of a DestinationRule, such as "canary=3,1.5",
+// into the values by subset, with an empty subset for the other clusters. name is what the values are and valid
+// checks them, with constraint describing the valid values in errors.
+func ParseSubsetValues(value, name, constraint string, valid func(float64) bool) (map[string]float64, error) {
+	values := map[string]float64{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		subset := ""
+		if s, v, found := strings.Cut(entry, "="); found {
+			subset, entry = strings.TrimSpace(s), strings.TrimSpace(v)
+			if subset == "" {
+				return nil, fmt.Errorf("empty subset name")
+			}
+		}
+		if _, f := values[subset]; f {
+			if subset == "" {
+				return nil, fmt.Errorf("duplicate %s for all subsets", name)
+			}
+			return nil, fmt.Errorf("duplicate %s for subset %s", name, subset)
+		}
+		v, err := strconv.ParseFloat(entry, 64)
+		if err != nil || !valid(v) {
+			return nil, fmt.Errorf("invalid %s %q, must be %s", name, entry, constraint)
+		}
+		values[subset] = v
+	}
+	return values, nil
+}
+
 func validateExportTo(namespace string, exportTo []string, isServiceEntry bool, isDestinationRuleWithSelector bool) (errs error) {
 	if len(exportTo) > 0 {
 		// Make sure there are no duplicates
@@ -1149,6 +1234,9 @@ var ValidateSidecar = registerValidateFunc("ValidateSidecar",
 				if i.Tls.CredentialName != "" {
 					errs = appendValidation(errs, fmt.Errorf("sidecar: credentialName is not currently supported"))
 				}
+				if gateway.UsesWorkloadCertificate(i.Tls) {
+					errs = appendValidation(errs, fmt.Errorf("sidecar: SIMPLE TLS requires a server certificate and a private key"))
+				}
 				if i.Tls.Mode == networking.ServerTLSSettings_ISTIO_MUTUAL || i.Tls.Mode == networking.ServerTLSSettings_AUTO_PASSTHROUGH {
 					errs = appendValidation(errs, fmt.Errorf("configuration is invalid: cannot set mode to %s in sidecar ingress tls", i.Tls.Mode.String()))
 				}
@@ -1704,7 +1792,7 @@ func ValidateMeshConfig(mesh *meshconfig.MeshConfig) (errs error) {
 		errs = multierror.Append(errs, err)
 	}
 
-	if err := validateExtensionProvider(mesh); err != nil {
+	if err := ValidateExtensionProviders(mesh); err != nil {
 		scope.Warnf("found invalid extension provider (can be ignored if the given extension provider is not used): %v", err)
 	}
 
@@ -3225,6 +3313,12 @@ var ValidateServiceEntry = registerValidateFunc("ValidateServiceEntry",
 			}
 		}
 
+		if timeouts, f := cfg.Annotations[constants.TCPIdleTimeoutAnnotation]; f {
+			if _, err := ParseTCPIdleTimeouts(timeouts); err != nil {
+				errs = appendValidation(errs, fmt.Errorf("invalid %s annotation %q: %v", constants.TCPIdleTimeoutAnnotation, timeouts, err))
+			}
+		}
+
 		cidrFound := false
 		for _, address := range serviceEntry.Addresses {
 			cidrFound = cidrFound || strings.Contains(address, "/")
@@ -3657,10 +3751,30 @@ var ValidateTelemetry = registerValidateFunc("ValidateTelemetry",
 			validateTelemetryMetrics(spec.Metrics),
 			validateTelemetryTracing(spec.Tracing),
 			validateTelemetryAccessLogging(spec.AccessLogging),
+			validateTelemetryAccessLogFormat(cfg.Annotations),
 		)
 		return errs.Unwrap()
 	})
 
+// validateTelemetryAccessLogFormat validates the annotations overriding the file access log format.
+func validateTelemetryAccessLogFormat(annotations map[string]string) (v Validation) {
+	text, hasText := annotations[constants.AccessLogFormatAnnotation]
+	labels, hasLabels := annotations[constants.AccessLogLabelsAnnotation]
+	if hasText && hasLabels {
+		return appendValidation(v, fmt.Errorf("only one of %s and %s can be set",
+			constants.AccessLogFormatAnnotation, constants.AccessLogLabelsAnnotation))
+	}
+	if hasText && text == "" {
+		v = appendValidation(v, fmt.Errorf("%s must not be empty", constants.AccessLogFormatAnnotation))
+	}
+	if hasLabels {
+		if err := protomarshal.Unmarshal([]byte(labels), &structpb.Struct{}); err != nil {
+			v = appendValidation(v, fmt.Errorf("invalid %s, must be a JSON object: %v", constants.AccessLogLabelsAnnotation, err))
+		}
+	}
+	return
+}
+
 func validateTelemetryAccessLogging(logging []*telemetry.AccessLogging) (v Validation) {
 	if len(logging) > 1 {
 		v = appendWarningf(v, "multiple accessLogging is not currently supported")
@@ -3848,3 +3962,32 @@ func validateWasmPluginVMConfig(vm *extensions.VmConfig) error {
 
 	return nil
 }
+
+// ParseTCPIdleTimeouts parses the value of the networking.istio.io/tcpIdleTimeout annotation into the timeouts by
+// port, with port 0 for the timeout of the other ports.
+func ParseTCPIdleTimeouts(value string) (map[int]time.Duration, error) {
+	timeouts := map[int]time.Duration{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		port := 0
+		if p, d, found := strings.Cut(entry, "="); found {
+			n, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil || n <= 0 || n > 65535 {
+				return nil, fmt.Errorf("invalid port %q", p)
+			}
+			port, entry = n, strings.TrimSpace(d)
+		}
+		if _, f := timeouts[port]; f {
+			if port == 0 {
+				return nil, fmt.Errorf("duplicate timeout for all ports")
+			}
+			return nil, fmt.Errorf("duplicate timeout for port %d", port)
+		}
+		timeout, err := time.ParseDuration(entry)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid duration %q", entry)
+		}
+		timeouts[port] = timeout
+	}
+	return timeouts, nil
+}
@@ -1199,6 +1199,20 @@ func TestValidateServer(t *testing.T) {
 	}
 }
 
+func TestValidateServerWorkloadCertificate(t *testing.T) {
+	server := &networking.Server{
+		Hosts: []string{"foo.bar.com"},
+		Port:  &networking.Port{Number: 443, Name: "https", Protocol: "https"},
+		Tls:   &networking.ServerTLSSettings{Mode: networking.ServerTLSSettings_SIMPLE},
+	}
+	warn, err := validateServer(server).Unwrap()
+	checkValidationMessage(t, warn, err, "", "SIMPLE TLS requires a server certificate")
+
+	test.SetBoolForTest(t, &features.EnableGatewayWorkloadCertificate, true)
+	warn, err = validateServer(server).Unwrap()
+	checkValidationMessage(t, warn, err, "certificate issued to the gateway by istiod", "")
+}
+
 func TestValidateServerPort(t *testing.T) {
 	tests := []struct {
 		name string
@@ -1511,11 +1525,19 @@ func TestValidateTlsOptions(t *testing.T) {
 		{
 			"invalid cipher suites with invalid config",
 			&networking.ServerTLSSettings{
-				Mode:         networking.ServerTLSSettings_SIMPLE,
-				CipherSuites: []string{"not-a-cipher-suite"},
+				Mode:              networking.ServerTLSSettings_SIMPLE,
+				ServerCertificate: "Captain Jean-Luc Picard",
+				CipherSuites:      []string{"not-a-cipher-suite"},
 			},
 			"requires a private key", "not-a-cipher-suite",
 		},
+		{
+			"simple without certificate",
+			&networking.ServerTLSSettings{
+				Mode: networking.ServerTLSSettings_SIMPLE,
+			},
+			"requires a server certificate", "",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -2505,6 +2527,144 @@ func TestValidateDestinationWithInheritance(t *testing.T) {
 	}
 }
 
+func TestValidateNamespaceDestinationRuleWithoutInheritance(t *testing.T) {
+	// Without either flag, a dest rule must have a host.
+	_, err := ValidateDestinationRule(config.Config{
+		Meta: config.Meta{Name: someName, Namespace: someNamespace},
+		Spec: &networking.DestinationRule{},
+	})
+	if err == nil {
+		t.Fatalf("expected a dest rule without host to be invalid without namespace defaults")
+	}
+
+	test.SetBoolForTest(t, &features.EnableNamespaceTrafficPolicyDefaults, true)
+	cases := []struct {
+		name  string
+		in    *networking.DestinationRule
+		valid bool
+	}{
+		{name: "no traffic policy", in: &networking.DestinationRule{}, valid: true},
+		{name: "connection pool and outlier detection", in: &networking.DestinationRule{
+			TrafficPolicy: &networking.TrafficPolicy{
+				ConnectionPool:   &networking.ConnectionPoolSettings{Tcp: &networking.ConnectionPoolSettings_TCPSettings{MaxConnections: 10}},
+				OutlierDetection: &networking.OutlierDetection{MinHealthPercent: 20},
+			},
+		}, valid: true},
+		{name: "tls", in: &networking.DestinationRule{
+			TrafficPolicy: &networking.TrafficPolicy{
+				Tls: &networking.ClientTLSSettings{Mode: networking.ClientTLSSettings_ISTIO_MUTUAL},
+			},
+		}, valid: false},
+		{name: "subsets", in: &networking.DestinationRule{
+			Subsets: []*networking.Subset{{Name: "v1", Labels: map[string]string{"version": "v1"}}},
+		}, valid: false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, got := ValidateDestinationRule(config.Config{
+				Meta: config.Meta{Name: someName, Namespace: someNamespace},
+				Spec: c.in,
+			})
+			if (got == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", got == nil, c.valid, got)
+			}
+		})
+	}
+}
+
+func TestValidateDestinationRuleDefaultRetryPolicy(t *testing.T) {
+	test.SetBoolForTest(t, &features.EnableNamespaceTrafficPolicyDefaults, true)
+	cases := []struct {
+		name  string
+		host  string
+		value string
+		valid bool
+	}{
+		{name: "valid policy", value: `{"attempts": 3, "perTryTimeout": "2s", "retryOn": "5xx"}`, valid: true},
+		{name: "invalid json", value: `{"attempts": "three"}`, valid: false},
+		{name: "unknown field", value: `{"tries": 3}`, valid: false},
+		{name: "invalid retry policy", value: `{"attempts": -1}`, valid: false},
+		{name: "ignored on host dest rule", host: "reviews", value: `{"tries": 3}`, valid: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, got := ValidateDestinationRule(config.Config{
+				Meta: config.Meta{
+					Name:        someName,
+					Namespace:   someNamespace,
+					Annotations: map[string]string{constants.DefaultHTTPRetryPolicyAnnotation: c.value},
+				},
+				Spec: &networking.DestinationRule{Host: c.host},
+			})
+			if (got == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", got == nil, c.valid, got)
+			}
+		})
+	}
+}
+
+func TestValidateDestinationRuleTCPMaxConnectAttempts(t *testing.T) {
+	cases := []struct {
+		value string
+		valid bool
+	}{
+		{value: "1", valid: true},
+		{value: "5", valid: true},
+		{value: "0", valid: false},
+		{value: "-1", valid: false},
+		{value: "three", valid: false},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			_, got := ValidateDestinationRule(config.Config{
+				Meta: config.Meta{
+					Name:        someName,
+					Namespace:   someNamespace,
+					Annotations: map[string]string{constants.TCPMaxConnectAttemptsAnnotation: c.value},
+				},
+				Spec: &networking.DestinationRule{Host: "reviews"},
+			})
+			if (got == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", got == nil, c.valid, got)
+			}
+		})
+	}
+}
+
+func TestValidateDestinationRulePreconnectRatio(t *testing.T) {
+	cases := []struct {
+		value string
+		valid bool
+	}{
+		{value: "1.5", valid: true},
+		{value: "canary=3, 1", valid: true},
+		{value: "canary=2", valid: true},
+		{value: "0.5", valid: false},
+		{value: "4", valid: false},
+		{value: "canary=2,canary=3", valid: false},
+		{value: "=2", valid: false},
+		{value: "1.5,2", valid: false},
+		{value: "fast", valid: false},
+	}
+	for _, c := range cases {
+		for _, annotation := range []string{constants.PreconnectRatioAnnotation, constants.PredictivePreconnectRatioAnnotation} {
+			t.Run(annotation+"="+c.value, func(t *testing.T) {
+				_, got := ValidateDestinationRule(config.Config{
+					Meta: config.Meta{
+						Name:        someName,
+						Namespace:   someNamespace,
+						Annotations: map[string]string{annotation: c.value},
+					},
+					Spec: &networking.DestinationRule{Host: "reviews"},
+				})
+				if (got == nil) != c.valid {
+					t.Errorf("got valid=%v but wanted valid=%v: %v", got == nil, c.valid, got)
+				}
+			})
+		}
+	}
+}
+
 func TestValidateDestination(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -4957,6 +5117,37 @@ func TestValidateServiceEntries(t *testing.T) {
 	}
 }
 
+func TestValidateServiceEntryTCPIdleTimeout(t *testing.T) {
+	cases := []struct {
+		value string
+		valid bool
+	}{
+		{"4h", true},
+		{"5432=4h, 30m", true},
+		{"5432=4h,5432=1h", false},
+		{"70000=4h", false},
+		{"0s", false},
+		{"forever", false},
+	}
+	for _, c := range cases {
+		_, err := ValidateServiceEntry(config.Config{
+			Meta: config.Meta{
+				Name:        someName,
+				Namespace:   someNamespace,
+				Annotations: map[string]string{constants.TCPIdleTimeoutAnnotation: c.value},
+			},
+			Spec: &networking.ServiceEntry{
+				Hosts:      []string{"db.example.com"},
+				Ports:      []*networking.Port{{Number: 5432, Protocol: "TCP", Name: "tcp-postgres"}},
+				Resolution: networking.ServiceEntry_DNS,
+			},
+		})
+		if (err == nil) != c.valid {
+			t.Errorf("ValidateServiceEntry(%q) got valid=%v but wanted valid=%v: %v", c.value, err == nil, c.valid, err)
+		}
+	}
+}
+
 func TestValidateAuthorizationPolicy(t *testing.T) {
 	cases := []struct {
 		name        string
@@ -7693,6 +7884,43 @@ func TestValidateProxyConfig(t *testing.T) {
 	}
 }
 
+func TestValidateTelemetryAccessLogFormat(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		valid       bool
+	}{
+		{name: "none", valid: true},
+		{name: "text", annotations: map[string]string{constants.AccessLogFormatAnnotation: "%RESPONSE_CODE%"}, valid: true},
+		{name: "empty text", annotations: map[string]string{constants.AccessLogFormatAnnotation: ""}, valid: false},
+		{name: "labels", annotations: map[string]string{constants.AccessLogLabelsAnnotation: `{"code":"%RESPONSE_CODE%"}`}, valid: true},
+		{name: "invalid labels", annotations: map[string]string{constants.AccessLogLabelsAnnotation: `["%RESPONSE_CODE%"]`}, valid: false},
+		{
+			name: "both",
+			annotations: map[string]string{
+				constants.AccessLogFormatAnnotation: "%RESPONSE_CODE%",
+				constants.AccessLogLabelsAnnotation: `{"code":"%RESPONSE_CODE%"}`,
+			},
+			valid: false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, got := ValidateTelemetry(config.Config{
+				Meta: config.Meta{
+					Name:        someName,
+					Namespace:   someNamespace,
+					Annotations: c.annotations,
+				},
+				Spec: &telemetry.Telemetry{},
+			})
+			if (got == nil) != c.valid {
+				t.Errorf("got valid=%v but wanted valid=%v: %v", got == nil, c.valid, got)
+			}
+		})
+	}
+}
+
 func TestValidateTelemetryFilter(t *testing.T) {
 	cases := []struct {
 		filter *telemetry.AccessLogging_Filter
@@ -0,0 +1,325 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+// APIChange records an Envoy API element whose support changed between Istio proxy versions.
+// Versions are Istio minor versions (for example 14 for 1.14); 0 means the change did not happen.
+type APIChange struct {
+	// Name is either a type URL, a type URL prefix ending in ".", or a fully qualified proto field name.
+	Name string
+	// Deprecated is the first proxy version that deprecates the element.
+	Deprecated int
+	// Removed is the first proxy version that rejects the element.
+	Removed int
+	// Replacement describes what to use instead.
+	Replacement string
+}
+
+// ProtoAPIVersion is the proxy minor version of the Envoy protos compiled into this binary. Their deprecation markers
+// only say that an element is deprecated in this version, so they are not reported for older proxies.
+const ProtoAPIVersion = 16
+
+// EnvoyAPIChanges is the compatibility table of Envoy API elements commonly used in EnvoyFilter patches.
+// Deprecations marked in the compiled-in protos are detected separately and do not need to be listed here.
+var EnvoyAPIChanges = []APIChange{
+	{
+		Name:        "type.googleapis.com/envoy.config.filter.",
+		Deprecated:  8,
+		Removed:     10,
+		Replacement: "the equivalent type.googleapis.com/envoy.extensions.filters.* v3 type",
+	},
+	{
+		Name:        "type.googleapis.com/envoy.api.v2.",
+		Deprecated:  8,
+		Removed:     10,
+		Replacement: "the equivalent type.googleapis.com/envoy.config.* v3 type",
+	},
+	{
+		Name:        "type.googleapis.com/envoy.config.accesslog.v2.",
+		Deprecated:  8,
+		Removed:     10,
+		Replacement: "the equivalent type.googleapis.com/envoy.extensions.access_loggers.* v3 type",
+	},
+	{
+		Name:        "envoy.config.cluster.v3.Cluster.hosts",
+		Deprecated:  8,
+		Removed:     10,
+		Replacement: "load_assignment",
+	},
+	{
+		Name:        "envoy.config.cluster.v3.Cluster.tls_context",
+		Deprecated:  8,
+		Removed:     10,
+		Replacement: "transport_socket",
+	},
+	{
+		Name:        "envoy.config.route.v3.VirtualHost.per_filter_config",
+		Deprecated:  8,
+		Removed:     10,
+		Replacement: "typed_per_filter_config",
+	},
+	{
+		Name:        "envoy.config.route.v3.Route.per_filter_config",
+		Deprecated:  8,
+		Removed:     10,
+		Replacement: "typed_per_filter_config",
+	},
+	{
+		Name:        "envoy.config.cluster.v3.Cluster.http2_protocol_options",
+		Deprecated:  9,
+		Replacement: "typed_extension_protocol_options with envoy.extensions.upstreams.http.v3.HttpProtocolOptions",
+	},
+	{
+		Name:        "envoy.config.cluster.v3.Cluster.common_http_protocol_options",
+		Deprecated:  9,
+		Replacement: "typed_extension_protocol_options with envoy.extensions.upstreams.http.v3.HttpProtocolOptions",
+	},
+	{
+		Name:        "envoy.config.route.v3.RouteAction.max_grpc_timeout",
+		Deprecated:  10,
+		Replacement: "max_stream_duration.grpc_timeout_header_max",
+	},
+	{
+		Name:        "envoy.extensions.transport_sockets.tls.v3.CertificateValidationContext.match_subject_alt_names",
+		Deprecated:  14,
+		Replacement: "match_typed_subject_alt_names",
+	},
+}
+
+// deprecatedFilterNamesVersion is the proxy version that deprecates the filter names of DeprecatedFilterNames.
+const deprecatedFilterNamesVersion = 6
+
+// CompatibilityIssue is a single problem found by CheckEnvoyFilterCompatibility.
+type CompatibilityIssue struct {
+	// Path locates the offending element within the config patch, such as patch.value.typed_config.@type.
+	Path string
+	// Message describes the problem.
+	Message string
+	// Removed is true if the target proxy does not accept the element at all.
+	Removed bool
+	// Element is the type URL, fully qualified field name or filter name the issue is about, if any.
+	Element string
+	// Change is the change of the element, or the zero value if the patch could not be decoded.
+	Change APIChange
+}
+
+func (i CompatibilityIssue) String() string {
+	return i.Path + ": " + i.Message
+}
+
+// LookupAPIChange returns the compatibility table entry matching a type URL or fully qualified field name.
+func LookupAPIChange(name string) (APIChange, bool) {
+	for _, c := range EnvoyAPIChanges {
+		if c.Name == name || (strings.HasSuffix(c.Name, ".") && strings.HasPrefix(name, c.Name)) {
+			return c, true
+		}
+	}
+	return APIChange{}, false
+}
+
+// CheckEnvoyFilterCompatibility reports the elements of an EnvoyFilter patch that are deprecated in, or removed
+// from, the given proxy minor version. Type URLs and field names are checked against EnvoyAPIChanges, and fields
+// are additionally checked against the deprecation markers of the compiled-in Envoy protos. The filter names of the
+// listener match and of filter patches are checked against DeprecatedFilterNames.
+func CheckEnvoyFilterCompatibility(cp *networking.EnvoyFilter_EnvoyConfigObjectPatch, minor int) []CompatibilityIssue {
+	var issues []CompatibilityIssue
+	filter := cp.GetMatch().GetListener().GetFilterChain().GetFilter()
+	issues = appendFilterNameIssue(issues, filter.GetName(), "match.listener.filterChain.filter.name", minor)
+	issues = appendFilterNameIssue(issues, filter.GetSubFilter().GetName(), "match.listener.filterChain.filter.subFilter.name", minor)
+	if cp.GetPatch().GetValue() == nil {
+		return sortIssues(issues)
+	}
+	switch cp.ApplyTo {
+	case networking.EnvoyFilter_HTTP_FILTER, networking.EnvoyFilter_NETWORK_FILTER:
+		issues = appendFilterNameIssue(issues, cp.Patch.Value.GetFields()["name"].GetStringValue(), "patch.value.name", minor)
+	}
+	// Type URLs are checked on the raw value: removed types cannot be decoded at all.
+	issues = append(issues, checkStructTypeURLs(cp.Patch.Value, "patch.value", minor)...)
+
+	obj, err := BuildXDSObjectFromStruct(cp.ApplyTo, cp.Patch.Value, false)
+	if err != nil {
+		issues = append(issues, CompatibilityIssue{Path: "patch.value", Message: err.Error(), Removed: true})
+		return sortIssues(issues)
+	}
+	issues = append(issues, checkRemovedFields(obj.ProtoReflect().Descriptor(), cp.Patch.Value, "patch.value", minor)...)
+	issues = append(issues, checkMessageFields(obj.ProtoReflect(), "patch.value", minor)...)
+	return sortIssues(issues)
+}
+
+func appendFilterNameIssue(issues []CompatibilityIssue, name, path string, minor int) []CompatibilityIssue {
+	canonical, ok := ReverseDeprecatedFilterNames[name]
+	if !ok {
+		return issues
+	}
+	c := APIChange{Name: name, Deprecated: deprecatedFilterNamesVersion, Replacement: canonical}
+	if issue, ok := changeIssue(c, name, path, minor); ok {
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// checkRemovedFields checks the top level fields of the raw value that the compiled-in protos do not know anymore,
+// and would be dropped by decoding.
+func checkRemovedFields(md protoreflect.MessageDescriptor, v *structpb.Struct, path string, minor int) []CompatibilityIssue {
+	var issues []CompatibilityIssue
+	for k := range v.GetFields() {
+		if md.Fields().ByName(protoreflect.Name(k)) != nil || md.Fields().ByJSONName(k) != nil {
+			continue
+		}
+		if issue, ok := checkName(string(md.FullName())+"."+k, path+"."+k, minor); ok {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func checkStructTypeURLs(v *structpb.Struct, path string, minor int) []CompatibilityIssue {
+	var issues []CompatibilityIssue
+	for k, f := range v.GetFields() {
+		p := path + "." + k
+		if k == "@type" {
+			if issue, ok := checkName(f.GetStringValue(), p, minor); ok {
+				issues = append(issues, issue)
+			}
+			continue
+		}
+		issues = append(issues, checkValueTypeURLs(f, p, minor)...)
+	}
+	return issues
+}
+
+func checkValueTypeURLs(v *structpb.Value, path string, minor int) []CompatibilityIssue {
+	switch t := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		return checkStructTypeURLs(t.StructValue, path, minor)
+	case *structpb.Value_ListValue:
+		var issues []CompatibilityIssue
+		for i, e := range t.ListValue.GetValues() {
+			issues = append(issues, checkValueTypeURLs(e, fmt.Sprintf("%s[%d]", path, i), minor)...)
+		}
+		return issues
+	}
+	return nil
+}
+
+func checkMessageFields(m protoreflect.Message, path string, minor int) []CompatibilityIssue {
+	var issues []CompatibilityIssue
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		p := path + "." + string(fd.Name())
+		if issue, ok := checkName(string(fd.FullName()), p, minor); ok {
+			issues = append(issues, issue)
+		} else if opts, ok := fd.Options().(*descriptorpb.FieldOptions); ok && opts.GetDeprecated() && minor >= ProtoAPIVersion {
+			issues = append(issues, CompatibilityIssue{
+				Path:    p,
+				Message: fmt.Sprintf("field %s is deprecated in proxy version 1.%d", fd.FullName(), ProtoAPIVersion),
+				Element: string(fd.FullName()),
+				Change:  APIChange{Name: string(fd.FullName()), Deprecated: ProtoAPIVersion},
+			})
+		}
+		switch {
+		case fd.IsList() && fd.Kind() == protoreflect.MessageKind:
+			l := v.List()
+			for i := 0; i < l.Len(); i++ {
+				issues = append(issues, checkNestedMessage(l.Get(i).Message(), fmt.Sprintf("%s[%d]", p, i), minor)...)
+			}
+		case fd.IsMap() && fd.MapValue().Kind() == protoreflect.MessageKind:
+			v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				issues = append(issues, checkNestedMessage(mv.Message(), fmt.Sprintf("%s[%s]", p, k.String()), minor)...)
+				return true
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Kind() == protoreflect.MessageKind:
+			issues = append(issues, checkNestedMessage(v.Message(), p, minor)...)
+		}
+		return true
+	})
+	return issues
+}
+
+// checkNestedMessage recurses into a message, unpacking Any values so typed_config contents are checked as well.
+func checkNestedMessage(m protoreflect.Message, path string, minor int) []CompatibilityIssue {
+	a, ok := m.Interface().(*anypb.Any)
+	if !ok {
+		return checkMessageFields(m, path, minor)
+	}
+	if _, ok := LookupAPIChange(a.TypeUrl); ok {
+		// The type itself is reported while checking the type URLs, the fields of a deprecated or removed type
+		// would only repeat it.
+		return nil
+	}
+	mt, err := protoregistry.GlobalTypes.FindMessageByURL(a.TypeUrl)
+	if err != nil {
+		return []CompatibilityIssue{{Path: path, Message: fmt.Sprintf("type %s is unknown, its fields were not checked", a.TypeUrl)}}
+	}
+	inner := mt.New().Interface()
+	if err := proto.Unmarshal(a.Value, inner); err != nil {
+		return []CompatibilityIssue{{Path: path, Message: fmt.Sprintf("cannot decode %s: %v", a.TypeUrl, err), Removed: true}}
+	}
+	return checkMessageFields(inner.ProtoReflect(), path, minor)
+}
+
+func checkName(name, path string, minor int) (CompatibilityIssue, bool) {
+	c, ok := LookupAPIChange(name)
+	if !ok {
+		return CompatibilityIssue{}, false
+	}
+	return changeIssue(c, name, path, minor)
+}
+
+func changeIssue(c APIChange, name, path string, minor int) (CompatibilityIssue, bool) {
+	suffix := ""
+	if c.Replacement != "" {
+		suffix = "; use " + c.Replacement + " instead"
+	}
+	switch {
+	case c.Removed != 0 && minor >= c.Removed:
+		return CompatibilityIssue{
+			Path:    path,
+			Message: fmt.Sprintf("%s was removed in proxy version 1.%d%s", name, c.Removed, suffix),
+			Removed: true,
+			Element: name,
+			Change:  c,
+		}, true
+	case c.Deprecated != 0 && minor >= c.Deprecated:
+		return CompatibilityIssue{
+			Path:    path,
+			Message: fmt.Sprintf("%s is deprecated since proxy version 1.%d%s", name, c.Deprecated, suffix),
+			Element: name,
+			Change:  c,
+		}, true
+	}
+	return CompatibilityIssue{}, false
+}
+
+func sortIssues(issues []CompatibilityIssue) []CompatibilityIssue {
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Path < issues[j].Path
+	})
+	return issues
+}
@@ -0,0 +1,130 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+func compatibilityPatch(t *testing.T, applyTo networking.EnvoyFilter_ApplyTo, value map[string]interface{}) *networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	t.Helper()
+	v, err := structpb.NewStruct(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: applyTo,
+		Patch:   &networking.EnvoyFilter_Patch{Operation: networking.EnvoyFilter_Patch_MERGE, Value: v},
+	}
+}
+
+func TestCheckEnvoyFilterCompatibilityProtoDeprecation(t *testing.T) {
+	// max_requests_per_connection is only deprecated by the marker of the compiled-in protos.
+	cp := compatibilityPatch(t, networking.EnvoyFilter_CLUSTER, map[string]interface{}{"max_requests_per_connection": 10})
+	if issues := CheckEnvoyFilterCompatibility(cp, ProtoAPIVersion-1); len(issues) != 0 {
+		t.Errorf("expected no issue for an older proxy, got %v", issues)
+	}
+	issues := CheckEnvoyFilterCompatibility(cp, ProtoAPIVersion)
+	if len(issues) != 1 || issues[0].Removed || issues[0].Path != "patch.value.max_requests_per_connection" {
+		t.Errorf("expected a deprecation warning, got %v", issues)
+	}
+}
+
+func TestCheckEnvoyFilterCompatibilityRemovedType(t *testing.T) {
+	cp := compatibilityPatch(t, networking.EnvoyFilter_HTTP_FILTER, map[string]interface{}{
+		"name": "envoy.filters.http.lua",
+		"typed_config": map[string]interface{}{
+			"@type":      "type.googleapis.com/envoy.config.filter.http.lua.v2.Lua",
+			"inlineCode": "function envoy_on_request(h) end",
+		},
+	})
+	issues := CheckEnvoyFilterCompatibility(cp, 15)
+	if len(issues) != 1 || !issues[0].Removed || issues[0].Path != "patch.value.typed_config.@type" {
+		t.Errorf("expected the removed type to be reported once, got %v", issues)
+	}
+	issues = CheckEnvoyFilterCompatibility(cp, 9)
+	if len(issues) != 1 || issues[0].Removed {
+		t.Errorf("expected a single deprecation warning, got %v", issues)
+	}
+}
+
+func TestCheckEnvoyFilterCompatibilityRemovedTypeFields(t *testing.T) {
+	// The v2 HttpConnectionManager is still compiled in, with its fields marked deprecated.
+	cp := compatibilityPatch(t, networking.EnvoyFilter_NETWORK_FILTER, map[string]interface{}{
+		"name": "envoy.filters.network.http_connection_manager",
+		"typed_config": map[string]interface{}{
+			"@type":        "type.googleapis.com/envoy.config.filter.network.http_connection_manager.v2.HttpConnectionManager",
+			"idle_timeout": "5s",
+		},
+	})
+	issues := CheckEnvoyFilterCompatibility(cp, ProtoAPIVersion)
+	if len(issues) != 1 || !issues[0].Removed || issues[0].Path != "patch.value.typed_config.@type" {
+		t.Errorf("expected the removed type to be reported once, got %v", issues)
+	}
+}
+
+func TestCheckEnvoyFilterCompatibilityUnknownType(t *testing.T) {
+	// Decoding a patch rejects the types that are not compiled in, so such an Any can only be built programmatically.
+	a := &anypb.Any{TypeUrl: "type.googleapis.com/example.Unknown"}
+	issues := checkNestedMessage(a.ProtoReflect(), "patch.value.typed_config", 15)
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, "type.googleapis.com/example.Unknown is unknown") {
+		t.Errorf("expected the unknown type to be reported, got %v", issues)
+	}
+	// The type URLs of EnvoyAPIChanges are reported by checking the raw value instead.
+	a = &anypb.Any{TypeUrl: "type.googleapis.com/envoy.config.filter.http.lua.v2.Lua"}
+	if issues := checkNestedMessage(a.ProtoReflect(), "patch.value.typed_config", 15); len(issues) != 0 {
+		t.Errorf("expected no issue, got %v", issues)
+	}
+}
+
+func TestCheckEnvoyFilterCompatibilityFilterNames(t *testing.T) {
+	cp := compatibilityPatch(t, networking.EnvoyFilter_HTTP_FILTER, map[string]interface{}{"name": "envoy.cors"})
+	cp.Match = &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+		ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+			Listener: &networking.EnvoyFilter_ListenerMatch{
+				FilterChain: &networking.EnvoyFilter_ListenerMatch_FilterChainMatch{
+					Filter: &networking.EnvoyFilter_ListenerMatch_FilterMatch{
+						Name:      "envoy.filters.network.http_connection_manager",
+						SubFilter: &networking.EnvoyFilter_ListenerMatch_SubFilterMatch{Name: "envoy.router"},
+					},
+				},
+			},
+		},
+	}
+	issues := CheckEnvoyFilterCompatibility(cp, 15)
+	if len(issues) != 2 ||
+		issues[0].Path != "match.listener.filterChain.filter.subFilter.name" || issues[0].Change.Replacement != "envoy.filters.http.router" ||
+		issues[1].Path != "patch.value.name" || issues[1].Change.Replacement != "envoy.filters.http.cors" {
+		t.Errorf("expected the deprecated filter names to be reported, got %v", issues)
+	}
+}
+
+func TestCheckEnvoyFilterCompatibilityRemovedField(t *testing.T) {
+	// hosts is not in the compiled-in Cluster anymore, so decoding drops it.
+	cp := compatibilityPatch(t, networking.EnvoyFilter_CLUSTER, map[string]interface{}{
+		"name":  "legacy",
+		"hosts": []interface{}{map[string]interface{}{"socket_address": map[string]interface{}{"address": "legacy.example.com"}}},
+	})
+	issues := CheckEnvoyFilterCompatibility(cp, 15)
+	if len(issues) != 1 || !issues[0].Removed || issues[0].Element != "envoy.config.cluster.v3.Cluster.hosts" {
+		t.Errorf("expected the removed field to be reported, got %v", issues)
+	}
+}
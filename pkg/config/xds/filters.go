@@ -20,8 +20,10 @@ import (
 )
 
 const (
-	WasmHTTPFilterType = resource.APITypePrefix + wellknown.HTTPWasm
-	TypedStructType    = resource.APITypePrefix + "udpa.type.v1.TypedStruct"
+	WasmHTTPFilterType    = resource.APITypePrefix + wellknown.HTTPWasm
+	WasmNetworkFilterType = resource.APITypePrefix + "envoy.extensions.filters.network.wasm.v3.Wasm"
+	TypedStructType       = resource.APITypePrefix + "udpa.type.v1.TypedStruct"
+	BandwidthLimitType    = resource.APITypePrefix + "envoy.extensions.filters.http.bandwidth_limit.v3.BandwidthLimit"
 
 	StatsFilterName       = "istio.stats"
 	StackdriverFilterName = "istio.stackdriver"
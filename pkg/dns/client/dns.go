@@ -75,12 +75,14 @@ type LookupTable struct {
 	// The cname records here (comprised of different variants of the hosts above,
 	// expanded by the search namespaces) pointing to the actual host.
 	cname map[string][]dns.RR
+	// The hosts whose A/AAAA records are ordered by preference, and must not be shuffled.
+	ordered map[string]struct{}
 }
 
 const (
 	// In case the client decides to honor the TTL, keep it low so that we can always serve
 	// the latest IP for a host.
-	// TODO: make it configurable
+	// Services can override it with the networking.istio.io/dnsTTL annotation.
 	defaultTTLInSeconds = 30
 )
 
@@ -190,6 +192,7 @@ func (h *LocalDNSServer) UpdateLookupTable(nt *dnsProto.NameTable) {
 		name4:    map[string][]dns.RR{},
 		name6:    map[string][]dns.RR{},
 		cname:    map[string][]dns.RR{},
+		ordered:  map[string]struct{}{},
 	}
 	h.BuildAlternateHosts(nt, lookupTable.buildDNSAnswers)
 	h.lookupTable.Store(lookupTable)
@@ -198,9 +201,10 @@ func (h *LocalDNSServer) UpdateLookupTable(nt *dnsProto.NameTable) {
 }
 
 // BuildAlternateHosts builds alternate hosts for Kubernetes services in the name table and
-// calls the passed in function with the built alternate hosts.
+// calls the passed in function with the built alternate hosts, the TTL of their records and whether their
+// addresses are ordered by preference.
 func (h *LocalDNSServer) BuildAlternateHosts(nt *dnsProto.NameTable,
-	apply func(map[string]struct{}, []net.IP, []net.IP, []string),
+	apply func(map[string]struct{}, []net.IP, []net.IP, []string, uint32, bool),
 ) {
 	for hostname, ni := range nt.Table {
 		// Given a host
@@ -221,7 +225,11 @@ func (h *LocalDNSServer) BuildAlternateHosts(nt *dnsProto.NameTable,
 			// malformed ips
 			continue
 		}
-		apply(altHosts, ipv4, ipv6, h.searchNamespaces)
+		ttl := ni.Ttl
+		if ttl == 0 {
+			ttl = defaultTTLInSeconds
+		}
+		apply(altHosts, ipv4, ipv6, h.searchNamespaces, ttl, ni.Ordered)
 	}
 }
 
@@ -278,7 +286,7 @@ func (h *LocalDNSServer) ServeDNS(proxy *dnsProxy, w dns.ResponseWriter, req *dn
 	// This name will always end in a dot.
 	// We expect only one question in the query even though the spec allows many
 	// clients usually do not do more than one query either.
-	answers, hostFound := lookupTable.lookupHost(req.Question[0].Qtype, hostname)
+	answers, hostFound, ordered := lookupTable.lookupHost(req.Question[0].Qtype, hostname)
 
 	if hostFound {
 		response = new(dns.Msg)
@@ -292,8 +300,9 @@ func (h *LocalDNSServer) ServeDNS(proxy *dnsProxy, w dns.ResponseWriter, req *dn
 		response.Answer = answers
 		// Randomize the responses; this ensures for things like headless services we can do DNS-LB
 		// This matches standard kube-dns behavior. We only do this for cached responses as the
-		// upstream DNS server would already round robin if desired.
-		if len(answers) > 0 {
+		// upstream DNS server would already round robin if desired. Answers ordered by istiod, for instance by
+		// locality, are returned as is.
+		if len(answers) > 0 && !ordered {
 			roundRobinResponse(response)
 		}
 		log.Debugf("response for hostname %q (found=true): %v", hostname, response)
@@ -521,7 +530,8 @@ func generateAltHosts(hostname string, nameinfo *dnsProto.NameTable_NameInfo, pr
 // Given a host, this function first decides if the host is part of our service registry.
 // If it is not part of the registry, return nil so that caller queries upstream. If it is part
 // of registry, we will look it up in one of our tables, failing which we will return NXDOMAIN.
-func (table *LookupTable) lookupHost(qtype uint16, hostname string) ([]dns.RR, bool) {
+// It also returns whether the A/AAAA records are ordered by preference.
+func (table *LookupTable) lookupHost(qtype uint16, hostname string) ([]dns.RR, bool, bool) {
 	var hostFound bool
 
 	question := host.Name(hostname)
@@ -544,7 +554,7 @@ func (table *LookupTable) lookupHost(qtype uint16, hostname string) ([]dns.RR, b
 	}
 
 	if !hostFound {
-		return nil, false
+		return nil, false, false
 	}
 
 	var out []dns.RR
@@ -565,8 +575,9 @@ func (table *LookupTable) lookupHost(qtype uint16, hostname string) ([]dns.RR, b
 		ipAnswers = table.name6[hostname]
 	default:
 		// TODO: handle PTR records for reverse dns lookups
-		return nil, false
+		return nil, false, false
 	}
+	_, ordered := table.ordered[hostname]
 
 	if len(ipAnswers) > 0 {
 		// For wildcard hosts, set the host that is being queried for.
@@ -589,7 +600,7 @@ func (table *LookupTable) lookupHost(qtype uint16, hostname string) ([]dns.RR, b
 			out = append(out, ipAnswers...)
 		}
 	}
-	return out, hostFound
+	return out, hostFound, ordered
 }
 
 // This function stores the list of hostnames along with the precomputed DNS response for that hostname.
@@ -607,15 +618,20 @@ func (table *LookupTable) lookupHost(qtype uint16, hostname string) ([]dns.RR, b
 // in the lookup table with a CNAME record as the DNS response. This technique eliminates the need
 // to do string parsing, memory allocations, etc. at query time at the cost of Nx number of entries (i.e. memory) to store
 // the lookup table, where N is number of search namespaces.
-func (table *LookupTable) buildDNSAnswers(altHosts map[string]struct{}, ipv4 []net.IP, ipv6 []net.IP, searchNamespaces []string) {
+func (table *LookupTable) buildDNSAnswers(altHosts map[string]struct{}, ipv4 []net.IP, ipv6 []net.IP, searchNamespaces []string,
+	ttl uint32, ordered bool,
+) {
 	for h := range altHosts {
 		h = strings.ToLower(h)
 		table.allHosts[h] = struct{}{}
+		if ordered {
+			table.ordered[h] = struct{}{}
+		}
 		if len(ipv4) > 0 {
-			table.name4[h] = a(h, ipv4)
+			table.name4[h] = withTTL(a(h, ipv4), ttl)
 		}
 		if len(ipv6) > 0 {
-			table.name6[h] = aaaa(h, ipv6)
+			table.name6[h] = withTTL(aaaa(h, ipv6), ttl)
 		}
 		if len(searchNamespaces) > 0 {
 			// NOTE: Right now, rather than storing one expanded host for each one of the search namespace
@@ -633,7 +649,7 @@ func (table *LookupTable) buildDNSAnswers(altHosts map[string]struct{}, ipv4 []n
 			// then the expanded host productpage.ns1.svc.cluster.local is a valid hostname
 			// that is likely to be already present in the altHosts
 			if _, exists := altHosts[expandedHost]; !exists {
-				table.cname[expandedHost] = cname(expandedHost, h)
+				table.cname[expandedHost] = withTTL(cname(expandedHost, h), ttl)
 				table.allHosts[expandedHost] = struct{}{}
 			}
 		}
@@ -677,6 +693,14 @@ func cname(host string, targetHost string) []dns.RR {
 	return []dns.RR{answer}
 }
 
+// withTTL sets the TTL of the records.
+func withTTL(rrs []dns.RR, ttl uint32) []dns.RR {
+	for _, rr := range rrs {
+		rr.Header().Ttl = ttl
+	}
+	return rrs
+}
+
 // Size returns if buffer size *advertised* in the requests OPT record.
 // Or when the request was over TCP, we return the maximum allowed size of 64K.
 func size(proto string, r *dns.Msg) int {
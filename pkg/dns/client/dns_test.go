@@ -55,6 +55,19 @@ func testDNS(t *testing.T, d *LocalDNSServer) {
 			host:     "www.google.com.",
 			expected: a("www.google.com.", []net.IP{net.ParseIP("1.1.1.1").To4()}),
 		},
+		{
+			name:     "success: host with its own TTL",
+			host:     "short-ttl.localhost.",
+			expected: withTTL(a("short-ttl.localhost.", []net.IP{net.ParseIP("3.3.3.3").To4()}), 5),
+		},
+		{
+			name: "success: host with ordered addresses keeps their order",
+			host: "ordered.localhost.",
+			expected: a("ordered.localhost.", []net.IP{
+				net.ParseIP("4.4.4.4").To4(), net.ParseIP("3.3.3.3").To4(), net.ParseIP("2.2.2.2").To4(),
+				net.ParseIP("5.5.5.5").To4(), net.ParseIP("1.1.1.1").To4(),
+			}),
+		},
 		{
 			name: "success: non k8s host with search namespace yields cname+A record",
 			host: "www.google.com.ns1.svc.cluster.local.",
@@ -549,6 +562,16 @@ func initDNS(t test.Failer, forwardToUpstreamParallel bool) *LocalDNSServer {
 				Ips:      []string{"2.2.2.2"},
 				Registry: "External",
 			},
+			"short-ttl.localhost": {
+				Ips:      []string{"3.3.3.3"},
+				Registry: "External",
+				Ttl:      5,
+			},
+			"ordered.localhost": {
+				Ips:      []string{"4.4.4.4", "3.3.3.3", "2.2.2.2", "5.5.5.5", "1.1.1.1"},
+				Registry: "External",
+				Ordered:  true,
+			},
 			"*.b.wildcard": {
 				Ips:      []string{"11.11.11.11"},
 				Registry: "External",
@@ -16,6 +16,7 @@ package server
 
 import (
 	"net"
+	"sort"
 	"strings"
 
 	"istio.io/istio/pilot/pkg/model"
@@ -32,6 +33,11 @@ type Config struct {
 	// MulticlusterHeadlessEnabled if true, the DNS name table for a headless service will resolve to
 	// same-network endpoints in any cluster.
 	MulticlusterHeadlessEnabled bool
+
+	// LocalityOrderingEnabled if true, the endpoint IPs of a headless service are ordered by their proximity to
+	// the proxy: same locality first, and within a locality, same cluster first. The agent keeps this order
+	// rather than shuffling the answers.
+	LocalityOrderingEnabled bool
 }
 
 // BuildNameTable produces a table of hostnames and their associated IPs that can then
@@ -49,6 +55,9 @@ func BuildNameTable(cfg Config) *dnsProto.NameTable {
 	for _, svc := range cfg.Node.SidecarScope.Services() {
 		svcAddress := svc.GetAddressForProxy(cfg.Node)
 		var addressList []string
+		// priorities holds the proximity of each endpoint in addressList to the proxy, lower is closer.
+		var priorities []int
+		ordered := false
 		hostName := svc.Hostname
 		if svcAddress != constants.UnspecifiedIP {
 			// Filter out things we cannot parse as IP. Generally this means CIDRs, as anything else
@@ -56,7 +65,8 @@ func BuildNameTable(cfg Config) *dnsProto.NameTable {
 			if addr := net.ParseIP(svcAddress); addr == nil {
 				continue
 			}
-			addressList = append(addressList, svcAddress)
+			// Dual-stack proxies resolve dual-stack services to the addresses of both IP families.
+			addressList = append(addressList, svc.GetAllAddressesForProxy(cfg.Node)...)
 		} else {
 			// The IP will be unspecified here if its headless service or if the auto
 			// IP allocation logic for service entry was unable to allocate an IP.
@@ -82,6 +92,7 @@ func BuildNameTable(cfg Config) *dnsProto.NameTable {
 							Registry:  string(svc.Attributes.ServiceRegistry),
 							Namespace: svc.Attributes.Namespace,
 							Shortname: shortName,
+							Ttl:       uint32(svc.Attributes.DNSTTL.Seconds()),
 						}
 
 						if _, f := out.Table[host]; !f || sameCluster {
@@ -105,8 +116,13 @@ func BuildNameTable(cfg Config) *dnsProto.NameTable {
 					}
 					// TODO: should we skip the node's own IP like we do in listener?
 					addressList = append(addressList, instance.Endpoint.Address)
+					priorities = append(priorities, endpointPriority(cfg.Node, instance.Endpoint, sameCluster))
 				}
 			}
+			if cfg.LocalityOrderingEnabled {
+				sortByPriority(addressList, priorities)
+				ordered = true
+			}
 			if len(addressList) == 0 {
 				// could not reliably determine the addresses of endpoints of headless service
 				// or this is not a k8s service
@@ -117,6 +133,8 @@ func BuildNameTable(cfg Config) *dnsProto.NameTable {
 		nameInfo := &dnsProto.NameTable_NameInfo{
 			Ips:      addressList,
 			Registry: string(svc.Attributes.ServiceRegistry),
+			Ttl:      uint32(svc.Attributes.DNSTTL.Seconds()),
+			Ordered:  ordered,
 		}
 		if svc.Attributes.ServiceRegistry == provider.Kubernetes &&
 			!strings.HasSuffix(hostName.String(), "."+constants.DefaultClusterSetLocalDomain) {
@@ -132,3 +150,47 @@ func BuildNameTable(cfg Config) *dnsProto.NameTable {
 	}
 	return out
 }
+
+// endpointPriority returns the proximity of an endpoint to the proxy. Locality takes precedence over the cluster,
+// as a cluster may span several zones while a zone is rarely shared by clusters.
+func endpointPriority(node *model.Proxy, ep *model.IstioEndpoint, sameCluster bool) int {
+	priority := localityPriority(node, ep.Locality.Label) * 2
+	if !sameCluster {
+		priority++
+	}
+	return priority
+}
+
+// localityPriority returns 0 if the locality label matches the locality of the proxy, 1 if only its region and zone
+// match, 2 if only its region matches and 3 otherwise.
+func localityPriority(node *model.Proxy, label string) int {
+	region, zone, subzone := model.SplitLocalityLabel(label)
+	switch {
+	case node.Locality.GetRegion() != region:
+		return 3
+	case node.Locality.GetZone() != zone:
+		return 2
+	case node.Locality.GetSubZone() != subzone:
+		return 1
+	}
+	return 0
+}
+
+// sortByPriority orders addresses by ascending priority, keeping the original order within a priority.
+func sortByPriority(addresses []string, priorities []int) {
+	sort.Stable(addressesByPriority{addresses: addresses, priorities: priorities})
+}
+
+type addressesByPriority struct {
+	addresses  []string
+	priorities []int
+}
+
+func (a addressesByPriority) Len() int { return len(a.addresses) }
+
+func (a addressesByPriority) Less(i, j int) bool { return a.priorities[i] < a.priorities[j] }
+
+func (a addressesByPriority) Swap(i, j int) {
+	a.addresses[i], a.addresses[j] = a.addresses[j], a.addresses[i]
+	a.priorities[i], a.priorities[j] = a.priorities[j], a.priorities[i]
+}
@@ -17,12 +17,14 @@ package server_test
 import (
 	"testing"
 
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/protobuf/testing/protocmp"
 
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/serviceregistry/provider"
+	"istio.io/istio/pkg/cluster"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/protocol"
@@ -453,3 +455,89 @@ func makeInstances(proxy *model.Proxy, svc *model.Service, servicePort int, targ
 	}
 	return ret
 }
+
+func TestNameTableLocalityOrdering(t *testing.T) {
+	proxy := &model.Proxy{
+		IPAddresses: []string{"9.9.9.9"},
+		Metadata:    &model.NodeMetadata{ClusterID: "cl1"},
+		Type:        model.SidecarProxy,
+		DNSDomain:   "testns.svc.cluster.local",
+		Locality:    &core.Locality{Region: "r1", Zone: "z1"},
+	}
+	svc := &model.Service{
+		Hostname:       host.Name("foo.bar.com"),
+		DefaultAddress: constants.UnspecifiedIP,
+		Ports: model.PortList{&model.Port{
+			Name:     "tcp-port",
+			Port:     9000,
+			Protocol: protocol.TCP,
+		}},
+		Resolution: model.Passthrough,
+		Attributes: model.ServiceAttributes{
+			Name:            "foo.bar.com",
+			Namespace:       "testns",
+			ServiceRegistry: provider.External,
+		},
+	}
+	push := model.NewPushContext()
+	push.Mesh = &meshconfig.MeshConfig{RootNamespace: "istio-system"}
+	push.AddPublicServices([]*model.Service{svc})
+	endpoints := []struct {
+		address  string
+		locality string
+		cluster  cluster.ID
+	}{
+		{"1.1.1.1", "r2/z1", "cl2"},
+		{"2.2.2.2", "r1/z2", "cl1"},
+		{"3.3.3.3", "r1/z1", "cl2"},
+		{"4.4.4.4", "r1/z1", "cl1"},
+		{"5.5.5.5", "r2/z1", "cl1"},
+	}
+	for _, ep := range endpoints {
+		push.AddServiceInstances(svc, map[int][]*model.ServiceInstance{
+			9000: {{
+				Service:     svc,
+				ServicePort: svc.Ports[0],
+				Endpoint: &model.IstioEndpoint{
+					Address:         ep.address,
+					ServicePortName: "tcp-port",
+					EndpointPort:    9000,
+					Locality:        model.Locality{Label: ep.locality, ClusterID: ep.cluster},
+				},
+			}},
+		})
+	}
+	proxy.SidecarScope = model.ConvertToSidecarScope(push, nil, "default")
+
+	cases := []struct {
+		name     string
+		enabled  bool
+		expected []string
+	}{
+		{
+			name:     "disabled",
+			expected: []string{"1.1.1.1", "2.2.2.2", "3.3.3.3", "4.4.4.4", "5.5.5.5"},
+		},
+		{
+			name:     "enabled",
+			enabled:  true,
+			expected: []string{"4.4.4.4", "3.3.3.3", "2.2.2.2", "5.5.5.5", "1.1.1.1"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			nt := dnsServer.BuildNameTable(dnsServer.Config{
+				Node:                        proxy,
+				Push:                        push,
+				MulticlusterHeadlessEnabled: true,
+				LocalityOrderingEnabled:     tt.enabled,
+			})
+			if diff := cmp.Diff(nt.Table["foo.bar.com"].Ips, tt.expected); diff != "" {
+				t.Fatalf("got diff: %v", diff)
+			}
+			if nt.Table["foo.bar.com"].Ordered != tt.enabled {
+				t.Fatalf("expected ordered %v, got %v", tt.enabled, nt.Table["foo.bar.com"].Ordered)
+			}
+		})
+	}
+}
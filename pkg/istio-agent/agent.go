@@ -127,6 +127,9 @@ type Agent struct {
 	// local DNS Server that processes DNS requests locally and forwards to upstream DNS if needed.
 	localDNSServer *dnsClient.LocalDNSServer
 
+	// startup records how long the startup phases take.
+	startup *startupTracker
+
 	// Signals true completion (e.g. with delayed graceful termination of Envoy)
 	wg sync.WaitGroup
 }
@@ -196,6 +199,9 @@ type AgentOptions struct {
 
 	ExitOnZeroActiveConnections bool
 
+	// DrainHealthCheckPath is the path of the status port health check failing once the proxy is drained.
+	DrainHealthCheckPath string
+
 	// Cloud platform
 	Platform platform.Environment
 
@@ -208,6 +214,10 @@ type AgentOptions struct {
 
 	IstiodSAN string
 
+	// XDSCompression is the compressor used on the xDS streams to istiod, to reduce their bandwidth. Empty disables
+	// compression.
+	XDSCompression string
+
 	WASMOptions wasm.Options
 }
 
@@ -215,13 +225,29 @@ type AgentOptions struct {
 // associated clients to sign certificates (when not using files), and the local XDS proxy (including
 // health checking for VMs and DNS proxying).
 func NewAgent(proxyConfig *mesh.ProxyConfig, agentOpts *AgentOptions, sopts *security.Options, eopts envoy.ProxyConfig) *Agent {
-	return &Agent{
+	a := &Agent{
 		proxyConfig:   proxyConfig,
 		cfg:           agentOpts,
 		secOpts:       sopts,
 		envoyOpts:     eopts,
 		caFileWatcher: filewatcher.NewWatcher(),
 	}
+	if a.EnvoyDisabled() {
+		a.startup = newStartupTracker(time.Now(), StartupPhaseCertificate)
+	} else {
+		a.startup = newStartupTracker(time.Now(), StartupPhaseCertificate, StartupPhaseXDSAck, StartupPhaseEnvoyReady)
+	}
+	return a
+}
+
+// StartupReport reports how long the startup phases of the agent took.
+func (a *Agent) StartupReport() StartupReport {
+	return a.startup.report()
+}
+
+// RecordEnvoyReady records that the readiness probe succeeded, completing the startup of Envoy.
+func (a *Agent) RecordEnvoyReady() {
+	a.startup.record(StartupPhaseEnvoyReady)
 }
 
 // EnvoyDisabled if true indicates calling Run will not run and wait for Envoy.
@@ -265,6 +291,7 @@ func (a *Agent) generateNodeMetadata() (*model.Node, error) {
 		EnvoyPrometheusPort:         a.cfg.EnvoyPrometheusPort,
 		EnvoyStatusPort:             a.cfg.EnvoyStatusPort,
 		ExitOnZeroActiveConnections: a.cfg.ExitOnZeroActiveConnections,
+		DrainHealthCheckPath:        a.cfg.DrainHealthCheckPath,
 		XDSRootCert:                 a.cfg.XDSRootCerts,
 	})
 }
@@ -428,6 +455,7 @@ func (a *Agent) Run(ctx context.Context) (func(), error) {
 
 	if socketExists {
 		log.Info("Workload SDS socket found. Istio SDS Server won't be started")
+		a.startup.skip(StartupPhaseCertificate)
 	} else {
 		log.Info("Workload SDS socket not found. Starting Istio SDS Server")
 		err = a.initSdsServer()
@@ -533,11 +561,13 @@ func (a *Agent) initSdsServer() error {
 				// TODO: extract the logic to detect expiration time, and use a simpler code to rotate to files.
 				_, _ = a.getWorkloadCerts(st)
 			})
-			_, _ = a.getWorkloadCerts(st)
+			if _, err := a.getWorkloadCerts(st); err == nil {
+				a.startup.record(StartupPhaseCertificate)
+			}
 		}()
 	} else {
 		pkpConf := a.proxyConfig.GetPrivateKeyProvider()
-		a.sdsServer = sds.NewServer(a.secOpts, a.secretCache, pkpConf)
+		a.sdsServer = sds.NewServer(a.secOpts, startupSecretManager{SecretManager: a.secretCache, startup: a.startup}, pkpConf)
 		a.secretCache.RegisterSecretHandler(a.sdsServer.OnSecretUpdate)
 	}
 
@@ -649,7 +679,7 @@ func (a *Agent) GetDNSTable() *dnsProto.NameTable {
 	if a.localDNSServer != nil && a.localDNSServer.NameTable() != nil {
 		nt := a.localDNSServer.NameTable()
 		nt = proto.Clone(nt).(*dnsProto.NameTable)
-		a.localDNSServer.BuildAlternateHosts(nt, func(althosts map[string]struct{}, ipv4 []net.IP, ipv6 []net.IP, _ []string) {
+		a.localDNSServer.BuildAlternateHosts(nt, func(althosts map[string]struct{}, ipv4 []net.IP, ipv6 []net.IP, _ []string, _ uint32, _ bool) {
 			for host := range althosts {
 				if _, exists := nt.Table[host]; !exists {
 					addresses := make([]string, len(ipv4)+len(ipv6))
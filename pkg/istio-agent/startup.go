@@ -0,0 +1,152 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package istioagent
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/security"
+	"istio.io/pkg/log"
+)
+
+// Startup phases of the agent, in the order they usually complete.
+const (
+	// StartupPhaseCertificate completes when the workload certificate is first received from the CA.
+	StartupPhaseCertificate = "certificate"
+	// StartupPhaseXDSAck completes when Envoy first ACKs a configuration pushed by istiod.
+	StartupPhaseXDSAck = "xdsAck"
+	// StartupPhaseEnvoyReady completes when the readiness probe first succeeds.
+	StartupPhaseEnvoyReady = "envoyReady"
+)
+
+// StartupPhase is a completed startup phase, with the time elapsed since the agent started.
+type StartupPhase struct {
+	Name           string  `json:"name"`
+	ElapsedSeconds float64 `json:"elapsedSeconds"`
+}
+
+// StartupReport reports how long the startup phases of the agent took, to diagnose slow starting proxies.
+type StartupReport struct {
+	Start    time.Time      `json:"start"`
+	Phases   []StartupPhase `json:"phases"`
+	Pending  []string       `json:"pending,omitempty"`
+	Complete bool           `json:"complete"`
+}
+
+// startupTracker records when the startup phases first complete, and calls onComplete once they all have.
+type startupTracker struct {
+	mu         sync.Mutex
+	start      time.Time
+	required   []string
+	completed  map[string]time.Duration
+	reported   bool
+	onComplete func(StartupReport)
+}
+
+func newStartupTracker(start time.Time, required ...string) *startupTracker {
+	return &startupTracker{
+		start:     start,
+		required:  required,
+		completed: map[string]time.Duration{},
+	}
+}
+
+// skip removes the phase from the phases required to complete the startup, such as the certificate phase when
+// certificates are provided by another SDS server.
+func (t *startupTracker) skip(phase string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	required := make([]string, 0, len(t.required))
+	for _, p := range t.required {
+		if p != phase {
+			required = append(required, p)
+		}
+	}
+	t.required = required
+}
+
+// setOnComplete sets the function called with the report once all the required phases are completed.
+func (t *startupTracker) setOnComplete(f func(StartupReport)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onComplete = f
+}
+
+// record marks the phase as completed, if it is not already.
+func (t *startupTracker) record(phase string) {
+	t.mu.Lock()
+	if _, f := t.completed[phase]; f {
+		t.mu.Unlock()
+		return
+	}
+	elapsed := time.Since(t.start)
+	t.completed[phase] = elapsed
+	report := t.reportLocked()
+	var onComplete func(StartupReport)
+	if report.Complete && !t.reported {
+		t.reported = true
+		onComplete = t.onComplete
+	}
+	t.mu.Unlock()
+	log.Infof("Startup phase %s completed in %v", phase, elapsed)
+	if onComplete != nil {
+		onComplete(report)
+	}
+}
+
+func (t *startupTracker) report() StartupReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.reportLocked()
+}
+
+func (t *startupTracker) reportLocked() StartupReport {
+	report := StartupReport{Start: t.start, Phases: []StartupPhase{}}
+	for _, phase := range t.required {
+		if elapsed, f := t.completed[phase]; f {
+			report.Phases = append(report.Phases, StartupPhase{Name: phase, ElapsedSeconds: elapsed.Seconds()})
+		} else {
+			report.Pending = append(report.Pending, phase)
+		}
+	}
+	report.Complete = len(report.Pending) == 0
+	return report
+}
+
+// startupResourceNames encodes the phases of the report as <phase>=<elapsed> names, sent to istiod in the resource
+// names of a StartupReportType request.
+func startupResourceNames(report StartupReport) []string {
+	names := make([]string, 0, len(report.Phases))
+	for _, p := range report.Phases {
+		names = append(names, fmt.Sprintf("%s=%v", p.Name, time.Duration(p.ElapsedSeconds*float64(time.Second)).Round(time.Millisecond)))
+	}
+	return names
+}
+
+// startupSecretManager records the certificate phase when the workload certificate is first generated.
+type startupSecretManager struct {
+	security.SecretManager
+	startup *startupTracker
+}
+
+func (s startupSecretManager) GenerateSecret(resourceName string) (*security.SecretItem, error) {
+	secret, err := s.SecretManager.GenerateSecret(resourceName)
+	if err == nil && resourceName == security.WorkloadKeyCertResourceName {
+		s.startup.record(StartupPhaseCertificate)
+	}
+	return secret, err
+}
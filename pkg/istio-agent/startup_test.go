@@ -0,0 +1,76 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package istioagent
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+type fakeSecretManager struct{}
+
+func (fakeSecretManager) GenerateSecret(string) (*security.SecretItem, error) {
+	return &security.SecretItem{}, nil
+}
+
+func TestStartupTracker(t *testing.T) {
+	tracker := newStartupTracker(time.Now().Add(-time.Second), StartupPhaseCertificate, StartupPhaseXDSAck, StartupPhaseEnvoyReady)
+	var reports []StartupReport
+	tracker.setOnComplete(func(r StartupReport) {
+		reports = append(reports, r)
+	})
+
+	sm := startupSecretManager{SecretManager: fakeSecretManager{}, startup: tracker}
+	_, _ = sm.GenerateSecret(security.RootCertReqResourceName)
+	assert.Equal(t, tracker.report().Pending, []string{StartupPhaseCertificate, StartupPhaseXDSAck, StartupPhaseEnvoyReady})
+	_, _ = sm.GenerateSecret(security.WorkloadKeyCertResourceName)
+	tracker.record(StartupPhaseEnvoyReady)
+	assert.Equal(t, tracker.report().Pending, []string{StartupPhaseXDSAck})
+	assert.Equal(t, len(reports), 0)
+
+	tracker.record(StartupPhaseXDSAck)
+	tracker.record(StartupPhaseXDSAck)
+	assert.Equal(t, len(reports), 1)
+	report := reports[0]
+	assert.Equal(t, report.Complete, true)
+	assert.Equal(t, len(report.Phases), 3)
+	names := startupResourceNames(report)
+	for i, phase := range []string{StartupPhaseCertificate, StartupPhaseXDSAck, StartupPhaseEnvoyReady} {
+		if !strings.HasPrefix(names[i], phase+"=1") {
+			t.Errorf("unexpected resource name %q for phase %s", names[i], phase)
+		}
+	}
+}
+
+func TestStartupTrackerSkip(t *testing.T) {
+	tracker := newStartupTracker(time.Now(), StartupPhaseCertificate, StartupPhaseXDSAck)
+	completed := false
+	tracker.setOnComplete(func(StartupReport) {
+		completed = true
+	})
+	tracker.skip(StartupPhaseCertificate)
+	tracker.record(StartupPhaseXDSAck)
+	assert.Equal(t, completed, true)
+}
+
+func TestSendStartupReportWhenDisconnected(t *testing.T) {
+	proxy := setupXdsProxy(t)
+	proxy.sendStartupReport(StartupReport{Phases: []StartupPhase{{Name: StartupPhaseXDSAck, ElapsedSeconds: 1.5}}})
+	assert.Equal(t, proxy.pendingStartupReport, []string{"xdsAck=1.5s"})
+}
@@ -103,7 +103,13 @@ type XdsProxy struct {
 	connected                 *ProxyConnection
 	initialHealthRequest      *discovery.DiscoveryRequest
 	initialDeltaHealthRequest *discovery.DeltaDiscoveryRequest
-	connectedMutex            sync.RWMutex
+	// pendingStartupReport is the startup report to send once connected, if the proxy was not connected when the
+	// startup completed.
+	pendingStartupReport []string
+	connectedMutex       sync.RWMutex
+
+	// startup records the first xDS ACK from Envoy.
+	startup *startupTracker
 
 	// Wasm cache and ecds channel are used to replace wasm remote load with local file.
 	wasmCache wasm.Cache
@@ -154,6 +160,10 @@ func initXdsProxy(ia *Agent) (*XdsProxy, error) {
 		wasmCache:             cache,
 		proxyAddresses:        ia.cfg.ProxyIPAddresses,
 		downstreamGrpcOptions: ia.cfg.DownstreamGrpcOptions,
+		startup:               ia.startup,
+	}
+	if !ia.EnvoyDisabled() {
+		ia.startup.setOnComplete(proxy.sendStartupReport)
 	}
 
 	if ia.localDNSServer != nil {
@@ -236,6 +246,38 @@ func (p *XdsProxy) sendHealthCheckRequest(req *discovery.DiscoveryRequest) {
 	p.connectedMutex.Unlock()
 }
 
+// sendStartupReport sends the startup report to istiod, which records it as an event of the pod. If the proxy is not
+// connected, the report is sent on the next connection.
+func (p *XdsProxy) sendStartupReport(report StartupReport) {
+	names := startupResourceNames(report)
+	p.connectedMutex.Lock()
+	defer p.connectedMutex.Unlock()
+	switch {
+	case p.connected != nil && p.connected.requestsChan != nil:
+		p.connected.requestsChan.Put(&discovery.DiscoveryRequest{TypeUrl: v3.StartupReportType, ResourceNames: names})
+	case p.connected != nil && p.connected.deltaRequestsChan != nil:
+		p.connected.deltaRequestsChan.Put(&discovery.DeltaDiscoveryRequest{TypeUrl: v3.StartupReportType, ResourceNamesSubscribe: names})
+	default:
+		p.pendingStartupReport = names
+	}
+}
+
+// sendPendingStartupReport sends the startup report which could not be sent when the startup completed.
+func (p *XdsProxy) sendPendingStartupReport(con *ProxyConnection) {
+	p.connectedMutex.Lock()
+	names := p.pendingStartupReport
+	p.pendingStartupReport = nil
+	p.connectedMutex.Unlock()
+	if names == nil {
+		return
+	}
+	if con.requestsChan != nil {
+		con.sendRequest(&discovery.DiscoveryRequest{TypeUrl: v3.StartupReportType, ResourceNames: names})
+	} else {
+		con.sendDeltaRequest(&discovery.DeltaDiscoveryRequest{TypeUrl: v3.StartupReportType, ResourceNamesSubscribe: names})
+	}
+}
+
 func (p *XdsProxy) unregisterStream(c *ProxyConnection) {
 	p.connectedMutex.Lock()
 	defer p.connectedMutex.Unlock()
@@ -444,6 +486,9 @@ func (p *XdsProxy) handleUpstreamRequest(con *ProxyConnection) {
 				return
 			}
 
+			if req.ResponseNonce != "" && req.ErrorDetail == nil {
+				p.startup.record(StartupPhaseXDSAck)
+			}
 			// forward to istiod
 			con.sendRequest(req)
 			if !initialRequestsSent.Load() && req.TypeUrl == v3.ListenerType {
@@ -468,6 +513,7 @@ func (p *XdsProxy) handleUpstreamRequest(con *ProxyConnection) {
 					con.sendRequest(initialRequest)
 				}
 				p.connectedMutex.RUnlock()
+				p.sendPendingStartupReport(con)
 			}
 		}
 	}()
@@ -679,6 +725,14 @@ func (p *XdsProxy) buildUpstreamClientDialOpts(sa *Agent) ([]grpc.DialOption, er
 		keepaliveOption, initialWindowSizeOption, initialConnWindowSizeOption, msgSizeOption,
 	}
 
+	if err := istiogrpc.ValidateCompressor(sa.cfg.XDSCompression); err != nil {
+		return nil, err
+	}
+	if sa.cfg.XDSCompression != "" {
+		// istiod compresses its responses with the compressor used for the requests.
+		dialOptions = append(dialOptions, grpc.WithDefaultCallOptions(grpc.UseCompressor(sa.cfg.XDSCompression)))
+	}
+
 	dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(caclient.NewXDSTokenProvider(sa.secOpts)))
 	return dialOptions, nil
 }
@@ -77,6 +77,9 @@ func (p *XdsProxy) DeltaAggregatedResources(downstream xds.DeltaDiscoveryStream)
 				}
 				return
 			}
+			if req.ResponseNonce != "" && req.ErrorDetail == nil {
+				p.startup.record(StartupPhaseXDSAck)
+			}
 			// forward to istiod
 			con.sendDeltaRequest(req)
 			if !initialRequestsSent && req.TypeUrl == v3.ListenerType {
@@ -96,6 +99,7 @@ func (p *XdsProxy) DeltaAggregatedResources(downstream xds.DeltaDiscoveryStream)
 				if initialRequest != nil {
 					con.sendDeltaRequest(initialRequest)
 				}
+				p.sendPendingStartupReport(con)
 				initialRequestsSent = true
 			}
 		}
@@ -166,6 +166,9 @@ type ExtendedClient interface {
 	// AllDiscoveryDo makes an http request to each Istio discovery instance.
 	AllDiscoveryDo(ctx context.Context, namespace, path string) (map[string][]byte, error)
 
+	// AllDiscoveryPost makes an http POST request with the body to each Istio discovery instance.
+	AllDiscoveryPost(ctx context.Context, namespace, path string, body []byte) (map[string][]byte, error)
+
 	// GetIstioVersions gets the version for each Istio control plane component.
 	GetIstioVersions(ctx context.Context, namespace string) (*version.MeshInfo, error)
 
@@ -742,6 +745,14 @@ func (c *client) PodLogs(ctx context.Context, podName, podNamespace, container s
 }
 
 func (c *client) AllDiscoveryDo(ctx context.Context, istiodNamespace, path string) (map[string][]byte, error) {
+	return c.allDiscoveryRequest(ctx, istiodNamespace, http.MethodGet, path, nil)
+}
+
+func (c *client) AllDiscoveryPost(ctx context.Context, istiodNamespace, path string, body []byte) (map[string][]byte, error) {
+	return c.allDiscoveryRequest(ctx, istiodNamespace, http.MethodPost, path, body)
+}
+
+func (c *client) allDiscoveryRequest(ctx context.Context, istiodNamespace, method, path string, body []byte) (map[string][]byte, error) {
 	istiods, err := c.GetIstioPods(ctx, istiodNamespace, map[string]string{
 		"labelSelector": "app=istiod",
 		"fieldSelector": "status.phase=Running",
@@ -755,7 +766,7 @@ func (c *client) AllDiscoveryDo(ctx context.Context, istiodNamespace, path strin
 
 	result := map[string][]byte{}
 	for _, istiod := range istiods {
-		res, err := c.portForwardRequest(ctx, istiod.Name, istiod.Namespace, http.MethodGet, path, 15014)
+		res, err := c.portForwardRequest(ctx, istiod.Name, istiod.Namespace, method, path, 15014, body)
 		if err != nil {
 			return nil, err
 		}
@@ -771,14 +782,16 @@ func (c *client) AllDiscoveryDo(ctx context.Context, istiodNamespace, path strin
 }
 
 func (c *client) EnvoyDo(ctx context.Context, podName, podNamespace, method, path string) ([]byte, error) {
-	return c.portForwardRequest(ctx, podName, podNamespace, method, path, 15000)
+	return c.portForwardRequest(ctx, podName, podNamespace, method, path, 15000, nil)
 }
 
 func (c *client) EnvoyDoWithPort(ctx context.Context, podName, podNamespace, method, path string, port int) ([]byte, error) {
-	return c.portForwardRequest(ctx, podName, podNamespace, method, path, port)
+	return c.portForwardRequest(ctx, podName, podNamespace, method, path, port, nil)
 }
 
-func (c *client) portForwardRequest(ctx context.Context, podName, podNamespace, method, path string, port int) ([]byte, error) {
+func (c *client) portForwardRequest(ctx context.Context, podName, podNamespace, method, path string, port int,
+	body []byte,
+) ([]byte, error) {
 	formatError := func(err error) error {
 		return fmt.Errorf("failure running port forward process: %v", err)
 	}
@@ -791,7 +804,7 @@ func (c *client) portForwardRequest(ctx context.Context, podName, podNamespace,
 		return nil, formatError(err)
 	}
 	defer fw.Close()
-	req, err := http.NewRequest(method, fmt.Sprintf("http://%s/%s", fw.Address(), path), nil)
+	req, err := http.NewRequest(method, fmt.Sprintf("http://%s/%s", fw.Address(), path), bytes.NewReader(body))
 	if err != nil {
 		return nil, formatError(err)
 	}
@@ -0,0 +1,145 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inject
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/pkg/log"
+)
+
+// AnnotationPolicyAction is the action taken on pod annotations which are out of the annotation policy.
+type AnnotationPolicyAction string
+
+const (
+	// AnnotationPolicyReject fails the injection of pods with out of policy annotations.
+	AnnotationPolicyReject AnnotationPolicyAction = "reject"
+	// AnnotationPolicyMutate removes forbidden annotations and annotations with disallowed values, and lowers
+	// quantities above their maximum to the maximum.
+	AnnotationPolicyMutate AnnotationPolicyAction = "mutate"
+)
+
+// AnnotationPolicy restricts the annotations pods can set to customize their sidecar.
+type AnnotationPolicy struct {
+	// Action is the action taken on out of policy annotations. Defaults to reject.
+	Action AnnotationPolicyAction `json:"action"`
+
+	// Forbidden annotations may not be set on pods.
+	Forbidden []string `json:"forbidden"`
+
+	// AllowedValues restricts the values of annotations, such as `sidecar.istio.io/logLevel: [warning, error]`.
+	AllowedValues map[string][]string `json:"allowedValues"`
+
+	// MaxQuantities caps the resource quantities of annotations, such as `sidecar.istio.io/proxyCPU: 500m`.
+	MaxQuantities map[string]string `json:"maxQuantities"`
+}
+
+// Validate checks the policy is well formed.
+func (p *AnnotationPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	var err error
+	switch p.Action {
+	case "", AnnotationPolicyReject, AnnotationPolicyMutate:
+	default:
+		err = multierror.Append(err, fmt.Errorf("unknown annotation policy action %q, must be %s or %s",
+			p.Action, AnnotationPolicyReject, AnnotationPolicyMutate))
+	}
+	for name, maxValue := range p.MaxQuantities {
+		if _, e := resource.ParseQuantity(maxValue); e != nil {
+			err = multierror.Append(err, fmt.Errorf("invalid maximum quantity %q for annotation %s: %v", maxValue, name, e))
+		}
+	}
+	return err
+}
+
+// apply enforces the policy on the annotations of the pod. Out of policy annotations are modified in place if the
+// action is mutate, or reported as an error otherwise.
+func (p *AnnotationPolicy) apply(metadata *metav1.ObjectMeta) error {
+	if p == nil || len(metadata.Annotations) == 0 {
+		return nil
+	}
+	mutate := p.Action == AnnotationPolicyMutate
+	var violations []string
+	for _, name := range p.Forbidden {
+		if _, ok := metadata.Annotations[name]; !ok {
+			continue
+		}
+		if mutate {
+			log.Infof("removing forbidden annotation %s from pod %s/%s", name, metadata.Namespace, potentialPodName(*metadata))
+			delete(metadata.Annotations, name)
+			continue
+		}
+		violations = append(violations, fmt.Sprintf("annotation %s is forbidden", name))
+	}
+	for name, allowed := range p.AllowedValues {
+		value, ok := metadata.Annotations[name]
+		if !ok || contains(allowed, value) {
+			continue
+		}
+		if mutate {
+			log.Infof("removing annotation %s=%s from pod %s/%s: value is not allowed", name, value, metadata.Namespace, potentialPodName(*metadata))
+			delete(metadata.Annotations, name)
+			continue
+		}
+		violations = append(violations, fmt.Sprintf("value %q of annotation %s is not one of the allowed values %v", value, name, allowed))
+	}
+	for name, maxValue := range p.MaxQuantities {
+		value, ok := metadata.Annotations[name]
+		if !ok {
+			continue
+		}
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			// Invalid values are reported by the annotation validation.
+			continue
+		}
+		maxQuantity, err := resource.ParseQuantity(maxValue)
+		if err != nil || q.Cmp(maxQuantity) <= 0 {
+			continue
+		}
+		if mutate {
+			log.Infof("lowering annotation %s=%s of pod %s/%s to the maximum %s", name, value, metadata.Namespace, potentialPodName(*metadata), maxValue)
+			metadata.Annotations[name] = maxValue
+			continue
+		}
+		violations = append(violations, fmt.Sprintf("value %s of annotation %s exceeds the maximum %s", value, name, maxValue))
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	sort.Strings(violations)
+	var err error
+	for _, v := range violations {
+		err = multierror.Append(err, errors.New(v))
+	}
+	return err
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inject
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/api/annotation"
+)
+
+func TestAnnotationPolicy(t *testing.T) {
+	annotations := map[string]string{
+		annotation.SidecarInterceptionMode.Name: "TPROXY",
+		annotation.SidecarLogLevel.Name:         "debug",
+		annotation.SidecarProxyCPU.Name:         "2",
+		annotation.SidecarProxyMemory.Name:      "128Mi",
+	}
+	policy := &AnnotationPolicy{
+		Forbidden:     []string{annotation.SidecarInterceptionMode.Name},
+		AllowedValues: map[string][]string{annotation.SidecarLogLevel.Name: {"warning", "error"}},
+		MaxQuantities: map[string]string{
+			annotation.SidecarProxyCPU.Name:    "500m",
+			annotation.SidecarProxyMemory.Name: "1Gi",
+		},
+	}
+	cases := []struct {
+		name    string
+		action  AnnotationPolicyAction
+		want    map[string]string
+		wantErr []string
+	}{
+		{
+			name:   "reject",
+			action: AnnotationPolicyReject,
+			want:   annotations,
+			wantErr: []string{
+				"annotation sidecar.istio.io/interceptionMode is forbidden",
+				`value "debug" of annotation sidecar.istio.io/logLevel is not one of the allowed values [warning error]`,
+				"value 2 of annotation sidecar.istio.io/proxyCPU exceeds the maximum 500m",
+			},
+		},
+		{
+			name:   "mutate",
+			action: AnnotationPolicyMutate,
+			want: map[string]string{
+				annotation.SidecarProxyCPU.Name:    "500m",
+				annotation.SidecarProxyMemory.Name: "128Mi",
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			p := *policy
+			p.Action = tt.action
+			meta := &metav1.ObjectMeta{Name: "pod", Namespace: "default", Annotations: map[string]string{}}
+			for k, v := range annotations {
+				meta.Annotations[k] = v
+			}
+			err := p.apply(meta)
+			if len(tt.wantErr) == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("expected error containing %q, got %v", want, err)
+				}
+			}
+			if !reflect.DeepEqual(meta.Annotations, tt.want) {
+				t.Errorf("got annotations %v, want %v", meta.Annotations, tt.want)
+			}
+		})
+	}
+
+	var nilPolicy *AnnotationPolicy
+	if err := nilPolicy.apply(&metav1.ObjectMeta{Annotations: annotations}); err != nil {
+		t.Errorf("unexpected error for nil policy: %v", err)
+	}
+}
+
+func TestAnnotationPolicyValidate(t *testing.T) {
+	if err := (&AnnotationPolicy{Action: "drop"}).Validate(); err == nil {
+		t.Error("expected error for unknown action")
+	}
+	if err := (&AnnotationPolicy{MaxQuantities: map[string]string{annotation.SidecarProxyCPU.Name: "lots"}}).Validate(); err == nil {
+		t.Error("expected error for invalid quantity")
+	}
+	if err := (&AnnotationPolicy{Action: AnnotationPolicyMutate}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSidecarAnnotationWarnings(t *testing.T) {
+	cases := []struct {
+		annotations map[string]string
+		valid       bool
+	}{
+		{map[string]string{annotation.SidecarProxyCPU.Name: "100m", annotation.SidecarProxyMemoryLimit.Name: "1Gi"}, true},
+		{map[string]string{annotation.SidecarProxyCPULimit.Name: "one"}, false},
+		{map[string]string{annotation.SidecarLogLevel.Name: "warning"}, true},
+		{map[string]string{annotation.SidecarLogLevel.Name: "verbose"}, false},
+		{map[string]string{annotation.SidecarComponentLogLevel.Name: "misc:error,upstream:debug"}, true},
+		{map[string]string{annotation.SidecarComponentLogLevel.Name: "debug"}, false},
+		{map[string]string{annotation.SidecarComponentLogLevel.Name: "upstream:loud"}, false},
+	}
+	for _, tt := range cases {
+		// These values used to be accepted, so they are only warned about.
+		if err := validateAnnotations(tt.annotations); err != nil {
+			t.Errorf("validateAnnotations(%v) = %v, want no error", tt.annotations, err)
+		}
+		if warnings := annotationWarnings(tt.annotations); (len(warnings) == 0) != tt.valid {
+			t.Errorf("annotationWarnings(%v) = %v, want valid %v", tt.annotations, warnings, tt.valid)
+		}
+	}
+}
@@ -29,6 +29,8 @@ import (
 
 	"github.com/Masterminds/sprig/v3"
 	jsonpatch "github.com/evanphx/json-patch/v5"
+	"google.golang.org/protobuf/proto"
+	wrappers "google.golang.org/protobuf/types/known/wrapperspb"
 	appsv1 "k8s.io/api/apps/v1"
 	batch "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -151,6 +153,9 @@ type Config struct {
 	// This is primarily to support PSP annotations.
 	InjectedAnnotations map[string]string `json:"injectedAnnotations"`
 
+	// AnnotationPolicy restricts the annotations pods can set to customize their sidecar.
+	AnnotationPolicy *AnnotationPolicy `json:"annotationPolicy,omitempty"`
+
 	// Templates is a pre-parsed copy of RawTemplates
 	Templates Templates `json:"-"`
 }
@@ -177,6 +182,10 @@ func UnmarshalConfig(yml []byte) (Config, error) {
 			" Please ensure the template is correct; mismatch template versions can lead to unexpected results, including pods not being injected.")
 	}
 
+	if err := injectConfig.AnnotationPolicy.Validate(); err != nil {
+		return injectConfig, fmt.Errorf("invalid annotation policy: %v", err)
+	}
+
 	var err error
 	injectConfig.Templates, err = ParseTemplates(injectConfig.RawTemplates)
 	if err != nil {
@@ -363,11 +372,29 @@ func RunTemplate(params InjectionParameters) (mergedPod *corev1.Pod, templatePod
 	metadata := &params.pod.ObjectMeta
 	meshConfig := params.meshConfig
 
+	if err := params.annotationPolicy.apply(metadata); err != nil {
+		log.Errorf("Injection failed due to annotations out of policy: %v", err)
+		return nil, nil, fmt.Errorf("annotations out of policy: %v", err)
+	}
+
 	if err := validateAnnotations(metadata.GetAnnotations()); err != nil {
 		log.Errorf("Injection failed due to invalid annotations: %v", err)
 		return nil, nil, err
 	}
 
+	pc, values := params.proxyConfig, params.valuesConfig.asMap
+	hold, holdSource, err := HoldApplicationUntilProxyStarts(metadata.GetAnnotations(), params.namespaceAnnotations)
+	if err != nil {
+		log.Errorf("Injection failed due to invalid annotations: %v", err)
+		return nil, nil, err
+	}
+	if holdSource.overridesMesh() {
+		// The templates hold the application if either the proxy config or the values do, so both are overridden.
+		pc = proto.Clone(pc).(*meshconfig.ProxyConfig)
+		pc.HoldApplicationUntilProxyStarts = wrappers.Bool(hold)
+		values = withGlobalProxyValue(values, "holdApplicationUntilProxyStarts", hold)
+	}
+
 	cluster := params.valuesConfig.asStruct.GetGlobal().GetMultiCluster().GetClusterName()
 	// TODO allow overriding the values.global network in injection with the system namespace label
 	network := params.valuesConfig.asStruct.GetGlobal().GetNetwork()
@@ -401,9 +428,9 @@ func RunTemplate(params InjectionParameters) (mergedPod *corev1.Pod, templatePod
 		DeploymentMeta:       params.deployMeta,
 		ObjectMeta:           strippedPod.ObjectMeta,
 		Spec:                 strippedPod.Spec,
-		ProxyConfig:          params.proxyConfig,
+		ProxyConfig:          pc,
 		MeshConfig:           meshConfig,
-		Values:               params.valuesConfig.asMap,
+		Values:               values,
 		Revision:             params.revision,
 		EstimatedConcurrency: estimateConcurrency(params.proxyConfig, metadata.Annotations, params.valuesConfig.asStruct),
 		ProxyImage:           ProxyImage(params.valuesConfig.asStruct, params.proxyConfig.Image, strippedPod.Annotations),
@@ -729,6 +756,10 @@ func IntoObject(injector Injector, sidecarTemplate Templates, valuesConfig Value
 		}
 	}
 
+	for _, w := range annotationWarnings(metadata.Annotations) {
+		warningHandler(fmt.Sprintf("===> %q: %s\n", fullName, w))
+	}
+
 	pod := &corev1.Pod{
 		ObjectMeta: *metadata,
 		Spec:       *podSpec,
@@ -869,6 +900,27 @@ func updateClusterEnvs(container *corev1.Container, newKVs map[string]string) {
 	container.Env = envVars
 }
 
+// withGlobalProxyValue returns a copy of the injection values with global.proxy.<name> set to the value, copying
+// only the maps on its path.
+func withGlobalProxyValue(values map[string]any, name string, value any) map[string]any {
+	copyMap := func(m any) map[string]any {
+		out := map[string]any{}
+		if m, ok := m.(map[string]any); ok {
+			for k, v := range m {
+				out[k] = v
+			}
+		}
+		return out
+	}
+	out := copyMap(values)
+	global := copyMap(out["global"])
+	proxy := copyMap(global["proxy"])
+	proxy[name] = value
+	global["proxy"] = proxy
+	out["global"] = global
+	return out
+}
+
 // Uses the default concurrency 2, unless either overridden by proxy config to a positive number,
 // or special value 0, in which case the value is computed from CPU limits/requests.
 func estimateConcurrency(cfg *meshconfig.ProxyConfig, annotations map[string]string, valuesStruct *opconfig.Values) int {
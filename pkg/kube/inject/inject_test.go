@@ -220,6 +220,14 @@ func TestInjection(t *testing.T) {
 				`values.global.proxy.holdApplicationUntilProxyStarts=true`,
 			},
 		},
+		{
+			// Verifies that HoldApplicationUntilProxyStarts=false in the pod annotation overrides the MeshConfig setting
+			in:   "hello-probes-noProxyHoldApplication-annotation.yaml",
+			want: "hello-probes-noProxyHoldApplication-annotation.yaml.injected",
+			setFlags: []string{
+				`values.global.proxy.holdApplicationUntilProxyStarts=true`,
+			},
+		},
 		{
 			// A test with no pods is not relevant for webhook
 			in:          "hello-service.yaml",
@@ -308,6 +316,12 @@ func TestInjection(t *testing.T) {
 			want:        "hello-host-network-with-ns.yaml.injected",
 			expectedLog: "Skipping injection because Deployment \"sample/hello-host-network\" has host networking enabled",
 		},
+		{
+			// Invalid log levels used to be accepted, so they are injected with a warning.
+			in:          "resource_annotations-bad-loglevel.yaml",
+			want:        "resource_annotations-bad-loglevel.yaml.injected",
+			expectedLog: "invalid value 'verbose' for annotation 'sidecar.istio.io/logLevel'",
+		},
 	}
 	// Keep track of tests we add options above
 	// We will search for all test files and skip these ones
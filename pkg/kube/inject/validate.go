@@ -17,17 +17,21 @@ package inject
 import (
 	"fmt"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/hashicorp/go-multierror"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"istio.io/api/annotation"
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/config/validation"
 	"istio.io/istio/pkg/util/protomarshal"
+	"istio.io/istio/pkg/util/sets"
 )
 
 type annotationValidationFunc func(value string) error
@@ -48,6 +52,21 @@ var (
 		annotation.SidecarTrafficExcludeOutboundPorts.Name:        ValidateExcludeOutboundPorts,
 		annotation.PrometheusMergeMetrics.Name:                    validateBool,
 		annotation.ProxyConfig.Name:                               validateProxyConfig,
+		constants.EgressBandwidthLimitAnnotation:                  validateBandwidthLimit,
+		constants.IngressBandwidthLimitAnnotation:                 validateBandwidthLimit,
+		constants.SidecarJobModeAnnotation:                        validateJobMode,
+		constants.HoldApplicationUntilProxyStartsAnnotation:       validateBool,
+	}
+
+	// AnnotationWarningValidation holds the checks of annotations whose invalid values used to be accepted. Pods
+	// failing them are still injected, with a warning.
+	AnnotationWarningValidation = map[string]annotationValidationFunc{
+		annotation.SidecarProxyCPU.Name:          validateQuantity,
+		annotation.SidecarProxyCPULimit.Name:     validateQuantity,
+		annotation.SidecarProxyMemory.Name:       validateQuantity,
+		annotation.SidecarProxyMemoryLimit.Name:  validateQuantity,
+		annotation.SidecarLogLevel.Name:          validateLogLevel,
+		annotation.SidecarComponentLogLevel.Name: validateComponentLogLevel,
 	}
 )
 
@@ -59,6 +78,18 @@ func validateProxyConfig(value string) error {
 	return validation.ValidateMeshConfigProxyConfig(config)
 }
 
+func validateBandwidthLimit(value string) error {
+	_, err := model.ParseBandwidthLimit(value)
+	return err
+}
+
+func validateJobMode(value string) error {
+	if value != JobModeTerminate && value != JobModeNone {
+		return fmt.Errorf("jobMode invalid, use %s,%s: %v", JobModeTerminate, JobModeNone, value)
+	}
+	return nil
+}
+
 func validateAnnotations(annotations map[string]string) (err error) {
 	for name, value := range annotations {
 		if v, ok := AnnotationValidation[name]; ok {
@@ -70,6 +101,20 @@ func validateAnnotations(annotations map[string]string) (err error) {
 	return
 }
 
+// annotationWarnings returns a warning for each annotation failing its AnnotationWarningValidation, sorted.
+func annotationWarnings(annotations map[string]string) []string {
+	var warnings []string
+	for name, value := range annotations {
+		if v, ok := AnnotationWarningValidation[name]; ok {
+			if e := v(value); e != nil {
+				warnings = append(warnings, fmt.Sprintf("invalid value '%s' for annotation '%s': %v", value, name, e))
+			}
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
 func validatePortList(parameterName, ports string) error {
 	if _, err := parsePorts(ports); err != nil {
 		return fmt.Errorf("%s invalid: %v", parameterName, err)
@@ -145,6 +190,36 @@ func validateBool(value string) error {
 	return err
 }
 
+// validateQuantity validates that the given annotation value is a resource quantity.
+func validateQuantity(value string) error {
+	_, err := resource.ParseQuantity(value)
+	return err
+}
+
+var logLevels = sets.New("trace", "debug", "info", "warning", "warn", "error", "critical", "off")
+
+// validateLogLevel validates that the given annotation value is a proxy log level.
+func validateLogLevel(value string) error {
+	if !logLevels.Contains(value) {
+		return fmt.Errorf("unknown log level %q, must be one of %v", value, logLevels.SortedList())
+	}
+	return nil
+}
+
+// validateComponentLogLevel validates that the given annotation value is a comma separated list of component:level.
+func validateComponentLogLevel(value string) error {
+	for _, cl := range strings.Split(value, ",") {
+		component, level, ok := strings.Cut(cl, ":")
+		if !ok || component == "" {
+			return fmt.Errorf("invalid component log level %q, must be in the form component:level", cl)
+		}
+		if err := validateLogLevel(level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func validateCIDRList(cidrs string) error {
 	if len(cidrs) > 0 {
 		for _, cidr := range strings.Split(cidrs, ",") {
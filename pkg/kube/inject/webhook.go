@@ -38,6 +38,7 @@ import (
 	kjson "k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/apimachinery/pkg/util/mergepatch"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	listerv1 "k8s.io/client-go/listers/core/v1"
 	"sigs.k8s.io/yaml"
 
 	"istio.io/api/annotation"
@@ -46,6 +47,7 @@ import (
 	opconfig "istio.io/istio/operator/pkg/apis/istio/v1alpha1"
 	"istio.io/istio/pilot/cmd/pilot-agent/status"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/util/protomarshal"
@@ -92,6 +94,8 @@ type Webhook struct {
 
 	env      *model.Environment
 	revision string
+	// namespaces reads the annotations of the namespaces of the pods, if set.
+	namespaces listerv1.NamespaceLister
 }
 
 // nolint directives: interfacer
@@ -144,6 +148,9 @@ type WebhookParameters struct {
 
 	// The istio.io/rev this injector is responsible for
 	Revision string
+
+	// KubeClient, if set, is used to read the namespaces of the pods, for the injection defaults set on them.
+	KubeClient kube.Client
 }
 
 // NewWebhook creates a new instance of a mutating webhook for automatic sidecar injection.
@@ -158,6 +165,9 @@ func NewWebhook(p WebhookParameters) (*Webhook, error) {
 		env:        p.Env,
 		revision:   p.Revision,
 	}
+	if p.KubeClient != nil {
+		wh.namespaces = p.KubeClient.KubeInformer().Core().V1().Namespaces().Lister()
+	}
 
 	p.Watcher.SetHandler(wh.updateConfig)
 	sidecarConfig, valuesConfig, err := p.Watcher.Get()
@@ -301,6 +311,9 @@ type InjectionParameters struct {
 	revision            string
 	proxyEnvs           map[string]string
 	injectedAnnotations map[string]string
+	annotationPolicy    *AnnotationPolicy
+	// namespaceAnnotations are the annotations of the namespace of the pod, if known.
+	namespaceAnnotations map[string]string
 }
 
 func checkPreconditions(params InjectionParameters) {
@@ -548,6 +561,8 @@ func postProcessPod(pod *corev1.Pod, injectedPod corev1.Pod, req InjectionParame
 
 	applyMetadata(pod, injectedPod, req)
 
+	applyJobMode(pod, req)
+
 	if err := reorderPod(pod, req); err != nil {
 		return err
 	}
@@ -570,20 +585,23 @@ func applyMetadata(pod *corev1.Pod, injectedPodData corev1.Pod, req InjectionPar
 
 // reorderPod ensures containers are properly ordered after merging
 func reorderPod(pod *corev1.Pod, req InjectionParameters) error {
-	var merr error
-	mc := req.meshConfig
-	// Get copy of pod proxyconfig, to determine container ordering
-	if pca, f := req.pod.ObjectMeta.GetAnnotations()[annotation.ProxyConfig.Name]; f {
-		mc, merr = mesh.ApplyProxyConfig(pca, req.meshConfig)
-		if merr != nil {
-			return merr
+	holdPod, source, err := HoldApplicationUntilProxyStarts(req.pod.Annotations, req.namespaceAnnotations)
+	if err != nil {
+		return err
+	}
+	if !source.overridesMesh() {
+		mc := req.meshConfig
+		// Get copy of pod proxyconfig, to determine container ordering
+		if pca, f := req.pod.ObjectMeta.GetAnnotations()[annotation.ProxyConfig.Name]; f {
+			if mc, err = mesh.ApplyProxyConfig(pca, req.meshConfig); err != nil {
+				return err
+			}
 		}
+		// nolint: staticcheck
+		holdPod = mc.GetDefaultConfig().GetHoldApplicationUntilProxyStarts().GetValue() ||
+			req.valuesConfig.asStruct.GetGlobal().GetProxy().GetHoldApplicationUntilProxyStarts().GetValue()
 	}
 
-	// nolint: staticcheck
-	holdPod := mc.GetDefaultConfig().GetHoldApplicationUntilProxyStarts().GetValue() ||
-		req.valuesConfig.asStruct.GetGlobal().GetProxy().GetHoldApplicationUntilProxyStarts().GetValue()
-
 	proxyLocation := MoveLast
 	// If HoldApplicationUntilProxyStarts is set, reorder the proxy location
 	if holdPod {
@@ -602,6 +620,68 @@ func reorderPod(pod *corev1.Pod, req InjectionParameters) error {
 	return nil
 }
 
+// HoldApplicationSource is where the holdApplicationUntilProxyStarts setting of a pod comes from.
+type HoldApplicationSource string
+
+const (
+	// HoldApplicationSourcePod is the HoldApplicationUntilProxyStartsAnnotation of the pod.
+	HoldApplicationSourcePod HoldApplicationSource = "pod annotation"
+	// HoldApplicationSourceProxyConfig is the holdApplicationUntilProxyStarts field of the proxy config annotation
+	// of the pod. Like the mesh config, the application is also held if the deprecated injection value is set.
+	HoldApplicationSourceProxyConfig HoldApplicationSource = "pod proxy config"
+	// HoldApplicationSourceNamespace is the HoldApplicationUntilProxyStartsAnnotation of the namespace of the pod.
+	HoldApplicationSourceNamespace HoldApplicationSource = "namespace annotation"
+	// HoldApplicationSourceMesh is the mesh config, or the injection values.
+	HoldApplicationSourceMesh HoldApplicationSource = "mesh config"
+)
+
+// overridesMesh returns whether the setting from the source replaces the mesh config and the injection values.
+func (s HoldApplicationSource) overridesMesh() bool {
+	return s == HoldApplicationSourcePod || s == HoldApplicationSourceNamespace
+}
+
+// HoldApplicationUntilProxyStarts returns whether the application containers of a pod wait for its sidecar to start,
+// as set by the annotations of the pod or of its namespace, and where the setting comes from. It returns
+// HoldApplicationSourceMesh if none of them sets it, for the mesh config to apply. The annotations of the pod must be
+// valid and agree with each other, while an invalid namespace annotation is ignored, so that it does not block the
+// admission of all the pods of the namespace.
+func HoldApplicationUntilProxyStarts(podAnnotations, namespaceAnnotations map[string]string) (bool, HoldApplicationSource, error) {
+	var podHold, proxyConfigHold *bool
+	if v, f := podAnnotations[constants.HoldApplicationUntilProxyStartsAnnotation]; f {
+		hold, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, "", fmt.Errorf("invalid value '%s' for annotation '%s': must be true or false",
+				v, constants.HoldApplicationUntilProxyStartsAnnotation)
+		}
+		podHold = &hold
+	}
+	if pca, f := podAnnotations[annotation.ProxyConfig.Name]; f {
+		pc := &meshconfig.ProxyConfig{}
+		if err := protomarshal.ApplyYAML(pca, pc); err != nil {
+			return false, "", fmt.Errorf("invalid value for annotation '%s': %v", annotation.ProxyConfig.Name, err)
+		}
+		if v := pc.GetHoldApplicationUntilProxyStarts(); v != nil {
+			hold := v.GetValue()
+			proxyConfigHold = &hold
+		}
+	}
+	switch {
+	case podHold != nil && proxyConfigHold != nil && *podHold != *proxyConfigHold:
+		return false, "", fmt.Errorf("annotation '%s' is %v but holdApplicationUntilProxyStarts of annotation '%s' is %v",
+			constants.HoldApplicationUntilProxyStartsAnnotation, *podHold, annotation.ProxyConfig.Name, *proxyConfigHold)
+	case podHold != nil:
+		return *podHold, HoldApplicationSourcePod, nil
+	case proxyConfigHold != nil:
+		return *proxyConfigHold, HoldApplicationSourceProxyConfig, nil
+	}
+	if v, f := namespaceAnnotations[constants.HoldApplicationUntilProxyStartsAnnotation]; f {
+		if hold, err := strconv.ParseBool(v); err == nil {
+			return hold, HoldApplicationSourceNamespace, nil
+		}
+	}
+	return false, HoldApplicationSourceMesh, nil
+}
+
 func applyRewrite(pod *corev1.Pod, req InjectionParameters) error {
 	sidecar := FindSidecar(pod.Spec.Containers)
 	if sidecar == nil {
@@ -619,6 +699,42 @@ func applyRewrite(pod *corev1.Pod, req InjectionParameters) error {
 	return nil
 }
 
+const (
+	// JobModeTerminate stops the sidecar of the pods of Jobs once their application containers exit.
+	JobModeTerminate = "terminate"
+	// JobModeNone, the default, keeps the sidecar of the pods of Jobs running, the application has to stop it.
+	JobModeNone = "none"
+)
+
+// argoWorkflowLabel is set by Argo Workflows on the pods of its workflows.
+const argoWorkflowLabel = "workflows.argoproj.io/workflow"
+
+// applyJobMode makes the sidecar of the pods of Jobs and CronJobs annotated with the terminate job mode exit once
+// their application containers exit, so that the pods complete without the application having to POST to
+// /quitquitquit. The sidecar watches the processes of the other containers, which requires the pod to share its
+// process namespace: the containers must all run to completion, another long running sidecar keeps the pod running.
+// The pods of Argo Workflows are left untouched, their executor already stops the sidecars once the main container
+// exits.
+func applyJobMode(pod *corev1.Pod, req InjectionParameters) {
+	if pod.Annotations[constants.SidecarJobModeAnnotation] != JobModeTerminate {
+		return
+	}
+	if _, f := pod.Labels[argoWorkflowLabel]; f {
+		log.Infof("ignoring %s on the Argo Workflows pod %s/%s", constants.SidecarJobModeAnnotation, pod.Namespace, pod.Name)
+		return
+	}
+	if req.typeMeta.Kind != "Job" && req.typeMeta.Kind != "CronJob" {
+		return
+	}
+	sidecar := FindSidecar(pod.Spec.Containers)
+	if sidecar == nil {
+		return
+	}
+	shareProcessNamespace := true
+	pod.Spec.ShareProcessNamespace = &shareProcessNamespace
+	sidecar.Env = append(sidecar.Env, corev1.EnvVar{Name: status.ExitOnApplicationExit.Name, Value: "true"})
+}
+
 var emptyScrape = status.PrometheusScrapeConfiguration{}
 
 // applyPrometheusMerge configures prometheus scraping annotations for the "metrics merge" feature.
@@ -896,7 +1012,10 @@ func (wh *Webhook) inject(ar *kube.AdmissionReview, path string) *kube.Admission
 		valuesConfig:        wh.valuesConfig,
 		revision:            wh.revision,
 		injectedAnnotations: wh.Config.InjectedAnnotations,
+		annotationPolicy:    wh.Config.AnnotationPolicy,
 		proxyEnvs:           parseInjectEnvs(path),
+		// The defaults set on the namespace apply to the pods injected at admission.
+		namespaceAnnotations: wh.namespaceAnnotations(pod.Namespace),
 	}
 	wh.mu.RUnlock()
 
@@ -906,6 +1025,10 @@ func (wh *Webhook) inject(ar *kube.AdmissionReview, path string) *kube.Admission
 		return toAdmissionResponse(err)
 	}
 
+	warnings := annotationWarnings(pod.Annotations)
+	for _, w := range warnings {
+		log.Warnf("Sidecar injection of %v/%v: %s", pod.Namespace, podName, w)
+	}
 	reviewResponse := kube.AdmissionResponse{
 		Allowed: true,
 		Patch:   patchBytes,
@@ -913,11 +1036,25 @@ func (wh *Webhook) inject(ar *kube.AdmissionReview, path string) *kube.Admission
 			pt := "JSONPatch"
 			return &pt
 		}(),
+		Warnings: warnings,
 	}
 	totalSuccessfulInjections.Increment()
 	return &reviewResponse
 }
 
+// namespaceAnnotations returns the annotations of the namespace, or nil if they cannot be read.
+func (wh *Webhook) namespaceAnnotations(name string) map[string]string {
+	if wh.namespaces == nil {
+		return nil
+	}
+	ns, err := wh.namespaces.Get(name)
+	if err != nil {
+		log.Debugf("failed to get namespace %s: %v", name, err)
+		return nil
+	}
+	return ns.Annotations
+}
+
 func (wh *Webhook) serveInject(w http.ResponseWriter, r *http.Request) {
 	totalInjections.Increment()
 	var body []byte
@@ -50,8 +50,10 @@ import (
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/test/util"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/test/util/file"
 	"istio.io/istio/pkg/test/util/retry"
 	sutil "istio.io/istio/security/pkg/nodeagent/util"
@@ -1315,3 +1317,195 @@ func defaultInstallPackageDir() string {
 	}
 	return filepath.Join(wd, "../../../manifests/")
 }
+
+func TestHoldApplicationUntilProxyStarts(t *testing.T) {
+	const hold = constants.HoldApplicationUntilProxyStartsAnnotation
+	cases := []struct {
+		name       string
+		pod        map[string]string
+		namespace  map[string]string
+		wantHold   bool
+		wantSource HoldApplicationSource
+		wantErr    bool
+	}{
+		{
+			name:       "unset",
+			wantSource: HoldApplicationSourceMesh,
+		},
+		{
+			name:       "namespace",
+			namespace:  map[string]string{hold: "true"},
+			wantHold:   true,
+			wantSource: HoldApplicationSourceNamespace,
+		},
+		{
+			name:       "invalid namespace ignored",
+			namespace:  map[string]string{hold: "yes please"},
+			wantSource: HoldApplicationSourceMesh,
+		},
+		{
+			name:       "pod overrides namespace",
+			pod:        map[string]string{hold: "false"},
+			namespace:  map[string]string{hold: "true"},
+			wantSource: HoldApplicationSourcePod,
+		},
+		{
+			name:       "pod proxy config overrides namespace",
+			pod:        map[string]string{annotation.ProxyConfig.Name: `{ "holdApplicationUntilProxyStarts": true }`},
+			namespace:  map[string]string{hold: "false"},
+			wantHold:   true,
+			wantSource: HoldApplicationSourceProxyConfig,
+		},
+		{
+			name:       "pod proxy config without the field",
+			pod:        map[string]string{annotation.ProxyConfig.Name: `{ "concurrency": 2 }`},
+			namespace:  map[string]string{hold: "true"},
+			wantHold:   true,
+			wantSource: HoldApplicationSourceNamespace,
+		},
+		{
+			name: "pod and proxy config agree",
+			pod: map[string]string{
+				hold:                        "true",
+				annotation.ProxyConfig.Name: `{ "holdApplicationUntilProxyStarts": true }`,
+			},
+			wantHold:   true,
+			wantSource: HoldApplicationSourcePod,
+		},
+		{
+			name: "pod and proxy config conflict",
+			pod: map[string]string{
+				hold:                        "false",
+				annotation.ProxyConfig.Name: `{ "holdApplicationUntilProxyStarts": true }`,
+			},
+			wantErr: true,
+		},
+		{
+			name:    "invalid pod",
+			pod:     map[string]string{hold: "yes please"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotHold, gotSource, err := HoldApplicationUntilProxyStarts(tc.pod, tc.namespace)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotHold != tc.wantHold || gotSource != tc.wantSource {
+				t.Errorf("got %v from %q, want %v from %q", gotHold, gotSource, tc.wantHold, tc.wantSource)
+			}
+		})
+	}
+}
+
+func TestNamespaceHoldApplicationUntilProxyStarts(t *testing.T) {
+	client := kube.NewFakeClient(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "held",
+		Annotations: map[string]string{constants.HoldApplicationUntilProxyStartsAnnotation: "true"},
+	}})
+	wh := &Webhook{namespaces: client.KubeInformer().Core().V1().Namespaces().Lister()}
+	stop := make(chan struct{})
+	defer close(stop)
+	client.RunAndWait(stop)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "held"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "app"},
+			{Name: ProxyContainerName},
+		}},
+	}
+	err := reorderPod(pod, InjectionParameters{
+		pod:                  pod,
+		meshConfig:           mesh.DefaultMeshConfig(),
+		namespaceAnnotations: wh.namespaceAnnotations("held"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Spec.Containers[0].Name != ProxyContainerName {
+		t.Errorf("expected the proxy to start first in a namespace holding the applications, got %v", pod.Spec.Containers)
+	}
+	if got := wh.namespaceAnnotations("missing"); got != nil {
+		t.Errorf("expected no annotations for a missing namespace, got %v", got)
+	}
+}
+
+func TestWebhookNamespaceHoldApplicationUntilProxyStarts(t *testing.T) {
+	client := kube.NewFakeClient(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "held",
+		Annotations: map[string]string{constants.HoldApplicationUntilProxyStartsAnnotation: "true"},
+	}})
+	wh := createWebhook(t, minimalSidecarTemplate, 0)
+	wh.namespaces = client.KubeInformer().Core().V1().Namespaces().Lister()
+	stop := make(chan struct{})
+	defer close(stop)
+	client.RunAndWait(stop)
+
+	for _, ns := range []string{"held", "other"} {
+		t.Run(ns, func(t *testing.T) {
+			podJSON := convertToJSON(&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: ns},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			}, t)
+			got := wh.inject(&kube.AdmissionReview{Request: &kube.AdmissionRequest{
+				Object:    runtime.RawExtension{Raw: podJSON},
+				Namespace: ns,
+			}}, "")
+			if got.Patch == nil {
+				t.Fatalf("expected the pod to be injected: %v", got.Result)
+			}
+			pod := &corev1.Pod{}
+			if err := json.Unmarshal(applyJSONPatch(podJSON, prettyJSON(got.Patch, t), t), pod); err != nil {
+				t.Fatal(err)
+			}
+			proxyFirst := pod.Spec.Containers[0].Name == ProxyContainerName
+			if want := ns == "held"; proxyFirst != want {
+				t.Errorf("got proxy first %v, want %v: %v", proxyFirst, want, pod.Spec.Containers)
+			}
+		})
+	}
+}
+
+func TestApplyJobMode(t *testing.T) {
+	terminate := map[string]string{constants.SidecarJobModeAnnotation: JobModeTerminate}
+	cases := []struct {
+		name        string
+		kind        string
+		annotations map[string]string
+		labels      map[string]string
+		want        bool
+	}{
+		{name: "job", kind: "Job", annotations: terminate, want: true},
+		{name: "cronjob", kind: "CronJob", annotations: terminate, want: true},
+		{name: "job without annotation", kind: "Job"},
+		{name: "deployment", kind: "Deployment", annotations: terminate},
+		{name: "argo workflow", kind: "Job", annotations: terminate, labels: map[string]string{argoWorkflowLabel: "wf"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations, Labels: tc.labels},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{
+					{Name: "app"},
+					{Name: ProxyContainerName},
+				}},
+			}
+			applyJobMode(pod, InjectionParameters{typeMeta: metav1.TypeMeta{Kind: tc.kind}})
+			got := pod.Spec.ShareProcessNamespace != nil && *pod.Spec.ShareProcessNamespace
+			if got != tc.want {
+				t.Fatalf("got process namespace shared %v, want %v", got, tc.want)
+			}
+			if got != (len(FindSidecar(pod.Spec.Containers).Env) == 1) {
+				t.Fatalf("expected the sidecar env to match the job mode, got %v", FindSidecar(pod.Spec.Containers).Env)
+			}
+		})
+	}
+}
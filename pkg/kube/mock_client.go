@@ -151,6 +151,10 @@ func (c MockClient) AllDiscoveryDo(_ context.Context, _, _ string) (map[string][
 	return c.Results, nil
 }
 
+func (c MockClient) AllDiscoveryPost(_ context.Context, _, _ string, _ []byte) (map[string][]byte, error) {
+	return c.Results, nil
+}
+
 func (c MockClient) EnvoyDo(ctx context.Context, podName, podNamespace, method, path string) ([]byte, error) {
 	results, ok := c.Results[podName]
 	if !ok {
@@ -45,6 +45,9 @@ const (
 	// DefaultRootCertFilePath is the well-known path for an existing root certificate file
 	DefaultRootCertFilePath = "./etc/certs/root-cert.pem"
 
+	// DefaultCRLFilePath is the well-known path of the CRLs of the CA, mounted from the istio-ca-root-cert ConfigMap
+	DefaultCRLFilePath = "./var/run/secrets/istio/ca-crl.pem"
+
 	// WorkloadIdentitySocketPath is the well-known path to the Unix Domain Socket for SDS.
 	WorkloadIdentitySocketPath = "./var/run/secrets/workload-spiffe-uds/socket"
 
@@ -140,6 +143,9 @@ const (
 
 	// CertSigner info
 	CertSigner = "CertSigner"
+
+	// DNSNames is the comma separated list of DNS names requested to be added to a workload certificate.
+	DNSNames = "DNSNames"
 )
 
 // Options provides all of the configuration parameters for secret discovery service
@@ -169,6 +175,14 @@ type Options struct {
 	// OutputKeyCertToDir is the directory for output the key and certificate
 	OutputKeyCertToDir string
 
+	// OutputKeyCertFileMode is the permission of the files written to OutputKeyCertToDir.
+	// If zero, a default based on the environment is used.
+	OutputKeyCertFileMode os.FileMode
+
+	// OutputKeyCertAtomic publishes the files in OutputKeyCertToDir through a symlinked directory, so
+	// applications reading them never observe a key that does not match the certificate chain.
+	OutputKeyCertAtomic bool
+
 	// ProvCert is the directory for client to provide the key and certificate to CA server when authenticating
 	// with mTLS. This is not used for workload mTLS communication, and is
 	ProvCert string
@@ -236,6 +250,10 @@ type Options struct {
 	// Cert signer info
 	CertSigner string
 
+	// DNSNames are the DNS names requested to be added, as SANs, to the workload certificate. The istiod CA only adds
+	// the ones allowed to the workload by its policy.
+	DNSNames []string
+
 	// Delay in reading certificates from file after the change is detected. This is useful in cases
 	// where the write operation of key and cert take longer.
 	FileDebounceDuration time.Duration
@@ -249,6 +267,9 @@ type Options struct {
 	KeyFilePath string
 	// The path for an existing root certificate bundle
 	RootCertFilePath string
+	// The path of the CRLs of the CA, which the proxy validates the peer certificates with if set and the file exists.
+	// Empty unless the proxy opted in, as the peer certificates whose issuer has no CRL are then rejected.
+	CRLFilePath string
 }
 
 // TokenManager contains methods for generating token.
@@ -327,6 +348,8 @@ type SecretItem struct {
 	PrivateKey       []byte
 
 	RootCert []byte
+	// CRL holds the CRLs to validate the certificates issued from RootCert with, if any.
+	CRL []byte
 
 	// ResourceName passed from envoy SDS discovery request.
 	// "ROOTCA" for root cert request, "default" for key/cert request.
@@ -21,6 +21,7 @@ import (
 	udpa "github.com/cncf/xds/go/udpa/type/v1"
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	wasm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/wasm/v3"
+	networkwasm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/wasm/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/conversion"
 	"go.uber.org/atomic"
 	anypb "google.golang.org/protobuf/types/known/anypb"
@@ -75,8 +76,18 @@ func convert(resource *anypb.Any, cache Cache) (newExtensionConfig *anypb.Any, s
 	}
 
 	wasmHTTPFilterConfig := &wasm.Wasm{}
+	// Network Wasm filters share the plugin configuration of HTTP Wasm filters, and are converted the same way.
+	network := false
 	// Wasm filter can be configured using typed struct and Wasm filter type
-	if ec.GetTypedConfig() != nil && ec.GetTypedConfig().TypeUrl == xds.WasmHTTPFilterType {
+	if ec.GetTypedConfig() != nil && ec.GetTypedConfig().TypeUrl == xds.WasmNetworkFilterType {
+		wasmNetworkFilterConfig := &networkwasm.Wasm{}
+		if err := ec.GetTypedConfig().UnmarshalTo(wasmNetworkFilterConfig); err != nil {
+			wasmLog.Debugf("failed to unmarshal extension config resource into Wasm network filter: %v", err)
+			return
+		}
+		wasmHTTPFilterConfig.Config = wasmNetworkFilterConfig.Config
+		network = true
+	} else if ec.GetTypedConfig() != nil && ec.GetTypedConfig().TypeUrl == xds.WasmHTTPFilterType {
 		err := ec.GetTypedConfig().UnmarshalTo(wasmHTTPFilterConfig)
 		if err != nil {
 			wasmLog.Debugf("failed to unmarshal extension config resource into Wasm HTTP filter: %v", err)
@@ -184,7 +195,12 @@ func convert(resource *anypb.Any, cache Cache) (newExtensionConfig *anypb.Any, s
 		},
 	}
 
-	wasmTypedConfig, err := anypb.New(wasmHTTPFilterConfig)
+	var wasmTypedConfig *anypb.Any
+	if network {
+		wasmTypedConfig, err = anypb.New(&networkwasm.Wasm{Config: wasmHTTPFilterConfig.Config})
+	} else {
+		wasmTypedConfig, err = anypb.New(wasmHTTPFilterConfig)
+	}
 	if err != nil {
 		status = marshalFailure
 		wasmLog.Errorf("failed to marshal new wasm HTTP filter %+v to protobuf Any: %v", wasmHTTPFilterConfig, err)
@@ -25,6 +25,7 @@ import (
 	udpa "github.com/cncf/xds/go/udpa/type/v1"
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	wasm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/wasm/v3"
+	networkwasm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/wasm/v3"
 	v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/wasm/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/conversion"
 	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
@@ -84,6 +85,16 @@ func TestWasmConvert(t *testing.T) {
 			},
 			wantNack: false,
 		},
+		{
+			name: "network remote load success",
+			input: []*core.TypedExtensionConfig{
+				extensionConfigMap["remote-load-network"],
+			},
+			wantOutput: []*core.TypedExtensionConfig{
+				extensionConfigMap["remote-load-network-local-file"],
+			},
+			wantNack: false,
+		},
 		{
 			name: "remote load fail",
 			input: []*core.TypedExtensionConfig{
@@ -286,6 +297,42 @@ var extensionConfigMap = map[string]*core.TypedExtensionConfig{
 			},
 		},
 	}),
+	"remote-load-network": {
+		Name: "remote-load-network",
+		TypedConfig: protoconv.MessageToAny(&networkwasm.Wasm{
+			Config: &v3.PluginConfig{
+				Vm: &v3.PluginConfig_VmConfig{
+					VmConfig: &v3.VmConfig{
+						Code: &core.AsyncDataSource{Specifier: &core.AsyncDataSource_Remote{
+							Remote: &core.RemoteDataSource{
+								HttpUri: &core.HttpUri{
+									Uri: "http://test?module=test.wasm",
+								},
+							},
+						}},
+					},
+				},
+			},
+		}),
+	},
+	"remote-load-network-local-file": {
+		Name: "remote-load-network",
+		TypedConfig: protoconv.MessageToAny(&networkwasm.Wasm{
+			Config: &v3.PluginConfig{
+				Vm: &v3.PluginConfig_VmConfig{
+					VmConfig: &v3.VmConfig{
+						Code: &core.AsyncDataSource{Specifier: &core.AsyncDataSource_Local{
+							Local: &core.DataSource{
+								Specifier: &core.DataSource_Filename{
+									Filename: "test.wasm",
+								},
+							},
+						}},
+					},
+				},
+			},
+		}),
+	},
 	"remote-load-fail": buildTypedStructExtensionConfig("remote-load-fail", &wasm.Wasm{
 		Config: &v3.PluginConfig{
 			Vm: &v3.PluginConfig_VmConfig{
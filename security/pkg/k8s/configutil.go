@@ -32,7 +32,8 @@ import (
 // lister: the configmap lister.
 // meta: the metadata of configmap.
 // caBundle: ca cert data bytes.
-func InsertDataToConfigMap(client corev1.ConfigMapsGetter, lister listerv1.ConfigMapLister, meta metav1.ObjectMeta, caBundle []byte) error {
+// crl: the CRLs of the ca certs, removed from the configmap if empty.
+func InsertDataToConfigMap(client corev1.ConfigMapsGetter, lister listerv1.ConfigMapLister, meta metav1.ObjectMeta, caBundle, crl []byte) error {
 	configmap, err := lister.ConfigMaps(meta.Namespace).Get(meta.Name)
 	if err != nil && !errors.IsNotFound(err) {
 		return fmt.Errorf("error when getting configmap %v: %v", meta.Name, err)
@@ -41,9 +42,7 @@ func InsertDataToConfigMap(client corev1.ConfigMapsGetter, lister listerv1.Confi
 		// Create a new ConfigMap.
 		configmap = &v1.ConfigMap{
 			ObjectMeta: meta,
-			Data: map[string]string{
-				constants.CACertNamespaceConfigMapDataName: string(caBundle),
-			},
+			Data:       caData(caBundle, crl),
 		}
 		if _, err = client.ConfigMaps(meta.Namespace).Create(context.TODO(), configmap, metav1.CreateOptions{}); err != nil {
 			// Namespace may be deleted between now... and our previous check. Just skip this, we cannot create into deleted ns
@@ -55,7 +54,7 @@ func InsertDataToConfigMap(client corev1.ConfigMapsGetter, lister listerv1.Confi
 		}
 	} else {
 		// Otherwise, update the config map if changes are required
-		err := updateDataInConfigMap(client, configmap, caBundle)
+		err := updateDataInConfigMap(client, configmap, caBundle, crl)
 		if err != nil {
 			return err
 		}
@@ -79,15 +78,28 @@ func insertData(cm *v1.ConfigMap, data map[string]string) bool {
 	return needsUpdate
 }
 
-func updateDataInConfigMap(client corev1.ConfigMapsGetter, cm *v1.ConfigMap, caBundle []byte) error {
+// caData returns the data of the configmap holding the ca certs and their CRLs, if any.
+func caData(caBundle, crl []byte) map[string]string {
+	data := map[string]string{
+		constants.CACertNamespaceConfigMapDataName: string(caBundle),
+	}
+	if len(crl) > 0 {
+		data[constants.CACertNamespaceConfigMapCRLDataName] = string(crl)
+	}
+	return data
+}
+
+func updateDataInConfigMap(client corev1.ConfigMapsGetter, cm *v1.ConfigMap, caBundle, crl []byte) error {
 	if cm == nil {
 		return fmt.Errorf("cannot update nil configmap")
 	}
 	newCm := cm.DeepCopy()
-	data := map[string]string{
-		constants.CACertNamespaceConfigMapDataName: string(caBundle),
+	needsUpdate := insertData(newCm, caData(caBundle, crl))
+	if _, f := newCm.Data[constants.CACertNamespaceConfigMapCRLDataName]; f && len(crl) == 0 {
+		delete(newCm.Data, constants.CACertNamespaceConfigMapCRLDataName)
+		needsUpdate = true
 	}
-	if needsUpdate := insertData(newCm, data); !needsUpdate {
+	if !needsUpdate {
 		return nil
 	}
 	if _, err := client.ConfigMaps(newCm.Namespace).Update(context.TODO(), newCm, metav1.UpdateOptions{}); err != nil {
@@ -95,7 +95,7 @@ func TestUpdateDataInConfigMap(t *testing.T) {
 				}
 			}
 			client.ClearActions()
-			err := updateDataInConfigMap(client.CoreV1(), tc.existingConfigMap, []byte(caBundle))
+			err := updateDataInConfigMap(client.CoreV1(), tc.existingConfigMap, []byte(caBundle), nil)
 			if err != nil && err.Error() != tc.expectedErr {
 				t.Errorf("actual error (%s) different from expected error (%s).", err.Error(), tc.expectedErr)
 			}
@@ -110,6 +110,38 @@ func TestUpdateDataInConfigMap(t *testing.T) {
 	}
 }
 
+func TestUpdateDataInConfigMapCRL(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	cm := createConfigMap(namespaceName, configMapName, map[string]string{})
+	if _, err := client.CoreV1().ConfigMaps(namespaceName).Create(context.TODO(), cm, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	get := func() map[string]string {
+		cm, err := client.CoreV1().ConfigMaps(namespaceName).Get(context.TODO(), configMapName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cm.Data
+	}
+
+	if err := updateDataInConfigMap(client.CoreV1(), cm, []byte("root"), []byte("crl")); err != nil {
+		t.Fatal(err)
+	}
+	data := get()
+	if data[constants.CACertNamespaceConfigMapDataName] != "root" || data[constants.CACertNamespaceConfigMapCRLDataName] != "crl" {
+		t.Errorf("expected the root cert and the CRLs, got %v", data)
+	}
+
+	// The CRLs are removed once the CA no longer publishes any.
+	cm, _ = client.CoreV1().ConfigMaps(namespaceName).Get(context.TODO(), configMapName, metav1.GetOptions{})
+	if err := updateDataInConfigMap(client.CoreV1(), cm, []byte("root"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if data := get(); len(data) != 1 {
+		t.Errorf("expected only the root cert, got %v", data)
+	}
+}
+
 func TestInsertDataToConfigMap(t *testing.T) {
 	gvr := schema.GroupVersionResource{
 		Resource: "configmaps",
@@ -209,7 +241,7 @@ func TestInsertDataToConfigMap(t *testing.T) {
 				}
 			}
 			client.ClearActions()
-			err := InsertDataToConfigMap(client.CoreV1(), cmInformer.Lister(), tc.meta, tc.caBundle)
+			err := InsertDataToConfigMap(client.CoreV1(), cmInformer.Lister(), tc.meta, tc.caBundle, nil)
 			if err != nil && err.Error() != tc.expectedErr {
 				t.Errorf("actual error (%s) different from expected error (%s).", err.Error(), tc.expectedErr)
 			}
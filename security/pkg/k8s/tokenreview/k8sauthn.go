@@ -24,12 +24,30 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
+const (
+	podNameExtra = "authentication.kubernetes.io/pod-name"
+	podUIDExtra  = "authentication.kubernetes.io/pod-uid"
+)
+
+// PodBinding is the pod a service account token is bound to.
+type PodBinding struct {
+	Name string
+	UID  string
+}
+
 // ValidateK8sJwt validates a k8s JWT at API server.
 // Return {<namespace>, <serviceaccountname>} in the targetToken when the validation passes.
 // Otherwise, return the error.
 // targetToken: the JWT of the K8s service account to be reviewed
 // aud: list of audiences to check. If empty 1st party tokens will be checked.
 func ValidateK8sJwt(kubeClient kubernetes.Interface, targetToken string, aud []string) ([]string, error) {
+	id, _, err := ValidateBoundK8sJwt(kubeClient, targetToken, aud)
+	return id, err
+}
+
+// ValidateBoundK8sJwt validates a k8s JWT at API server like ValidateK8sJwt, and also returns the pod the token is
+// bound to, or nil if it is not bound to a pod.
+func ValidateBoundK8sJwt(kubeClient kubernetes.Interface, targetToken string, aud []string) ([]string, *PodBinding, error) {
 	tokenReview := &k8sauth.TokenReview{
 		Spec: k8sauth.TokenReviewSpec{
 			Token: targetToken,
@@ -40,10 +58,23 @@ func ValidateK8sJwt(kubeClient kubernetes.Interface, targetToken string, aud []s
 	}
 	reviewRes, err := kubeClient.AuthenticationV1().TokenReviews().Create(context.TODO(), tokenReview, metav1.CreateOptions{})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return getTokenReviewResult(reviewRes)
+	id, err := getTokenReviewResult(reviewRes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return id, getPodBinding(reviewRes), nil
+}
+
+// getPodBinding returns the pod of a token bound to a pod, which the token review reports as extra user info.
+func getPodBinding(tokenReview *k8sauth.TokenReview) *PodBinding {
+	extra := tokenReview.Status.User.Extra
+	if len(extra[podNameExtra]) != 1 || len(extra[podUIDExtra]) != 1 {
+		return nil
+	}
+	return &PodBinding{Name: extra[podNameExtra][0], UID: extra[podUIDExtra][0]}
 }
 
 func getTokenReviewResult(tokenReview *k8sauth.TokenReview) ([]string, error) {
@@ -123,3 +123,18 @@ func errEqual(err error, target error) bool {
 		return err.Error() == target.Error()
 	}
 }
+
+func TestGetPodBinding(t *testing.T) {
+	tokenReview := &authenticationv1.TokenReview{}
+	if got := getPodBinding(tokenReview); got != nil {
+		t.Errorf("got pod binding %v for an unbound token", got)
+	}
+	tokenReview.Status.User.Extra = map[string]authenticationv1.ExtraValue{
+		podNameExtra: {"example-pod"},
+		podUIDExtra:  {"example-uid"},
+	}
+	want := &PodBinding{Name: "example-pod", UID: "example-uid"}
+	if got := getPodBinding(tokenReview); !reflect.DeepEqual(got, want) {
+		t.Errorf("got pod binding %v, want %v", got, want)
+	}
+}
@@ -222,6 +222,7 @@ func (sc *SecretManagerClient) getCachedSecret(resourceName string) (secret *sec
 			ns = &security.SecretItem{
 				ResourceName: resourceName,
 				RootCert:     rootCertBundle,
+				CRL:          sc.readCRL(),
 			}
 			cacheLog.WithLabels("ttl", time.Until(c.ExpireTime)).Info("returned workload trust anchor from cache")
 
@@ -250,13 +251,16 @@ func (sc *SecretManagerClient) GenerateSecret(resourceName string) (secret *secu
 			return
 		}
 		// We need to hold a mutex here, otherwise if two threads are writing the same certificate,
-		// we may permanently end up with a mismatch key/cert pair. We still make end up temporarily
-		// with mismatched key/cert pair since we cannot atomically write multiple files. It may be
-		// possible by keeping the output in a directory with clever use of symlinks in the future,
-		// if needed.
+		// we may permanently end up with a mismatch key/cert pair. Unless OutputKeyCertAtomic is set,
+		// we may still end up temporarily with mismatched key/cert pair since the files are written
+		// one by one.
 		sc.outputMutex.Lock()
 		if resourceName == security.RootCertReqResourceName || resourceName == security.WorkloadKeyCertResourceName {
-			if err := nodeagentutil.OutputKeyCertToDir(sc.configOptions.OutputKeyCertToDir, secret.PrivateKey,
+			opts := nodeagentutil.OutputOptions{
+				FileMode: sc.configOptions.OutputKeyCertFileMode,
+				Atomic:   sc.configOptions.OutputKeyCertAtomic,
+			}
+			if err := nodeagentutil.OutputKeyCertToDirWithOptions(sc.configOptions.OutputKeyCertToDir, opts, secret.PrivateKey,
 				secret.CertificateChain, secret.RootCert); err != nil {
 				cacheLog.Errorf("error when output the resource: %v", err)
 			} else {
@@ -304,6 +308,7 @@ func (sc *SecretManagerClient) GenerateSecret(resourceName string) (secret *secu
 
 	if resourceName == security.RootCertReqResourceName {
 		ns.RootCert = sc.mergeTrustAnchorBytes(ns.RootCert)
+		ns.CRL = sc.readCRL()
 	} else {
 		// If periodic cert refresh resulted in discovery of a new root, trigger a ROOTCA request to refresh trust anchor
 		oldRoot := sc.cache.GetRoot()
@@ -368,6 +373,21 @@ func (sc *SecretManagerClient) tryAddFileWatcher(file string, resourceName strin
 	return nil
 }
 
+// readCRL returns the CRLs of the CA to validate the peer certificates with, if the file exists, and watches the
+// file so that the trust anchor is pushed again when they change.
+func (sc *SecretManagerClient) readCRL() []byte {
+	path := sc.configOptions.CRLFilePath
+	if path == "" {
+		return nil
+	}
+	crl, err := os.ReadFile(path)
+	if err != nil || len(crl) == 0 {
+		return nil
+	}
+	sc.addFileWatcher(path, security.RootCertReqResourceName)
+	return crl
+}
+
 // If there is existing root certificates under a well known path, return true.
 // Otherwise, return false.
 func (sc *SecretManagerClient) rootCertificateExist(filePath string) bool {
@@ -169,6 +169,40 @@ func TestWorkloadAgentRefreshSecret(t *testing.T) {
 	u.Expect(map[string]int{security.WorkloadKeyCertResourceName: 2, security.RootCertReqResourceName: 1})
 }
 
+func TestWorkloadAgentRootCertCRL(t *testing.T) {
+	fakeCACli, err := mock.NewMockCAClient(time.Hour, false)
+	if err != nil {
+		t.Fatalf("Error creating Mock CA client: %v", err)
+	}
+	crlFile := filepath.Join(t.TempDir(), "ca-crl.pem")
+	if err := os.WriteFile(crlFile, []byte("crl"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	u := NewUpdateTracker(t)
+	sc := createCache(t, fakeCACli, u.Callback, security.Options{WorkloadRSAKeySize: 2048, CRLFilePath: crlFile})
+	if _, err := sc.GenerateSecret(security.WorkloadKeyCertResourceName); err != nil {
+		t.Fatalf("failed to get secrets: %v", err)
+	}
+	u.Expect(map[string]int{security.RootCertReqResourceName: 1})
+
+	root, err := sc.GenerateSecret(security.RootCertReqResourceName)
+	if err != nil {
+		t.Fatalf("failed to get secrets: %v", err)
+	}
+	if string(root.CRL) != "crl" {
+		t.Errorf("expected the CRLs of the file, got %q", root.CRL)
+	}
+
+	// The trust anchor is pushed again when the CRLs change.
+	if err := os.WriteFile(crlFile, []byte("updated crl"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	u.Expect(map[string]int{security.RootCertReqResourceName: 2})
+	if root, err = sc.GenerateSecret(security.RootCertReqResourceName); err != nil || string(root.CRL) != "updated crl" {
+		t.Errorf("expected the updated CRLs, got %q, %v", root.CRL, err)
+	}
+}
+
 // Compare times, with 5s error allowance
 func almostEqual(t1, t2 time.Duration) bool {
 	diff := t1 - t2
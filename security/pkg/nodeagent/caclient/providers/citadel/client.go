@@ -27,12 +27,15 @@ import (
 
 	"go.uber.org/atomic"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/structpb"
 
 	pb "istio.io/api/security/v1alpha1"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/security"
 	"istio.io/istio/security/pkg/nodeagent/caclient"
 	"istio.io/pkg/log"
@@ -94,6 +97,9 @@ func (c *CitadelClient) CSRSign(csrPEM []byte, certValidTTLInSec int64) ([]strin
 			},
 		},
 	}
+	if len(c.opts.DNSNames) > 0 {
+		crMetaStruct.Fields[security.DNSNames] = structpb.NewStringValue(strings.Join(c.opts.DNSNames, ","))
+	}
 	req := &pb.IstioCertificateRequest{
 		Csr:              string(csrPEM),
 		ValidityDuration: certValidTTLInSec,
@@ -107,6 +113,10 @@ func (c *CitadelClient) CSRSign(csrPEM []byte, certValidTTLInSec int64) ([]strin
 	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("ClusterID", c.opts.ClusterID))
 	resp, err := c.client.CreateCertificate(ctx, req)
 	if err != nil {
+		if status.Code(err) == codes.PermissionDenied && len(c.opts.DNSNames) > 0 {
+			return nil, fmt.Errorf("create certificate: the DNS names %v of the %s annotation are not allowed by the "+
+				"CITADEL_DNS_SAN_ALLOWLIST of istiod: %v", c.opts.DNSNames, constants.GatewayDNSSANsAnnotation, err)
+		}
 		return nil, fmt.Errorf("create certificate: %v", err)
 	}
 
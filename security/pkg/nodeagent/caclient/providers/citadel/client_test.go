@@ -223,6 +223,7 @@ func TestCitadelClientRotation(t *testing.T) {
 func TestCitadelClient(t *testing.T) {
 	testCases := map[string]struct {
 		server       mockCAServer
+		dnsNames     []string
 		expectedCert []string
 		expectedErr  string
 		expectRetry  bool
@@ -248,12 +249,18 @@ func TestCitadelClient(t *testing.T) {
 			expectedErr:  "rpc error: code = Unavailable desc = test failure",
 			expectRetry:  true,
 		},
+		"Denied DNS names": {
+			server:       mockCAServer{Certs: nil, Err: status.Error(codes.PermissionDenied, "test failure")},
+			dnsNames:     []string{"api.example.com"},
+			expectedCert: nil,
+			expectedErr:  "the DNS names [api.example.com] of the security.istio.io/gatewayDNSSANs annotation are not allowed",
+		},
 	}
 
 	for id, tc := range testCases {
 		t.Run(id, func(t *testing.T) {
 			addr := serve(t, tc.server)
-			cli, err := NewCitadelClient(&security.Options{CAEndpoint: addr}, nil)
+			cli, err := NewCitadelClient(&security.Options{CAEndpoint: addr, DNSNames: tc.dnsNames}, nil)
 			if err != nil {
 				t.Errorf("failed to create ca client: %v", err)
 			}
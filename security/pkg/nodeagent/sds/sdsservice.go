@@ -222,15 +222,27 @@ func toEnvoySecret(s *security.SecretItem, caRootPath string, pkpConf *mesh.Priv
 		cfg, ok = security.SdsCertificateConfigFromResourceName(s.ResourceName)
 	}
 	if s.ResourceName == security.RootCertReqResourceName || (ok && cfg.IsRootCertificate()) {
-		secret.Type = &tls.Secret_ValidationContext{
-			ValidationContext: &tls.CertificateValidationContext{
-				TrustedCa: &core.DataSource{
-					Specifier: &core.DataSource_InlineBytes{
-						InlineBytes: s.RootCert,
-					},
+		validationContext := &tls.CertificateValidationContext{
+			TrustedCa: &core.DataSource{
+				Specifier: &core.DataSource_InlineBytes{
+					InlineBytes: s.RootCert,
 				},
 			},
 		}
+		if len(s.CRL) > 0 {
+			// The proxy opted in to the CRLs of the istiod CA, where each issuer of peer certificates publishes a
+			// CRL and a revoked intermediate CA has none: only checking the CRL of the issuer of the peer
+			// certificate rejects the certificates of revoked CAs.
+			validationContext.Crl = &core.DataSource{
+				Specifier: &core.DataSource_InlineBytes{
+					InlineBytes: s.CRL,
+				},
+			}
+			validationContext.OnlyVerifyLeafCertCrl = true
+		}
+		secret.Type = &tls.Secret_ValidationContext{
+			ValidationContext: validationContext,
+		}
 	} else {
 		switch pkpConf.GetProvider().(type) {
 		case *mesh.PrivateKeyProvider_Cryptomb:
@@ -329,6 +329,18 @@ func TestSDS(t *testing.T) {
 	})
 }
 
+func TestToEnvoySecretCRL(t *testing.T) {
+	root := toEnvoySecret(&ca2.SecretItem{ResourceName: rootResourceName, RootCert: []byte(fakeRootCert)}, "", nil)
+	if root.GetValidationContext().GetCrl() != nil {
+		t.Errorf("expected no CRL without CRLs, got %v", root.GetValidationContext().GetCrl())
+	}
+	root = toEnvoySecret(&ca2.SecretItem{ResourceName: rootResourceName, RootCert: []byte(fakeRootCert), CRL: []byte("crl")}, "", nil)
+	vc := root.GetValidationContext()
+	if string(vc.GetCrl().GetInlineBytes()) != "crl" || !vc.GetOnlyVerifyLeafCertCrl() {
+		t.Errorf("expected the CRLs of the issuers of the peer certificates to be checked, got %v", vc)
+	}
+}
+
 func setupConnection(socket string) (*grpc.ClientConn, error) {
 	var opts []grpc.DialOption
 
@@ -69,26 +69,63 @@ func GetMetricsCounterValueWithTags(metricName string, tags map[string]string) (
 	return float64(0), fmt.Errorf("no metrics matched tags %s: %d", metricName, len(rows))
 }
 
+// Names of the files written by OutputKeyCertToDir.
+const (
+	KeyFileName       = "key.pem"
+	CertChainFileName = "cert-chain.pem"
+	RootCertFileName  = "root-cert.pem"
+)
+
+// atomicDataDir is the symlink, relative to the output directory, pointing to the current version of the files
+// written in atomic mode.
+const atomicDataDir = "..data"
+
+// OutputOptions controls how the key and certificates are written by OutputKeyCertToDirWithOptions.
+type OutputOptions struct {
+	// FileMode is the permission of the written files. If zero, 0600 is used, or 0644 when running on Kubernetes.
+	FileMode os.FileMode
+	// Atomic writes each version of the files into a new directory and switches a symlink to it, so readers
+	// never observe a private key that does not match the certificate chain during rotation.
+	Atomic bool
+}
+
 // Output the key and certificate to the given directory.
 // If directory string is empty, return nil.
 func OutputKeyCertToDir(dir string, privateKey, certChain, rootCert []byte) error {
+	return OutputKeyCertToDirWithOptions(dir, OutputOptions{}, privateKey, certChain, rootCert)
+}
+
+// OutputKeyCertToDirWithOptions outputs the key and certificate to the given directory.
+// If directory string is empty, return nil.
+func OutputKeyCertToDirWithOptions(dir string, opts OutputOptions, privateKey, certChain, rootCert []byte) error {
 	var err error
 	if len(dir) == 0 {
 		return nil
 	}
 
-	certFileMode := os.FileMode(0o600)
-	if k8sInCluster.Get() != "" {
-		// If this is running on k8s, give more permission to the file certs.
-		// This is typically used to share the certs with non-proxy containers in the pod which does not run as root or 1337.
-		// For example, prometheus server could use proxy provisioned certs to scrape application metrics through mTLS.
-		certFileMode = os.FileMode(0o644)
+	certFileMode := opts.FileMode
+	if certFileMode == 0 {
+		certFileMode = os.FileMode(0o600)
+		if k8sInCluster.Get() != "" {
+			// If this is running on k8s, give more permission to the file certs.
+			// This is typically used to share the certs with non-proxy containers in the pod which does not run as root or 1337.
+			// For example, prometheus server could use proxy provisioned certs to scrape application metrics through mTLS.
+			certFileMode = os.FileMode(0o644)
+		}
 	}
 	// Depending on the SDS resource to output, some fields may be nil
 	if privateKey == nil && certChain == nil && rootCert == nil {
 		return fmt.Errorf("the input private key, cert chain, and root cert are nil")
 	}
 
+	if opts.Atomic {
+		return outputAtomic(dir, certFileMode, map[string][]byte{
+			KeyFileName:       privateKey,
+			CertChainFileName: certChain,
+			RootCertFileName:  rootCert,
+		})
+	}
+
 	writeIfNotEqual := func(fileName string, newData []byte) error {
 		if newData == nil {
 			return nil
@@ -102,14 +139,94 @@ func OutputKeyCertToDir(dir string, privateKey, certChain, rootCert []byte) erro
 		return nil
 	}
 
-	if err = writeIfNotEqual("key.pem", privateKey); err != nil {
+	if err = writeIfNotEqual(KeyFileName, privateKey); err != nil {
 		return err
 	}
-	if err = writeIfNotEqual("cert-chain.pem", certChain); err != nil {
+	if err = writeIfNotEqual(CertChainFileName, certChain); err != nil {
+		return err
+	}
+	if err = writeIfNotEqual(RootCertFileName, rootCert); err != nil {
+		return err
+	}
+	return nil
+}
+
+// outputAtomic writes a complete new version of the files into a hidden directory and switches the ..data symlink
+// to it, in the same way Kubernetes updates secret volumes. The files in dir are symlinks through ..data, so
+// readers see either the previous or the new version of all files. Files not provided keep their current content.
+func outputAtomic(dir string, mode os.FileMode, files map[string][]byte) error {
+	changed := false
+	for name, data := range files {
+		current, err := os.ReadFile(path.Join(dir, name))
+		if data == nil {
+			if err == nil {
+				files[name] = current
+			}
+			continue
+		}
+		if err != nil || !bytes.Equal(current, data) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	version, err := os.MkdirTemp(dir, "..")
+	if err != nil {
+		return fmt.Errorf("failed to create directory for certificates: %v", err)
+	}
+	if err := os.Chmod(version, 0o755); err != nil {
 		return err
 	}
-	if err = writeIfNotEqual("root-cert.pem", rootCert); err != nil {
+	for name, data := range files {
+		if data == nil {
+			continue
+		}
+		if err := os.WriteFile(path.Join(version, name), data, mode); err != nil {
+			_ = os.RemoveAll(version)
+			return fmt.Errorf("failed to write data to file %v: %v", name, err)
+		}
+		// WriteFile only applies the mode on creation, and is subject to umask.
+		if err := os.Chmod(path.Join(version, name), mode); err != nil {
+			_ = os.RemoveAll(version)
+			return err
+		}
+	}
+
+	previous, _ := os.Readlink(path.Join(dir, atomicDataDir))
+	if err := replaceSymlink(path.Base(version), path.Join(dir, atomicDataDir)); err != nil {
+		_ = os.RemoveAll(version)
 		return err
 	}
+	for name, data := range files {
+		if data == nil {
+			continue
+		}
+		target := path.Join(atomicDataDir, name)
+		if link, err := os.Readlink(path.Join(dir, name)); err == nil && link == target {
+			continue
+		}
+		if err := replaceSymlink(target, path.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	if previous != "" && previous != path.Base(version) {
+		_ = os.RemoveAll(path.Join(dir, previous))
+	}
+	return nil
+}
+
+// replaceSymlink atomically replaces name with a symlink to target.
+func replaceSymlink(target, name string) error {
+	tmp := name + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return fmt.Errorf("failed to create symlink %v: %v", name, err)
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to replace %v: %v", name, err)
+	}
 	return nil
 }
@@ -0,0 +1,85 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputKeyCertToDirAtomic(t *testing.T) {
+	dir := t.TempDir()
+	opts := OutputOptions{FileMode: 0o640, Atomic: true}
+
+	if err := OutputKeyCertToDirWithOptions(dir, opts, []byte("key1"), []byte("chain1"), nil); err != nil {
+		t.Fatal(err)
+	}
+	// The root certificate is delivered separately, and must not drop the key and chain.
+	if err := OutputKeyCertToDirWithOptions(dir, opts, nil, nil, []byte("root1")); err != nil {
+		t.Fatal(err)
+	}
+	expectFiles(t, dir, map[string]string{KeyFileName: "key1", CertChainFileName: "chain1", RootCertFileName: "root1"})
+	first, err := os.Readlink(filepath.Join(dir, atomicDataDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := OutputKeyCertToDirWithOptions(dir, opts, []byte("key2"), []byte("chain2"), nil); err != nil {
+		t.Fatal(err)
+	}
+	expectFiles(t, dir, map[string]string{KeyFileName: "key2", CertChainFileName: "chain2", RootCertFileName: "root1"})
+	if _, err := os.Stat(filepath.Join(dir, first)); !os.IsNotExist(err) {
+		t.Fatalf("previous version %v was not removed: %v", first, err)
+	}
+
+	for _, name := range []string{KeyFileName, CertChainFileName, RootCertFileName} {
+		fi, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0o640 {
+			t.Errorf("%v: got mode %v, want %v", name, fi.Mode().Perm(), os.FileMode(0o640))
+		}
+		if _, err := os.Readlink(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%v: expected symlink: %v", name, err)
+		}
+	}
+}
+
+func TestOutputKeyCertToDirAtomicReplacesFiles(t *testing.T) {
+	dir := t.TempDir()
+	// Files written by a previous version of the agent are regular files.
+	if err := OutputKeyCertToDir(dir, []byte("key1"), []byte("chain1"), []byte("root1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := OutputKeyCertToDirWithOptions(dir, OutputOptions{Atomic: true}, []byte("key2"), []byte("chain2"), nil); err != nil {
+		t.Fatal(err)
+	}
+	expectFiles(t, dir, map[string]string{KeyFileName: "key2", CertChainFileName: "chain2", RootCertFileName: "root1"})
+}
+
+func expectFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%v: got %q, want %q", name, got, want)
+		}
+	}
+}
@@ -22,12 +22,14 @@ import (
 	"os"
 	"time"
 
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	"istio.io/istio/security/pkg/cmd"
 	k8ssecret "istio.io/istio/security/pkg/k8s/secret"
 	caerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/kms"
 	"istio.io/istio/security/pkg/pki/util"
 	certutil "istio.io/istio/security/pkg/util"
 	"istio.io/pkg/log"
@@ -92,6 +94,8 @@ const (
 	selfSignedCA caTypes = iota
 	// pluggedCertCA means the Istio CA uses a operator-specified key/cert.
 	pluggedCertCA
+	// namespaceCA means the Istio CA uses a key/cert issued to a namespace by another Istio CA.
+	namespaceCA
 )
 
 // IstioCAOptions holds the configurations for creating an Istio CA.
@@ -110,11 +114,12 @@ type IstioCAOptions struct {
 }
 
 // NewSelfSignedIstioCAOptions returns a new IstioCAOptions instance using self-signed certificate.
+// If keyWrapper is not nil, the CA private key is envelope-encrypted with it in CASecret.
 func NewSelfSignedIstioCAOptions(ctx context.Context,
 	rootCertGracePeriodPercentile int, caCertTTL, rootCertCheckInverval, defaultCertTTL,
 	maxCertTTL time.Duration, org string, dualUse bool, namespace string,
 	readCertRetryInterval time.Duration, client corev1.CoreV1Interface,
-	rootCertFile string, enableJitter bool, caRSAKeySize int,
+	rootCertFile string, enableJitter bool, caRSAKeySize int, keyWrapper kms.KeyWrapper,
 ) (caOpts *IstioCAOptions, err error) {
 	// For the first time the CA is up, if readSigningCertOnly is unset,
 	// it generates a self-signed key/cert pair and write it to CASecret.
@@ -153,6 +158,7 @@ func NewSelfSignedIstioCAOptions(ctx context.Context,
 			rootCertFile:       rootCertFile,
 			enableJitter:       enableJitter,
 			client:             client,
+			keyWrapper:         keyWrapper,
 		},
 	}
 	if scrtErr != nil {
@@ -180,8 +186,12 @@ func NewSelfSignedIstioCAOptions(ctx context.Context,
 			return nil, fmt.Errorf("failed to create CA KeyCertBundle (%v)", err)
 		}
 
+		storedKey, err := encryptCAKey(ctx, keyWrapper, pemKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt CA private key (%v)", err)
+		}
 		// Write the key/cert back to secret so they will be persistent when CA restarts.
-		secret := k8ssecret.BuildSecret("", CASecret, namespace, nil, nil, nil, pemCert, pemKey, istioCASecretType)
+		secret := k8ssecret.BuildSecret("", CASecret, namespace, nil, nil, nil, pemCert, storedKey, istioCASecretType)
 		if _, err = client.Secrets(namespace).Create(context.TODO(), secret, metav1.CreateOptions{}); err != nil {
 			pkiCaLog.Errorf("Failed to write secret to CA (error: %s). Abort.", err)
 			return nil, fmt.Errorf("failed to create CA due to secret write error")
@@ -193,15 +203,58 @@ func NewSelfSignedIstioCAOptions(ctx context.Context,
 		if err != nil {
 			return nil, fmt.Errorf("failed to append root certificates (%v)", err)
 		}
+		caKey, err := kms.Decrypt(ctx, keyWrapper, caSecret.Data[CAPrivateKeyFile])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt CA private key (%v)", err)
+		}
 		if caOpts.KeyCertBundle, err = util.NewVerifiedKeyCertBundleFromPem(caSecret.Data[CACertFile],
-			caSecret.Data[CAPrivateKeyFile], nil, rootCerts); err != nil {
+			caKey, nil, rootCerts); err != nil {
 			return nil, fmt.Errorf("failed to create CA KeyCertBundle (%v)", err)
 		}
+		if keyWrapper != nil {
+			if err := reencryptCAKey(ctx, client, keyWrapper, caSecret, caKey); err != nil {
+				// The key can still be decrypted, so the CA can run; the rotator retries.
+				pkiCaLog.Warnf("Failed to re-encrypt CA private key: %v", err)
+			}
+		}
 		pkiCaLog.Infof("Using existing public key: %v", string(rootCerts))
 	}
 	return caOpts, nil
 }
 
+// encryptCAKey returns the CA private key as stored in CASecret: encrypted with keyWrapper, or as is if it is nil.
+func encryptCAKey(ctx context.Context, keyWrapper kms.KeyWrapper, key []byte) ([]byte, error) {
+	if keyWrapper == nil {
+		return key, nil
+	}
+	return kms.Encrypt(ctx, keyWrapper, key)
+}
+
+// reencryptCAKey encrypts the CA private key in caSecret with the primary version of the KMS key, if it is stored in
+// plaintext or encrypted with another KMS key or version, for example after the KMS key was rotated.
+func reencryptCAKey(ctx context.Context, client corev1.CoreV1Interface, keyWrapper kms.KeyWrapper, caSecret *v1.Secret,
+	key []byte,
+) error {
+	reencrypt, err := kms.NeedsReencryption(ctx, keyWrapper, caSecret.Data[CAPrivateKeyFile])
+	if err != nil || !reencrypt {
+		return err
+	}
+	encrypted, err := kms.Encrypt(ctx, keyWrapper, key)
+	if err != nil {
+		return err
+	}
+	updated := caSecret.DeepCopy()
+	updated.Data[CAPrivateKeyFile] = encrypted
+	if updated, err = client.Secrets(caSecret.Namespace).Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	// Keep the resource version current, so caSecret can be updated again.
+	*caSecret = *updated
+	pkiCaLog.Infof("CA private key in secret %s:%s is encrypted with KMS key %s", caSecret.Namespace, caSecret.Name,
+		keyWrapper.Name())
+	return nil
+}
+
 // NewSelfSignedDebugIstioCAOptions returns a new IstioCAOptions instance using self-signed certificate produced by in-memory CA,
 // which runs without K8s, and no local ca key file presented.
 func NewSelfSignedDebugIstioCAOptions(rootCertFile string, caCertTTL, defaultCertTTL, maxCertTTL time.Duration,
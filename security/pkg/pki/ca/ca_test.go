@@ -29,6 +29,7 @@ import (
 
 	k8ssecret "istio.io/istio/security/pkg/k8s/secret"
 	caerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/kms"
 	"istio.io/istio/security/pkg/pki/util"
 )
 
@@ -100,7 +101,7 @@ func TestCreateSelfSignedIstioCAWithoutSecret(t *testing.T) {
 	caopts, err := NewSelfSignedIstioCAOptions(context.Background(),
 		0, caCertTTL, rootCertCheckInverval, defaultCertTTL,
 		maxCertTTL, org, false, caNamespace, -1, client.CoreV1(),
-		rootCertFile, false, rsaKeySize)
+		rootCertFile, false, rsaKeySize, nil)
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
 	}
@@ -177,7 +178,7 @@ func TestCreateSelfSignedIstioCAWithSecret(t *testing.T) {
 	caopts, err := NewSelfSignedIstioCAOptions(context.Background(),
 		0, caCertTTL, rootCertCheckInverval, defaultCertTTL, maxCertTTL,
 		org, false, caNamespace, -1, client.CoreV1(),
-		rootCertFile, false, rsaKeySize)
+		rootCertFile, false, rsaKeySize, nil)
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
 	}
@@ -233,7 +234,7 @@ func TestCreateSelfSignedIstioCAReadSigningCertOnly(t *testing.T) {
 	defer cancel0()
 	_, err := NewSelfSignedIstioCAOptions(ctx0, 0,
 		caCertTTL, defaultCertTTL, rootCertCheckInverval, maxCertTTL, org, false,
-		caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile, false, rsaKeySize)
+		caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile, false, rsaKeySize, nil)
 	if err == nil {
 		t.Errorf("Expected error, but succeeded.")
 	} else if err.Error() != expectedErr {
@@ -252,7 +253,7 @@ func TestCreateSelfSignedIstioCAReadSigningCertOnly(t *testing.T) {
 	defer cancel1()
 	caopts, err := NewSelfSignedIstioCAOptions(ctx1, 0,
 		caCertTTL, defaultCertTTL, rootCertCheckInverval, maxCertTTL, org, false,
-		caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile, false, rsaKeySize)
+		caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile, false, rsaKeySize, nil)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -285,6 +286,95 @@ func TestCreateSelfSignedIstioCAReadSigningCertOnly(t *testing.T) {
 	}
 }
 
+// fakeKeyWrapper wraps data keys by prefixing them with the primary version of the KMS key.
+type fakeKeyWrapper struct {
+	primary string
+	// unwraps counts the calls to Unwrap.
+	unwraps int
+}
+
+func (f *fakeKeyWrapper) Name() string {
+	return "gcpkms://fake"
+}
+
+func (f *fakeKeyWrapper) Wrap(_ context.Context, dataKey []byte) ([]byte, string, error) {
+	return append([]byte(f.primary+":"), dataKey...), f.primary, nil
+}
+
+func (f *fakeKeyWrapper) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	f.unwraps++
+	return wrapped[bytes.IndexByte(wrapped, ':')+1:], nil
+}
+
+func (f *fakeKeyWrapper) PrimaryVersion(context.Context) (string, error) {
+	return f.primary, nil
+}
+
+func TestCreateSelfSignedIstioCAWithKMS(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	initSecret := k8ssecret.BuildSecret("", CASecret, "default",
+		nil, nil, nil, []byte(cert1Pem), []byte(key1Pem), istioCASecretType)
+	if _, err := client.CoreV1().Secrets("default").Create(context.TODO(), initSecret, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create secret (error: %s)", err)
+	}
+	storedKey := func() []byte {
+		secret, err := client.CoreV1().Secrets("default").Get(context.TODO(), CASecret, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get secret (error: %s)", err)
+		}
+		return secret.Data[CAPrivateKeyFile]
+	}
+	newCA := func(w kms.KeyWrapper) *IstioCA {
+		caopts, err := NewSelfSignedIstioCAOptions(context.Background(),
+			0, time.Hour, time.Hour, 30*time.Minute, time.Hour,
+			"test.ca.Org", false, "default", -1, client.CoreV1(),
+			"", false, 2048, w)
+		if err != nil {
+			t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+		}
+		ca, err := NewIstioCA(caopts)
+		if err != nil {
+			t.Fatalf("Got error while creating self-signed CA: %v", err)
+		}
+		return ca
+	}
+
+	// The plaintext key is encrypted at startup.
+	w := &fakeKeyWrapper{primary: "v1"}
+	ca := newCA(w)
+	if _, key, _, _ := ca.GetCAKeyCertBundle().GetAllPem(); !bytes.Equal(key, []byte(key1Pem)) {
+		t.Error("CA signing key does not match the plaintext key")
+	}
+	v1Key := storedKey()
+	if !kms.IsEncrypted(v1Key) {
+		t.Fatalf("CA signing key is not encrypted in the K8s secret: %s", v1Key)
+	}
+
+	// The key is decrypted at startup, and not encrypted again while the KMS key is not rotated.
+	ca = newCA(w)
+	if _, key, _, _ := ca.GetCAKeyCertBundle().GetAllPem(); !bytes.Equal(key, []byte(key1Pem)) {
+		t.Error("CA signing key does not match the decrypted key")
+	}
+	if !bytes.Equal(storedKey(), v1Key) {
+		t.Error("CA signing key was encrypted again, but the KMS key was not rotated")
+	}
+
+	// The key is encrypted again after the KMS key is rotated.
+	w.primary = "v2"
+	newCA(w)
+	if reencrypt, err := kms.NeedsReencryption(context.Background(), w, storedKey()); err != nil || reencrypt {
+		t.Errorf("CA signing key is not encrypted with the rotated KMS key: %v", err)
+	}
+
+	// The encrypted key cannot be loaded without the KMS key.
+	if _, err := NewSelfSignedIstioCAOptions(context.Background(),
+		0, time.Hour, time.Hour, 30*time.Minute, time.Hour,
+		"test.ca.Org", false, "default", -1, client.CoreV1(),
+		"", false, 2048, nil); err == nil {
+		t.Error("Expected error loading the encrypted key without a KMS key")
+	}
+}
+
 func TestCreatePluggedCertCA(t *testing.T) {
 	rootCertFile := "../testdata/multilevelpki/root-cert.pem"
 	certChainFile := []string{"../testdata/multilevelpki/int2-cert-chain.pem"}
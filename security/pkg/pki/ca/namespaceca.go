@@ -0,0 +1,609 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/spiffe"
+	caerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// maxNamespaceCAUpdateAttempts is the number of times a replica tries to update the state of a namespace in the
+// store, when other replicas update it concurrently.
+const maxNamespaceCAUpdateAttempts = 5
+
+// ErrNamespaceCAConflict is returned by a NamespaceCAStore when the state was updated since it was read.
+var ErrNamespaceCAConflict = errors.New("the namespace CA state was updated concurrently")
+
+// NamespaceCAInfo is the metadata of the intermediate CA certificate of a namespace.
+type NamespaceCAInfo struct {
+	Namespace string    `json:"namespace"`
+	Subject   string    `json:"subject"`
+	Serial    string    `json:"serial"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	// RevokedAt is the time the CA certificate was revoked, or nil if it is in use.
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// NamespaceCAKeyCert is the PEM encoded certificate and private key of the intermediate CA of a namespace.
+type NamespaceCAKeyCert struct {
+	CertPem []byte
+	KeyPem  []byte
+}
+
+// NamespaceCAState is the stored state of the intermediate CAs of a namespace.
+type NamespaceCAState struct {
+	Namespace string
+	// CAs are the intermediate CAs of the namespace which are neither revoked nor expired, the latest last.
+	CAs []NamespaceCAKeyCert
+	// Revoked are the intermediate CAs of the namespace which are revoked and have not expired.
+	Revoked []NamespaceCAInfo
+	// Published is the time the CRL of each intermediate CA of CAs, by serial, was first observed in the
+	// istio-ca-root-cert ConfigMap of the namespace.
+	Published map[string]time.Time
+	// Version is the version of the state in the store, or empty if the state is not stored yet.
+	Version string
+}
+
+// NamespaceCAStore stores the intermediate CAs of the namespaces, so that all the istiod replicas sign with and
+// revoke the same ones, across restarts.
+type NamespaceCAStore interface {
+	// Get returns the state of the namespace, with an empty Version if it is not stored.
+	Get(namespace string) (*NamespaceCAState, error)
+	// Update stores the state of a namespace. It fails with ErrNamespaceCAConflict if the state was updated since
+	// it was read.
+	Update(state *NamespaceCAState) error
+	// List returns the states of all the namespaces.
+	List() ([]*NamespaceCAState, error)
+}
+
+type namespaceCAEntry struct {
+	ca   *IstioCA
+	cert *x509.Certificate
+	// certPem is the PEM encoded intermediate CA certificate, or nil if the entry is the parent CA.
+	certPem []byte
+	// crlPem is the PEM encoded empty CRL of the intermediate CA, which proxies require to accept the
+	// certificates it issued.
+	crlPem []byte
+	// signedByParent tells whether the certificate was signed by the current signing certificate of the parent CA.
+	signedByParent bool
+	parentCertPem  []byte
+}
+
+// NamespaceCA issues the workload certificates of each namespace from an intermediate CA of the namespace, signed
+// by the parent Istio CA. The workload certificates of a namespace can then be audited by their issuer, and
+// revoked together by revoking the intermediate CA certificate of the namespace.
+//
+// The intermediate CAs are kept in a NamespaceCAStore shared by the istiod replicas. Revocation is enforced by
+// proxies with CRLs: each intermediate CA publishes an empty CRL, and proxies only accept the workload
+// certificates whose issuer has one. The CRL of a revoked intermediate CA is withdrawn, and the CRL of the parent
+// CA lists it. A new intermediate CA is only used activationDelay after its CRL is observed in the
+// istio-ca-root-cert ConfigMap of its namespace, the time for the kubelets to update the pods; until then, the
+// workload certificates of the namespace are issued by the parent CA, with a short TTL.
+type NamespaceCA struct {
+	parent          *IstioCA
+	caCertTTL       time.Duration
+	activationDelay time.Duration
+	store           NamespaceCAStore
+
+	mu sync.Mutex
+	// cas caches the parsed intermediate CAs of the store, by certificate serial.
+	cas map[string]*namespaceCAEntry
+	now func() time.Time
+}
+
+// NewNamespaceCA returns a NamespaceCA issuing intermediate CA certificates valid for caCertTTL, signed by parent,
+// which are used activationDelay after their CRL is observed in the ConfigMap of their namespace, or at once if
+// activationDelay is 0. The signing certificate of parent must allow signing CRLs.
+func NewNamespaceCA(parent *IstioCA, caCertTTL, activationDelay time.Duration, store NamespaceCAStore) (*NamespaceCA, error) {
+	signingCert, _, _, _ := parent.GetCAKeyCertBundle().GetAll()
+	if signingCert != nil && signingCert.KeyUsage != 0 && signingCert.KeyUsage&x509.KeyUsageCRLSign == 0 {
+		return nil, fmt.Errorf("the Istio CA certificate %s does not allow signing CRLs, which is required to revoke "+
+			"the namespace CAs", signingCert.Subject)
+	}
+	return &NamespaceCA{
+		parent:          parent,
+		caCertTTL:       caCertTTL,
+		activationDelay: activationDelay,
+		store:           store,
+		cas:             map[string]*namespaceCAEntry{},
+		now:             time.Now,
+	}, nil
+}
+
+// Sign signs the CSR with the intermediate CA of the namespace of the SPIFFE identities in certOpts, and returns
+// the signed certificate followed by the intermediate CA certificate.
+func (n *NamespaceCA) Sign(csrPEM []byte, certOpts CertOpts) ([]byte, error) {
+	entry, err := n.namespaceCA(certOpts.SubjectIDs)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := entry.ca.Sign(csrPEM, n.capTTL(entry, certOpts))
+	if err != nil {
+		return nil, err
+	}
+	return append(cert, entry.certPem...), nil
+}
+
+// SignWithCertChain is similar to Sign but returns the leaf cert and the entire cert chain, up to the root.
+func (n *NamespaceCA) SignWithCertChain(csrPEM []byte, certOpts CertOpts) ([]string, error) {
+	entry, err := n.namespaceCA(certOpts.SubjectIDs)
+	if err != nil {
+		return nil, err
+	}
+	return entry.ca.SignWithCertChain(csrPEM, n.capTTL(entry, certOpts))
+}
+
+// GetCAKeyCertBundle returns the KeyCertBundle of the parent CA.
+func (n *NamespaceCA) GetCAKeyCertBundle() *util.KeyCertBundle {
+	return n.parent.GetCAKeyCertBundle()
+}
+
+// List returns the intermediate CA certificates of the namespaces, revoked or not, which have not expired,
+// ordered by namespace, with the revoked certificates of a namespace first.
+func (n *NamespaceCA) List() ([]NamespaceCAInfo, error) {
+	states, err := n.store.List()
+	if err != nil {
+		return nil, err
+	}
+	now := n.now()
+	var out []NamespaceCAInfo
+	for _, state := range states {
+		for _, info := range state.Revoked {
+			if info.NotAfter.After(now) {
+				out = append(out, info)
+			}
+		}
+		for _, keyCert := range state.CAs {
+			cert, err := util.ParsePemEncodedCertificate(keyCert.CertPem)
+			if err != nil {
+				return nil, err
+			}
+			if cert.NotAfter.After(now) {
+				out = append(out, newNamespaceCAInfo(state.Namespace, cert))
+			}
+		}
+	}
+	sort.SliceStable(out, func(a, b int) bool {
+		return out[a].Namespace < out[b].Namespace
+	})
+	return out, nil
+}
+
+// Revoke revokes the intermediate CA certificates of the namespace, and returns them. The next workload
+// certificates of the namespace are issued from a new one. The workloads holding a certificate of a revoked one
+// must be restarted once the CRLs reached the proxies.
+func (n *NamespaceCA) Revoke(namespace string) ([]NamespaceCAInfo, error) {
+	for attempt := 0; attempt < maxNamespaceCAUpdateAttempts; attempt++ {
+		state, err := n.store.Get(namespace)
+		if err != nil {
+			return nil, err
+		}
+		if len(state.CAs) == 0 {
+			return nil, fmt.Errorf("no CA certificate is issued to namespace %s", namespace)
+		}
+		revokedAt := n.now()
+		var revoked []NamespaceCAInfo
+		for _, keyCert := range state.CAs {
+			cert, err := util.ParsePemEncodedCertificate(keyCert.CertPem)
+			if err != nil {
+				return nil, err
+			}
+			info := newNamespaceCAInfo(namespace, cert)
+			info.RevokedAt = &revokedAt
+			revoked = append(revoked, info)
+		}
+		state.CAs = nil
+		state.Published = nil
+		state.Revoked = append(n.unexpired(state.Revoked), revoked...)
+		if err := n.store.Update(state); err != nil {
+			if errors.Is(err, ErrNamespaceCAConflict) {
+				continue
+			}
+			return nil, err
+		}
+		for _, info := range revoked {
+			pkiCaLog.Infof("Revoked the CA certificate %s of namespace %s", info.Serial, namespace)
+		}
+		return revoked, nil
+	}
+	return nil, fmt.Errorf("failed to revoke the CA certificates of namespace %s: %v", namespace, ErrNamespaceCAConflict)
+}
+
+// ObserveCRL records the time the CRLs of the intermediate CAs of the namespace are first observed in crl, the CRLs
+// of the istio-ca-root-cert ConfigMap of the namespace. The intermediate CAs are used activationDelay later.
+func (n *NamespaceCA) ObserveCRL(namespace string, crl []byte) error {
+	var crls []*x509.RevocationList
+	for _, block := range splitPemBlocks(crl) {
+		b, _ := pem.Decode(block)
+		if parsed, err := x509.ParseRevocationList(b.Bytes); err == nil {
+			crls = append(crls, parsed)
+		}
+	}
+	for attempt := 0; attempt < maxNamespaceCAUpdateAttempts; attempt++ {
+		state, err := n.store.Get(namespace)
+		if err != nil {
+			return err
+		}
+		observed := false
+		for _, keyCert := range state.CAs {
+			cert, err := util.ParsePemEncodedCertificate(keyCert.CertPem)
+			if err != nil {
+				return err
+			}
+			serial := cert.SerialNumber.String()
+			if _, f := state.Published[serial]; f || !hasCRL(crls, cert) {
+				continue
+			}
+			if state.Published == nil {
+				state.Published = map[string]time.Time{}
+			}
+			state.Published[serial] = n.now()
+			observed = true
+		}
+		if !observed {
+			return nil
+		}
+		if err := n.store.Update(state); err != nil {
+			if errors.Is(err, ErrNamespaceCAConflict) {
+				continue
+			}
+			return err
+		}
+		return nil
+	}
+	return fmt.Errorf("failed to record the CRLs of namespace %s: %v", namespace, ErrNamespaceCAConflict)
+}
+
+// CRL returns the PEM encoded CRLs to validate the workload certificates with: the CRL of the parent CA, listing
+// the revoked intermediate CA certificates, and the empty CRL of each intermediate CA which is not revoked.
+func (n *NamespaceCA) CRL() ([]byte, error) {
+	states, err := n.store.List()
+	if err != nil {
+		return nil, err
+	}
+	signingCert, signingKey, _, _ := n.parent.GetCAKeyCertBundle().GetAll()
+	if signingCert == nil {
+		return nil, fmt.Errorf("Istio CA is not ready") // nolint
+	}
+	now := n.now()
+	var revoked []x509.RevocationListEntry
+	var out bytes.Buffer
+	for _, state := range states {
+		for _, info := range n.unexpired(state.Revoked) {
+			serial, ok := new(big.Int).SetString(info.Serial, 10)
+			if !ok {
+				return nil, fmt.Errorf("invalid serial %q of a revoked CA certificate of namespace %s", info.Serial, state.Namespace)
+			}
+			revoked = append(revoked, x509.RevocationListEntry{SerialNumber: serial, RevocationTime: *info.RevokedAt})
+		}
+		for _, keyCert := range state.CAs {
+			entry, err := n.entry(keyCert)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(entry.crlPem)
+		}
+	}
+	sort.Slice(revoked, func(a, b int) bool {
+		return revoked[a].SerialNumber.Cmp(revoked[b].SerialNumber) < 0
+	})
+	crl, err := newCRL(signingCert, *signingKey, revoked, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the CRL of the Istio CA: %v", err)
+	}
+	return append(crl, out.Bytes()...), nil
+}
+
+// capTTL returns certOpts with a TTL such that the workload certificate does not outlive the intermediate CA.
+func (n *NamespaceCA) capTTL(entry *namespaceCAEntry, certOpts CertOpts) CertOpts {
+	// The parent CA only signs until the intermediate CA of the namespace is used: the workload certificate is
+	// rotated at half of its lifetime, by then.
+	remaining := 2 * n.activationDelay
+	if entry.certPem != nil {
+		remaining = entry.cert.NotAfter.Sub(n.now())
+	}
+	ttl := certOpts.TTL
+	if ttl <= 0 {
+		ttl = entry.ca.defaultCertTTL
+	}
+	if ttl > remaining {
+		certOpts.TTL = remaining
+	}
+	return certOpts
+}
+
+// namespaceCA returns the CA signing the workload certificates of the namespace of the first SPIFFE identity in
+// subjectIDs: its latest intermediate CA which is active, or the parent CA if none is. A new intermediate CA is
+// issued if the namespace has none, or if its latest one is past half of its lifetime or was not signed by the
+// current signing certificate of the parent CA.
+func (n *NamespaceCA) namespaceCA(subjectIDs []string) (*namespaceCAEntry, error) {
+	var identity spiffe.Identity
+	var err error
+	for _, id := range subjectIDs {
+		if identity, err = spiffe.ParseIdentity(id); err == nil {
+			break
+		}
+	}
+	if identity.Namespace == "" {
+		return nil, caerror.NewError(caerror.CSRError, fmt.Errorf("no SPIFFE identity in %v", subjectIDs))
+	}
+
+	for attempt := 0; attempt < maxNamespaceCAUpdateAttempts; attempt++ {
+		state, err := n.store.Get(identity.Namespace)
+		if err != nil {
+			return nil, caerror.NewError(caerror.CertGenError, fmt.Errorf("failed to get the CA certificates of namespace %s: %v",
+				identity.Namespace, err))
+		}
+		now := n.now()
+		var active, latest *namespaceCAEntry
+		for _, keyCert := range state.CAs {
+			entry, err := n.entry(keyCert)
+			if err != nil {
+				return nil, caerror.NewError(caerror.CertGenError, err)
+			}
+			latest = entry
+			if entry.signedByParent && n.activated(state, entry.cert) && entry.cert.NotAfter.After(now) {
+				active = entry
+			}
+		}
+		if latest != nil && latest.signedByParent && latest.cert.NotAfter.Sub(now) > n.caCertTTL/2 {
+			if active == nil {
+				return &namespaceCAEntry{ca: n.parent}, nil
+			}
+			return active, nil
+		}
+
+		// The key is generated without holding any lock: concurrent requests for the namespace may each generate
+		// one, and only the first one stored is kept.
+		keyCert, err := n.newNamespaceCA(identity.Namespace)
+		if err != nil {
+			return nil, caerror.NewError(caerror.CertGenError, fmt.Errorf("failed to issue the CA certificate of namespace %s: %v",
+				identity.Namespace, err))
+		}
+		state.CAs = append(n.unexpiredCAs(state.CAs), keyCert)
+		state.Published = published(state)
+		state.Revoked = n.unexpired(state.Revoked)
+		if err := n.store.Update(state); err != nil {
+			if errors.Is(err, ErrNamespaceCAConflict) {
+				continue
+			}
+			return nil, caerror.NewError(caerror.CertGenError, fmt.Errorf("failed to store the CA certificate of namespace %s: %v",
+				identity.Namespace, err))
+		}
+		entry, err := n.entry(keyCert)
+		if err != nil {
+			return nil, caerror.NewError(caerror.CertGenError, err)
+		}
+		pkiCaLog.Infof("Issued the CA certificate %s of namespace %s, valid until %v", entry.cert.SerialNumber,
+			identity.Namespace, entry.cert.NotAfter)
+	}
+	return nil, caerror.NewError(caerror.CertGenError, fmt.Errorf("failed to issue the CA certificate of namespace %s: %v",
+		identity.Namespace, ErrNamespaceCAConflict))
+}
+
+// entry returns the parsed intermediate CA of keyCert, from the cache if it was already parsed.
+func (n *NamespaceCA) entry(keyCert NamespaceCAKeyCert) (*namespaceCAEntry, error) {
+	cert, err := util.ParsePemEncodedCertificate(keyCert.CertPem)
+	if err != nil {
+		return nil, err
+	}
+	signingCert, _, certChainPem, rootCertPem := n.parent.GetCAKeyCertBundle().GetAll()
+	if signingCert == nil {
+		return nil, fmt.Errorf("Istio CA is not ready") // nolint
+	}
+	serial := cert.SerialNumber.String()
+	n.mu.Lock()
+	entry, f := n.cas[serial]
+	n.mu.Unlock()
+	if f && bytes.Equal(entry.certPem, keyCert.CertPem) && bytes.Equal(entry.parentCertPem, signingCert.Raw) {
+		return entry, nil
+	}
+
+	// The chain of the intermediate CA certificate is itself followed by the chain of the parent CA certificate,
+	// which is empty when the parent CA certificate is the root. The bundle is not verified, as the certificate is
+	// not valid before it is used.
+	chainPem := append(append([]byte{}, keyCert.CertPem...), certChainPem...)
+	keyCertBundle := util.NewKeyCertBundleFromPem(keyCert.CertPem, keyCert.KeyPem, chainPem, rootCertPem)
+	if _, key, _, _ := keyCertBundle.GetAll(); key == nil {
+		return nil, fmt.Errorf("invalid private key of the CA certificate %s", serial)
+	}
+	istioCA, err := NewIstioCA(&IstioCAOptions{
+		CAType:         namespaceCA,
+		DefaultCertTTL: n.parent.defaultCertTTL,
+		MaxCertTTL:     n.parent.maxCertTTL,
+		CARSAKeySize:   n.parent.caRSAKeySize,
+		KeyCertBundle:  keyCertBundle,
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, key, _, _ := keyCertBundle.GetAll()
+	crlPem, err := newCRL(cert, *key, nil, cert.NotBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the CRL of the CA certificate %s: %v", serial, err)
+	}
+	entry = &namespaceCAEntry{
+		ca:             istioCA,
+		cert:           cert,
+		certPem:        keyCert.CertPem,
+		crlPem:         crlPem,
+		signedByParent: bytes.Equal(cert.RawIssuer, signingCert.RawSubject) && cert.CheckSignatureFrom(signingCert) == nil,
+		parentCertPem:  signingCert.Raw,
+	}
+	n.mu.Lock()
+	n.cas[serial] = entry
+	n.mu.Unlock()
+	return entry, nil
+}
+
+// newNamespaceCA issues an intermediate CA certificate to the namespace, with the namespace as the organization of
+// its subject.
+func (n *NamespaceCA) newNamespaceCA(namespace string) (NamespaceCAKeyCert, error) {
+	signingCert, signingKey, _, _ := n.parent.GetCAKeyCertBundle().GetAll()
+	if signingCert == nil {
+		return NamespaceCAKeyCert{}, fmt.Errorf("Istio CA is not ready") // nolint
+	}
+	var key crypto.Signer
+	var err error
+	if util.IsSupportedECPrivateKey(signingKey) {
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	} else {
+		keySize := n.parent.caRSAKeySize
+		if keySize == 0 {
+			keySize = rsaKeySize
+		}
+		key, err = rsa.GenerateKey(rand.Reader, keySize)
+	}
+	if err != nil {
+		return NamespaceCAKeyCert{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return NamespaceCAKeyCert{}, err
+	}
+	notBefore := n.now().Add(-util.ClockSkewGracePeriod)
+	notAfter := n.now().Add(n.caCertTTL)
+	if notAfter.After(signingCert.NotAfter) {
+		notAfter = signingCert.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		// The serial number of the subject tells the successive CAs of the namespace apart, so that proxies look up
+		// the CRL of the right one.
+		Subject:               pkix.Name{Organization: []string{namespace}, SerialNumber: serial.Text(16)},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, signingCert, key.Public(), *signingKey)
+	if err != nil {
+		return NamespaceCAKeyCert{}, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return NamespaceCAKeyCert{}, err
+	}
+	return NamespaceCAKeyCert{
+		CertPem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		KeyPem:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
+
+// activated tells whether the intermediate CA certificate is used: activationDelay after its CRL was observed in the
+// ConfigMap of its namespace, or at once if activationDelay is 0.
+func (n *NamespaceCA) activated(state *NamespaceCAState, cert *x509.Certificate) bool {
+	if n.activationDelay == 0 {
+		return true
+	}
+	observed, f := state.Published[cert.SerialNumber.String()]
+	return f && !observed.Add(n.activationDelay).After(n.now())
+}
+
+// published returns the publication times of the intermediate CAs of the state, dropping those of the other CAs.
+func published(state *NamespaceCAState) map[string]time.Time {
+	out := map[string]time.Time{}
+	for _, keyCert := range state.CAs {
+		cert, err := util.ParsePemEncodedCertificate(keyCert.CertPem)
+		if err != nil {
+			continue
+		}
+		if t, f := state.Published[cert.SerialNumber.String()]; f {
+			out[cert.SerialNumber.String()] = t
+		}
+	}
+	return out
+}
+
+// hasCRL tells whether one of the CRLs is signed by the CA certificate.
+func hasCRL(crls []*x509.RevocationList, cert *x509.Certificate) bool {
+	for _, crl := range crls {
+		if bytes.Equal(crl.RawIssuer, cert.RawSubject) && crl.CheckSignatureFrom(cert) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// unexpired returns the revoked CA certificates which have not expired.
+func (n *NamespaceCA) unexpired(revoked []NamespaceCAInfo) []NamespaceCAInfo {
+	now := n.now()
+	var out []NamespaceCAInfo
+	for _, info := range revoked {
+		if info.NotAfter.After(now) {
+			out = append(out, info)
+		}
+	}
+	return out
+}
+
+// unexpiredCAs returns the intermediate CAs which have not expired.
+func (n *NamespaceCA) unexpiredCAs(cas []NamespaceCAKeyCert) []NamespaceCAKeyCert {
+	now := n.now()
+	var out []NamespaceCAKeyCert
+	for _, keyCert := range cas {
+		if cert, err := util.ParsePemEncodedCertificate(keyCert.CertPem); err == nil && cert.NotAfter.After(now) {
+			out = append(out, keyCert)
+		}
+	}
+	return out
+}
+
+// newCRL returns the PEM encoded CRL of the CA, valid until the CA certificate expires.
+func newCRL(caCert *x509.Certificate, caKey crypto.PrivateKey, revoked []x509.RevocationListEntry, now time.Time) ([]byte, error) {
+	signer, ok := caKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported CA private key %T", caKey)
+	}
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(now.UnixNano()),
+		ThisUpdate:                now.Add(-util.ClockSkewGracePeriod),
+		NextUpdate:                caCert.NotAfter,
+		RevokedCertificateEntries: revoked,
+	}, caCert, signer)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl}), nil
+}
+
+func newNamespaceCAInfo(namespace string, cert *x509.Certificate) NamespaceCAInfo {
+	return NamespaceCAInfo{
+		Namespace: namespace,
+		Subject:   cert.Subject.String(),
+		Serial:    cert.SerialNumber.String(),
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+	}
+}
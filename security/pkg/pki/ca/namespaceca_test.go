@@ -0,0 +1,463 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// memoryNamespaceCAStore is a NamespaceCAStore keeping the states in memory.
+type memoryNamespaceCAStore struct {
+	mu      sync.Mutex
+	states  map[string]NamespaceCAState
+	version int
+}
+
+func newMemoryNamespaceCAStore() *memoryNamespaceCAStore {
+	return &memoryNamespaceCAStore{states: map[string]NamespaceCAState{}}
+}
+
+func (s *memoryNamespaceCAStore) Get(namespace string) (*NamespaceCAState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.states[namespace]
+	state.Namespace = namespace
+	state.CAs = append([]NamespaceCAKeyCert{}, state.CAs...)
+	state.Revoked = append([]NamespaceCAInfo{}, state.Revoked...)
+	published := map[string]time.Time{}
+	for serial, t := range state.Published {
+		published[serial] = t
+	}
+	state.Published = published
+	return &state, nil
+}
+
+func (s *memoryNamespaceCAStore) Update(state *NamespaceCAState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.states[state.Namespace].Version != state.Version {
+		return ErrNamespaceCAConflict
+	}
+	s.version++
+	stored := *state
+	stored.Version = strconv.Itoa(s.version)
+	s.states[state.Namespace] = stored
+	return nil
+}
+
+func (s *memoryNamespaceCAStore) List() ([]*NamespaceCAState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*NamespaceCAState
+	for ns := range s.states {
+		state := s.states[ns]
+		out = append(out, &state)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Namespace < out[j].Namespace
+	})
+	return out, nil
+}
+
+func newTestParentCA(t *testing.T, plugged bool) *IstioCA {
+	t.Helper()
+	caopts, err := NewSelfSignedDebugIstioCAOptions("", 24*time.Hour, 30*time.Minute, time.Hour, "cluster.local", 2048)
+	if err != nil {
+		t.Fatalf("failed to create CA options: %v", err)
+	}
+	if plugged {
+		// The plugged CA certificate is an intermediate of the self-signed root.
+		rootCert, rootKey, _, rootPem := caopts.KeyCertBundle.GetAll()
+		certPem, keyPem, err := util.GenCertKeyFromOptions(util.CertOptions{
+			TTL:        24 * time.Hour,
+			SignerCert: rootCert,
+			SignerPriv: *rootKey,
+			Org:        "plugged",
+			IsCA:       true,
+			RSAKeySize: 2048,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		caopts.KeyCertBundle, err = util.NewVerifiedKeyCertBundleFromPem(certPem, keyPem, certPem, rootPem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		caopts.CAType = pluggedCertCA
+	}
+	parent, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	return parent
+}
+
+func newTestNamespaceCA(t *testing.T, parent *IstioCA, store NamespaceCAStore) *NamespaceCA {
+	t.Helper()
+	nsCA, err := NewNamespaceCA(parent, 12*time.Hour, 0, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nsCA
+}
+
+func signNamespaceCSR(t *testing.T, nsCA *NamespaceCA, id string) ([]byte, []byte) {
+	t.Helper()
+	csrPEM, keyPEM, err := util.GenCSR(util.CertOptions{Host: id, RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := nsCA.Sign(csrPEM, CertOpts{SubjectIDs: []string{id}, TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("failed to sign the CSR of %s: %v", id, err)
+	}
+	return certPEM, keyPEM
+}
+
+func TestNamespaceCASign(t *testing.T) {
+	for _, plugged := range []bool{false, true} {
+		nsCA := newTestNamespaceCA(t, newTestParentCA(t, plugged), newMemoryNamespaceCAStore())
+		_, _, parentChain, root := nsCA.GetCAKeyCertBundle().GetAllPem()
+
+		fooCert, fooKey := signNamespaceCSR(t, nsCA, "spiffe://cluster.local/ns/foo/sa/a")
+		// The server appends the chain of the parent CA to the signed certificate and its intermediate CA.
+		chain := append(append([]byte{}, fooCert...), parentChain...)
+		if err := util.VerifyCertificate(fooKey, chain, root, nil); err != nil {
+			t.Errorf("plugged=%v: failed to verify the certificate: %v", plugged, err)
+		}
+		leaf, err := util.ParsePemEncodedCertificate(fooCert)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(leaf.Issuer.Organization, []string{"foo"}) {
+			t.Errorf("plugged=%v: expected the certificate to be issued by the CA of namespace foo, got %v", plugged, leaf.Issuer)
+		}
+
+		// The workloads of a namespace share its CA, and each namespace has its own.
+		fooCert2, _ := signNamespaceCSR(t, nsCA, "spiffe://cluster.local/ns/foo/sa/b")
+		barCert, _ := signNamespaceCSR(t, nsCA, "spiffe://cluster.local/ns/bar/sa/a")
+		if intermediate(fooCert) != intermediate(fooCert2) {
+			t.Errorf("plugged=%v: expected the workloads of namespace foo to share their CA", plugged)
+		}
+		if intermediate(fooCert) == intermediate(barCert) {
+			t.Errorf("plugged=%v: expected namespaces foo and bar to have different CAs", plugged)
+		}
+
+		certChain, err := nsCA.SignWithCertChain(mustGenCSR(t), CertOpts{SubjectIDs: []string{"spiffe://cluster.local/ns/bar/sa/a"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(certChain[0], intermediate(barCert)) || !strings.HasSuffix(certChain[0], string(parentChain)) {
+			t.Errorf("plugged=%v: expected the chain to hold the CA of namespace bar and the chain of the parent CA", plugged)
+		}
+	}
+}
+
+func TestNamespaceCARequiresCRLSign(t *testing.T) {
+	caopts, err := NewPluggedCertIstioCAOptions(SigningCAFileBundle{
+		RootCertFile:    "../testdata/multilevelpki/root-cert.pem",
+		CertChainFiles:  []string{"../testdata/multilevelpki/int-cert-chain.pem"},
+		SigningCertFile: "../testdata/multilevelpki/int-cert.pem",
+		SigningKeyFile:  "../testdata/multilevelpki/int-key.pem",
+	}, 30*time.Minute, time.Hour, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewNamespaceCA(parent, 12*time.Hour, 0, newMemoryNamespaceCAStore()); err == nil {
+		t.Errorf("expected an error for a CA certificate which cannot sign CRLs")
+	}
+}
+
+func TestNamespaceCARevoke(t *testing.T) {
+	// The replicas share the CAs of the namespaces through the store.
+	parent := newTestParentCA(t, false)
+	store := newMemoryNamespaceCAStore()
+	replica1 := newTestNamespaceCA(t, parent, store)
+	replica2 := newTestNamespaceCA(t, parent, store)
+
+	before, _ := signNamespaceCSR(t, replica1, "spiffe://cluster.local/ns/foo/sa/a")
+	if other, _ := signNamespaceCSR(t, replica2, "spiffe://cluster.local/ns/foo/sa/b"); intermediate(before) != intermediate(other) {
+		t.Errorf("expected the replicas to sign with the same CA")
+	}
+	signNamespaceCSR(t, replica1, "spiffe://cluster.local/ns/bar/sa/a")
+
+	revoked, err := replica1.Revoke("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revoked) != 1 || revoked[0].RevokedAt == nil {
+		t.Errorf("expected the CA of foo to be revoked, got %v", revoked)
+	}
+	if _, err := replica1.Revoke("baz"); err == nil {
+		t.Errorf("expected an error revoking the CA of a namespace without one")
+	}
+	after, _ := signNamespaceCSR(t, replica2, "spiffe://cluster.local/ns/foo/sa/a")
+	if intermediate(before) == intermediate(after) {
+		t.Errorf("expected a new CA to be issued to namespace foo after the revocation by the other replica")
+	}
+
+	got, err := replica2.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected the CAs of bar and foo and the revoked CA of foo, got %v", got)
+	}
+	if got[0].Namespace != "bar" || got[1].Serial != revoked[0].Serial || got[2].RevokedAt != nil {
+		t.Errorf("unexpected CAs %v", got)
+	}
+
+	// The revoked CAs are listed until they expire.
+	replica2.now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+	if got, _ := replica2.List(); len(got) != 0 {
+		t.Errorf("expected the expired CAs not to be listed, got %v", got)
+	}
+}
+
+func TestNamespaceCACRL(t *testing.T) {
+	nsCA := newTestNamespaceCA(t, newTestParentCA(t, false), newMemoryNamespaceCAStore())
+	fooCert, _ := signNamespaceCSR(t, nsCA, "spiffe://cluster.local/ns/foo/sa/a")
+	barCert, _ := signNamespaceCSR(t, nsCA, "spiffe://cluster.local/ns/bar/sa/a")
+	revoked, err := nsCA.Revoke("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crlPem, err := nsCA.CRL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuers := map[string]*x509.RevocationList{}
+	for _, block := range splitPemBlocks(crlPem) {
+		b, _ := pem.Decode(block)
+		crl, err := x509.ParseRevocationList(b.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		issuers[crl.Issuer.String()] = crl
+	}
+	signingCert, _, _, _ := nsCA.GetCAKeyCertBundle().GetAll()
+	fooCA, _ := util.ParsePemEncodedCertificate([]byte(intermediate(fooCert)))
+	barCA, _ := util.ParsePemEncodedCertificate([]byte(intermediate(barCert)))
+
+	// The CRL of the parent CA lists the revoked CA of foo, which no longer has a CRL.
+	parentCRL := issuers[signingCert.Subject.String()]
+	if parentCRL == nil {
+		t.Fatalf("expected a CRL of the parent CA, got %v", issuers)
+	}
+	if err := parentCRL.CheckSignatureFrom(signingCert); err != nil {
+		t.Errorf("failed to verify the CRL of the parent CA: %v", err)
+	}
+	if len(parentCRL.RevokedCertificateEntries) != 1 || parentCRL.RevokedCertificateEntries[0].SerialNumber.String() != revoked[0].Serial {
+		t.Errorf("expected the CRL of the parent CA to list the CA of foo, got %v", parentCRL.RevokedCertificateEntries)
+	}
+	if _, f := issuers[fooCA.Subject.String()]; f {
+		t.Errorf("expected the revoked CA of foo not to have a CRL")
+	}
+	barCRL := issuers[barCA.Subject.String()]
+	if barCRL == nil {
+		t.Fatalf("expected a CRL of the CA of bar, got %v", issuers)
+	}
+	if err := barCRL.CheckSignatureFrom(barCA); err != nil || len(barCRL.RevokedCertificateEntries) != 0 {
+		t.Errorf("expected an empty CRL signed by the CA of bar, got %v, %v", barCRL.RevokedCertificateEntries, err)
+	}
+}
+
+func TestNamespaceCAActivationDelay(t *testing.T) {
+	nsCA, err := NewNamespaceCA(newTestParentCA(t, false), 12*time.Hour, time.Hour, newMemoryNamespaceCAStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Until the CRL of the CA of the namespace reached the proxies, the parent CA signs short lived certificates.
+	certPEM, _ := signNamespaceCSR(t, nsCA, "spiffe://cluster.local/ns/foo/sa/a")
+	leaf, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intermediate(certPEM) != "" || len(leaf.Issuer.Organization) != 1 || leaf.Issuer.Organization[0] != "cluster.local" {
+		t.Errorf("expected the certificate to be issued by the parent CA, got %v", leaf.Issuer)
+	}
+	if ttl := time.Until(leaf.NotAfter); ttl > 2*time.Hour {
+		t.Errorf("expected a certificate of the parent CA to be rotated once the CA of the namespace is used, got TTL %v", ttl)
+	}
+
+	// The delay only starts once the CRL of the CA of the namespace is observed in its ConfigMap.
+	nsCA.now = func() time.Time { return time.Now().Add(time.Hour) }
+	certPEM, _ = signNamespaceCSR(t, nsCA, "spiffe://cluster.local/ns/foo/sa/a")
+	if intermediate(certPEM) != "" {
+		t.Errorf("expected the certificate to be issued by the parent CA until the CRL is observed")
+	}
+	crl, err := nsCA.CRL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := nsCA.ObserveCRL("foo", crl); err != nil {
+		t.Fatal(err)
+	}
+	if certPEM, _ = signNamespaceCSR(t, nsCA, "spiffe://cluster.local/ns/foo/sa/a"); intermediate(certPEM) != "" {
+		t.Errorf("expected the certificate to be issued by the parent CA until the delay elapsed")
+	}
+
+	nsCA.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	certPEM, _ = signNamespaceCSR(t, nsCA, "spiffe://cluster.local/ns/foo/sa/a")
+	if leaf, _ = util.ParsePemEncodedCertificate(certPEM); leaf.Issuer.Organization[0] != "foo" {
+		t.Errorf("expected the certificate to be issued by the CA of namespace foo, got %v", leaf.Issuer)
+	}
+
+	// The CRLs of other namespaces or of the parent CA do not count.
+	signNamespaceCSR(t, nsCA, "spiffe://cluster.local/ns/bar/sa/a")
+	if err := nsCA.ObserveCRL("bar", crl); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := nsCA.store.Get("bar"); len(state.Published) != 0 {
+		t.Errorf("expected the CRL of the CA of bar not to be observed, got %v", state.Published)
+	}
+}
+
+func TestNamespaceCAInvalidIdentity(t *testing.T) {
+	nsCA := newTestNamespaceCA(t, newTestParentCA(t, false), newMemoryNamespaceCAStore())
+	if _, err := nsCA.Sign(mustGenCSR(t), CertOpts{SubjectIDs: []string{"localhost"}}); err == nil {
+		t.Errorf("expected an error signing a CSR without a SPIFFE identity")
+	}
+}
+
+func TestNamespaceCACapTTL(t *testing.T) {
+	nsCA := newTestNamespaceCA(t, newTestParentCA(t, false), newMemoryNamespaceCAStore())
+	nsCA.caCertTTL = 10 * time.Minute
+	certPEM, _ := signNamespaceCSR(t, nsCA, "spiffe://cluster.local/ns/foo/sa/a")
+	leaf, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl := time.Until(leaf.NotAfter); ttl > 10*time.Minute+util.ClockSkewGracePeriod {
+		t.Errorf("expected the certificate not to outlive the CA of its namespace, got TTL %v", ttl)
+	}
+}
+
+func TestNamespaceCASecretStore(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	// The fake clientset does not check the resource versions: emulate the optimistic concurrency of the API server.
+	version := 0
+	client.PrependReactor("*", "secrets", func(action ktesting.Action) (bool, runtime.Object, error) {
+		if action.GetVerb() != "create" && action.GetVerb() != "update" {
+			return false, nil, nil
+		}
+		secret := action.(ktesting.CreateAction).GetObject().(*v1.Secret)
+		if action.GetVerb() == "update" {
+			current, err := client.Tracker().Get(action.GetResource(), action.GetNamespace(), secret.Name)
+			if err != nil {
+				return true, nil, err
+			}
+			if current.(*v1.Secret).ResourceVersion != secret.ResourceVersion {
+				return true, nil, kerrors.NewConflict(action.GetResource().GroupResource(), secret.Name, errors.New("stale"))
+			}
+		}
+		version++
+		secret.ResourceVersion = strconv.Itoa(version)
+		return false, nil, nil
+	})
+	store := NewNamespaceCASecretStore(client, "istio-system")
+	stop := make(chan struct{})
+	defer close(stop)
+	store.Run(stop)
+
+	nsCA := newTestNamespaceCA(t, newTestParentCA(t, false), store)
+	signNamespaceCSR(t, nsCA, "spiffe://cluster.local/ns/foo/sa/a")
+	if _, err := nsCA.Revoke("foo"); err != nil {
+		t.Fatal(err)
+	}
+	signNamespaceCSR(t, nsCA, "spiffe://cluster.local/ns/foo/sa/a")
+
+	state, err := store.Get("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.CAs) != 1 || len(state.Revoked) != 1 || state.Version == "" {
+		t.Errorf("expected a CA and a revoked CA to be stored, got %+v", state)
+	}
+	// The CRLs are listed from the informer cache.
+	retry.UntilSuccessOrFail(t, func() error {
+		crl, err := nsCA.CRL()
+		if err != nil {
+			return err
+		}
+		if err := nsCA.ObserveCRL("foo", crl); err != nil {
+			return err
+		}
+		state, err := store.Get("foo")
+		if err != nil {
+			return err
+		}
+		if len(state.Published) != 1 {
+			return fmt.Errorf("expected the time the CRL was observed to be stored, got %v", state.Published)
+		}
+		return nil
+	}, retry.Timeout(5*time.Second))
+	// A state read before the last update is rejected.
+	stale := *state
+	stale.Version = "0"
+	if err := store.Update(&stale); !errors.Is(err, ErrNamespaceCAConflict) {
+		t.Errorf("expected a conflict updating a stale state, got %v", err)
+	}
+	retry.UntilSuccessOrFail(t, func() error {
+		states, err := store.List()
+		if err != nil {
+			return err
+		}
+		if len(states) != 1 || states[0].Namespace != "foo" || len(states[0].CAs) != 1 {
+			return fmt.Errorf("unexpected states %v", states)
+		}
+		return nil
+	}, retry.Timeout(5*time.Second))
+}
+
+func mustGenCSR(t *testing.T) []byte {
+	t.Helper()
+	csrPEM, _, err := util.GenCSR(util.CertOptions{RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return csrPEM
+}
+
+// intermediate returns the PEM encoded certificates following the leaf certificate.
+func intermediate(certPEM []byte) string {
+	s := string(certPEM)
+	const end = "-----END CERTIFICATE-----\n"
+	return s[strings.Index(s, end)+len(end):]
+}
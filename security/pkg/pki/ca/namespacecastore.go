@@ -0,0 +1,207 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	informersv1 "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"istio.io/istio/pkg/kube"
+)
+
+const (
+	// namespaceCASecretPrefix is the prefix of the name of the Secrets storing the intermediate CAs of the namespaces.
+	namespaceCASecretPrefix = "istio-ca-namespace-"
+	// NamespaceCALabel is the label holding the namespace of the Secrets storing the intermediate CAs of a namespace.
+	NamespaceCALabel = "istio.io/namespace-ca"
+	// namespaceCARevokedFile is the key of the revoked intermediate CA certificates in the Secrets.
+	namespaceCARevokedFile = "revoked.json"
+	// namespaceCAPublishedFile is the key of the times the CRLs of the intermediate CAs were observed in the Secrets.
+	namespaceCAPublishedFile = "published.json"
+)
+
+// NamespaceCASecretStore is a NamespaceCAStore keeping the intermediate CAs of each namespace in a Secret of the
+// istiod namespace, with their certificates under ca-cert.pem and their keys under ca-key.pem, in the same order.
+type NamespaceCASecretStore struct {
+	client    kubernetes.Interface
+	namespace string
+	informer  informersv1.SecretInformer
+}
+
+// NewNamespaceCASecretStore returns a NamespaceCASecretStore storing the Secrets in namespace.
+func NewNamespaceCASecretStore(client kubernetes.Interface, namespace string) *NamespaceCASecretStore {
+	return &NamespaceCASecretStore{
+		client:    client,
+		namespace: namespace,
+		// Although using a separate informer factory isn't ideal, this limits watching to the labeled Secrets.
+		informer: informers.NewSharedInformerFactoryWithOptions(client, 12*time.Hour,
+			informers.WithNamespace(namespace),
+			informers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+				listOptions.LabelSelector = NamespaceCALabel
+			})).
+			Core().V1().Secrets(),
+	}
+}
+
+// AddHandler calls f whenever the intermediate CAs of a namespace change.
+func (s *NamespaceCASecretStore) AddHandler(f func()) {
+	s.informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { f() },
+		UpdateFunc: func(interface{}, interface{}) { f() },
+		DeleteFunc: func(interface{}) { f() },
+	})
+}
+
+// Run starts watching the Secrets until stop is closed.
+func (s *NamespaceCASecretStore) Run(stop <-chan struct{}) {
+	go s.informer.Informer().Run(stop)
+	kube.WaitForCacheSync(stop, s.informer.Informer().HasSynced)
+}
+
+// Get reads the Secret of the namespace from the API server rather than the informer cache, so that a revocation
+// by another replica is seen at once.
+func (s *NamespaceCASecretStore) Get(namespace string) (*NamespaceCAState, error) {
+	secret, err := s.client.CoreV1().Secrets(s.namespace).Get(context.TODO(), namespaceCASecretPrefix+namespace, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return &NamespaceCAState{Namespace: namespace}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return secretToNamespaceCAState(secret)
+}
+
+func (s *NamespaceCASecretStore) Update(state *NamespaceCAState) error {
+	secret, err := namespaceCAStateToSecret(state)
+	if err != nil {
+		return err
+	}
+	secret.Namespace = s.namespace
+	if state.Version == "" {
+		_, err = s.client.CoreV1().Secrets(s.namespace).Create(context.TODO(), secret, metav1.CreateOptions{})
+	} else {
+		secret.ResourceVersion = state.Version
+		_, err = s.client.CoreV1().Secrets(s.namespace).Update(context.TODO(), secret, metav1.UpdateOptions{})
+	}
+	if errors.IsConflict(err) || errors.IsAlreadyExists(err) {
+		return fmt.Errorf("%w: %v", ErrNamespaceCAConflict, err)
+	}
+	return err
+}
+
+// List reads the Secrets from the informer cache, ordered by namespace.
+func (s *NamespaceCASecretStore) List() ([]*NamespaceCAState, error) {
+	secrets, err := s.informer.Lister().Secrets(s.namespace).List(klabels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*NamespaceCAState, 0, len(secrets))
+	for _, secret := range secrets {
+		state, err := secretToNamespaceCAState(secret)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, state)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Namespace < out[j].Namespace
+	})
+	return out, nil
+}
+
+func secretToNamespaceCAState(secret *v1.Secret) (*NamespaceCAState, error) {
+	state := &NamespaceCAState{
+		Namespace: secret.Labels[NamespaceCALabel],
+		Version:   secret.ResourceVersion,
+	}
+	certs := splitPemBlocks(secret.Data[CACertFile])
+	keys := splitPemBlocks(secret.Data[CAPrivateKeyFile])
+	if len(certs) != len(keys) {
+		return nil, fmt.Errorf("secret %s/%s holds %d CA certificates and %d keys", secret.Namespace, secret.Name, len(certs), len(keys))
+	}
+	for i := range certs {
+		state.CAs = append(state.CAs, NamespaceCAKeyCert{CertPem: certs[i], KeyPem: keys[i]})
+	}
+	if revoked := secret.Data[namespaceCARevokedFile]; len(revoked) > 0 {
+		if err := json.Unmarshal(revoked, &state.Revoked); err != nil {
+			return nil, fmt.Errorf("secret %s/%s holds invalid revoked CA certificates: %v", secret.Namespace, secret.Name, err)
+		}
+	}
+	if published := secret.Data[namespaceCAPublishedFile]; len(published) > 0 {
+		if err := json.Unmarshal(published, &state.Published); err != nil {
+			return nil, fmt.Errorf("secret %s/%s holds invalid CRL publication times: %v", secret.Namespace, secret.Name, err)
+		}
+	}
+	return state, nil
+}
+
+func namespaceCAStateToSecret(state *NamespaceCAState) (*v1.Secret, error) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   namespaceCASecretPrefix + state.Namespace,
+			Labels: map[string]string{NamespaceCALabel: state.Namespace},
+		},
+		Type: istioCASecretType,
+		Data: map[string][]byte{},
+	}
+	var certs, keys []byte
+	for _, keyCert := range state.CAs {
+		certs = append(certs, keyCert.CertPem...)
+		keys = append(keys, keyCert.KeyPem...)
+	}
+	secret.Data[CACertFile] = certs
+	secret.Data[CAPrivateKeyFile] = keys
+	if len(state.Revoked) > 0 {
+		revoked, err := json.Marshal(state.Revoked)
+		if err != nil {
+			return nil, err
+		}
+		secret.Data[namespaceCARevokedFile] = revoked
+	}
+	if len(state.Published) > 0 {
+		published, err := json.Marshal(state.Published)
+		if err != nil {
+			return nil, err
+		}
+		secret.Data[namespaceCAPublishedFile] = published
+	}
+	return secret, nil
+}
+
+// splitPemBlocks returns each PEM block of b, PEM encoded.
+func splitPemBlocks(b []byte) [][]byte {
+	var out [][]byte
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			return out
+		}
+		out = append(out, pem.EncodeToMemory(block))
+	}
+}
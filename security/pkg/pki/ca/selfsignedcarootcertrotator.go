@@ -16,6 +16,7 @@ package ca
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"math/rand"
 	"time"
@@ -24,6 +25,7 @@ import (
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	"istio.io/istio/security/pkg/k8s/controller"
+	"istio.io/istio/security/pkg/pki/kms"
 	"istio.io/istio/security/pkg/pki/util"
 	certutil "istio.io/istio/security/pkg/util"
 	"istio.io/pkg/log"
@@ -37,6 +39,7 @@ type SelfSignedCARootCertRotatorConfig struct {
 	org                string
 	rootCertFile       string
 	client             corev1.CoreV1Interface
+	keyWrapper         kms.KeyWrapper
 	CheckInterval      time.Duration
 	caCertTTL          time.Duration
 	retryInterval      time.Duration
@@ -52,6 +55,10 @@ type SelfSignedCARootCertRotator struct {
 	config             *SelfSignedCARootCertRotatorConfig
 	backOffTime        time.Duration
 	ca                 *IstioCA
+	// storedCAKey is the CA private key last read from istio-ca-secret, and caKey the key it decrypts to, kept so the
+	// KMS is only called when the stored key changes.
+	storedCAKey []byte
+	caKey       []byte
 }
 
 // NewSelfSignedCARootCertRotator returns a new root cert rotator instance that
@@ -133,6 +140,19 @@ func (rotator *SelfSignedCARootCertRotator) checkAndRotateRootCertForSigningCert
 			CASecret)
 		return
 	}
+	caKey, err := rotator.decryptCAKey(caSecret.Data[CAPrivateKeyFile])
+	if err != nil {
+		rootCertRotatorLog.Errorf("failed to decrypt CA private key (%v), skip cert rotation job", err)
+		return
+	}
+	if rotator.config.keyWrapper != nil {
+		// The KMS key may have been rotated since the CA private key was encrypted.
+		if err := reencryptCAKey(context.TODO(), rotator.config.client, rotator.config.keyWrapper, caSecret, caKey); err != nil {
+			rootCertRotatorLog.Errorf("failed to re-encrypt CA private key: %v", err)
+		} else {
+			rotator.storedCAKey = caSecret.Data[CAPrivateKeyFile]
+		}
+	}
 	// Check root certificate expiration time in CA secret
 	waitTime, err := rotator.config.certInspector.GetWaitTime(caSecret.Data[CACertFile], time.Now(), time.Duration(0))
 	if err == nil && waitTime > 0 {
@@ -150,7 +170,7 @@ func (rotator *SelfSignedCARootCertRotator) checkAndRotateRootCertForSigningCert
 				return
 			}
 			if err := rotator.ca.GetCAKeyCertBundle().VerifyAndSetAll(caSecret.Data[CACertFile],
-				caSecret.Data[CAPrivateKeyFile], nil, rootCerts); err != nil {
+				caKey, nil, rootCerts); err != nil {
 				rootCertRotatorLog.Errorf("failed to reload root cert into KeyCertBundle (%v)", err)
 			} else {
 				rootCertRotatorLog.Info("Successfully reloaded root cert into KeyCertBundle.")
@@ -168,7 +188,7 @@ func (rotator *SelfSignedCARootCertRotator) checkAndRotateRootCertForSigningCert
 	}
 	options := util.CertOptions{
 		TTL:           rotator.config.caCertTTL,
-		SignerPrivPem: caSecret.Data[CAPrivateKeyFile],
+		SignerPrivPem: caKey,
 		Org:           rotator.config.org,
 		IsCA:          true,
 		IsSelfSigned:  true,
@@ -192,7 +212,7 @@ func (rotator *SelfSignedCARootCertRotator) checkAndRotateRootCertForSigningCert
 	}
 
 	oldCaCert := caSecret.Data[CACertFile]
-	oldCaPrivateKey := caSecret.Data[CAPrivateKeyFile]
+	oldCaPrivateKey := caKey
 	oldRootCerts := rotator.ca.GetCAKeyCertBundle().GetRootCertPem()
 	if rollback, err := rotator.updateRootCertificate(caSecret, true, pemCert, pemKey, pemRootCerts); err != nil {
 		if !rollback {
@@ -210,6 +230,19 @@ func (rotator *SelfSignedCARootCertRotator) checkAndRotateRootCertForSigningCert
 	rootCertRotatorLog.Info("Root certificate rotation is completed successfully.")
 }
 
+// decryptCAKey returns the CA private key stored in istio-ca-secret, decrypted with the KMS key if it is encrypted.
+func (rotator *SelfSignedCARootCertRotator) decryptCAKey(storedKey []byte) ([]byte, error) {
+	if rotator.caKey != nil && bytes.Equal(storedKey, rotator.storedCAKey) {
+		return rotator.caKey, nil
+	}
+	caKey, err := kms.Decrypt(context.TODO(), rotator.config.keyWrapper, storedKey)
+	if err != nil {
+		return nil, err
+	}
+	rotator.storedCAKey, rotator.caKey = storedKey, caKey
+	return caKey, nil
+}
+
 // updateRootCertificate updates root certificate in istio-ca-secret, keycertbundle and configmap. It takes a scrt
 // object, cert, and key, and a flag rollForward indicating whether this update is to roll forward root certificate or
 // to roll backward.
@@ -225,11 +258,16 @@ func (rotator *SelfSignedCARootCertRotator) updateRootCertificate(caSecret *v1.S
 				err.Error())
 		}
 	}
+	storedKey, err := encryptCAKey(context.TODO(), rotator.config.keyWrapper, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to encrypt CA private key (error: %s)", err.Error())
+	}
 	caSecret.Data[CACertFile] = cert
-	caSecret.Data[CAPrivateKeyFile] = key
+	caSecret.Data[CAPrivateKeyFile] = storedKey
 	if err = rotator.caSecretController.UpdateCASecretWithRetry(caSecret, rotator.config.retryInterval, rotator.config.retryMax); err != nil {
 		return false, fmt.Errorf("failed to update CA secret (error: %s)", err.Error())
 	}
+	rotator.storedCAKey, rotator.caKey = storedKey, key
 	rootCertRotatorLog.Infof("Root certificate is written into CA secret: %v", string(cert))
 	if err := rotator.ca.GetCAKeyCertBundle().VerifyAndSetAll(cert, key, nil, rootCert); err != nil {
 		if rollForward {
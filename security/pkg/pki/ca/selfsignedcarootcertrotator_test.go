@@ -29,6 +29,7 @@ import (
 	ktesting "k8s.io/client-go/testing"
 
 	"istio.io/istio/security/pkg/cmd"
+	"istio.io/istio/security/pkg/pki/kms"
 	"istio.io/istio/security/pkg/pki/util"
 	certutil "istio.io/istio/security/pkg/util"
 )
@@ -125,6 +126,38 @@ func TestRootCertRotatorForSigningCitadel(t *testing.T) {
 	verifyRootCertAndPrivateKey(t, false, certItem1, certItem2)
 }
 
+// TestRootCertRotatorDecryptsCAKeyOnce verifies that rotator only decrypts the CA private key with the KMS key when
+// it changes in istio-ca-secret.
+func TestRootCertRotatorDecryptsCAKeyOnce(t *testing.T) {
+	w := &fakeKeyWrapper{primary: "v1"}
+	opts, err := NewSelfSignedIstioCAOptions(context.Background(),
+		cmd.DefaultRootCertGracePeriodPercentile, time.Hour, time.Hour, 30*time.Minute, time.Hour, "test.ca.Org", false,
+		caNamespace, -1, fake.NewSimpleClientset().CoreV1(), "", false, 2048, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotator := getRootCertRotator(opts)
+
+	rotator.config.certInspector = certutil.NewCertUtil(0)
+	rotator.checkAndRotateRootCert()
+	rotator.checkAndRotateRootCert()
+	if w.unwraps != 1 {
+		t.Errorf("CA private key was decrypted %d times, want 1", w.unwraps)
+	}
+
+	// The rotated key is kept, so it is not decrypted again either.
+	rotator.config.certInspector = certutil.NewCertUtil(100)
+	rotator.checkAndRotateRootCert()
+	rotator.config.certInspector = certutil.NewCertUtil(0)
+	rotator.checkAndRotateRootCert()
+	if w.unwraps != 1 {
+		t.Errorf("CA private key was decrypted %d times, want 1", w.unwraps)
+	}
+	if !kms.IsEncrypted(loadCert(rotator).caSecret.Data[CAPrivateKeyFile]) {
+		t.Error("rotated CA private key is not encrypted in istio-ca-secret")
+	}
+}
+
 // TestRootCertRotatorKeepCertFieldsUnchanged verifies that rotator
 // extracts information from existing certificate and passes then into new root
 // certificate.
@@ -335,7 +368,7 @@ func getDefaultSelfSignedIstioCAOptions(fclient *fake.Clientset) *IstioCAOptions
 	caopts, _ := NewSelfSignedIstioCAOptions(context.Background(),
 		cmd.DefaultRootCertGracePeriodPercentile, caCertTTL,
 		rootCertCheckInverval, defaultCertTTL, maxCertTTL, org, false,
-		caNamespace, -1, client, rootCertFile, false, rsaKeySize)
+		caNamespace, -1, client, rootCertFile, false, rsaKeySize, nil)
 	return caopts
 }
 
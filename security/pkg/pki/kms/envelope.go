@@ -0,0 +1,126 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+)
+
+const (
+	// pemType is the type of the PEM blocks holding encrypted data.
+	pemType = "ISTIO ENCRYPTED DATA"
+
+	keyHeader        = "Kms-Key"
+	keyVersionHeader = "Kms-Key-Version"
+	dataKeyHeader    = "Wrapped-Data-Key"
+
+	dataKeySize = 32
+)
+
+// Encrypt encrypts data with a new AES-256-GCM data key, and returns a PEM block holding the encrypted data and the
+// data key wrapped with the KMS key.
+func Encrypt(ctx context.Context, w KeyWrapper, data []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	wrapped, version, err := w.Wrap(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key with KMS key %s: %v", w.Name(), err)
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type: pemType,
+		Headers: map[string]string{
+			keyHeader:        w.Name(),
+			keyVersionHeader: version,
+			dataKeyHeader:    base64.StdEncoding.EncodeToString(wrapped),
+		},
+		Bytes: aead.Seal(nonce, nonce, data, nil),
+	}), nil
+}
+
+// IsEncrypted returns true if data was encrypted by Encrypt.
+func IsEncrypted(data []byte) bool {
+	block, _ := pem.Decode(data)
+	return block != nil && block.Type == pemType
+}
+
+// Decrypt returns the data encrypted by Encrypt. Data which is not encrypted is returned as is, so data written before
+// encryption was enabled can still be read. w may be nil if encryption is disabled.
+func Decrypt(ctx context.Context, w KeyWrapper, data []byte) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != pemType {
+		return data, nil
+	}
+	if w == nil {
+		return nil, fmt.Errorf("data is encrypted with KMS key %s, but no KMS key is configured", block.Headers[keyHeader])
+	}
+	if block.Headers[keyHeader] != w.Name() {
+		return nil, fmt.Errorf("data is encrypted with KMS key %s, not %s", block.Headers[keyHeader], w.Name())
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(block.Headers[dataKeyHeader])
+	if err != nil {
+		return nil, fmt.Errorf("invalid wrapped data key: %v", err)
+	}
+	dataKey, err := w.Unwrap(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key with KMS key %s: %v", w.Name(), err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(block.Bytes) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is truncated")
+	}
+	nonce, sealed := block.Bytes[:aead.NonceSize()], block.Bytes[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, nil)
+}
+
+// NeedsReencryption returns true if data is not encrypted with the primary version of the KMS key, so it should be
+// encrypted again, for example after the KMS key was rotated.
+func NeedsReencryption(ctx context.Context, w KeyWrapper, data []byte) (bool, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != pemType || block.Headers[keyHeader] != w.Name() {
+		return true, nil
+	}
+	primary, err := w.PrimaryVersion(ctx)
+	if err != nil {
+		return false, err
+	}
+	return block.Headers[keyVersionHeader] != primary, nil
+}
+
+func newAEAD(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
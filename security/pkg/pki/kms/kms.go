@@ -0,0 +1,59 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kms envelope-encrypts data, such as the private key of the CA, with a key held by a key management service.
+package kms
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	// GCPScheme prefixes the names of Google Cloud KMS keys, such as
+	// gcpkms://projects/p/locations/l/keyRings/r/cryptoKeys/k.
+	GCPScheme = "gcpkms://"
+	// AWSScheme prefixes the ARNs of AWS KMS keys.
+	AWSScheme = "awskms://"
+	// AzureScheme prefixes the URLs of Azure Key Vault keys.
+	AzureScheme = "azurekeyvault://"
+)
+
+// KeyWrapper encrypts and decrypts data keys with a key held by a key management service. KMS keys can have several
+// versions: new data keys are wrapped with the primary version, and wrapped data keys can be unwrapped with any
+// enabled version.
+type KeyWrapper interface {
+	// Name is the URI of the KMS key.
+	Name() string
+	// Wrap encrypts a data key with the primary version of the KMS key, and returns the version used.
+	Wrap(ctx context.Context, dataKey []byte) (wrapped []byte, version string, err error)
+	// Unwrap decrypts a data key wrapped by any version of the KMS key.
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+	// PrimaryVersion returns the version of the KMS key new data keys are wrapped with.
+	PrimaryVersion(ctx context.Context) (string, error)
+}
+
+// NewKeyWrapper returns the wrapper of the KMS key identified by uri. Only Google Cloud KMS keys are supported:
+// AWS KMS and Azure Key Vault keys are recognized, so that they fail with a clear error, but have no wrapper yet.
+func NewKeyWrapper(ctx context.Context, uri string) (KeyWrapper, error) {
+	switch {
+	case strings.HasPrefix(uri, GCPScheme):
+		return NewGCPKeyWrapper(ctx, strings.TrimPrefix(uri, GCPScheme))
+	case strings.HasPrefix(uri, AWSScheme), strings.HasPrefix(uri, AzureScheme):
+		return nil, fmt.Errorf("KMS key %s is not supported, only Google Cloud KMS keys (%s) are", uri, GCPScheme)
+	default:
+		return nil, fmt.Errorf("invalid KMS key %q, expected %s<key name>", uri, GCPScheme)
+	}
+}
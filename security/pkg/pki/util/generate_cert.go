@@ -324,8 +324,8 @@ func genCertTemplateFromCSR(csr *x509.CertificateRequest, subjectIDs []string, t
 func genCertTemplateFromOptions(options CertOptions) (*x509.Certificate, error) {
 	var keyUsage x509.KeyUsage
 	if options.IsCA {
-		// If the cert is a CA cert, the private key is allowed to sign other certificates.
-		keyUsage = x509.KeyUsageCertSign
+		// If the cert is a CA cert, the private key is allowed to sign other certificates, and the CRLs revoking them.
+		keyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
 	} else {
 		// Otherwise the private key is allowed for digital signature and key encipherment.
 		keyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
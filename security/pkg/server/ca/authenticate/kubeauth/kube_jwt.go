@@ -21,6 +21,7 @@ import (
 
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"k8s.io/client-go/kubernetes"
 
 	"istio.io/istio/pkg/cluster"
@@ -55,6 +56,9 @@ type KubeJWTAuthenticator struct {
 
 	// remote cluster kubeClient getter
 	remoteKubeClientGetter RemoteKubeClientGetter
+
+	// nodeAttestation requires the tokens to be bound to a pod, and used from the node of the pod.
+	nodeAttestation bool
 }
 
 var _ security.Authenticator = &KubeJWTAuthenticator{}
@@ -72,6 +76,14 @@ func NewKubeJWTAuthenticator(meshHolder mesh.Holder, client kubernetes.Interface
 	}
 }
 
+// WithNodeAttestation returns a copy of the authenticator which also requires the tokens to be bound to a pod, and
+// used from that pod on the node reported by its kubelet.
+func (a *KubeJWTAuthenticator) WithNodeAttestation() *KubeJWTAuthenticator {
+	out := *a
+	out.nodeAttestation = true
+	return &out
+}
+
 func (a *KubeJWTAuthenticator) AuthenticatorType() string {
 	return KubeJWTAuthenticatorType
 }
@@ -101,7 +113,7 @@ func (a *KubeJWTAuthenticator) authenticateHTTP(req *http.Request) (*security.Ca
 		return nil, fmt.Errorf("target JWT extraction error: %v", err)
 	}
 	clusterID := cluster.ID(req.Header.Get(clusterIDMeta))
-	return a.authenticate(targetJWT, clusterID)
+	return a.authenticate(targetJWT, clusterID, req.RemoteAddr)
 }
 
 func (a *KubeJWTAuthenticator) authenticateGrpc(ctx context.Context) (*security.Caller, error) {
@@ -110,11 +122,15 @@ func (a *KubeJWTAuthenticator) authenticateGrpc(ctx context.Context) (*security.
 		return nil, fmt.Errorf("target JWT extraction error: %v", err)
 	}
 	clusterID := extractClusterID(ctx)
+	peerAddr := ""
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		peerAddr = p.Addr.String()
+	}
 
-	return a.authenticate(targetJWT, clusterID)
+	return a.authenticate(targetJWT, clusterID, peerAddr)
 }
 
-func (a *KubeJWTAuthenticator) authenticate(targetJWT string, clusterID cluster.ID) (*security.Caller, error) {
+func (a *KubeJWTAuthenticator) authenticate(targetJWT string, clusterID cluster.ID, peerAddr string) (*security.Caller, error) {
 	kubeClient := a.getKubeClient(clusterID)
 	if kubeClient == nil {
 		return nil, fmt.Errorf("could not get cluster %s's kube client", clusterID)
@@ -150,7 +166,7 @@ func (a *KubeJWTAuthenticator) authenticate(targetJWT string, clusterID cluster.
 		// is unbound and the setting to require bound tokens is off
 		aud = nil
 	}
-	id, err := tokenreview.ValidateK8sJwt(kubeClient, targetJWT, aud)
+	id, binding, err := tokenreview.ValidateBoundK8sJwt(kubeClient, targetJWT, aud)
 	if err != nil {
 		return nil, fmt.Errorf("failed to validate the JWT from cluster %q: %v", clusterID, err)
 	}
@@ -159,6 +175,11 @@ func (a *KubeJWTAuthenticator) authenticate(targetJWT string, clusterID cluster.
 	}
 	callerNamespace := id[0]
 	callerServiceAccount := id[1]
+	if a.nodeAttestation {
+		if err := attestNode(kubeClient, callerNamespace, binding, peerAddr); err != nil {
+			return nil, fmt.Errorf("failed to attest the node of the JWT from cluster %q: %v", clusterID, err)
+		}
+	}
 	return &security.Caller{
 		AuthSource: security.AuthSourceIDToken,
 		Identities: []string{fmt.Sprintf(authenticate.IdentityTemplate, a.meshHolder.Mesh().GetTrustDomain(), callerNamespace, callerServiceAccount)},
@@ -0,0 +1,80 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeauth
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/security/pkg/k8s/tokenreview"
+)
+
+// attestNode verifies that a token bound to a pod is used from that pod: the pod still exists, runs on the node the
+// kubelet reported, and the request comes from the IP of the pod, or of its node for host network pods. This
+// protects against stolen service account tokens used off-node.
+func attestNode(kubeClient kubernetes.Interface, namespace string, binding *tokenreview.PodBinding, peerAddr string) error {
+	if binding == nil {
+		return fmt.Errorf("the token is not bound to a pod")
+	}
+	pod, err := kubeClient.CoreV1().Pods(namespace).Get(context.TODO(), binding.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get pod %s/%s: %v", namespace, binding.Name, err)
+	}
+	if string(pod.UID) != binding.UID {
+		return fmt.Errorf("pod %s/%s is not the pod the token is bound to", namespace, binding.Name)
+	}
+	if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		return fmt.Errorf("pod %s/%s is terminated", namespace, binding.Name)
+	}
+	if pod.Spec.NodeName == "" || pod.Status.HostIP == "" {
+		return fmt.Errorf("pod %s/%s is not running on a node", namespace, binding.Name)
+	}
+	node, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), pod.Spec.NodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get node %s of pod %s/%s: %v", pod.Spec.NodeName, namespace, binding.Name, err)
+	}
+	nodeIPs := sets.New()
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == v1.NodeInternalIP || addr.Type == v1.NodeExternalIP {
+			nodeIPs.Insert(addr.Address)
+		}
+	}
+	if !nodeIPs.Contains(pod.Status.HostIP) {
+		return fmt.Errorf("pod %s/%s runs on %s, which is not an address of its node %s",
+			namespace, binding.Name, pod.Status.HostIP, pod.Spec.NodeName)
+	}
+
+	podIPs := sets.New(pod.Status.PodIP)
+	for _, ip := range pod.Status.PodIPs {
+		podIPs.Insert(ip.IP)
+	}
+	if pod.Spec.HostNetwork {
+		podIPs = podIPs.Union(nodeIPs)
+	}
+	host, _, err := net.SplitHostPort(peerAddr)
+	if err != nil {
+		host = peerAddr
+	}
+	if !podIPs.Contains(host) {
+		return fmt.Errorf("the token of pod %s/%s is used from %q, which is not an address of the pod", namespace, binding.Name, host)
+	}
+	return nil
+}
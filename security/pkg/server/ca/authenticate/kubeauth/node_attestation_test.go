@@ -0,0 +1,129 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeauth
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/security/pkg/k8s/tokenreview"
+)
+
+func TestAttestNode(t *testing.T) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: v1.NodeStatus{Addresses: []v1.NodeAddress{
+			{Type: v1.NodeInternalIP, Address: "10.0.0.1"},
+			{Type: v1.NodeHostName, Address: "node-1"},
+		}},
+	}
+	newPod := func(name, uid, nodeName, hostIP, podIP string, hostNetwork bool) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(uid)},
+			Spec:       v1.PodSpec{NodeName: nodeName, HostNetwork: hostNetwork},
+			Status: v1.PodStatus{
+				Phase:  v1.PodRunning,
+				HostIP: hostIP,
+				PodIP:  podIP,
+				PodIPs: []v1.PodIP{{IP: podIP}},
+			},
+		}
+	}
+	client := fake.NewSimpleClientset(
+		node,
+		newPod("app", "uid-app", "node-1", "10.0.0.1", "172.16.0.5", false),
+		newPod("host", "uid-host", "node-1", "10.0.0.1", "10.0.0.1", true),
+		newPod("pending", "uid-pending", "", "", "", false),
+		newPod("spoofed", "uid-spoofed", "node-1", "10.9.9.9", "172.16.0.6", false),
+	)
+
+	cases := []struct {
+		name     string
+		binding  *tokenreview.PodBinding
+		peerAddr string
+		wantErr  string
+	}{
+		{
+			name:     "request from the pod",
+			binding:  &tokenreview.PodBinding{Name: "app", UID: "uid-app"},
+			peerAddr: "172.16.0.5:43210",
+		},
+		{
+			name:     "request from the node of a host network pod",
+			binding:  &tokenreview.PodBinding{Name: "host", UID: "uid-host"},
+			peerAddr: "10.0.0.1:43210",
+		},
+		{
+			name:     "request off the pod",
+			binding:  &tokenreview.PodBinding{Name: "app", UID: "uid-app"},
+			peerAddr: "192.168.1.1:43210",
+			wantErr:  `is used from "192.168.1.1"`,
+		},
+		{
+			name:     "request from the node of a pod network pod",
+			binding:  &tokenreview.PodBinding{Name: "app", UID: "uid-app"},
+			peerAddr: "10.0.0.1:43210",
+			wantErr:  `is used from "10.0.0.1"`,
+		},
+		{
+			name:     "unbound token",
+			peerAddr: "172.16.0.5:43210",
+			wantErr:  "not bound to a pod",
+		},
+		{
+			name:     "recreated pod",
+			binding:  &tokenreview.PodBinding{Name: "app", UID: "uid-old"},
+			peerAddr: "172.16.0.5:43210",
+			wantErr:  "not the pod the token is bound to",
+		},
+		{
+			name:     "deleted pod",
+			binding:  &tokenreview.PodBinding{Name: "deleted", UID: "uid-deleted"},
+			peerAddr: "172.16.0.5:43210",
+			wantErr:  "failed to get pod default/deleted",
+		},
+		{
+			name:     "unscheduled pod",
+			binding:  &tokenreview.PodBinding{Name: "pending", UID: "uid-pending"},
+			peerAddr: "172.16.0.5:43210",
+			wantErr:  "not running on a node",
+		},
+		{
+			name:     "host IP not of the node",
+			binding:  &tokenreview.PodBinding{Name: "spoofed", UID: "uid-spoofed"},
+			peerAddr: "172.16.0.6:43210",
+			wantErr:  "not an address of its node node-1",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := attestNode(client, "default", tt.binding, tt.peerAddr)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"istio.io/istio/pkg/spiffe"
+)
+
+// DNSSANPolicy authorizes the DNS names that workloads request to add, as SANs, to the certificates issued to them.
+// It is an allowlist of DNS names, or of domains with a *. prefix, per namespace and service account.
+type DNSSANPolicy struct {
+	rules []dnsSANRule
+}
+
+type dnsSANRule struct {
+	namespace string
+	// serviceAccount is the service account allowed by the rule, or "*" for all the service accounts of the namespace.
+	serviceAccount string
+	// name is the DNS name allowed by the rule. A *.<domain> name allows any name under the domain.
+	name string
+}
+
+// ParseDNSSANPolicy parses a comma separated list of <namespace>/<service account>=<DNS name> rules, such as
+// istio-system/istio-ingressgateway-service-account=*.internal.example.com. The service account may be * to allow
+// the DNS name to all the service accounts of the namespace.
+func ParseDNSSANPolicy(s string) (*DNSSANPolicy, error) {
+	p := &DNSSANPolicy{}
+	for _, r := range strings.Split(s, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		account, name, f := strings.Cut(r, "=")
+		ns, sa, saFound := strings.Cut(account, "/")
+		if !f || !saFound || ns == "" || sa == "" {
+			return nil, fmt.Errorf("invalid DNS SAN rule %q, expected <namespace>/<service account>=<DNS name>", r)
+		}
+		if err := validateDNSName(strings.TrimPrefix(name, "*.")); err != nil {
+			return nil, fmt.Errorf("invalid DNS SAN rule %q: %v", r, err)
+		}
+		p.rules = append(p.rules, dnsSANRule{namespace: ns, serviceAccount: sa, name: name})
+	}
+	return p, nil
+}
+
+// Authorize returns an error unless all the DNS names are allowed to one of the identities.
+func (p *DNSSANPolicy) Authorize(identities []string, dnsNames []string) error {
+	for _, name := range dnsNames {
+		if err := validateDNSName(name); err != nil {
+			return err
+		}
+		if !p.allowed(identities, name) {
+			return fmt.Errorf("DNS name %q is not allowed to %v", name, identities)
+		}
+	}
+	return nil
+}
+
+func (p *DNSSANPolicy) allowed(identities []string, name string) bool {
+	if p == nil {
+		return false
+	}
+	for _, id := range identities {
+		identity, err := spiffe.ParseIdentity(id)
+		if err != nil {
+			continue
+		}
+		for _, r := range p.rules {
+			if r.namespace != identity.Namespace || (r.serviceAccount != "*" && r.serviceAccount != identity.ServiceAccount) {
+				continue
+			}
+			if r.name == name || (strings.HasPrefix(r.name, "*.") && strings.HasSuffix(name, r.name[1:])) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func validateDNSName(name string) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid DNS name %q: %s", name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// requestedDNSNames returns the DNS names of the comma separated list requested in the metadata of a CSR.
+func requestedDNSNames(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,71 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"testing"
+)
+
+func TestParseDNSSANPolicy(t *testing.T) {
+	for _, s := range []string{
+		"istio-system",
+		"istio-system=gw.example.com",
+		"istio-system/=gw.example.com",
+		"istio-system/gw=",
+		"istio-system/gw=Not_A_Name",
+		"istio-system/gw=*.",
+	} {
+		if _, err := ParseDNSSANPolicy(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestDNSSANPolicyAuthorize(t *testing.T) {
+	policy, err := ParseDNSSANPolicy("istio-system/ingress=*.internal.example.com, gateways/*=gw.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ingress := []string{"spiffe://cluster.local/ns/istio-system/sa/ingress"}
+	gateway := []string{"spiffe://cluster.local/ns/gateways/sa/any"}
+	cases := []struct {
+		name       string
+		policy     *DNSSANPolicy
+		identities []string
+		dnsNames   []string
+		allowed    bool
+	}{
+		{"domain", policy, ingress, []string{"api.internal.example.com", "a.b.internal.example.com"}, true},
+		{"domain itself", policy, ingress, []string{"internal.example.com"}, false},
+		{"other domain", policy, ingress, []string{"api.example.com"}, false},
+		{"name of other namespace", policy, ingress, []string{"gw.example.com"}, false},
+		{"any service account", policy, gateway, []string{"gw.example.com"}, true},
+		{"one name not allowed", policy, gateway, []string{"gw.example.com", "other.example.com"}, false},
+		{"requested wildcard", policy, ingress, []string{"*.internal.example.com"}, false},
+		{"no spiffe identity", policy, []string{"istio-system/ingress"}, []string{"api.internal.example.com"}, false},
+		{"no policy", nil, ingress, []string{"api.internal.example.com"}, false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Authorize(tt.identities, tt.dnsNames)
+			if tt.allowed && err != nil {
+				t.Fatalf("expected %v to be allowed: %v", tt.dnsNames, err)
+			}
+			if !tt.allowed && err == nil {
+				t.Fatalf("expected %v to be denied", tt.dnsNames)
+			}
+		})
+	}
+}
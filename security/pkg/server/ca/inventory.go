@@ -0,0 +1,132 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// IssuedCertificate is the metadata of a workload certificate issued by the CA.
+type IssuedCertificate struct {
+	// Requester is the address of the proxy which requested the certificate.
+	Requester string    `json:"requester"`
+	SANs      []string  `json:"sans"`
+	Issuer    string    `json:"issuer"`
+	Serial    string    `json:"serial"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+}
+
+// expiredRetention is how long the Inventory keeps the certificates which expired, for ListAll.
+const expiredRetention = time.Hour
+
+// Inventory keeps the latest certificate issued to each requester and identity, until an hour after it expires.
+type Inventory struct {
+	mu    sync.Mutex
+	certs map[string]IssuedCertificate
+	now   func() time.Time
+}
+
+// NewInventory creates an empty certificate inventory.
+func NewInventory() *Inventory {
+	return &Inventory{
+		certs: map[string]IssuedCertificate{},
+		now:   time.Now,
+	}
+}
+
+// Record adds the PEM encoded certificate issued to the requester, replacing the certificate previously
+// issued to it for the same identities.
+func (i *Inventory) Record(requester string, certPEM []byte) error {
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		return err
+	}
+	ic := IssuedCertificate{
+		Requester: requester,
+		Issuer:    cert.Issuer.String(),
+		Serial:    cert.SerialNumber.String(),
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+	}
+	for _, uri := range cert.URIs {
+		ic.SANs = append(ic.SANs, uri.String())
+	}
+	ic.SANs = append(ic.SANs, cert.DNSNames...)
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.certs[requester+"/"+strings.Join(ic.SANs, ",")] = ic
+	return nil
+}
+
+// List returns the certificates which have not expired, ordered by expiry.
+func (i *Inventory) List() []IssuedCertificate {
+	return i.list(i.now())
+}
+
+// ListAll returns the certificates, including the ones which expired in the last hour, ordered by expiry.
+func (i *Inventory) ListAll() []IssuedCertificate {
+	return i.list(i.now().Add(-expiredRetention))
+}
+
+// list returns the certificates expiring after since, dropping the ones which expired before the retention.
+func (i *Inventory) list(since time.Time) []IssuedCertificate {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	retained := i.now().Add(-expiredRetention)
+	out := make([]IssuedCertificate, 0, len(i.certs))
+	for k, c := range i.certs {
+		if c.NotAfter.Before(retained) {
+			delete(i.certs, k)
+			continue
+		}
+		if c.NotAfter.Before(since) {
+			continue
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if !out[a].NotAfter.Equal(out[b].NotAfter) {
+			return out[a].NotAfter.Before(out[b].NotAfter)
+		}
+		return out[a].Requester < out[b].Requester
+	})
+	return out
+}
+
+// WriteCSV writes the certificates as CSV, with a header row.
+func WriteCSV(w io.Writer, certs []IssuedCertificate) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"requester", "sans", "issuer", "serial", "not_before", "not_after"}); err != nil {
+		return err
+	}
+	for _, c := range certs {
+		if err := cw.Write([]string{
+			c.Requester, strings.Join(c.SANs, " "), c.Issuer, c.Serial,
+			c.NotBefore.UTC().Format(time.RFC3339), c.NotAfter.UTC().Format(time.RFC3339),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
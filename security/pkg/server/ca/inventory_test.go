@@ -0,0 +1,113 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func genCert(t *testing.T, host string, notBefore time.Time, ttl time.Duration) []byte {
+	t.Helper()
+	cert, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:         host,
+		NotBefore:    notBefore,
+		TTL:          ttl,
+		Org:          "cluster.local",
+		IsSelfSigned: true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestInventory(t *testing.T) {
+	now := time.Now()
+	inv := NewInventory()
+	inv.now = func() time.Time { return now }
+
+	a := "spiffe://cluster.local/ns/default/sa/a"
+	b := "spiffe://cluster.local/ns/default/sa/b"
+	if err := inv.Record("10.0.0.1", genCert(t, a, now.Add(-time.Hour), 2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	// Renewal replaces the certificate previously issued to the same requester and identity.
+	renewed := genCert(t, a, now, 3*time.Hour)
+	if err := inv.Record("10.0.0.1", renewed); err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.Record("10.0.0.2", genCert(t, b, now, time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.Record("10.0.0.3", genCert(t, b, now.Add(-90*time.Minute), time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.Record("10.0.0.4", []byte("not a cert")); err == nil {
+		t.Fatal("expected error for invalid certificate")
+	}
+
+	got := inv.List()
+	if len(got) != 2 {
+		t.Fatalf("expected 2 certificates, got %+v", got)
+	}
+	if got[0].Requester != "10.0.0.2" || got[0].SANs[0] != b {
+		t.Errorf("unexpected first certificate %+v", got[0])
+	}
+	if got[1].Requester != "10.0.0.1" || !got[1].NotAfter.Equal(now.Add(3*time.Hour).Truncate(time.Second)) {
+		t.Errorf("expected renewed certificate, got %+v", got[1])
+	}
+	if got[1].Issuer != "O=cluster.local" || got[1].Serial == "" {
+		t.Errorf("unexpected issuer or serial %+v", got[1])
+	}
+
+	// The expired certificates are kept for an hour.
+	if all := inv.ListAll(); len(all) != 3 || all[0].Requester != "10.0.0.3" {
+		t.Errorf("expected the expired certificate to be listed, got %+v", all)
+	}
+	inv.now = func() time.Time { return now.Add(90 * time.Minute) }
+	if all := inv.ListAll(); len(all) != 2 || all[0].Requester != "10.0.0.2" {
+		t.Errorf("expected the certificate expired over an hour ago to be dropped, got %+v", all)
+	}
+	if got := inv.List(); len(got) != 1 || got[0].Requester != "10.0.0.1" {
+		t.Errorf("expected only the valid certificate, got %+v", got)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	notBefore := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	out := &bytes.Buffer{}
+	err := WriteCSV(out, []IssuedCertificate{{
+		Requester: "10.0.0.1",
+		SANs:      []string{"spiffe://cluster.local/ns/default/sa/a", "a.default.svc"},
+		Issuer:    "O=cluster.local",
+		Serial:    "1234",
+		NotBefore: notBefore,
+		NotAfter:  notBefore.Add(24 * time.Hour),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `requester,sans,issuer,serial,not_before,not_after
+10.0.0.1,spiffe://cluster.local/ns/default/sa/a a.default.svc,O=cluster.local,1234,2022-01-01T00:00:00Z,2022-01-02T00:00:00Z
+`
+	if got := out.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -15,11 +15,13 @@
 package ca
 
 import (
+	"net"
 	"time"
 
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
 	pb "istio.io/api/security/v1alpha1"
@@ -50,11 +52,17 @@ type Server struct {
 	Authenticators []security.Authenticator
 	ca             CertificateAuthority
 	serverCertTTL  time.Duration
+	// Inventory holds the metadata of the workload certificates issued by this server.
+	Inventory *Inventory
+	// DNSSANPolicy authorizes the DNS names that workloads request to add to their certificates. If nil, no DNS
+	// name is allowed.
+	DNSSANPolicy *DNSSANPolicy
 }
 
 // CreateCertificate handles an incoming certificate signing request (CSR). It does
 // authentication and authorization. Upon validated, signs a certificate that:
-// the SAN is the identity of the caller in authentication result.
+// the SAN is the identity of the caller in authentication result, and the DNS names requested in the metadata of
+// the request, if allowed by the DNSSANPolicy.
 // the subject public key is the public key in the CSR.
 // the validity duration is the ValidityDuration in request, or default value if the given duration is invalid.
 // it is signed by the CA signing key.
@@ -72,9 +80,17 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 	crMetadata := request.Metadata.GetFields()
 	certSigner := crMetadata[security.CertSigner].GetStringValue()
 	log.Debugf("cert signer from workload %s", certSigner)
+	subjectIDs := caller.Identities
+	if dnsNames := requestedDNSNames(crMetadata[security.DNSNames].GetStringValue()); len(dnsNames) > 0 {
+		if err := s.DNSSANPolicy.Authorize(caller.Identities, dnsNames); err != nil {
+			serverCaLog.Warnf("CSR with DNS names denied: %v", err)
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		subjectIDs = append(append([]string{}, caller.Identities...), dnsNames...)
+	}
 	_, _, certChainBytes, rootCertBytes := s.ca.GetCAKeyCertBundle().GetAll()
 	certOpts := ca.CertOpts{
-		SubjectIDs: caller.Identities,
+		SubjectIDs: subjectIDs,
 		TTL:        time.Duration(request.ValidityDuration) * time.Second,
 		ForCA:      false,
 		CertSigner: certSigner,
@@ -101,6 +117,7 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 	if len(rootCertBytes) != 0 {
 		respCertChain = append(respCertChain, string(rootCertBytes))
 	}
+	s.recordIssuedCertificate(ctx, respCertChain[0])
 	response := &pb.IstioCertificateResponse{
 		CertChain: respCertChain,
 	}
@@ -109,6 +126,23 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 	return response, nil
 }
 
+// recordIssuedCertificate adds the leaf certificate issued to the caller to the inventory.
+func (s *Server) recordIssuedCertificate(ctx context.Context, certPEM string) {
+	if s.Inventory == nil {
+		return
+	}
+	requester := "unknown"
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		requester = p.Addr.String()
+		if host, _, err := net.SplitHostPort(requester); err == nil {
+			requester = host
+		}
+	}
+	if err := s.Inventory.Record(requester, []byte(certPEM)); err != nil {
+		serverCaLog.Debugf("failed to record issued certificate for %s: %v", requester, err)
+	}
+}
+
 func recordCertsExpiry(keyCertBundle *util.KeyCertBundle) {
 	rootCertExpiry, err := keyCertBundle.ExtractRootCertExpiryTimestamp()
 	if err != nil {
@@ -145,6 +179,7 @@ func New(ca CertificateAuthority, ttl time.Duration,
 		serverCertTTL:  ttl,
 		ca:             ca,
 		monitoring:     newMonitoringMetrics(),
+		Inventory:      NewInventory(),
 	}
 	return server, nil
 }
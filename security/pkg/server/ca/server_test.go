@@ -20,6 +20,7 @@ import (
 	"crypto/x509/pkix"
 	"fmt"
 	"net"
+	"reflect"
 	"testing"
 
 	"golang.org/x/net/context"
@@ -27,6 +28,7 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	pb "istio.io/api/security/v1alpha1"
 	"istio.io/istio/pkg/security"
@@ -258,3 +260,59 @@ func TestCreateCertificate(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateCertificateDNSNames(t *testing.T) {
+	policy, err := ParseDNSSANPolicy("istio-system/ingress=*.internal.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := "spiffe://cluster.local/ns/istio-system/sa/ingress"
+	testCases := map[string]struct {
+		dnsNames string
+		code     codes.Code
+		ids      []string
+	}{
+		"No DNS names": {
+			code: codes.OK,
+			ids:  []string{identity},
+		},
+		"Allowed DNS names": {
+			dnsNames: "api.internal.example.com, admin.internal.example.com",
+			code:     codes.OK,
+			ids:      []string{identity, "api.internal.example.com", "admin.internal.example.com"},
+		},
+		"Denied DNS name": {
+			dnsNames: "api.internal.example.com,api.example.com",
+			code:     codes.PermissionDenied,
+		},
+	}
+
+	for id, c := range testCases {
+		fakeCA := &mockca.FakeCA{
+			SignedCert:    []byte("cert"),
+			KeyCertBundle: util.NewKeyCertBundleFromPem(nil, nil, []byte("cert_chain"), []byte("root_cert")),
+		}
+		server := &Server{
+			ca:             fakeCA,
+			Authenticators: []security.Authenticator{&mockAuthenticator{identities: []string{identity}}},
+			monitoring:     newMonitoringMetrics(),
+			DNSSANPolicy:   policy,
+		}
+		request := &pb.IstioCertificateRequest{
+			Csr: "dumb CSR",
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				security.DNSNames: structpb.NewStringValue(c.dnsNames),
+			}},
+		}
+
+		_, err := server.CreateCertificate(context.Background(), request)
+		s, _ := status.FromError(err)
+		if c.code != s.Code() {
+			t.Errorf("Case %s: expecting code to be (%d) but got (%d): %s", id, c.code, s.Code(), s.Message())
+			continue
+		}
+		if c.code == codes.OK && !reflect.DeepEqual(fakeCA.ReceivedIDs, c.ids) {
+			t.Errorf("Case %s: expecting subject IDs %v but got %v", id, c.ids, fakeCA.ReceivedIDs)
+		}
+	}
+}
@@ -42,6 +42,7 @@ import (
 	"istio.io/istio/tools/bug-report/pkg/config"
 	"istio.io/istio/tools/bug-report/pkg/content"
 	"istio.io/istio/tools/bug-report/pkg/filter"
+	"istio.io/istio/tools/bug-report/pkg/findings"
 	"istio.io/istio/tools/bug-report/pkg/kubeclient"
 	"istio.io/istio/tools/bug-report/pkg/kubectlcmd"
 	"istio.io/istio/tools/bug-report/pkg/processlog"
@@ -67,6 +68,8 @@ func Cmd(logOpts *log.Options) *cobra.Command {
 		Short:        "Cluster information and log capture support tool.",
 		SilenceUsage: true,
 		Long: `bug-report selectively captures cluster information and logs into an archive to help diagnose problems.
+The archive includes a findings.md summary of the likely causes found in the captured data, such as analyzer
+messages, crash looping or OOM killed containers, configurations rejected by proxies and expired certificates.
 Proxy logs can be filtered using:
   --include|--exclude ns1,ns2.../dep1,dep2.../pod1,pod2.../lbl1=val1,lbl2=val2.../ann1=val1,ann2=val2.../cntr1,cntr...
 where ns=namespace, dep=deployment, lbl=label, ann=annotation, cntr=container
@@ -172,6 +175,8 @@ func runBugReportCommand(_ *cobra.Command, logOpts *log.Options) error {
 		writeFile(filepath.Join(archive.ProxyOutputPath(tempDir, namespace, pod), common.ProxyContainerName+".log"), text)
 	}
 
+	writeFindings()
+
 	outDir, err := os.Getwd()
 	if err != nil {
 		log.Errorf("using ./ to write archive: %s", err.Error())
@@ -440,6 +445,15 @@ func runAnalyze(config *config.BugReportConfig, params *content.Params, analyzeT
 	writeFiles(archive.AnalyzePath(tempDir, common.StrNamespaceAll), out)
 }
 
+// writeFindings runs the heuristics over the collected artifacts and writes the likely causes they find to the
+// archive, so that they are the first thing read.
+func writeFindings() {
+	found := findings.Analyze(tempDir, time.Now())
+	common.LogAndPrintf("\nFound %d likely causes of problems in the collected artifacts, see %s in the archive.\n",
+		len(found), findings.FileName)
+	writeFile(filepath.Join(archive.OutputRootDir(tempDir), findings.FileName), findings.Markdown(found))
+}
+
 func writeFiles(dir string, files map[string]string) {
 	for fname, text := range files {
 		writeFile(filepath.Join(dir, fname), text)
@@ -0,0 +1,388 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package findings analyzes the artifacts collected by bug-report for the likely causes of a problem, such as
+// crash looping pods, rejected configurations or expired certificates.
+package findings
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pkg/util/protomarshal"
+	"istio.io/istio/tools/bug-report/pkg/archive"
+	"istio.io/istio/tools/bug-report/pkg/common"
+	"istio.io/pkg/log"
+)
+
+// Severity is the severity of a finding.
+type Severity string
+
+const (
+	Error   Severity = "Error"
+	Warning Severity = "Warning"
+)
+
+// Categories of the findings.
+const (
+	CategoryAnalyzer    = "Analyzer"
+	CategoryCrashLoop   = "CrashLoop"
+	CategoryOOMKilled   = "OOMKilled"
+	CategoryNACK        = "NACK"
+	CategoryCertificate = "Certificate"
+)
+
+const (
+	// FileName is the name of the findings summary in the archive.
+	FileName = "findings.md"
+
+	// caCertExpiryWarning is how long before their expiration the CA certificates of the proxies are reported.
+	// Workload certificates are rotated well before they expire, so they are only reported once expired.
+	caCertExpiryWarning = 30 * 24 * time.Hour
+	// crashLoopRestarts is the number of restarts from which a container is reported as crash looping, even if it
+	// is not waiting in CrashLoopBackOff when the resources are collected.
+	crashLoopRestarts = 5
+)
+
+// Finding is a likely cause of a problem, found in the collected artifacts.
+type Finding struct {
+	Severity Severity
+	Category string
+	// Resource is the resource the finding is about, such as namespace/pod.
+	Resource string
+	Message  string
+	// Source is the path of the artifact the finding comes from, relative to the archive root.
+	Source string
+}
+
+// Analyze returns the findings of the heuristics over the artifacts collected in rootDir, ordered by severity,
+// category and resource. now is the time the certificate expiration is compared to.
+func Analyze(rootDir string, now time.Time) []Finding {
+	root := archive.OutputRootDir(rootDir)
+	var out []Finding
+	out = append(out, analyzerFindings(root)...)
+	out = append(out, podFindings(root)...)
+	out = append(out, nackFindings(root)...)
+	out = append(out, certFindings(root, now)...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Severity != out[j].Severity {
+			return out[i].Severity == Error
+		}
+		if out[i].Category != out[j].Category {
+			return out[i].Category < out[j].Category
+		}
+		if out[i].Resource != out[j].Resource {
+			return out[i].Resource < out[j].Resource
+		}
+		return out[i].Message < out[j].Message
+	})
+	return out
+}
+
+// Markdown returns the findings as a Markdown summary.
+func Markdown(findings []Finding) string {
+	var sb strings.Builder
+	sb.WriteString("# Findings\n\n")
+	if len(findings) == 0 {
+		sb.WriteString("No likely causes were found in the collected artifacts.\n")
+		return sb.String()
+	}
+	counts := map[Severity]int{}
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+	fmt.Fprintf(&sb, "%d errors and %d warnings were found in the collected artifacts. "+
+		"See the source artifact of each finding for its details.\n\n", counts[Error], counts[Warning])
+	sb.WriteString("| Severity | Category | Resource | Finding | Source |\n")
+	sb.WriteString("|---|---|---|---|---|\n")
+	for _, f := range findings {
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s |\n", f.Severity, f.Category, escape(f.Resource), escape(f.Message), escape(f.Source))
+	}
+	return sb.String()
+}
+
+// escape escapes the text for a Markdown table cell.
+func escape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// analyzerFindings returns the errors and warnings reported by istioctl analyze.
+func analyzerFindings(root string) []Finding {
+	path := filepath.Join(archive.AnalyzePath(root, common.StrNamespaceAll), common.StrNamespaceAll)
+	b, ok := readFile(path)
+	if !ok {
+		return nil
+	}
+	var out []Finding
+	scanner := bufio.NewScanner(strings.NewReader(string(b)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		var severity Severity
+		switch {
+		case strings.HasPrefix(line, string(Error)+" ["):
+			severity = Error
+		case strings.HasPrefix(line, string(Warning)+" ["):
+			severity = Warning
+		default:
+			continue
+		}
+		out = append(out, Finding{
+			Severity: severity,
+			Category: CategoryAnalyzer,
+			Message:  strings.TrimSpace(strings.TrimPrefix(line, string(severity))),
+			Source:   relative(root, path),
+		})
+	}
+	return out
+}
+
+// podFindings returns the crash looping and OOM killed containers of the collected pods.
+func podFindings(root string) []Finding {
+	path := filepath.Join(archive.ClusterInfoPath(root), "k8s-resources")
+	b, ok := readFile(path)
+	if !ok {
+		return nil
+	}
+	list := struct {
+		Items []json.RawMessage `json:"items"`
+	}{}
+	if err := yaml.Unmarshal(b, &list); err != nil {
+		log.Warnf("failed to parse %s: %v", path, err)
+		return nil
+	}
+	var out []Finding
+	for _, item := range list.Items {
+		kind := struct {
+			Kind string `json:"kind"`
+		}{}
+		if err := json.Unmarshal(item, &kind); err != nil || kind.Kind != "Pod" {
+			continue
+		}
+		pod := &corev1.Pod{}
+		if err := json.Unmarshal(item, pod); err != nil {
+			log.Warnf("failed to parse a pod of %s: %v", path, err)
+			continue
+		}
+		resource := pod.Namespace + "/" + pod.Name
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			if waiting := cs.State.Waiting; (waiting != nil && waiting.Reason == "CrashLoopBackOff") || cs.RestartCount >= crashLoopRestarts {
+				out = append(out, Finding{
+					Severity: Error,
+					Category: CategoryCrashLoop,
+					Resource: resource,
+					Message:  fmt.Sprintf("container %s restarted %d times%s", cs.Name, cs.RestartCount, lastTermination(cs)),
+					Source:   relative(root, path),
+				})
+			}
+			for _, terminated := range []*corev1.ContainerStateTerminated{cs.State.Terminated, cs.LastTerminationState.Terminated} {
+				if terminated != nil && terminated.Reason == "OOMKilled" {
+					out = append(out, Finding{
+						Severity: Error,
+						Category: CategoryOOMKilled,
+						Resource: resource,
+						Message: fmt.Sprintf("container %s was killed for running out of memory at %v, consider raising its memory limit",
+							cs.Name, terminated.FinishedAt.UTC().Format(time.RFC3339)),
+						Source: relative(root, path),
+					})
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+// lastTermination returns the reason and exit code of the last termination of the container, if any.
+func lastTermination(cs corev1.ContainerStatus) string {
+	terminated := cs.LastTerminationState.Terminated
+	if terminated == nil {
+		return ""
+	}
+	return fmt.Sprintf(", last terminated with %s (exit code %d)", terminated.Reason, terminated.ExitCode)
+}
+
+// nackDiagnostic is a rejected configuration, as listed by the debug/nackz endpoint of istiod.
+type nackDiagnostic struct {
+	ProxyID  string `json:"proxy"`
+	TypeURL  string `json:"type"`
+	Resource string `json:"resource,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Message  string `json:"message"`
+}
+
+// nackFindings returns the configurations rejected by the proxies, as tracked by istiod, or else as logged by the
+// proxies.
+func nackFindings(root string) []Finding {
+	var out []Finding
+	nackz, _ := filepath.Glob(filepath.Join(archive.IstiodPath(root, "*", "*"), "debug", "nackz"))
+	for _, path := range nackz {
+		b, ok := readFile(path)
+		if !ok {
+			continue
+		}
+		var nacks []nackDiagnostic
+		if err := json.Unmarshal(b, &nacks); err != nil {
+			log.Warnf("failed to parse %s: %v", path, err)
+			continue
+		}
+		for _, n := range nacks {
+			msg := fmt.Sprintf("%s rejected", strings.TrimPrefix(n.TypeURL, "type.googleapis.com/"))
+			if n.Resource != "" {
+				msg += " " + n.Resource
+			}
+			if n.Field != "" {
+				msg += " at field " + n.Field
+			}
+			out = append(out, Finding{
+				Severity: Error,
+				Category: CategoryNACK,
+				Resource: n.ProxyID,
+				Message:  msg + ": " + n.Message,
+				Source:   relative(root, path),
+			})
+		}
+	}
+	logs, _ := filepath.Glob(filepath.Join(archive.ProxyOutputPath(root, "*", "*"), common.ProxyContainerName+".log"))
+	for _, path := range logs {
+		b, ok := readFile(path)
+		if !ok {
+			continue
+		}
+		// The rejections logged by Envoy are reported once per type, with the last error.
+		rejected := map[string]string{}
+		scanner := bufio.NewScanner(strings.NewReader(string(b)))
+		scanner.Buffer(nil, 1024*1024)
+		for scanner.Scan() {
+			typeURL, msg, ok := parseRejection(scanner.Text())
+			if ok {
+				rejected[typeURL] = msg
+			}
+		}
+		resource := proxyResource(root, path)
+		for typeURL, msg := range rejected {
+			out = append(out, Finding{
+				Severity: Error,
+				Category: CategoryNACK,
+				Resource: resource,
+				Message:  fmt.Sprintf("%s rejected: %s", typeURL, msg),
+				Source:   relative(root, path),
+			})
+		}
+	}
+	return out
+}
+
+// parseRejection parses a log line of Envoy rejecting a configuration, such as
+// "gRPC config for type.googleapis.com/envoy.config.cluster.v3.Cluster rejected: Error adding/updating cluster(s) ...".
+func parseRejection(line string) (typeURL, msg string, ok bool) {
+	const prefix, suffix = "gRPC config for ", " rejected: "
+	i := strings.Index(line, prefix)
+	if i < 0 {
+		return "", "", false
+	}
+	rest := line[i+len(prefix):]
+	j := strings.Index(rest, suffix)
+	if j < 0 {
+		return "", "", false
+	}
+	return strings.TrimPrefix(rest[:j], "type.googleapis.com/"), strings.TrimSpace(rest[j+len(suffix):]), true
+}
+
+// certFindings returns the expired certificates of the proxies, and their CA certificates close to expiration.
+func certFindings(root string, now time.Time) []Finding {
+	var out []Finding
+	paths, _ := filepath.Glob(filepath.Join(archive.ProxyOutputPath(root, "*", "*"), "certs"))
+	for _, path := range paths {
+		b, ok := readFile(path)
+		if !ok {
+			continue
+		}
+		certs := &admin.Certificates{}
+		if err := protomarshal.UnmarshalAllowUnknown(b, certs); err != nil {
+			log.Warnf("failed to parse %s: %v", path, err)
+			continue
+		}
+		resource := proxyResource(root, path)
+		for _, c := range certs.GetCertificates() {
+			for _, cert := range c.GetCertChain() {
+				if expiration := cert.GetExpirationTime(); expiration != nil && !expiration.AsTime().After(now) {
+					out = append(out, certFinding(Error, resource, "certificate", cert, "expired", root, path))
+				}
+			}
+			for _, cert := range c.GetCaCert() {
+				expiration := cert.GetExpirationTime()
+				switch {
+				case expiration == nil:
+				case !expiration.AsTime().After(now):
+					out = append(out, certFinding(Error, resource, "CA certificate", cert, "expired", root, path))
+				case expiration.AsTime().Sub(now) < caCertExpiryWarning:
+					out = append(out, certFinding(Warning, resource, "CA certificate", cert, "expires", root, path))
+				}
+			}
+		}
+	}
+	return out
+}
+
+func certFinding(severity Severity, resource, kind string, cert *admin.CertificateDetails, verb, root, path string) Finding {
+	return Finding{
+		Severity: severity,
+		Category: CategoryCertificate,
+		Resource: resource,
+		Message: fmt.Sprintf("%s %s (serial %s) %s at %v", kind, cert.GetPath(), cert.GetSerialNumber(), verb,
+			cert.GetExpirationTime().AsTime().UTC().Format(time.RFC3339)),
+		Source: relative(root, path),
+	}
+}
+
+// proxyResource returns the namespace/pod of the proxy of an artifact under the proxies output path.
+func proxyResource(root, path string) string {
+	rel := strings.Split(relative(root, path), string(filepath.Separator))
+	if len(rel) < 3 {
+		return ""
+	}
+	return rel[1] + "/" + rel[2]
+}
+
+func relative(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
+
+// readFile returns the contents of the artifact at path, false if it was not collected.
+func readFile(path string) ([]byte, bool) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("failed to read %s: %v", path, err)
+		}
+		return nil, false
+	}
+	return b, true
+}
@@ -0,0 +1,172 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package findings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+const k8sResources = `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: productpage
+    namespace: default
+  spec:
+    selector:
+      app: productpage
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: productpage-v1
+    namespace: default
+  status:
+    containerStatuses:
+    - name: productpage
+      restartCount: 0
+      state:
+        running: {}
+    - name: istio-proxy
+      restartCount: 3
+      state:
+        waiting:
+          reason: CrashLoopBackOff
+      lastState:
+        terminated:
+          reason: Error
+          exitCode: 255
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: reviews-v1
+    namespace: default
+  status:
+    containerStatuses:
+    - name: istio-proxy
+      restartCount: 1
+      state:
+        running: {}
+      lastState:
+        terminated:
+          reason: OOMKilled
+          exitCode: 137
+          finishedAt: "2023-01-01T00:00:00Z"
+`
+
+const nackz = `[{"proxy":"reviews-v1.default","type":"type.googleapis.com/envoy.config.cluster.v3.Cluster",` +
+	`"resource":"outbound|9080||ratings.default.svc.cluster.local","field":"circuit_breakers","message":"invalid"}]`
+
+const proxyLog = `2023-01-01T00:00:00.000000Z	info	Envoy proxy is ready
+2023-01-01T00:00:01.000000Z	warning	envoy config	gRPC config for type.googleapis.com/envoy.config.listener.v3.Listener rejected: ` +
+	`Error adding/updating listener(s) virtualInbound: duplicate filter chain match
+`
+
+const certs = `{"certificates":[{
+"ca_cert":[{"path":"ROOTCA","serial_number":"1","expiration_time":"2023-01-10T00:00:00Z"}],
+"cert_chain":[{"path":"default","serial_number":"2","expiration_time":"2022-12-31T00:00:00Z"}]
+}]}`
+
+const analyze = `Error [IST0101] (VirtualService default/reviews) Referenced host not found: "reviews2"
+Info [IST0102] (Namespace foo) The namespace is not enabled for Istio injection.
+Warning [IST0108] (Pod default/ratings) Unknown annotation: sidecar.istio.io/foo
+`
+
+func writeArtifact(t *testing.T, root, path, text string) {
+	t.Helper()
+	path = filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	root := t.TempDir()
+	writeArtifact(t, root, "cluster/k8s-resources", k8sResources)
+	writeArtifact(t, root, "istio/istio-system/istiod-1/debug/nackz", nackz)
+	writeArtifact(t, root, "proxies/default/productpage-v1/istio-proxy.log", proxyLog)
+	writeArtifact(t, root, "proxies/default/productpage-v1/certs", certs)
+	writeArtifact(t, root, "analyze/allNamespaces/allNamespaces", analyze)
+
+	got := Analyze(root, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	want := []Finding{
+		{
+			Severity: Error, Category: CategoryAnalyzer,
+			Message: `[IST0101] (VirtualService default/reviews) Referenced host not found: "reviews2"`,
+			Source:  "analyze/allNamespaces/allNamespaces",
+		},
+		{
+			Severity: Error, Category: CategoryCertificate, Resource: "default/productpage-v1",
+			Message: "certificate default (serial 2) expired at 2022-12-31T00:00:00Z", Source: "proxies/default/productpage-v1/certs",
+		},
+		{
+			Severity: Error, Category: CategoryCrashLoop, Resource: "default/productpage-v1",
+			Message: "container istio-proxy restarted 3 times, last terminated with Error (exit code 255)", Source: "cluster/k8s-resources",
+		},
+		{
+			Severity: Error, Category: CategoryNACK, Resource: "default/productpage-v1",
+			Message: "envoy.config.listener.v3.Listener rejected: Error adding/updating listener(s) virtualInbound: duplicate filter chain match",
+			Source:  "proxies/default/productpage-v1/istio-proxy.log",
+		},
+		{
+			Severity: Error, Category: CategoryNACK, Resource: "reviews-v1.default",
+			Message: "envoy.config.cluster.v3.Cluster rejected outbound|9080||ratings.default.svc.cluster.local at field circuit_breakers: invalid",
+			Source:  "istio/istio-system/istiod-1/debug/nackz",
+		},
+		{
+			Severity: Error, Category: CategoryOOMKilled, Resource: "default/reviews-v1",
+			Message: "container istio-proxy was killed for running out of memory at 2023-01-01T00:00:00Z, consider raising its memory limit",
+			Source:  "cluster/k8s-resources",
+		},
+		{
+			Severity: Warning, Category: CategoryAnalyzer,
+			Message: "[IST0108] (Pod default/ratings) Unknown annotation: sidecar.istio.io/foo", Source: "analyze/allNamespaces/allNamespaces",
+		},
+		{
+			Severity: Warning, Category: CategoryCertificate, Resource: "default/productpage-v1",
+			Message: "CA certificate ROOTCA (serial 1) expires at 2023-01-10T00:00:00Z", Source: "proxies/default/productpage-v1/certs",
+		},
+	}
+	assert.Equal(t, got, want)
+
+	md := Markdown(got)
+	if !strings.Contains(md, "6 errors and 2 warnings") {
+		t.Errorf("expected the summary to count the findings, got:\n%s", md)
+	}
+	if !strings.Contains(md, `outbound\|9080\|\|ratings.default.svc.cluster.local`) {
+		t.Errorf("expected the table cells to be escaped, got:\n%s", md)
+	}
+}
+
+func TestAnalyzeNothingCollected(t *testing.T) {
+	root := t.TempDir()
+	// Dry runs collect the commands that would have run rather than the artifacts.
+	writeArtifact(t, root, "cluster/k8s-resources", "Dry run: would be running kubectl get --all-namespaces all -o yaml")
+	got := Analyze(root, time.Now())
+	assert.Equal(t, len(got), 0)
+	if md := Markdown(got); !strings.Contains(md, "No likely causes") {
+		t.Errorf("unexpected summary:\n%s", md)
+	}
+}